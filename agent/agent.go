@@ -2,167 +2,351 @@ package agent
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"meal-agent/config"
+	"meal-agent/debugdump"
+	"meal-agent/i18n"
 	"meal-agent/memory"
 	"meal-agent/preference"
 	"meal-agent/tools"
+	"meal-agent/tracing"
 )
 
+const (
+	maxPromptRestaurants = 15 // prompt 中最多展示的餐厅数量
+	detailConcurrency    = 3  // 并发查询餐厅详情的数量
+	maxRatingLookups     = 30 // 每次最多补全评分的餐厅数量
+
+	weatherTimeout = 5 * time.Second // 获取天气的最长等待时间，超时按未知天气推荐
+)
+
+// 对话中识别的菜系/食物关键词（用于排除和定向搜索）
+var cuisineKeywords = []string{
+	"火锅", "川菜", "湘菜", "烧烤", "日料", "韩餐", "西餐",
+	"面", "米饭", "快餐", "麻辣", "清淡", "油腻",
+	"粤菜", "东北菜", "本帮菜", "鲁菜", "徽菜",
+	"披萨", "汉堡", "炸鸡", "烤肉", "寿司", "拉面",
+	"饺子", "包子", "小吃", "甜品", "奶茶",
+}
+
+// 定向搜索的触发词（"附近有没有日料"、"想吃火锅"）
+var searchTriggers = []string{"有没有", "想吃", "找一家", "找家", "附近的"}
+
+// 英文对话中的常用说法（language: en），按小写匹配
+var (
+	englishRecommend = []string{"recommend", "suggest", "what to eat", "what should i eat", "hungry"}
+	englishRefresh   = []string{"another batch", "something else", "more options", "shuffle"}
+	englishConfirm   = []string{"first one", "second one", "third one", "go with", "i'll take", "i'll have", "sounds good"}
+)
+
+// containsAny s 是否包含任意一个词
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
 // MealAgent 饮食建议 Agent
 type MealAgent struct {
 	cfg        *config.Config
 	llm        LLM
-	weather    *tools.WeatherClient
-	restaurant *tools.RestaurantClient
+	weather    tools.WeatherProvider
+	restaurant tools.RestaurantProvider
+	cache      *tools.SearchCache     // 搜索结果缓存（未启用时为 nil）
+	quota      *tools.QuotaTracker    // 高德调用量统计（未使用高德时为 nil）
+	ratings    tools.RatingProvider   // 第三方评分（未配置时为 nil）
+	canteen    *tools.CanteenProvider // 公司食堂菜单（未配置时为 nil）
 	history    *memory.History
-	pref       *preference.Preferences // 餐厅偏好配置
+	pref       *preference.Preferences            // 餐厅偏好配置
+	prefPath   string                             // 偏好配置文件路径（对话中修改偏好后保存，为空时不保存）
+	profiles   map[string]*preference.Preferences // 其他人的偏好（一起吃饭时合并）
+	learner    *preference.Learner                // 根据评分和选择学到的权重调整（未开启时为 nil）
+	safety     *SafetyFilter                      // 内容安全过滤（未启用时为 nil）
+	privacy    *PrivacyFilter                     // 隐私模式（未启用时为 nil）
+	foodRules  *tools.FoodRuleSet                 // 天气→饮食规则
+	health     *tools.HealthGuide                 // 健康状况的饮食指导（没有配置时为 nil）
+	providers  Providers                          // 创建时注入的数据来源（重新加载配置时保留）
+	dumper     *debugdump.Dumper                  // --debug-dir 的调试输出（未开启时为 nil）
+
+	location         string    // 当前位置的名称（为空表示 location 配置的默认位置）
+	locationSwitched time.Time // 对话中切换位置的时间（当天不再按 schedule 自动切换）
 
 	// 对话上下文
 	messages        []Message
-	tempExclude     []string           // 本次对话临时排除的类型
-	lastRestaurants []tools.Restaurant // 上次推荐的餐厅列表（用于确认选择）
+	tempExclude     []string                // 本次对话临时排除的类型
+	skipped         []string                // 本次对话中"换一批"换掉的餐厅
+	budgetOverride  int                     // 本次对话临时设置的人均预算（0 表示使用配置）
+	deliveryMode    bool                    // 外卖模式
+	cookingMode     bool                    // 做饭模式（推荐菜谱而不是餐厅）
+	group           []string                // 一起吃饭的人（为空表示自己吃）
+	groupPref       *preference.Preferences // 一起吃饭时合并后的偏好
+	favoriteNote    string                  // 本次推荐中必须出现的常吃餐厅说明（为空表示没有）
+	request         string                  // 推荐时附加的要求（如 recommend 命令的"不要辣"），原样写进 prompt
+	noResult        bool                    // 上次推荐附近没有找到合适的餐厅
+	lastRestaurants []tools.Restaurant      // 上次推荐的餐厅列表（用于确认选择）
+	lastWeather     *tools.WeatherInfo      // 上次推荐时的天气（获取失败时为 nil）
+	lastRecipes     []Recipe                // 做饭模式上次推荐的菜谱（用于确认选择）
+}
+
+// Providers 外部数据来源，为 nil 的字段按配置创建默认实现
+// 可以注入 tools.FakeRestaurantProvider / tools.FakeWeatherProvider 等替身，避免请求真实接口
+type Providers struct {
+	Restaurant tools.RestaurantProvider
+	Weather    tools.WeatherProvider
 }
 
 // NewMealAgent 创建 Agent
 func NewMealAgent(cfg *config.Config, history *memory.History, pref *preference.Preferences) *MealAgent {
+	return NewMealAgentWithProviders(cfg, history, pref, Providers{})
+}
+
+// NewMealAgentWithProviders 使用指定的数据来源创建 Agent
+func NewMealAgentWithProviders(cfg *config.Config, history *memory.History, pref *preference.Preferences, providers Providers) *MealAgent {
+	// 只缓存按配置创建的网络数据来源：static 每次读取手动维护的列表，修改后立即生效；
+	// 注入的数据来源（测试替身）不读写磁盘缓存，避免被旧结果覆盖
+	var cache *tools.SearchCache
+	if provider := cfg.API.RestaurantProvider; providers.Restaurant == nil && provider != "static" {
+		if provider == "" {
+			provider = "amap"
+		}
+		var err error
+		if cache, err = tools.NewSearchCache(cfg.Search.CacheDir, provider, cfg.Search.CacheDuration()); err != nil {
+			cache = nil // 缓存目录不可用时直接请求接口
+		}
+	}
+
+	// 调用量统计只针对默认创建的高德客户端
+	restaurant := providers.Restaurant
+	var quota *tools.QuotaTracker
+	if restaurant == nil {
+		if cfg.API.RestaurantProvider == "" || cfg.API.RestaurantProvider == "amap" {
+			quota = tools.NewQuotaTracker(filepath.Join(cfg.Search.CacheDir, "amap_quota.json"), cfg.API.AmapDailyQuota, cfg.API.AmapQPS)
+		}
+		restaurant = newRestaurantProvider(cfg, quota)
+	}
+
+	weather := providers.Weather
+	if weather == nil {
+		weather = newWeatherProvider(cfg, cfg.Location)
+	}
+
+	foodRules, err := tools.NewFoodRuleSet(cfg.FoodRules)
+	if err != nil {
+		foodRules, _ = tools.NewFoodRuleSet(nil) // 自定义规则有误时使用内置规则
+	}
+
+	health, _ := tools.NewHealthGuide(cfg.Health.Conditions, cfg.Health.Profiles) // 有误时加载配置已报错
+
+	var ratings tools.RatingProvider
+	if cfg.API.RatingURL != "" {
+		ratings = tools.NewHTTPRatingProvider(cfg.API.RatingURL, cfg.API.RatingKey)
+	}
+
+	var canteen *tools.CanteenProvider
+	if cfg.Canteen.Menu != "" {
+		canteen = tools.NewCanteenProvider(cfg.Canteen.Menu, cfg.Canteen.Name)
+	}
+
 	return &MealAgent{
 		cfg:             cfg,
 		llm:             NewLLM(cfg.LLM),
-		weather:         tools.NewWeatherClient(cfg.API.WeatherKey),
-		restaurant:      tools.NewRestaurantClient(cfg.API.AmapKey),
+		weather:         weather,
+		restaurant:      restaurant,
+		cache:           cache,
+		quota:           quota,
+		ratings:         ratings,
+		canteen:         canteen,
 		history:         history,
 		pref:            pref,
+		safety:          NewSafetyFilter(cfg.LLM.Safety),
+		privacy:         NewPrivacyFilter(cfg),
+		foodRules:       foodRules,
+		health:          health,
+		providers:       providers,
 		messages:        []Message{},
 		tempExclude:     []string{},
 		lastRestaurants: []tools.Restaurant{},
 	}
 }
 
-// GetRecommendation 获取用餐推荐
-func (a *MealAgent) GetRecommendation(mealType string) (string, error) {
-	// 1. 获取天气信息
-	weatherInfo, err := a.weather.GetWeather(a.cfg.Location.City)
-	if err != nil {
-		weatherInfo = &tools.WeatherInfo{Text: "未知", Temp: "20"}
-	}
+// weatherAt 获取用餐时的天气
+// 用餐时间在半小时以后且数据源支持预报时使用预报，否则使用实时天气；数据源支持时附带气象预警
+func (a *MealAgent) weatherAt(mealTime time.Time) *tools.WeatherInfo {
+	city := a.currentLocation().City
 
-	// 2. 搜索附近餐厅
-	restaurants, err := a.restaurant.SearchNearby(
-		a.cfg.Location.Lat,
-		a.cfg.Location.Lng,
-		a.cfg.Location.Radius,
-		"",
-	)
-	if err != nil {
-		return "", fmt.Errorf("搜索餐厅失败: %v", err)
+	var info *tools.WeatherInfo
+	if fp, ok := a.weather.(tools.ForecastProvider); ok && time.Until(mealTime) > 30*time.Minute {
+		hours := int(time.Until(mealTime).Hours()) + 2
+		if forecasts, err := fp.GetForecast(city, hours); err == nil {
+			info = tools.ForecastAt(forecasts, mealTime)
+		}
 	}
-
-	// 3. 过滤黑名单（按餐厅名称）
-	allBlacklist := append([]string{}, a.cfg.Blacklist...)
-	allBlacklist = append(allBlacklist, a.cfg.TempExclude...)
-	restaurants = tools.FilterByBlacklist(restaurants, allBlacklist)
-
-	// 4. 过滤排除的类型（按餐厅类型关键词）
-	if len(a.tempExclude) > 0 {
-		restaurants = tools.FilterByType(restaurants, a.tempExclude)
+	if info == nil {
+		info, _ = a.weather.GetWeather(city)
 	}
 
-	// 5. 为所有餐厅分类（快餐/正餐）
-	tools.ClassifyAllRestaurants(restaurants)
+	if wp, ok := a.weather.(tools.WarningProvider); ok && info != nil {
+		if warnings, err := wp.GetWarnings(city); err == nil {
+			info.Warnings = warnings
+		}
+	}
+	return info
+}
 
-	// 6. 获取本周炒菜类次数
-	thisWeekFullMealCount := a.history.GetThisWeekMealCategoryCount(string(tools.CategoryFullMeal))
+// newWeatherProvider 根据配置选择天气数据来源，查询 loc 的天气
+func newWeatherProvider(cfg *config.Config, loc config.Location) tools.WeatherProvider {
+	switch cfg.API.WeatherProvider {
+	case "openweathermap":
+		return tools.NewOpenWeatherClient(cfg.API.WeatherKey, loc.Lat, loc.Lng)
+	case "open-meteo":
+		return tools.NewOpenMeteoClient(loc.Lat, loc.Lng)
+	default:
+		client := tools.NewWeatherClient(cfg.API.WeatherKey)
+		client.SetCoordinates(loc.Lat, loc.Lng)
+		client.SetCityCache(filepath.Join(cfg.Search.CacheDir, "qweather_city.json"))
+		return client
+	}
+}
 
-	// 7. 计算权重并排序（综合距离、评分、历史等因素）
-	penalties := a.history.GetAllPenalties()
-	for i := range restaurants {
-		// 基础权重 100
-		weight := 100
-
-		// 加上用户偏好权重
-		if a.pref != nil {
-			prefWeight := a.pref.GetRestaurantWeight(restaurants[i].Name)
-			if prefWeight == 0 {
-				// 权重为0表示黑名单，跳过
-				weight = 0
-			} else {
-				weight = prefWeight
-			}
-			// 加上菜系偏好
-			catWeight := a.pref.GetCategoryWeight(restaurants[i].Type)
-			if catWeight != 100 {
-				weight = weight * catWeight / 100
-			}
-		}
+// newRestaurantProvider 根据配置选择餐厅数据来源
+func newRestaurantProvider(cfg *config.Config, quota *tools.QuotaTracker) tools.RestaurantProvider {
+	switch cfg.API.RestaurantProvider {
+	case "osm":
+		return tools.NewOverpassClient(cfg.API.OverpassURL, cfg.Search.MaxResults)
+	case "static":
+		return tools.NewStaticProvider(cfg.API.StaticList)
+	default:
+		client := tools.NewRestaurantClient(cfg.API.AmapKey, cfg.Search.MaxResults)
+		client.SetQuota(quota)
+		return client
+	}
+}
 
-		// 减去历史惩罚（最近吃过的降权）
-		if penalty, ok := penalties[restaurants[i].Name]; ok {
-			weight += penalty
-		}
+// GetRecommendation 获取用餐推荐
+func (a *MealAgent) GetRecommendation(mealType string) (string, error) {
+	return a.recommend(mealType, "", time.Now())
+}
 
-		// === 距离因素（平衡权重，不再让近距离主导） ===
-		// 距离奖励/惩罚：500m以内轻微加分，500-1000m正常，1000m以上轻微减分
-		dist := restaurants[i].GetDistanceInt()
-		switch {
-		case dist <= 300:
-			weight += 10 // 很近，轻微加分
-		case dist <= 500:
-			weight += 5 // 近，小幅加分
-		case dist <= 1000:
-			// 中等距离，不调整
-		case dist <= 1500:
-			weight -= 10 // 稍远，轻微减分
-		default:
-			weight -= 20 // 较远，减分
-		}
+// GetRecommendationAt 为稍后的用餐时间提前推荐（按那时的天气预报和营业状态）
+func (a *MealAgent) GetRecommendationAt(mealType string, mealTime time.Time) (string, error) {
+	return a.recommend(mealType, "", mealTime)
+}
 
-		// === 评分因素 ===
-		rating := restaurants[i].GetRatingFloat()
-		if rating > 0 {
-			// 评分 4.5+ 加分，4.0以下减分
-			if rating >= 4.5 {
-				weight += 15
-			} else if rating >= 4.0 {
-				weight += 5
-			} else if rating < 3.5 {
-				weight -= 10
-			}
-		}
+// SearchRecommendation 按关键词定向搜索附近餐厅并推荐（如"日料"）
+func (a *MealAgent) SearchRecommendation(mealType, keyword string) (string, error) {
+	return a.recommend(mealType, keyword, time.Now())
+}
 
-		// === 炒菜类频率限制 ===
-		// 如果本周炒菜类已吃>=2次，大幅降低炒菜类权重
-		if restaurants[i].Category == tools.CategoryFullMeal && thisWeekFullMealCount >= 2 {
-			weight -= 40 // 大幅降权
-		}
+// recommend 推荐流程，keyword 为空时搜索所有餐饮，mealTime 为预计用餐时间
+func (a *MealAgent) recommend(mealType, keyword string, mealTime time.Time) (response string, err error) {
+	// 各步骤的耗时记录为链路追踪的 span（开启导出时）
+	trace := tracing.Start(nil, "recommend", tracing.KindInternal)
+	trace.Set("meal", mealType)
+	trace.Set("keyword", keyword)
+	trace.Set("location", a.LocationName())
+	defer func() {
+		trace.Fail(err)
+		trace.End()
+	}()
+
+	a.noResult = false
+
+	// 做饭模式推荐菜谱，不搜索餐厅
+	if a.cookingMode {
+		trace.Set("cooking", true)
+		return a.recommendRecipes(mealType, keyword, mealTime)
+	}
 
-		restaurants[i].Weight = weight
+	// 1. 并行获取天气和搜索附近餐厅，天气超时不阻塞推荐
+	weatherCh := make(chan *tools.WeatherInfo, 1)
+	weatherTimer := time.NewTimer(weatherTimeout)
+	defer weatherTimer.Stop()
+	weatherSpan := tracing.Start(trace, "weather", tracing.KindClient)
+	weatherSpan.Set("provider", a.cfg.API.WeatherProvider)
+	go func() {
+		info := a.weatherAt(mealTime) // 失败时为 nil
+		weatherSpan.Set("found", info != nil)
+		weatherSpan.End()
+		weatherCh <- info
+	}()
+
+	searchSpan := tracing.Start(trace, "search_nearby", tracing.KindClient)
+	searchSpan.Set("provider", a.cfg.API.RestaurantProvider)
+	searchSpan.Set("radius", a.searchRadius())
+	nearby, searchErr := a.searchNearby(a.searchRadius(), keyword)
+	searchSpan.Set("results", len(nearby))
+	searchSpan.Fail(searchErr)
+	searchSpan.End()
+
+	var weatherInfo *tools.WeatherInfo
+	select {
+	case weatherInfo = <-weatherCh:
+	case <-weatherTimer.C:
+		trace.Set("weather_timeout", true)
+	}
+	a.lastWeather = weatherInfo
+	if weatherInfo == nil {
+		weatherInfo = &tools.WeatherInfo{Text: "未知", Temp: "20"}
 	}
 
-	// 过滤掉权重<=0的餐厅
-	restaurants = tools.FilterByWeight(restaurants)
+	if searchErr != nil {
+		return "", fmt.Errorf(i18n.T("搜索餐厅失败: %v"), searchErr)
+	}
 
-	// 按权重排序
-	tools.SortByWeight(restaurants)
+	// 2. 过滤并排序候选餐厅（候选太少时自动扩大搜索范围）
+	rankSpan := tracing.Start(trace, "rank", tracing.KindInternal)
+	restaurants, radius := a.findCandidates(mealType, keyword, mealTime, nearby, weatherInfo)
+	rankSpan.Set("candidates", len(restaurants))
+	rankSpan.Set("radius", radius)
+	rankSpan.End()
 
 	if len(restaurants) == 0 {
-		return "附近没有找到合适的餐厅，考虑扩大搜索范围或减少排除条件", nil
+		a.noResult = true
+		if keyword != "" {
+			return i18n.T("%d米内没有找到%s相关的餐厅，换个口味试试？", radius, keyword), nil
+		}
+		return i18n.T("%d米内没有找到合适的餐厅，考虑减少排除条件", radius), nil
+	}
+
+	// 补全候选餐厅的营业时间等详情
+	detailSpan := tracing.Start(trace, "enrich_details", tracing.KindClient)
+	a.enrichDetails(restaurants)
+	detailSpan.End()
+
+	// 营业状态：标注即将打烊，按配置过滤已打烊的餐厅
+	tools.MarkOpenStatus(restaurants, mealTime)
+	if a.cfg.Filters.OpenNow {
+		restaurants = tools.FilterClosed(restaurants)
+		if len(restaurants) == 0 {
+			a.noResult = true
+			return i18n.T("附近的餐厅现在都已打烊，考虑点外卖或稍后再试"), nil
+		}
 	}
 
 	// 保存推荐的餐厅列表（用于后续确认）
 	a.lastRestaurants = restaurants
+	a.dumpCandidates(mealType, keyword, weatherInfo, restaurants, radius)
 
-	// 6. 构建 prompt，让 LLM 推荐
-	prompt := a.buildPrompt(mealType, weatherInfo, restaurants)
+	// 3. 构建 prompt，让 LLM 推荐（隐私模式下去掉地址、电话和坐标）
+	prompt := a.privacy.Redact(a.buildPrompt(mealType, keyword, weatherInfo, restaurants), restaurants)
 
 	// 添加系统消息
 	if len(a.messages) == 0 {
+		prompt := systemPrompt
+		if inst := i18n.Instruction(a.cfg.Language); inst != "" {
+			prompt += "\n\n" + inst
+		}
 		a.messages = append(a.messages, Message{
 			Role:    "system",
-			Content: systemPrompt,
+			Content: prompt,
 		})
 	}
 
@@ -171,36 +355,135 @@ func (a *MealAgent) GetRecommendation(mealType string) (string, error) {
 		Content: prompt,
 	})
 
-	// 7. 调用 LLM
-	response, err := a.llm.Chat(a.messages)
+	a.dumpPrompt()
+
+	// 4. 调用 LLM
+	llmSpan := tracing.Start(trace, "llm", tracing.KindClient)
+	llmSpan.Set("model", a.cfg.LLM.Model)
+	llmSpan.Set("messages", len(a.messages))
+	llmSpan.Set("prompt_chars", len([]rune(prompt)))
+	response, err = a.complete(a.messages)
+	llmSpan.Fail(err)
+	llmSpan.End()
 	if err != nil {
-		return "", fmt.Errorf("LLM 调用失败: %v", err)
+		return "", fmt.Errorf(i18n.T("LLM 调用失败: %v"), err)
 	}
+	response = a.safety.SanitizeOutput(response, a.knownPhones())
 
 	a.messages = append(a.messages, Message{
 		Role:    "assistant",
 		Content: response,
 	})
 
+	// 推荐了有健康提醒的餐厅时附在末尾（模型漏掉时也能看到）
+	if note := a.healthWarningNote(response); note != "" {
+		response += "\n\n" + note
+	}
+
+	// 有气象预警时主动提醒（定时推送也会带上）
+	for _, warn := range weatherInfo.Warnings {
+		response = i18n.T("⚠️ %s，出门注意安全", warn.Title) + "\n" + response
+	}
+
+	if a.quota.NearLimit() {
+		used, limit := a.quota.Usage()
+		response = i18n.T("（⚠️ 高德接口今日已调用 %d/%d 次，暂时只使用缓存的餐厅数据）", used, limit) + "\n" + response
+	}
+
+	if radius > a.searchRadius() {
+		response = i18n.T("（附近合适的餐厅较少，已将搜索范围扩大到%d米）", radius) + "\n" + response
+	}
+
 	return response, nil
 }
 
 // Chat 对话模式
 func (a *MealAgent) Chat(userInput string) (string, error) {
+	// 内容安全检查（拒绝与用餐无关的输入）
+	userInput, ok := a.safety.CheckInput(userInput)
+	if !ok {
+		return userInput, nil
+	}
+
+	// 撤销、删除、修改用餐记录
+	if reply, handled := a.parseRecordEdit(userInput); handled {
+		return reply, nil
+	}
+
+	// 修改偏好（"以后多推荐日料"、"把海底捞拉黑"），要在排除和推荐判断之前处理
+	if reply, handled := a.parsePreferenceEdit(userInput); handled {
+		return reply, nil
+	}
+
+	// 查询以前的用餐记录（"上次吃那家泰国菜是什么时候"）
+	if reply, handled := a.parseHistoryQuery(userInput); handled {
+		return reply, nil
+	}
+
+	// 换一批：不再推荐上次推荐给用户的餐厅
+	lower := strings.ToLower(userInput)
+	if strings.Contains(userInput, "换一批") || strings.Contains(userInput, "换一组") || containsAny(lower, englishRefresh) {
+		for _, r := range promptCandidates(a.lastRestaurants) {
+			a.skipped = append(a.skipped, r.Name)
+		}
+		return a.GetRecommendation(currentMealType())
+	}
+
 	// 检查是否要排除某些选项
 	if strings.Contains(userInput, "不想吃") || strings.Contains(userInput, "不要") ||
 		strings.Contains(userInput, "不吃") || strings.Contains(userInput, "换一个") {
 		a.parseExclusion(userInput)
 	}
 
-	// 检查是否确认选择
+	// 检查是否切换位置（"我现在在家"，切换后直接给出新推荐）
+	if reply, changed := a.parseLocationSwitch(userInput); changed {
+		recommendation, err := a.GetRecommendation(currentMealType())
+		if err != nil {
+			return "", err
+		}
+		return reply + "\n\n" + recommendation, nil
+	} else if reply != "" {
+		return reply, nil
+	}
+
+	// 检查是否切换做饭模式（"今天在家做饭"，切换后直接推荐菜谱）
+	if a.parseCookingMode(userInput) {
+		return a.GetRecommendation(currentMealType())
+	}
+
+	// 检查是否切换外卖模式（切换后直接给出新推荐）
+	if reply, changed := a.parseDeliveryMode(userInput); reply != "" {
+		return reply, nil
+	} else if changed {
+		return a.GetRecommendation(currentMealType())
+	}
+
+	// 检查是否和别人一起吃（合并各人的偏好后直接给出新推荐）
+	if reply, changed := a.parseGroupMode(userInput); reply != "" {
+		return reply, nil
+	} else if changed {
+		return a.GetRecommendation(currentMealType())
+	}
+
+	// 检查是否调整预算（"今天想吃好点，预算150"）
+	budgetChanged := a.parseBudget(userInput)
+
+	// 检查是否确认选择（做饭模式中确认要做的菜）
+	if a.cookingMode && (a.isConfirmation(userInput) || strings.Contains(userInput, "就做")) {
+		return a.confirmRecipe(userInput)
+	}
 	if a.isConfirmation(userInput) {
 		return a.confirmChoice(userInput)
 	}
 
-	// 检查是否请求推荐
-	if strings.Contains(userInput, "推荐") || strings.Contains(userInput, "吃什么") ||
-		strings.Contains(userInput, "有什么") {
+	// 检查是否定向搜索某类餐厅（"附近有没有日料"）
+	if keyword := a.parseSearchKeyword(userInput); keyword != "" {
+		return a.SearchRecommendation(currentMealType(), keyword)
+	}
+
+	// 检查是否请求推荐（调整预算后也重新推荐）
+	if budgetChanged || strings.Contains(userInput, "推荐") || strings.Contains(userInput, "吃什么") ||
+		strings.Contains(userInput, "有什么") || containsAny(lower, englishRecommend) {
 		hour := time.Now().Hour()
 		mealType := "lunch"
 		if hour >= 15 {
@@ -212,14 +495,15 @@ func (a *MealAgent) Chat(userInput string) (string, error) {
 	// 添加用户消息
 	a.messages = append(a.messages, Message{
 		Role:    "user",
-		Content: userInput,
+		Content: a.privacy.Redact(userInput, a.lastRestaurants),
 	})
 
 	// 调用 LLM
-	response, err := a.llm.Chat(a.messages)
+	response, err := a.complete(a.messages)
 	if err != nil {
 		return "", err
 	}
+	response = a.safety.SanitizeOutput(response, a.knownPhones())
 
 	a.messages = append(a.messages, Message{
 		Role:    "assistant",
@@ -232,30 +516,100 @@ func (a *MealAgent) Chat(userInput string) (string, error) {
 // isConfirmation 检查是否是确认选择
 func (a *MealAgent) isConfirmation(input string) bool {
 	confirmKeywords := []string{"就这个", "就吃", "好的", "确定", "就它", "选这个", "第一个", "第二个", "第三个"}
-	for _, kw := range confirmKeywords {
-		if strings.Contains(input, kw) {
+	return containsAny(input, confirmKeywords) || containsAny(strings.ToLower(input), englishConfirm)
+}
+
+// parseExclusion 解析排除项
+func (a *MealAgent) parseExclusion(input string) {
+	for _, kw := range cuisineKeywords {
+		if strings.Contains(input, kw) && !a.containsExclude(kw) {
+			a.tempExclude = append(a.tempExclude, kw)
+		}
+	}
+}
+
+// parseBudget 解析对话中的预算调整，如 "预算150"、"人均100以内"
+func (a *MealAgent) parseBudget(input string) bool {
+	m := budgetPattern.FindStringSubmatch(input)
+	if m == nil {
+		return false
+	}
+	for _, g := range m[1:] {
+		if g == "" {
+			continue
+		}
+		var budget int
+		fmt.Sscanf(g, "%d", &budget)
+		if budget > 0 {
+			a.budgetOverride = budget
 			return true
 		}
 	}
 	return false
 }
 
-// parseExclusion 解析排除项
-func (a *MealAgent) parseExclusion(input string) {
-	// 扩展关键词列表
-	keywords := []string{
-		"火锅", "川菜", "湘菜", "烧烤", "日料", "韩餐", "西餐",
-		"面", "米饭", "快餐", "麻辣", "清淡", "油腻",
-		"粤菜", "东北菜", "本帮菜", "鲁菜", "徽菜",
-		"披萨", "汉堡", "炸鸡", "烤肉", "寿司", "拉面",
-		"饺子", "包子", "小吃", "甜品", "奶茶",
+// parseDeliveryMode 解析外卖/堂食切换
+// changed 表示模式发生了变化；reply 非空时直接回复用户（如外卖模式未开启）
+func (a *MealAgent) parseDeliveryMode(input string) (reply string, changed bool) {
+	switch {
+	case strings.Contains(input, "外卖") && !strings.Contains(input, "不点外卖") && !strings.Contains(input, "不要外卖"):
+		if !a.cfg.Delivery.Enabled {
+			return i18n.T("外卖模式未开启，可以在配置文件的 delivery.enabled 中打开"), false
+		}
+		changed = !a.deliveryMode || a.cookingMode
+		a.deliveryMode = true
+		a.cookingMode = false
+	case strings.Contains(input, "堂食") || strings.Contains(input, "出去吃"):
+		changed = a.deliveryMode
+		a.deliveryMode = false
 	}
+	return "", changed
+}
 
-	for _, kw := range keywords {
-		if strings.Contains(input, kw) && !a.containsExclude(kw) {
-			a.tempExclude = append(a.tempExclude, kw)
+// SetLearner 开启权重学习，评分和选择会调整之后的推荐排序
+func (a *MealAgent) SetLearner(l *preference.Learner) {
+	a.learner = l
+}
+
+// Learner 权重学习器（未开启时为 nil）
+func (a *MealAgent) Learner() *preference.Learner {
+	return a.learner
+}
+
+// SetDeliveryMode 设置外卖模式
+func (a *MealAgent) SetDeliveryMode(on bool) {
+	a.deliveryMode = on && a.cfg.Delivery.Enabled
+}
+
+// SetRequest 设置之后推荐时附加的要求（为空表示没有），不经过对话解析，不会切换做饭、位置等模式
+func (a *MealAgent) SetRequest(request string) {
+	a.request = strings.TrimSpace(request)
+}
+
+// parseSearchKeyword 解析定向搜索意图，返回要搜索的菜系关键词
+func (a *MealAgent) parseSearchKeyword(input string) string {
+	// "不想吃"也包含"想吃"，排除类表达不算搜索
+	if strings.Contains(input, "不想吃") || strings.Contains(input, "不要") || strings.Contains(input, "不吃") {
+		return ""
+	}
+
+	triggered := false
+	for _, t := range searchTriggers {
+		if strings.Contains(input, t) {
+			triggered = true
+			break
 		}
 	}
+	if !triggered {
+		return ""
+	}
+
+	for _, kw := range cuisineKeywords {
+		if strings.Contains(input, kw) {
+			return kw
+		}
+	}
+	return ""
 }
 
 // containsExclude 检查是否已在排除列表
@@ -275,9 +629,22 @@ func (a *MealAgent) confirmChoice(input string) (string, error) {
 
 	if selectedRestaurant == nil {
 		// 如果无法确定，让用户明确
-		return "请告诉我你选择哪个餐厅，可以说餐厅名称或者「第一个」「第二个」等", nil
+		return i18n.T("请告诉我你选择哪个餐厅，可以说餐厅名称或者「第一个」「第二个」等"), nil
 	}
+	return a.recordChoice(selectedRestaurant)
+}
 
+// ConfirmRestaurant 确认选择上次推荐的第 index 家餐厅（从 0 开始，按 LastRestaurants 的顺序）并记录
+func (a *MealAgent) ConfirmRestaurant(index int) (string, error) {
+	if index < 0 || index >= len(a.lastRestaurants) {
+		return "", fmt.Errorf("%s", i18n.T("推荐已经更新，请重新选择"))
+	}
+	selected := a.lastRestaurants[index]
+	return a.recordChoice(&selected)
+}
+
+// recordChoice 记录选择的餐厅
+func (a *MealAgent) recordChoice(selectedRestaurant *tools.Restaurant) (string, error) {
 	// 记录到历史
 	mealType := "lunch"
 	hour := time.Now().Hour()
@@ -285,20 +652,27 @@ func (a *MealAgent) confirmChoice(input string) (string, error) {
 		mealType = "dinner"
 	}
 
-	err := a.history.Add(memory.MealRecord{
+	record := memory.MealRecord{
 		Date:         time.Now().Format("2006-01-02"),
 		MealType:     mealType,
 		Restaurant:   selectedRestaurant.Name,
-		Category:     extractCategory(selectedRestaurant.Type),
+		RestaurantID: selectedRestaurant.ID,
+		Category:     selectedRestaurant.Cuisine,
 		MealCategory: string(selectedRestaurant.Category), // 保存餐厅大类（快餐/正餐）
-	})
-	if err != nil {
-		return "", fmt.Errorf("记录失败: %v", err)
+		Amount:       selectedRestaurant.GetCostFloat(),   // 先按人均预填，可以用"记录"命令修正
+	}
+	record.Nutrition = a.estimateNutrition(record)
+	if err := a.history.Add(record); err != nil {
+		return "", fmt.Errorf(i18n.T("记录失败: %v"), err)
 	}
+	a.learnChoice(*selectedRestaurant)
 
 	mealName := map[string]string{"lunch": "午餐", "dinner": "晚餐"}[mealType]
-	return fmt.Sprintf("好的，已记录本次%s选择：%s。下次会避免重复推荐。祝用餐愉快！🍽️",
-		mealName, selectedRestaurant.Name), nil
+	reply := i18n.T("好的，已记录本次%s选择：%s。下次会避免重复推荐。祝用餐愉快！🍽️", i18n.T(mealName), selectedRestaurant.Name)
+	if note := a.calorieRecordNote(mealType); note != "" {
+		reply += "\n" + note
+	}
+	return reply, nil
 }
 
 // extractSelection 从用户输入中提取选择的餐厅
@@ -312,13 +686,14 @@ func (a *MealAgent) extractSelection(input string) *tools.Restaurant {
 		pattern string
 		index   int
 	}{
-		{"第一", 0}, {"1号", 0}, {"第1", 0},
-		{"第二", 1}, {"2号", 1}, {"第2", 1},
-		{"第三", 2}, {"3号", 2}, {"第3", 2},
+		{"第一", 0}, {"1号", 0}, {"第1", 0}, {"first", 0}, {"#1", 0},
+		{"第二", 1}, {"2号", 1}, {"第2", 1}, {"second", 1}, {"#2", 1},
+		{"第三", 2}, {"3号", 2}, {"第3", 2}, {"third", 2}, {"#3", 2},
 	}
 
+	lower := strings.ToLower(input)
 	for _, p := range orderPatterns {
-		if strings.Contains(input, p.pattern) && p.index < len(a.lastRestaurants) {
+		if strings.Contains(lower, p.pattern) && p.index < len(a.lastRestaurants) {
 			return &a.lastRestaurants[p.index]
 		}
 	}
@@ -332,75 +707,247 @@ func (a *MealAgent) extractSelection(input string) *tools.Restaurant {
 
 	// 如果只说"就这个"、"好的"之类，且只有一个推荐，默认选第一个
 	if len(a.lastRestaurants) > 0 && (strings.Contains(input, "就这个") ||
-		strings.Contains(input, "就它") || strings.Contains(input, "好的")) {
+		strings.Contains(input, "就它") || strings.Contains(input, "好的") || strings.Contains(lower, "sounds good")) {
 		return &a.lastRestaurants[0]
 	}
 
 	return nil
 }
 
-// extractCategory 从高德类型字符串中提取主要分类
-func extractCategory(typeStr string) string {
-	// 高德返回的类型格式类似 "餐饮服务;中餐厅;川菜"
-	parts := strings.Split(typeStr, ";")
-	if len(parts) >= 3 {
-		return parts[2]
-	}
-	if len(parts) >= 2 {
-		return parts[1]
+// currentMealType 根据当前时间判断餐次
+func currentMealType() string {
+	if time.Now().Hour() >= 15 {
+		return "dinner"
 	}
-	return typeStr
+	return "lunch"
 }
 
 // RecordMeal 记录用餐
-func (a *MealAgent) RecordMeal(restaurant, category string) error {
-	mealType := "lunch"
-	hour := time.Now().Hour()
-	if hour >= 15 {
-		mealType = "dinner"
+// 只需要填写餐厅名称，以及可选的菜系、花费（0 表示未知）、备注和照片，日期和餐次按当前时间填写
+// 开启营养估算时同时估算这一餐的营养
+func (a *MealAgent) RecordMeal(record memory.MealRecord) error {
+	record.Date = time.Now().Format("2006-01-02")
+	record.MealType = "lunch"
+	if time.Now().Hour() >= 15 {
+		record.MealType = "dinner"
 	}
 
-	return a.history.Add(memory.MealRecord{
-		Date:       time.Now().Format("2006-01-02"),
-		MealType:   mealType,
-		Restaurant: restaurant,
-		Category:   category,
-	})
+	// 如果是最近推荐过的餐厅，顺便记录 POI ID
+	for _, r := range a.lastRestaurants {
+		if r.Name == record.Restaurant {
+			record.RestaurantID = r.ID
+			break
+		}
+	}
+
+	record.Nutrition = a.estimateNutrition(record)
+	if err := a.history.Add(record); err != nil {
+		return err
+	}
+	if record.Rating > 0 {
+		a.learnRating(record)
+	}
+	return nil
 }
 
 // GetHistorySummary 获取历史记录摘要
 func (a *MealAgent) GetHistorySummary() string {
-	return a.history.Summary()
+	return a.history.Summary() + a.monthlyBudgetNote() + a.CalorieNote()
+}
+
+// monthlyBudgetNote 本月预算的使用情况（未设置月预算时为空）
+func (a *MealAgent) monthlyBudgetNote() string {
+	limit := a.cfg.Budget.MonthlyMax
+	if limit <= 0 {
+		return ""
+	}
+	spent := a.history.MonthSpend(time.Now())
+	if spent > float64(limit) {
+		return i18n.T("本月预算%d元，已超支%.0f元", limit, spent-float64(limit)) + "\n"
+	}
+	return i18n.T("本月预算%d元，还剩%.0f元", limit, float64(limit)-spent) + "\n"
+}
+
+// GetStats 统计用餐情况（附带截至今天的饮食习惯），period 见 memory.ParsePeriod
+func (a *MealAgent) GetStats(period string) (memory.Stats, error) {
+	p, err := memory.ParsePeriod(period)
+	if err != nil {
+		return memory.Stats{}, err
+	}
+	stats := a.history.Stats(p)
+	streaks := a.history.Streaks(a.cfg.Budget.MaxFor)
+	stats.Streaks = &streaks
+	return stats, nil
 }
 
 // Reset 重置对话上下文
 func (a *MealAgent) Reset() {
 	a.messages = []Message{}
 	a.tempExclude = []string{}
+	a.skipped = nil
+	a.budgetOverride = 0
+	a.deliveryMode = false
+	a.cookingMode = false
+	a.EndGroup()
 	a.lastRestaurants = []tools.Restaurant{}
+	a.lastRecipes = nil
+	a.lastWeather = nil
+}
+
+// promptCandidates 进入 prompt 的候选餐厅：findCandidates 返回的列表已经排好序，直接取前 maxPromptRestaurants 家
+// （不再复制、重新排序，prompt 中的编号与 lastRestaurants 的顺序一致）
+func promptCandidates(restaurants []tools.Restaurant) []tools.Restaurant {
+	if len(restaurants) > maxPromptRestaurants {
+		return restaurants[:maxPromptRestaurants]
+	}
+	return restaurants
+}
+
+// enrichDetails 为进入 prompt 的候选餐厅查询详情（营业时间、图片）
+// 数据来源不支持详情查询或调用量接近配额时直接跳过
+func (a *MealAgent) enrichDetails(restaurants []tools.Restaurant) {
+	dp, ok := a.restaurant.(tools.DetailProvider)
+	if !ok || a.quota.NearLimit() {
+		return
+	}
+
+	restaurants = promptCandidates(restaurants)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, detailConcurrency)
+	for i := range restaurants {
+		if restaurants[i].ID == "" || restaurants[i].OpenTime != "" || restaurants[i].IsCanteen() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *tools.Restaurant) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			detail, err := dp.GetDetails(r.ID)
+			if err != nil {
+				return // 详情只是补充信息，失败不影响推荐
+			}
+			r.ApplyDetail(detail)
+		}(&restaurants[i])
+	}
+	wg.Wait()
+}
+
+// enrichRatings 用第三方评分补全缺失评分的餐厅（最多 maxRatingLookups 家）
+func (a *MealAgent) enrichRatings(restaurants []tools.Restaurant) {
+	if a.ratings == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, detailConcurrency)
+	lookups := 0
+	for i := range restaurants {
+		if restaurants[i].GetRatingFloat() > 0 || restaurants[i].IsCanteen() {
+			continue
+		}
+		if lookups >= maxRatingLookups {
+			break
+		}
+		lookups++
+
+		wg.Add(1)
+		go func(r *tools.Restaurant) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			info, err := a.ratings.GetRating(r)
+			if err != nil {
+				return // 评分只是补充信息，失败不影响推荐
+			}
+			r.ApplyRating(info)
+		}(&restaurants[i])
+	}
+	wg.Wait()
 }
 
 // buildPrompt 构建推荐 prompt
-func (a *MealAgent) buildPrompt(mealType string, weather *tools.WeatherInfo, restaurants []tools.Restaurant) string {
+func (a *MealAgent) buildPrompt(mealType, keyword string, weather *tools.WeatherInfo, restaurants []tools.Restaurant) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("现在是%s时间，请推荐用餐选择。\n\n",
-		map[string]string{"lunch": "午餐", "dinner": "晚餐"}[mealType]))
+	sb.WriteString(fmt.Sprintf("现在是%s时间（%s），请推荐用餐选择。\n\n",
+		map[string]string{"lunch": "午餐", "dinner": "晚餐"}[mealType], time.Now().Format("15:04")))
+
+	if keyword != "" {
+		sb.WriteString(fmt.Sprintf("用户想吃%s，以下餐厅是按「%s」搜索的结果。\n\n", keyword, keyword))
+	}
+	if a.request != "" {
+		sb.WriteString(fmt.Sprintf("用户的要求：%s，请按要求从以下餐厅中选择。\n\n", a.request))
+	}
+	if len(a.group) > 0 {
+		sb.WriteString(fmt.Sprintf("用户和%s一起吃，以下餐厅已综合所有人的偏好排序，请选择大家都能接受的。\n\n", strings.Join(a.group, "、")))
+	}
 
 	sb.WriteString("【天气信息】\n")
 	sb.WriteString(weather.Describe() + "\n")
-	sb.WriteString(weather.SuggestFoodType() + "\n\n")
+	sb.WriteString(a.foodRules.Evaluate(weather).Suggestion + "\n\n")
 
-	sb.WriteString("【附近餐厅】\n")
-	for i, r := range restaurants {
-		if i >= 15 { // 最多展示15个
-			break
+	if foods := a.seasonalFoods(); len(foods) > 0 {
+		sb.WriteString("【节气节日】\n")
+		for _, food := range foods {
+			sb.WriteString(fmt.Sprintf("今天是%s，%s\n", food.Name, food.Suggestion))
 		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("【附近餐厅】\n")
+	_, hasCalorieGoal := a.mealCalorieLimit(mealType, time.Now())
+	for i, r := range promptCandidates(restaurants) {
 		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Describe()))
+		if hasCalorieGoal {
+			sb.WriteString(fmt.Sprintf("   一餐估算约%.0f千卡\n", tools.EstimateNutrition(r.Cuisine, r.Name).Calories))
+		}
+		for _, warning := range r.HealthWarnings {
+			sb.WriteString("   健康提醒：" + warning + "\n")
+		}
+		if note := a.history.LastNote(r.ID, r.Name); note != "" {
+			sb.WriteString(fmt.Sprintf("   你上次备注：%s\n", note))
+		}
+	}
+
+	if taste := a.tastePrompt(time.Now()); taste != "" {
+		sb.WriteString("\n【口味】\n" + taste)
+	}
+
+	if a.favoriteNote != "" {
+		sb.WriteString("\n【常吃清单】\n" + a.favoriteNote + "\n")
 	}
 
 	sb.WriteString("\n【历史记录】\n")
 	sb.WriteString(a.history.Summary())
+	if note := a.monthlyBudgetNote(); note != "" {
+		sb.WriteString(note)
+		if a.history.MonthSpend(time.Now()) > float64(a.cfg.Budget.MonthlyMax) {
+			sb.WriteString("本月已超预算，请优先推荐实惠的餐厅\n")
+		}
+	}
+
+	if a.deliveryMode {
+		sb.WriteString("\n【外卖模式】\n用户选择点外卖，请按送达时间和配送费推荐，不需要考虑步行距离\n")
+	} else if a.cfg.Delivery.Enabled && a.cfg.Delivery.SuggestInBadWeather && weather.IsBadWeather() {
+		sb.WriteString("\n【外卖建议】\n今天天气不适合出门，请在回复末尾提醒用户可以回复「点外卖」切换到外卖推荐\n")
+	}
+
+	if budget := a.budgetFor(mealType); budget > 0 {
+		sb.WriteString(fmt.Sprintf("\n【预算】\n人均不超过%d元\n", budget))
+	}
+
+	if note := a.nutritionGoalNote(mealType, time.Now()); note != "" {
+		sb.WriteString("\n【营养目标】\n" + note)
+	}
+
+	if guidance := a.health.Guidance(); guidance != "" {
+		sb.WriteString("\n【健康状况】\n" + guidance + "推荐带有健康提醒的餐厅时，请在推荐理由中说明怎么点、要注意什么\n")
+	}
 
 	if len(a.tempExclude) > 0 {
 		sb.WriteString("\n【本次排除】\n")
@@ -412,6 +959,32 @@ func (a *MealAgent) buildPrompt(mealType string, weather *tools.WeatherInfo, res
 	return sb.String()
 }
 
+// knownPhones 返回上次推荐餐厅的真实电话（用于识别模型编造的号码）
+func (a *MealAgent) knownPhones() []string {
+	phones := make([]string, 0, len(a.lastRestaurants))
+	for _, r := range a.lastRestaurants {
+		if r.Tel != "" {
+			phones = append(phones, r.Tel)
+		}
+	}
+	return phones
+}
+
+// LastRestaurants 上次推荐的候选餐厅（按排序先后）
+func (a *MealAgent) LastRestaurants() []tools.Restaurant {
+	return append([]tools.Restaurant(nil), a.lastRestaurants...)
+}
+
+// LastWeather 上次推荐时用餐时间的天气（没有推荐过或获取失败时为 nil）
+func (a *MealAgent) LastWeather() *tools.WeatherInfo {
+	return a.lastWeather
+}
+
+// NoResult 上次推荐是否因为附近没有合适（或营业中）的餐厅而没有给出推荐
+func (a *MealAgent) NoResult() bool {
+	return a.noResult
+}
+
 // GetExcludeList 获取当前排除列表（用于调试）
 func (a *MealAgent) GetExcludeList() []string {
 	return a.tempExclude
@@ -426,6 +999,11 @@ const systemPrompt = `你是一个贴心的饮食建议助手。你的任务是
 4. 如果用户说不想吃某种类型，要记住并排除
 5. 回复要简洁实用，不要太啰嗦
 6. 给出 2-3 个选择，让用户决定
+7. 如果餐厅标注了营业时间，绝对不要推荐当前时间不在营业的餐厅
+8. 如果推荐了标注「即将打烊」的餐厅，要提醒用户抓紧时间
+9. 如果有气象预警，优先推荐最近的餐厅或外卖，并提醒用户注意安全
+10. 推荐带有「你上次备注」的餐厅时，原样转述备注，如「你上次备注：辣度刚好」
+11. 推荐公司食堂时，结合天气说明今天食堂的哪几道菜值得吃
 
 回复格式示例：
 根据今天的天气和你的位置，我推荐：
@@ -435,5 +1013,8 @@ const systemPrompt = `你是一个贴心的饮食建议助手。你的任务是
 
 想吃哪个？或者告诉我你不想吃什么，我再推荐。`
 
+// 对话中的预算表达："预算150"、"人均100"、"150元以内"
+var budgetPattern = regexp.MustCompile(`(?:预算|人均)\s*(\d+)|(\d+)\s*(?:元|块)(?:以内|以下|左右)`)
+
 // 用于从 LLM 回复中提取推荐的餐厅（备用）
 var restaurantPattern = regexp.MustCompile(`\d+\.\s*([^\n（(]+)`)
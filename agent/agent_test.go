@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"meal-agent/config"
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+// fakeLLM 记录收到的最后一条消息，固定返回 reply
+type fakeLLM struct {
+	reply   string
+	prompts []string
+}
+
+func (f *fakeLLM) Chat(messages []Message) (string, error) {
+	f.prompts = append(f.prompts, messages[len(messages)-1].Content)
+	return f.reply, nil
+}
+
+// testConfig 测试用的最小配置，缓存目录在临时目录中，extra 追加到配置文件末尾
+func testConfig(t *testing.T, extra string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `location:
+  lat: "39.908"
+  lng: "116.397"
+  city: "北京"
+  radius: 1000
+search:
+  cache_dir: "` + filepath.ToSlash(filepath.Join(dir, "cache")) + `"
+` + extra
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	return cfg
+}
+
+// newTestAgent 使用替身数据来源和 LLM 创建 Agent，不请求任何真实接口
+func newTestAgent(t *testing.T, cfg *config.Config, restaurants []tools.Restaurant) (*MealAgent, *tools.FakeRestaurantProvider, *fakeLLM) {
+	t.Helper()
+	history, err := memory.NewHistory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &tools.FakeRestaurantProvider{Restaurants: restaurants}
+	weather := &tools.FakeWeatherProvider{Info: &tools.WeatherInfo{Text: "晴", Temp: "22"}}
+	a := NewMealAgentWithProviders(cfg, history, nil, Providers{Restaurant: provider, Weather: weather})
+	llm := &fakeLLM{reply: "推荐：第一家"}
+	a.llm = llm
+	return a, provider, llm
+}
+
+// restaurantNames 餐厅名称列表，方便比较
+func restaurantNames(restaurants []tools.Restaurant) []string {
+	result := make([]string, len(restaurants))
+	for i, r := range restaurants {
+		result[i] = r.Name
+	}
+	return result
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRecommendFiltersAndRanksCandidates(t *testing.T) {
+	cfg := testConfig(t, `blacklist:
+  - "某某餐厅"
+  - "*便利店*"
+  - "海底捞(国贸店)"
+`)
+	a, provider, llm := newTestAgent(t, cfg, []tools.Restaurant{
+		{ID: "1", Name: "某某餐厅", Type: "中餐厅", Distance: "100", Rating: "4.8"},
+		{ID: "2", Name: "全家便利店", Type: "便利店", Distance: "50", Rating: "4.0"},
+		{ID: "3", Name: "海底捞(国贸店)", Type: "火锅", Distance: "300", Rating: "4.9"},
+		{ID: "4", Name: "海底捞(望京店)", Type: "火锅", Distance: "900", Rating: "4.7"},
+		{ID: "5", Name: "近处小馆", Type: "中餐厅", Distance: "200", Rating: "4.5"},
+		{ID: "6", Name: "中距小馆", Type: "中餐厅", Distance: "800", Rating: "4.5"},
+		{ID: "7", Name: "远处小馆", Type: "中餐厅", Distance: "2500", Rating: "5.0"},
+	})
+
+	if _, err := a.GetRecommendation("lunch"); err != nil {
+		t.Fatalf("GetRecommendation() error = %v", err)
+	}
+	if provider.Calls == 0 {
+		t.Fatal("没有调用注入的餐厅数据来源")
+	}
+
+	got := restaurantNames(a.LastRestaurants())
+	for _, excluded := range []string{"某某餐厅", "全家便利店", "海底捞(国贸店)", "远处小馆"} {
+		if indexOf(got, excluded) >= 0 {
+			t.Errorf("候选中不应包含 %s: %v", excluded, got)
+		}
+	}
+	for _, kept := range []string{"海底捞(望京店)", "近处小馆", "中距小馆"} {
+		if indexOf(got, kept) < 0 {
+			t.Errorf("候选中应包含 %s: %v", kept, got)
+		}
+	}
+	if indexOf(got, "近处小馆") > indexOf(got, "中距小馆") {
+		t.Errorf("同类、同评分时近的应排在前面: %v", got)
+	}
+
+	last := a.LastRestaurants()
+	for i := 1; i < len(last); i++ {
+		if last[i].Weight > last[i-1].Weight {
+			t.Errorf("候选没有按权重排序: %s(%d) 在 %s(%d) 后面", last[i].Name, last[i].Weight, last[i-1].Name, last[i-1].Weight)
+		}
+	}
+
+	// prompt 中的编号与候选的顺序一致（确认选择"第二个"时按这个顺序）
+	if len(llm.prompts) != 1 {
+		t.Fatalf("LLM 调用次数 = %d, want 1", len(llm.prompts))
+	}
+	for i, r := range last {
+		if line := fmt.Sprintf("%d. %s", i+1, r.Name); !strings.Contains(llm.prompts[0], line) {
+			t.Errorf("prompt 中没有 %q", line)
+		}
+	}
+}
+
+func TestRecommendPenalizesRecentMeals(t *testing.T) {
+	cfg := testConfig(t, "")
+	a, _, _ := newTestAgent(t, cfg, []tools.Restaurant{
+		{ID: "1", Name: "老王面馆", Type: "中餐厅", Distance: "200", Rating: "4.5"},
+		{ID: "2", Name: "老李面馆", Type: "中餐厅", Distance: "200", Rating: "4.5"},
+	})
+
+	if _, err := a.GetRecommendation("lunch"); err != nil {
+		t.Fatal(err)
+	}
+	if got := restaurantNames(a.LastRestaurants()); indexOf(got, "老王面馆") != 0 {
+		t.Fatalf("条件相同时保持原有顺序: %v", got)
+	}
+
+	yesterday := memory.MealRecord{Date: time.Now().AddDate(0, 0, -1).Format("2006-01-02"), MealType: "lunch", Restaurant: "老王面馆"}
+	if err := a.history.Add(yesterday); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetRecommendation("lunch"); err != nil {
+		t.Fatal(err)
+	}
+	if got := restaurantNames(a.LastRestaurants()); indexOf(got, "老王面馆") < indexOf(got, "老李面馆") {
+		t.Errorf("昨天吃过的餐厅应该降权: %v", got)
+	}
+}
+
+func TestChatExcludesCuisine(t *testing.T) {
+	cfg := testConfig(t, "")
+	a, _, _ := newTestAgent(t, cfg, []tools.Restaurant{
+		{ID: "1", Name: "蜀香小馆", Type: "餐饮服务;中餐厅;四川菜(川菜)", Distance: "200", Rating: "4.8"},
+		{ID: "2", Name: "潮汕粥铺", Type: "餐饮服务;中餐厅;潮州菜", Distance: "300", Rating: "4.2"},
+		{ID: "3", Name: "东北饺子馆", Type: "餐饮服务;中餐厅;东北菜", Distance: "400", Rating: "4.3"},
+	})
+
+	if _, err := a.Chat("不想吃川菜，推荐一下"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	got := restaurantNames(a.LastRestaurants())
+	if indexOf(got, "蜀香小馆") >= 0 {
+		t.Errorf("排除川菜后仍推荐了川菜: %v", got)
+	}
+	if len(got) != 2 {
+		t.Errorf("候选 = %v, want 潮汕粥铺和东北饺子馆", got)
+	}
+}
+
+func TestInjectedProviderSkipsSearchCache(t *testing.T) {
+	cfg := testConfig(t, "")
+	a, provider, _ := newTestAgent(t, cfg, []tools.Restaurant{
+		{ID: "1", Name: "第一家", Type: "中餐厅", Distance: "200", Rating: "4.5"},
+	})
+	if _, err := a.GetRecommendation("lunch"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 替身数据变化后立即生效，不读到上次的结果
+	provider.Restaurants = []tools.Restaurant{{ID: "2", Name: "第二家", Type: "中餐厅", Distance: "200", Rating: "4.5"}}
+	if _, err := a.GetRecommendation("lunch"); err != nil {
+		t.Fatal(err)
+	}
+	if got := restaurantNames(a.LastRestaurants()); len(got) != 1 || got[0] != "第二家" {
+		t.Errorf("候选 = %v, want [第二家]", got)
+	}
+
+	entries, _ := os.ReadDir(cfg.Search.CacheDir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "search_") {
+			t.Errorf("注入数据来源时不应写入搜索缓存: %s", e.Name())
+		}
+	}
+}
+
+func TestRecommendNoResult(t *testing.T) {
+	cfg := testConfig(t, "")
+	a, _, llm := newTestAgent(t, cfg, []tools.Restaurant{
+		{ID: "1", Name: "远处小馆", Type: "中餐厅", Distance: "5000", Rating: "4.5"},
+	})
+
+	reply, err := a.GetRecommendation("lunch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.NoResult() {
+		t.Errorf("NoResult() = false, reply = %q", reply)
+	}
+	if len(llm.prompts) != 0 {
+		t.Errorf("没有候选时不应调用 LLM")
+	}
+}
+
+func TestRecommendSearchError(t *testing.T) {
+	cfg := testConfig(t, "")
+	a, provider, _ := newTestAgent(t, cfg, nil)
+	provider.Err = errors.New("接口不可用")
+
+	if _, err := a.GetRecommendation("lunch"); err == nil || !strings.Contains(err.Error(), "接口不可用") {
+		t.Errorf("GetRecommendation() error = %v, want 搜索失败的错误", err)
+	}
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+const (
+	defaultSearchLimit  = 10
+	maxSearchLimit      = 30
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// 内置工具：天气、附近餐厅和用餐记录
+func init() {
+	RegisterTool(&FuncTool{
+		ToolName:        "search_restaurants",
+		ToolDescription: "搜索附近的餐厅，按个人偏好、最近的用餐记录和天气排好序（与推荐使用相同的候选，不调用 LLM）",
+		Parameters: objectSchema(map[string]interface{}{
+			"keyword":   stringProp("关键词，如 火锅、拉面（留空搜索所有餐饮）"),
+			"meal_type": mealTypeProp(),
+			"limit":     intProp(fmt.Sprintf("返回的数量（默认 %d，最多 %d）", defaultSearchLimit, maxSearchLimit)),
+		}),
+		Run: searchRestaurantsTool,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "get_weather",
+		ToolDescription: "获取所在位置的天气，指定时间时返回那时的预报",
+		Parameters: objectSchema(map[string]interface{}{
+			"time": stringProp("今天的时间，如 12:00（留空为现在）"),
+		}),
+		Run: weatherTool,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "get_history",
+		ToolDescription: "查询用餐记录（包含归档），最近的在前",
+		Parameters: objectSchema(map[string]interface{}{
+			"keyword":   stringProp("关键词，匹配餐厅名、菜系或备注"),
+			"range":     stringProp("日期范围：week、month、all、2024-06 或 2024-01..2024-06（默认全部）"),
+			"meal_type": mealTypeProp(),
+			"limit":     intProp(fmt.Sprintf("返回的条数（默认 %d，最多 %d）", defaultHistoryLimit, maxHistoryLimit)),
+		}),
+		Run: historyTool,
+	})
+}
+
+func searchRestaurantsTool(a *MealAgent, raw json.RawMessage) (string, error) {
+	var args struct {
+		Keyword  string `json:"keyword"`
+		MealType string `json:"meal_type"`
+		Limit    int    `json:"limit"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", errors.New("无效的参数")
+	}
+	mealType := args.MealType
+	if mealType != "lunch" && mealType != "dinner" {
+		mealType = currentMealType()
+	}
+	restaurants, err := a.SearchRestaurants(mealType, strings.TrimSpace(args.Keyword), time.Now())
+	if err != nil {
+		return "", err
+	}
+	restaurants = tools.TopK(restaurants, clamp(args.Limit, defaultSearchLimit, maxSearchLimit))
+
+	type item struct {
+		Rank     int      `json:"rank"`
+		Name     string   `json:"name"`
+		Cuisine  string   `json:"cuisine,omitempty"`
+		Distance int      `json:"distance_m,omitempty"`
+		Walk     int      `json:"walk_minutes,omitempty"`
+		Rating   float64  `json:"rating,omitempty"`
+		Cost     float64  `json:"cost_per_person,omitempty"`
+		Open     string   `json:"open_status,omitempty"`
+		Hours    string   `json:"open_time,omitempty"`
+		Address  string   `json:"address,omitempty"`
+		Tel      string   `json:"tel,omitempty"`
+		Menu     []string `json:"menu,omitempty"`
+	}
+	items := make([]item, 0, len(restaurants))
+	for i, r := range restaurants {
+		items = append(items, item{
+			Rank:     i + 1,
+			Name:     r.Name,
+			Cuisine:  r.Cuisine,
+			Distance: r.GetDistanceInt(),
+			Walk:     r.WalkMinutes,
+			Rating:   r.GetRatingFloat(),
+			Cost:     r.GetCostFloat(),
+			Open:     r.OpenStatus.Code(),
+			Hours:    r.OpenTime,
+			Address:  r.Address,
+			Tel:      r.Tel,
+			Menu:     r.Menu,
+		})
+	}
+	return toJSON(map[string]interface{}{"restaurants": items})
+}
+
+func weatherTool(a *MealAgent, raw json.RawMessage) (string, error) {
+	var args struct {
+		Time string `json:"time"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", errors.New("无效的参数")
+	}
+	at := time.Now()
+	if args.Time != "" {
+		hour, minute, err := ParseScheduleTime(args.Time)
+		if err != nil || hour > 23 || minute > 59 {
+			return "", fmt.Errorf("无效的时间: %s（格式为 12:00）", args.Time)
+		}
+		at = time.Date(at.Year(), at.Month(), at.Day(), hour, minute, 0, 0, at.Location())
+	}
+	info, err := a.Weather(at)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(map[string]interface{}{"time": at.Format("2006-01-02 15:04"), "weather": info, "summary": info.Describe()})
+}
+
+func historyTool(a *MealAgent, raw json.RawMessage) (string, error) {
+	var args struct {
+		Keyword  string `json:"keyword"`
+		Range    string `json:"range"`
+		MealType string `json:"meal_type"`
+		Limit    int    `json:"limit"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", errors.New("无效的参数")
+	}
+	f := memory.Filter{Keyword: strings.TrimSpace(args.Keyword), MealType: args.MealType}
+	if args.Range != "" {
+		period, err := memory.ParsePeriod(args.Range)
+		if err != nil {
+			return "", err
+		}
+		f.Period = period
+	}
+	records, err := a.FindMeals(f)
+	if err != nil {
+		return "", err
+	}
+	total := len(records)
+	if limit := clamp(args.Limit, defaultHistoryLimit, maxHistoryLimit); len(records) > limit {
+		records = records[:limit]
+	}
+	return toJSON(map[string]interface{}{"total": total, "records": records})
+}
+
+// clamp 未填写（0 或负数）时使用默认值，超过上限时取上限
+func clamp(n, def, max int) int {
+	if n <= 0 {
+		return def
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"meal-agent/logging"
+	"meal-agent/tools"
+)
+
+// canteenCandidate 当前位置这一餐的食堂候选；没有配置食堂、不在可以去食堂的位置、食堂不开门或不符合搜索关键词时为 nil
+func (a *MealAgent) canteenCandidate(mealType, keyword string, mealTime time.Time) *tools.Restaurant {
+	if a.canteen == nil || a.deliveryMode || !a.atCanteenLocation() {
+		return nil
+	}
+	c, err := a.canteen.Candidate(mealType, mealTime)
+	if err != nil {
+		// 菜单读取失败只是少一个候选，不影响推荐
+		logging.Module("agent").Warn("读取食堂菜单失败", "err", err)
+		return nil
+	}
+	if c == nil {
+		return nil
+	}
+	if keyword != "" && !strings.Contains(c.Name+c.Type+strings.Join(c.Menu, " "), keyword) {
+		return nil
+	}
+	return c
+}
+
+// atCanteenLocation 当前位置是否可以去食堂（canteen.locations 为空时所有位置都可以）
+func (a *MealAgent) atCanteenLocation() bool {
+	if len(a.cfg.Canteen.Locations) == 0 {
+		return true
+	}
+	current := a.LocationName()
+	for _, name := range a.cfg.Canteen.Locations {
+		if _, key, ok := a.cfg.FindLocation(name); ok && key == current {
+			return true
+		}
+	}
+	return false
+}
+
+// withCanteen 把食堂加入搜索结果（不修改 restaurants，它可能来自缓存）
+func withCanteen(restaurants []tools.Restaurant, canteen *tools.Restaurant) []tools.Restaurant {
+	if canteen == nil {
+		return restaurants
+	}
+	merged := make([]tools.Restaurant, 0, len(restaurants)+1)
+	merged = append(merged, restaurants...)
+	return append(merged, *canteen)
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// schedulerState 保存到文件的提醒记录，重启后据此判断有没有错过提醒
+type schedulerState struct {
+	Fired map[string]time.Time `json:"fired"` // 餐次 -> 最近一次提醒的时间
+}
+
+// SetStatePath 设置提醒记录文件，加载上次运行时的记录
+func (s *Scheduler) SetStatePath(path string) error {
+	s.statePath = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var state schedulerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	for mealType, t := range state.Fired {
+		s.fired[mealType] = t
+	}
+	return nil
+}
+
+// markFired 记录提醒时间，设置了记录文件时保存（保存失败只影响重启后的补发）
+func (s *Scheduler) markFired(mealType string, t time.Time) {
+	s.fired[mealType] = t
+	if s.statePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(schedulerState{Fired: s.fired}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(s.statePath, data, 0644); err != nil {
+		s.reportError(err)
+	}
+}
+
+// dueMeal 到了提醒时间、这次还没提醒过的餐次，scheduled 为当天的提醒时间
+// 超过提醒时间 catch_up 分钟以内的都算（睡眠或停机错过时补发），都错过时只补发较晚的一餐
+func (s *Scheduler) dueMeal(now time.Time) (mealType string, scheduled time.Time, ok bool) {
+	lunch, dinner, _ := s.schedule.TimesOn(now)
+	window := time.Minute + s.schedule.CatchUpDuration()
+	for _, m := range []struct{ mealType, at string }{{"dinner", dinner}, {"lunch", lunch}} {
+		scheduled, ok := scheduledAt(now, m.at)
+		if !ok || now.Before(scheduled) || now.Sub(scheduled) >= window {
+			continue
+		}
+		if !s.fired[m.mealType].Before(scheduled) {
+			continue // 已经提醒过
+		}
+		return m.mealType, scheduled, true
+	}
+	return "", time.Time{}, false
+}
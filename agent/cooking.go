@@ -0,0 +1,330 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/i18n"
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+// cookingDays 推荐菜谱时参考最近多少天做过的菜
+const cookingDays = 14
+
+// 英文对话中切换做饭模式的说法（按小写匹配）
+var (
+	englishCook   = []string{"cook at home", "cooking mode", "i'll cook"}
+	englishEatOut = []string{"eat out", "go out"}
+)
+
+// 家里默认都有的基础调料，不列入购物清单
+var basicSeasonings = []string{"盐", "糖", "食用油", "油", "酱油", "生抽", "老抽", "醋", "料酒", "水", "淀粉"}
+
+// Recipe 做饭模式推荐的一道菜
+type Recipe struct {
+	Name        string       `json:"name"`
+	Reason      string       `json:"reason,omitempty"`  // 推荐理由
+	Minutes     int          `json:"minutes,omitempty"` // 准备加烹饪的时间
+	Ingredients []Ingredient `json:"ingredients"`
+	Steps       []string     `json:"steps,omitempty"`
+	Shopping    []Ingredient `json:"shopping,omitempty"` // 家里没有、需要买的食材
+}
+
+// Ingredient 食材和用量
+type Ingredient struct {
+	Name   string `json:"name"`
+	Amount string `json:"amount,omitempty"` // 如 "3个"、"200克"
+}
+
+func (i Ingredient) String() string {
+	if i.Amount == "" {
+		return i.Name
+	}
+	return i.Name + " " + i.Amount
+}
+
+// SetCookingMode 设置做饭模式（推荐在家做的菜谱，而不是附近的餐厅）
+func (a *MealAgent) SetCookingMode(on bool) {
+	a.cookingMode = on
+	if on {
+		a.deliveryMode = false
+	}
+}
+
+// CookingMode 是否为做饭模式
+func (a *MealAgent) CookingMode() bool {
+	return a.cookingMode
+}
+
+// LastRecipes 上次推荐的菜谱（按推荐先后）
+func (a *MealAgent) LastRecipes() []Recipe {
+	return append([]Recipe(nil), a.lastRecipes...)
+}
+
+// parseCookingMode 对话中切换做饭模式（"今天在家做饭"，"出去吃"切回）
+func (a *MealAgent) parseCookingMode(input string) (changed bool) {
+	lower := strings.ToLower(input)
+	switch {
+	case (containsAny(input, []string{"做饭模式", "在家做", "自己做饭", "自己做", "下厨"}) || containsAny(lower, englishCook)) &&
+		!containsAny(input, []string{"不做饭", "不想做", "懒得做"}):
+		changed = !a.cookingMode
+		a.SetCookingMode(true)
+	case a.cookingMode && (containsAny(input, []string{"出去吃", "堂食", "不做饭", "不想做", "懒得做", "下馆子"}) || containsAny(lower, englishEatOut)):
+		changed = true
+		a.cookingMode = false
+	}
+	return changed
+}
+
+// recommendRecipes 做饭模式的推荐：按天气、最近做过的菜和家里的食材推荐几道菜，附上购物清单
+// keyword 为用户想吃的（如"鱼"），可以为空
+func (a *MealAgent) recommendRecipes(mealType, keyword string, mealTime time.Time) (string, error) {
+	weatherCh := make(chan *tools.WeatherInfo, 1)
+	go func() { weatherCh <- a.weatherAt(mealTime) }()
+	var weatherInfo *tools.WeatherInfo
+	select {
+	case weatherInfo = <-weatherCh:
+	case <-time.After(weatherTimeout):
+	}
+	a.lastWeather = weatherInfo
+	if weatherInfo == nil {
+		weatherInfo = &tools.WeatherInfo{Text: "未知", Temp: "20"}
+	}
+
+	prompt := a.privacy.Redact(a.buildRecipePrompt(mealType, keyword, weatherInfo), nil)
+	if len(a.messages) == 0 {
+		system := systemPrompt
+		if inst := i18n.Instruction(a.cfg.Language); inst != "" {
+			system += "\n\n" + inst
+		}
+		a.messages = append(a.messages, Message{Role: "system", Content: system})
+	}
+	a.messages = append(a.messages, Message{Role: "user", Content: prompt})
+	a.dumpPrompt()
+
+	response, err := a.complete(a.messages)
+	if err != nil {
+		a.messages = a.messages[:len(a.messages)-1]
+		return "", fmt.Errorf(i18n.T("LLM 调用失败: %v"), err)
+	}
+
+	// 模型没有按格式回复时直接使用原文
+	reply := response
+	a.lastRecipes = nil
+	if recipes, err := parseRecipes(response); err == nil && len(recipes) > 0 {
+		have := a.pantryNames(time.Now())
+		for i := range recipes {
+			recipes[i].Shopping = shoppingList(recipes[i], have)
+		}
+		a.lastRecipes = recipes
+		reply = a.describeRecipes(weatherInfo, recipes)
+	}
+	reply = a.safety.SanitizeOutput(reply, nil)
+
+	// 上下文中保存整理后的文字，之后可以追问"第二个怎么做"
+	a.messages = append(a.messages, Message{Role: "assistant", Content: reply})
+	return reply, nil
+}
+
+// buildRecipePrompt 构建做饭模式的 prompt
+func (a *MealAgent) buildRecipePrompt(mealType, keyword string, weather *tools.WeatherInfo) string {
+	cfg := a.cfg.Cooking
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("现在是%s时间（%s），用户今天想在家自己做饭，请推荐 %d 道适合在家做的菜（%d人份），不要推荐餐厅。\n\n",
+		map[string]string{"lunch": "午餐", "dinner": "晚餐"}[mealType], time.Now().Format("15:04"), cfg.Recipes, cfg.Servings))
+	if keyword != "" {
+		sb.WriteString(fmt.Sprintf("用户的要求：%s\n\n", keyword))
+	}
+
+	sb.WriteString("【天气信息】\n")
+	sb.WriteString(weather.Describe() + "\n")
+	sb.WriteString(a.foodRules.Evaluate(weather).Suggestion + "\n\n")
+
+	if foods := a.seasonalFoods(); len(foods) > 0 {
+		sb.WriteString("【节气节日】\n")
+		for _, food := range foods {
+			sb.WriteString(fmt.Sprintf("今天是%s，%s\n", food.Name, food.Suggestion))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("【家里现有的食材】\n")
+	if pantry := a.pantryPrompt(time.Now()); pantry != "" {
+		sb.WriteString(pantry)
+	} else {
+		sb.WriteString("未知\n")
+	}
+
+	sb.WriteString("\n【最近做过的菜】\n")
+	sb.WriteString(a.history.Cooking().Summary(cookingDays))
+	sb.WriteString("请不要重复最近做过的菜，评分高的可以换个做法。\n")
+
+	sb.WriteString("\n【最近在外面吃的】\n")
+	sb.WriteString(a.history.Summary())
+
+	if taste := a.tastePrompt(time.Now()); taste != "" {
+		sb.WriteString("\n【口味】\n" + taste)
+	}
+	if cfg.MaxMinutes > 0 {
+		sb.WriteString(fmt.Sprintf("\n【时间】\n每道菜准备加烹饪不超过%d分钟\n", cfg.MaxMinutes))
+	}
+	if note := a.nutritionGoalNote(mealType, time.Now()); note != "" {
+		sb.WriteString("\n【营养目标】\n" + note)
+	}
+	if guidance := a.health.Guidance(); guidance != "" {
+		sb.WriteString("\n【健康状况】\n" + guidance)
+	}
+	if len(a.tempExclude) > 0 {
+		sb.WriteString("\n【本次排除】\n用户表示不想吃：" + strings.Join(a.tempExclude, "、") + "\n")
+	}
+
+	sb.WriteString(`
+只回复 JSON，不要其他文字，格式为：
+{"recipes": [{"name": "菜名", "reason": "推荐理由（结合天气和最近吃的）", "minutes": 准备加烹饪的分钟数,
+  "ingredients": [{"name": "食材", "amount": "用量"}], "steps": ["简要步骤"]}]}`)
+	return sb.String()
+}
+
+// parseRecipes 解析模型回复中的菜谱
+func parseRecipes(response string) ([]Recipe, error) {
+	var result struct {
+		Recipes []Recipe `json:"recipes"`
+	}
+	if err := json.Unmarshal([]byte(jsonObjectPattern.FindString(response)), &result); err != nil {
+		return nil, fmt.Errorf("无法解析菜谱: %v", err)
+	}
+	recipes := result.Recipes[:0]
+	for _, r := range result.Recipes {
+		if strings.TrimSpace(r.Name) != "" {
+			recipes = append(recipes, r)
+		}
+	}
+	return recipes, nil
+}
+
+// shoppingList 菜谱中家里没有的食材（基础调料和 have 中的不用买）
+func shoppingList(r Recipe, have []string) []Ingredient {
+	var list []Ingredient
+	for _, ing := range r.Ingredients {
+		if !inPantry(ing.Name, have) {
+			list = append(list, ing)
+		}
+	}
+	return list
+}
+
+// inPantry 家里是否有这种食材（名称互相包含即可，如 "鸡蛋" 和 "土鸡蛋"）
+func inPantry(name string, have []string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return true
+	}
+	for _, s := range basicSeasonings {
+		if name == s {
+			return true
+		}
+	}
+	for _, item := range have {
+		if item != "" && (strings.Contains(name, item) || strings.Contains(item, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeRecipes 把菜谱整理成回复文字
+func (a *MealAgent) describeRecipes(weather *tools.WeatherInfo, recipes []Recipe) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T("根据今天的天气（%s %s°C），推荐在家做：", weather.Text, weather.Temp) + "\n")
+	for i, r := range recipes {
+		sb.WriteString(fmt.Sprintf("%d. **%s**", i+1, r.Name))
+		if r.Minutes > 0 {
+			sb.WriteString(i18n.T("（约%d分钟）", r.Minutes))
+		}
+		if r.Reason != "" {
+			sb.WriteString(" - " + r.Reason)
+		}
+		sb.WriteString("\n")
+		if len(r.Ingredients) > 0 {
+			sb.WriteString("   " + i18n.T("食材：") + joinIngredients(r.Ingredients) + "\n")
+		}
+		for j, step := range r.Steps {
+			sb.WriteString(fmt.Sprintf("   %d) %s\n", j+1, step))
+		}
+		if len(r.Shopping) > 0 {
+			sb.WriteString("   🛒 " + i18n.T("需要买：") + joinIngredients(r.Shopping) + "\n")
+		} else {
+			sb.WriteString("   🛒 " + i18n.T("家里的食材就够了") + "\n")
+		}
+	}
+	sb.WriteString("\n" + i18n.T("想做哪个？说「第一个」记录下来，或者说「出去吃」换回餐厅推荐"))
+	return sb.String()
+}
+
+func joinIngredients(list []Ingredient) string {
+	parts := make([]string, 0, len(list))
+	for _, ing := range list {
+		parts = append(parts, ing.String())
+	}
+	return strings.Join(parts, "、")
+}
+
+// confirmRecipe 做饭模式中确认要做的菜并记录
+func (a *MealAgent) confirmRecipe(input string) (string, error) {
+	recipe := a.extractRecipe(input)
+	if recipe == nil {
+		return i18n.T("请告诉我你要做哪道菜，可以说菜名或者「第一个」「第二个」等"), nil
+	}
+
+	names := make([]string, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		names = append(names, ing.Name)
+	}
+	record := memory.CookRecord{
+		MealType:    currentMealType(),
+		Recipe:      recipe.Name,
+		Ingredients: names,
+	}
+	if err := a.history.Cooking().Add(record); err != nil {
+		return "", fmt.Errorf(i18n.T("记录失败: %v"), err)
+	}
+
+	reply := i18n.T("好的，已记录今天在家做：%s。", recipe.Name)
+	if len(recipe.Shopping) > 0 {
+		reply += "\n🛒 " + i18n.T("需要买：") + joinIngredients(recipe.Shopping)
+	}
+	return reply, nil
+}
+
+// extractRecipe 从用户输入中找出选择的菜（序号或菜名，只说"好的"时为第一道）
+func (a *MealAgent) extractRecipe(input string) *Recipe {
+	if len(a.lastRecipes) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(input)
+	for _, p := range []struct {
+		pattern string
+		index   int
+	}{
+		{"第一", 0}, {"第1", 0}, {"first", 0}, {"#1", 0},
+		{"第二", 1}, {"第2", 1}, {"second", 1}, {"#2", 1},
+		{"第三", 2}, {"第3", 2}, {"third", 2}, {"#3", 2},
+	} {
+		if strings.Contains(lower, p.pattern) && p.index < len(a.lastRecipes) {
+			return &a.lastRecipes[p.index]
+		}
+	}
+	for i := range a.lastRecipes {
+		if strings.Contains(input, a.lastRecipes[i].Name) {
+			return &a.lastRecipes[i]
+		}
+	}
+	if strings.Contains(input, "就这个") || strings.Contains(input, "好的") || strings.Contains(lower, "sounds good") {
+		return &a.lastRecipes[0]
+	}
+	return nil
+}
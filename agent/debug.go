@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"meal-agent/debugdump"
+	"meal-agent/tools"
+)
+
+// SetDumper 开启调试输出：每次推荐写出发给 LLM 的 prompt 和带权重的候选餐厅（d 为 nil 时关闭）
+func (a *MealAgent) SetDumper(d *debugdump.Dumper) {
+	a.dumper = d
+}
+
+// dumpCandidates 按排序写出候选餐厅和各自的权重、距离、营业状态（列之间用制表符分隔，可以粘贴到表格中）
+func (a *MealAgent) dumpCandidates(mealType, keyword string, weather *tools.WeatherInfo, restaurants []tools.Restaurant, radius int) {
+	if a.dumper == nil {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "餐次: %s  关键词: %s  位置: %s  搜索半径: %d 米\n", mealType, keyword, a.LocationName(), radius)
+	fmt.Fprintf(&b, "天气: %s %s°C\n\n", weather.Text, weather.Temp)
+	fmt.Fprintln(&b, "#\t权重\t名称\t菜系\t距离\t步行\t评分\t人均\t营业\tID")
+	for i, r := range restaurants {
+		cuisine := r.Cuisine
+		if cuisine == "" {
+			cuisine = r.Type
+		}
+		fmt.Fprintf(&b, "%d\t%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", i+1, r.Weight, r.Name, cuisine,
+			r.Distance, r.WalkMinutes, r.Rating, r.Cost, r.OpenStatus.Code(), r.ID)
+	}
+	a.dumper.Write("candidates.txt", []byte(b.String()))
+}
+
+// dumpPrompt 写出本次发给 LLM 的全部消息（包括系统提示和之前的对话）
+func (a *MealAgent) dumpPrompt() {
+	if a.dumper == nil {
+		return
+	}
+	var b strings.Builder
+	for _, m := range a.messages {
+		fmt.Fprintf(&b, "===== %s =====\n%s\n\n", m.Role, m.Content)
+	}
+	a.dumper.Write("prompt.txt", []byte(b.String()))
+}
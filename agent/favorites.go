@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+	"meal-agent/preference"
+	"meal-agent/tools"
+)
+
+// 常吃清单的对话表达
+var (
+	// "保证每周至少吃一次山西面馆"、"每3天至少来一次沙县"
+	favoriteAfterPattern = regexp.MustCompile(`每(周|星期|两周|月|(\d+)天)至少(?:吃|去|来)?一次(.+)`)
+	// "山西面馆每周至少吃一次"
+	favoriteBeforePattern = regexp.MustCompile(`(.+?)每(周|星期|两周|月|(\d+)天)至少(?:吃|去|来)?一次`)
+	// "取消常吃山西面馆"、"把山西面馆从常吃清单移除"
+	favoriteRemovePattern = regexp.MustCompile(`取消常吃(.+)|把(.+?)从常吃清单(?:里|中)?(?:移除|删除|删掉|去掉)`)
+)
+
+var favoritePeriodDays = map[string]int{"周": 7, "星期": 7, "两周": 14, "月": 30}
+
+// dueFavorite 到期的常吃餐厅
+type dueFavorite struct {
+	favorite preference.Favorite
+	days     int  // 距上次吃的天数
+	never    bool // 还没吃过
+}
+
+// overdue 超期程度：（距上次吃的天数 + 1）/ 间隔天数，还没吃过的按刚好到期算
+func (d dueFavorite) overdue() float64 {
+	if d.never {
+		return 1
+	}
+	return float64(d.days+1) / float64(d.favorite.Interval())
+}
+
+// describe 写进 prompt 的说明
+func (d dueFavorite) describe(name string) string {
+	eaten := "还没吃过"
+	if !d.never {
+		eaten = fmt.Sprintf("已经 %d 天没吃了", d.days)
+	}
+	return fmt.Sprintf("「%s」在用户的常吃清单中（每 %d 天至少一次），%s，本次请务必把它列为推荐之一",
+		name, d.favorite.Interval(), eaten)
+}
+
+// dueFavorites 常吃清单中到期（再不吃就超过间隔）的餐厅，超期多的在前
+// 一起吃饭时不强制，常吃清单只代表自己的口味
+func (a *MealAgent) dueFavorites(now time.Time) []dueFavorite {
+	if a.pref == nil || a.groupPref != nil {
+		return nil
+	}
+	var due []dueFavorite
+	for _, f := range a.pref.Favorites {
+		days, ok := a.history.DaysSinceLast(memory.Filter{Restaurant: tools.BrandName(f.Name)}, now)
+		if ok && days+1 < f.Interval() {
+			continue
+		}
+		due = append(due, dueFavorite{favorite: f, days: days, never: !ok})
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].overdue() > due[j].overdue() })
+	return due
+}
+
+// isDueFavorite 餐厅是否为到期的常吃餐厅
+func isDueFavorite(due []dueFavorite, name string) bool {
+	for _, d := range due {
+		if d.favorite.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// promoteDueFavorite 常吃清单轮换：到期的常吃餐厅中超期最多、且在候选中的一家移到首位，
+// 并在 prompt 中要求推荐；每次只保证一家，几家同时到期时吃过一家后下次轮到下一家
+// 权重也提到原来的第一名之上，之后按权重排序、截取时仍排在首位
+func (a *MealAgent) promoteDueFavorite(restaurants []tools.Restaurant, now time.Time) []tools.Restaurant {
+	a.favoriteNote = ""
+	for _, d := range a.dueFavorites(now) {
+		for i, r := range restaurants {
+			if !d.favorite.Matches(r.Name) {
+				continue
+			}
+			if i > 0 && r.Weight <= restaurants[0].Weight {
+				r.Weight = restaurants[0].Weight + 1
+			}
+			promoted := append([]tools.Restaurant{r}, restaurants[:i]...)
+			a.favoriteNote = d.describe(r.Name)
+			return append(promoted, restaurants[i+1:]...)
+		}
+	}
+	return restaurants
+}
+
+// parseFavoriteEdit 解析常吃清单的对话（加入、取消），返回要执行的修改；不是这类请求时返回 nil
+func (a *MealAgent) parseFavoriteEdit(input string) func() string {
+	if m := favoriteRemovePattern.FindStringSubmatch(input); m != nil {
+		name := cleanFavoriteName(m[1] + m[2])
+		if name == "" {
+			return nil
+		}
+		return func() string {
+			if !a.pref.RemoveFavorite(name) {
+				return fmt.Sprintf("常吃清单里没有「%s」", name)
+			}
+			return fmt.Sprintf("已把「%s」移出常吃清单", name)
+		}
+	}
+
+	var name, period, n string
+	if m := favoriteAfterPattern.FindStringSubmatch(input); m != nil {
+		name, period, n = m[3], m[1], m[2]
+	} else if m := favoriteBeforePattern.FindStringSubmatch(input); m != nil {
+		name, period, n = m[1], m[2], m[3]
+	} else {
+		return nil
+	}
+	_, name = a.resolveRestaurant(cleanFavoriteName(name))
+	if name == "" {
+		return nil
+	}
+	days := favoritePeriodDays[period]
+	if n != "" {
+		days, _ = strconv.Atoi(n)
+	}
+	if days <= 0 {
+		return nil
+	}
+	return func() string {
+		a.pref.SetFavorite(name, days, "对话中添加")
+		return fmt.Sprintf("已把「%s」加入常吃清单，保证每 %d 天至少推荐一次", name, days)
+	}
+}
+
+// cleanFavoriteName 去掉餐厅名称前后的口语词
+func cleanFavoriteName(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), "。！!，,吧")
+	for _, prefix := range []string{"保证", "确保", "我", "要", "都"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return strings.TrimSpace(name)
+}
+
+// FavoritesSummary 常吃清单及各家距上次吃的天数
+func (a *MealAgent) FavoritesSummary() string {
+	if a.pref == nil || len(a.pref.Favorites) == 0 {
+		return "常吃清单是空的，可以说「每周至少吃一次山西面馆」添加"
+	}
+	var sb strings.Builder
+	sb.WriteString("常吃清单：")
+	for _, f := range a.pref.Favorites {
+		status := "还没吃过"
+		if days, ok := a.history.DaysSinceLast(memory.Filter{Restaurant: tools.BrandName(f.Name)}, time.Now()); ok {
+			status = fmt.Sprintf("%d 天前吃过", days)
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s（每 %d 天至少一次，%s）", f.Name, f.Interval(), status))
+	}
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"meal-agent/preference"
+)
+
+// 一起吃饭的对话表达："和 partner 一起吃"、"今天大家聚餐"；"一个人吃"退出
+var (
+	groupTriggers    = []string{"一起吃", "聚餐", "一块吃"}
+	groupAllWords    = []string{"大家", "所有人", "全部人"}
+	groupEndTriggers = []string{"一个人吃", "自己吃", "单独吃"}
+)
+
+// SetProfiles 设置其他人的偏好配置（名称 -> 偏好），一起吃饭时与自己的偏好合并
+func (a *MealAgent) SetProfiles(profiles map[string]*preference.Preferences) {
+	a.profiles = profiles
+}
+
+// StartGroup 进入一起吃饭模式：合并自己和 names 中各人的偏好用于推荐
+func (a *MealAgent) StartGroup(names []string) error {
+	members := []*preference.Preferences{a.pref}
+	for _, name := range names {
+		p, ok := a.profiles[name]
+		if !ok {
+			return fmt.Errorf("没有找到 %s 的偏好配置（可用: %s）", name, strings.Join(a.profileNames(), "、"))
+		}
+		members = append(members, p)
+	}
+
+	merged, err := preference.Merge(members...)
+	if err != nil {
+		return err
+	}
+	a.group = names
+	a.groupPref = merged
+	return nil
+}
+
+// EndGroup 退出一起吃饭模式，恢复只按自己的偏好推荐
+func (a *MealAgent) EndGroup() {
+	a.group = nil
+	a.groupPref = nil
+}
+
+// rankingPref 排序使用的偏好：一起吃饭时为合并后的偏好，否则为自己的偏好
+// 对话中修改偏好始终修改自己的配置
+func (a *MealAgent) rankingPref() *preference.Preferences {
+	if a.groupPref != nil {
+		return a.groupPref
+	}
+	return a.pref
+}
+
+// parseGroupMode 解析一起吃饭的对话，changed 为 true 时应重新推荐；reply 非空时直接回复
+func (a *MealAgent) parseGroupMode(input string) (reply string, changed bool) {
+	for _, t := range groupEndTriggers {
+		if strings.Contains(input, t) {
+			if a.group == nil {
+				return "", false
+			}
+			a.EndGroup()
+			return "", true
+		}
+	}
+
+	triggered := false
+	for _, t := range groupTriggers {
+		if strings.Contains(input, t) {
+			triggered = true
+			break
+		}
+	}
+	if !triggered {
+		return "", false
+	}
+	if len(a.profiles) == 0 {
+		return "还没有配置其他人的偏好，可以在配置文件的 profiles 中添加（如 partner: partner.yaml）", false
+	}
+
+	var names []string
+	for _, name := range a.profileNames() {
+		if strings.Contains(input, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		for _, w := range groupAllWords {
+			if strings.Contains(input, w) {
+				names = a.profileNames()
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("和谁一起吃？可以说「和%s一起吃」或「大家一起吃」", a.profileNames()[0]), false
+	}
+
+	if err := a.StartGroup(names); err != nil {
+		return err.Error(), false
+	}
+	return "", true
+}
+
+// profileNames 已配置的偏好名称（排序后）
+func (a *MealAgent) profileNames() []string {
+	names := make([]string, 0, len(a.profiles))
+	for name := range a.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 值得祝贺的连续天数
+var streakMilestones = map[int]bool{3: true, 7: true, 14: true, 21: true, 30: true, 60: true, 100: true}
+
+// HabitNote 根据饮食习惯生成祝贺或提醒，没有值得说的时返回空
+// 连续记录到达 3、7、14 天等节点时祝贺；连续吃快餐、月过半还没尝试新餐厅时提醒
+func (a *MealAgent) HabitNote() string {
+	s := a.history.Streaks(a.cfg.Budget.MaxFor)
+
+	var notes []string
+	if streakMilestones[s.NoFastFoodDays] {
+		notes = append(notes, fmt.Sprintf("🎉 已经连续 %d 天没吃快餐了，继续保持！", s.NoFastFoodDays))
+	}
+	if s.FastFoodDays >= 3 {
+		notes = append(notes, fmt.Sprintf("最近 %d 天都在吃快餐，今天换家正餐吧", s.FastFoodDays))
+	}
+	if streakMilestones[s.UnderBudgetDays] {
+		notes = append(notes, fmt.Sprintf("💰 已经连续 %d 天没超预算了", s.UnderBudgetDays))
+	}
+	if len(s.NewRestaurants) == 0 && time.Now().Day() >= 15 {
+		notes = append(notes, "这个月还没尝试新餐厅，今天去一家没去过的试试？")
+	}
+	return strings.Join(notes, "\n")
+}
@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"strings"
+
+	"meal-agent/i18n"
+)
+
+// healthWarningNote 回复中提到的候选餐厅的健康提醒（没有配置健康状况或没有提醒时为空）
+func (a *MealAgent) healthWarningNote(reply string) string {
+	var lines []string
+	for _, r := range promptCandidates(a.lastRestaurants) {
+		if len(r.HealthWarnings) == 0 || !strings.Contains(reply, r.Name) {
+			continue
+		}
+		lines = append(lines, r.Name+"："+strings.Join(r.HealthWarnings, "；"))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return i18n.T("⚠️ 健康提醒（%s）：", strings.Join(a.health.Names(), "、")) + "\n- " + strings.Join(lines, "\n- ")
+}
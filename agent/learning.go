@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"meal-agent/memory"
+	"meal-agent/preference"
+	"meal-agent/tools"
+)
+
+// learnChoice 从推荐中选了某家餐厅：选中的加权，一起推荐但没选的累计多次后降权
+func (a *MealAgent) learnChoice(chosen tools.Restaurant) {
+	if a.learner == nil {
+		return
+	}
+	shown := make([]preference.Candidate, 0, len(a.lastRestaurants))
+	for _, r := range a.lastRestaurants {
+		shown = append(shown, preference.Candidate{ID: r.ID, Name: r.Name})
+	}
+	a.learner.ObserveChoice(chosen.ID, chosen.Name, shown) // 学习失败不影响记录
+}
+
+// learnRating 根据用餐评分调整餐厅权重
+func (a *MealAgent) learnRating(record memory.MealRecord) {
+	if a.learner == nil {
+		return
+	}
+	a.learner.ObserveRating(record.RestaurantID, record.Restaurant, record.Rating)
+}
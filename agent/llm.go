@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"meal-agent/config"
+	"meal-agent/logging"
+	"meal-agent/network"
 )
 
 // LLM 定义 LLM 接口
@@ -15,10 +18,27 @@ type LLM interface {
 	Chat(messages []Message) (string, error)
 }
 
+// ToolLLM 支持调用工具（function calling）的 LLM，返回的消息带有 ToolCalls 时需要执行工具后继续请求
+type ToolLLM interface {
+	ChatWithTools(messages []Message, tools []Tool) (Message, error)
+}
+
 // Message 聊天消息
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // 模型请求调用的工具（role 为 assistant）
+	ToolCallID string     `json:"tool_call_id,omitempty"` // 工具结果对应的调用（role 为 tool）
+}
+
+// ToolCall 模型请求的一次工具调用
+type ToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON 格式的参数
+	} `json:"function"`
 }
 
 // OpenAICompatibleLLM 兼容 OpenAI 格式的 LLM（大部分国产模型都支持）
@@ -31,86 +51,115 @@ type OpenAICompatibleLLM struct {
 
 // NewLLM 根据配置创建 LLM 实例
 func NewLLM(cfg config.LLMConfig) LLM {
-	baseURL := cfg.BaseURL
-	if baseURL == "" {
-		// 根据 provider 设置默认 URL
-		switch cfg.Provider {
-		case "openai":
-			baseURL = "https://api.openai.com/v1"
-		case "claude":
-			// Claude 需要单独实现，这里先用兼容模式
-			baseURL = "https://api.anthropic.com/v1"
-		case "zhipu":
-			baseURL = "https://open.bigmodel.cn/api/paas/v4"
-		case "deepseek":
-			baseURL = "https://api.deepseek.com/v1"
-		case "moonshot":
-			baseURL = "https://api.moonshot.cn/v1"
-		case "qwen":
-			baseURL = "https://dashscope.aliyuncs.com/compatible-mode/v1"
-		default:
-			baseURL = "https://api.openai.com/v1"
-		}
-	}
-
 	return &OpenAICompatibleLLM{
 		apiKey:  cfg.APIKey,
-		baseURL: baseURL,
+		baseURL: LLMBaseURL(cfg),
 		model:   cfg.Model,
-		client:  &http.Client{},
+		client:  &http.Client{Transport: network.Transport(network.LLM)},
+	}
+}
+
+// LLMBaseURL 接口地址，未配置 base_url 时根据 provider 使用默认地址
+func LLMBaseURL(cfg config.LLMConfig) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	switch cfg.Provider {
+	case "openai":
+		return "https://api.openai.com/v1"
+	case "claude":
+		// Claude 需要单独实现，这里先用兼容模式
+		return "https://api.anthropic.com/v1"
+	case "zhipu":
+		return "https://open.bigmodel.cn/api/paas/v4"
+	case "deepseek":
+		return "https://api.deepseek.com/v1"
+	case "moonshot":
+		return "https://api.moonshot.cn/v1"
+	case "qwen":
+		return "https://dashscope.aliyuncs.com/compatible-mode/v1"
+	default:
+		return "https://api.openai.com/v1"
 	}
 }
 
 // Chat 发送聊天请求
 func (l *OpenAICompatibleLLM) Chat(messages []Message) (string, error) {
-	reqBody := map[string]interface{}{
+	reply, err := l.complete(map[string]interface{}{
 		"model":    l.model,
 		"messages": messages,
+	})
+	return reply.Content, err
+}
+
+// ChatWithTools 发送聊天请求，模型可以选择调用 tools 中的工具
+func (l *OpenAICompatibleLLM) ChatWithTools(messages []Message, tools []Tool) (Message, error) {
+	specs := make([]map[string]interface{}, 0, len(tools))
+	for _, t := range tools {
+		specs = append(specs, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        t.Name(),
+				"description": t.Description(),
+				"parameters":  t.Schema(),
+			},
+		})
 	}
+	return l.complete(map[string]interface{}{
+		"model":    l.model,
+		"messages": messages,
+		"tools":    specs,
+	})
+}
 
+// complete 请求 /chat/completions，返回第一个回复
+func (l *OpenAICompatibleLLM) complete(reqBody map[string]interface{}) (Message, error) {
+	messages, _ := reqBody["messages"].([]Message)
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return Message{}, err
 	}
 
 	req, err := http.NewRequest("POST", l.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return Message{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+l.apiKey)
 
+	start := time.Now()
 	resp, err := l.client.Do(req)
 	if err != nil {
-		return "", err
+		logging.Module("llm").Warn("LLM 请求失败", "model", l.model, "latency_ms", time.Since(start).Milliseconds(), "err", err)
+		return Message{}, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return Message{}, err
 	}
+	logging.Module("llm").Debug("LLM 请求", "model", l.model, "status", resp.StatusCode,
+		"latency_ms", time.Since(start).Milliseconds(), "messages", len(messages))
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s", string(body))
+		return Message{}, fmt.Errorf("API error: %s", string(body))
 	}
 
 	var result struct {
 		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
+			Message Message `json:"message"`
 		} `json:"choices"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+		return Message{}, err
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from LLM")
+		return Message{}, fmt.Errorf("no response from LLM")
 	}
 
-	return result.Choices[0].Message.Content, nil
-}
\ No newline at end of file
+	return result.Choices[0].Message, nil
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"meal-agent/config"
+	"meal-agent/i18n"
+)
+
+// 切换位置的对话表达："我现在在家"、"到公司了"、"切换到 office"、"I'm at home"
+var (
+	locationStatePattern   = regexp.MustCompile(`^我?(?:现在|今天|目前|这会儿|这几天)?人?(?:在|到了|回到|到)\s*(.+?)(?:了|啦|呢|附近|这边)*[。！!.～~]?$`)
+	locationCommandPattern = regexp.MustCompile(`^(?:切换到|换到|位置(?:改成|换成|切换到))\s*(.+?)[。！!.]?$`)
+	englishLocationPattern = regexp.MustCompile(`(?i)^(?:(?:i'?m|i am)\s+(?:now\s+)?(?:at|in)|switch to)\s+(?:the\s+)?(.+?)[.!]?$`)
+	locationQueryWords     = []string{"我在哪", "现在在哪", "当前位置", "where am i"}
+)
+
+// currentLocation 推荐时使用的位置
+func (a *MealAgent) currentLocation() config.Location {
+	if a.location != "" {
+		if loc, _, ok := a.cfg.FindLocation(a.location); ok {
+			return loc
+		}
+	}
+	return a.cfg.Location
+}
+
+// LocationName 当前位置的名称（默认位置为 location.name，未设置时为 default）
+func (a *MealAgent) LocationName() string {
+	if a.location != "" {
+		return a.location
+	}
+	return a.cfg.LocationNames()[0]
+}
+
+// SetLocation 切换推荐使用的位置，name 为 locations 中的名称或叫法（default 为默认位置）
+func (a *MealAgent) SetLocation(name string) error {
+	_, key, ok := a.cfg.FindLocation(name)
+	if !ok {
+		return fmt.Errorf("没有找到位置: %s（可用 %s）", name, strings.Join(a.cfg.LocationNames(), " / "))
+	}
+	a.location = key
+	// 天气按新位置查询（注入的天气来源不变）
+	if a.providers.Weather == nil {
+		a.weather = newWeatherProvider(a.cfg, a.currentLocation())
+	}
+	return nil
+}
+
+// applyScheduledLocation 按 schedule 切换到当天的位置；当天在对话中切换过位置时保留对话中的选择
+func (a *MealAgent) applyScheduledLocation(name string, now time.Time) {
+	if name == "" || sameDay(a.locationSwitched, now) {
+		return
+	}
+	a.SetLocation(name) // 名称在加载配置时已经检查过
+}
+
+func sameDay(a, b time.Time) bool {
+	return !a.IsZero() && a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// parseLocationSwitch 解析切换位置的对话，changed 为 true 时应重新推荐，reply 为切换的说明或错误提示
+func (a *MealAgent) parseLocationSwitch(input string) (reply string, changed bool) {
+	input = strings.TrimSpace(input)
+	if containsAny(strings.ToLower(input), locationQueryWords) {
+		loc := a.currentLocation()
+		return i18n.T("📍 当前位置: %s（%s,%s，半径 %d 米）", a.LocationName(), loc.Lat, loc.Lng, loc.Radius), false
+	}
+	if len(a.cfg.Locations) == 0 {
+		return "", false
+	}
+
+	// "切换到 X" 明确要切换，找不到时提示可用的位置；"我在 X" 找不到时当作普通对话
+	var name string
+	explicit := false
+	if m := locationCommandPattern.FindStringSubmatch(input); m != nil {
+		name, explicit = m[1], true
+	} else if m := englishLocationPattern.FindStringSubmatch(input); m != nil {
+		name = m[1]
+	} else if m := locationStatePattern.FindStringSubmatch(input); m != nil {
+		name = m[1]
+	} else {
+		return "", false
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(name, "里"), "那边"))
+
+	if _, _, ok := a.cfg.FindLocation(name); !ok {
+		if explicit {
+			return i18n.T("没有找到位置「%s」，可用: %s", name, strings.Join(a.cfg.LocationNames(), " / ")), false
+		}
+		return "", false
+	}
+	a.SetLocation(name)
+	a.locationSwitched = time.Now()
+	return i18n.T("📍 已切换到「%s」附近", a.LocationName()), true
+}
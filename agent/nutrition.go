@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"meal-agent/i18n"
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+// LLM 回复中的 JSON 对象（模型有时会在前后加说明文字或代码块）
+var jsonObjectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+// estimateNutrition 估算一餐的营养，未开启营养估算时返回 nil
+// source 为 llm 时让模型根据餐厅、菜系和备注估算，请求失败或回复无法解析时按菜系查表
+func (a *MealAgent) estimateNutrition(record memory.MealRecord) *memory.Nutrition {
+	if !a.cfg.Nutrition.Enabled {
+		return nil
+	}
+
+	if a.cfg.Nutrition.Source == "llm" {
+		if n, err := a.llmNutrition(record); err == nil {
+			return n
+		}
+	}
+
+	facts := tools.EstimateNutrition(record.Category, record.Restaurant)
+	return &memory.Nutrition{Calories: facts.Calories, Protein: facts.Protein, Fat: facts.Fat, Source: "table"}
+}
+
+// llmNutrition 让 LLM 估算营养（单独请求，不影响对话上下文）
+func (a *MealAgent) llmNutrition(record memory.MealRecord) (*memory.Nutrition, error) {
+	dish := record.Restaurant
+	if record.Category != "" {
+		dish += "（" + record.Category + "）"
+	}
+	if record.Note != "" {
+		dish += "，备注：" + record.Note
+	}
+
+	response, err := a.llm.Chat([]Message{
+		{Role: "system", Content: "你是营养师。根据用户描述的一餐估算一人份的营养，只回复 JSON，格式为 {\"calories\": 千卡, \"protein\": 克, \"fat\": 克}，不要其他文字。"},
+		{Role: "user", Content: a.privacy.Redact("在"+dish+"吃了一顿饭", nil)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var n memory.Nutrition
+	if err := json.Unmarshal([]byte(jsonObjectPattern.FindString(response)), &n); err != nil {
+		return nil, fmt.Errorf("无法解析营养估算: %v", err)
+	}
+	if n.Calories <= 0 || n.Calories > 5000 {
+		return nil, fmt.Errorf("营养估算不合理: %.0f 千卡", n.Calories)
+	}
+	n.Source = "llm"
+	return &n, nil
+}
+
+// mealCalorieLimit 这一餐建议的热量上限：每天的目标减去今天已经记录的，平分给今天还没吃的午餐、晚餐
+// 今天已经超过目标时为 0；没有设置热量目标时 ok 为 false
+func (a *MealAgent) mealCalorieLimit(mealType string, now time.Time) (limit float64, ok bool) {
+	goal := a.cfg.Nutrition.Goals.Calories
+	if !a.cfg.Nutrition.Enabled || goal <= 0 {
+		return 0, false
+	}
+	eaten, mealTypes := a.history.DayNutrition(now)
+	meals := 1
+	if mealType == "lunch" && !containsString(mealTypes, "dinner") {
+		meals = 2
+	}
+	return math.Max(goal-eaten.Calories, 0) / float64(meals), true
+}
+
+// caloriePenalty 估算热量超出这一餐建议热量的餐厅降权，每超出 10 千卡减 1 分，最多减 40 分
+func caloriePenalty(r tools.Restaurant, limit float64) int {
+	over := tools.EstimateNutrition(r.Cuisine, r.Name).Calories - limit
+	if over <= 0 {
+		return 0
+	}
+	return int(math.Min(over/10, 40))
+}
+
+// nutritionGoalNote 今天的营养目标和已经吃了多少（写进 prompt），没有设置目标时为空
+func (a *MealAgent) nutritionGoalNote(mealType string, now time.Time) string {
+	goals := a.cfg.Nutrition.Goals
+	if !a.cfg.Nutrition.Enabled || !goals.Set() {
+		return ""
+	}
+	eaten, _ := a.history.DayNutrition(now)
+
+	var sb strings.Builder
+	if limit, ok := a.mealCalorieLimit(mealType, now); ok {
+		sb.WriteString(fmt.Sprintf("每天热量目标%.0f千卡，今天已经吃了约%.0f千卡", goals.Calories, eaten.Calories))
+		switch {
+		case eaten.Calories >= goals.Calories:
+			sb.WriteString("，已经超过目标。这一餐请推荐" + lightFoods + "，并在推荐理由中提醒用户\n")
+		case limit < goals.Calories/2:
+			sb.WriteString(fmt.Sprintf("，前面吃多了，这一餐建议不超过%.0f千卡。请推荐%s\n", limit, lightFoods))
+		default:
+			sb.WriteString(fmt.Sprintf("，这一餐建议不超过%.0f千卡\n", limit))
+		}
+	}
+	if goals.Protein > 0 && eaten.Protein < goals.Protein {
+		sb.WriteString(fmt.Sprintf("每天蛋白质目标%.0f克，今天还差约%.0f克，可以多选肉、蛋、鱼、豆制品\n", goals.Protein, goals.Protein-eaten.Protein))
+	}
+	if goals.Fat > 0 {
+		if eaten.Fat >= goals.Fat {
+			sb.WriteString(fmt.Sprintf("每天脂肪不超过%.0f克，今天已经约%.0f克，请避免油腻的\n", goals.Fat, eaten.Fat))
+		} else {
+			sb.WriteString(fmt.Sprintf("每天脂肪不超过%.0f克，今天已经约%.0f克\n", goals.Fat, eaten.Fat))
+		}
+	}
+	return sb.String()
+}
+
+// lightFoods 超过热量目标时 prompt 中建议的吃法
+const lightFoods = "清淡、分量小、热量低的（如轻食沙拉、粥、清汤面、蒸菜），避免火锅、烧烤、油炸和油腻的"
+
+// CalorieNote 今天的热量和目标（如 "今天热量约1300/1800千卡，还剩500千卡"），没有设置热量目标时为空
+func (a *MealAgent) CalorieNote() string {
+	goal := a.cfg.Nutrition.Goals.Calories
+	if !a.cfg.Nutrition.Enabled || goal <= 0 {
+		return ""
+	}
+	eaten, _ := a.history.DayNutrition(time.Now())
+	if eaten.Calories > goal {
+		return fmt.Sprintf("今天热量约%.0f/%.0f千卡，超出%.0f千卡\n", eaten.Calories, goal, eaten.Calories-goal)
+	}
+	return fmt.Sprintf("今天热量约%.0f/%.0f千卡，还剩%.0f千卡\n", eaten.Calories, goal, goal-eaten.Calories)
+}
+
+// calorieRecordNote 记录用餐后的热量提醒：午餐吃多了时说明晚餐会清淡一些，超过每天的目标时提醒，其他情况为空
+func (a *MealAgent) calorieRecordNote(mealType string) string {
+	goal := a.cfg.Nutrition.Goals.Calories
+	if !a.cfg.Nutrition.Enabled || goal <= 0 {
+		return ""
+	}
+	eaten, _ := a.history.DayNutrition(time.Now())
+	switch {
+	case eaten.Calories > goal:
+		return i18n.T("今天已经吃了约%.0f千卡，超过目标%.0f千卡。", eaten.Calories, eaten.Calories-goal)
+	case mealType == "lunch" && eaten.Calories > goal/2:
+		return i18n.T("今天已经吃了约%.0f千卡（目标%.0f千卡），晚餐会推荐清淡一些的。", eaten.Calories, goal)
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
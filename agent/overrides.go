@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/preference"
+	"meal-agent/tools"
+)
+
+// 临时偏好的对话表达："下周减脂，沙拉权重x2，炸鸡为0，截止到2024-07-01"
+var (
+	// 截止日期："截止到2024-07-01"、"到7月1日"
+	overrideUntilPattern = regexp.MustCompile(`(?:截止到?|直到|到)\s*(?:(\d{4})[-/.年])?(\d{1,2})[-/.月](\d{1,2})[日号]?`)
+	// 持续天数："接下来5天"、"5天内"
+	overrideDaysPattern = regexp.MustCompile(`(?:接下来|未来|之后)?(\d+)天(?:内|之内)?`)
+	// 倍数："沙拉权重x2"、"轻食×1.5"
+	overrideMultiplyPattern = regexp.MustCompile(`^(.+?)的?(?:权重)?\s*(?:x|X|×|\*|乘以?)\s*(\d+(?:\.\d+)?)$`)
+	// 固定权重："炸鸡为0"、"火锅权重设为50"
+	overrideWeightPattern = regexp.MustCompile(`^(.+?)的?(?:权重)?\s*(?:为|设为|改为|调到|=)\s*(\d+)$`)
+	// 分隔各项
+	overrideSeparator = regexp.MustCompile(`[，,；;、。]`)
+)
+
+// 表示时间段的词（解析调整项和原因时去掉）
+var overridePeriodWords = []string{"这周", "本周", "下周", "这个月", "本月", "今天", "明天"}
+
+// parseOverrideEdit 解析临时偏好的对话，返回要执行的修改；不是这类请求时返回 nil
+// 需要同时有时间范围和至少一项调整，时间范围只写"下周"时从下周一开始
+func (a *MealAgent) parseOverrideEdit(input string, now time.Time) func() string {
+	from, until, ok := overridePeriod(input, now)
+	if !ok {
+		return nil
+	}
+
+	var overrides []preference.Override
+	var reasons []string
+	for _, part := range overrideSeparator.Split(input, -1) {
+		// 去掉时间范围的写法，剩下的是调整项或原因
+		part = overrideUntilPattern.ReplaceAllString(part, "")
+		part = overrideDaysPattern.ReplaceAllString(part, "")
+		for _, w := range overridePeriodWords {
+			part = strings.ReplaceAll(part, w, "")
+		}
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var target, weight string
+		if m := overrideMultiplyPattern.FindStringSubmatch(part); m != nil {
+			target, weight = m[1], "x"+m[2]
+		} else if m := overrideWeightPattern.FindStringSubmatch(part); m != nil {
+			target, weight = m[1], m[2]
+		} else {
+			reasons = append(reasons, part)
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if cuisine := tools.CanonicalCuisine(target, ""); cuisine != tools.CuisineOther {
+			target = cuisine
+		}
+		overrides = append(overrides, preference.Override{Target: target, Weight: weight})
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	for i := range overrides {
+		overrides[i].From = from
+		overrides[i].Until = until
+		overrides[i].Reason = strings.Join(reasons, "，")
+	}
+
+	return func() string {
+		a.pref.PruneExpired(now)
+		a.pref.AddOverrides(overrides...)
+		var sb strings.Builder
+		sb.WriteString("已添加临时偏好，到期后自动删除：")
+		for _, o := range overrides {
+			sb.WriteString("\n  " + o.Describe())
+		}
+		return sb.String()
+	}
+}
+
+// overridePeriod 从对话中解析临时偏好的开始和截止日期（2006-01-02），from 为空表示立即生效
+func overridePeriod(input string, now time.Time) (from, until string, ok bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// 本周日、下周一
+	sunday := today.AddDate(0, 0, (7-int(today.Weekday()))%7)
+	nextMonday := sunday.AddDate(0, 0, 1)
+
+	if strings.Contains(input, "下周") {
+		from = nextMonday.Format("2006-01-02")
+	}
+
+	var end time.Time
+	if m := overrideUntilPattern.FindStringSubmatch(input); m != nil {
+		year := now.Year()
+		if m[1] != "" {
+			year, _ = strconv.Atoi(m[1])
+		}
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		end = time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+		if m[1] == "" && end.Before(today) {
+			end = end.AddDate(1, 0, 0) // 没写年份且已经过了，指明年
+		}
+	} else if m := overrideDaysPattern.FindStringSubmatch(input); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		if days <= 0 {
+			return "", "", false
+		}
+		end = today.AddDate(0, 0, days-1)
+	} else {
+		switch {
+		case strings.Contains(input, "下周"):
+			end = nextMonday.AddDate(0, 0, 6)
+		case strings.Contains(input, "这周"), strings.Contains(input, "本周"):
+			end = sunday
+		case strings.Contains(input, "这个月"), strings.Contains(input, "本月"):
+			end = time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
+		case strings.Contains(input, "一周"), strings.Contains(input, "一个星期"):
+			end = today.AddDate(0, 0, 6)
+		case strings.Contains(input, "两周"):
+			end = today.AddDate(0, 0, 13)
+		case strings.Contains(input, "今天"):
+			end = today
+		default:
+			return "", "", false
+		}
+	}
+
+	if end.Before(today) {
+		return "", "", false
+	}
+	if end.Before(nextMonday) {
+		from = "" // 截止日期早于下周一时从今天开始
+	}
+	return from, end.Format("2006-01-02"), true
+}
+
+// OverridesSummary 当前的临时偏好
+func (a *MealAgent) OverridesSummary() string {
+	if a.pref == nil {
+		return "没有临时偏好"
+	}
+	active := a.pref.ActiveOverrides(time.Now())
+	if len(active) == 0 {
+		return "没有临时偏好，可以说「下周减脂，沙拉权重x2，炸鸡为0」添加"
+	}
+	var sb strings.Builder
+	sb.WriteString("临时偏好：")
+	for _, o := range active {
+		sb.WriteString("\n  " + o.Describe())
+	}
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+)
+
+// pantryExpiringDays 几天内过期的食材算快过期（做饭模式优先用，提醒中列出）
+const pantryExpiringDays = 3
+
+var (
+	expiresPattern  = regexp.MustCompile(`(?:过期|到期|expires?)[:：]\s*(\S+)`)
+	minPattern      = regexp.MustCompile(`(?:常备|最少|min)[:：]\s*(\d+(?:\.\d+)?)`)
+	quantityPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(\D*)$`)
+	relativeDays    = regexp.MustCompile(`^(\d+)\s*天(?:后)?$`)
+)
+
+// ParsePantryItem 解析买到的食材，如 "鸡蛋 10个 过期:2024-07-01 常备:4"
+// 数量可以带单位（"500克"、"2 袋"），省略时为 1；过期可以写日期、07-01、3天、明天
+func ParsePantryItem(args string, now time.Time) (memory.PantryItem, error) {
+	var item memory.PantryItem
+	if m := expiresPattern.FindStringSubmatch(args); m != nil {
+		date, err := parseExpiry(m[1], now)
+		if err != nil {
+			return item, err
+		}
+		item.Expires = date
+		args = strings.Replace(args, m[0], " ", 1)
+	}
+	if m := minPattern.FindStringSubmatch(args); m != nil {
+		item.Min, _ = strconv.ParseFloat(m[1], 64)
+		args = strings.Replace(args, m[0], " ", 1)
+	}
+
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		return item, errors.New("请输入食材名称，例如: pantry add 鸡蛋 10个 过期:7天")
+	}
+	item.Name = parts[0]
+	item.Quantity = 1
+	if len(parts) >= 2 {
+		m := quantityPattern.FindStringSubmatch(parts[1])
+		if m == nil {
+			return item, fmt.Errorf("无效的数量: %s（如 10、500克）", parts[1])
+		}
+		item.Quantity, _ = strconv.ParseFloat(m[1], 64)
+		item.Unit = m[2]
+		if item.Unit == "" && len(parts) >= 3 {
+			item.Unit = parts[2]
+		}
+	}
+	return item, nil
+}
+
+// ParsePantryUse 解析用掉的食材，如 "鸡蛋 3"，没有数量时为全部用完（返回 0）
+func ParsePantryUse(args string) (name string, quantity float64, err error) {
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		return "", 0, errors.New("请输入食材名称，例如: pantry use 鸡蛋 3")
+	}
+	if len(parts) >= 2 {
+		m := quantityPattern.FindStringSubmatch(parts[1])
+		if m == nil {
+			return "", 0, fmt.Errorf("无效的数量: %s", parts[1])
+		}
+		quantity, _ = strconv.ParseFloat(m[1], 64)
+	}
+	return parts[0], quantity, nil
+}
+
+// parseExpiry 过期日期：2024-07-01、07-01（今年）、3天、今天、明天、后天
+func parseExpiry(s string, now time.Time) (string, error) {
+	switch s {
+	case "今天":
+		return now.Format("2006-01-02"), nil
+	case "明天":
+		return now.AddDate(0, 0, 1).Format("2006-01-02"), nil
+	case "后天":
+		return now.AddDate(0, 0, 2).Format("2006-01-02"), nil
+	}
+	if m := relativeDays.FindStringSubmatch(s); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		return now.AddDate(0, 0, days).Format("2006-01-02"), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t.Format("2006-01-02"), nil
+	}
+	if t, err := time.ParseInLocation("2006-1-2", fmt.Sprintf("%d-%s", now.Year(), s), now.Location()); err == nil {
+		return t.Format("2006-01-02"), nil
+	}
+	return "", fmt.Errorf("无效的过期日期: %s（如 2024-07-01、07-01、3天、明天）", s)
+}
+
+// Pantry 家里的食材库存
+func (a *MealAgent) Pantry() *memory.Pantry {
+	return a.history.Pantry()
+}
+
+// pantryStock 库存中还有、没有过期的食材（读取失败时为空）
+func (a *MealAgent) pantryStock(now time.Time) []memory.PantryItem {
+	items, err := a.Pantry().Items()
+	if err != nil {
+		return nil
+	}
+	stock := items[:0]
+	for _, item := range items {
+		if item.Quantity > 0 && !item.Expired(now) {
+			stock = append(stock, item)
+		}
+	}
+	return stock
+}
+
+// pantryNames 家里有的食材名称：库存中的和 cooking.pantry 中的
+func (a *MealAgent) pantryNames(now time.Time) []string {
+	names := append([]string(nil), a.cfg.Cooking.Pantry...)
+	for _, item := range a.pantryStock(now) {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// pantryPrompt 写进做饭模式 prompt 的家里的食材（库存和 cooking.pantry），没有时为空
+func (a *MealAgent) pantryPrompt(now time.Time) string {
+	var have, expiring []string
+	for _, item := range a.pantryStock(now) {
+		have = append(have, item.Describe(now))
+		if days, ok := item.DaysLeft(now); ok && days <= pantryExpiringDays {
+			expiring = append(expiring, item.Name)
+		}
+	}
+	for _, name := range a.cfg.Cooking.Pantry {
+		have = append(have, name)
+	}
+	if len(have) == 0 {
+		return ""
+	}
+
+	prompt := strings.Join(have, "、") + "\n"
+	if len(expiring) > 0 {
+		prompt += "快过期的：" + strings.Join(expiring, "、") + "，请优先推荐用到它们的菜\n"
+	}
+	return prompt + "请尽量用这些食材，少买新的。\n"
+}
+
+// PantryNote 每天提醒中附带的库存提醒：常备食材不够了、食材快过期，没有时为空
+func (a *MealAgent) PantryNote() string {
+	items, err := a.Pantry().Items()
+	if err != nil {
+		return ""
+	}
+	now := time.Now()
+	var low, expiring []string
+	for _, item := range items {
+		if item.Low() {
+			low = append(low, fmt.Sprintf("%s（剩%s）", item.Name, item.Amount()))
+		}
+		if days, ok := item.DaysLeft(now); ok && days <= pantryExpiringDays && item.Quantity > 0 {
+			expiring = append(expiring, item.Describe(now))
+		}
+	}
+
+	var notes []string
+	if len(low) > 0 {
+		notes = append(notes, "🛒 常备食材快用完了："+strings.Join(low, "、"))
+	}
+	if len(expiring) > 0 {
+		notes = append(notes, "⏰ 家里的食材快过期了："+strings.Join(expiring, "、"))
+	}
+	return strings.Join(notes, "\n")
+}
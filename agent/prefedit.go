@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+// 修改偏好的对话表达
+var (
+	// "以后多推荐日料"、"以后少吃火锅"
+	categoryPrefPattern = regexp.MustCompile(`以后(多|少)(?:推荐|推|吃|来)点?(.+)`)
+	// "把海底捞拉黑"、"拉黑海底捞"
+	blacklistPattern = regexp.MustCompile(`把(.+?)拉黑|拉黑(.+)`)
+	// "这家店权重调到150"、"海底捞的权重改成80"
+	weightPattern = regexp.MustCompile(`(.+?)的?权重(?:调到|调成|调为|改成|改为|设为|设成|设置为)\s*(\d+)`)
+)
+
+// 对话中调整菜系偏好时使用的权重
+const (
+	moreCategoryWeight = 150
+	lessCategoryWeight = 50
+	maxChatWeight      = 300 // 对话中能设置的最大权重
+)
+
+// SetPreferencePath 设置偏好配置文件路径，对话中修改的偏好会保存到这里
+// 未设置时修改只在本次运行中生效
+func (a *MealAgent) SetPreferencePath(path string) {
+	a.prefPath = path
+}
+
+// parsePreferenceEdit 处理修改偏好的对话，handled 为 false 表示不是这类请求
+func (a *MealAgent) parsePreferenceEdit(input string) (reply string, handled bool) {
+	switch strings.TrimSpace(input) {
+	case "常吃清单":
+		return a.FavoritesSummary(), true
+	case "临时偏好":
+		return a.OverridesSummary(), true
+	case "口味", "口味偏好":
+		return a.TasteSummary(), true
+	}
+
+	var apply func() string
+
+	if strings.Contains(input, "取消临时偏好") {
+		apply = func() string {
+			return fmt.Sprintf("已清空 %d 条临时偏好", a.pref.ClearOverrides())
+		}
+	} else if edit := a.parseOverrideEdit(input, time.Now()); edit != nil {
+		apply = edit
+	} else if edit := a.parseTasteEdit(input, time.Now()); edit != nil {
+		apply = edit
+	} else if edit := a.parseFavoriteEdit(input); edit != nil {
+		apply = edit
+	} else if m := blacklistPattern.FindStringSubmatch(input); m != nil {
+		id, name := a.resolveRestaurant(m[1] + m[2])
+		if name == "" {
+			return "", false
+		}
+		apply = func() string {
+			a.pref.SetRestaurantWeightByID(id, name, 0, "对话中拉黑")
+			return fmt.Sprintf("已把「%s」拉黑，以后不会再推荐", name)
+		}
+	} else if m := weightPattern.FindStringSubmatch(input); m != nil {
+		id, name := a.resolveRestaurant(m[1])
+		weight, err := strconv.Atoi(m[2])
+		if name == "" || err != nil {
+			return "", false
+		}
+		if weight > maxChatWeight {
+			return fmt.Sprintf("权重最多设置为 %d（100 为默认）", maxChatWeight), true
+		}
+		apply = func() string {
+			a.pref.SetRestaurantWeightByID(id, name, weight, "对话中设置")
+			return fmt.Sprintf("已把「%s」的权重调整为 %d", name, weight)
+		}
+	} else if m := categoryPrefPattern.FindStringSubmatch(input); m != nil {
+		category := strings.Trim(strings.TrimSpace(m[2]), "吧。！!的")
+		if category == "" {
+			return "", false
+		}
+		// 能识别的菜系使用统一名称，识别不了的按类型关键词匹配
+		if cuisine := tools.CanonicalCuisine(category, ""); cuisine != tools.CuisineOther {
+			category = cuisine
+		}
+		weight, word := moreCategoryWeight, "多"
+		if m[1] == "少" {
+			weight, word = lessCategoryWeight, "少"
+		}
+		apply = func() string {
+			a.pref.SetCategoryWeight(category, weight, "对话中设置")
+			return fmt.Sprintf("好的，以后%s推荐%s", word, category)
+		}
+	} else {
+		return "", false
+	}
+
+	if a.pref == nil {
+		return "偏好配置加载失败，暂时无法修改，请检查 restaurants.yaml", true
+	}
+	reply = apply()
+	if a.prefPath != "" {
+		if err := a.pref.Save(a.prefPath); err != nil {
+			return reply + fmt.Sprintf("（保存失败，只在本次运行中生效: %v）", err), true
+		}
+	}
+	return reply, true
+}
+
+// resolveRestaurant 把对话中提到的餐厅换成完整名称和 POI ID
+// "这家"、"这个"指最近一次用餐的餐厅；与上次推荐的餐厅名称相符时使用推荐中的名称和 ID
+func (a *MealAgent) resolveRestaurant(text string) (id, name string) {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "把"))
+	for _, ref := range []string{"这家店", "这家", "这个", "那家店", "那家"} {
+		if text == ref {
+			records := a.history.Find(memory.Filter{})
+			if len(records) == 0 {
+				return "", ""
+			}
+			return records[0].RestaurantID, records[0].Restaurant
+		}
+	}
+
+	if selected := a.extractSelection(text); selected != nil {
+		return selected.ID, selected.Name
+	}
+	for _, r := range a.lastRestaurants {
+		if text != "" && strings.Contains(r.Name, text) {
+			return r.ID, r.Name
+		}
+	}
+	return "", text
+}
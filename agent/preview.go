@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/i18n"
+	"meal-agent/notify"
+	"meal-agent/tools"
+)
+
+const (
+	previewPicks  = 5   // 预告中列出的餐厅数量
+	popularRating = 4.5 // 评分不低于它的正餐餐厅视为热门，预告中提醒提前订位或取号
+)
+
+// Preview 用餐预告：按 mealTime 的天气和偏好排序，列出可能推荐的餐厅，热门的和偏好中标记了 reserve 的提醒提前订位或取号
+// 不调用 LLM，也不改变对话上下文，正式推荐仍在提醒时间进行
+func (a *MealAgent) Preview(mealType string, mealTime time.Time) (string, []tools.Restaurant, error) {
+	restaurants, weather, err := a.rankedAt(mealType, "", mealTime)
+	if err != nil {
+		return "", nil, err
+	}
+	picks := tools.TopK(restaurants, previewPicks)
+	if len(picks) == 0 {
+		return "附近暂时没有合适的餐厅，到时再看看", nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s，%s%s°C，可能会推荐：", mealTime.Format("15:04"), mealTypeNames[mealType], weather.Text, weather.Temp))
+	var reserve []string
+	for i, r := range picks {
+		sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, r.Name))
+		if r.Cuisine != "" {
+			sb.WriteString("（" + r.Cuisine + "）")
+		}
+		if rating := r.GetRatingFloat(); rating > 0 {
+			sb.WriteString(fmt.Sprintf(" ⭐%.1f", rating))
+		}
+		if a.needsReservation(r) {
+			sb.WriteString(" 🔥")
+			reserve = append(reserve, r.Name)
+		}
+	}
+	if len(reserve) > 0 {
+		sb.WriteString("\n\n🔥 " + strings.Join(reserve, "、") + " 比较热门，想去的话提前订位或取号")
+		for _, r := range picks {
+			if r.Tel != "" && a.needsReservation(r) {
+				sb.WriteString(fmt.Sprintf("\n📞 %s：%s", r.Name, r.Tel))
+			}
+		}
+	}
+	return sb.String(), picks, nil
+}
+
+// needsReservation 偏好中标记了 reserve，或者是评分高的正餐餐厅
+func (a *MealAgent) needsReservation(r tools.Restaurant) bool {
+	if pref := a.rankingPref(); pref != nil && pref.NeedsReservation(r.ID, r.Name) {
+		return true
+	}
+	return r.Category == tools.CategoryFullMeal && r.GetRatingFloat() >= popularRating
+}
+
+// previewKey 提醒记录中预告使用的键
+func previewKey(mealType string) string {
+	return "preview_" + mealType
+}
+
+// duePreview 到了预告时间、还没有预告过的餐次（到正式提醒时间后不再预告）
+func (s *Scheduler) duePreview(now time.Time) (mealType string, scheduled time.Time, ok bool) {
+	lunch, dinner, _ := s.schedule.TimesOn(now)
+	for _, m := range []struct{ mealType, at string }{{"dinner", dinner}, {"lunch", lunch}} {
+		lead := s.schedule.Preview.Lead(m.mealType)
+		scheduled, ok := scheduledAt(now, m.at)
+		if lead == 0 || !ok || !now.Before(scheduled) {
+			continue
+		}
+		at := scheduled.Add(-lead)
+		if now.Before(at) || !s.fired[previewKey(m.mealType)].Before(at) {
+			continue
+		}
+		return m.mealType, scheduled, true
+	}
+	return "", time.Time{}, false
+}
+
+// sendPreview 推送用餐预告，预告失败只记录错误，不影响正式提醒
+func (s *Scheduler) sendPreview(mealType string, scheduled time.Time) {
+	s.markFired(previewKey(mealType), time.Now())
+	mealTime := scheduled.Add(s.schedule.MealDelayDuration())
+	s.agent.applyScheduledLocation(s.schedule.LocationOn(mealTime), time.Now())
+	text, picks, err := s.agent.Preview(mealType, mealTime)
+	if err != nil {
+		if !s.stopping() {
+			s.reportError(fmt.Errorf("%s预告失败: %v", mealTypeNames[mealType], err))
+		}
+		return
+	}
+	s.send(notify.Notification{
+		MealType:    mealType,
+		Title:       i18n.T("📋 %s预告（%s 提醒）", i18n.T(mealTypeNames[mealType]), scheduled.Format("15:04")),
+		Text:        text,
+		Restaurants: picks,
+		Time:        time.Now(),
+	})
+}
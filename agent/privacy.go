@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"meal-agent/config"
+	"meal-agent/tools"
+)
+
+// 经纬度（小数点后至少 3 位，如 39.9087,116.3975）
+var coordinatePattern = regexp.MustCompile(`-?\d{1,3}\.\d{3,}\s*[,，]\s*-?\d{1,3}\.\d{3,}`)
+
+// 带门牌号的地址，如 建国路88号、三里屯路19号院3号楼
+var streetAddressPattern = regexp.MustCompile(`[\p{Han}A-Za-z0-9]{0,12}?(?:大街|大道|路|街|道|巷|胡同|弄|里)\d+(?:-\d+)?号(?:院)?(?:\d+号楼|\d+栋)?`)
+
+// PrivacyFilter 隐私模式：发给 LLM 的内容去掉准确的地址、电话和坐标
+// 候选餐厅的地址换成距离和步行时间（如 "350米, 步行5分钟"），配置的位置地址换成 "用户所在位置"，
+// 其他地址、电话和坐标换成 "（地址已隐藏）" 等
+type PrivacyFilter struct {
+	locations []string // 配置中各位置的地址
+}
+
+// NewPrivacyFilter 根据配置创建过滤器，未启用时返回 nil
+func NewPrivacyFilter(cfg *config.Config) *PrivacyFilter {
+	if !cfg.LLM.Privacy {
+		return nil
+	}
+	f := &PrivacyFilter{}
+	if cfg.Location.Address != "" {
+		f.locations = append(f.locations, cfg.Location.Address)
+	}
+	for _, loc := range cfg.Locations {
+		if loc.Address != "" {
+			f.locations = append(f.locations, loc.Address)
+		}
+	}
+	return f
+}
+
+// Redact 替换 text 中的地址、电话和坐标；restaurants 为当前的候选餐厅，其地址换成相对位置
+func (f *PrivacyFilter) Redact(text string, restaurants []tools.Restaurant) string {
+	if f == nil {
+		return text
+	}
+
+	// 先替换已知的地址（长的在前，避免只替换掉一部分），再按格式替换其余的
+	type replacement struct{ old, new string }
+	var known []replacement
+	for _, r := range restaurants {
+		if r.Address != "" {
+			known = append(known, replacement{r.Address, relativePosition(r)})
+		}
+	}
+	for _, addr := range f.locations {
+		known = append(known, replacement{addr, "用户所在位置"})
+	}
+	sort.SliceStable(known, func(i, j int) bool { return len(known[i].old) > len(known[j].old) })
+	for _, k := range known {
+		text = strings.ReplaceAll(text, k.old, k.new)
+	}
+
+	text = coordinatePattern.ReplaceAllString(text, "（坐标已隐藏）")
+	text = streetAddressPattern.ReplaceAllStringFunc(text, func(addr string) string {
+		// 匹配可能从 "我在"、"住" 等开始，保留到其中最后一个介词为止
+		keep := 0
+		for _, word := range []string{"在", "到", "去", "于", "住"} {
+			if i := strings.LastIndex(addr, word); i >= 0 && i+len(word) > keep {
+				keep = i + len(word)
+			}
+		}
+		return addr[:keep] + "（地址已隐藏）"
+	})
+	return phonePattern.ReplaceAllString(text, "（电话已隐藏）")
+}
+
+// relativePosition 餐厅相对用户的位置，如 "350米, 步行5分钟"
+func relativePosition(r tools.Restaurant) string {
+	var parts []string
+	if r.Distance != "" {
+		parts = append(parts, r.Distance+"米")
+	}
+	if r.WalkMinutes > 0 {
+		parts = append(parts, fmt.Sprintf("步行%d分钟", r.WalkMinutes))
+	}
+	if len(parts) == 0 {
+		return "附近"
+	}
+	return strings.Join(parts, ", ")
+}
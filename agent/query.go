@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"meal-agent/memory"
+	"meal-agent/tools"
+)
+
+// SearchRestaurants 附近的候选餐厅，按偏好、历史和 mealTime 的天气排序（与推荐使用相同的过滤和权重），keyword 为空时搜索所有餐饮
+// 不调用 LLM，也不改变对话上下文
+func (a *MealAgent) SearchRestaurants(mealType, keyword string, mealTime time.Time) ([]tools.Restaurant, error) {
+	restaurants, _, err := a.rankedAt(mealType, keyword, mealTime)
+	return restaurants, err
+}
+
+// Weather 用餐时间的天气（提前半小时以上时使用预报）
+func (a *MealAgent) Weather(mealTime time.Time) (*tools.WeatherInfo, error) {
+	info := a.weatherAt(mealTime)
+	if info == nil {
+		return nil, fmt.Errorf("获取天气失败")
+	}
+	return info, nil
+}
+
+// FindMeals 按条件搜索用餐记录（包含归档），最近的在前
+func (a *MealAgent) FindMeals(f memory.Filter) ([]memory.MealRecord, error) {
+	return a.history.Search(f)
+}
+
+// rankedAt 搜索并排序候选餐厅，标注营业状态并按配置过滤已打烊的；天气获取失败时按未知天气排序
+func (a *MealAgent) rankedAt(mealType, keyword string, mealTime time.Time) ([]tools.Restaurant, *tools.WeatherInfo, error) {
+	nearby, err := a.searchNearby(a.searchRadius(), keyword)
+	if err != nil {
+		return nil, nil, fmt.Errorf("搜索餐厅失败: %v", err)
+	}
+	weather := a.weatherAt(mealTime)
+	if weather == nil {
+		weather = &tools.WeatherInfo{Text: "未知", Temp: "20"}
+	}
+
+	restaurants, _ := a.findCandidates(mealType, keyword, mealTime, nearby, weather)
+	tools.MarkOpenStatus(restaurants, mealTime)
+	if a.cfg.Filters.OpenNow {
+		restaurants = tools.FilterClosed(restaurants)
+	}
+	return restaurants, weather, nil
+}
@@ -0,0 +1,417 @@
+package agent
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/tools"
+)
+
+// findCandidates 过滤并排序候选餐厅
+// nearby 是基础半径内已搜索到的餐厅；过滤后候选少于 search.min_candidates 时，
+// 依次按 1.5 倍、2 倍半径重新搜索（不超过 search.max_radius）
+// 配置了公司食堂时，当天的食堂作为距离为 0 的候选一起排序
+// 返回候选列表和最终使用的搜索半径
+func (a *MealAgent) findCandidates(mealType, keyword string, mealTime time.Time, nearby []tools.Restaurant, weather *tools.WeatherInfo) ([]tools.Restaurant, int) {
+	base := a.searchRadius()
+	canteen := a.canteenCandidate(mealType, keyword, mealTime)
+	restaurants := a.rankCandidates(mealType, mealTime, withCanteen(nearby, canteen), weather)
+	radius := base
+
+	for _, factor := range []float64{1.5, 2} {
+		if len(restaurants) >= a.cfg.Search.MinCandidates {
+			break
+		}
+
+		r := int(float64(base) * factor)
+		if r > a.cfg.Search.MaxRadius {
+			break
+		}
+
+		expanded, err := a.searchNearby(r, keyword)
+		if err != nil {
+			break // 扩大范围失败时使用已有结果
+		}
+		restaurants, radius = a.rankCandidates(mealType, mealTime, withCanteen(expanded, canteen), weather), r
+	}
+
+	// 到期的常吃餐厅排到首位（指定了口味时不强制）
+	a.favoriteNote = ""
+	if keyword == "" {
+		restaurants = a.promoteDueFavorite(restaurants, mealTime)
+	}
+
+	return restaurants, radius
+}
+
+// rankCandidates 过滤搜索结果并按权重排序，mealTime 为预计用餐时间（按时间生效的偏好规则使用）
+func (a *MealAgent) rankCandidates(mealType string, mealTime time.Time, restaurants []tools.Restaurant, weatherInfo *tools.WeatherInfo) []tools.Restaurant {
+	// 统一菜系分类，后续的排除、偏好匹配都基于它
+	tools.NormalizeCuisines(restaurants)
+
+	// 1. 过滤黑名单（按餐厅名称）
+	allBlacklist := append([]string{}, a.cfg.Blacklist...)
+	allBlacklist = append(allBlacklist, a.cfg.TempExclude...)
+	restaurants = tools.FilterByBlacklist(restaurants, allBlacklist)
+
+	// "换一批"换掉的餐厅（按完整名称，分店名中的括号不能当成正则）
+	if len(a.skipped) > 0 {
+		restaurants = a.withoutSkipped(restaurants)
+	}
+
+	// 2. 过滤排除的类型（按餐厅类型关键词）
+	if len(a.tempExclude) > 0 {
+		restaurants = tools.FilterByType(restaurants, a.tempExclude)
+	}
+
+	// 健康状况不适合的菜系（如痛风不推荐海鲜）
+	if exclude := a.health.Exclude(); len(exclude) > 0 {
+		restaurants = tools.FilterByType(restaurants, exclude)
+	}
+
+	// 连锁店只保留最近的一家分店
+	restaurants = tools.GroupByBrand(restaurants)
+
+	// 外卖模式：不考虑步行，改为估算送达时间和配送费
+	if a.deliveryMode {
+		restaurants = tools.FilterNoDelivery(restaurants)
+		tools.MarkDelivery(restaurants, a.cfg.Delivery.BaseFee)
+	}
+
+	// 3. 计算实际步行时间，按天气收紧可接受的步行时间
+	if wp, ok := a.restaurant.(tools.WalkingTimeProvider); ok && !a.deliveryMode && !a.quota.NearLimit() {
+		loc := a.currentLocation()
+		if err := wp.FillWalkingTimes(loc.Lat, loc.Lng, restaurants); err == nil {
+			restaurants = tools.FilterByWalkTime(restaurants, a.maxWalkMinutes(weatherInfo))
+		}
+	}
+
+	// 补全缺失的评分（评分参与权重计算）
+	a.enrichRatings(restaurants)
+
+	// 为所有餐厅分类（快餐/正餐）
+	tools.ClassifyAllRestaurants(restaurants)
+
+	// 4. 获取本周炒菜类次数
+	thisWeekFullMealCount := a.history.GetThisWeekMealCategoryCount(string(tools.CategoryFullMeal))
+
+	// 5. 计算权重并排序（综合距离、评分、历史等因素）
+	penalties := brandPenalties(a.history.GetAllPenalties())
+	idPenalties := a.history.GetAllIDPenalties()
+	budget := a.budgetFor(mealType)
+	calorieLimit, hasCalorieGoal := a.mealCalorieLimit(mealType, mealTime)
+	severe := weatherInfo.IsSevereWeather()
+	adverse := a.isAdverseWeather(weatherInfo)
+	advice := a.foodRules.Evaluate(weatherInfo)
+	seasonal := a.seasonalFoods()
+	pref := a.rankingPref() // 一起吃饭时为合并后的偏好
+	due := a.dueFavorites(mealTime)
+	for i := range restaurants {
+		// 基础权重 100
+		weight := 100
+		blocked := false // 拉黑、超预算等硬性排除
+
+		// 加上用户偏好权重
+		if pref != nil {
+			prefWeight := pref.GetRestaurantWeightFor(restaurants[i].ID, restaurants[i].Name)
+			if prefWeight == 0 {
+				// 权重为0表示黑名单，跳过
+				weight = 0
+				blocked = true
+			} else {
+				weight = prefWeight
+			}
+			// 加上菜系偏好
+			catWeight := pref.GetCuisineWeight(restaurants[i].Cuisine, restaurants[i].Type)
+			if catWeight != 100 {
+				weight = weight * catWeight / 100
+			}
+			blocked = blocked || catWeight == 0
+			// 临时偏好（如减脂期间沙拉 x2、炸鸡为 0），过期的不生效
+			if weight > 0 {
+				var excluded bool
+				weight, excluded = pref.ApplyOverrides(weight, mealTime, restaurants[i].Cuisine, restaurants[i].Type, restaurants[i].Name)
+				blocked = blocked || excluded
+			}
+			// 按餐次、星期、季节生效的规则（如周五晚餐烧烤加分；黑名单不受影响）
+			if weight > 0 {
+				weight += pref.TimeBoost(mealType, mealTime, restaurants[i].Cuisine, restaurants[i].Type, restaurants[i].Name)
+				// 口味偏好（如不吃辣时川菜、火锅降权，偏爱清淡时粤菜加分）
+				weight += pref.TasteBoost(restaurants[i].Cuisine, mealTime)
+			}
+		}
+
+		// 食堂按 canteen.weight 调整（黑名单不受影响）
+		if restaurants[i].IsCanteen() {
+			weight = weight * a.cfg.Canteen.Weight / 100
+		}
+
+		// 加上根据评分和选择学到的调整（黑名单不受影响）
+		if weight > 0 {
+			weight += a.learner.Adjustment(restaurants[i].ID, restaurants[i].Name)
+		}
+
+		// 减去历史惩罚（最近吃过的降权，同品牌的任意分店都算；按 ID 匹配兼容改名）
+		penalty := penalties[tools.BrandName(restaurants[i].Name)]
+		if idPenalty, ok := idPenalties[restaurants[i].ID]; ok && idPenalty < penalty {
+			penalty = idPenalty
+		}
+		weight += penalty
+
+		// === 距离因素（平衡权重，不再让近距离主导） ===
+		if minutes := restaurants[i].DeliveryMinutes; minutes > 0 {
+			// 外卖模式只看送达时间
+			switch {
+			case minutes <= 25:
+				weight += 10
+			case minutes <= 35:
+				// 正常送达时间，不调整
+			case minutes <= 45:
+				weight -= 10
+			default:
+				weight -= 20
+			}
+		} else if walk := restaurants[i].WalkMinutes; walk > 0 {
+			// 有实际步行时间时优先使用
+			switch {
+			case walk <= 5:
+				weight += 10
+			case walk <= 8:
+				weight += 5
+			case walk <= 15:
+				// 正常步行距离，不调整
+			case walk <= 20:
+				weight -= 10
+			default:
+				weight -= 20
+			}
+		} else {
+			// 距离奖励/惩罚：500m以内轻微加分，500-1000m正常，1000m以上轻微减分
+			dist := restaurants[i].GetDistanceInt()
+			switch {
+			case dist <= 300:
+				weight += 10 // 很近，轻微加分
+			case dist <= 500:
+				weight += 5 // 近，小幅加分
+			case dist <= 1000:
+				// 中等距离，不调整
+			case dist <= 1500:
+				weight -= 10 // 稍远，轻微减分
+			default:
+				weight -= 20 // 较远，减分
+			}
+		}
+
+		// 天气规则对菜系的加减分（如冷天火锅加分）
+		weight += advice.Boost(restaurants[i])
+
+		// 节气、节日相关的餐厅加分（如冬至的饺子馆）
+		if tools.SeasonalMatch(restaurants[i], seasonal) {
+			weight += a.cfg.Seasonal.Boost
+		}
+
+		// 下雨、酷热、严寒时远的餐厅降权
+		weight -= a.weatherDistancePenalty(restaurants[i], adverse)
+
+		// 大雨、重度污染时只有很近的餐厅不额外降权（外卖不受影响）
+		if severe && restaurants[i].DeliveryMinutes == 0 {
+			walk := restaurants[i].WalkMinutes
+			if walk > 5 || (walk == 0 && restaurants[i].GetDistanceInt() > 400) {
+				weight -= 25
+			}
+		}
+
+		// === 评分因素 ===
+		rating := restaurants[i].GetRatingFloat()
+		if rating > 0 {
+			// 评分 4.5+ 加分，4.0以下减分
+			if rating >= 4.5 {
+				weight += 15
+			} else if rating >= 4.0 {
+				weight += 5
+			} else if rating < 3.5 {
+				weight -= 10
+			}
+		}
+
+		// 偏好配置中的表达式规则（如 distance < 300 && rating >= 4.5 加 30 分）
+		if pref != nil {
+			weight += pref.RuleBoost(restaurants[i])
+		}
+
+		// === 预算因素 ===
+		// 超出预算降权，超出 1.5 倍直接排除；人均未知的不调整
+		if budget > 0 {
+			if cost := restaurants[i].GetCostFloat(); cost > float64(budget)*1.5 {
+				weight = 0
+				blocked = true
+			} else if cost > float64(budget) {
+				weight -= 30
+			}
+		}
+
+		// === 热量目标 ===
+		// 估算热量超出这一餐建议热量的降权（前面几餐吃多了时建议的热量更低）
+		if hasCalorieGoal {
+			weight -= caloriePenalty(restaurants[i], calorieLimit)
+		}
+
+		// === 炒菜类频率限制 ===
+		// 如果本周炒菜类已吃>=2次，大幅降低炒菜类权重
+		if restaurants[i].Category == tools.CategoryFullMeal && thisWeekFullMealCount >= 2 {
+			weight -= 40 // 大幅降权
+		}
+
+		// 到期的常吃餐厅不会因为距离、天气等扣分被过滤掉
+		if weight <= 0 && !blocked && isDueFavorite(due, restaurants[i].Name) {
+			weight = 1
+		}
+
+		restaurants[i].Weight = weight
+		restaurants[i].HealthWarnings = a.health.Warnings(restaurants[i])
+	}
+
+	// 过滤掉权重<=0的餐厅
+	restaurants = tools.FilterByWeight(restaurants)
+
+	// 按权重排序
+	tools.SortByWeight(restaurants)
+
+	return restaurants
+}
+
+// brandPenalties 把按餐厅名称的历史惩罚合并到品牌上（取最重的惩罚）
+func brandPenalties(penalties map[string]int) map[string]int {
+	byBrand := make(map[string]int, len(penalties))
+	for name, penalty := range penalties {
+		brand := tools.BrandName(name)
+		if existing, ok := byBrand[brand]; !ok || penalty < existing {
+			byBrand[brand] = penalty
+		}
+	}
+	return byBrand
+}
+
+// isAdverseWeather 按配置的阈值判断是否下雨、酷热或严寒
+func (a *MealAgent) isAdverseWeather(w *tools.WeatherInfo) bool {
+	if w == nil || w.Text == "未知" {
+		return false
+	}
+	rules := a.cfg.Weather
+	if strings.Contains(w.Text, "雨") || strings.Contains(w.Text, "雪") || w.PopValue() >= rules.RainPop {
+		return true
+	}
+
+	temp, err := strconv.Atoi(w.Temp)
+	if err != nil {
+		return false
+	}
+	return temp >= rules.HotTemp || temp <= rules.ColdTemp
+}
+
+// weatherDistancePenalty 天气不好时远的餐厅的降权分数（外卖不受影响）
+// 有步行时间时按每分钟 80 米折算成距离
+func (a *MealAgent) weatherDistancePenalty(r tools.Restaurant, adverse bool) int {
+	rules := a.cfg.Weather
+	if !adverse || rules.FarPenalty <= 0 || r.DeliveryMinutes > 0 {
+		return 0
+	}
+
+	dist := r.GetDistanceInt()
+	if r.WalkMinutes > 0 {
+		dist = r.WalkMinutes * 80
+	}
+	switch {
+	case dist > rules.FarDistance*2:
+		return rules.FarPenalty * 2
+	case dist > rules.FarDistance:
+		return rules.FarPenalty
+	}
+	return 0
+}
+
+// seasonalFoods 今天的节气、节日饮食习俗（未启用时为空）
+func (a *MealAgent) seasonalFoods() []tools.SeasonalFood {
+	if !a.cfg.Seasonal.IsEnabled() {
+		return nil
+	}
+	return tools.SeasonalFoodsOn(time.Now())
+}
+
+// searchNearby 搜索附近餐厅（优先使用缓存）
+// 调用量接近配额时只使用缓存（包括已过期的），接口失败时也用过期缓存兜底
+func (a *MealAgent) searchNearby(radius int, keyword string) ([]tools.Restaurant, error) {
+	loc := a.currentLocation()
+	if cached, ok := a.cache.Get(loc.Lat, loc.Lng, radius, keyword); ok {
+		return cached, nil
+	}
+	if a.quota.NearLimit() {
+		if cached, ok := a.cache.GetStale(loc.Lat, loc.Lng, radius, keyword); ok {
+			return cached, nil
+		}
+	}
+
+	restaurants, err := a.restaurant.SearchNearby(loc.Lat, loc.Lng, radius, keyword)
+	if err != nil {
+		if cached, ok := a.cache.GetStale(loc.Lat, loc.Lng, radius, keyword); ok {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	a.cache.Put(loc.Lat, loc.Lng, radius, keyword, restaurants)
+	return restaurants, nil
+}
+
+// searchRadius 本次搜索的基础半径（外卖模式使用配送范围）
+func (a *MealAgent) searchRadius() int {
+	if a.deliveryMode {
+		return a.cfg.Delivery.MaxDistance
+	}
+	return a.currentLocation().Radius
+}
+
+// budgetFor 返回本次推荐的人均预算（对话中临时设置的优先）
+func (a *MealAgent) budgetFor(mealType string) int {
+	if a.budgetOverride > 0 {
+		return a.budgetOverride
+	}
+	return a.cfg.Budget.MaxFor(mealType)
+}
+
+// maxWalkMinutes 计算本次可接受的最长步行时间
+// 天气不好时缩短为配置值的 2/3，大雨或重度污染时缩短为 1/2（至少 5 分钟）
+func (a *MealAgent) maxWalkMinutes(weather *tools.WeatherInfo) int {
+	limit := a.cfg.Filters.MaxWalkMinutes
+	if limit <= 0 {
+		return 0
+	}
+	if weather.IsSevereWeather() {
+		limit = limit / 2
+		if limit < 5 {
+			limit = 5
+		}
+	} else if weather.IsBadWeather() {
+		limit = limit * 2 / 3
+		if limit < 5 {
+			limit = 5
+		}
+	}
+	return limit
+}
+
+// withoutSkipped 去掉本次对话中"换一批"换掉的餐厅
+func (a *MealAgent) withoutSkipped(restaurants []tools.Restaurant) []tools.Restaurant {
+	skipped := make(map[string]bool, len(a.skipped))
+	for _, name := range a.skipped {
+		skipped[name] = true
+	}
+	filtered := make([]tools.Restaurant, 0, len(restaurants))
+	for _, r := range restaurants {
+		if !skipped[r.Name] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
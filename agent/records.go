@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+)
+
+// 修改记录的对话表达："撤销"、"记错了撤销"、"删除今天中午的记录"、"昨天晚上的记录改成海底捞"
+var (
+	// 撤销要整句匹配，"撤销拉黑海底捞"等其他带"撤销"的话交给偏好等后面的处理
+	undoRecordPattern   = regexp.MustCompile(`^(?:记错了[，,]?\s*)?撤销(?:上一条|刚才的|最近一条)?(?:用餐)?(?:记录)?[。！!]?$`)
+	deleteRecordPattern = regexp.MustCompile(`删除?(今天|昨天|前天)?(中午|午餐|午饭|晚上|晚餐|晚饭)?的?(用餐)?记录`)
+	updateRecordPattern = regexp.MustCompile(`(今天|昨天|前天)?(中午|午餐|午饭|晚上|晚餐|晚饭)(的记录|记录|吃的)(改成|应该是)(.+)`)
+	// 评分的对话表达："这顿打5分"、"给海底捞评2分"
+	ratePattern       = regexp.MustCompile(`(?:打|评)了?\s*([1-5])\s*(?:分|星)`)
+	rateTargetPattern = regexp.MustCompile(`给(.+?)(?:打|评)`)
+	// 查询历史的对话表达："我上次吃那家泰国菜是什么时候"、"最近一次去海底捞是哪天"
+	lastMealPattern = regexp.MustCompile(`(?:上次|上一次|最近一次)(?:去吃|去|吃)(?:那家|这家|的)?(.+?)(?:是|在)?(?:什么时候|哪天|哪一天|几号)`)
+	// 记录命令中的备注、照片和评分："备注:辣度刚好"（到行尾）、"照片:/path/a.jpg"、"评分:5"
+	notePattern   = regexp.MustCompile(`(?:备注|note)[:：]\s*(.*)$`)
+	photoPattern  = regexp.MustCompile(`(?:照片|图片|photo)[:：]\s*(\S+)`)
+	ratingPattern = regexp.MustCompile(`(?:评分|rating)[:：]\s*([1-5])`)
+)
+
+var mealTypeNames = map[string]string{"lunch": "午餐", "dinner": "晚餐"}
+
+// parseRecordEdit 处理撤销、删除、修改、评分用餐记录的对话，handled 为 false 表示不是这类请求
+func (a *MealAgent) parseRecordEdit(input string) (reply string, handled bool) {
+	if undoRecordPattern.MatchString(strings.TrimSpace(input)) {
+		record, err := a.history.UndoLast()
+		if err != nil {
+			return "没有可以撤销的用餐记录", true
+		}
+		return fmt.Sprintf("已撤销记录：%s", describeRecord(*record)), true
+	}
+
+	if m := ratePattern.FindStringSubmatch(input); m != nil {
+		return a.rateRecord(input, int(m[1][0]-'0')), true
+	}
+
+	if m := updateRecordPattern.FindStringSubmatch(input); m != nil {
+		restaurant := strings.Trim(strings.TrimSpace(m[5]), "了。！!")
+		if restaurant == "" {
+			return "", false
+		}
+		record, ok := a.findRecord(m[1], m[2])
+		if !ok {
+			return "没有找到这顿饭的记录", true
+		}
+		old := record.Restaurant
+		record.Restaurant = restaurant
+		record.RestaurantID = "" // 换了餐厅，原来的 POI ID 和菜系不再适用
+		record.Category = ""
+		record.Nutrition = a.estimateNutrition(record)
+		if err := a.history.Update(record.ID, record); err != nil {
+			return fmt.Sprintf("修改记录失败: %v", err), true
+		}
+		return fmt.Sprintf("已把%s的记录从「%s」改成「%s」", recordWhen(record), old, restaurant), true
+	}
+
+	if m := deleteRecordPattern.FindStringSubmatch(input); m != nil {
+		record, ok := a.findRecord(m[1], m[2])
+		if !ok {
+			return "没有找到这顿饭的记录", true
+		}
+		if err := a.history.Delete(record.ID); err != nil {
+			if errors.Is(err, memory.ErrRecordNotFound) {
+				return "没有找到这顿饭的记录", true
+			}
+			return fmt.Sprintf("删除记录失败: %v", err), true
+		}
+		return fmt.Sprintf("已删除记录：%s", describeRecord(record)), true
+	}
+
+	return "", false
+}
+
+// rateRecord 给用餐记录评分：说了餐厅名时评最近一次去这家的记录，否则评最近一条记录
+func (a *MealAgent) rateRecord(input string, rating int) string {
+	filter := memory.Filter{}
+	if m := rateTargetPattern.FindStringSubmatch(input); m != nil {
+		if name := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(m[1], "这顿"), "那顿")); name != "" {
+			filter.Restaurant = name
+		}
+	}
+
+	records := a.history.Find(filter)
+	if len(records) == 0 {
+		return "没有找到要评分的用餐记录"
+	}
+	record := records[0]
+	record.Rating = rating
+	if err := a.history.Update(record.ID, record); err != nil {
+		return fmt.Sprintf("评分失败: %v", err)
+	}
+	a.learnRating(record)
+	return fmt.Sprintf("已给 %s 打 %d 分", describeRecord(record), rating)
+}
+
+// findRecord 按"今天/昨天/前天"和"中午/晚上"查找最近的一条记录
+// 没说哪天时默认今天，没说餐次时取那天最后一条
+func (a *MealAgent) findRecord(day, meal string) (memory.MealRecord, bool) {
+	offset := map[string]int{"": 0, "今天": 0, "昨天": -1, "前天": -2}[day]
+	date := time.Now().AddDate(0, 0, offset).Format("2006-01-02")
+
+	mealType := ""
+	switch meal {
+	case "中午", "午餐", "午饭":
+		mealType = "lunch"
+	case "晚上", "晚餐", "晚饭":
+		mealType = "dinner"
+	}
+
+	records := a.history.Find(memory.Filter{Period: memory.Period{From: date, To: date}, MealType: mealType})
+	if len(records) == 0 {
+		return memory.MealRecord{}, false
+	}
+	return records[0], true
+}
+
+// parseHistoryQuery 回答"上次吃某家/某类是什么时候"，handled 为 false 表示不是这类问题
+// 关键词同时匹配餐厅名、菜系和备注，已归档的记录也会查找
+func (a *MealAgent) parseHistoryQuery(input string) (reply string, handled bool) {
+	m := lastMealPattern.FindStringSubmatch(input)
+	if m == nil {
+		return "", false
+	}
+	keyword := strings.TrimSpace(m[1])
+	if keyword == "" {
+		return "", false
+	}
+
+	records, err := a.history.Search(memory.Filter{Keyword: keyword})
+	if err != nil {
+		return fmt.Sprintf("查询用餐记录失败: %v", err), true
+	}
+	if len(records) == 0 {
+		return fmt.Sprintf("没有找到吃%s的记录", keyword), true
+	}
+
+	last := records[0]
+	reply = fmt.Sprintf("上次吃%s是 %s", keyword, describeRecord(last))
+	if last.Category != "" && !strings.Contains(last.Restaurant, last.Category) {
+		reply += "（" + last.Category + "）"
+	}
+	if last.Note != "" {
+		reply += "，备注：" + last.Note
+	}
+	if len(records) > 1 {
+		reply += fmt.Sprintf("。一共吃过 %d 次", len(records))
+	}
+	return reply, true
+}
+
+// SearchHistory 按关键词搜索用餐记录（匹配餐厅名、菜系和备注，包含归档），返回可直接展示的结果
+func (a *MealAgent) SearchHistory(keyword string) (string, error) {
+	records, err := a.history.Search(memory.Filter{Keyword: keyword})
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return fmt.Sprintf("没有找到包含「%s」的用餐记录", keyword), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "找到 %d 条包含「%s」的用餐记录：\n", len(records), keyword)
+	for _, r := range records {
+		sb.WriteString("- " + describeRecord(r))
+		if r.Category != "" {
+			sb.WriteString("（" + r.Category + "）")
+		}
+		if r.Rating > 0 {
+			fmt.Fprintf(&sb, " 评分%d", r.Rating)
+		}
+		if r.Note != "" {
+			sb.WriteString(" 备注：" + r.Note)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// ParseRecord 解析记录命令的参数："餐厅名 [类型] [金额] [评分:1-5] [备注:内容] [照片:路径或链接]"，金额是最后一个数字参数
+func ParseRecord(args string) (memory.MealRecord, error) {
+	var record memory.MealRecord
+	if m := ratingPattern.FindStringSubmatch(args); m != nil {
+		record.Rating, _ = strconv.Atoi(m[1])
+		args = strings.Replace(args, m[0], " ", 1)
+	}
+	if m := photoPattern.FindStringSubmatch(args); m != nil {
+		record.Photo = m[1]
+		args = strings.Replace(args, m[0], " ", 1)
+	}
+	if m := notePattern.FindStringSubmatch(args); m != nil {
+		record.Note = strings.TrimSpace(m[1])
+		args = strings.Replace(args, m[0], " ", 1)
+	}
+
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		return record, errors.New("请输入餐厅名称，例如: 记录 海底捞 火锅 138")
+	}
+	if n := len(parts); n >= 2 {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(parts[n-1], "元"), 64); err == nil && v >= 0 {
+			record.Amount = v
+			parts = parts[:n-1]
+		}
+	}
+	record.Restaurant = parts[0]
+	if len(parts) >= 2 {
+		record.Category = parts[1]
+	}
+	return record, nil
+}
+
+// RecordSummary 记录命令的回复，如 "已记录本次用餐: 海底捞（火锅），花费 138 元"
+func RecordSummary(r memory.MealRecord) string {
+	var sb strings.Builder
+	sb.WriteString("已记录本次用餐: " + r.Restaurant)
+	if r.Category != "" {
+		sb.WriteString("（" + r.Category + "）")
+	}
+	if r.Amount > 0 {
+		sb.WriteString(fmt.Sprintf("，花费 %.0f 元", r.Amount))
+	}
+	if r.Rating > 0 {
+		sb.WriteString(fmt.Sprintf("，评分 %d", r.Rating))
+	}
+	if r.Note != "" {
+		sb.WriteString("，备注：" + r.Note)
+	}
+	if r.Photo != "" {
+		sb.WriteString("，已附照片")
+	}
+	return sb.String()
+}
+
+// describeRecord 记录的简短描述，如 "2024-01-15 午餐 海底捞"
+func describeRecord(r memory.MealRecord) string {
+	return fmt.Sprintf("%s %s", recordWhen(r), r.Restaurant)
+}
+
+func recordWhen(r memory.MealRecord) string {
+	if name, ok := mealTypeNames[r.MealType]; ok {
+		return r.Date + " " + name
+	}
+	return r.Date
+}
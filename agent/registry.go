@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"meal-agent/logging"
+)
+
+// Tool 代理可以调用的工具：内置的天气、餐厅搜索和用餐记录，以及其他包注册的工具（如公司食堂菜单、日历忙闲）
+// 工具只查询信息，不修改用餐记录和偏好；开启 llm.tools 后 LLM 在推荐和对话中按需调用，MCP 服务也会提供
+type Tool interface {
+	Name() string                                               // 名称，如 get_weather（字母、数字和下划线）
+	Description() string                                        // 给模型看的说明：什么时候用、返回什么
+	Schema() map[string]interface{}                             // 参数的 JSON Schema（type 为 object）
+	Execute(a *MealAgent, args json.RawMessage) (string, error) // 返回给模型的文字，数据建议用 JSON
+}
+
+// FuncTool 用函数实现的工具
+type FuncTool struct {
+	ToolName        string
+	ToolDescription string
+	Parameters      map[string]interface{} // 为 nil 时没有参数
+	Run             func(a *MealAgent, args json.RawMessage) (string, error)
+}
+
+func (t *FuncTool) Name() string        { return t.ToolName }
+func (t *FuncTool) Description() string { return t.ToolDescription }
+
+func (t *FuncTool) Schema() map[string]interface{} {
+	if t.Parameters == nil {
+		return objectSchema(map[string]interface{}{})
+	}
+	return t.Parameters
+}
+
+func (t *FuncTool) Execute(a *MealAgent, args json.RawMessage) (string, error) {
+	return t.Run(a, args)
+}
+
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+var (
+	toolsMu  sync.RWMutex
+	registry = make(map[string]Tool)
+)
+
+// RegisterTool 注册工具，所有 Agent 共用（在 init 中调用，不需要修改 agent.go）
+// 名称格式不对或重复时 panic
+func RegisterTool(t Tool) {
+	name := t.Name()
+	if !toolNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("agent: 工具名称只能包含字母、数字和下划线: %q", name))
+	}
+	toolsMu.Lock()
+	defer toolsMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("agent: 重复注册工具 " + name)
+	}
+	registry[name] = t
+}
+
+// UnregisterTool 删除注册的工具（插件重新加载时使用），没有时不做什么
+func UnregisterTool(name string) {
+	toolsMu.Lock()
+	defer toolsMu.Unlock()
+	delete(registry, name)
+}
+
+// Tools 已注册的工具，按名称排序
+func Tools() []Tool {
+	toolsMu.RLock()
+	defer toolsMu.RUnlock()
+	list := make([]Tool, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// LookupTool 按名称查找工具
+func LookupTool(name string) (Tool, bool) {
+	toolsMu.RLock()
+	defer toolsMu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// CallTool 执行已注册的工具，参数为空时按 {} 处理
+func (a *MealAgent) CallTool(name string, args json.RawMessage) (string, error) {
+	t, ok := LookupTool(name)
+	if !ok {
+		return "", fmt.Errorf("未知的工具: %s", name)
+	}
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	return t.Execute(a, args)
+}
+
+func objectSchema(props map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func intProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "description": description}
+}
+
+func mealTypeProp() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": []string{"lunch", "dinner"}, "description": "午餐或晚餐（默认按当前时间）"}
+}
+
+// maxToolRounds 一次回复中模型最多连续请求几轮工具
+const maxToolRounds = 5
+
+// complete 请求 LLM 回复；开启 llm.tools 且模型支持时可以调用已注册的工具
+// 工具调用和结果只在这次请求中发送，不计入对话上下文
+func (a *MealAgent) complete(messages []Message) (string, error) {
+	tl, ok := a.llm.(ToolLLM)
+	registered := Tools()
+	if !a.cfg.LLM.Tools || !ok || len(registered) == 0 {
+		return a.llm.Chat(messages)
+	}
+
+	messages = append([]Message(nil), messages...)
+	for round := 0; round < maxToolRounds; round++ {
+		reply, err := tl.ChatWithTools(messages, registered)
+		if err != nil {
+			return "", err
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+		messages = append(messages, reply)
+		for _, call := range reply.ToolCalls {
+			start := time.Now()
+			result, err := a.CallTool(call.Function.Name, json.RawMessage(call.Function.Arguments))
+			logging.Module("agent").Debug("调用工具", "tool", call.Function.Name,
+				"latency_ms", time.Since(start).Milliseconds(), "err", err)
+			if err != nil {
+				// 失败原因交给模型，由它决定换个参数或直接回答
+				result = "调用失败: " + err.Error()
+			}
+			messages = append(messages, Message{
+				Role:       "tool",
+				ToolCallID: call.ID,
+				Content:    a.privacy.Redact(result, a.lastRestaurants),
+			})
+		}
+	}
+	return "", fmt.Errorf("模型连续调用工具超过 %d 轮", maxToolRounds)
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"meal-agent/config"
+	"meal-agent/preference"
+)
+
+// Reload 替换为重新加载的配置和偏好（修改 config.yaml、restaurants.yaml 后不需要重启）
+// 按新配置重新创建天气、餐厅、LLM 等客户端；对话上下文和当天的临时排除保留
+// 与推荐不是并发安全的，后台模式下通过 Scheduler.Reload 在调度协程中执行
+func (a *MealAgent) Reload(cfg *config.Config, pref *preference.Preferences, profiles map[string]*preference.Preferences) {
+	for _, name := range a.cfg.TempExclude {
+		if !cfg.IsBlacklisted(name) {
+			cfg.AddTempExclude(name)
+		}
+	}
+
+	fresh := NewMealAgentWithProviders(cfg, a.history, pref, a.providers)
+	a.cfg = cfg
+	a.llm = fresh.llm
+	a.weather = fresh.weather
+	a.restaurant = fresh.restaurant
+	a.cache = fresh.cache
+	a.quota = fresh.quota
+	a.ratings = fresh.ratings
+	a.canteen = fresh.canteen
+	a.safety = fresh.safety
+	a.privacy = fresh.privacy
+	a.foodRules = fresh.foodRules
+	a.health = fresh.health
+	a.pref = pref
+	a.profiles = profiles
+	a.SetDeliveryMode(a.deliveryMode)
+
+	// 当前位置在新配置中已删除时回到默认位置
+	if a.location != "" {
+		if err := a.SetLocation(a.location); err != nil {
+			a.location = ""
+		}
+	}
+
+	// 一起吃饭时按新的偏好重新合并，有人的偏好不在了就退出
+	if a.group != nil {
+		if err := a.StartGroup(a.group); err != nil {
+			a.EndGroup()
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/notify"
+)
+
+// 稍后提醒的对话表达："过20分钟再提醒我"、"半小时后提醒"、"稍后提醒"
+var (
+	snoozeMinutesPattern = regexp.MustCompile(`(\d+|[一二两三四五六十]+)\s*(分钟|小时|个小时)`)
+	snoozeTriggers       = []string{"提醒", "snooze"}
+	dismissWords         = []string{"不用提醒", "别提醒", "不要提醒", "吃过了", "已经吃了"}
+)
+
+var chineseNumbers = map[string]int{
+	"一": 1, "二": 2, "两": 2, "三": 3, "四": 4, "五": 5, "六": 6,
+	"十": 10, "二十": 20, "三十": 30, "四十": 40, "五十": 50, "六十": 60,
+}
+
+// pendingMeal 已推送提醒、还没有记录的一餐
+type pendingMeal struct {
+	mealType     string
+	date         string              // 提醒的日期，过了当天不再提醒
+	notification notify.Notification // 上次推送的提醒（再提醒时重新推送，为空表示还没推荐过）
+	next         time.Time           // 下次再提醒的时间，零值表示不再提醒
+	reminded     int                 // 已自动再提醒的次数
+	snoozed      bool                // next 是要求稍后提醒的时间（不计入次数限制）
+}
+
+// ParseSnooze 解析稍后提醒的对话，返回推迟的时间；没说时间（"稍后提醒"）时 d 为 0，使用配置的默认值
+// dismiss 为 true 表示这一餐不再提醒（"吃过了"、"不用提醒了"）
+func ParseSnooze(input string) (d time.Duration, dismiss, ok bool) {
+	for _, w := range dismissWords {
+		if strings.Contains(input, w) {
+			return 0, true, true
+		}
+	}
+	triggered := false
+	for _, t := range snoozeTriggers {
+		if strings.Contains(strings.ToLower(input), t) {
+			triggered = true
+		}
+	}
+	if !triggered {
+		return 0, false, false
+	}
+
+	if strings.Contains(input, "半小时") || strings.Contains(input, "半个小时") {
+		return 30 * time.Minute, false, true
+	}
+	if m := snoozeMinutesPattern.FindStringSubmatch(input); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = chineseNumbers[m[1]]
+		}
+		if n > 0 {
+			if strings.HasSuffix(m[2], "小时") {
+				return time.Duration(n) * time.Hour, false, true
+			}
+			return time.Duration(n) * time.Minute, false, true
+		}
+	}
+	for _, w := range []string{"稍后", "等会", "一会", "待会", "晚点", "snooze"} {
+		if strings.Contains(strings.ToLower(input), w) {
+			return 0, false, true
+		}
+	}
+	return 0, false, false
+}
+
+// Snooze 推迟提醒：d 后再推送这一餐的提醒（还没提醒过时到时重新推荐），d 为 0 时使用配置的默认值
+func (s *Scheduler) Snooze(d time.Duration) string {
+	reply := make(chan string, 1)
+	s.do(func() { reply <- s.snooze(d, time.Now()) })
+	select {
+	case r := <-reply:
+		return r
+	case <-s.stopCh:
+		return ""
+	}
+}
+
+// Dismiss 这一餐不再提醒
+func (s *Scheduler) Dismiss() string {
+	reply := make(chan string, 1)
+	s.do(func() {
+		s.pending = nil
+		reply <- "好的，这一餐不再提醒"
+	})
+	select {
+	case r := <-reply:
+		return r
+	case <-s.stopCh:
+		return ""
+	}
+}
+
+func (s *Scheduler) snooze(d time.Duration, now time.Time) string {
+	if d <= 0 {
+		d = time.Duration(s.schedule.Remind.Snooze) * time.Minute
+	}
+	if d <= 0 {
+		d = 15 * time.Minute
+	}
+	today := now.Format("2006-01-02")
+	if s.pending == nil || s.pending.date != today {
+		mealType := "lunch"
+		if now.Hour() >= 15 {
+			mealType = "dinner"
+		}
+		s.pending = &pendingMeal{mealType: mealType, date: today}
+	}
+	s.pending.next = now.Add(d)
+	s.pending.snoozed = true
+	return fmt.Sprintf("好的，%s 再提醒你%s", s.pending.next.Format("15:04"), mealTypeNames[s.pending.mealType])
+}
+
+// newPending 推送提醒后开始等待记录，开启自动再提醒时设置下次提醒的时间
+func (s *Scheduler) newPending(n notify.Notification) *pendingMeal {
+	p := &pendingMeal{mealType: n.MealType, date: n.Time.Format("2006-01-02"), notification: n}
+	if after := s.schedule.Remind.After; after > 0 {
+		p.next = n.Time.Add(time.Duration(after) * time.Minute)
+	}
+	return p
+}
+
+// remindPending 到了再提醒的时间且这一餐还没有记录时再推送一次，自动再提醒最多 remind.max 次
+func (s *Scheduler) remindPending(now time.Time) {
+	p := s.pending
+	if p == nil || p.next.IsZero() || now.Before(p.next) {
+		return
+	}
+	if p.date != now.Format("2006-01-02") || s.agent.hasMealToday(p.mealType) {
+		s.pending = nil
+		return
+	}
+	if p.notification.Text == "" {
+		// 还没推荐过（提醒前就要求稍后提醒），到时重新推荐
+		s.pending = nil
+		s.triggerRecommendation(p.mealType, now, "")
+		return
+	}
+
+	n := p.notification
+	n.Title = fmt.Sprintf("⏰ 还没吃%s？", mealTypeNames[p.mealType])
+	n.Time = now
+	s.send(n)
+
+	if p.snoozed {
+		p.snoozed = false
+	} else {
+		p.reminded++
+	}
+	p.next = time.Time{}
+	if after := s.schedule.Remind.After; after > 0 && p.reminded < s.schedule.Remind.Max {
+		p.next = now.Add(time.Duration(after) * time.Minute)
+	}
+}
+
+// hasMealToday 今天是否已经记录了这一餐
+func (a *MealAgent) hasMealToday(mealType string) bool {
+	for _, r := range a.history.GetToday() {
+		if r.MealType == mealType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"meal-agent/notify"
+	"meal-agent/tools"
+)
+
+// SetReplyURL 设置回复服务对外的地址，设置后推送的提醒附带快捷回复（"第二个"、"换一批"）的链接，需要在 Start 之前调用
+func (s *Scheduler) SetReplyURL(base string) {
+	s.replyBase = base
+}
+
+// Reply 处理通知中的回复：token 为最近一次推荐的令牌时交给这次推荐的对话，回复的内容同时推送出去
+// 对话上下文已经换成了新的推荐时返回 notify.ErrReplyExpired
+func (s *Scheduler) Reply(token, text string) (string, error) {
+	n, err := s.chat(token, "💬 "+text, text, true, true)
+	return n.Text, err
+}
+
+// Chat 在调度协程中继续最近一次推荐的对话（后台模式下终端中的输入），回复的内容同时推送出去
+func (s *Scheduler) Chat(text string) (string, error) {
+	n, err := s.chat("", "💬 "+text, text, false, true)
+	return n.Text, err
+}
+
+// GroupChat 群聊中的消息（企业微信群机器人），同样接着最近一次推荐的对话
+// 回复的通知由调用方发回群里，不推送到其他渠道；user 为发消息的人
+func (s *Scheduler) GroupChat(user, text string) (notify.Notification, error) {
+	title := "💬 " + text
+	if user != "" {
+		title = "💬 " + user + "：" + text
+	}
+	return s.chat("", title, text, false, false)
+}
+
+func (s *Scheduler) chat(token, title, text string, checkToken, push bool) (notify.Notification, error) {
+	type result struct {
+		n   notify.Notification
+		err error
+	}
+	done := make(chan result, 1)
+	s.do(func() {
+		if checkToken && (s.replyToken == "" || token != s.replyToken) {
+			done <- result{err: notify.ErrReplyExpired}
+			return
+		}
+		n, err := s.replyTo(title, text)
+		if err == nil && push {
+			s.send(n)
+		}
+		done <- result{n, err}
+	})
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-s.stopCh:
+		return notify.Notification{}, fmt.Errorf("调度器已停止")
+	}
+}
+
+// replyTo 把回复交给 Agent，返回回复内容的通知；换了一批推荐时，之后的再提醒推送新的推荐
+func (s *Scheduler) replyTo(title, text string) (notify.Notification, error) {
+	before := s.agent.LastRestaurants()
+	reply, err := s.agent.Chat(text)
+	if err != nil {
+		return notify.Notification{}, err
+	}
+
+	n := notify.Notification{
+		MealType: currentMealType(),
+		Title:    title,
+		Text:     reply,
+		Time:     time.Now(),
+		Token:    s.replyToken,
+		ReplyURL: notify.ReplyURL(s.replyBase, s.replyToken),
+	}
+	if p := s.pending; p != nil {
+		n.MealType = p.mealType
+	}
+	if after := s.agent.LastRestaurants(); !sameRestaurants(before, after) {
+		n.Restaurants = after
+		n.Weather = s.agent.LastWeather()
+		if s.pending != nil && s.pending.notification.Text != "" {
+			s.pending.notification.Text = reply
+			s.pending.notification.Restaurants = after
+		}
+		if len(after) > 0 {
+			s.latest = n
+		}
+	}
+	return n, nil
+}
+
+// sameRestaurants 两次推荐的候选餐厅是否相同
+func sameRestaurants(a, b []tools.Restaurant) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"meal-agent/notify"
+)
+
+// reportKey 提醒记录中每周报告使用的键
+const reportKey = "report"
+
+// dueReport 到了每周报告的时间、这周还没发送过（超过时间 catch_up 分钟以内的补发），scheduled 为报告时间
+func (s *Scheduler) dueReport(now time.Time) (scheduled time.Time, ok bool) {
+	day, at, ok := s.schedule.ReportTime()
+	if !ok || now.Weekday() != day {
+		return time.Time{}, false
+	}
+	scheduled, ok = scheduledAt(now, at)
+	if !ok || now.Before(scheduled) || now.Sub(scheduled) >= time.Minute+s.schedule.CatchUpDuration() {
+		return time.Time{}, false
+	}
+	if !s.fired[reportKey].Before(scheduled) {
+		return time.Time{}, false
+	}
+	return scheduled, true
+}
+
+// sendReport 推送最近 7 天的用餐统计
+func (s *Scheduler) sendReport(now time.Time) {
+	s.markFired(reportKey, now)
+	stats, err := s.agent.GetStats("week")
+	if err != nil {
+		s.reportError(fmt.Errorf("生成每周报告失败: %v", err))
+		return
+	}
+	s.send(notify.Notification{
+		Title:  "📊 本周饮食报告",
+		Text:   stats.Describe(),
+		Time:   now,
+		Report: true,
+	})
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"meal-agent/config"
+)
+
+const defaultRefuseMessage = "我只能帮你解决吃饭相关的问题哦，比如「推荐午餐」「不想吃辣」「就吃第一个」。"
+
+// 与用餐相关的关键词，输入中包含任意一个即视为相关
+var mealKeywords = []string{
+	"吃", "餐", "饭", "菜", "饿", "食", "推荐", "口味", "味道",
+	"辣", "甜", "咸", "酸", "清淡", "油腻", "店", "馆", "外卖",
+	"面", "粉", "汤", "肉", "鱼", "虾", "火锅", "烧烤", "奶茶", "咖啡",
+	"预算", "便宜", "贵", "近", "远", "天气", "换", "第", "这个", "好的",
+	"记录", "撤销", "上次", "评", "顿",
+	"拉黑", "权重", "一起", "聚餐",
+}
+
+// 常见的提示词注入语句，过滤时直接剔除
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)ignore (all )?(the )?(previous|above) (instructions|prompts?)`),
+	regexp.MustCompile(`忽略(之前|以上|上面)的?(所有)?(指令|提示|设定)`),
+	regexp.MustCompile(`(?i)you are now`),
+	regexp.MustCompile(`你现在(是|扮演)`),
+}
+
+// 医疗功效类表述，不允许出现在推荐中
+var medicalClaimWords = []string{
+	"治疗", "治愈", "根治", "药效", "疗效", "抗癌", "防癌",
+	"降血压", "降血糖", "降血脂", "预防疾病", "包治", "医治",
+}
+
+// 手机号和座机号
+var phonePattern = regexp.MustCompile(`1[3-9]\d{9}|0\d{2,3}-?\d{7,8}`)
+
+// 按句子切分（保留标点）
+var sentencePattern = regexp.MustCompile(`[^。！？!?\n]*[。！？!?]?`)
+
+// SafetyFilter 内容安全过滤器
+type SafetyFilter struct {
+	filterInput   bool
+	filterOutput  bool
+	blockedWords  []string
+	refuseMessage string
+}
+
+// NewSafetyFilter 根据配置创建过滤器，未启用时返回 nil
+func NewSafetyFilter(cfg config.SafetyConfig) *SafetyFilter {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	refuse := cfg.RefuseMessage
+	if refuse == "" {
+		refuse = defaultRefuseMessage
+	}
+
+	return &SafetyFilter{
+		filterInput:   cfg.FilterInput,
+		filterOutput:  cfg.FilterOutput,
+		blockedWords:  append(append([]string{}, medicalClaimWords...), cfg.BlockedWords...),
+		refuseMessage: refuse,
+	}
+}
+
+// CheckInput 检查用户输入
+// 返回清理后的输入；如果与用餐无关，ok 为 false，并返回拒绝回复
+func (f *SafetyFilter) CheckInput(input string) (cleaned string, ok bool) {
+	if f == nil || !f.filterInput {
+		return input, true
+	}
+
+	cleaned = input
+	for _, p := range injectionPatterns {
+		cleaned = p.ReplaceAllString(cleaned, "")
+	}
+	cleaned = strings.Trim(cleaned, " \t，,。")
+
+	if cleaned == "" {
+		return f.refuseMessage, false
+	}
+
+	// 很短的输入通常是对上一轮推荐的追问（"嗯"、"还有吗"），直接放行
+	if utf8.RuneCountInString(cleaned) <= 6 {
+		return cleaned, true
+	}
+
+	for _, kw := range mealKeywords {
+		if strings.Contains(cleaned, kw) {
+			return cleaned, true
+		}
+	}
+
+	return f.refuseMessage, false
+}
+
+// SanitizeOutput 清理 LLM 输出
+// knownPhones: 餐厅数据中真实存在的电话，其他号码视为模型编造
+func (f *SafetyFilter) SanitizeOutput(output string, knownPhones []string) string {
+	if f == nil || !f.filterOutput {
+		return output
+	}
+
+	// 去掉包含医疗功效表述的句子
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(output, "\n") {
+		for _, sentence := range sentencePattern.FindAllString(line, -1) {
+			if !f.containsBlocked(sentence) {
+				sb.WriteString(sentence)
+			}
+		}
+		if strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	// 替换不在餐厅数据中的电话号码
+	known := make(map[string]bool)
+	for _, tel := range knownPhones {
+		for _, p := range phonePattern.FindAllString(tel, -1) {
+			known[strings.ReplaceAll(p, "-", "")] = true
+		}
+	}
+
+	return phonePattern.ReplaceAllStringFunc(sb.String(), func(p string) string {
+		if known[strings.ReplaceAll(p, "-", "")] {
+			return p
+		}
+		return "（电话请以地图信息为准）"
+	})
+}
+
+// containsBlocked 检查是否包含屏蔽词
+func (f *SafetyFilter) containsBlocked(text string) bool {
+	for _, w := range f.blockedWords {
+		if strings.Contains(text, w) {
+			return true
+		}
+	}
+	return false
+}
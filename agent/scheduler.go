@@ -4,25 +4,46 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"meal-agent/config"
+	"meal-agent/i18n"
+	"meal-agent/logging"
+	"meal-agent/notify"
+	"meal-agent/preference"
 )
 
 // Scheduler 定时调度器
 type Scheduler struct {
 	agent      *MealAgent
-	lunchTime  string // "11:00"
-	dinnerTime string // "17:00"
+	schedule   config.Schedule // 提醒时间（工作日、周末、节假日）
 	stopCh     chan struct{}
-	notifyCh   chan string // 推送通知的 channel
+	done       chan struct{}       // 调度协程退出后关闭
+	notifier   notify.Notifier     // 推送提醒（可以同时推送到多个地方）
+	errCh      chan error          // 推送失败的错误
+	actionCh   chan func()         // 在调度协程中执行的操作（重新加载、稍后提醒）
+	habitDate  string              // 上次附带习惯提醒的日期（每天只提醒一次）
+	pantryDate string              // 上次附带食材库存提醒的日期（每天只提醒一次）
+	pending    *pendingMeal        // 已提醒、还没记录的一餐（为 nil 表示没有要再提醒的）
+	latest     notify.Notification // 最近一次推荐（对话中换了一批时更新），Home Assistant 传感器使用
+
+	replyBase  string // 回复服务对外的地址（为空表示不接收通知中的回复）
+	replyToken string // 最近一次推荐的回复令牌（Agent 只保留最近一次推荐的对话上下文）
+
+	statePath string               // 提醒记录文件（为空时不保存，重启后无法补发错过的提醒）
+	fired     map[string]time.Time // 餐次 -> 最近一次提醒的时间
 }
 
-// NewScheduler 创建调度器
-func NewScheduler(agent *MealAgent, lunch, dinner string) *Scheduler {
+// NewScheduler 创建调度器，到提醒时间时把推荐推送给 notifier
+func NewScheduler(agent *MealAgent, schedule config.Schedule, notifier notify.Notifier) *Scheduler {
 	return &Scheduler{
-		agent:      agent,
-		lunchTime:  lunch,
-		dinnerTime: dinner,
-		stopCh:     make(chan struct{}),
-		notifyCh:   make(chan string, 10),
+		agent:    agent,
+		schedule: schedule,
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+		notifier: notifier,
+		errCh:    make(chan error, 10),
+		actionCh: make(chan func()),
+		fired:    make(map[string]time.Time),
 	}
 }
 
@@ -36,56 +57,241 @@ func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
-// Notifications 获取通知 channel
-func (s *Scheduler) Notifications() <-chan string {
-	return s.notifyCh
+// Shutdown 停止定时任务，等待正在进行的推荐、推送和记录完成，最多等待 timeout
+// 进行中的外部接口请求由调用方通过 context 取消（见 tools.CancelOnDone），推荐失败时不再推送
+func (s *Scheduler) Shutdown(timeout time.Duration) error {
+	s.Stop()
+	select {
+	case <-s.done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf(i18n.T("等待正在进行的推荐超时（%s），强制退出"), timeout)
+	}
+}
+
+// stopping 是否正在退出
+func (s *Scheduler) stopping() bool {
+	select {
+	case <-s.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reload 替换 Agent 的配置和偏好，提醒时间按新配置调整
+// 在调度协程中执行，正在推荐时等推荐完成后再替换
+func (s *Scheduler) Reload(cfg *config.Config, pref *preference.Preferences, profiles map[string]*preference.Preferences) {
+	s.do(func() {
+		s.agent.Reload(cfg, pref, profiles)
+		s.schedule = cfg.Schedule
+	})
+}
+
+// do 在调度协程中执行 fn，避免与正在进行的推荐同时修改状态
+func (s *Scheduler) do(fn func()) {
+	select {
+	case s.actionCh <- fn:
+	case <-s.stopCh:
+	}
+}
+
+// Errors 推送失败的错误
+func (s *Scheduler) Errors() <-chan error {
+	return s.errCh
 }
 
 func (s *Scheduler) run() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	defer close(s.done)
 
 	// 每天清空临时排除
 	lastDate := time.Now().Format("2006-01-02")
 
+	// 启动时先检查一次，补发停机期间错过的提醒
+	s.checkDue(time.Now())
+
+	timer := time.NewTimer(s.untilNext(time.Now()))
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-s.stopCh:
 			return
-		case <-ticker.C:
+		case fn := <-s.actionCh:
+			fn()
+			// 提醒时间或稍后提醒可能变了，重新计算
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-timer.C:
 			now := time.Now()
-			currentTime := now.Format("15:04")
 			currentDate := now.Format("2006-01-02")
 
 			// 新的一天，清空临时排除
 			if currentDate != lastDate {
 				s.agent.cfg.ClearTempExclude()
 				s.agent.Reset()
+				s.replyToken = ""
 				lastDate = currentDate
 			}
 
-			// 检查是否到了提醒时间
-			if currentTime == s.lunchTime {
-				s.triggerRecommendation("lunch")
-			} else if currentTime == s.dinnerTime {
-				s.triggerRecommendation("dinner")
+			s.checkDue(now)
+		}
+		timer.Reset(s.untilNext(time.Now()))
+	}
+}
+
+// maxSleep 最长等待时间：电脑睡眠时计时器也会暂停，定期按实际时间重新计算，醒来后能及时补发
+const maxSleep = time.Minute
+
+// untilNext 到下一个要处理的时刻（预告时间、提醒时间、每周报告、再提醒时间或零点）的等待时间，最长 maxSleep
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	lunch, dinner, _ := s.schedule.TimesOn(now)
+	for _, m := range []struct{ mealType, at string }{{"lunch", lunch}, {"dinner", dinner}} {
+		t, ok := scheduledAt(now, m.at)
+		if !ok {
+			continue
+		}
+		if lead := s.schedule.Preview.Lead(m.mealType); lead > 0 {
+			if p := t.Add(-lead); p.After(now) && p.Before(next) {
+				next = p
 			}
 		}
+		if t.After(now) && t.Before(next) {
+			next = t
+		}
+	}
+	if day, at, ok := s.schedule.ReportTime(); ok && now.Weekday() == day {
+		if t, ok := scheduledAt(now, at); ok && t.After(now) && t.Before(next) {
+			next = t
+		}
+	}
+	if s.pending != nil && !s.pending.next.IsZero() && s.pending.next.Before(next) {
+		next = s.pending.next
+	}
+
+	wait := next.Sub(now)
+	if wait > maxSleep {
+		wait = maxSleep
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// scheduledAt day 当天 at（"11:30"）的时刻，at 为空或格式错误时 ok 为 false
+func scheduledAt(day time.Time, at string) (time.Time, bool) {
+	if at == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("15:04", at, day.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+// checkDue 到了提醒时间时推荐（提醒后过一段时间才去吃饭，按那时的天气推荐），否则检查是否需要再提醒
+// 睡眠或停机错过了提醒时间、但没超过 catch_up 分钟时补发
+func (s *Scheduler) checkDue(now time.Time) {
+	mealType, scheduled, ok := s.dueMeal(now)
+	if !ok {
+		if mealType, scheduled, ok := s.duePreview(now); ok {
+			s.sendPreview(mealType, scheduled)
+		}
+		if _, ok := s.dueReport(now); ok {
+			s.sendReport(now)
+		}
+		s.remindPending(now)
+		return
 	}
+	var missed string
+	if now.Sub(scheduled) >= time.Minute {
+		missed = scheduled.Format("15:04")
+	}
+	s.triggerRecommendation(mealType, now.Add(s.schedule.MealDelayDuration()), missed)
 }
 
-func (s *Scheduler) triggerRecommendation(mealType string) {
+// triggerRecommendation 推荐并推送，missed 为补发时错过的提醒时间（如 "11:30"）
+// 返回推送的通知（获取推荐失败时 Failed 为 true），退出时取消了请求返回 ok 为 false
+func (s *Scheduler) triggerRecommendation(mealType string, mealTime time.Time, missed string) (n notify.Notification, ok bool) {
 	s.agent.Reset() // 重置对话上下文
+	s.agent.applyScheduledLocation(s.schedule.LocationOn(mealTime), time.Now())
+	previous := s.fired[mealType]
+	s.markFired(mealType, time.Now())
 
-	recommendation, err := s.agent.GetRecommendation(mealType)
+	s.replyToken = notify.NewReplyToken()
+	notification := notify.Notification{
+		MealType: mealType,
+		Title:    i18n.T("🍽️ %s时间到！", i18n.T(mealTypeNames[mealType])),
+		Time:     time.Now(),
+		Token:    s.replyToken,
+		ReplyURL: notify.ReplyURL(s.replyBase, s.replyToken),
+	}
+	if missed != "" {
+		notification.Title += i18n.T("（补发 %s 的提醒）", missed)
+	}
+
+	start := time.Now()
+	recommendation, err := s.agent.GetRecommendationAt(mealType, mealTime)
+	log := logging.Module("scheduler").With("meal", mealType, "latency_ms", time.Since(start).Milliseconds())
 	if err != nil {
-		s.notifyCh <- fmt.Sprintf("获取推荐失败: %v", err)
-		return
+		if s.stopping() {
+			// 退出时取消了请求：不推送失败，恢复提醒记录，重启后补发
+			s.markFired(mealType, previous)
+			return notification, false
+		}
+		log.Warn("获取推荐失败", "err", err)
+		notification.Title = i18n.T("获取推荐失败")
+		notification.Text = err.Error()
+		notification.Failed = true
+		s.pending = nil
+		s.send(notification)
+		return notification, true
+	}
+	log.Info("推送推荐", "missed", missed, "restaurants", len(s.agent.LastRestaurants()))
+	notification.Text = recommendation
+	notification.Restaurants = s.agent.LastRestaurants()
+	notification.Weather = s.agent.LastWeather()
+	s.pending = s.newPending(notification)
+	s.latest = notification
+
+	// 附带饮食习惯的祝贺或提醒
+	if today := time.Now().Format("2006-01-02"); s.habitDate != today {
+		if note := s.agent.HabitNote(); note != "" {
+			notification.Text += "\n\n" + note
+			s.habitDate = today
+		}
+	}
+	// 附带常备食材补货、食材快过期的提醒
+	if today := time.Now().Format("2006-01-02"); s.pantryDate != today {
+		if note := s.agent.PantryNote(); note != "" {
+			notification.Text += "\n\n" + note
+			s.pantryDate = today
+		}
 	}
+	s.send(notification)
+	return notification, true
+}
 
-	mealName := map[string]string{"lunch": "午餐", "dinner": "晚餐"}[mealType]
-	notification := fmt.Sprintf("\n🍽️  %s时间到！\n\n%s", mealName, recommendation)
-	s.notifyCh <- notification
+// send 推送提醒，失败时把错误放进 Errors
+func (s *Scheduler) send(n notify.Notification) {
+	if err := s.notifier.Notify(n); err != nil {
+		s.reportError(err)
+	}
+}
+
+// reportError 把错误放进 Errors（放不下时丢弃）
+func (s *Scheduler) reportError(err error) {
+	select {
+	case s.errCh <- err:
+	default:
+	}
 }
 
 // ManualTrigger 手动触发推荐
@@ -95,7 +301,7 @@ func (s *Scheduler) ManualTrigger() {
 	if hour >= 15 {
 		mealType = "dinner"
 	}
-	s.triggerRecommendation(mealType)
+	s.triggerRecommendation(mealType, time.Now(), "")
 }
 
 // ParseScheduleTime 解析时间字符串
@@ -107,4 +313,4 @@ func ParseScheduleTime(timeStr string) (hour, minute int, err error) {
 
 	_, err = fmt.Sscanf(timeStr, "%d:%d", &hour, &minute)
 	return
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"meal-agent/memory"
+	"meal-agent/notify"
+)
+
+// TodayRecommendation 今天最近一次推荐（对话中换了一批时为新的推荐），今天还没推荐过时 ok 为 false
+func (s *Scheduler) TodayRecommendation() (n notify.Notification, ok bool) {
+	done := make(chan notify.Notification, 1)
+	s.do(func() {
+		done <- s.latest
+	})
+	select {
+	case n = <-done:
+	case <-s.stopCh:
+		return notify.Notification{}, false
+	}
+	if n.Time.IsZero() || n.Time.Format("2006-01-02") != time.Now().Format("2006-01-02") {
+		return notify.Notification{}, false
+	}
+	return n, true
+}
+
+// LastMeal 最近一次用餐记录，还没有记录时 ok 为 false
+func (s *Scheduler) LastMeal() (r memory.MealRecord, ok bool) {
+	done := make(chan []memory.MealRecord, 1)
+	s.do(func() {
+		done <- s.agent.history.Find(memory.Filter{})
+	})
+	select {
+	case records := <-done:
+		if len(records) == 0 {
+			return memory.MealRecord{}, false
+		}
+		return records[0], true
+	case <-s.stopCh:
+		return memory.MealRecord{}, false
+	}
+}
+
+// Recommend 立即推荐一餐并推送（Home Assistant 等外部触发），mealType 为空时按当前时间判断
+func (s *Scheduler) Recommend(mealType string) (notify.Notification, error) {
+	if mealType == "" {
+		mealType = currentMealType()
+	}
+	if mealType != "lunch" && mealType != "dinner" {
+		return notify.Notification{}, fmt.Errorf("未知的餐次: %s（可用 lunch / dinner）", mealType)
+	}
+	type result struct {
+		n  notify.Notification
+		ok bool
+	}
+	done := make(chan result, 1)
+	s.do(func() {
+		n, ok := s.triggerRecommendation(mealType, time.Now(), "")
+		done <- result{n, ok}
+	})
+	select {
+	case r := <-done:
+		if !r.ok {
+			return notify.Notification{}, fmt.Errorf("调度器已停止")
+		}
+		if r.n.Failed {
+			return r.n, errors.New(r.n.Text)
+		}
+		return r.n, nil
+	case <-s.stopCh:
+		return notify.Notification{}, fmt.Errorf("调度器已停止")
+	}
+}
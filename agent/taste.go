@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/preference"
+)
+
+// 口味的对话表达，按顺序匹配，否定的写法在前（"不想吃辣" 不能当成 "想吃辣"）
+var tasteWords = []struct {
+	words []string
+	set   func(t *preference.Taste)
+}{
+	{[]string{"不吃辣", "不能吃辣", "不想吃辣", "不要辣", "少吃辣", "别太辣"}, func(t *preference.Taste) { t.Spicy = -2 }},
+	{[]string{"少辣", "微辣"}, func(t *preference.Taste) { t.Spicy = -1 }},
+	{[]string{"无辣不欢", "想吃辣", "爱吃辣", "多吃辣", "吃点辣"}, func(t *preference.Taste) { t.Spicy = 2 }},
+	{[]string{"不想吃油腻", "不要油腻", "不吃油腻", "别太油", "少吃油腻"}, func(t *preference.Taste) { t.Oily = -2 }},
+	{[]string{"少油"}, func(t *preference.Taste) { t.Oily = -1 }},
+	{[]string{"不想吃清淡", "不要清淡", "重口", "口味重"}, func(t *preference.Taste) { t.Light = -2 }},
+	{[]string{"清淡"}, func(t *preference.Taste) { t.Light = 2 }},
+}
+
+// 长期口味的说法："以后少吃辣"、"我平时不吃辣"
+var tastePermanentWords = []string{"以后", "平时", "一直", "一般"}
+
+// parseTaste 从对话中找出口味偏好，同一项以先匹配到的为准
+func parseTaste(input string) preference.Taste {
+	var taste preference.Taste
+	rest := input
+	for _, tw := range tasteWords {
+		for _, w := range tw.words {
+			if strings.Contains(rest, w) {
+				tw.set(&taste)
+				rest = strings.ReplaceAll(rest, w, "") // 避免 "不想吃清淡" 再被当成 "清淡"
+			}
+		}
+	}
+	return taste
+}
+
+// parseTasteEdit 解析修改口味的对话，返回要执行的修改；不是这类请求时返回 nil
+// 带时间范围的（"持续一周"、"这周"）为临时口味，带"以后"、"平时"的为长期口味，其余的只影响本次推荐
+func (a *MealAgent) parseTasteEdit(input string, now time.Time) func() string {
+	taste := parseTaste(input)
+	if taste.IsZero() {
+		return nil
+	}
+
+	// 只说"今天"的按本次推荐的口味处理，不保存
+	if from, until, ok := overridePeriod(input, now); ok && until != now.Format("2006-01-02") {
+		o := preference.TasteOverride{Taste: taste, Reason: tasteReason(input)}
+		o.From, o.Until = from, until
+		return func() string {
+			a.pref.PruneExpired(now)
+			a.pref.AddTasteOverride(o)
+			return "好的，已添加临时口味，到期后自动恢复：" + o.Describe()
+		}
+	}
+
+	for _, w := range tastePermanentWords {
+		if strings.Contains(input, w) {
+			return func() string {
+				a.pref.SetTaste(taste)
+				return "好的，已记住你的口味：" + a.pref.Taste.Describe()
+			}
+		}
+	}
+	return nil
+}
+
+// tasteReason 取第一句中口味和"想"、"要"之前的部分作为原因，如 "我最近肠胃不好想吃清淡的" -> "肠胃不好"
+func tasteReason(input string) string {
+	reason := overrideSeparator.Split(input, 2)[0]
+	for _, tw := range tasteWords {
+		for _, w := range tw.words {
+			if i := strings.Index(reason, w); i >= 0 {
+				reason = reason[:i]
+			}
+		}
+	}
+	for _, sep := range []string{"想", "要", "所以", "只能"} {
+		if i := strings.Index(reason, sep); i >= 0 {
+			reason = reason[:i]
+		}
+	}
+	for _, prefix := range []string{"我", "最近", "这几天", "这段时间", "下周", "这周"} {
+		reason = strings.TrimPrefix(reason, prefix)
+	}
+	return strings.TrimSpace(reason)
+}
+
+// TasteSummary 当前的口味偏好
+func (a *MealAgent) TasteSummary() string {
+	if a.pref == nil {
+		return "没有设置口味偏好"
+	}
+	var sb strings.Builder
+	if desc := a.pref.Taste.Describe(); desc != "" {
+		sb.WriteString("口味偏好：" + desc)
+	} else {
+		sb.WriteString("没有设置长期口味，可以说「以后少吃辣」")
+	}
+	for _, o := range a.pref.ActiveTasteOverrides(time.Now()) {
+		sb.WriteString("\n临时口味：" + o.Describe())
+	}
+	return sb.String()
+}
+
+// tastePrompt 写进 prompt 的口味偏好，没有时为空
+func (a *MealAgent) tastePrompt(t time.Time) string {
+	pref := a.rankingPref()
+	if pref == nil {
+		return ""
+	}
+	desc := pref.TasteAt(t).Describe()
+	if desc == "" {
+		return ""
+	}
+	return fmt.Sprintf("用户的口味：%s，请推荐符合口味的餐厅和菜品\n", desc)
+}
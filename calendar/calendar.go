@@ -0,0 +1,117 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/config"
+	"meal-agent/memory"
+)
+
+const (
+	DefaultDays  = 14 // 默认列出今后多少天的用餐安排
+	MaxDays      = 60
+	pastDays     = 30           // 列出最近多少天确认过的选择
+	mealDuration = time.Hour    // 日程中每餐的时长
+	uidDomain    = "meal-agent" // UID 的后缀，同一餐的安排和确认的选择使用相同的 UID，日历中原地更新
+)
+
+var mealNames = map[string]string{"lunch": "午餐", "dinner": "晚餐"}
+
+// Event 日历中的一餐
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+}
+
+// Feed 生成日历：最近 30 天确认过的选择，以及今天起 days 天内按提醒时间安排的午餐、晚餐
+// 安排的用餐时刻为提醒时间加上 meal_delay；已经记录的餐次显示选择的餐厅，没有记录的过去的餐次不列出
+// user 为用餐记录的用户（同 -user），用于区分 UID
+func Feed(schedule config.Schedule, records []memory.MealRecord, user string, now time.Time, days int) []Event {
+	if days <= 0 {
+		days = DefaultDays
+	}
+	if days > MaxDays {
+		days = MaxDays
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// 同一天同一餐有多条记录时取最新的（records 为最新的在前）
+	chosen := make(map[string]memory.MealRecord)
+	for _, r := range records {
+		key := r.LocalDate() + "/" + r.MealType
+		if _, ok := chosen[key]; !ok {
+			chosen[key] = r
+		}
+	}
+
+	var events []Event
+	for day := today.AddDate(0, 0, -pastDays); day.Before(today.AddDate(0, 0, days)); day = day.AddDate(0, 0, 1) {
+		lunch, dinner, note := schedule.TimesOn(day)
+		for _, m := range []struct{ mealType, at string }{{"lunch", lunch}, {"dinner", dinner}} {
+			date := day.Format("2006-01-02")
+			r, ok := chosen[date+"/"+m.mealType]
+			var start time.Time
+			if remind, err := time.ParseInLocation("15:04", m.at, day.Location()); err == nil {
+				start = time.Date(day.Year(), day.Month(), day.Day(), remind.Hour(), remind.Minute(), 0, 0, day.Location()).
+					Add(schedule.MealDelayDuration())
+			}
+			switch {
+			case ok:
+				// 没有安排的餐次按记录的时刻
+				if start.IsZero() {
+					start = r.Timestamp()
+				}
+				events = append(events, choiceEvent(r, uid(date, m.mealType, user), start))
+			case !start.IsZero() && !day.Before(today):
+				e := Event{
+					UID:         uid(date, m.mealType, user),
+					Start:       start,
+					End:         start.Add(mealDuration),
+					Summary:     "🍽️ " + mealNames[m.mealType],
+					Description: fmt.Sprintf("%s 推送推荐", m.at),
+				}
+				if note != "" {
+					e.Description += "（" + note + "）"
+				}
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+// choiceEvent 确认过的选择
+func choiceEvent(r memory.MealRecord, uid string, start time.Time) Event {
+	var desc []string
+	if r.Category != "" {
+		desc = append(desc, r.Category)
+	}
+	if r.Rating > 0 {
+		desc = append(desc, strings.Repeat("⭐", r.Rating))
+	}
+	if r.Note != "" {
+		desc = append(desc, r.Note)
+	}
+	return Event{
+		UID:         uid,
+		Start:       start,
+		End:         start.Add(mealDuration),
+		Summary:     fmt.Sprintf("🍽️ %s：%s", mealNames[r.MealType], r.Restaurant),
+		Location:    r.Restaurant,
+		Description: strings.Join(desc, "\n"),
+	}
+}
+
+func uid(date, mealType, user string) string {
+	id := strings.ReplaceAll(date, "-", "") + "-" + mealType
+	if user != "" {
+		id += "-" + user
+	}
+	return id + "@" + uidDomain
+}
@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsTime       = "20060102T150405Z"
+	maxLineOctets = 75 // RFC 5545 每行最多 75 字节，超过时折行
+)
+
+// Write 把日程写成 iCalendar（.ics）格式，name 为日历名称
+// 时间都用 UTC 表示，日历软件按本地时区显示
+func Write(w io.Writer, name string, events []Event, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(fold(s))
+		bw.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//meal-agent//meal-agent//CN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(name))
+	// 订阅时建议每小时刷新一次
+	line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	line("X-PUBLISHED-TTL:PT1H")
+	stamp := now.UTC().Format(icsTime)
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + e.Start.UTC().Format(icsTime))
+		line("DTEND:" + e.End.UTC().Format(icsTime))
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:" + escape(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		line("TRANSP:TRANSPARENT") // 不占用忙闲时间
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escape 转义文本中的反斜杠、逗号、分号和换行
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// fold 把超过 75 字节的行折成多行（续行以空格开头），不拆开多字节字符
+func fold(s string) string {
+	if len(s) <= maxLineOctets {
+		return s
+	}
+	var sb strings.Builder
+	limit := maxLineOctets
+	n := 0
+	for _, r := range s {
+		size := utf8.RuneLen(r)
+		if n+size > limit {
+			sb.WriteString("\r\n ")
+			n = 0
+			limit = maxLineOctets - 1 // 续行开头的空格也算在内
+		}
+		sb.WriteRune(r)
+		n += size
+	}
+	return sb.String()
+}
@@ -0,0 +1,157 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/agent"
+	"meal-agent/session"
+)
+
+// messageLimit 聊天软件单条消息的最大字符数（Discord 为 2000）
+const messageLimit = 1900
+
+// 断线重连的等待时间
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Usage /meal 命令的用法
+const Usage = "用法：\n" +
+	"/meal recommend [要求]  获取推荐，如 /meal recommend 不要辣\n" +
+	"/meal record <序号|餐厅名 [类型] [金额]>  记录这顿吃了推荐中的第几家，或手动记录\n" +
+	"/meal history [关键词]  最近的用餐记录，带关键词时搜索全部记录\n" +
+	"/meal reset  清空对话\n" +
+	"/meal <内容>  接着对话，如 /meal 换一批、/meal 第二个"
+
+// Bot 聊天软件的 /meal 命令：每个聊天用户一个会话（与网页共用会话表）
+type Bot struct {
+	sessions *session.Store
+	users    map[string]string // 聊天软件的用户 ID -> 用餐记录的用户，没有列出的使用共享记录
+}
+
+// NewBot 创建命令处理，users 为聊天软件用户 ID 到用餐记录用户（-user）的对应
+func NewBot(sessions *session.Store, users map[string]string) *Bot {
+	return &Bot{sessions: sessions, users: users}
+}
+
+// Handle 处理 /meal 后面的内容，key 为会话（如 "slack:U123"），userID 为聊天软件中的用户 ID
+func (b *Bot) Handle(key, userID, text string) string {
+	text = strings.TrimSpace(text)
+	command, args, _ := strings.Cut(text, " ")
+	args = strings.TrimSpace(args)
+
+	var reply string
+	b.sessions.Do(key, b.users[userID], func(a *agent.MealAgent) {
+		reply = runCommand(a, strings.ToLower(command), args, text)
+	})
+	return truncate(reply, messageLimit)
+}
+
+func runCommand(a *agent.MealAgent, command, args, text string) string {
+	switch command {
+	case "", "help", "帮助":
+		return Usage
+	case "recommend", "推荐", "r":
+		if args != "" {
+			return chat(a, "推荐，"+args)
+		}
+		reply, err := a.GetRecommendation(mealType(time.Now()))
+		if err != nil {
+			return "获取推荐失败: " + err.Error()
+		}
+		return reply
+	case "record", "记录":
+		return record(a, args)
+	case "history", "历史":
+		if args == "" {
+			return a.GetHistorySummary()
+		}
+		reply, err := a.SearchHistory(args)
+		if err != nil {
+			return "搜索失败: " + err.Error()
+		}
+		return reply
+	case "reset", "重置":
+		a.Reset()
+		return "已清空对话，有什么可以帮你的？"
+	}
+	return chat(a, text)
+}
+
+// record "/meal record 2" 记录推荐中的第 2 家，其他写法同终端中的"记录"命令
+func record(a *agent.MealAgent, args string) string {
+	if n, err := strconv.Atoi(args); err == nil {
+		reply, err := a.ConfirmRestaurant(n - 1)
+		if err != nil {
+			return err.Error()
+		}
+		return reply
+	}
+	r, err := agent.ParseRecord(args)
+	if err != nil {
+		return err.Error()
+	}
+	if err := a.RecordMeal(r); err != nil {
+		return "记录失败: " + err.Error()
+	}
+	return agent.RecordSummary(r)
+}
+
+func chat(a *agent.MealAgent, text string) string {
+	reply, err := a.Chat(text)
+	if err != nil {
+		return "抱歉，出错了: " + err.Error()
+	}
+	return reply
+}
+
+// mealType 按当前时间判断午餐还是晚餐
+func mealType(t time.Time) string {
+	if t.Hour() >= 15 {
+		return "dinner"
+	}
+	return "lunch"
+}
+
+// truncate 截取前 limit 个字符，多余部分用省略号代替
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
+
+// keepConnected 保持连接：connect 返回（断线、对方要求重连）后等一会儿重新连接，直到 ctx 取消
+// 连接成功后重置等待时间，连续失败时等待时间加倍
+func keepConnected(ctx context.Context, name string, connect func(ctx context.Context) (connected bool, err error), onError func(error)) {
+	delay := minReconnectDelay
+	for {
+		connected, err := connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+		if err != nil && onError != nil {
+			onError(fmt.Errorf("%s 连接断开: %v（%s后重连）", name, err, delay))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if !connected {
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+		}
+	}
+}
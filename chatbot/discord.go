@@ -0,0 +1,313 @@
+package chatbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"meal-agent/network"
+)
+
+var discordAPI = "https://discord.com/api/v10"
+
+// Gateway 操作码
+const (
+	discordDispatch       = 0
+	discordHeartbeat      = 1
+	discordIdentify       = 2
+	discordReconnect      = 7
+	discordInvalidSession = 9
+	discordHello          = 10
+)
+
+// discordCommand 注册的 /meal 命令：子命令与 Slack 中 /meal 后面的第一个词一致
+var discordCommand = map[string]interface{}{
+	"name":        "meal",
+	"description": "饮食推荐",
+	"options": []map[string]interface{}{
+		discordSubcommand("recommend", "获取推荐", "要求，如 不要辣", false),
+		discordSubcommand("record", "记录这顿吃了推荐中的第几家，或手动记录", "序号，或 餐厅名 [类型] [金额]", true),
+		discordSubcommand("history", "最近的用餐记录", "搜索的关键词", false),
+		discordSubcommand("reset", "清空对话", "", false),
+		discordSubcommand("chat", "接着对话，如 换一批、第二个", "内容", true),
+	},
+}
+
+func discordSubcommand(name, description, text string, required bool) map[string]interface{} {
+	sub := map[string]interface{}{"type": 1, "name": name, "description": description}
+	if text != "" {
+		sub["options"] = []map[string]interface{}{
+			{"type": 3, "name": "text", "description": text, "required": required},
+		}
+	}
+	return sub
+}
+
+// Discord 通过 Gateway 接收 /meal 斜杠命令（不需要公网地址），启动时注册命令
+// guildID 不为空时只在这个服务器注册（立即生效），否则注册为全局命令（可能要等一段时间才出现）
+type Discord struct {
+	bot     *Bot
+	token   string
+	guildID string
+	client  *http.Client
+	onError func(error)
+}
+
+// NewDiscord 创建 Discord 机器人，token 为 Bot Token
+func NewDiscord(bot *Bot, token, guildID string) (*Discord, error) {
+	if token == "" {
+		return nil, errors.New("Discord 需要填写 token（Bot Token）")
+	}
+	return &Discord{bot: bot, token: token, guildID: guildID, client: &http.Client{Timeout: 15 * time.Second, Transport: network.Transport(network.Chatbot)}}, nil
+}
+
+// Run 连接 Discord 并处理命令，断线后自动重连，直到 ctx 取消
+func (d *Discord) Run(ctx context.Context, onError func(error)) {
+	d.onError = onError
+	keepConnected(ctx, "Discord", d.connect, onError)
+}
+
+type discordPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int64          `json:"s"`
+	T  string          `json:"t"`
+}
+
+type discordUser struct {
+	ID string `json:"id"`
+}
+
+type discordInteraction struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"application_id"`
+	Type          int    `json:"type"` // 2 为斜杠命令
+	Token         string `json:"token"`
+	Data          struct {
+		Name    string          `json:"name"`
+		Options []discordOption `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"` // 在服务器中使用时
+	User *discordUser `json:"user"` // 私信中使用时
+}
+
+type discordOption struct {
+	Name    string          `json:"name"`
+	Value   interface{}     `json:"value"`
+	Options []discordOption `json:"options"`
+}
+
+func (d *Discord) connect(ctx context.Context) (bool, error) {
+	gateway, err := d.gatewayURL(ctx)
+	if err != nil {
+		return false, err
+	}
+	conn, err := dialWebsocket(ctx, gateway+"/?v=10&encoding=json")
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var (
+		seq     atomic.Int64 // 最近的序号，心跳时带上（还没有收到时为 -1）
+		started bool
+	)
+	seq.Store(-1)
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return started, err
+		}
+		var p discordPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			continue
+		}
+		if p.S != nil {
+			seq.Store(*p.S)
+		}
+
+		switch p.Op {
+		case discordHello:
+			var hello struct {
+				Interval int `json:"heartbeat_interval"`
+			}
+			json.Unmarshal(p.D, &hello)
+			if hello.Interval <= 0 {
+				return false, errors.New("无效的 Hello 消息")
+			}
+			go heartbeat(ctx, conn, time.Duration(hello.Interval)*time.Millisecond, &seq)
+			identify := map[string]interface{}{
+				"token":   d.token,
+				"intents": 0, // 斜杠命令不需要任何 intent
+				"properties": map[string]string{
+					"os": "linux", "browser": "meal-agent", "device": "meal-agent",
+				},
+			}
+			if err := conn.WriteJSON(map[string]interface{}{"op": discordIdentify, "d": identify}); err != nil {
+				return false, err
+			}
+		case discordReconnect:
+			return started, nil
+		case discordInvalidSession:
+			return started, errors.New("会话无效")
+		case discordDispatch:
+			switch p.T {
+			case "READY":
+				started = true
+				var ready struct {
+					Application struct {
+						ID string `json:"id"`
+					} `json:"application"`
+				}
+				json.Unmarshal(p.D, &ready)
+				go d.registerCommand(ready.Application.ID)
+			case "INTERACTION_CREATE":
+				var in discordInteraction
+				if err := json.Unmarshal(p.D, &in); err == nil && in.Type == 2 && in.Data.Name == "meal" {
+					go d.respond(in)
+				}
+			}
+		}
+	}
+}
+
+// heartbeat 按 Hello 中的间隔发送心跳
+func heartbeat(ctx context.Context, conn *wsConn, interval time.Duration, seq *atomic.Int64) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var last interface{}
+		if s := seq.Load(); s >= 0 {
+			last = s
+		}
+		if err := conn.WriteJSON(map[string]interface{}{"op": discordHeartbeat, "d": last}); err != nil {
+			return
+		}
+	}
+}
+
+// gatewayURL 获取 Gateway 地址
+func (d *Discord) gatewayURL(ctx context.Context) (string, error) {
+	var result struct {
+		URL string `json:"url"`
+	}
+	if err := d.call(ctx, http.MethodGet, "/gateway/bot", nil, &result); err != nil {
+		return "", err
+	}
+	if result.URL == "" {
+		return "", errors.New("没有获取到 Gateway 地址")
+	}
+	return result.URL, nil
+}
+
+// registerCommand 注册（覆盖）/meal 命令
+func (d *Discord) registerCommand(appID string) {
+	if appID == "" {
+		return
+	}
+	path := "/applications/" + appID + "/commands"
+	if d.guildID != "" {
+		path = "/applications/" + appID + "/guilds/" + d.guildID + "/commands"
+	}
+	if err := d.call(context.Background(), http.MethodPut, path, []interface{}{discordCommand}, nil); err != nil && d.onError != nil {
+		d.onError(fmt.Errorf("Discord 注册 /meal 命令失败: %v", err))
+	}
+}
+
+// respond 先告诉 Discord 稍后回复（命令要在 3 秒内响应，推荐往往更慢），处理完再更新这条回复
+func (d *Discord) respond(in discordInteraction) {
+	ctx := context.Background()
+	err := d.call(ctx, http.MethodPost, "/interactions/"+in.ID+"/"+in.Token+"/callback", map[string]int{"type": 5}, nil)
+	if err == nil {
+		user := in.User
+		if in.Member != nil {
+			user = &in.Member.User
+		}
+		var userID string
+		if user != nil {
+			userID = user.ID
+		}
+		reply := d.bot.Handle("discord:"+userID, userID, discordText(in.Data.Options))
+		err = d.call(ctx, http.MethodPatch, "/webhooks/"+in.ApplicationID+"/"+in.Token+"/messages/@original",
+			map[string]string{"content": reply}, nil)
+	}
+	if err != nil && d.onError != nil {
+		d.onError(fmt.Errorf("Discord 回复失败: %v", err))
+	}
+}
+
+// discordText 把子命令和参数还原成 "recommend 不要辣" 的写法，chat 子命令只取内容
+func discordText(options []discordOption) string {
+	if len(options) == 0 {
+		return ""
+	}
+	sub := options[0]
+	var text string
+	for _, o := range sub.Options {
+		if o.Name == "text" {
+			text = strings.TrimSpace(fmt.Sprint(o.Value))
+		}
+	}
+	if sub.Name == "chat" {
+		if text == "" {
+			return "help"
+		}
+		return text
+	}
+	return strings.TrimSpace(sub.Name + " " + text)
+}
+
+// call 调用 Discord 接口，result 不为 nil 时解析返回的 JSON
+func (d *Discord) call(ctx context.Context, method, path string, payload, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, discordAPI+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.token)
+	req.Header.Set("User-Agent", "DiscordBot (meal-agent, 1.0)")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s", resp.Status, truncate(string(data), 200))
+	}
+	if result != nil {
+		return json.Unmarshal(data, result)
+	}
+	return nil
+}
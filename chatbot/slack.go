@@ -0,0 +1,141 @@
+package chatbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"meal-agent/network"
+)
+
+var slackAPI = "https://slack.com/api"
+
+// Slack 通过 Socket Mode 接收 /meal 斜杠命令（不需要公网地址），回复发到命令所在的频道
+// 需要在 Slack 应用中开启 Socket Mode，添加 /meal 命令，使用带 connections:write 权限的应用级令牌（xapp-）
+type Slack struct {
+	bot      *Bot
+	appToken string
+	client   *http.Client
+	onError  func(error)
+}
+
+// NewSlack 创建 Slack 机器人
+func NewSlack(bot *Bot, appToken string) (*Slack, error) {
+	if appToken == "" {
+		return nil, errors.New("Slack 需要填写 app_token（xapp- 开头的应用级令牌）")
+	}
+	return &Slack{bot: bot, appToken: appToken, client: &http.Client{Timeout: 15 * time.Second, Transport: network.Transport(network.Chatbot)}}, nil
+}
+
+// Run 连接 Slack 并处理命令，断线后自动重连，直到 ctx 取消
+func (s *Slack) Run(ctx context.Context, onError func(error)) {
+	s.onError = onError
+	keepConnected(ctx, "Slack", s.connect, onError)
+}
+
+// slackEnvelope Socket Mode 推送的消息
+type slackEnvelope struct {
+	EnvelopeID string `json:"envelope_id"`
+	Type       string `json:"type"` // hello / slash_commands / disconnect / events_api ...
+	Reason     string `json:"reason"`
+	Payload    struct {
+		Command     string `json:"command"`
+		Text        string `json:"text"`
+		UserID      string `json:"user_id"`
+		TeamID      string `json:"team_id"`
+		ResponseURL string `json:"response_url"`
+	} `json:"payload"`
+}
+
+func (s *Slack) connect(ctx context.Context) (bool, error) {
+	wsURL, err := s.openConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+	conn, err := dialWebsocket(ctx, wsURL)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		var env slackEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			continue
+		}
+		// 收到后要立即确认，否则 Slack 会重发
+		if env.EnvelopeID != "" {
+			if err := conn.WriteJSON(map[string]string{"envelope_id": env.EnvelopeID}); err != nil {
+				return true, err
+			}
+		}
+		switch env.Type {
+		case "disconnect":
+			return true, nil // Slack 要求换一个连接（定期刷新或服务端维护）
+		case "slash_commands":
+			go s.respond(env)
+		}
+	}
+}
+
+// openConnection 获取 Socket Mode 的连接地址（每次连接都要重新获取）
+func (s *Slack) openConnection(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackAPI+"/apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.appToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK    bool   `json:"ok"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("apps.connections.open: %s", resp.Status)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("apps.connections.open: %s", result.Error)
+	}
+	return result.URL, nil
+}
+
+// respond 处理命令，通过 response_url 把回复发到频道（命令要在 3 秒内确认，推荐往往更慢）
+func (s *Slack) respond(env slackEnvelope) {
+	p := env.Payload
+	reply := s.bot.Handle("slack:"+p.TeamID+":"+p.UserID, p.UserID, p.Text)
+	if p.ResponseURL == "" {
+		return
+	}
+	body, _ := json.Marshal(map[string]string{
+		"response_type": "in_channel",
+		"text":          fmt.Sprintf("<@%s> %s %s\n%s", p.UserID, p.Command, p.Text, reply),
+	})
+	resp, err := s.client.Post(p.ResponseURL, "application/json", bytes.NewReader(body))
+	if err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = errors.New(resp.Status)
+		}
+	}
+	if err != nil && s.onError != nil {
+		s.onError(fmt.Errorf("Slack 回复失败: %v", err))
+	}
+}
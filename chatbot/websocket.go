@@ -0,0 +1,224 @@
+package chatbot
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"meal-agent/network"
+)
+
+// WebSocket 帧类型
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+const (
+	wsHandshakeTimeout = 15 * time.Second
+	wsReadTimeout      = 3 * time.Minute // 这么久没有任何消息（包括 ping、心跳确认）时认为连接已断开
+	wsMaxMessage       = 16 << 20        // 单条消息的最大字节数
+	wsGUID             = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// wsConn Slack Socket Mode、Discord Gateway 使用的 WebSocket 客户端连接（只支持文本消息，不支持压缩扩展）
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	mu   sync.Mutex // 写入帧时加锁（心跳和回复在不同的协程中发送）
+}
+
+// dialWebsocket 连接 ws:// 或 wss:// 地址并完成握手
+func dialWebsocket(ctx context.Context, rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("不支持的 WebSocket 地址: %s", rawURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wsHandshakeTimeout)
+	defer cancel()
+	// 按 network 的设置连接（公司网络中通过代理，信任额外的 CA）
+	conn, err := network.Dial(ctx, network.Chatbot, u)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes := make([]byte, 16)
+	rand.Read(keyBytes)
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("WebSocket 握手失败: %s", resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, errors.New("WebSocket 握手失败: 无效的 Sec-WebSocket-Accept")
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, br: br}, nil
+}
+
+// ReadMessage 读取下一条完整的消息，自动回复 ping；对方关闭连接时返回 io.EOF
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+			if len(message) > wsMaxMessage {
+				return nil, errors.New("WebSocket 消息过大")
+			}
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("未知的 WebSocket 帧类型: %d", op)
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > wsMaxMessage {
+		err = errors.New("WebSocket 消息过大")
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// writeFrame 发送一个完整的帧，客户端发送的帧必须加掩码
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsHandshakeTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// WriteJSON 以文本消息发送 JSON
+func (c *wsConn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(opText, data)
+}
+
+// Close 发送关闭帧并断开连接
+func (c *wsConn) Close() error {
+	c.writeFrame(opClose, []byte{0x03, 0xE8}) // 1000 正常关闭
+	return c.conn.Close()
+}
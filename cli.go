@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"meal-agent/config"
+	"meal-agent/i18n"
+)
+
+// options 全局选项，写在子命令前后都可以：meal-agent -config a.yaml daemon 或 meal-agent daemon -config a.yaml
+type options struct {
+	configPath string
+	prefPath   string
+	dataDir    string
+	user       string
+	output     outputFormat
+	noColor    bool
+	location   string
+	sets       overrideList
+	logLevel   string
+	logFormat  string
+	debugDir   string
+	logToFile  bool // 后台模式和网页服务：日志写入配置中的 log.file（不是命令行选项）
+}
+
+// register 在 fs 中注册全局选项，默认值为已经解析到的值
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", o.configPath, i18n.T("配置文件路径"))
+	fs.StringVar(&o.prefPath, "pref", o.prefPath, i18n.T("餐厅偏好配置路径"))
+	fs.StringVar(&o.dataDir, "data", o.dataDir, i18n.T("数据目录路径"))
+	fs.StringVar(&o.user, "user", o.user, i18n.T("用户 ID（多人共用数据目录时各自记录历史，留空使用共享记录）"))
+	fs.StringVar(&o.location, "location", o.location, i18n.T("推荐时使用的位置（config.yaml 中 locations 的名称，如 office），默认为 location"))
+	fs.Var(&o.sets, "set", i18n.T("覆盖配置文件中的一项 `路径=值`，如 --set llm.api_key=xxx（可以写多次，优先于环境变量 MEAL_AGENT_*）"))
+	fs.StringVar(&o.logLevel, "log-level", o.logLevel, i18n.T("日志级别: debug / info / warn / error（默认使用配置中的 log.level）"))
+	fs.StringVar(&o.logFormat, "log-format", o.logFormat, i18n.T("日志格式: text / json（默认使用配置中的 log.format）"))
+	fs.StringVar(&o.debugDir, "debug-dir", o.debugDir, i18n.T("把每次推荐的 prompt、带权重的候选餐厅和高德、天气、LLM 接口的原始响应写到这个目录（排查推荐不合适的原因）"))
+	fs.BoolVar(&o.noColor, "no-color", o.noColor, i18n.T("终端中不使用颜色和样式显示回复（也可以设置环境变量 NO_COLOR）"))
+}
+
+// overrideList 全局选项 --set 路径=值，可以写多次
+type overrideList []config.Override
+
+// String 只显示路径（帮助中的默认值不显示密钥）
+func (l *overrideList) String() string {
+	paths := make([]string, len(*l))
+	for i, o := range *l {
+		paths[i] = o.Path
+	}
+	return strings.Join(paths, ", ")
+}
+
+func (l *overrideList) Set(s string) error {
+	o, err := config.ParseOverride(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, o)
+	return nil
+}
+
+// 退出码：recommend、ask 等在脚本、快捷键和 Raycast / Alfred 中调用时据此判断结果
+const (
+	exitError    = 1 // 出错（配置、网络、LLM 接口等，错误输出到标准错误）
+	exitUsage    = 2 // 命令行用法有误
+	exitNoResult = 3 // 附近没有找到合适的餐厅（回复照常输出）
+)
+
+// errNoResult 推荐成功但没有找到合适的餐厅，以 exitNoResult 退出
+var errNoResult = errors.New("没有找到合适的餐厅")
+
+// usageError 命令行用法有误，以 exitUsage 退出
+// 子命令返回它而不是直接 os.Exit，main 才能在退出前导出链路追踪、停止插件
+type usageError struct {
+	err error // 输出到标准错误的说明，为 nil 表示已经打印了用法
+}
+
+func (e usageError) Error() string {
+	if e.err == nil {
+		return i18n.T("命令行用法有误")
+	}
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() error { return e.err }
+
+// errUsage 已经打印了用法（fs.Usage）时返回
+var errUsage = usageError{}
+
+// fileOutputCommands 这些命令的 --output 为输出文件，不接受全局的 --output 格式（写在子命令前面）
+var fileOutputCommands = map[string]bool{"calendar": true, "history export": true, "pref export": true}
+
+// command 子命令
+type command struct {
+	name    string
+	aliases []string
+	args    string // 参数的写法，显示在用法中，如 "[要求]"
+	summary string
+	detail  string // --help 中补充的说明（子命令列表、示例），可以为空
+	run     func(opts *options, args []string) error
+}
+
+// commandList 所有子命令，按帮助中显示的顺序
+// （写成函数而不是包级变量：help 命令要引用这个列表）
+func commandList() []*command {
+	return []*command{
+		{name: "chat", args: "", summary: "交互模式：在终端中对话（不写子命令时的默认命令）", run: runChat},
+		{name: "daemon", summary: "后台模式：按提醒时间推送推荐，修改配置后自动重新加载", run: runDaemon},
+		{name: "serve", aliases: []string{"server"}, summary: "局域网网页和 Slack、Discord 聊天机器人", run: runServe},
+		{name: "mcp", summary: "MCP 服务：通过标准输入输出供 Claude Desktop 等客户端调用", run: runMCP},
+		{name: "tui", args: "[关键词]", summary: "终端界面：方向键浏览候选餐厅，回车确认并记录，/ 筛选", run: runTUI},
+		{name: "recommend", aliases: []string{"r"}, args: "[要求]", summary: "推荐一次并输出，如 recommend 不要辣", run: runRecommendCommand},
+		{name: "ask", args: "<要说的话>", summary: "说一句话、输出回复后退出，如 ask \"不想吃辣，推荐晚餐\"", detail: askUsage, run: runAskCommand},
+		{name: "cook", args: "[想吃的]", summary: "做饭模式：推荐几道在家做的菜和购物清单，如 cook 想吃鱼", run: runCookCommand},
+		{name: "record", args: "<餐厅名> [类型] [金额] [评分:1-5] [备注:内容] [照片:路径或链接]", summary: "记录一次用餐，如 record 海底捞 火锅 138 评分:5", run: runRecordCommand},
+		{name: "history", args: "[list|export|import|search] ...", summary: "查看、搜索、导出和导入用餐记录", detail: historyUsage, run: runHistoryCommand},
+		{name: "stats", summary: "用餐统计", run: runStatsCommand},
+		{name: "calendar", summary: "导出用餐安排和确认的选择为 .ics 日历", run: runCalendarCommand},
+		{name: "doctor", summary: "自检：检查配置是否完整，测试高德、天气、LLM 接口和数据目录", run: runDoctorCommand},
+		{name: "keyring", args: "<set|get|delete> <服务/账户>", summary: "在系统钥匙串中保存密钥，配置中写 keyring:meal-agent/amap 引用", detail: keyringUsage, run: runKeyringCommand},
+		{name: "encryption", args: "<status|encrypt|decrypt|keygen>", summary: "加密保存用餐历史和偏好文件（AES-GCM）", detail: encryptionUsage, run: runEncryptionCommand},
+		{name: "config", args: "<validate|init>", summary: "检查配置文件，或从示例生成配置文件", detail: configUsage, run: runConfigCommand},
+		{name: "pref", aliases: []string{"preferences", "prefs"}, args: "<validate|export|import> ...", summary: "检查、导出和导入偏好配置", detail: preferencesUsage, run: runPreferencesCommand},
+		{name: "pantry", args: "[add|use|remove] ...", summary: "家里的食材库存，做饭模式优先用快过期的，常备食材不够时提醒", detail: pantryUsage, run: runPantryCommand},
+		{name: "learned", args: "[reset [餐厅]]", summary: "查看或清空根据评分和选择学到的权重调整", run: runLearnedCommand},
+		{name: "sync", summary: "与其他设备同步用餐历史和偏好（需要配置 sync）", run: runSyncCommand},
+		{name: "service", args: "<install|uninstall|status> [--system] [--print]", summary: "安装为开机自动运行的后台服务（systemd / launchd）", detail: serviceUsage, run: runServiceCommand},
+		{name: "help", args: "[子命令]", summary: "显示帮助", run: runHelp},
+	}
+}
+
+// findCommand 按名称或别名查找子命令
+func findCommand(name string) *command {
+	for _, c := range commandList() {
+		if c.name == name {
+			return c
+		}
+		for _, alias := range c.aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// modeCommands 旧的 -mode 参数对应的子命令
+var modeCommands = map[string]string{"chat": "chat", "daemon": "daemon", "server": "serve", "mcp": "mcp", "stats": "stats"}
+
+// flags 子命令的选项：同时接受全局选项，--help 时显示用法、说明和所有选项
+func (o *options) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	o.register(fs)
+	if !fileOutputCommands[name] {
+		fs.Var(&o.output, "output", i18n.T(outputUsage))
+		fs.Var(jsonFlag{&o.output}, "json", i18n.T("同 --output json"))
+	}
+	fs.Usage = func() {
+		printCommandHelp(fs, findCommand(strings.Fields(name)[0]), name)
+	}
+	return fs
+}
+
+// printCommandHelp 子命令的用法，name 为完整的命令（如 "history export"）
+func printCommandHelp(fs *flag.FlagSet, c *command, name string) {
+	out := fs.Output()
+	if c != nil && name == c.name {
+		fmt.Fprintf(out, "%s: %s\n\n%s\n", i18n.T("用法"), strings.TrimSpace("meal-agent "+c.name+" "+i18n.T("[选项]")+" "+i18n.T(c.args)), i18n.T(c.summary))
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(out, "%s: %s\n", i18n.T("别名"), strings.Join(c.aliases, ", "))
+		}
+		if c.detail != "" {
+			fmt.Fprintf(out, "\n%s\n", i18n.T(c.detail))
+		}
+	} else {
+		fmt.Fprintf(out, "%s: meal-agent %s %s\n", i18n.T("用法"), name, i18n.T("[选项]"))
+	}
+	fmt.Fprintf(out, "\n%s:\n", i18n.T("选项"))
+	fs.PrintDefaults()
+}
+
+// parseArgs 解析选项，选项和参数可以交替书写（如 history search 泰国菜 --range 2024），返回参数
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// printUsage 总的帮助：全局选项和子命令列表
+// 全局选项重新注册一遍，说明按配置中的语言显示（已废弃的 -mode 不显示）
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, i18n.T("饮食推荐助手 Meal Agent"))
+	fmt.Fprintf(out, "\n%s: meal-agent %s\n", i18n.T("用法"), i18n.T("[全局选项] <子命令> [选项] [参数]"))
+	fmt.Fprintf(out, "\n%s:\n", i18n.T("子命令"))
+	commands := commandList()
+	width := 0
+	for _, c := range commands {
+		if len(c.name) > width {
+			width = len(c.name)
+		}
+	}
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-*s  %s\n", width, c.name, i18n.T(c.summary))
+	}
+
+	fs := flag.NewFlagSet("meal-agent", flag.ContinueOnError)
+	fs.SetOutput(out)
+	opts := &options{output: "text"}
+	opts.register(fs)
+	fs.Var(&opts.output, "output", i18n.T(outputUsage))
+	flag.VisitAll(func(f *flag.Flag) {
+		if g := fs.Lookup(f.Name); g != nil {
+			g.DefValue = f.DefValue
+		}
+	})
+	fmt.Fprintf(out, "\n%s:\n", i18n.T("全局选项（也可以写在子命令后面）"))
+	fs.PrintDefaults()
+	fmt.Fprintf(out, "\n%s\n", i18n.T("运行 meal-agent <子命令> --help 查看子命令的选项"))
+}
+
+func runHelp(opts *options, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		flag.CommandLine.SetOutput(os.Stdout)
+		printUsage()
+		return nil
+	}
+	c := findCommand(args[0])
+	if c == nil {
+		return usageError{fmt.Errorf(i18n.T("未知的子命令: %s"), args[0])}
+	}
+	return c.run(opts, []string{"--help"})
+}
@@ -0,0 +1,112 @@
+package cloudsync
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitRemote git 仓库：在本地克隆中读写文件，同步结束时提交并推送
+// 认证沿用本机的 git 配置（SSH Key 或凭据管理器）
+type GitRemote struct {
+	repoURL string
+	branch  string
+	dir     string // 本地克隆目录
+	pulled  bool
+}
+
+// NewGitRemote 创建 git 远端，dir 是本地克隆目录（不存在时自动克隆）
+func NewGitRemote(repoURL, branch, dir string) *GitRemote {
+	if branch == "" {
+		branch = "main"
+	}
+	return &GitRemote{repoURL: repoURL, branch: branch, dir: dir}
+}
+
+// Get 读取文件（第一次读取前先拉取远端最新内容）
+func (g *GitRemote) Get(name string) ([]byte, error) {
+	if err := g.pull(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(g.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put 写入文件（Commit 时才推送）
+func (g *GitRemote) Put(name string, data []byte) error {
+	if err := g.pull(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(g.dir, name), data, 0644)
+}
+
+// Commit 提交并推送改动，没有改动时什么都不做
+func (g *GitRemote) Commit(message string) error {
+	if _, err := g.git("add", "-A"); err != nil {
+		return err
+	}
+	status, err := g.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		return nil
+	}
+	if _, err := g.git("commit", "-m", message); err != nil {
+		return err
+	}
+	if _, err := g.git("push", "origin", "HEAD:"+g.branch); err != nil {
+		return fmt.Errorf("推送失败（可能另一台设备刚刚同步过，请重试）: %v", err)
+	}
+	return nil
+}
+
+// pull 克隆或更新本地仓库
+// 同步总是用合并后的结果覆盖文件，所以直接重置到远端分支，不需要 git 自己合并
+func (g *GitRemote) pull() error {
+	if g.pulled {
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(g.dir), 0755); err != nil {
+			return err
+		}
+		if out, err := exec.Command("git", "clone", g.repoURL, g.dir).CombinedOutput(); err != nil {
+			return fmt.Errorf("克隆同步仓库失败: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	if _, err := g.git("fetch", "origin"); err != nil {
+		return err
+	}
+	// 空仓库还没有远端分支，直接在本地新建
+	if _, err := g.git("rev-parse", "--verify", "origin/"+g.branch); err == nil {
+		if _, err := g.git("checkout", "-B", g.branch, "origin/"+g.branch); err != nil {
+			return err
+		}
+		if _, err := g.git("reset", "--hard", "origin/"+g.branch); err != nil {
+			return err
+		}
+	} else if _, err := g.git("checkout", "-B", g.branch); err != nil {
+		return err
+	}
+
+	g.pulled = true
+	return nil
+}
+
+func (g *GitRemote) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s 失败: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,115 @@
+package cloudsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"meal-agent/network"
+)
+
+// ErrNotFound 远端还没有这个文件（第一次同步）
+var ErrNotFound = errors.New("远端文件不存在")
+
+// Remote 远端存储：按文件名读写整个文件
+type Remote interface {
+	Get(name string) ([]byte, error)
+	Put(name string, data []byte) error
+}
+
+// Committer 需要在写入后提交的远端（如 git 仓库），同步结束时调用
+type Committer interface {
+	Commit(message string) error
+}
+
+// WebDAVRemote WebDAV 目录（坚果云、Nextcloud 等）
+type WebDAVRemote struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVRemote 创建 WebDAV 远端，baseURL 是存放同步文件的目录地址
+func NewWebDAVRemote(baseURL, username, password string) *WebDAVRemote {
+	return &WebDAVRemote{
+		baseURL:  strings.TrimRight(baseURL, "/") + "/",
+		username: username,
+		password: password,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: network.Transport(network.Sync),
+		},
+	}
+}
+
+// Get 下载文件
+func (w *WebDAVRemote) Get(name string) ([]byte, error) {
+	resp, err := w.do("GET", w.baseURL+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("WebDAV 下载 %s 失败: HTTP %d", name, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put 上传文件，目录不存在时先创建
+func (w *WebDAVRemote) Put(name string, data []byte) error {
+	status, err := w.put(name, data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict || status == http.StatusNotFound {
+		resp, err := w.do("MKCOL", w.baseURL, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if status, err = w.put(name, data); err != nil {
+			return err
+		}
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("WebDAV 上传 %s 失败: HTTP %d", name, status)
+	}
+	return nil
+}
+
+func (w *WebDAVRemote) put(name string, data []byte) (int, error) {
+	resp, err := w.do("PUT", w.baseURL+name, data)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (w *WebDAVRemote) do(method, url string, data []byte) (*http.Response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV 请求失败: %v", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+package cloudsync
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"meal-agent/network"
+)
+
+// S3Remote S3 兼容的对象存储（AWS S3、MinIO、阿里云 OSS、腾讯云 COS 等）
+// 使用路径风格的地址 endpoint/bucket/key，请求按 AWS Signature V4 签名
+type S3Remote struct {
+	endpoint  string
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3Remote 创建 S3 远端，prefix 是对象键的前缀（如 "meal-agent/"）
+func NewS3Remote(endpoint, bucket, prefix, region, accessKey, secretKey string) *S3Remote {
+	if region == "" {
+		region = "us-east-1"
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3Remote{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		bucket:    bucket,
+		prefix:    strings.TrimLeft(prefix, "/"),
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: network.Transport(network.Sync),
+		},
+	}
+}
+
+// Get 下载对象
+func (s *S3Remote) Get(name string) ([]byte, error) {
+	resp, err := s.do("GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("S3 下载 %s 失败: HTTP %d", name, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put 上传对象
+func (s *S3Remote) Put(name string, data []byte) error {
+	resp, err := s.do("PUT", name, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("S3 上传 %s 失败: HTTP %d %s", name, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (s *S3Remote) do(method, name string, data []byte) (*http.Response, error) {
+	u, err := url.Parse(s.endpoint + "/" + s.bucket + "/" + s.prefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("S3 地址错误: %v", err)
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 请求失败: %v", err)
+	}
+	return resp, nil
+}
+
+// sign 按 AWS Signature V4 签名请求
+func (s *S3Remote) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
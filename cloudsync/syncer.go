@@ -0,0 +1,276 @@
+package cloudsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"meal-agent/encryption"
+	"meal-agent/i18n"
+	"meal-agent/memory"
+)
+
+const (
+	historyName  = "history.json"
+	manifestName = "manifest.json"
+)
+
+// Syncer 在多台设备间同步用餐历史和偏好文件
+// 历史记录按 ID 三方合并，同一条记录两边都改过时保留修改时间较新的；
+// 偏好文件整体同步，两边都改过时保留较新的，另一份另存为 .conflict
+type Syncer struct {
+	remote    Remote
+	history   *memory.History
+	files     []string // 需要同步的偏好文件
+	statePath string   // 本地同步状态（上次同步时的记录和文件版本）
+}
+
+// Result 一次同步的结果
+type Result struct {
+	History   memory.SyncResult
+	Pushed    []string // 上传的偏好文件
+	Pulled    []string // 下载的偏好文件
+	Conflicts []string // 两边都改过的偏好文件（较旧的一份另存为 .conflict）
+}
+
+// syncState 上次同步的状态，用于区分"对方删除了"和"这边新增的"
+type syncState struct {
+	LastSync int64             `json:"last_sync"` // Unix 毫秒
+	Records  []string          `json:"records"`   // 上次同步后的全部记录 ID
+	Files    map[string]string `json:"files"`     // 上次同步后各文件的哈希
+}
+
+// fileInfo 远端清单中的文件版本
+type fileInfo struct {
+	Hash     string `json:"hash"`
+	Modified int64  `json:"modified"` // 文件修改时间（Unix 毫秒）
+}
+
+// New 创建同步器，files 是需要同步的偏好文件路径（远端按文件名保存）
+func New(remote Remote, history *memory.History, statePath string, files ...string) *Syncer {
+	return &Syncer{
+		remote:    remote,
+		history:   history,
+		files:     files,
+		statePath: statePath,
+	}
+}
+
+// Run 执行一次双向同步
+func (s *Syncer) Run() (*Result, error) {
+	state := s.loadState()
+	result := &Result{}
+	now := time.Now().UnixMilli()
+
+	var ids []string
+	err := s.history.Sync(func(local []memory.MealRecord) ([]memory.MealRecord, error) {
+		remote := []memory.MealRecord{}
+		data, err := s.remote.Get(historyName)
+		switch {
+		case errors.Is(err, ErrNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			// 远端的历史记录和本地一样按 encryption 的设置加密
+			if data, err = encryption.Decrypt(data); err != nil {
+				return nil, fmt.Errorf("远端历史记录: %v", err)
+			}
+			if remote, err = memory.ParseRecords(data); err != nil {
+				return nil, fmt.Errorf("解析远端历史记录失败: %v", err)
+			}
+		}
+
+		base := make(map[string]bool, len(state.Records))
+		for _, id := range state.Records {
+			base[id] = true
+		}
+		merged, res := memory.MergeRecords(local, remote, base, state.LastSync)
+		result.History = res
+
+		out, err := json.MarshalIndent(merged, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if out, err = encryption.Encrypt(out); err != nil {
+			return nil, err
+		}
+		if err := s.remote.Put(historyName, out); err != nil {
+			return nil, err
+		}
+
+		for _, r := range merged {
+			ids = append(ids, r.ID)
+		}
+		return merged, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("同步历史记录失败: %v", err)
+	}
+
+	if err := s.syncFiles(state, result); err != nil {
+		return nil, err
+	}
+
+	if c, ok := s.remote.(Committer); ok {
+		host, _ := os.Hostname()
+		if err := c.Commit(fmt.Sprintf("同步 %s %s", host, time.Now().Format("2006-01-02 15:04"))); err != nil {
+			return nil, err
+		}
+	}
+
+	state.LastSync = now
+	state.Records = ids
+	if err := s.saveState(state); err != nil {
+		return nil, fmt.Errorf("保存同步状态失败: %v", err)
+	}
+	return result, nil
+}
+
+// syncFiles 同步偏好文件：只有一边改过时以改过的为准，两边都改过时以修改时间较新的为准
+func (s *Syncer) syncFiles(state *syncState, result *Result) error {
+	manifest := make(map[string]fileInfo)
+	if data, err := s.remote.Get(manifestName); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("解析远端清单失败: %v", err)
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	changed := false
+	for _, path := range s.files {
+		name := filepath.Base(path)
+		remote := manifest[name]
+		local, err := readFileInfo(path)
+		if err != nil {
+			return err
+		}
+
+		localChanged := local.Hash != "" && local.Hash != state.Files[name]
+		remoteChanged := remote.Hash != "" && remote.Hash != state.Files[name]
+
+		switch {
+		case local.Hash == remote.Hash:
+		case remoteChanged && (!localChanged || remote.Modified > local.Modified):
+			data, err := s.remote.Get(name)
+			if err != nil {
+				return fmt.Errorf("下载 %s 失败: %v", name, err)
+			}
+			if localChanged {
+				if err := copyFile(path, path+".conflict"); err != nil {
+					return err
+				}
+				result.Conflicts = append(result.Conflicts, name)
+			}
+			if err := writeFile(path, data); err != nil {
+				return err
+			}
+			local = fileInfo{Hash: sha256Hex(data), Modified: remote.Modified}
+			result.Pulled = append(result.Pulled, name)
+		case local.Hash != "":
+			if remoteChanged {
+				if data, err := s.remote.Get(name); err == nil {
+					writeFile(path+".conflict", data)
+				}
+				result.Conflicts = append(result.Conflicts, name)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := s.remote.Put(name, data); err != nil {
+				return fmt.Errorf("上传 %s 失败: %v", name, err)
+			}
+			manifest[name] = local
+			changed = true
+			result.Pushed = append(result.Pushed, name)
+		}
+
+		if local.Hash != "" {
+			state.Files[name] = local.Hash
+		} else {
+			state.Files[name] = remote.Hash
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.remote.Put(manifestName, data)
+}
+
+// Describe 生成同步结果描述
+func (r *Result) Describe() string {
+	desc := i18n.T("同步完成：新增 %d 条记录，更新 %d 条，删除 %d 条",
+		r.History.Added, r.History.Updated, r.History.Removed)
+	if len(r.Pulled) > 0 {
+		desc += i18n.T("；下载 %v", r.Pulled)
+	}
+	if len(r.Pushed) > 0 {
+		desc += i18n.T("；上传 %v", r.Pushed)
+	}
+	if len(r.Conflicts) > 0 {
+		desc += i18n.T("；%v 两边都有修改，已保留较新的版本，另一份保存为 .conflict", r.Conflicts)
+	}
+	return desc
+}
+
+func (s *Syncer) loadState() *syncState {
+	state := &syncState{Files: make(map[string]string)}
+	if data, err := os.ReadFile(s.statePath); err == nil {
+		json.Unmarshal(data, state)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]string)
+	}
+	return state
+}
+
+func (s *Syncer) saveState(state *syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(s.statePath, data)
+}
+
+// readFileInfo 读取本地文件的版本，文件不存在时返回空版本
+func readFileInfo(path string) (fileInfo, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fileInfo{}, nil
+	}
+	if err != nil {
+		return fileInfo{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fileInfo{}, err
+	}
+	return fileInfo{Hash: sha256Hex(data), Modified: stat.ModTime().UnixMilli()}, nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, data)
+}
+
+// writeFile 原子地写入文件（先写临时文件再重命名），同步中途崩溃或后台模式同时保存时不会留下不完整的文件
+// 已有的文件保留原来的权限（加密的偏好文件只允许自己读写）
+func writeFile(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return memory.WriteFileAtomic(path, data, perm)
+}
@@ -0,0 +1,764 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"meal-agent/agent"
+	"meal-agent/calendar"
+	"meal-agent/config"
+	"meal-agent/debugdump"
+	"meal-agent/encryption"
+	"meal-agent/i18n"
+	"meal-agent/keyring"
+	"meal-agent/logging"
+	"meal-agent/mcp"
+	"meal-agent/memory"
+	"meal-agent/preference"
+	"meal-agent/tracing"
+	"meal-agent/tui"
+)
+
+//go:embed config.example.yaml
+var exampleConfig []byte
+
+// app 子命令共用的配置、历史记录和偏好
+type app struct {
+	opts     *options
+	cfg      *config.Config
+	history  *memory.History
+	pref     *preference.Preferences
+	profiles map[string]*preference.Preferences
+	dumper   *debugdump.Dumper // --debug-dir（未指定时为 nil）
+}
+
+// loadApp 加载配置、历史记录和偏好配置：开启自动同步时先同步，归档较早的记录，删除过期的临时偏好
+// 配置或历史记录加载失败时退出
+func loadApp(opts *options) (*app, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s\n%s", i18n.T("加载配置失败: %v", err),
+			i18n.T("请复制 config.example.yaml 为 config.yaml 并填写配置（或运行 meal-agent config init）"))
+	}
+	i18n.SetLanguage(cfg.Language)
+	setupLogging(opts, cfg)
+	// 按 OTEL_* 环境变量开启推荐流程的链路追踪
+	if err := tracing.Setup(); err != nil {
+		logging.Module("trace").Warn(i18n.T("不导出链路追踪"), "err", err)
+	}
+	if opts.location != "" {
+		if _, _, ok := cfg.FindLocation(opts.location); !ok {
+			return nil, fmt.Errorf(i18n.T("没有找到位置: %s（可用 %s）"), opts.location, strings.Join(cfg.LocationNames(), " / "))
+		}
+	}
+
+	history, err := memory.NewHistory(opts.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("初始化历史记录失败: %v"), err)
+	}
+
+	// 开启 auto 时启动时自动同步
+	if cfg.Sync.Auto && cfg.Sync.Provider != "" {
+		if err := runSync(cfg, history, opts.dataDir, opts.prefPath); err != nil {
+			logging.Module("sync").Warn(i18n.T("同步失败，继续使用本地数据"), "err", err)
+		}
+	}
+
+	// 归档较早的记录，当前文件只保留最近的记录
+	if n, err := history.Archive(cfg.History.ArchiveMonths); err != nil {
+		logging.Module("history").Warn(i18n.T("归档历史记录失败"), "err", err)
+	} else if n > 0 {
+		fmt.Println(i18n.T("已归档 %d 条较早的用餐记录", n))
+	}
+
+	// 加载餐厅偏好配置（可选）
+	pref, err := loadPreferences(cfg, opts.prefPath)
+	if err != nil {
+		logging.Module("preference").Warn(i18n.T("加载偏好配置失败，将使用默认权重"), "path", opts.prefPath, "err", err)
+		pref = nil
+	} else if n := pref.PruneExpired(time.Now()); n > 0 {
+		// 过期的临时偏好自动删除
+		if err := pref.Save(opts.prefPath); err != nil {
+			logging.Module("preference").Warn(i18n.T("删除过期的临时偏好失败"), "err", err)
+		} else {
+			fmt.Println(i18n.T("已删除 %d 条过期的临时偏好", n))
+		}
+	}
+
+	// 调试输出：接口的原始响应通过替换 http.DefaultTransport 记录
+	var dumper *debugdump.Dumper
+	if opts.debugDir != "" {
+		if dumper, err = debugdump.New(opts.debugDir); err != nil {
+			fmt.Println(i18n.T("⚠️ %v（不输出调试文件）", err))
+		} else {
+			http.DefaultTransport = dumper.Transport(http.DefaultTransport)
+		}
+	}
+
+	return &app{opts: opts, cfg: cfg, history: history, pref: pref, profiles: loadProfiles(cfg), dumper: dumper}, nil
+}
+
+// newAgent 创建 Agent（指定用户时只读写该用户的记录）
+func (a *app) newAgent(user string) *agent.MealAgent {
+	// 插件提供的工具注册到 agent 中（已经启动的插件不重复启动）
+	setupPlugins(a.cfg)
+	m := agent.NewMealAgent(a.cfg, a.history.ForUser(user), a.pref)
+	m.SetPreferencePath(a.opts.prefPath) // 对话中修改的偏好保存到偏好配置
+	m.SetProfiles(a.profiles)
+	m.SetDumper(a.dumper)
+	if a.opts.location != "" {
+		m.SetLocation(a.opts.location) // 名称在 loadApp 中已经检查过
+	}
+
+	// 根据评分和选择自动调整餐厅权重（学到的调整按用户分别保存）
+	if a.cfg.Learning.Enabled {
+		learner, err := preference.NewLearner(learnedPath(a.opts.dataDir, user), a.cfg.Learning.Rate)
+		if err != nil {
+			fmt.Println(i18n.T("加载学习记录失败: %v（不使用学到的调整）", err))
+		} else {
+			m.SetLearner(learner)
+		}
+	}
+	return m
+}
+
+func runChat(opts *options, args []string) error {
+	opts.flags("chat").Parse(args)
+	if opts.color() {
+		renderReply = tui.Markdown
+	}
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	runChatMode(a.newAgent(opts.user))
+	return nil
+}
+
+func runDaemon(opts *options, args []string) error {
+	opts.flags("daemon").Parse(args)
+	if opts.color() {
+		renderReply = tui.Markdown
+	}
+
+	// 同一个数据目录只能运行一个后台实例（重复推送提醒，同时写 history.json）
+	lock, err := memory.LockDaemon(opts.dataDir, opts.user)
+	if err != nil {
+		return fmt.Errorf(i18n.T("启动失败: %v"), err)
+	}
+	defer lock.Release()
+
+	opts.logToFile = true
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	defer logging.Close()
+	// 修改配置文件后重新加载，加载失败时继续使用原配置
+	watched := []string{opts.configPath, opts.prefPath}
+	for _, path := range a.cfg.Profiles {
+		watched = append(watched, path)
+	}
+	reload := func(s *agent.Scheduler) error {
+		newCfg, err := loadConfig(opts)
+		if err != nil {
+			return fmt.Errorf(i18n.T("加载配置失败: %v"), err)
+		}
+		newPref, err := loadPreferences(newCfg, opts.prefPath)
+		if err != nil {
+			return fmt.Errorf(i18n.T("加载偏好配置失败: %v"), err)
+		}
+		newPref.PruneExpired(time.Now())
+		setupLogging(opts, newCfg)
+		setupPlugins(newCfg)
+		s.Reload(newCfg, newPref, loadProfiles(newCfg))
+		return nil
+	}
+	runDaemonMode(a.newAgent(opts.user), a.cfg, schedulerStatePath(opts.dataDir, opts.user), watched, reload)
+	return nil
+}
+
+func runServe(opts *options, args []string) error {
+	fs := opts.flags("serve")
+	listen := fs.String("listen", "", i18n.T("监听地址，如 :8080（默认使用配置中的 server.listen）"))
+	fs.Parse(args)
+
+	opts.logToFile = true
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	defer logging.Close()
+	if *listen != "" {
+		a.cfg.Server.Listen = *listen
+	}
+	// 网页上没有填写用户名时使用 -user
+	runServerMode(a.cfg, opts.dataDir, func(user string) *agent.MealAgent {
+		if user == "" {
+			user = opts.user
+		}
+		return a.newAgent(user)
+	})
+	return nil
+}
+
+func runMCP(opts *options, args []string) error {
+	opts.flags("mcp").Parse(args)
+
+	// 标准输出只用于协议消息，加载提示、警告等输出改到标准错误
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	if err := mcp.NewServer(a.newAgent(opts.user)).Serve(os.Stdin, stdout); err != nil {
+		return fmt.Errorf(i18n.T("MCP 服务出错: %v"), err)
+	}
+	return nil
+}
+
+// runRecommendCommand 推荐一次并输出，可以用在脚本、快捷指令中
+func runRecommendCommand(opts *options, args []string) error {
+	fs := opts.flags("recommend")
+	meal := fs.String("meal", "", i18n.T("餐次: lunch / dinner（默认按当前时间）"))
+	limit := fs.Int("limit", 5, i18n.T("json / yaml 输出中列出的候选餐厅数量"))
+	request := strings.Join(parseArgs(fs, args), " ")
+
+	mealType, err := parseMealType(*meal)
+	if err != nil {
+		return err
+	}
+
+	out := opts.stdout()
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	mealAgent := a.newAgent(opts.user)
+	mealAgent.SetRequest(request)
+	reply, err := mealAgent.GetRecommendationAt(mealType, time.Now())
+	if err != nil {
+		return fmt.Errorf(i18n.T("获取推荐失败: %v"), err)
+	}
+	restaurants := mealAgent.LastRestaurants()
+	if !opts.structured() {
+		if opts.color() {
+			reply = tui.Markdown(reply)
+		}
+		fmt.Fprintln(out, reply)
+	} else {
+		if *limit > 0 && len(restaurants) > *limit {
+			restaurants = restaurants[:*limit]
+		}
+		err = writeOutput(out, opts.output, recommendOutput{
+			MealType:    mealType,
+			Request:     request,
+			Reply:       reply,
+			Weather:     mealAgent.LastWeather(),
+			Restaurants: newRestaurantOutputs(restaurants),
+		})
+	}
+	if err == nil && len(restaurants) == 0 {
+		return errNoResult
+	}
+	return err
+}
+
+const askUsage = `和对话模式一样理解这句话（推荐、排除、记录、改偏好都可以），输出回复后退出:
+  meal-agent ask "不想吃辣，推荐晚餐"
+  meal-agent ask "上次吃那家泰国菜是什么时候"
+  meal-agent ask --json "来点清淡的"    # 输出 JSON，推荐了餐厅时附带候选
+
+每次都是新的对话，不记得上一次 ask 推荐了什么。
+
+退出码: 0 成功，1 出错（错误输出到标准错误），2 用法有误，3 附近没有找到合适的餐厅`
+
+// runAskCommand 说一句话并输出回复，可以绑定到快捷键或在 Raycast / Alfred 中调用
+func runAskCommand(opts *options, args []string) error {
+	fs := opts.flags("ask")
+	limit := fs.Int("limit", 5, i18n.T("json / yaml 输出中列出的候选餐厅数量"))
+	question := strings.TrimSpace(strings.Join(parseArgs(fs, args), " "))
+	if question == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	out := opts.stdout()
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	mealAgent := a.newAgent(opts.user)
+	reply, err := mealAgent.Chat(question)
+	if err != nil {
+		return fmt.Errorf(i18n.T("抱歉，出错了: %v"), err)
+	}
+	restaurants := mealAgent.LastRestaurants()
+	if !opts.structured() {
+		if opts.color() {
+			reply = tui.Markdown(reply)
+		}
+		fmt.Fprintln(out, reply)
+	} else {
+		if *limit > 0 && len(restaurants) > *limit {
+			restaurants = restaurants[:*limit]
+		}
+		err = writeOutput(out, opts.output, askOutput{
+			Question:    question,
+			Reply:       reply,
+			Restaurants: newRestaurantOutputs(restaurants),
+		})
+	}
+	if err == nil && mealAgent.NoResult() {
+		return errNoResult
+	}
+	return err
+}
+
+// runCookCommand 做饭模式推荐一次：在家做的菜谱和购物清单
+func runCookCommand(opts *options, args []string) error {
+	fs := opts.flags("cook")
+	meal := fs.String("meal", "", i18n.T("餐次: lunch / dinner（默认按当前时间）"))
+	request := strings.Join(parseArgs(fs, args), " ")
+
+	mealType, err := parseMealType(*meal)
+	if err != nil {
+		return err
+	}
+
+	out := opts.stdout()
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	mealAgent := a.newAgent(opts.user)
+	mealAgent.SetCookingMode(true)
+	reply, err := mealAgent.SearchRecommendation(mealType, request)
+	if err != nil {
+		return fmt.Errorf(i18n.T("获取推荐失败: %v"), err)
+	}
+	if !opts.structured() {
+		if opts.color() {
+			reply = tui.Markdown(reply)
+		}
+		fmt.Fprintln(out, reply)
+		return nil
+	}
+	recipes := mealAgent.LastRecipes()
+	if recipes == nil {
+		recipes = []agent.Recipe{}
+	}
+	return writeOutput(out, opts.output, cookOutput{
+		MealType: mealType,
+		Request:  request,
+		Reply:    reply,
+		Weather:  mealAgent.LastWeather(),
+		Recipes:  recipes,
+	})
+}
+
+// runTUI 终端界面：候选餐厅显示为列表，选中后回车确认并记录
+func runTUI(opts *options, args []string) error {
+	fs := opts.flags("tui")
+	meal := fs.String("meal", "", i18n.T("餐次: lunch / dinner（默认按当前时间）"))
+	keyword := strings.Join(parseArgs(fs, args), " ")
+
+	mealType, err := parseMealType(*meal)
+	if err != nil {
+		return err
+	}
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	return tui.Run(a.newAgent(opts.user), mealType, keyword)
+}
+
+// parseMealType 检查 --meal 参数，为空时按当前时间（15 点以后为晚餐）
+func parseMealType(meal string) (string, error) {
+	switch meal {
+	case "":
+		if time.Now().Hour() >= 15 {
+			return "dinner", nil
+		}
+		return "lunch", nil
+	case "lunch", "dinner":
+		return meal, nil
+	}
+	return "", fmt.Errorf(i18n.T("未知的餐次: %s（可用 lunch / dinner）"), meal)
+}
+
+func runRecordCommand(opts *options, args []string) error {
+	fs := opts.flags("record")
+	input := strings.Join(parseArgs(fs, args), " ")
+	if input == "" {
+		fs.Usage()
+		return errUsage
+	}
+	record, err := agent.ParseRecord(input)
+	if err != nil {
+		return err
+	}
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	if err := a.newAgent(opts.user).RecordMeal(record); err != nil {
+		return fmt.Errorf(i18n.T("记录失败: %v"), err)
+	}
+	fmt.Println(agent.RecordSummary(record))
+	return nil
+}
+
+func runStatsCommand(opts *options, args []string) error {
+	fs := opts.flags("stats")
+	period := fs.String("period", "month", i18n.T("统计区间: week / month / all / 2024-06 / 2024-01..2024-06"))
+	fs.Parse(args)
+
+	out := opts.stdout()
+	a, err := loadApp(opts)
+	if err != nil {
+		return err
+	}
+	stats, err := a.newAgent(opts.user).GetStats(*period)
+	if err != nil {
+		return err
+	}
+	if opts.structured() {
+		return writeOutput(out, opts.output, stats)
+	}
+	fmt.Fprintln(out, stats.Describe())
+	return nil
+}
+
+// runCalendarCommand 把用餐安排和最近确认的选择导出为 .ics 文件，可以放在同步盘或共享目录中让日历软件订阅
+// 写入时先写临时文件再替换，定时运行时日历软件不会读到写了一半的文件
+// 不做自动同步（输出到标准输出时不能混入同步的提示）
+func runCalendarCommand(opts *options, args []string) error {
+	fs := opts.flags("calendar")
+	days := fs.Int("days", calendar.DefaultDays, i18n.T("列出今后多少天的安排（最多 %d）", calendar.MaxDays))
+	output := fs.String("output", "", i18n.T("输出文件（留空输出到标准输出）"))
+	fs.Parse(args)
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return fmt.Errorf(i18n.T("加载配置失败: %v"), err)
+	}
+	history, err := memory.NewHistory(opts.dataDir)
+	if err != nil {
+		return fmt.Errorf(i18n.T("初始化历史记录失败: %v"), err)
+	}
+
+	now := time.Now()
+	name := i18n.T("饮食安排")
+	if opts.user != "" {
+		name += " - " + opts.user
+	}
+	events := calendar.Feed(cfg.Schedule, history.ForUser(opts.user).Find(memory.Filter{}), opts.user, now, *days)
+	if *output == "" {
+		return calendar.Write(os.Stdout, name, events, now)
+	}
+
+	tmp := *output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf(i18n.T("创建输出文件失败: %v"), err)
+	}
+	err = calendar.Write(f, name, events, now)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, *output)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf(i18n.T("写入日历失败: %v"), err)
+	}
+	return nil
+}
+
+const configUsage = `子命令:
+  validate          检查配置文件（-config）和偏好配置（-pref），输出主要设置
+  init [--force]    从示例生成配置文件（已存在时需要 --force 覆盖）`
+
+func runConfigCommand(opts *options, args []string) error {
+	fs := opts.flags("config")
+	force := fs.Bool("force", false, i18n.T("init 时覆盖已存在的配置文件"))
+	rest := parseArgs(fs, args)
+	if len(rest) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	switch rest[0] {
+	case "validate", "check":
+		cfg, err := loadConfig(opts)
+		if err != nil {
+			return fmt.Errorf("%s: %v", opts.configPath, err)
+		}
+		fmt.Println(i18n.T("%s: 没有发现问题", opts.configPath))
+		fmt.Println(i18n.T("  位置: %s,%s（%s，半径 %d 米）", cfg.Location.Lat, cfg.Location.Lng, cfg.Location.City, cfg.Location.Radius))
+		if cfg.Location.Address != "" {
+			fmt.Println(i18n.T("  地址: %s", cfg.Location.Address))
+		}
+		if len(cfg.Locations) > 0 {
+			fmt.Println(i18n.T("  常用位置: %s", strings.Join(cfg.LocationNames(), "、")))
+		}
+		fmt.Println(i18n.T("  餐厅数据: %s，天气: %s，LLM: %s %s",
+			orDefault(cfg.API.RestaurantProvider, "amap"), orDefault(cfg.API.WeatherProvider, "qweather"), cfg.LLM.Provider, cfg.LLM.Model))
+		fmt.Println(i18n.T("  提醒时间: 午餐 %s，晚餐 %s", scheduleTime(cfg.Schedule.Lunch), scheduleTime(cfg.Schedule.Dinner)))
+		if _, err := os.Stat(opts.prefPath); err == nil && !validatePreferences([]string{opts.prefPath}) {
+			return errors.New(i18n.T("偏好配置有错误"))
+		}
+		return nil
+
+	case "init":
+		if _, err := os.Stat(opts.configPath); err == nil && !*force {
+			return fmt.Errorf(i18n.T("%s 已存在（覆盖请加 --force）"), opts.configPath)
+		}
+		if err := os.WriteFile(opts.configPath, exampleConfig, 0600); err != nil {
+			return fmt.Errorf(i18n.T("写入配置文件失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已生成 %s，填写位置、API Key 后运行 meal-agent config validate 检查", opts.configPath))
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: config %s\n%s"), rest[0], i18n.T(configUsage))}
+}
+
+const keyringUsage = `子命令:
+  set <服务/账户>       保存密钥（在提示后输入，不回显；也可以从标准输入读取）
+  get <服务/账户>       检查密钥是否已保存（只显示开头和结尾）
+  delete <服务/账户>    删除密钥
+
+只写账户时服务为 meal-agent。保存后在 config.yaml 中写:
+  api:
+    amap_key: "keyring:meal-agent/amap"`
+
+func runKeyringCommand(opts *options, args []string) error {
+	fs := opts.flags("keyring")
+	rest := parseArgs(fs, args)
+	if len(rest) < 2 {
+		fs.Usage()
+		return errUsage
+	}
+	service, account, err := keyring.Parse(rest[1])
+	if err != nil {
+		return err
+	}
+	ref := keyring.Prefix + service + "/" + account
+
+	switch rest[0] {
+	case "set":
+		secret, err := tui.ReadSecret(os.Stdin, i18n.T("输入 %s/%s 的密钥: ", service, account))
+		if err != nil {
+			return fmt.Errorf(i18n.T("读取输入失败: %v"), err)
+		}
+		if secret == "" {
+			return errors.New(i18n.T("密钥为空，没有保存"))
+		}
+		if err := keyring.Set(service, account, secret); err != nil {
+			return fmt.Errorf(i18n.T("保存到系统钥匙串失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已保存，在 config.yaml 中写 \"%s\" 引用", ref))
+		return nil
+
+	case "get":
+		secret, err := keyring.Get(service, account)
+		if err != nil {
+			return fmt.Errorf("%s: %v", ref, err)
+		}
+		fmt.Println(i18n.T("%s: %s（%d 个字符）", ref, maskSecret(secret), len([]rune(secret))))
+		return nil
+
+	case "delete", "rm":
+		if err := keyring.Delete(service, account); err != nil {
+			return fmt.Errorf("%s: %v", ref, err)
+		}
+		fmt.Println(i18n.T("已删除 %s", ref))
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: keyring %s\n%s"), rest[0], i18n.T(keyringUsage))}
+}
+
+const encryptionUsage = `子命令:
+  status    查看是否设置了密钥，以及各文件是否已加密
+  encrypt   用当前密钥加密用餐历史和偏好文件（之后保存时自动加密）
+  decrypt   把加密的文件还原为明文（之后还要删掉 encryption.key，否则保存时又会加密）
+  keygen    生成随机密钥
+
+密钥写在 encryption.key 或环境变量 MEAL_AGENT_ENCRYPTION_KEY 中，建议保存到系统钥匙串:
+  meal-agent keyring set meal-agent/encryption
+  encryption:
+    key: "keyring:meal-agent/encryption"
+同步的设备要使用同一个密钥。`
+
+func runEncryptionCommand(opts *options, args []string) error {
+	fs := opts.flags("encryption")
+	rest := parseArgs(fs, args)
+	if len(rest) < 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	switch rest[0] {
+	case "keygen":
+		fmt.Println(encryption.NewKey())
+		fmt.Fprintln(os.Stderr, i18n.T("保存到系统钥匙串: meal-agent keyring set meal-agent/encryption"))
+		return nil
+
+	case "status":
+		if encryption.Enabled() {
+			fmt.Println(i18n.T("已设置密钥，保存时加密"))
+		} else {
+			fmt.Println(i18n.T("没有设置密钥，保存时不加密"))
+		}
+		for _, path := range encryptedFiles(opts) {
+			data, err := os.ReadFile(path)
+			switch {
+			case os.IsNotExist(err):
+				continue
+			case err != nil:
+				fmt.Printf("  %s: %v\n", path, err)
+			case encryption.IsEncrypted(data):
+				fmt.Println(i18n.T("  %s: 已加密", path))
+			default:
+				fmt.Println(i18n.T("  %s: 未加密", path))
+			}
+		}
+		return nil
+
+	case "encrypt", "decrypt":
+		encrypt := rest[0] == "encrypt"
+		if encrypt && !encryption.Enabled() {
+			return errors.New(i18n.T("没有设置密钥（encryption.key 或环境变量 MEAL_AGENT_ENCRYPTION_KEY），用 meal-agent encryption keygen 生成"))
+		}
+		changed := 0
+		for _, path := range encryptedFiles(opts) {
+			raw, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if encryption.IsEncrypted(raw) == encrypt {
+				continue
+			}
+			plain, err := encryption.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if encrypt {
+				err = encryption.WriteFile(path, plain, 0600)
+			} else {
+				err = os.WriteFile(path, plain, 0600)
+			}
+			if err != nil {
+				return fmt.Errorf(i18n.T("写入 %s 失败: %v"), path, err)
+			}
+			fmt.Println(path)
+			changed++
+		}
+		if encrypt {
+			fmt.Println(i18n.T("已加密 %d 个文件", changed))
+		} else {
+			fmt.Println(i18n.T("已解密 %d 个文件", changed))
+		}
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: encryption %s\n%s"), rest[0], i18n.T(encryptionUsage))}
+}
+
+// encryptedFiles 设置密钥后加密保存的文件：用餐历史（包括备份和归档）、学到的权重调整和偏好配置
+func encryptedFiles(opts *options) []string {
+	files := []string{
+		filepath.Join(opts.dataDir, "history.json"),
+		filepath.Join(opts.dataDir, "history.json.bak"),
+		filepath.Join(opts.dataDir, "cooking.json"),
+		filepath.Join(opts.dataDir, "cooking.json.bak"),
+		filepath.Join(opts.dataDir, "pantry.json"),
+		filepath.Join(opts.dataDir, "pantry.json.bak"),
+	}
+	archived, _ := filepath.Glob(filepath.Join(opts.dataDir, "archive", "history_*.json"))
+	learned, _ := filepath.Glob(filepath.Join(opts.dataDir, "learned*.json"))
+	files = append(append(files, archived...), learned...)
+	files = append(files, opts.prefPath)
+	// 其他人的偏好配置（配置无法加载时跳过）
+	if cfg, err := config.Load(opts.configPath, opts.sets...); err == nil {
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, cfg.Profiles[name])
+		}
+	}
+	return files
+}
+
+// maskSecret 只显示密钥的开头和结尾
+func maskSecret(s string) string {
+	r := []rune(s)
+	if len(r) <= 8 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-6) + string(r[len(r)-3:])
+}
+
+// orDefault s 为空时返回 def
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func runLearnedCommand(opts *options, args []string) error {
+	fs := opts.flags("learned")
+	rest := parseArgs(fs, args)
+	learner, err := preference.NewLearner(learnedPath(opts.dataDir, opts.user), 0)
+	if err != nil {
+		return fmt.Errorf(i18n.T("加载学习记录失败: %v"), err)
+	}
+	fmt.Println(learnedCommand(learner, rest))
+	return nil
+}
+
+const pantryUsage = `子命令:
+  list                                         列出家里的食材（默认）
+  add <食材> [数量] [过期:日期] [常备:数量]    买了食材，如 add 鸡蛋 10个 过期:7天 常备:4
+  use <食材> [数量]                            用掉一些（不写数量时全部用完）
+  remove <食材>                                从库存中删除
+
+过期可以写 2024-07-01、07-01、3天、明天。常备食材低于常备数量时，每天的提醒中会提示补货；
+做饭模式推荐菜谱时优先用快过期的食材，购物清单中不再列出家里有的。`
+
+func runPantryCommand(opts *options, args []string) error {
+	fs := opts.flags("pantry")
+	rest := parseArgs(fs, args)
+	fmt.Println(pantryCommand(memory.NewPantry(opts.dataDir), rest))
+	return nil
+}
+
+func runSyncCommand(opts *options, args []string) error {
+	opts.flags("sync").Parse(args)
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return fmt.Errorf(i18n.T("加载配置失败: %v"), err)
+	}
+	history, err := memory.NewHistory(opts.dataDir)
+	if err != nil {
+		return fmt.Errorf(i18n.T("初始化历史记录失败: %v"), err)
+	}
+	return runSync(cfg, history, opts.dataDir, opts.prefPath)
+}
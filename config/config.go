@@ -1,52 +1,705 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"meal-agent/tools"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Location Location  `yaml:"location"`
-	Schedule Schedule  `yaml:"schedule"`
-	Blacklist []string `yaml:"blacklist"`
-	TempExclude []string `yaml:"temp_exclude"`
-	API      APIConfig `yaml:"api"`
-	LLM      LLMConfig `yaml:"llm"`
+	Language    string              `yaml:"language"` // 界面和回复的语言: zh（默认）/ en
+	Location    Location            `yaml:"location"`
+	Locations   map[string]Location `yaml:"locations"` // 其他常用位置：名称 -> 位置（--location 或对话中"我现在在家"切换）
+	Search      Search              `yaml:"search"`
+	Filters     Filters             `yaml:"filters"`
+	Budget      Budget              `yaml:"budget"`
+	Delivery    Delivery            `yaml:"delivery"`
+	Canteen     CanteenConfig       `yaml:"canteen"`
+	Cooking     CookingConfig       `yaml:"cooking"`
+	Schedule    Schedule            `yaml:"schedule"`
+	Weather     WeatherRules        `yaml:"weather"`
+	FoodRules   []tools.FoodRule    `yaml:"food_rules"` // 天气→饮食规则（留空使用内置规则）
+	Seasonal    Seasonal            `yaml:"seasonal"`
+	History     HistoryConfig       `yaml:"history"`
+	Nutrition   NutritionConfig     `yaml:"nutrition"`
+	Health      HealthConfig        `yaml:"health"`
+	Learning    LearningConfig      `yaml:"learning"`
+	NameMatch   NameMatch           `yaml:"name_match"`
+	Profiles    map[string]string   `yaml:"profiles"` // 其他人的偏好配置：名称 -> restaurants.yaml 格式的文件（一起吃饭时合并）
+	Sync        SyncConfig          `yaml:"sync"`
+	Notify      NotifyConfig        `yaml:"notify"`
+	Server      ServerConfig        `yaml:"server"`
+	Log         LogConfig           `yaml:"log"`
+	Network     NetworkConfig       `yaml:"network"`
+	Encryption  EncryptionConfig    `yaml:"encryption"`
+	Plugins     []PluginConfig      `yaml:"plugins"` // 外部插件：提供工具（如公司食堂菜单）或接收提醒
+	Blacklist   []string            `yaml:"blacklist"`
+	TempExclude []string            `yaml:"temp_exclude"`
+	API         APIConfig           `yaml:"api"`
+	LLM         LLMConfig           `yaml:"llm"`
 }
 
 type Location struct {
-	Lat    string `yaml:"lat"`
-	Lng    string `yaml:"lng"`
-	City   string `yaml:"city"`
-	Radius int    `yaml:"radius"`
+	Lat     string   `yaml:"lat"`
+	Lng     string   `yaml:"lng"`
+	Address string   `yaml:"address,omitempty"` // 地址（未填写坐标时加载配置时通过高德解析为坐标）
+	City    string   `yaml:"city"`
+	Radius  int      `yaml:"radius"`
+	Name    string   `yaml:"name,omitempty"`    // 默认位置的名称（可选，locations 中的位置以键为名称）
+	Aliases []string `yaml:"aliases,omitempty"` // 对话中的叫法，如 "家"、"公司"
+}
+
+// DefaultLocationName 切换回 location 配置的默认位置时使用的名称
+const DefaultLocationName = "default"
+
+// FindLocation 按名称或叫法查找位置（不区分大小写），返回位置和名称（默认位置的名称为 location.name，未设置时为 default）
+func (c *Config) FindLocation(name string) (Location, string, bool) {
+	name = strings.TrimSpace(name)
+	matches := func(key string, loc Location) bool {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+		for _, alias := range loc.Aliases {
+			if strings.EqualFold(name, alias) {
+				return true
+			}
+		}
+		return false
+	}
+	defaultName := c.Location.Name
+	if defaultName == "" {
+		defaultName = DefaultLocationName
+	}
+	if matches(defaultName, c.Location) || strings.EqualFold(name, DefaultLocationName) || name == "默认位置" {
+		return c.Location, defaultName, true
+	}
+	if loc, ok := c.Locations[name]; ok {
+		return loc, name, true
+	}
+	for key, loc := range c.Locations {
+		if matches(key, loc) {
+			return loc, key, true
+		}
+	}
+	return Location{}, "", false
+}
+
+// LocationNames 默认位置和 locations 中的名称（排序后，默认位置在最前面）
+func (c *Config) LocationNames() []string {
+	names := make([]string, 0, len(c.Locations))
+	for name := range c.Locations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	defaultName := c.Location.Name
+	if defaultName == "" {
+		defaultName = DefaultLocationName
+	}
+	return append([]string{defaultName}, names...)
+}
+
+// Search 餐厅搜索配置
+type Search struct {
+	MaxResults    int    `yaml:"max_results"`    // 最多获取的餐厅数量（自动翻页）
+	MinCandidates int    `yaml:"min_candidates"` // 过滤后候选少于该数量时自动扩大搜索范围
+	MaxRadius     int    `yaml:"max_radius"`     // 扩大搜索范围的上限（米）
+	CacheTTL      string `yaml:"cache_ttl"`      // 搜索结果缓存时长，如 "24h"；"0" 关闭缓存
+	CacheDir      string `yaml:"cache_dir"`      // 缓存目录（留空使用 数据目录/cache）
+}
+
+// CacheDuration 解析缓存时长（未配置或格式错误时默认 24 小时）
+func (s Search) CacheDuration() time.Duration {
+	if s.CacheTTL == "" {
+		return 24 * time.Hour
+	}
+	d, err := time.ParseDuration(s.CacheTTL)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	return d
+}
+
+// Filters 候选餐厅过滤配置
+type Filters struct {
+	OpenNow        bool `yaml:"open_now"`         // 过滤掉推荐时已打烊的餐厅
+	MaxWalkMinutes int  `yaml:"max_walk_minutes"` // 最长步行时间（分钟，0 不限制；恶劣天气自动收紧）
+}
+
+// Budget 每餐人均预算（元，0 表示不限制）
+type Budget struct {
+	LunchMax   int `yaml:"lunch_max"`
+	DinnerMax  int `yaml:"dinner_max"`
+	MonthlyMax int `yaml:"monthly_max"` // 每月餐饮预算（0 不限制）
+}
+
+// MaxFor 返回某餐的预算上限
+func (b Budget) MaxFor(mealType string) int {
+	if mealType == "dinner" {
+		return b.DinnerMax
+	}
+	return b.LunchMax
+}
+
+// Delivery 外卖模式配置
+type Delivery struct {
+	Enabled             bool `yaml:"enabled"`                // 是否允许外卖模式
+	SuggestInBadWeather bool `yaml:"suggest_in_bad_weather"` // 恶劣天气时主动建议点外卖
+	MaxDistance         int  `yaml:"max_distance"`           // 外卖配送范围（米）
+	BaseFee             int  `yaml:"base_fee"`               // 起步配送费（元）
+}
+
+// CanteenConfig 公司食堂：当天的菜单作为一个距离为 0 的候选，和附近餐厅一起排序
+type CanteenConfig struct {
+	Menu      string   `yaml:"menu"`      // 菜单文件或 http(s) 地址（留空不启用，格式参考 canteen.example.yaml）
+	Name      string   `yaml:"name"`      // 菜单中没有填写名称时使用的名称，默认 "公司食堂"
+	Weight    int      `yaml:"weight"`    // 食堂的权重（%），100 与同等条件的餐厅相同，大于 100 更常推荐食堂
+	Locations []string `yaml:"locations"` // 只在这些位置推荐食堂（位置名称，如 公司），留空时所有位置都推荐
+}
+
+// CookingConfig 做饭模式：推荐在家做的菜谱和购物清单，而不是附近的餐厅
+type CookingConfig struct {
+	Servings   int      `yaml:"servings"`    // 几人份，默认 1
+	MaxMinutes int      `yaml:"max_minutes"` // 准备加烹饪最多多少分钟，0 不限制
+	Recipes    int      `yaml:"recipes"`     // 每次推荐几道菜（2~3），默认 3
+	Pantry     []string `yaml:"pantry"`      // 家里现有的食材（优先用它们，不列入购物清单）
+}
+
+// WeatherRules 天气对候选排序的影响：下雨、酷热、严寒时远的餐厅降权
+type WeatherRules struct {
+	HotTemp     int `yaml:"hot_temp"`     // 气温不低于该值视为酷热（°C）
+	ColdTemp    int `yaml:"cold_temp"`    // 气温不高于该值视为严寒（°C）
+	RainPop     int `yaml:"rain_pop"`     // 降水概率不低于该值视为会下雨（%）
+	FarDistance int `yaml:"far_distance"` // 超过该距离（米）的餐厅降权，步行时间按每分钟 80 米折算
+	FarPenalty  int `yaml:"far_penalty"`  // 降权分数，超过两倍距离时加倍；0 关闭
+}
+
+// Seasonal 节气和节日饮食建议（冬至饺子、端午粽子等）
+type Seasonal struct {
+	Enabled *bool `yaml:"enabled"` // 默认开启
+	Boost   int   `yaml:"boost"`   // 相关餐厅的加分
+}
+
+// IsEnabled 是否启用节气和节日建议（未配置时启用）
+func (s Seasonal) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// NameMatch 偏好配置中的餐厅名称如何匹配搜索到的餐厅（规范化名称精确匹配之外）
+type NameMatch struct {
+	Brand  *bool   `yaml:"brand"`  // 忽略分店后缀，"海底捞" 匹配 "海底捞(国贸店)"（默认开启）
+	Pinyin bool    `yaml:"pinyin"` // 按拼音匹配，"haidilao" 匹配 "海底捞"
+	Fuzzy  float64 `yaml:"fuzzy"`  // 名称相似度阈值（0~1，如 0.8），0 关闭
+}
+
+// BrandEnabled 是否忽略分店后缀匹配（未配置时开启）
+func (m NameMatch) BrandEnabled() bool {
+	return m.Brand == nil || *m.Brand
+}
+
+// HistoryConfig 用餐历史配置
+type HistoryConfig struct {
+	ArchiveMonths int `yaml:"archive_months"` // 超过该月数的记录归档到按年划分的文件（-1 不归档）
+}
+
+// NutritionConfig 用餐营养估算（记录用餐时估算热量、蛋白质、脂肪，统计中展示每周热量趋势）
+type NutritionConfig struct {
+	Enabled bool           `yaml:"enabled"`
+	Source  string         `yaml:"source"` // table（按菜系查表，默认）/ llm（让 LLM 估算，失败时查表）
+	Goals   NutritionGoals `yaml:"goals"`
+}
+
+// NutritionGoals 每天的营养目标（0 表示不设置），只计算记录的用餐
+// 设置热量目标后，前面几餐吃多了时，这一餐推荐热量低的餐厅
+type NutritionGoals struct {
+	Calories float64 `yaml:"calories"` // 每天最多摄入的热量（千卡）
+	Protein  float64 `yaml:"protein"`  // 每天至少摄入的蛋白质（克）
+	Fat      float64 `yaml:"fat"`      // 每天最多摄入的脂肪（克）
+}
+
+// Set 是否设置了任意一项目标
+func (g NutritionGoals) Set() bool {
+	return g.Calories > 0 || g.Protein > 0 || g.Fat > 0
+}
+
+// HealthConfig 健康状况的饮食指导（可以同时选择多种）
+type HealthConfig struct {
+	Conditions []string                       `yaml:"conditions"` // 内置的 high_blood_sugar / gout / pregnancy / hypertension（也可以写 高血糖、痛风、孕期、高血压），或 profiles 中自定义的名称
+	Profiles   map[string]tools.HealthProfile `yaml:"profiles"`   // 自定义的健康状况，与内置的同名时覆盖
+}
+
+// LearningConfig 根据评分和选择自动调整餐厅权重
+type LearningConfig struct {
+	Enabled bool    `yaml:"enabled"`
+	Rate    float64 `yaml:"rate"` // 学习率，1 为默认幅度（评 5 分 +10），0.5 减半
+}
+
+// SyncConfig 云同步配置（多台设备共用一份用餐历史和偏好）
+type SyncConfig struct {
+	Provider  string `yaml:"provider"`   // webdav / s3 / git，留空不同步
+	Auto      bool   `yaml:"auto"`       // 启动时自动同步
+	URL       string `yaml:"url"`        // WebDAV 目录地址 / S3 服务地址 / git 仓库地址
+	Username  string `yaml:"username"`   // WebDAV 用户名
+	Password  string `yaml:"password"`   // WebDAV 密码（应用密码）
+	Bucket    string `yaml:"bucket"`     // S3 存储桶
+	Prefix    string `yaml:"prefix"`     // S3 对象键前缀
+	Region    string `yaml:"region"`     // S3 区域
+	AccessKey string `yaml:"access_key"` // S3 Access Key
+	SecretKey string `yaml:"secret_key"` // S3 Secret Key
+	Branch    string `yaml:"branch"`     // git 分支（默认 main）
+}
+
+// ServerConfig 局域网网页和聊天机器人（-mode server）
+type ServerConfig struct {
+	Listen  string        `yaml:"listen"`  // 监听地址（默认 ":8080"，只在本机使用时写 "127.0.0.1:8080"）
+	Slack   SlackConfig   `yaml:"slack"`   // Slack 频道中的 /meal 命令
+	Discord DiscordConfig `yaml:"discord"` // Discord 频道中的 /meal 命令
+}
+
+// LogConfig 运行日志（--log-level、--log-format 优先）
+type LogConfig struct {
+	Level      string `yaml:"level"`       // debug / info（默认）/ warn / error
+	Format     string `yaml:"format"`      // text（默认）/ json
+	File       string `yaml:"file"`        // 后台模式和网页服务写入的日志文件，如 data/meal-agent.log（留空输出到终端）
+	MaxSize    int    `yaml:"max_size"`    // 单个日志文件的大小上限（MB，默认 10），超过时切分
+	MaxBackups int    `yaml:"max_backups"` // 保留的旧日志文件数量（默认 5）
+}
+
+// NetworkConfig 外部请求的代理和证书（公司网络中通过代理访问 LLM 等接口）
+type NetworkConfig struct {
+	Proxy   string                   `yaml:"proxy"`    // 代理地址，如 http://proxy.corp:8080（留空使用 HTTPS_PROXY / HTTP_PROXY 环境变量，direct 不使用代理）
+	NoProxy string                   `yaml:"no_proxy"` // 不走代理的域名、IP 或网段，逗号分隔
+	CAFile  string                   `yaml:"ca_file"`  // 额外信任的 CA 证书（PEM）
+	Clients map[string]ClientNetwork `yaml:"clients"`  // 按客户端单独设置: llm / amap / weather / osm / rating / sync / notify / chatbot / trace / canteen，没有填写的项使用上面的设置
+}
+
+// PluginConfig 外部插件：任意语言写的可执行文件，通过标准输入输出交换 JSON（协议见 plugin 包）
+type PluginConfig struct {
+	Name    string   `yaml:"name"`    // 名称（日志和错误提示中使用）
+	Command string   `yaml:"command"` // 可执行文件，如 ./plugins/canteen.py、python3
+	Args    []string `yaml:"args"`    // 参数
+	Timeout string   `yaml:"timeout"` // 每次调用的超时（默认 30s）
+}
+
+// EncryptionConfig 用餐历史和偏好文件的加密
+type EncryptionConfig struct {
+	Key string `yaml:"key"` // 密钥或口令，设置后用 AES-GCM 加密保存；建议写成 keyring:meal-agent/encryption 或用环境变量 MEAL_AGENT_ENCRYPTION_KEY
+}
+
+// ClientNetwork 一个客户端的代理和证书
+type ClientNetwork struct {
+	Proxy   string `yaml:"proxy"`
+	NoProxy string `yaml:"no_proxy"`
+	CAFile  string `yaml:"ca_file"`
+}
+
+// SlackConfig Slack 机器人（Socket Mode，不需要公网地址）
+type SlackConfig struct {
+	AppToken string            `yaml:"app_token"` // 带 connections:write 权限的应用级令牌（xapp-），留空不开启
+	Users    map[string]string `yaml:"users"`     // Slack 用户 ID -> 用餐记录的用户（同 -user），没有列出的人使用共享记录
+}
+
+// DiscordConfig Discord 机器人（Gateway，不需要公网地址）
+type DiscordConfig struct {
+	Token   string            `yaml:"token"`    // Bot Token，留空不开启
+	GuildID string            `yaml:"guild_id"` // 只在这个服务器注册 /meal 命令（立即生效；留空注册全局命令，可能要等一段时间）
+	Users   map[string]string `yaml:"users"`    // Discord 用户 ID -> 用餐记录的用户，没有列出的人使用共享记录
+}
+
+// NotifyConfig 后台模式的提醒方式，可以同时推送到多个地方
+type NotifyConfig struct {
+	Console  *bool           `yaml:"console"`  // 输出到终端（默认开启）
+	Desktop  bool            `yaml:"desktop"`  // 系统桌面通知（macOS / Linux notify-send / Windows）
+	Webhooks []WebhookConfig `yaml:"webhooks"` // 自定义 webhook（只写地址时以 JSON 格式 POST 完整提醒）
+	Bots     []BotConfig     `yaml:"bots"`     // 聊天软件机器人
+	Push     []PushConfig    `yaml:"push"`     // 手机推送
+	Email    EmailConfig     `yaml:"email"`    // 邮件（公司屏蔽了聊天软件 webhook 时使用）
+	Reply    ReplyConfig     `yaml:"reply"`    // 接收通知中的回复（"第二个"、"换一批"）
+
+	// Webhook webhooks 的另一种写法 notify.webhook，可以只写一个；加载后合并到 Webhooks
+	Webhook WebhookList `yaml:"webhook,omitempty"`
+}
+
+// ReplyConfig 回复服务：ntfy 按钮、机器人消息中的链接把回复发到这里，交给产生推荐的对话处理
+type ReplyConfig struct {
+	Listen string           `yaml:"listen"` // 监听地址，如 ":8787"（留空不开启）
+	URL    string           `yaml:"url"`    // 手机、聊天软件访问回复服务的地址，如 "http://192.168.1.10:8787"（留空按 listen 使用本机地址）
+	Wecom  WecomReplyConfig `yaml:"wecom"`  // 企业微信群机器人的消息回调（群里 @机器人 对话）
+
+	HomeAssistant HomeAssistantConfig `yaml:"home_assistant"` // Home Assistant 的传感器和触发推荐接口
+}
+
+// HomeAssistantConfig 回复服务上提供给 Home Assistant 的 /ha/ 接口
+type HomeAssistantConfig struct {
+	Token string `yaml:"token"` // 访问令牌，Home Assistant 请求时带 Authorization: Bearer <token>（留空不开启）
+}
+
+// WecomReplyConfig 企业微信群机器人"接收消息"的回调配置，回调地址为回复服务的 /wecom
+type WecomReplyConfig struct {
+	Token  string `yaml:"token"`   // 回调配置中的 Token
+	AESKey string `yaml:"aes_key"` // 回调配置中的 EncodingAESKey
+}
+
+// Enabled 是否开启了企业微信消息回调
+func (w WecomReplyConfig) Enabled() bool {
+	return w.Token != "" || w.AESKey != ""
+}
+
+// PublicURL 通知中使用的回复服务地址
+func (r ReplyConfig) PublicURL() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	host, port, err := net.SplitHostPort(r.Listen)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// WebhookConfig 自定义 webhook，可以只写地址：webhooks: ["http://..."]
+type WebhookConfig struct {
+	URL      string            `yaml:"url"`
+	Template string            `yaml:"template"` // 请求体模板（Go text/template，字段同提醒的 JSON），留空发送完整提醒的 JSON
+	Secret   string            `yaml:"secret"`   // 签名密钥，设置后请求头带上 X-Meal-Agent-Signature（可选）
+	Headers  map[string]string `yaml:"headers"`  // 额外的请求头，如 Authorization、Content-Type
+}
+
+// UnmarshalYAML 兼容只写地址的写法
+func (w *WebhookConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		w.URL = node.Value
+		return nil
+	}
+	type plain WebhookConfig
+	return node.Decode((*plain)(w))
+}
+
+// WebhookList notify.webhook：可以写一个（地址或映射），也可以写列表
+type WebhookList []WebhookConfig
+
+// UnmarshalYAML 单独一个 webhook 当作只有一项的列表
+func (l *WebhookList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		var w WebhookConfig
+		if err := node.Decode(&w); err != nil {
+			return err
+		}
+		*l = WebhookList{w}
+		return nil
+	}
+	type plain WebhookList
+	return node.Decode((*plain)(l))
+}
+
+// PushConfig 手机推送服务
+type PushConfig struct {
+	Type   string `yaml:"type"`   // serverchan（Server酱，推送到微信）/ bark（iOS）/ ntfy
+	Key    string `yaml:"key"`    // Server酱 SendKey / Bark 设备 Key
+	Server string `yaml:"server"` // Bark、ntfy 自建服务地址（留空使用官方服务）
+	Topic  string `yaml:"topic"`  // ntfy 主题
+	Token  string `yaml:"token"`  // ntfy 访问令牌（可选）
+}
+
+// EmailConfig 通过 SMTP 发送邮件：推荐以餐厅卡片的网页格式显示
+type EmailConfig struct {
+	Host     string   `yaml:"host"`      // SMTP 服务器，如 smtp.qq.com（留空不发送邮件）
+	Port     int      `yaml:"port"`      // 端口（默认 465；465 使用 SSL，其他端口服务器支持时使用 STARTTLS）
+	Username string   `yaml:"username"`  // 登录用户名（留空不登录）
+	Password string   `yaml:"password"`  // 密码或授权码
+	From     string   `yaml:"from"`      // 发件人（默认同 username）
+	To       []string `yaml:"to"`        // 收件人
+	ReportTo []string `yaml:"report_to"` // 每周报告的收件人（默认同 to）
+}
+
+// Enabled 是否发送邮件
+func (e EmailConfig) Enabled() bool {
+	return e.Host != ""
+}
+
+// BotConfig 聊天软件机器人
+type BotConfig struct {
+	Type   string `yaml:"type"`    // wecom（企业微信）/ dingtalk（钉钉）/ feishu（飞书）/ telegram
+	URL    string `yaml:"url"`     // 群机器人的 webhook 地址
+	Secret string `yaml:"secret"`  // 钉钉、飞书机器人的加签密钥（可选）
+	Token  string `yaml:"token"`   // Telegram Bot Token
+	ChatID string `yaml:"chat_id"` // Telegram 会话 ID
+}
+
+// ConsoleEnabled 是否输出到终端（未配置时开启）
+func (n NotifyConfig) ConsoleEnabled() bool {
+	return n.Console == nil || *n.Console
 }
 
 type Schedule struct {
-	Lunch  string `yaml:"lunch"`
-	Dinner string `yaml:"dinner"`
+	Lunch         string                 `yaml:"lunch"`
+	Dinner        string                 `yaml:"dinner"`
+	MealDelay     string                 `yaml:"meal_delay"`     // 提醒后多久去吃饭（如 "1h"），按那时的天气预报推荐
+	Location      string                 `yaml:"location"`       // 工作日推荐时使用的位置（locations 中的名称，留空不自动切换）
+	Weekend       DaySchedule            `yaml:"weekend"`        // 周六、周日的提醒时间和位置（留空的项同工作日）
+	Days          map[string]DaySchedule `yaml:"days"`           // 按星期单独设置（周一~周日 或 mon~sun），优先于 weekend
+	Holidays      string                 `yaml:"holidays"`       // 法定节假日：normal（按星期，默认）/ shift（按周末时间）/ skip（按周末时间且不提醒午餐）
+	ExtraHolidays []string               `yaml:"extra_holidays"` // 补充的放假日期，如公司额外的假期
+	ExtraWorkdays []string               `yaml:"extra_workdays"` // 补充的上班日期
+	Remind        RemindConfig           `yaml:"remind"`
+	CatchUp       int                    `yaml:"catch_up"` // 睡眠或停机错过提醒时间后多少分钟内补发（默认 60，-1 不补发）
+	Preview       PreviewConfig          `yaml:"preview"`  // 提醒前的预告（提前订位、取号）
+	Report        string                 `yaml:"report"`   // 每周报告的时间，如 "周日 20:00"（留空不发送）
+
+	calendar *tools.HolidayCalendar // 加载配置时创建
+}
+
+// RemindConfig 再提醒：提醒后一段时间还没有记录这一餐时再提醒，也可以要求稍后提醒
+type RemindConfig struct {
+	After  int `yaml:"after"`  // 提醒后多少分钟还没记录就再提醒（0 不自动再提醒）
+	Max    int `yaml:"max"`    // 每餐最多自动再提醒几次（默认 2，要求的稍后提醒不计入）
+	Snooze int `yaml:"snooze"` // "稍后提醒"没说时间时推迟的分钟数（默认 15）
+}
+
+// PreviewConfig 用餐预告：在提醒前推送可能推荐的餐厅，提醒热门餐厅提前订位或取号
+type PreviewConfig struct {
+	Lunch  int `yaml:"lunch"`  // 午餐提醒前多少分钟预告（0 不预告），如 60 表示 11:30 提醒时 10:30 预告
+	Dinner int `yaml:"dinner"` // 晚餐提醒前多少分钟预告（0 不预告）
+}
+
+// Lead 某餐预告提前的时长，0 表示不预告
+func (p PreviewConfig) Lead(mealType string) time.Duration {
+	minutes := p.Lunch
+	if mealType == "dinner" {
+		minutes = p.Dinner
+	}
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// DaySchedule 某类日子的提醒时间，留空表示沿用工作日的时间，"off" 表示不提醒
+type DaySchedule struct {
+	Lunch    string `yaml:"lunch,omitempty"`
+	Dinner   string `yaml:"dinner,omitempty"`
+	Location string `yaml:"location,omitempty"` // 这些天推荐时使用的位置（locations 中的名称）
+}
+
+// ScheduleOff 提醒时间写 off 表示当天不提醒
+const ScheduleOff = "off"
+
+// 节假日的处理方式
+const (
+	HolidaysNormal = "normal"
+	HolidaysShift  = "shift"
+	HolidaysSkip   = "skip"
+)
+
+// merge 用 d 中填写的项覆盖
+func (s DaySchedule) merge(d DaySchedule) DaySchedule {
+	if d.Lunch != "" {
+		s.Lunch = d.Lunch
+	}
+	if d.Dinner != "" {
+		s.Dinner = d.Dinner
+	}
+	if d.Location != "" {
+		s.Location = d.Location
+	}
+	return s
+}
+
+// TimesOn 某天的午餐、晚餐提醒时间（"15:04"），为空表示当天不提醒；note 说明按哪类日子安排，如 "国庆节"
+func (s Schedule) TimesOn(t time.Time) (lunch, dinner, note string) {
+	day, note := s.dayOn(t)
+	lunch, dinner = day.Lunch, day.Dinner
+	if lunch == ScheduleOff {
+		lunch = ""
+	}
+	if dinner == ScheduleOff {
+		dinner = ""
+	}
+	return lunch, dinner, note
+}
+
+// LocationOn 某天推荐时使用的位置名称，为空表示不自动切换
+func (s Schedule) LocationOn(t time.Time) string {
+	day, _ := s.dayOn(t)
+	return day.Location
+}
+
+// dayOn 某天生效的安排
+// 优先级：节假日（shift / skip）> 调休上班日（按工作日）> days > weekend > 工作日
+func (s Schedule) dayOn(t time.Time) (day DaySchedule, note string) {
+	day = DaySchedule{Lunch: s.Lunch, Dinner: s.Dinner, Location: s.Location}
+	kind := tools.Workday
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		kind = tools.Weekend
+	}
+	var name string
+	if s.calendar != nil && s.Holidays != "" && s.Holidays != HolidaysNormal {
+		kind, name = s.calendar.KindOf(t)
+	}
+
+	switch {
+	case kind == tools.Holiday:
+		day = day.merge(s.Weekend)
+		if s.Holidays == HolidaysSkip {
+			day.Lunch = ""
+		}
+		note = name
+	case kind == tools.Workday && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday):
+		note = "调休上班"
+	default:
+		if kind == tools.Weekend {
+			day = day.merge(s.Weekend)
+		}
+		for key, d := range s.Days {
+			if weekday, ok := tools.ParseWeekday(key); ok && weekday == t.Weekday() {
+				day = day.merge(d)
+			}
+		}
+	}
+	return day, note
+}
+
+// validate 检查提醒时间、星期和日期的写法，创建节假日日历
+func (s *Schedule) validate(v *validator) {
+	checkTime := func(where, value string) {
+		if value == "" || value == ScheduleOff {
+			return
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			v.add("schedule."+where, "格式应为 11:30 或 off: %s", value)
+		}
+	}
+	checkTime("lunch", s.Lunch)
+	checkTime("dinner", s.Dinner)
+	checkTime("weekend.lunch", s.Weekend.Lunch)
+	checkTime("weekend.dinner", s.Weekend.Dinner)
+	for key, d := range s.Days {
+		if _, ok := tools.ParseWeekday(key); !ok {
+			v.add("schedule.days."+key, "无法识别的星期: %s（可用 周一~周日 或 mon~sun）", key)
+		}
+		checkTime("days."+key+".lunch", d.Lunch)
+		checkTime("days."+key+".dinner", d.Dinner)
+	}
+
+	if s.Preview.Lunch < 0 || s.Preview.Lunch > 12*60 {
+		v.add("schedule.preview.lunch", "应为 0~720 分钟: %d", s.Preview.Lunch)
+	}
+	if s.Preview.Dinner < 0 || s.Preview.Dinner > 12*60 {
+		v.add("schedule.preview.dinner", "应为 0~720 分钟: %d", s.Preview.Dinner)
+	}
+
+	if s.Report != "" {
+		if _, _, ok := s.ReportTime(); !ok {
+			v.add("schedule.report", "格式应为 周日 20:00 或 sun 20:00: %s", s.Report)
+		}
+	}
+
+	switch s.Holidays {
+	case "", HolidaysNormal, HolidaysShift, HolidaysSkip:
+	default:
+		v.add("schedule.holidays", "应为 normal / shift / skip: %s", s.Holidays)
+	}
+	calendar, err := tools.NewHolidayCalendar(s.ExtraHolidays, s.ExtraWorkdays)
+	if err != nil {
+		v.add("schedule", "%v", err)
+		return
+	}
+	s.calendar = calendar
+}
+
+// ReportTime 每周报告的星期和时间（"20:00"），未设置或格式错误时 ok 为 false
+func (s Schedule) ReportTime() (day time.Weekday, at string, ok bool) {
+	fields := strings.Fields(s.Report)
+	if len(fields) != 2 {
+		return 0, "", false
+	}
+	day, ok = tools.ParseWeekday(fields[0])
+	if _, err := time.Parse("15:04", fields[1]); err != nil {
+		return 0, "", false
+	}
+	return day, fields[1], ok
+}
+
+// CatchUpDuration 错过提醒时间后仍然补发的时长
+func (s Schedule) CatchUpDuration() time.Duration {
+	if s.CatchUp < 0 {
+		return 0
+	}
+	return time.Duration(s.CatchUp) * time.Minute
+}
+
+// MealDelayDuration 解析提醒到用餐的间隔（未配置或格式错误时默认 1 小时）
+func (s Schedule) MealDelayDuration() time.Duration {
+	d, err := time.ParseDuration(s.MealDelay)
+	if err != nil || d < 0 {
+		return time.Hour
+	}
+	return d
 }
 
 type APIConfig struct {
-	AmapKey    string `yaml:"amap_key"`
-	WeatherKey string `yaml:"weather_key"`
+	RestaurantProvider string `yaml:"restaurant_provider"` // amap（默认）/ osm / static
+	AmapKey            string `yaml:"amap_key"`
+	AmapDailyQuota     int    `yaml:"amap_daily_quota"` // 高德每日调用配额（0 不限制）
+	AmapQPS            int    `yaml:"amap_qps"`         // 高德每秒最多请求数
+	OverpassURL        string `yaml:"overpass_url"`     // 可选，OSM Overpass 接口地址
+	StaticList         string `yaml:"static_list"`      // 自定义餐厅列表文件（provider 为 static 时使用）
+	WeatherProvider    string `yaml:"weather_provider"` // qweather（默认）/ openweathermap / open-meteo
+	WeatherKey         string `yaml:"weather_key"`      // 所选天气服务的 Key（open-meteo 不需要）
+	RatingURL          string `yaml:"rating_url"`       // 可选，第三方评分接口（如大众点评代理服务）
+	RatingKey          string `yaml:"rating_key"`       // 可选，评分接口的 Key
 }
 
 type LLMConfig struct {
-	Provider string `yaml:"provider"`
-	APIKey   string `yaml:"api_key"`
-	BaseURL  string `yaml:"base_url"`
-	Model    string `yaml:"model"`
+	Provider string       `yaml:"provider"`
+	APIKey   string       `yaml:"api_key"`
+	BaseURL  string       `yaml:"base_url"`
+	Model    string       `yaml:"model"`
+	Safety   SafetyConfig `yaml:"safety"`
+	Privacy  bool         `yaml:"privacy"` // 隐私模式：发给 LLM 的内容去掉准确地址、电话和坐标，地址换成距离和步行时间
+	Tools    bool         `yaml:"tools"`   // 推荐和对话时允许 LLM 调用工具（天气、餐厅搜索、用餐记录和其他注册的工具，需要模型支持 function calling）
 }
 
-func Load(path string) (*Config, error) {
+// SafetyConfig 内容安全过滤配置
+type SafetyConfig struct {
+	Enabled       bool     `yaml:"enabled"`        // 是否启用过滤
+	FilterInput   bool     `yaml:"filter_input"`   // 拒绝与用餐无关的输入
+	FilterOutput  bool     `yaml:"filter_output"`  // 清理 LLM 输出中的医疗功效和虚构电话
+	BlockedWords  []string `yaml:"blocked_words"`  // 额外需要从输出中剔除的词语
+	RefuseMessage string   `yaml:"refuse_message"` // 拒绝无关输入时的回复（留空使用默认）
+}
+
+// Load 加载配置文件，环境变量 MEAL_AGENT_* 和 overrides（--set）覆盖其中的值，keyring: 开头的值从系统钥匙串读取
+func Load(path string, overrides ...Override) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	doc, err := parseDocument(data, overrides)
+	if err != nil {
+		return nil, err
+	}
+	// 先按配置结构检查；写错类型的值解析时跳过（已经记录了问题），其余的项继续检查，最后一起报告
+	v := &validator{root: doc.Content[0]}
+	v.checkNode(doc.Content[0], reflect.TypeOf(Config{}), "")
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := doc.Decode(&cfg); err != nil {
+		if _, ok := err.(*yaml.TypeError); !ok || len(v.issues) == 0 {
+			return nil, err
+		}
+	}
+	if err := cfg.resolveSecrets(); err != nil {
 		return nil, err
 	}
 
@@ -54,10 +707,178 @@ func Load(path string) (*Config, error) {
 	if cfg.Location.Radius == 0 {
 		cfg.Location.Radius = 1000
 	}
+	if cfg.Search.MaxResults == 0 {
+		cfg.Search.MaxResults = 100
+	}
+	if cfg.API.AmapQPS == 0 {
+		cfg.API.AmapQPS = 3
+	}
+	if cfg.API.StaticList == "" {
+		cfg.API.StaticList = "nearby.yaml"
+	}
+	if cfg.Delivery.MaxDistance == 0 {
+		cfg.Delivery.MaxDistance = 3000
+	}
+	if cfg.Canteen.Name == "" {
+		cfg.Canteen.Name = "公司食堂"
+	}
+	if cfg.Canteen.Weight == 0 {
+		cfg.Canteen.Weight = 100
+	}
+	if cfg.Cooking.Servings == 0 {
+		cfg.Cooking.Servings = 1
+	}
+	if cfg.Cooking.Recipes == 0 {
+		cfg.Cooking.Recipes = 3
+	}
+	if cfg.Search.MinCandidates == 0 {
+		cfg.Search.MinCandidates = 5
+	}
+	if cfg.Search.MaxRadius == 0 {
+		cfg.Search.MaxRadius = cfg.Location.Radius * 2
+	}
+	if cfg.Weather == (WeatherRules{}) {
+		// 未配置时启用默认规则（0°C 是合法的严寒阈值，只在整段未配置时设置）
+		cfg.Weather.ColdTemp = 5
+		cfg.Weather.FarPenalty = 20
+	}
+	if cfg.Weather.HotTemp == 0 {
+		cfg.Weather.HotTemp = 33
+	}
+	if cfg.Weather.RainPop == 0 {
+		cfg.Weather.RainPop = 60
+	}
+	if cfg.Weather.FarDistance == 0 {
+		cfg.Weather.FarDistance = 800
+	}
+	if cfg.History.ArchiveMonths == 0 {
+		cfg.History.ArchiveMonths = 12
+	}
+	if cfg.Learning.Rate <= 0 {
+		cfg.Learning.Rate = 1
+	}
+	if cfg.Seasonal.Boost == 0 {
+		cfg.Seasonal.Boost = 15
+	}
+	if cfg.Log.MaxSize == 0 {
+		cfg.Log.MaxSize = 10
+	}
+	if cfg.Log.MaxBackups == 0 {
+		cfg.Log.MaxBackups = 5
+	}
+	if cfg.Server.Listen == "" {
+		cfg.Server.Listen = ":8080"
+	}
+	if cfg.Notify.Email.Port == 0 {
+		cfg.Notify.Email.Port = 465
+	}
+
+	if cfg.Schedule.CatchUp == 0 {
+		cfg.Schedule.CatchUp = 60
+	}
+	if cfg.Schedule.Remind.Max == 0 {
+		cfg.Schedule.Remind.Max = 2
+	}
+	if cfg.Schedule.Remind.Snooze == 0 {
+		cfg.Schedule.Remind.Snooze = 15
+	}
+	cfg.validate(v)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	cfg.Notify.Webhooks = append(cfg.Notify.Webhooks, cfg.Notify.Webhook...)
+	cfg.Notify.Webhook = nil
 
 	return &cfg, nil
 }
 
+// validateLocations 检查坐标，补全 locations 中未填写的城市、半径，检查 schedule 中的位置名称
+// 只填写了地址的位置由 ResolveAddresses 补全坐标和城市
+func (c *Config) validateLocations(v *validator) {
+	checkCoordinates(v, "location", c.Location)
+	for name, loc := range c.Locations {
+		path := "locations." + name
+		if (loc.Lat == "" || loc.Lng == "") && loc.Address == "" {
+			v.add(path, "需要填写 lat、lng 或 address")
+		} else {
+			checkCoordinates(v, path, loc)
+		}
+		if loc.City == "" && !loc.needsGeocode() {
+			loc.City = c.Location.City
+		}
+		if loc.Radius == 0 {
+			loc.Radius = c.Location.Radius
+		}
+		c.Locations[name] = loc
+	}
+
+	check := func(where, name string) {
+		if name == "" {
+			return
+		}
+		if _, _, ok := c.FindLocation(name); !ok {
+			v.add("schedule."+where, "位置不存在: %s（可用 %s）", name, strings.Join(c.LocationNames(), " / "))
+		}
+	}
+	check("location", c.Schedule.Location)
+	check("weekend.location", c.Schedule.Weekend.Location)
+	for key, d := range c.Schedule.Days {
+		check("days."+key+".location", d.Location)
+	}
+}
+
+// needsGeocode 只填写了地址、没有坐标
+func (l Location) needsGeocode() bool {
+	return l.Address != "" && (l.Lat == "" || l.Lng == "")
+}
+
+// ResolveAddresses 把只填写了地址的位置解析为坐标（同时填写了坐标时以坐标为准）
+// 未填写城市时使用解析出的城市；locations 中解析不出城市时同 location
+func (c *Config) ResolveAddresses(g *tools.Geocoder) error {
+	resolve := func(field string, loc *Location) error {
+		if !loc.needsGeocode() {
+			return nil
+		}
+		p, err := g.Geocode(loc.Address, loc.City)
+		if err != nil {
+			return fmt.Errorf("%s.address 解析失败: %v", field, err)
+		}
+		loc.Lat, loc.Lng = p.Lat, p.Lng
+		if loc.City == "" {
+			loc.City = p.City
+		}
+		return nil
+	}
+
+	if err := resolve("location", &c.Location); err != nil {
+		return err
+	}
+	for name, loc := range c.Locations {
+		if err := resolve("locations."+name, &loc); err != nil {
+			return err
+		}
+		if loc.City == "" {
+			loc.City = c.Location.City
+		}
+		c.Locations[name] = loc
+	}
+	return nil
+}
+
+// PeekLanguage 只读取配置中的 language（加载配置之前显示帮助时使用，同样可以被覆盖），读取失败时为空
+func PeekLanguage(path string, overrides ...Override) string {
+	data, _ := os.ReadFile(path)
+	doc, err := parseDocument(data, overrides)
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		Language string `yaml:"language"`
+	}
+	doc.Decode(&cfg)
+	return cfg.Language
+}
+
 // Save 保存配置（用于更新临时排除列表）
 func (c *Config) Save(path string) error {
 	data, err := yaml.Marshal(c)
@@ -68,11 +889,10 @@ func (c *Config) Save(path string) error {
 }
 
 // IsBlacklisted 检查餐厅是否在黑名单中
+// 黑名单支持通配符和正则，临时排除只按名称精确匹配
 func (c *Config) IsBlacklisted(name string) bool {
-	for _, b := range c.Blacklist {
-		if b == name {
-			return true
-		}
+	if tools.NewNameMatcher(c.Blacklist).Match(name) {
+		return true
 	}
 	for _, t := range c.TempExclude {
 		if t == name {
@@ -90,4 +910,4 @@ func (c *Config) AddTempExclude(name string) {
 // ClearTempExclude 清空临时排除（每天清空）
 func (c *Config) ClearTempExclude() {
 	c.TempExclude = []string{}
-}
\ No newline at end of file
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 覆盖配置文件中的值：环境变量 MEAL_AGENT_LLM_API_KEY 或 --set llm.api_key=xxx（密钥不用写在 config.yaml 中）
+// 命令行的 --set 优先于环境变量，环境变量优先于配置文件
+
+// EnvPrefix 覆盖配置的环境变量前缀，其余部分为配置路径的大写、各层用下划线连接
+const EnvPrefix = "MEAL_AGENT_"
+
+// envAliases 常用密钥的简写：MEAL_AGENT_AMAP_KEY 等同于 MEAL_AGENT_API_AMAP_KEY
+var envAliases = map[string]string{
+	"AMAP_KEY":    "api.amap_key",
+	"WEATHER_KEY": "api.weather_key",
+}
+
+// Override 覆盖一项配置
+type Override struct {
+	Path   string // 配置路径，如 llm.api_key、notify.bots.0.token、locations.home.lat
+	Value  string // 值，以 [ 或 { 开头时按 YAML 解析（如 "[川菜, 湘菜]"）
+	Source string // 来源，用于错误提示，如 "--set"、"MEAL_AGENT_LLM_API_KEY"
+}
+
+// ParseOverride 解析 --set 的 "路径=值"
+func ParseOverride(s string) (Override, error) {
+	path, value, ok := strings.Cut(s, "=")
+	path = strings.TrimSpace(path)
+	if !ok || path == "" {
+		return Override{}, fmt.Errorf("应为 路径=值，如 llm.model=qwen-plus: %s", s)
+	}
+	if _, err := splitPath(path); err != nil {
+		return Override{}, err
+	}
+	return Override{Path: path, Value: value, Source: "--set " + path}, nil
+}
+
+// EnvOverrides 环境变量中以 MEAL_AGENT_ 开头的覆盖，按名称排序
+func EnvOverrides(environ []string) ([]Override, error) {
+	var overrides []Override
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, EnvPrefix)
+		path, ok := envAliases[key]
+		if !ok {
+			segments, found := matchEnv(reflect.TypeOf(Config{}), strings.Split(strings.ToLower(key), "_"))
+			if !found {
+				return nil, fmt.Errorf("环境变量 %s 不对应任何配置项（如 %sLLM_API_KEY 对应 llm.api_key）", name, EnvPrefix)
+			}
+			path = strings.Join(segments, ".")
+		}
+		overrides = append(overrides, Override{Path: path, Value: value, Source: name})
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Source < overrides[j].Source })
+	return overrides, nil
+}
+
+// matchEnv 按配置结构把环境变量名中下划线分开的部分组合为各层的键（键中本身带下划线，如 api_key）
+// map 的键只取一段，带下划线的名称请用 --set
+func matchEnv(t reflect.Type, tokens []string) ([]string, bool) {
+	if len(tokens) == 0 {
+		return nil, true
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// 键长的优先：schedule_extra_holidays 先试 extra_holidays
+		for n := len(tokens); n > 0; n-- {
+			key := strings.Join(tokens[:n], "_")
+			field, ok := yamlField(t, key)
+			if !ok {
+				continue
+			}
+			if rest, ok := matchEnv(field.Type, tokens[n:]); ok {
+				return append([]string{key}, rest...), true
+			}
+		}
+	case reflect.Map:
+		if rest, ok := matchEnv(t.Elem(), tokens[1:]); ok {
+			return append([]string{tokens[0]}, rest...), true
+		}
+	case reflect.Slice:
+		if _, err := strconv.Atoi(tokens[0]); err == nil {
+			if rest, ok := matchEnv(t.Elem(), tokens[1:]); ok {
+				return append([]string{tokens[0]}, rest...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// checkPath 按配置结构检查 --set 的路径
+func checkPath(t reflect.Type, segments []string) bool {
+	for _, seg := range segments {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := yamlField(t, seg)
+			if !ok {
+				return false
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice:
+			if _, err := strconv.Atoi(seg); err != nil {
+				return false
+			}
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// yamlField 按 yaml 标签中的键查找结构体字段
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name != "" && name != "-" && name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func splitPath(path string) ([]string, error) {
+	segments := strings.Split(path, ".")
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("配置路径格式错误: %s", path)
+		}
+	}
+	if !checkPath(reflect.TypeOf(Config{}), segments) {
+		return nil, fmt.Errorf("没有这个配置项: %s", path)
+	}
+	return segments, nil
+}
+
+// parseDocument 解析配置文件并按顺序应用环境变量和 overrides 中的覆盖
+func parseDocument(data []byte, overrides []Override) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	env, err := EnvOverrides(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range append(env, overrides...) {
+		if err := applyOverride(doc.Content[0], o); err != nil {
+			return nil, fmt.Errorf("%s: %v", o.Source, err)
+		}
+	}
+	return &doc, nil
+}
+
+// applyOverride 在配置文件的节点中设置一项，没有的层级自动创建
+func applyOverride(root *yaml.Node, o Override) error {
+	segments, err := splitPath(o.Path)
+	if err != nil {
+		return err
+	}
+	value, err := overrideValue(o.Value)
+	if err != nil {
+		return err
+	}
+
+	node := root
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		// 空值（如只写了 "locations:"）当作空的映射或列表
+		if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+			if _, err := strconv.Atoi(seg); err == nil {
+				*node = yaml.Node{Kind: yaml.SequenceNode}
+			} else {
+				*node = yaml.Node{Kind: yaml.MappingNode}
+			}
+		}
+
+		var child *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == seg {
+					child = node.Content[j+1]
+				}
+			}
+			if child == nil {
+				child = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: seg}, child)
+			}
+		case yaml.SequenceNode:
+			index, _ := strconv.Atoi(seg)
+			switch {
+			case index < len(node.Content):
+				child = node.Content[index]
+			case index == len(node.Content):
+				child = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+				node.Content = append(node.Content, child)
+			default:
+				return fmt.Errorf("%s 只有 %d 项", strings.Join(segments[:i], "."), len(node.Content))
+			}
+		default:
+			return fmt.Errorf("%s 不是映射或列表，不能设置其中的 %s", strings.Join(segments[:i], "."), seg)
+		}
+
+		if last {
+			*child = *value
+		}
+		node = child
+	}
+	return nil
+}
+
+// overrideValue 覆盖的值：以 [ 或 { 开头时按 YAML 解析，否则为普通文字（不解析 #、: 等，密钥原样使用）
+func overrideValue(s string) (*yaml.Node, error) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(trimmed), &doc); err != nil {
+			return nil, fmt.Errorf("值格式错误: %v", err)
+		}
+		return doc.Content[0], nil
+	}
+	// 不设置标签：数字、true/false 按字段类型解析
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: s}, nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"meal-agent/keyring"
+)
+
+// resolveSecrets 把写成 keyring:服务/账户 的值换成系统钥匙串中的密钥（任意配置项都可以这样写）
+func (c *Config) resolveSecrets() error {
+	return resolveRefs(reflect.ValueOf(c).Elem(), "")
+}
+
+func resolveRefs(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return resolveRefs(v.Elem(), path)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if err := resolveRefs(v.Field(i), joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveRefs(v.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map 的值不能直接修改，只处理字符串（如 webhook 的 headers）
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			value := v.MapIndex(key).String()
+			if !keyring.IsRef(value) {
+				continue
+			}
+			secret, err := readSecret(joinPath(path, key.String()), value)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(key, reflect.ValueOf(secret).Convert(v.Type().Elem()))
+		}
+	case reflect.String:
+		if keyring.IsRef(v.String()) && v.CanSet() {
+			secret, err := readSecret(path, v.String())
+			if err != nil {
+				return err
+			}
+			v.SetString(secret)
+		}
+	}
+	return nil
+}
+
+// PeekEncryptionKey 只读取配置中的 encryption.key（不加载配置的子命令读写加密文件时使用），
+// 同样可以被环境变量和 --set 覆盖，keyring: 开头时从系统钥匙串读取；没有配置时为空
+func PeekEncryptionKey(path string, overrides ...Override) (string, error) {
+	data, _ := os.ReadFile(path)
+	doc, err := parseDocument(data, overrides)
+	if err != nil {
+		return "", err
+	}
+	var cfg struct {
+		Encryption EncryptionConfig `yaml:"encryption"`
+	}
+	doc.Decode(&cfg)
+	key := cfg.Encryption.Key
+	if keyring.IsRef(key) {
+		return readSecret("encryption.key", key)
+	}
+	return key, nil
+}
+
+func readSecret(path, ref string) (string, error) {
+	secret, err := keyring.Resolve(ref)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("%s: 系统钥匙串中没有 %s（用 meal-agent keyring set %s 保存）", path, ref, strings.TrimPrefix(ref, keyring.Prefix))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: 读取系统钥匙串失败: %v", path, err)
+	}
+	return secret, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,505 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/i18n"
+	"meal-agent/network"
+	"meal-agent/tools"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue 配置中的一个问题
+type Issue struct {
+	Line    int    // 在配置文件中的行号，0 表示不在文件中（来自环境变量、--set 或没有填写）
+	Path    string // 配置路径，如 schedule.lunch、notify.bots.0.url
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("第 %d 行 %s: %s", i.Line, i.Path, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// ValidationError 加载配置时发现的全部问题
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return e.Issues[0].String()
+	}
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = "  " + issue.String()
+	}
+	return fmt.Sprintf("%d 个问题:\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// validator 收集配置中的问题，按配置路径在配置文件中查找行号
+type validator struct {
+	root   *yaml.Node // 配置文件的根映射
+	issues []Issue
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Line: v.line(path), Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err 没有问题时为 nil，问题按行号排列（不在文件中的排在最后）
+func (v *validator) err() error {
+	if len(v.issues) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		a, b := v.issues[i].Line, v.issues[j].Line
+		return a != 0 && (b == 0 || a < b)
+	})
+	return &ValidationError{Issues: v.issues}
+}
+
+// line 配置路径在文件中的行号；没有填写的项为所在上一层的行号
+func (v *validator) line(path string) int {
+	node, line := v.root, 0
+	for _, seg := range strings.Split(path, ".") {
+		var key, child *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					key, child = node.Content[i], node.Content[i+1]
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(seg); err == nil && i < len(node.Content) {
+				child = node.Content[i]
+			}
+		}
+		if child == nil {
+			return line
+		}
+		node, line = child, child.Line
+		// 子项从下一行开始的映射、列表取键所在的行（环境变量设置的值没有行号，保持为 0）
+		if key != nil && child.Kind != yaml.ScalarNode && child.Line != 0 {
+			line = key.Line
+		}
+	}
+	return line
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// checkNode 按配置结构检查配置文件：没有对应配置项的键、写错类型的值
+func (v *validator) checkNode(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return
+	}
+	// 自己解析的类型（如只写地址的 webhook）
+	if node.Kind == yaml.ScalarNode && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+	// 可以只写一项的列表（如 notify.webhook），按列表中的一项检查
+	if node.Kind == yaml.MappingNode && t.Kind() == reflect.Slice && reflect.PtrTo(t).Implements(unmarshalerType) {
+		v.checkNode(node, t.Elem(), path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.add(path, "应为包含子项的映射，而不是 %s", describeNode(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if key == "<<" {
+				continue
+			}
+			field, ok := yamlField(t, key)
+			if !ok {
+				v.issues = append(v.issues, Issue{Line: node.Content[i].Line, Path: joinPath(path, key), Message: "没有这个配置项" + suggestKey(t, key)})
+				continue
+			}
+			v.checkNode(node.Content[i+1], field.Type, joinPath(path, key))
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.add(path, "应为 名称: 值 的映射，而不是 %s", describeNode(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.add(path, "应为列表，如 [a, b]，而不是 %s", describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.checkNode(item, t.Elem(), joinPath(path, strconv.Itoa(i)))
+		}
+	case reflect.Interface:
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.add(path, "应为单个值，而不是 %s", describeNode(node))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			v.add(path, "应为%s: %s", kindName(t.Kind()), node.Value)
+		}
+	}
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "映射"
+	case yaml.SequenceNode:
+		return "列表"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+func kindName(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return " true 或 false"
+	case reflect.Float32, reflect.Float64:
+		return "数字"
+	case reflect.String:
+		return "文字"
+	}
+	return "整数"
+}
+
+// suggestKey 写错的键最接近的配置项，如 "（是不是 dinner？）"
+func suggestKey(t reflect.Type, key string) string {
+	best, bestDistance := "", 3
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance && d < len(name) {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("（是不是 %s？）", best)
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// validate 检查取值：时间、坐标、可选值，以及开启的功能需要填写的项
+func (c *Config) validate(v *validator) {
+	c.Schedule.validate(v)
+	c.validateLocations(v)
+
+	if !i18n.Supported(c.Language) {
+		v.add("language", "不支持: %s（可用 %s）", c.Language, strings.Join(i18n.Languages(), " / "))
+	}
+	if c.NameMatch.Fuzzy < 0 || c.NameMatch.Fuzzy > 1 {
+		v.add("name_match.fuzzy", "应在 0~1 之间: %g", c.NameMatch.Fuzzy)
+	}
+	if _, err := tools.NewFoodRuleSet(c.FoodRules); err != nil {
+		v.add("food_rules", "%v", err)
+	}
+	for i, rule := range c.Blacklist {
+		if err := tools.ValidateNameRule(rule); err != nil {
+			v.add(fmt.Sprintf("blacklist.%d", i), "正则有误: %v", err)
+		}
+	}
+	checkDuration(v, "search.cache_ttl", c.Search.CacheTTL)
+	checkDuration(v, "schedule.meal_delay", c.Schedule.MealDelay)
+	checkListen(v, "server.listen", c.Server.Listen)
+	checkListen(v, "notify.reply.listen", c.Notify.Reply.Listen)
+	if c.Nutrition.Source != "" && c.Nutrition.Source != "table" && c.Nutrition.Source != "llm" {
+		v.add("nutrition.source", "应为 table / llm: %s", c.Nutrition.Source)
+	}
+
+	oneOf(v, "log.level", c.Log.Level, "debug", "info", "warn", "error")
+	oneOf(v, "log.format", c.Log.Format, "text", "json")
+	if c.Log.MaxSize < 0 {
+		v.add("log.max_size", "不能小于 0: %d", c.Log.MaxSize)
+	}
+	if c.Log.MaxBackups < 0 {
+		v.add("log.max_backups", "不能小于 0: %d", c.Log.MaxBackups)
+	}
+
+	c.validateProviders(v)
+	c.validateNotify(v)
+	c.validateNetwork(v)
+	c.validatePlugins(v)
+	c.validateCanteen(v)
+	c.validateNutritionGoals(v)
+	if _, err := tools.NewHealthGuide(c.Health.Conditions, c.Health.Profiles); err != nil {
+		v.add("health.conditions", "%v", err)
+	}
+
+	if c.Cooking.Servings < 0 {
+		v.add("cooking.servings", "不能小于 0: %d", c.Cooking.Servings)
+	}
+	if c.Cooking.MaxMinutes < 0 {
+		v.add("cooking.max_minutes", "不能小于 0: %d", c.Cooking.MaxMinutes)
+	}
+	if c.Cooking.Recipes < 0 || c.Cooking.Recipes > 5 {
+		v.add("cooking.recipes", "应在 1~5 之间: %d", c.Cooking.Recipes)
+	}
+}
+
+// validateCanteen 食堂的权重和推荐位置
+func (c *Config) validateCanteen(v *validator) {
+	if c.Canteen.Weight < 0 {
+		v.add("canteen.weight", "不能小于 0: %d", c.Canteen.Weight)
+	}
+	for i, name := range c.Canteen.Locations {
+		if _, _, ok := c.FindLocation(name); !ok {
+			v.add(fmt.Sprintf("canteen.locations.%d", i), "位置不存在: %s（可用 %s）", name, strings.Join(c.LocationNames(), " / "))
+		}
+	}
+}
+
+// validateNutritionGoals 检查每天的营养目标
+func (c *Config) validateNutritionGoals(v *validator) {
+	g := c.Nutrition.Goals
+	for _, goal := range []struct {
+		path  string
+		value float64
+	}{
+		{"nutrition.goals.calories", g.Calories},
+		{"nutrition.goals.protein", g.Protein},
+		{"nutrition.goals.fat", g.Fat},
+	} {
+		if goal.value < 0 {
+			v.add(goal.path, "不能小于 0: %g", goal.value)
+		}
+	}
+	if g.Set() && !c.Nutrition.Enabled {
+		v.add("nutrition.goals", "需要开启 nutrition.enabled（按每餐的营养估算计算）")
+	}
+}
+
+// validatePlugins 插件的名称、命令和超时
+func (c *Config) validatePlugins(v *validator) {
+	seen := make(map[string]bool)
+	for i, p := range c.Plugins {
+		path := fmt.Sprintf("plugins.%d", i)
+		required(v, path+".name", p.Name, "插件")
+		required(v, path+".command", p.Command, "插件")
+		if p.Name != "" && seen[p.Name] {
+			v.add(path+".name", "重复: %s", p.Name)
+		}
+		seen[p.Name] = true
+		checkDuration(v, path+".timeout", p.Timeout)
+	}
+}
+
+// validateNetwork 代理地址、证书文件和客户端名称
+func (c *Config) validateNetwork(v *validator) {
+	checkNetwork(v, "network", c.Network.Proxy, c.Network.CAFile)
+	for name, n := range c.Network.Clients {
+		path := "network.clients." + name
+		known := false
+		for _, client := range network.Clients {
+			known = known || name == client
+		}
+		if !known {
+			v.add(path, "不认识的客户端（可用 %s）", strings.Join(network.Clients, " / "))
+			continue
+		}
+		checkNetwork(v, path, n.Proxy, n.CAFile)
+	}
+}
+
+func checkNetwork(v *validator, path, proxy, caFile string) {
+	if proxy != "" && !strings.EqualFold(proxy, network.Direct) {
+		if _, err := network.ParseProxy(proxy); err != nil {
+			v.add(path+".proxy", "%v", err)
+		}
+	}
+	if caFile != "" {
+		if info, err := os.Stat(caFile); err != nil {
+			v.add(path+".ca_file", "无法读取: %v", err)
+		} else if info.IsDir() {
+			v.add(path+".ca_file", "应为证书文件，不是目录: %s", caFile)
+		}
+	}
+}
+
+// validateProviders 数据来源和云同步
+func (c *Config) validateProviders(v *validator) {
+	oneOf(v, "api.restaurant_provider", c.API.RestaurantProvider, "amap", "osm", "static")
+	oneOf(v, "api.weather_provider", c.API.WeatherProvider, "qweather", "openweathermap", "open-meteo")
+
+	s := c.Sync
+	switch s.Provider {
+	case "":
+	case "webdav", "git":
+		required(v, "sync.url", s.URL, "sync.provider 为 "+s.Provider)
+	case "s3":
+		required(v, "sync.url", s.URL, "sync.provider 为 s3")
+		required(v, "sync.bucket", s.Bucket, "sync.provider 为 s3")
+		required(v, "sync.access_key", s.AccessKey, "sync.provider 为 s3")
+		required(v, "sync.secret_key", s.SecretKey, "sync.provider 为 s3")
+	default:
+		v.add("sync.provider", "应为 webdav / s3 / git: %s", s.Provider)
+	}
+}
+
+// validateNotify 推送方式需要填写的项（与 notify 中创建推送时的检查一致）
+func (c *Config) validateNotify(v *validator) {
+	n := c.Notify
+	for i, w := range n.Webhooks {
+		path := fmt.Sprintf("notify.webhooks.%d", i)
+		required(v, path+".url", w.URL, "webhook")
+	}
+	for i, w := range n.Webhook {
+		path := "notify.webhook"
+		if len(n.Webhook) > 1 {
+			path = fmt.Sprintf("notify.webhook.%d", i)
+		}
+		required(v, path+".url", w.URL, "webhook")
+	}
+	for i, b := range n.Bots {
+		path := fmt.Sprintf("notify.bots.%d", i)
+		switch b.Type {
+		case "wecom", "dingtalk", "feishu":
+			required(v, path+".url", b.URL, b.Type+" 机器人")
+		case "telegram":
+			required(v, path+".token", b.Token, "telegram 机器人")
+			required(v, path+".chat_id", b.ChatID, "telegram 机器人")
+		default:
+			v.add(path+".type", "应为 wecom / dingtalk / feishu / telegram: %q", b.Type)
+		}
+	}
+	for i, p := range n.Push {
+		path := fmt.Sprintf("notify.push.%d", i)
+		switch p.Type {
+		case "serverchan", "bark":
+			required(v, path+".key", p.Key, p.Type+" 推送")
+		case "ntfy":
+			required(v, path+".topic", p.Topic, "ntfy 推送")
+		default:
+			v.add(path+".type", "应为 serverchan / bark / ntfy: %q", p.Type)
+		}
+	}
+	if n.Email.Enabled() && len(n.Email.To) == 0 {
+		required(v, "notify.email.to", "", "发送邮件（填写了 notify.email.host）")
+	}
+	if w := n.Reply.Wecom; w.Enabled() {
+		required(v, "notify.reply.wecom.token", w.Token, "企业微信消息回调")
+		if len(w.AESKey) != 43 {
+			v.add("notify.reply.wecom.aes_key", "应为企业微信回调配置中 43 位的 EncodingAESKey")
+		}
+		if n.Reply.Listen == "" {
+			required(v, "notify.reply.listen", "", "企业微信消息回调")
+		}
+	}
+}
+
+func required(v *validator, path, value, feature string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "%s 需要填写", feature)
+	}
+}
+
+func oneOf(v *validator, path, value string, allowed ...string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(path, "应为 %s: %s", strings.Join(allowed, " / "), value)
+}
+
+func checkDuration(v *validator, path, value string) {
+	if value == "" || value == "0" {
+		return
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		v.add(path, "格式应为 30m、1h、24h: %s", value)
+	}
+}
+
+func checkListen(v *validator, path, value string) {
+	if value == "" {
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		v.add(path, "格式应为 :8080 或 127.0.0.1:8080: %s", value)
+	}
+}
+
+var coordinatePattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// checkCoordinates 坐标应为数字且在范围内，纬度超过 90 时多半是和经度写反了
+func checkCoordinates(v *validator, path string, loc Location) {
+	if loc.Lat == "" && loc.Lng == "" {
+		return
+	}
+	if loc.Lat == "" || loc.Lng == "" {
+		v.add(path, "lat 和 lng 需要同时填写")
+		return
+	}
+	lat, latErr := strconv.ParseFloat(loc.Lat, 64)
+	lng, lngErr := strconv.ParseFloat(loc.Lng, 64)
+	if latErr != nil || !coordinatePattern.MatchString(loc.Lat) {
+		v.add(path+".lat", "应为数字，如 39.9042: %s", loc.Lat)
+	}
+	if lngErr != nil || !coordinatePattern.MatchString(loc.Lng) {
+		v.add(path+".lng", "应为数字，如 116.4074: %s", loc.Lng)
+	}
+	if latErr != nil || lngErr != nil {
+		return
+	}
+	switch {
+	case (lat < -90 || lat > 90) && lng >= -90 && lng <= 90:
+		v.add(path+".lat", "纬度应在 -90~90 之间: %s（是不是和 lng 写反了？）", loc.Lat)
+	case lat < -90 || lat > 90:
+		v.add(path+".lat", "纬度应在 -90~90 之间: %s", loc.Lat)
+	case lng < -180 || lng > 180:
+		v.add(path+".lng", "经度应在 -180~180 之间: %s", loc.Lng)
+	}
+}
@@ -0,0 +1,143 @@
+package debugdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"meal-agent/logging"
+)
+
+// Dumper 把推荐时构建的 prompt、带权重的候选餐厅和外部接口的原始响应写到目录中，排查推荐不合适的原因
+// 文件名以时间和序号开头（如 20261016-113000.123-0001-prompt.txt），按文件名排序即为发生的顺序
+type Dumper struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// New 创建输出到 dir 的 Dumper（目录不存在时创建）
+func New(dir string) (*Dumper, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建调试输出目录失败: %v", err)
+	}
+	return &Dumper{dir: dir}, nil
+}
+
+// Write 写入一个文件，name 为文件名中时间和序号之后的部分；d 为 nil 时不做什么
+// 写入失败只记录日志，不影响推荐
+func (d *Dumper) Write(name string, data []byte) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.seq++
+	file := fmt.Sprintf("%s-%04d-%s", time.Now().Format("20060102-150405.000"), d.seq, name)
+	d.mu.Unlock()
+	// 内容包括位置和对话，只允许自己读取
+	if err := os.WriteFile(filepath.Join(d.dir, file), data, 0600); err != nil {
+		logging.Module("debug").Warn("写入调试文件失败", "file", file, "err", err)
+	}
+}
+
+// Transport 包装 base，把每个请求和原始响应写成 http-<域名>.txt（查询参数中的密钥替换为 ***，不写请求头）
+// 用它替换 http.DefaultTransport 后，高德、和风天气、LLM 等接口的请求都会记录
+func (d *Dumper) Transport(base http.RoundTripper) http.RoundTripper {
+	return &dumpTransport{dumper: d, base: base}
+}
+
+type dumpTransport struct {
+	dumper *Dumper
+	base   http.RoundTripper
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, redactURL(req.URL))
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fmt.Fprintf(&b, "耗时: %dms\n", time.Since(start).Milliseconds())
+	if len(reqBody) > 0 {
+		fmt.Fprintf(&b, "\n--- 请求 ---\n%s\n", formatBody(reqBody))
+	}
+
+	name := "http-" + safeName(req.URL.Hostname()) + ".txt"
+	if err != nil {
+		fmt.Fprintf(&b, "\n--- 失败 ---\n%v\n", err)
+		t.dumper.Write(name, []byte(b.String()))
+		return nil, err
+	}
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	fmt.Fprintf(&b, "\n--- 响应 %s ---\n%s\n", resp.Status, formatBody(respBody))
+	if readErr != nil {
+		fmt.Fprintf(&b, "（读取响应中断: %v）\n", readErr)
+	}
+	t.dumper.Write(name, []byte(b.String()))
+	return resp, nil
+}
+
+// secretParams 查询参数中的密钥（高德 key、和风天气 key、OpenWeatherMap appid 等）
+var secretParams = []string{"key", "apikey", "api_key", "appid", "token", "access_token", "secret", "sig", "signature"}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	for name := range q {
+		for _, s := range secretParams {
+			if strings.EqualFold(name, s) {
+				q.Set(name, "***")
+			}
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// formatBody JSON 缩进显示（\u 转义的中文还原为文字，键按字母排序），其他内容原样输出
+func formatBody(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return string(data)
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return string(data)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, s)
+}
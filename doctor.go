@@ -0,0 +1,585 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"meal-agent/agent"
+	"meal-agent/config"
+	"meal-agent/encryption"
+	"meal-agent/i18n"
+	"meal-agent/network"
+	"meal-agent/preference"
+	"meal-agent/tools"
+)
+
+// doctor 子命令：检查配置是否完整，测试高德、天气、LLM 接口能否正常调用，检查数据目录能否写入
+// 接口失败时区分 Key 错误、配额用完和网络问题，并给出处理建议
+
+// checkStatus 检查结果
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+	checkSkip
+)
+
+func (s checkStatus) icon() string {
+	switch s {
+	case checkOK:
+		return "✅"
+	case checkWarn:
+		return "⚠️"
+	case checkFail:
+		return "❌"
+	}
+	return "➖"
+}
+
+// checkResult 一项检查，hint 为处理建议（可以为空）
+type checkResult struct {
+	status checkStatus
+	detail string
+	hint   string
+}
+
+// okResult 通过的检查，format 经 i18n.T 翻译
+func okResult(format string, args ...interface{}) checkResult {
+	return checkResult{status: checkOK, detail: i18n.T(format, args...)}
+}
+
+func warnResult(detail, hint string) checkResult {
+	return checkResult{status: checkWarn, detail: detail, hint: hint}
+}
+
+func failResult(detail, hint string) checkResult {
+	return checkResult{status: checkFail, detail: detail, hint: hint}
+}
+
+func skipResult(detail string) checkResult {
+	return checkResult{status: checkSkip, detail: detail}
+}
+
+// doctorTimeout 每个接口请求的超时
+const doctorTimeout = 10 * time.Second
+
+// doctorReport 按分组输出检查结果，统计失败和提醒的数量
+type doctorReport struct {
+	failed, warned int
+}
+
+func (r *doctorReport) section(title string) {
+	fmt.Printf("\n%s\n", title)
+}
+
+func (r *doctorReport) add(c checkResult) {
+	switch c.status {
+	case checkFail:
+		r.failed++
+	case checkWarn:
+		r.warned++
+	}
+	fmt.Printf("  %s %s\n", c.status.icon(), c.detail)
+	if c.hint != "" {
+		fmt.Printf("     → %s\n", c.hint)
+	}
+}
+
+func runDoctorCommand(opts *options, args []string) error {
+	fs := opts.flags("doctor")
+	offline := fs.Bool("offline", false, i18n.T("只检查配置和数据目录，不请求外部接口"))
+	fs.Parse(args)
+
+	report := &doctorReport{}
+	report.section(i18n.T("配置文件 %s", opts.configPath))
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.add(failResult(i18n.T("配置文件不存在"), i18n.T("运行 meal-agent config init 从示例生成，再填写位置和 API Key")))
+		} else {
+			report.add(failResult(i18n.T("无法加载: %v", err), i18n.T("按错误提示修改后运行 meal-agent config validate")))
+		}
+	} else {
+		report.add(okResult("格式和取值没有问题"))
+		for _, c := range checkConfig(cfg) {
+			report.add(c)
+		}
+	}
+
+	report.section(i18n.T("偏好配置 %s", opts.prefPath))
+	report.add(checkPreferences(opts.prefPath))
+
+	report.section(i18n.T("数据目录 %s", opts.dataDir))
+	report.add(checkWritable(opts.dataDir))
+	report.add(checkHistoryFile(opts.dataDir))
+	if cfg != nil && filepath.Clean(cfg.Search.CacheDir) != filepath.Join(filepath.Clean(opts.dataDir), "cache") {
+		report.add(checkWritable(cfg.Search.CacheDir))
+	}
+
+	if cfg != nil {
+		restaurant := orDefault(cfg.API.RestaurantProvider, "amap")
+		weather := orDefault(cfg.API.WeatherProvider, "qweather")
+		checks := []struct {
+			title string
+			run   func(*config.Config) checkResult
+		}{
+			{i18n.T("餐厅数据（%s）", restaurant), checkRestaurantProvider},
+			{i18n.T("天气（%s）", weather), checkWeatherProvider},
+			{i18n.T("LLM（%s）", strings.TrimSpace(cfg.LLM.Provider+" "+cfg.LLM.Model)), checkLLM},
+		}
+		for _, c := range checks {
+			report.section(c.title)
+			if *offline {
+				report.add(skipResult(i18n.T("已跳过（--offline）")))
+				continue
+			}
+			report.add(c.run(cfg))
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case report.failed > 0:
+		return fmt.Errorf(i18n.T("发现 %d 个问题，%d 个提醒"), report.failed, report.warned)
+	case report.warned > 0:
+		fmt.Println(i18n.T("没有发现问题，%d 个提醒", report.warned))
+	default:
+		fmt.Println(i18n.T("全部检查通过"))
+	}
+	return nil
+}
+
+// checkConfig 检查运行所需的配置是否填写（Load 只检查格式和取值范围）
+func checkConfig(cfg *config.Config) []checkResult {
+	var results []checkResult
+	required := func(value, field, hint string) {
+		switch {
+		case value == "":
+			results = append(results, failResult(i18n.T("未填写 %s", field), hint))
+		case isPlaceholder(value):
+			results = append(results, failResult(i18n.T("%s 还是示例中的占位文字", field), hint))
+		}
+	}
+
+	if cfg.Location.Lat == "" || cfg.Location.Lng == "" {
+		if cfg.Location.City == "" {
+			results = append(results, failResult(i18n.T("未填写位置（location.lat、location.lng）"), i18n.T("在高德坐标拾取器中查询公司或家的坐标，或填写 location.address")))
+		} else {
+			results = append(results, warnResult(i18n.T("未填写坐标，只有城市 %s", cfg.Location.City), i18n.T("搜索附近餐厅需要 location.lat、location.lng 或 location.address")))
+		}
+	}
+
+	switch orDefault(cfg.API.RestaurantProvider, "amap") {
+	case "amap":
+		required(cfg.API.AmapKey, "api.amap_key", i18n.T("在高德开放平台创建「Web服务」类型的 Key"))
+	case "static":
+		if _, err := os.Stat(cfg.API.StaticList); err != nil {
+			results = append(results, failResult(i18n.T("餐厅列表 api.static_list 无法读取: %s", cfg.API.StaticList), i18n.T("复制 nearby.example.yaml 并修改 static_list 为它的路径")))
+		}
+	}
+	if cfg.Canteen.Menu != "" {
+		if _, err := tools.NewCanteenProvider(cfg.Canteen.Menu, cfg.Canteen.Name).Candidate("lunch", time.Now()); err != nil {
+			results = append(results, failResult(i18n.T("食堂菜单 canteen.menu 无法读取: %v", err), i18n.T("复制 canteen.example.yaml 并修改 canteen.menu 为它的路径")))
+		}
+	}
+	switch orDefault(cfg.API.WeatherProvider, "qweather") {
+	case "qweather":
+		required(cfg.API.WeatherKey, "api.weather_key", i18n.T("在和风天气控制台创建 Key，或设置 weather_provider: open-meteo（无需 Key）"))
+	case "openweathermap":
+		required(cfg.API.WeatherKey, "api.weather_key", i18n.T("在 OpenWeatherMap 的 API keys 页面获取 Key"))
+	}
+	required(cfg.LLM.APIKey, "llm.api_key", i18n.T("填写所选 LLM 服务的 API Key"))
+	required(cfg.LLM.Model, "llm.model", i18n.T("填写模型名称，如 deepseek-chat、qwen-plus"))
+
+	if len(results) == 0 {
+		results = append(results, okResult("位置、API Key、模型都已填写"))
+	}
+	return results
+}
+
+// isPlaceholder 是否为示例配置中的占位文字（如"你的高德地图API Key"）
+func isPlaceholder(s string) bool {
+	return strings.HasPrefix(s, "你的") || strings.Contains(strings.ToLower(s), "xxx")
+}
+
+func checkPreferences(path string) checkResult {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return skipResult(i18n.T("没有偏好配置（可选），使用默认权重"))
+	}
+	issues, err := preference.Validate(path)
+	if err != nil {
+		return failResult(i18n.T("无法加载: %v", err), "")
+	}
+	errs := 0
+	for _, issue := range issues {
+		if issue.Error {
+			errs++
+		}
+	}
+	switch {
+	case errs > 0:
+		return failResult(i18n.T("%d 个错误，%d 个提醒", errs, len(issues)-errs), i18n.T("运行 meal-agent pref validate 查看详情"))
+	case len(issues) > 0:
+		return warnResult(i18n.T("%d 个提醒", len(issues)), i18n.T("运行 meal-agent pref validate 查看详情"))
+	}
+	return okResult("没有发现问题")
+}
+
+// checkWritable 目录能否创建和写入（用餐记录、缓存、提醒状态都保存在这里）
+func checkWritable(dir string) checkResult {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return failResult(i18n.T("无法创建 %s: %v", dir, err), i18n.T("检查上级目录的权限，或用 -data 指定其他目录"))
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return failResult(i18n.T("%s 无法写入: %v", dir, err), i18n.T("检查目录的所有者和权限（如服务以其他用户运行）"))
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return okResult("%s 可以写入", dir)
+}
+
+func checkHistoryFile(dir string) checkResult {
+	data, err := encryption.ReadFile(filepath.Join(dir, "history.json"))
+	if os.IsNotExist(err) {
+		return skipResult(i18n.T("还没有用餐记录"))
+	}
+	if encryption.KeyError(err) {
+		return failResult(i18n.T("无法读取 history.json: %v", err), i18n.T("检查 encryption.key 是否和加密时使用的密钥一致"))
+	}
+	if err != nil {
+		return failResult(i18n.T("无法读取 history.json: %v", err), "")
+	}
+	if !json.Valid(data) {
+		return failResult(i18n.T("history.json 不是合法的 JSON，记录不会被加载"), i18n.T("从备份或同步的远端恢复，或用 history import 重新导入"))
+	}
+	return okResult("history.json 可以正常读取")
+}
+
+// probe 按 client 的代理和证书设置请求接口，返回状态码和内容；网络问题返回的 error 已经是可读的原因
+func probe(client string, req *http.Request) (int, []byte, error) {
+	c := &http.Client{Timeout: doctorTimeout, Transport: network.Transport(client)}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, nil, errors.New(networkProblem(err))
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, errors.New(networkProblem(err))
+	}
+	return resp.StatusCode, body, nil
+}
+
+// networkProblem 把网络错误归类为超时、域名解析、连接失败
+func networkProblem(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &dnsErr):
+		return i18n.T("网络问题：无法解析域名 %s", dnsErr.Name)
+	case errors.As(err, &certErr):
+		return i18n.T("网络问题：证书不受信任（公司网络检查 HTTPS 时在 network.ca_file 中填写公司的 CA 证书）")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return i18n.T("网络问题：请求超时（%s）", doctorTimeout)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return i18n.T("网络问题：%v", err)
+}
+
+// networkHint 网络问题的处理建议（使用时翻译）
+const networkHint = "检查网络连接和代理设置（network.proxy 或 HTTPS_PROXY），公司内网可能需要放行该域名"
+
+func checkRestaurantProvider(cfg *config.Config) checkResult {
+	switch cfg.API.RestaurantProvider {
+	case "osm":
+		return checkSearch(tools.NewOverpassClient(cfg.API.OverpassURL, 1), cfg)
+	case "static":
+		return checkSearch(tools.NewStaticProvider(cfg.API.StaticList), cfg)
+	}
+	return checkAmap(cfg)
+}
+
+// checkSearch 不需要 Key 的餐厅数据来源，搜索一次附近的餐厅
+func checkSearch(p tools.RestaurantProvider, cfg *config.Config) checkResult {
+	restaurants, err := p.SearchNearby(cfg.Location.Lat, cfg.Location.Lng, cfg.Location.Radius, "")
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return failResult(networkProblem(err), i18n.T(networkHint))
+		}
+		return failResult(err.Error(), "")
+	}
+	if len(restaurants) == 0 {
+		return warnResult(i18n.T("%d 米内没有找到餐厅", cfg.Location.Radius), i18n.T("检查坐标是否正确，或增大 location.radius"))
+	}
+	return okResult("%d 米内找到 %d 家餐厅", cfg.Location.Radius, len(restaurants))
+}
+
+// amapProblems 高德 infocode 对应的原因和处理建议（使用时再翻译，包初始化时还没有设置语言）
+var amapProblems = map[string][2]string{
+	"10001": {"Key 错误：Key 不正确或已过期", "核对 api.amap_key，需要「Web服务」类型的 Key"},
+	"10002": {"Key 错误：没有权限使用周边搜索服务", "在高德控制台确认 Key 开通了 Web服务 API"},
+	"10005": {"Key 错误：本机 IP 不在 Key 的白名单中", "在高德控制台修改 IP 白名单或清空白名单"},
+	"10007": {"Key 错误：Key 开启了数字签名", "在高德控制台关闭这个 Key 的数字签名"},
+	"10009": {"Key 错误：Key 的类型不匹配", "需要「Web服务」类型的 Key，JS API、Android 等类型的 Key 不能使用"},
+	"10003": {"配额用完：今日调用量已超过限制", "明天恢复，或在高德控制台申请提高配额；期间会使用缓存的餐厅数据"},
+	"10004": {"配额问题：调用过于频繁", "稍后再试，或降低 api.amap_qps"},
+	"10010": {"配额问题：本机 IP 访问超限", "稍后再试"},
+	"10014": {"配额问题：服务总调用量超限", "稍后再试"},
+	"10019": {"配额问题：服务 QPS 超限", "稍后再试，或降低 api.amap_qps"},
+	"10020": {"配额问题：接口 QPS 超限", "稍后再试，或降低 api.amap_qps"},
+	"10021": {"配额问题：账号 QPS 超限", "稍后再试，或降低 api.amap_qps"},
+	"10044": {"配额用完：账号今日调用量已超过限制", "明天恢复，或在高德控制台申请提高配额"},
+	"10045": {"配额用完：账号今日调用量已超过限制", "明天恢复，或在高德控制台申请提高配额"},
+	"20000": {"请求参数错误", "检查 location.lat、location.lng 是否为 GCJ-02 坐标"},
+}
+
+func checkAmap(cfg *config.Config) checkResult {
+	if cfg.API.AmapKey == "" || isPlaceholder(cfg.API.AmapKey) {
+		return skipResult(i18n.T("没有填写 api.amap_key，跳过"))
+	}
+	// 与推荐时共用调用量统计：本地统计已到配额时不再请求
+	quota := tools.NewQuotaTracker(filepath.Join(cfg.Search.CacheDir, "amap_quota.json"), cfg.API.AmapDailyQuota, cfg.API.AmapQPS)
+	if err := quota.Acquire(); err != nil {
+		used, limit := quota.Usage()
+		return failResult(i18n.T("配额用完：本地统计今日已调用 %d/%d 次", used, limit), i18n.T("明天恢复，或调大 api.amap_daily_quota"))
+	}
+
+	reqURL := fmt.Sprintf("https://restapi.amap.com/v3/place/around?key=%s&location=%s,%s&radius=%d&types=050000&offset=1&page=1",
+		url.QueryEscape(cfg.API.AmapKey), cfg.Location.Lng, cfg.Location.Lat, cfg.Location.Radius)
+	req, _ := http.NewRequest("GET", reqURL, nil)
+	status, body, err := probe(network.Amap, req)
+	if err != nil {
+		return failResult(err.Error(), i18n.T(networkHint))
+	}
+	var result struct {
+		Status   string      `json:"status"`
+		Info     string      `json:"info"`
+		Infocode string      `json:"infocode"`
+		Count    interface{} `json:"count"` // 一般为字符串，兼容数字
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return failResult(i18n.T("无法识别的响应（HTTP %d）", status), i18n.T(networkHint))
+	}
+	if result.Status != "1" {
+		if p, ok := amapProblems[result.Infocode]; ok {
+			return failResult(fmt.Sprintf("%s（%s %s）", i18n.T(p[0]), result.Infocode, result.Info), i18n.T(p[1]))
+		}
+		return failResult(i18n.T("高德API错误: %s %s", result.Infocode, result.Info), "")
+	}
+
+	used, limit := quota.Usage()
+	count := fmt.Sprint(result.Count)
+	detail := i18n.T("Key 正常，%d 米内有 %s 家餐饮 POI", cfg.Location.Radius, count)
+	if limit > 0 {
+		detail += i18n.T("（今日已调用 %d/%d 次）", used, limit)
+	}
+	if count == "0" {
+		return warnResult(detail, i18n.T("检查坐标是否正确（高德使用 GCJ-02 坐标），或增大 location.radius"))
+	}
+	if quota.NearLimit() {
+		return warnResult(detail, i18n.T("今日调用量接近配额，推荐时会优先使用缓存"))
+	}
+	return okResult("%s", detail)
+}
+
+func checkWeatherProvider(cfg *config.Config) checkResult {
+	switch cfg.API.WeatherProvider {
+	case "openweathermap":
+		return checkOpenWeather(cfg)
+	case "open-meteo":
+		reqURL := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%s&longitude=%s&current=temperature_2m", cfg.Location.Lat, cfg.Location.Lng)
+		req, _ := http.NewRequest("GET", reqURL, nil)
+		status, body, err := probe(network.Weather, req)
+		if err != nil {
+			return failResult(err.Error(), i18n.T(networkHint))
+		}
+		if status != http.StatusOK {
+			return failResult(i18n.T("Open-Meteo API错误（HTTP %d）: %s", status, apiMessage(body)), i18n.T("检查 location.lat、location.lng"))
+		}
+		return okResult("接口正常（无需 Key）")
+	}
+	return checkQWeather(cfg)
+}
+
+// qweatherProblems 和风天气 code 对应的原因和处理建议（使用时再翻译）
+var qweatherProblems = map[string][2]string{
+	"400": {"请求参数错误", "检查 location.lat、location.lng 或 location.city"},
+	"401": {"Key 错误：认证失败", "核对 api.weather_key；免费订阅的 Key 只能访问 devapi.qweather.com"},
+	"402": {"配额用完：超过访问次数或余额不足", "在和风天气控制台查看用量和余额"},
+	"403": {"Key 错误：无访问权限", "在和风天气控制台确认 Key 的项目订阅了实时天气"},
+	"404": {"查询的地区不存在", "检查 location.lat、location.lng 或 location.city"},
+	"429": {"配额问题：每分钟请求次数超限", "稍后再试"},
+}
+
+func checkQWeather(cfg *config.Config) checkResult {
+	if cfg.API.WeatherKey == "" || isPlaceholder(cfg.API.WeatherKey) {
+		return skipResult(i18n.T("没有填写 api.weather_key，跳过"))
+	}
+	// 和风天气的实时天气只接受坐标或城市 ID，没有坐标时先查询城市
+	location := cfg.Location.Lng + "," + cfg.Location.Lat
+	if cfg.Location.Lat == "" || cfg.Location.Lng == "" {
+		var city struct {
+			Code     string `json:"code"`
+			Location []struct {
+				ID string `json:"id"`
+			} `json:"location"`
+		}
+		reqURL := fmt.Sprintf("https://geoapi.qweather.com/v2/city/lookup?location=%s&key=%s", url.QueryEscape(cfg.Location.City), url.QueryEscape(cfg.API.WeatherKey))
+		if c := qweatherGet(reqURL, &city); c.status != checkOK {
+			return c
+		}
+		if len(city.Location) == 0 {
+			return failResult(i18n.T("城市未找到: %s", cfg.Location.City), i18n.T("检查 location.city，或填写 location.lat、location.lng"))
+		}
+		location = city.Location[0].ID
+	}
+
+	var result struct {
+		Code string `json:"code"`
+		Now  struct {
+			Text string `json:"text"`
+			Temp string `json:"temp"`
+		} `json:"now"`
+	}
+	reqURL := fmt.Sprintf("https://devapi.qweather.com/v7/weather/now?location=%s&key=%s", url.QueryEscape(location), url.QueryEscape(cfg.API.WeatherKey))
+	if c := qweatherGet(reqURL, &result); c.status != checkOK {
+		return c
+	}
+	return okResult("Key 正常，当前 %s %s°C", result.Now.Text, result.Now.Temp)
+}
+
+// qweatherGet 请求和风天气接口，按返回的 code 判断问题（成功时结果的 status 为 checkOK）
+func qweatherGet(reqURL string, v interface{}) checkResult {
+	req, _ := http.NewRequest("GET", reqURL, nil)
+	status, body, err := probe(network.Weather, req)
+	if err != nil {
+		return failResult(err.Error(), i18n.T(networkHint))
+	}
+	var result struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Code == "" {
+		result.Code = fmt.Sprint(status)
+	}
+	if result.Code != "200" {
+		if p, ok := qweatherProblems[result.Code]; ok {
+			return failResult(fmt.Sprintf("%s（code %s）", i18n.T(p[0]), result.Code), i18n.T(p[1]))
+		}
+		return failResult(i18n.T("天气API错误，code: %s", result.Code), "")
+	}
+	json.Unmarshal(body, v)
+	return okResult("")
+}
+
+func checkOpenWeather(cfg *config.Config) checkResult {
+	if cfg.API.WeatherKey == "" || isPlaceholder(cfg.API.WeatherKey) {
+		return skipResult(i18n.T("没有填写 api.weather_key，跳过"))
+	}
+	reqURL := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?lat=%s&lon=%s&appid=%s&units=metric",
+		cfg.Location.Lat, cfg.Location.Lng, url.QueryEscape(cfg.API.WeatherKey))
+	req, _ := http.NewRequest("GET", reqURL, nil)
+	status, body, err := probe(network.Weather, req)
+	if err != nil {
+		return failResult(err.Error(), i18n.T(networkHint))
+	}
+	switch status {
+	case http.StatusOK:
+		return okResult("Key 正常")
+	case http.StatusUnauthorized:
+		return failResult(i18n.T("Key 错误：%s", apiMessage(body)), i18n.T("核对 api.weather_key；新建的 Key 可能需要等待几小时才生效"))
+	case http.StatusTooManyRequests:
+		return failResult(i18n.T("配额用完：%s", apiMessage(body)), i18n.T("免费账号每分钟 60 次、每月 100 万次，稍后再试"))
+	}
+	return failResult(i18n.T("OpenWeatherMap API错误（HTTP %d）: %s", status, apiMessage(body)), "")
+}
+
+// checkLLM 发送一条很短的消息，确认地址、Key 和模型名称
+func checkLLM(cfg *config.Config) checkResult {
+	if cfg.LLM.APIKey == "" || isPlaceholder(cfg.LLM.APIKey) {
+		return skipResult(i18n.T("没有填写 llm.api_key，跳过"))
+	}
+	baseURL := agent.LLMBaseURL(cfg.LLM)
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"model":      cfg.LLM.Model,
+		"messages":   []agent.Message{{Role: "user", Content: "ping"}},
+		"max_tokens": 1,
+	})
+	req, err := http.NewRequest("POST", baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return failResult(i18n.T("接口地址无效: %v", err), i18n.T("检查 llm.base_url"))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.LLM.APIKey)
+
+	start := time.Now()
+	status, body, err := probe(network.LLM, req)
+	if err != nil {
+		return failResult(err.Error(), i18n.T(networkHint)+i18n.T("；自建或代理的接口检查 llm.base_url"))
+	}
+	msg := apiMessage(body)
+	lower := strings.ToLower(msg)
+	switch {
+	case status == http.StatusOK:
+		return okResult("连接正常，%s 响应用时 %.1f 秒", baseURL, time.Since(start).Seconds())
+	case status == http.StatusUnauthorized:
+		return failResult(i18n.T("Key 错误：%s", msg), i18n.T("核对 llm.api_key，以及 provider / base_url 是否与 Key 所属的服务一致"))
+	case status == http.StatusForbidden:
+		return failResult(i18n.T("Key 无权限：%s", msg), i18n.T("确认账号已开通该模型，部分服务限制访问地区"))
+	case status == http.StatusPaymentRequired, strings.Contains(lower, "insufficient"), strings.Contains(lower, "quota"), strings.Contains(msg, "余额"):
+		return failResult(i18n.T("配额用完：%s", msg), i18n.T("在服务商控制台查看余额和额度"))
+	case status == http.StatusTooManyRequests:
+		return failResult(i18n.T("配额问题：请求过于频繁：%s", msg), i18n.T("稍后再试，或提高账号的速率限制"))
+	case status == http.StatusNotFound, status == http.StatusBadRequest && strings.Contains(lower, "model"):
+		return failResult(i18n.T("地址或模型名称错误（HTTP %d）：%s", status, msg), i18n.T("检查 llm.base_url（当前 %s）和 llm.model（当前 %s）", baseURL, cfg.LLM.Model))
+	case status >= 500:
+		return failResult(i18n.T("服务端错误（HTTP %d）：%s", status, msg), i18n.T("服务暂时不可用，稍后再试"))
+	}
+	return failResult(i18n.T("LLM 接口错误（HTTP %d）：%s", status, msg), "")
+}
+
+// apiMessage 取出接口返回的错误说明（OpenAI 格式的 error.message、message 字段），否则截取原文
+func apiMessage(body []byte) string {
+	var result struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &result) == nil {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(result.Error, &e) == nil && e.Message != "" {
+			return e.Message
+		}
+		var s string
+		if json.Unmarshal(result.Error, &s) == nil && s != "" {
+			return s
+		}
+		if result.Message != "" {
+			return result.Message
+		}
+	}
+	text := strings.TrimSpace(string(body))
+	if r := []rune(text); len(r) > 200 {
+		text = string(r[:200]) + "..."
+	}
+	return text
+}
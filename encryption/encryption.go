@@ -0,0 +1,218 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// 用餐历史和偏好文件的加密（AES-256-GCM）：设置密钥后写入的文件都加密，读取时自动识别加密和未加密的文件，
+// 开启加密前的文件照常读取，下次保存时加密
+//
+// 文件格式: magic(8) | salt(16) | nonce(12) | 密文
+// 密钥为 32 字节的 base64 时直接使用，否则作为口令用 PBKDF2-SHA256 派生（salt 保存在文件中）
+
+const (
+	magic      = "MAENC01\n"
+	saltSize   = 16
+	iterations = 200000
+)
+
+var (
+	// ErrNoKey 文件已加密但没有设置密钥
+	ErrNoKey = errors.New("文件已加密，需要设置 encryption.key（或环境变量 MEAL_AGENT_ENCRYPTION_KEY）")
+	// ErrWrongKey 密钥不对或文件被改动过
+	ErrWrongKey = errors.New("解密失败：密钥不正确或文件已损坏")
+)
+
+var (
+	mu     sync.Mutex
+	secret string
+	salt   []byte            // 本次运行写入文件使用的 salt
+	keys   map[string][]byte // salt -> 派生出的密钥（派生较慢，缓存）
+)
+
+// SetKey 设置密钥，为空时关闭加密（仍然可以读取未加密的文件）
+func SetKey(s string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if s == secret {
+		return
+	}
+	secret = s
+	salt = make([]byte, saltSize)
+	rand.Read(salt)
+	keys = make(map[string][]byte)
+}
+
+// Enabled 是否设置了密钥
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return secret != ""
+}
+
+// IsEncrypted data 是否是加密的文件内容
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Encrypt 设置了密钥时加密，否则原样返回
+func Encrypt(plain []byte) ([]byte, error) {
+	mu.Lock()
+	if secret == "" {
+		mu.Unlock()
+		return plain, nil
+	}
+	s := salt
+	key := deriveLocked(s)
+	mu.Unlock()
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(magic)+len(s)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, magic...)
+	out = append(out, s...)
+	out = append(out, nonce...)
+	// 文件头作为附加数据，改动文件头也会解密失败
+	header := append([]byte(nil), out...)
+	return gcm.Seal(out, nonce, plain, header), nil
+}
+
+// Decrypt 解密加密的文件内容，未加密的原样返回
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	mu.Lock()
+	if secret == "" {
+		mu.Unlock()
+		return nil, ErrNoKey
+	}
+	if len(data) < len(magic)+saltSize {
+		mu.Unlock()
+		return nil, ErrWrongKey
+	}
+	key := deriveLocked(data[len(magic) : len(magic)+saltSize])
+	mu.Unlock()
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(magic) + saltSize + gcm.NonceSize()
+	if len(data) < headerSize+gcm.Overhead() {
+		return nil, ErrWrongKey
+	}
+	plain, err := gcm.Open(nil, data[len(magic)+saltSize:headerSize], data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plain, nil
+}
+
+// ReadFile 读取文件，加密的文件解密后返回
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// WriteFile 写入文件，设置了密钥时加密（加密的文件只允许自己读写）
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	enabled := Enabled()
+	if enabled {
+		perm = 0600
+	}
+	out, err := Encrypt(data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, perm); err != nil {
+		return err
+	}
+	if enabled {
+		// 已有的文件写入时不改权限
+		return os.Chmod(path, perm)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveLocked 按 salt 取得密钥（调用时持有 mu）
+func deriveLocked(s []byte) []byte {
+	if key, ok := keys[string(s)]; ok {
+		return key
+	}
+	var key []byte
+	if raw, err := base64.StdEncoding.DecodeString(secret); err == nil && len(raw) == 32 {
+		key = raw
+	} else {
+		key = pbkdf2([]byte(secret), s, iterations, 32)
+	}
+	keys[string(s)] = key
+	return key
+}
+
+// pbkdf2 PBKDF2-HMAC-SHA256（RFC 8018）
+func pbkdf2(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var out []byte
+	for block := uint32(1); len(out) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
+
+// NewKey 随机生成 32 字节的密钥（base64），保存到系统钥匙串后在 encryption.key 中引用
+func NewKey() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// KeyError err 是否是没有密钥或解密失败（这时不能用空记录覆盖加密的文件）
+func KeyError(err error) bool {
+	return errors.Is(err, ErrNoKey) || errors.Is(err, ErrWrongKey)
+}
@@ -0,0 +1,559 @@
+package i18n
+
+// en 英文目录，键为代码中的中文原文
+var en = map[string]string{
+	// 对话
+	"你":                     "You",
+	"助手":                    "Assistant",
+	"午餐":                    "lunch",
+	"晚餐":                    "dinner",
+	"早餐/早午餐":                "breakfast/brunch",
+	"午餐推荐":                  "Lunch picks",
+	"晚餐推荐":                  "Dinner picks",
+	"🍽️  饮食推荐助手 Meal Agent": "🍽️  Meal Agent",
+	"我可以根据天气和你的位置推荐附近餐厅。":                 "I recommend restaurants near you based on the weather and your meal history.",
+	"输入 'help' 查看所有命令，输入 'quit' 退出。":      "Type 'help' for all commands, 'quit' to exit.",
+	"现在是 %s 时间，需要我推荐%s吗？":                 "It's %s. Want a %s recommendation?",
+	"再见，祝用餐愉快！🍽️":                         "Bye, enjoy your meal! 🍽️",
+	"已重置对话，有什么可以帮你的？":                     "Conversation reset. What can I do for you?",
+	"没有开启权重学习（在配置文件中设置 learning.enabled）": "Weight learning is off (set learning.enabled in the config file)",
+	"抱歉，出错了: %v":                          "Sorry, something went wrong: %v",
+	"正在为你搜索附近餐厅...":                       "Searching nearby restaurants...",
+	"抱歉，获取推荐失败: %v":                       "Sorry, failed to get a recommendation: %v",
+	"搜索失败: %v":                            "Search failed: %v",
+	"统计失败: %v":                            "Stats failed: %v",
+	"记录失败: %v":                            "Failed to record: %v",
+	"下次推荐时会避免重复。":                         "I'll avoid repeating it next time.",
+	`
+命令列表:
+  推荐 / r          获取用餐推荐
+  历史 / history    查看最近用餐记录（加关键词搜索全部记录，如: 历史 泰国菜）
+  记录 <餐厅名> [类型] [金额]  记录本次用餐，如: 记录 海底捞 火锅 138
+                    可加 评分:5 备注:辣度刚好 照片:/path/a.jpg，下次推荐这家时会提醒备注
+  统计 / stats      本月用餐统计（可加 week / all / 2024-06，末尾加 json 输出 JSON）
+  学习 / learned    查看根据评分和选择学到的权重调整（学习 重置 [餐厅] 清空）
+  食材 / pantry     查看家里的食材库存（食材 添加 鸡蛋 10个 过期:7天 常备:4，食材 用掉 鸡蛋 3）
+  重置 / reset      重置对话上下文
+  帮助 / help       显示此帮助
+  退出 / quit       退出程序
+
+对话示例:
+  "不想吃火锅"      排除火锅类餐厅
+  "来点清淡的"      获取清淡食物推荐
+  "就吃第一个"      确认选择
+  "点外卖"          切换到外卖推荐（"堂食"切回）
+  "今天在家做饭"    切换到做饭模式，推荐菜谱和购物清单（"出去吃"切回）
+  "记错了撤销"      撤销最近一条用餐记录
+  "删除今天中午的记录"         删除指定的用餐记录
+  "昨天晚上的记录改成海底捞"   修改记错的餐厅
+  "上次吃那家泰国菜是什么时候" 查询以前的用餐记录
+  "这顿打5分"                  给最近一次用餐评分（开启 learning 后会调整推荐权重）
+  "以后多推荐日料"             调整菜系偏好并保存（"以后少吃火锅"降低）
+  "把海底捞拉黑"               以后不再推荐
+  "这家店权重调到150"          调整餐厅权重并保存（100 为默认）
+  "下周减脂，沙拉权重x2，炸鸡为0，截止到7月1日"
+                               临时偏好，到期自动删除（"临时偏好"查看，"取消临时偏好"清空）
+  "我最近肠胃不好想吃清淡的，持续一周"
+                               临时口味，到期自动恢复（"以后少吃辣"设置长期口味，"口味"查看）
+  "每周至少吃一次山西面馆"     加入常吃清单，到期时保证出现在推荐中（"常吃清单"查看，"取消常吃山西面馆"移除）
+  "和 partner 一起吃"          合并几个人的偏好推荐（需配置 profiles，"一个人吃"恢复）
+  "我现在在家"                 切换到 locations 中配置的位置推荐（"当前位置"查看）
+	`: `
+Commands:
+  recommend / r     Get a meal recommendation
+  history           Recent meals (add a keyword to search all records, e.g. history thai)
+  record <restaurant> [type] [amount]  Record this meal, e.g. record Haidilao hotpot 138
+                    Add 评分:5 备注:note 照片:/path/a.jpg; the note is shown next time it is recommended
+  stats             This month's stats (add week / all / 2024-06, append json for JSON)
+  learned           Weight adjustments learned from ratings and choices (learned reset [restaurant])
+  pantry            Ingredients at home (pantry add eggs 10 过期:7天 常备:4, pantry use eggs 3)
+  reset             Reset the conversation
+  help              Show this help
+  quit              Exit
+
+Conversation examples:
+  "what should I eat"   Get a recommendation
+  "something else"      Another batch, skipping the restaurants just suggested
+  "the second one"      Confirm a choice and record it (also "first one", "#3", or the restaurant name)
+  "I'm at home"         Recommend near a location from locations in the config ("where am I" shows it)
+  "cook at home"        Cooking mode: recipes and a shopping list instead of restaurants ("eat out" switches back)
+  Anything else is answered by the LLM in English.
+  Editing preferences, records and temporary tastes in conversation currently
+  understands Chinese phrases only (see the Chinese help, language: zh).
+	`,
+
+	// 后台模式、网页模式
+	"🍽️  饮食推荐 Agent 已启动（后台模式）": "🍽️  Meal Agent started (daemon mode)",
+	"午餐提醒时间: %s":               "Lunch reminder: %s",
+	"晚餐提醒时间: %s":               "Dinner reminder: %s",
+	"今天%s: 午餐 %s，晚餐 %s":        "Today%s: lunch %s, dinner %s",
+	"用餐预告: 午餐提前 %d 分钟，晚餐提前 %d 分钟（0 表示不预告）": "Meal preview: %d minutes before lunch, %d minutes before dinner (0 = off)",
+	"每周报告: %s": "Weekly report: %s",
+	"今天推荐时的位置: %s（对话中切换后当天不再自动切换）": "Location for today's recommendations: %s (a switch in chat overrides it for the rest of the day)",
+	"修改配置文件后自动重新加载，按 Ctrl+C 退出":    "Config changes are reloaded automatically. Press Ctrl+C to exit",
+	"重新加载失败，继续使用原配置":               "Reload failed, keeping the previous config",
+	"已重新加载配置":                  "Config reloaded",
+	"🍽️  饮食推荐 Agent 网页已启动: %s": "🍽️  Meal Agent web UI started: %s",
+	"   手机访问: http://%s":       "   On your phone: http://%s",
+	"按 Ctrl+C 退出":              "Press Ctrl+C to exit",
+	"网页服务启动失败":                 "Failed to start the web server",
+	"正在退出...":                  "Shutting down...",
+	"强制退出":                     "Forced exit",
+	"已退出":                      "Stopped",
+	"没有可用的提醒方式，只输出到终端":         "No notifier available, printing to the terminal only",
+	"启动失败: %v":                 "Failed to start: %v",
+	"监听地址，如 :8080（默认使用配置中的 server.listen）": "Listen address, e.g. :8080 (default: server.listen in the config)",
+	"提示: -mode 已废弃，请改用 meal-agent %s":      "Note: -mode is deprecated, use meal-agent %s",
+	"未知模式: %s": "Unknown mode: %s",
+
+	// 加载配置和数据
+	"加载配置失败: %v": "Failed to load config: %v",
+	"请复制 config.example.yaml 为 config.yaml 并填写配置（或运行 meal-agent config init）": "Copy config.example.yaml to config.yaml and fill it in (or run meal-agent config init)",
+	"初始化历史记录失败: %v":          "Failed to open meal history: %v",
+	"同步失败，继续使用本地数据":          "Sync failed, continuing with local data",
+	"归档历史记录失败":               "Failed to archive meal history",
+	"已归档 %d 条较早的用餐记录":        "Archived %d older meal records",
+	"加载偏好配置失败，将使用默认权重":       "Failed to load preferences, using default weights",
+	"删除过期的临时偏好失败":            "Failed to remove expired temporary preferences",
+	"已删除 %d 条过期的临时偏好":        "Removed %d expired temporary preferences",
+	"加载学习记录失败: %v（不使用学到的调整）": "Failed to load learned weights: %v (not using them)",
+
+	// 推荐
+	"📍 当前位置: %s（%s,%s，半径 %d 米）": "📍 Current location: %s (%s,%s, radius %d m)",
+	"没有找到位置「%s」，可用: %s":         "No location named \"%s\". Available: %s",
+	"📍 已切换到「%s」附近":              "📍 Switched to near \"%s\"",
+	"没有找到位置: %s（可用 %s）":         "No location named %s (available: %s)",
+	"搜索餐厅失败: %v":                "Restaurant search failed: %v",
+	"%d米内没有找到%s相关的餐厅，换个口味试试？":   "No %[2]s restaurants found within %[1]dm. Try something else?",
+	"%d米内没有找到合适的餐厅，考虑减少排除条件":    "No suitable restaurants within %dm. Consider removing some exclusions",
+	"附近的餐厅现在都已打烊，考虑点外卖或稍后再试":    "Nearby restaurants are all closed now. Consider delivery or try again later",
+	"LLM 调用失败: %v":              "LLM request failed: %v",
+	"根据今天的天气（%s %s°C），推荐在家做：":   "For today's weather (%s %s°C), try cooking:",
+	"（约%d分钟）":                   " (about %d min)",
+	"食材：":                       "Ingredients: ",
+	"需要买：":                      "To buy: ",
+	"家里的食材就够了":                  "You have everything at home",
+	"想做哪个？说「第一个」记录下来，或者说「出去吃」换回餐厅推荐":      "Which one? Say \"first one\" to record it, or \"eat out\" to go back to restaurants",
+	"请告诉我你要做哪道菜，可以说菜名或者「第一个」「第二个」等":       "Which dish are you making? Say its name or \"first one\", \"second one\"",
+	"好的，已记录今天在家做：%s。":                     "Got it, recorded that you're cooking %s today.",
+	"⚠️ %s，出门注意安全":                        "⚠️ %s, take care when going out",
+	"⚠️ 健康提醒（%s）：":                        "⚠️ Health notes (%s):",
+	"（⚠️ 高德接口今日已调用 %d/%d 次，暂时只使用缓存的餐厅数据）": "(⚠️ Amap API used %d/%d times today, using cached restaurant data for now)",
+	"（附近合适的餐厅较少，已将搜索范围扩大到%d米）":            "(Few suitable restaurants nearby, search radius widened to %dm)",
+	"请告诉我你选择哪个餐厅，可以说餐厅名称或者「第一个」「第二个」等":    "Which restaurant did you pick? Say its name or \"first one\", \"second one\", etc.",
+	"推荐已经更新，请重新选择":                        "The recommendations have changed, please pick again",
+	"好的，已记录本次%s选择：%s。下次会避免重复推荐。祝用餐愉快！🍽️":  "Got it, recorded your %s choice: %s. I'll avoid repeating it. Enjoy! 🍽️",
+	"今天已经吃了约%.0f千卡，超过目标%.0f千卡。":           "About %.0f kcal eaten today, %.0f kcal over your goal.",
+	"今天已经吃了约%.0f千卡（目标%.0f千卡），晚餐会推荐清淡一些的。": "About %.0f kcal eaten today (goal %.0f kcal); dinner suggestions will be lighter.",
+	"获取推荐失败: %v":                   "Failed to get a recommendation: %v",
+	"未知的餐次: %s（可用 lunch / dinner）": "Unknown meal: %s (use lunch / dinner)",
+
+	// 终端界面
+	"终端界面需要在终端中运行（不能重定向输入）": "The terminal UI must run in a terminal (stdin cannot be redirected)",
+	"正在换一批...":             "Finding another batch...",
+	"换一批失败: %v":            "Failed to get another batch: %v",
+	"没有符合筛选条件的餐厅":          "No restaurants match the filter",
+	"候选 %d 家":              "%d candidates",
+	"筛选: %s█  回车完成，Esc 清除": "Filter: %s█  Enter to apply, Esc to clear",
+	"筛选「%s」· ↑↓ 浏览  回车 确认并记录  / 修改筛选  Esc 清除筛选  q 退出": "Filter \"%s\" · ↑↓ browse  Enter confirm & record  / edit filter  Esc clear  q quit",
+	"↑↓ 浏览  回车 确认并记录  / 筛选  r 换一批  q 退出":              "↑↓ browse  Enter confirm & record  / filter  r another batch  q quit",
+	"即将打烊":        "closing soon",
+	"地址: %s":      "Address: %s",
+	"评分: %.1f":    "Rating: %.1f",
+	"（%s %d 条评价）": " (%s, %d reviews)",
+	"人均: ¥%.0f":   "Per person: ¥%.0f",
+	"距离: %d 米":    "Distance: %d m",
+	"，步行约 %d 分钟":  ", about %d min walk",
+	"营业时间: %s":    "Hours: %s",
+	"电话: %s":      "Phone: %s",
+	"地图: %s":      "Map: %s",
+
+	// 命令行帮助
+	"饮食推荐助手 Meal Agent": "Meal Agent - meal recommendation assistant",
+	"用法":                "Usage",
+	"别名":                "Aliases",
+	"选项":                "Options",
+	"子命令":               "Commands",
+	"[选项]":              "[options]",
+	"[全局选项] <子命令> [选项] [参数]":              "[global options] <command> [options] [arguments]",
+	"全局选项（也可以写在子命令后面）":                    "Global options (may also follow the command)",
+	"运行 meal-agent <子命令> --help 查看子命令的选项": "Run meal-agent <command> --help for a command's options",
+	"未知的子命令: %s":                          "Unknown command: %s",
+	"配置文件路径":                              "Config file path",
+	"餐厅偏好配置路径":                            "Restaurant preferences file path",
+	"数据目录路径":                              "Data directory path",
+	"用户 ID（多人共用数据目录时各自记录历史，留空使用共享记录）":                                       "User ID (separate history when several people share a data directory; empty for the shared history)",
+	"推荐时使用的位置（config.yaml 中 locations 的名称，如 office），默认为 location":           "Location to recommend near (a name from locations in config.yaml, e.g. office); default: location",
+	"覆盖配置文件中的一项 `路径=值`，如 --set llm.api_key=xxx（可以写多次，优先于环境变量 MEAL_AGENT_*）": "Override a config.yaml value `path=value`, e.g. --set llm.api_key=xxx (repeatable; takes precedence over MEAL_AGENT_* environment variables)",
+	"日志级别: debug / info / warn / error（默认使用配置中的 log.level）":                 "Log level: debug / info / warn / error (defaults to log.level in the config)",
+	"日志格式: text / json（默认使用配置中的 log.format）":                                "Log format: text / json (defaults to log.format in the config)",
+	"部分插件没有启动":             "Some plugins failed to start",
+	"⚠️ 读取加密密钥失败: %v":      "⚠️ Failed to read the encryption key: %v",
+	"⚠️ 日志设置失败: %v（输出到终端）": "⚠️ Failed to set up logging: %v (logging to the terminal)",
+	"不导出链路追踪":              "Not exporting traces",
+	"把每次推荐的 prompt、带权重的候选餐厅和高德、天气、LLM 接口的原始响应写到这个目录（排查推荐不合适的原因）": "Write each recommendation's prompt, weighted candidates and raw Amap / weather / LLM responses to this directory (for diagnosing bad recommendations)",
+	"⚠️ %v（不输出调试文件）":                                                                "⚠️ %v (not writing debug files)",
+	"终端中不使用颜色和样式显示回复（也可以设置环境变量 NO_COLOR）":                                           "Don't use colors and styles for replies in the terminal (or set NO_COLOR)",
+	"`format`: text / json / yaml（recommend、ask、cook、history、stats 支持 json 和 yaml）": "`format`: text / json / yaml (recommend, ask, cook, history and stats support json and yaml)",
+	"餐次: lunch / dinner（默认按当前时间）":                                                   "Meal: lunch / dinner (default: by the current time)",
+	"json / yaml 输出中列出的候选餐厅数量":                                                      "Number of candidates in json / yaml output",
+	"统计区间: week / month / all / 2024-06 / 2024-01..2024-06":                         "Period: week / month / all / 2024-06 / 2024-01..2024-06",
+	"同 --output json":                        "Same as --output json",
+	"列出今后多少天的安排（最多 %d）":                      "Days ahead to include (at most %d)",
+	"输出文件（留空输出到标准输出）":                        "Output file (empty for standard output)",
+	"init 时覆盖已存在的配置文件":                       "Overwrite an existing config file on init",
+	"最多列出多少条":                                "Maximum number of records",
+	"导出格式: csv / xlsx":                       "Export format: csv / xlsx",
+	"日期范围: all / 2024-06 / 2024-01..2024-06": "Date range: all / 2024-06 / 2024-01..2024-06",
+	"餐厅名称（包含即可）":                             "Restaurant name (substring)",
+	"菜系":                                     "Cuisine",
+	"备注包含的文字":                                "Text in the note",
+	"最低评分":                                   "Minimum rating",
+	"偏好包名称，如 公司周边好店":                         "Bundle name, e.g. Near the office",
+	"整理人":                                    "Author",
+	"用偏好包替换本地的餐厅、菜系和规则（默认合并，本地已有的保留）":    "Replace local restaurants, cuisines and rules with the bundle (default: merge, keeping local entries)",
+	"Linux 上安装为系统服务（需要 root），默认为当前用户的服务": "On Linux install a system service (requires root); default is a user service",
+	"只输出生成的服务文件，不安装":                     "Print the generated service file without installing",
+
+	// 子命令说明
+	"交互模式：在终端中对话（不写子命令时的默认命令）":               "Interactive chat in the terminal (the default command)",
+	"后台模式：按提醒时间推送推荐，修改配置后自动重新加载":             "Daemon: push recommendations at reminder times, reload on config changes",
+	"局域网网页和 Slack、Discord 聊天机器人":             "LAN web UI and Slack / Discord bots",
+	"MCP 服务：通过标准输入输出供 Claude Desktop 等客户端调用": "MCP server over stdio for Claude Desktop and other clients",
+	"终端界面：方向键浏览候选餐厅，回车确认并记录，/ 筛选":            "Terminal UI: browse candidates with arrow keys, Enter to confirm and record, / to filter",
+	"推荐一次并输出，如 recommend 不要辣":                "Recommend once and print it, e.g. recommend no spicy food",
+	"说一句话、输出回复后退出，如 ask \"不想吃辣，推荐晚餐\"":       "Say one thing, print the reply and exit, e.g. ask \"nothing spicy, dinner ideas\"",
+	"<要说的话>": "<message>",
+	`和对话模式一样理解这句话（推荐、排除、记录、改偏好都可以），输出回复后退出:
+  meal-agent ask "不想吃辣，推荐晚餐"
+  meal-agent ask "上次吃那家泰国菜是什么时候"
+  meal-agent ask --json "来点清淡的"    # 输出 JSON，推荐了餐厅时附带候选
+
+每次都是新的对话，不记得上一次 ask 推荐了什么。
+
+退出码: 0 成功，1 出错（错误输出到标准错误），2 用法有误，3 附近没有找到合适的餐厅`: `The message is understood as in chat (recommend, exclude, record, edit preferences), then the reply is printed:
+  meal-agent ask "nothing spicy, dinner ideas"
+  meal-agent ask "when did I last have thai food"
+  meal-agent ask --json "something light"   # JSON output, with candidates when restaurants were recommended
+
+Each call is a new conversation and does not remember what the previous ask recommended.
+
+Exit codes: 0 success, 1 error (printed to stderr), 2 usage error, 3 no suitable restaurant nearby`,
+	"做饭模式：推荐几道在家做的菜和购物清单，如 cook 想吃鱼":  "Cooking mode: suggest a few recipes to make at home with a shopping list, e.g. cook fish",
+	"记录一次用餐，如 record 海底捞 火锅 138 评分:5": "Record a meal, e.g. record Haidilao hotpot 138 评分:5",
+	"查看、搜索、导出和导入用餐记录":                 "List, search, export and import meal records",
+	"用餐统计": "Meal statistics",
+	"导出用餐安排和确认的选择为 .ics 日历":                       "Export planned meals and confirmed choices as an .ics calendar",
+	"自检：检查配置是否完整，测试高德、天气、LLM 接口和数据目录":             "Self-check: config completeness, Amap, weather and LLM connectivity, data directory",
+	"只检查配置和数据目录，不请求外部接口":                          "Only check the config and data directory, without calling external APIs",
+	"加密保存用餐历史和偏好文件（AES-GCM）":                      "Encrypt meal history and preference files at rest (AES-GCM)",
+	"在系统钥匙串中保存密钥，配置中写 keyring:meal-agent/amap 引用": "Store secrets in the OS keychain, referenced from config as keyring:meal-agent/amap",
+	"<set|get|delete> <服务/账户>":                    "<set|get|delete> <service/account>",
+	`子命令:
+  set <服务/账户>       保存密钥（在提示后输入，不回显；也可以从标准输入读取）
+  get <服务/账户>       检查密钥是否已保存（只显示开头和结尾）
+  delete <服务/账户>    删除密钥
+
+只写账户时服务为 meal-agent。保存后在 config.yaml 中写:
+  api:
+    amap_key: "keyring:meal-agent/amap"`: `Commands:
+  set <service/account>       Store a secret (typed at the prompt without echo, or read from stdin)
+  get <service/account>       Check that a secret is stored (shows only its start and end)
+  delete <service/account>    Delete a secret
+
+The service defaults to meal-agent. After storing, write in config.yaml:
+  api:
+    amap_key: "keyring:meal-agent/amap"`,
+	"检查配置文件，或从示例生成配置文件":             "Check the config file, or create one from the example",
+	"检查、导出和导入偏好配置":                  "Check, export and import preferences",
+	"查看或清空根据评分和选择学到的权重调整":           "Show or clear weights learned from ratings and choices",
+	"家里的食材库存，做饭模式优先用快过期的，常备食材不够时提醒": "Ingredients at home: cooking mode uses what expires soon, reminders when staples run low",
+	"[add|use|remove] ...": "[add|use|remove] ...",
+	`子命令:
+  list                                         列出家里的食材（默认）
+  add <食材> [数量] [过期:日期] [常备:数量]    买了食材，如 add 鸡蛋 10个 过期:7天 常备:4
+  use <食材> [数量]                            用掉一些（不写数量时全部用完）
+  remove <食材>                                从库存中删除
+
+过期可以写 2024-07-01、07-01、3天、明天。常备食材低于常备数量时，每天的提醒中会提示补货；
+做饭模式推荐菜谱时优先用快过期的食材，购物清单中不再列出家里有的。`: `Commands:
+  list                                         List ingredients at home (default)
+  add <item> [amount] [过期:date] [常备:min]   Bought something, e.g. add eggs 10 过期:7天 常备:4
+  use <item> [amount]                          Used some (all of it when no amount is given)
+  remove <item>                                Remove from the pantry
+
+过期 (expiry) accepts 2024-07-01, 07-01, 3天 (in 3 days) or 明天 (tomorrow). Staples (常备) below their
+minimum are mentioned in the daily reminder; cooking mode prefers ingredients that expire soon and
+leaves what you have off the shopping list.`,
+	"与其他设备同步用餐历史和偏好（需要配置 sync）":         "Sync meal history and preferences with other devices (requires sync config)",
+	"安装为开机自动运行的后台服务（systemd / launchd）": "Install the daemon as a service started at login (systemd / launchd)",
+	"显示帮助":         "Show help",
+	"[要求]":         "[request]",
+	"[关键词]":        "[keyword]",
+	"[子命令]":        "[command]",
+	"[reset [餐厅]]": "[reset [restaurant]]",
+	"<餐厅名> [类型] [金额] [评分:1-5] [备注:内容] [照片:路径或链接]": "<restaurant> [type] [amount] [评分:1-5] [备注:note] [照片:path or URL]",
+	`子命令:
+  list [--limit 20]                   最近的用餐记录（默认）
+  export [--format csv|xlsx] [--range 2024-01..2024-06] [--output 文件]
+  import <文件.csv|文件.xlsx>
+  search [关键词] [--restaurant 名称] [--category 菜系] [--note 文字] [--range 2024-01..2024-06] [--rating 4]`: `Commands:
+  list [--limit 20]                   Recent meals (default)
+  export [--format csv|xlsx] [--range 2024-01..2024-06] [--output file]
+  import <file.csv|file.xlsx>
+  search [keyword] [--restaurant name] [--category cuisine] [--note text] [--range 2024-01..2024-06] [--rating 4]`,
+	`子命令:
+  validate          检查配置文件（-config）和偏好配置（-pref），输出主要设置
+  init [--force]    从示例生成配置文件（已存在时需要 --force 覆盖）`: `Commands:
+  validate          Check the config file (-config) and preferences (-pref), print the main settings
+  init [--force]    Create the config file from the example (--force to overwrite)`,
+	`子命令:
+  validate [偏好配置文件...]                              检查偏好配置（默认检查 -pref）
+  export [--name 名称] [--author 整理人] [--output 文件]  导出为偏好包，分享给同事
+  import <偏好包.yaml> [--replace]                       导入偏好包（默认合并）`: `Commands:
+  validate [file...]                                     Check preference files (default: -pref)
+  export [--name name] [--author author] [--output file] Export a shareable bundle
+  import <bundle.yaml> [--replace]                       Import a bundle (default: merge)`,
+	`子命令:
+  install [--system] [--print]   安装并启动服务（--print 只输出生成的服务文件）
+  uninstall [--system]           停止并删除服务
+  status [--system]              查看服务状态`: `Commands:
+  install [--system] [--print]   Install and start the service (--print only prints the service file)
+  uninstall [--system]           Stop and remove the service
+  status [--system]              Show the service status`,
+
+	// 子命令输出
+	"命令行用法有误":                  "Invalid command line usage",
+	"错误: %v":                   "Error: %v",
+	"   Slack: 已开启 /meal 命令":   "   Slack: /meal command enabled",
+	"   Discord: 已开启 /meal 命令": "   Discord: /meal command enabled",
+	"不提醒":                      "no reminder",
+	"清空失败: %v":                 "Failed to clear: %v",
+	"没有「%s」的学习记录":              "No learned adjustment for \"%s\"",
+	"已清空 %d 条学习记录":             "Cleared %d learned adjustments",
+	"还没有学到的权重调整（评分或从推荐中选择餐厅后会自动调整）": "No learned weight adjustments yet (they are made automatically when you rate or pick a recommended restaurant)",
+	"根据评分和选择学到的权重调整：":               "Weight adjustments learned from ratings and picks:",
+	"\n  %s %+d（评分 %d 次，选中 %d 次）":   "\n  %s %+d (rated %d times, picked %d times)",
+	"保存失败: %v":      "Failed to save: %v",
+	"已添加，现在有 %s":    "Added, now have %s",
+	"%s用完了，记得补货":    "%s is used up, remember to restock",
+	"%s用完了，已从库存中删除": "%s is used up and was removed from the pantry",
+	"已更新，还剩 %s":     "Updated, %s left",
+	"删除失败: %v":      "Failed to delete: %v",
+	"已删除%s":         "Removed %s",
+	"用法: 食材 [添加|用掉|删除] ...，如: 食材 添加 鸡蛋 10个 过期:7天 常备:4": "Usage: pantry [add|use|remove] ..., e.g. pantry add eggs 10个 过期:7天 常备:4",
+	"读取食材库存失败: %v": "Failed to read the pantry: %v",
+	"家里还没有记录食材（如: 食材 添加 鸡蛋 10个 过期:7天）": "No pantry items recorded yet (e.g. pantry add eggs 10个 过期:7天)",
+	"家里的食材：":       "Pantry:",
+	"  ⚠️ 不足%g%s":  "  ⚠️ below %g%s",
+	"加载偏好配置失败: %v": "Failed to load preferences: %v",
+	"偏好配置有错误":      "The preferences file has errors",
+	"创建输出文件失败: %v": "Failed to create the output file: %v",
+	"导出失败: %v":     "Export failed: %v",
+	"已导出 %d 家餐厅、%d 个菜系、%d 条规则到 %s（不包含常吃清单、口味和临时偏好）":     "Exported %d restaurants, %d cuisines and %d rules to %s (favorites, taste and temporary preferences are not included)",
+	"用法: meal-agent pref import <偏好包.yaml> [--replace]": "Usage: meal-agent pref import <bundle.yaml> [--replace]",
+	"加载偏好包失败: %v":    "Failed to load the preference bundle: %v",
+	"备份偏好配置失败: %v":   "Failed to back up preferences: %v",
+	"原配置已备份到 %s.bak": "The previous preferences were backed up to %s.bak",
+	"导入失败: %v":       "Import failed: %v",
+	"保存偏好配置失败: %v":   "Failed to save preferences: %v",
+	"合并":             "Merged",
+	"替换为":            "Replaced with",
+	"已%s%s：%d 家餐厅、%d 个菜系、%d 条规则":                     "%s %s: %d restaurants, %d cuisines, %d rules",
+	"，%d 条本地已有的保留本地设置":                               ", %d kept their local settings",
+	"未知的子命令: pref %s\n%s":                            "Unknown subcommand: pref %s\n%s",
+	"找不到可执行文件: %v":                                   "Executable not found: %v",
+	"安装服务失败: %v":                                     "Failed to install the service: %v",
+	"已安装并启动服务 %s（%s），开机后自动运行后台模式":                    "Installed and started service %s (%s); daemon mode will run at boot",
+	"卸载服务失败: %v":                                     "Failed to uninstall the service: %v",
+	"已停止并删除服务 %s":                                    "Stopped and removed service %s",
+	"查询服务状态失败: %v":                                   "Failed to query the service status: %v",
+	"未知的子命令: service %s\n%s":                         "Unknown subcommand: service %s\n%s",
+	"%s: 没有发现问题":                                     "%s: no problems found",
+	"%s: %d 个错误，%d 个提醒":                              "%s: %d errors, %d warnings",
+	"未配置云同步，请在 config.yaml 中设置 sync.provider":        "Cloud sync is not configured, set sync.provider in config.yaml",
+	"未知的同步方式: %s":                                    "Unknown sync provider: %s",
+	"云同步失败: %v":                                      "Cloud sync failed: %v",
+	"xlsx 格式需要用 --output 指定输出文件":                     "The xlsx format needs an output file given with --output",
+	"用法: meal-agent history import <文件.csv|文件.xlsx>": "Usage: meal-agent history import <file.csv|file.xlsx>",
+	"已导入 %d 条记录（重复的记录已跳过）":                           "Imported %d records (duplicates were skipped)",
+	"没有找到符合条件的用餐记录":                                  "No matching meal records",
+	"共 %d 条":                       "%d in total",
+	"未知的子命令: history %s\n%s":       "Unknown subcommand: history %s\n%s",
+	" 评分%d":                        " rated %d",
+	" 备注：%s":                       " note: %s",
+	"MCP 服务出错: %v":                 "MCP server error: %v",
+	"饮食安排":                         "Meal plan",
+	"写入日历失败: %v":                   "Failed to write the calendar: %v",
+	"  位置: %s,%s（%s，半径 %d 米）":      "  Location: %s,%s (%s, radius %d m)",
+	"  地址: %s":                     "  Address: %s",
+	"  常用位置: %s":                   "  Saved locations: %s",
+	"  餐厅数据: %s，天气: %s，LLM: %s %s": "  Restaurants: %s, weather: %s, LLM: %s %s",
+	"  提醒时间: 午餐 %s，晚餐 %s":          "  Reminders: lunch %s, dinner %s",
+	"%s 已存在（覆盖请加 --force）":         "%s already exists (add --force to overwrite)",
+	"写入配置文件失败: %v":                 "Failed to write the config file: %v",
+	"已生成 %s，填写位置、API Key 后运行 meal-agent config validate 检查":  "Created %s; fill in the location and API keys, then run meal-agent config validate",
+	"未知的子命令: config %s\n%s":                                  "Unknown subcommand: config %s\n%s",
+	"输入 %s/%s 的密钥: ":                                         "Enter the secret for %s/%s: ",
+	"读取输入失败: %v":                                             "Failed to read input: %v",
+	"密钥为空，没有保存":                                              "The secret is empty, nothing was saved",
+	"保存到系统钥匙串失败: %v":                                         "Failed to save to the system keyring: %v",
+	"已保存，在 config.yaml 中写 \"%s\" 引用":                         "Saved; reference it in config.yaml as \"%s\"",
+	"%s: %s（%d 个字符）":                                         "%s: %s (%d characters)",
+	"已删除 %s":                                                 "Deleted %s",
+	"未知的子命令: keyring %s\n%s":                                 "Unknown subcommand: keyring %s\n%s",
+	"保存到系统钥匙串: meal-agent keyring set meal-agent/encryption": "To save it in the system keyring: meal-agent keyring set meal-agent/encryption",
+	"已设置密钥，保存时加密":                                            "A key is set, files are encrypted when saved",
+	"没有设置密钥，保存时不加密":                                          "No key is set, files are saved unencrypted",
+	"  %s: 已加密":                                              "  %s: encrypted",
+	"  %s: 未加密":                                              "  %s: not encrypted",
+	"没有设置密钥（encryption.key 或环境变量 MEAL_AGENT_ENCRYPTION_KEY），用 meal-agent encryption keygen 生成": "No key is set (encryption.key or the MEAL_AGENT_ENCRYPTION_KEY environment variable); generate one with meal-agent encryption keygen",
+	"写入 %s 失败: %v":              "Failed to write %s: %v",
+	"已加密 %d 个文件":                "Encrypted %d files",
+	"已解密 %d 个文件":                "Decrypted %d files",
+	"未知的子命令: encryption %s\n%s": "Unknown subcommand: encryption %s\n%s",
+	"加载学习记录失败: %v":              "Failed to load learned adjustments: %v",
+
+	// 体检
+	"配置文件 %s": "Config file %s",
+	"配置文件不存在": "The config file does not exist",
+	"运行 meal-agent config init 从示例生成，再填写位置和 API Key": "Run meal-agent config init to create it from the example, then fill in the location and API keys",
+	"无法加载: %v": "Cannot load: %v",
+	"按错误提示修改后运行 meal-agent config validate": "Fix the reported errors, then run meal-agent config validate",
+	"格式和取值没有问题":                             "Format and values are OK",
+	"偏好配置 %s":                               "Preferences %s",
+	"数据目录 %s":                               "Data directory %s",
+	"餐厅数据（%s）":                              "Restaurants (%s)",
+	"LLM（%s）":                               "LLM (%s)",
+	"天气（%s）":                                "Weather (%s)",
+	"已跳过（--offline）":                        "Skipped (--offline)",
+	"发现 %d 个问题，%d 个提醒":                      "Found %d problems, %d warnings",
+	"没有发现问题，%d 个提醒":                         "No problems found, %d warnings",
+	"全部检查通过":                                "All checks passed",
+	"未填写 %s":                                "%s is not set",
+	"%s 还是示例中的占位文字":                         "%s is still the placeholder from the example",
+	"未填写位置（location.lat、location.lng）":      "No location set (location.lat, location.lng)",
+	"在高德坐标拾取器中查询公司或家的坐标，或填写 location.address":                 "Look up the coordinates of your office or home with the AMap coordinate picker, or set location.address",
+	"未填写坐标，只有城市 %s":                                           "No coordinates set, only the city %s",
+	"搜索附近餐厅需要 location.lat、location.lng 或 location.address":   "Searching nearby restaurants needs location.lat and location.lng, or location.address",
+	"在高德开放平台创建「Web服务」类型的 Key":                                 "Create a \"Web Service\" key on the AMap open platform",
+	"餐厅列表 api.static_list 无法读取: %s":                           "Cannot read the restaurant list api.static_list: %s",
+	"复制 nearby.example.yaml 并修改 static_list 为它的路径":            "Copy nearby.example.yaml and point static_list at it",
+	"食堂菜单 canteen.menu 无法读取: %v":                              "Cannot read the canteen menu canteen.menu: %v",
+	"复制 canteen.example.yaml 并修改 canteen.menu 为它的路径":          "Copy canteen.example.yaml and point canteen.menu at it",
+	"在和风天气控制台创建 Key，或设置 weather_provider: open-meteo（无需 Key）": "Create a key in the QWeather console, or set weather_provider: open-meteo (no key needed)",
+	"在 OpenWeatherMap 的 API keys 页面获取 Key":                    "Get a key from the OpenWeatherMap API keys page",
+	"填写所选 LLM 服务的 API Key":                                    "Set the API key of the chosen LLM service",
+	"填写模型名称，如 deepseek-chat、qwen-plus":                        "Set the model name, e.g. deepseek-chat or qwen-plus",
+	"位置、API Key、模型都已填写":                                       "Location, API keys and model are set",
+	"没有偏好配置（可选），使用默认权重":                                       "No preferences file (optional), using default weights",
+	"%d 个错误，%d 个提醒":                                           "%d errors, %d warnings",
+	"运行 meal-agent pref validate 查看详情":                        "Run meal-agent pref validate for details",
+	"%d 个提醒":      "%d warnings",
+	"没有发现问题":      "No problems found",
+	"无法创建 %s: %v": "Cannot create %s: %v",
+	"检查上级目录的权限，或用 -data 指定其他目录": "Check the permissions of the parent directory, or choose another directory with -data",
+	"%s 无法写入: %v": "%s is not writable: %v",
+	"检查目录的所有者和权限（如服务以其他用户运行）": "Check the owner and permissions of the directory (e.g. when the service runs as another user)",
+	"%s 可以写入":               "%s is writable",
+	"还没有用餐记录":               "No meal records yet",
+	"无法读取 history.json: %v": "Cannot read history.json: %v",
+	"检查 encryption.key 是否和加密时使用的密钥一致":                           "Check that encryption.key matches the key used to encrypt it",
+	"history.json 不是合法的 JSON，记录不会被加载":                           "history.json is not valid JSON, records will not be loaded",
+	"从备份或同步的远端恢复，或用 history import 重新导入":                        "Restore it from a backup or the sync remote, or import it again with history import",
+	"history.json 可以正常读取":                                       "history.json can be read",
+	"网络问题：无法解析域名 %s":                                            "Network problem: cannot resolve %s",
+	"网络问题：证书不受信任（公司网络检查 HTTPS 时在 network.ca_file 中填写公司的 CA 证书）": "Network problem: untrusted certificate (if your company network inspects HTTPS, set its CA certificate in network.ca_file)",
+	"网络问题：请求超时（%s）":                                             "Network problem: request timed out (%s)",
+	"网络问题：%v":                                                   "Network problem: %v",
+	"检查网络连接和代理设置（network.proxy 或 HTTPS_PROXY），公司内网可能需要放行该域名":    "Check the network connection and proxy settings (network.proxy or HTTPS_PROXY); a company network may need to allow this domain",
+	"%d 米内没有找到餐厅":                                               "No restaurants found within %d m",
+	"检查坐标是否正确，或增大 location.radius":                              "Check the coordinates, or increase location.radius",
+	"%d 米内找到 %d 家餐厅":                                            "Found %[2]d restaurants within %[1]d m",
+	"Key 错误：Key 不正确或已过期":                                        "Key error: the key is wrong or has expired",
+	"核对 api.amap_key，需要「Web服务」类型的 Key":                          "Check api.amap_key; a \"Web Service\" key is required",
+	"Key 错误：没有权限使用周边搜索服务":                                       "Key error: no permission to use nearby search",
+	"在高德控制台确认 Key 开通了 Web服务 API":                                "Make sure the key has the Web Service API enabled in the AMap console",
+	"Key 错误：本机 IP 不在 Key 的白名单中":                                 "Key error: this machine's IP is not in the key's allow list",
+	"在高德控制台修改 IP 白名单或清空白名单":                                     "Update or clear the IP allow list in the AMap console",
+	"Key 错误：Key 开启了数字签名":                                        "Key error: the key requires a digital signature",
+	"在高德控制台关闭这个 Key 的数字签名":                                      "Turn off the digital signature for this key in the AMap console",
+	"Key 错误：Key 的类型不匹配":                                         "Key error: wrong key type",
+	"需要「Web服务」类型的 Key，JS API、Android 等类型的 Key 不能使用":             "A \"Web Service\" key is required; JS API, Android and other key types do not work",
+	"配额用完：今日调用量已超过限制":                                           "Quota exhausted: today's call limit has been exceeded",
+	"明天恢复，或在高德控制台申请提高配额；期间会使用缓存的餐厅数据":                           "It resets tomorrow, or request a higher quota in the AMap console; cached restaurant data is used meanwhile",
+	"配额问题：调用过于频繁":                                               "Quota problem: too many requests",
+	"稍后再试，或降低 api.amap_qps":                                     "Try again later, or lower api.amap_qps",
+	"配额问题：本机 IP 访问超限":                                           "Quota problem: request limit for this IP exceeded",
+	"稍后再试":                                                      "Try again later",
+	"配额问题：服务总调用量超限":                                             "Quota problem: total call limit of the service exceeded",
+	"配额问题：服务 QPS 超限":                                            "Quota problem: service QPS limit exceeded",
+	"配额问题：接口 QPS 超限":                                            "Quota problem: API QPS limit exceeded",
+	"配额问题：账号 QPS 超限":                                            "Quota problem: account QPS limit exceeded",
+	"配额用完：账号今日调用量已超过限制":                                         "Quota exhausted: the account's call limit for today has been exceeded",
+	"明天恢复，或在高德控制台申请提高配额":                                        "It resets tomorrow, or request a higher quota in the AMap console",
+	"请求参数错误":                                                    "Invalid request parameters",
+	"检查 location.lat、location.lng 是否为 GCJ-02 坐标":                "Check that location.lat and location.lng are GCJ-02 coordinates",
+	"没有填写 api.amap_key，跳过":                                      "api.amap_key is not set, skipped",
+	"配额用完：本地统计今日已调用 %d/%d 次":                                    "Quota exhausted: %d/%d calls made today (local count)",
+	"明天恢复，或调大 api.amap_daily_quota":                             "It resets tomorrow, or raise api.amap_daily_quota",
+	"无法识别的响应（HTTP %d）":                                          "Unrecognized response (HTTP %d)",
+	"高德API错误: %s %s":                                            "AMap API error: %s %s",
+	"Key 正常，%d 米内有 %s 家餐饮 POI":                                  "Key OK, %[2]s food POIs within %[1]d m",
+	"（今日已调用 %d/%d 次）":                                           " (%d/%d calls today)",
+	"检查坐标是否正确（高德使用 GCJ-02 坐标），或增大 location.radius":              "Check the coordinates (AMap uses GCJ-02), or increase location.radius",
+	"今日调用量接近配额，推荐时会优先使用缓存":                                      "Today's calls are close to the quota, recommendations will prefer the cache",
+	"Open-Meteo API错误（HTTP %d）: %s":                             "Open-Meteo API error (HTTP %d): %s",
+	"检查 location.lat、location.lng":                              "Check location.lat and location.lng",
+	"接口正常（无需 Key）":                                              "API OK (no key needed)",
+	"检查 location.lat、location.lng 或 location.city":              "Check location.lat and location.lng, or location.city",
+	"Key 错误：认证失败":                                               "Key error: authentication failed",
+	"核对 api.weather_key；免费订阅的 Key 只能访问 devapi.qweather.com":     "Check api.weather_key; free subscription keys only work with devapi.qweather.com",
+	"配额用完：超过访问次数或余额不足":                                          "Quota exhausted: request limit exceeded or insufficient balance",
+	"在和风天气控制台查看用量和余额":                                           "Check usage and balance in the QWeather console",
+	"Key 错误：无访问权限":                                              "Key error: access denied",
+	"在和风天气控制台确认 Key 的项目订阅了实时天气":                                 "Make sure the key's project subscribes to real-time weather in the QWeather console",
+	"查询的地区不存在":                                                  "The requested location does not exist",
+	"配额问题：每分钟请求次数超限":                                            "Quota problem: per-minute request limit exceeded",
+	"没有填写 api.weather_key，跳过":                                   "api.weather_key is not set, skipped",
+	"城市未找到: %s":                                                 "City not found: %s",
+	"检查 location.city，或填写 location.lat、location.lng":            "Check location.city, or set location.lat and location.lng",
+	"Key 正常，当前 %s %s°C":                                         "Key OK, currently %s %s°C",
+	"天气API错误，code: %s":                                          "Weather API error, code: %s",
+	"Key 正常":                                                    "Key OK",
+	"Key 错误：%s":                                                 "Key error: %s",
+	"核对 api.weather_key；新建的 Key 可能需要等待几小时才生效":                   "Check api.weather_key; a new key may take a few hours to become active",
+	"配额用完：%s":                                                   "Quota exhausted: %s",
+	"免费账号每分钟 60 次、每月 100 万次，稍后再试":                               "Free accounts allow 60 calls per minute and 1,000,000 per month, try again later",
+	"OpenWeatherMap API错误（HTTP %d）: %s":                         "OpenWeatherMap API error (HTTP %d): %s",
+	"没有填写 llm.api_key，跳过":                                       "llm.api_key is not set, skipped",
+	"接口地址无效: %v":                                                "Invalid API address: %v",
+	"检查 llm.base_url":                                           "Check llm.base_url",
+	"；自建或代理的接口检查 llm.base_url":                                  "; for self-hosted or proxied APIs check llm.base_url",
+	"连接正常，%s 响应用时 %.1f 秒":                                       "Connection OK, %s responded in %.1f s",
+	"核对 llm.api_key，以及 provider / base_url 是否与 Key 所属的服务一致":     "Check llm.api_key, and that provider / base_url match the service the key belongs to",
+	"Key 无权限：%s":                                                "Key not authorized: %s",
+	"确认账号已开通该模型，部分服务限制访问地区":                                     "Make sure the account has access to the model; some services restrict access by region",
+	"在服务商控制台查看余额和额度":                                            "Check the balance and quota in the provider's console",
+	"配额问题：请求过于频繁：%s":                                            "Quota problem: too many requests: %s",
+	"稍后再试，或提高账号的速率限制":                                           "Try again later, or raise the account's rate limit",
+	"地址或模型名称错误（HTTP %d）：%s":                                     "Wrong address or model name (HTTP %d): %s",
+	"检查 llm.base_url（当前 %s）和 llm.model（当前 %s）":                  "Check llm.base_url (currently %s) and llm.model (currently %s)",
+	"服务端错误（HTTP %d）：%s":                                         "Server error (HTTP %d): %s",
+	"服务暂时不可用，稍后再试":                                              "The service is temporarily unavailable, try again later",
+	"LLM 接口错误（HTTP %d）：%s":                                      "LLM API error (HTTP %d): %s",
+
+	// 提醒和同步
+	"🍽️ %s时间到！":            "🍽️ Time for %s!",
+	"（补发 %s 的提醒）":          " (missed reminder from %s)",
+	"📋 %s预告（%s 提醒）":        "📋 %s preview (reminder at %s)",
+	"获取推荐失败":               "Failed to get a recommendation",
+	"等待正在进行的推荐超时（%s），强制退出": "Timed out waiting for the running recommendation (%s), exiting anyway",
+	"外卖模式未开启，可以在配置文件的 delivery.enabled 中打开": "Delivery mode is off; turn it on with delivery.enabled in the config file",
+	"本月预算%d元，已超支%.0f元":                      "Monthly budget ¥%d, over by ¥%.0f",
+	"本月预算%d元，还剩%.0f元":                       "Monthly budget ¥%d, ¥%.0f left",
+	"同步完成：新增 %d 条记录，更新 %d 条，删除 %d 条":        "Sync complete: %d records added, %d updated, %d deleted",
+	"；下载 %v": "; downloaded %v",
+	"；上传 %v": "; uploaded %v",
+	"；%v 两边都有修改，已保留较新的版本，另一份保存为 .conflict": "; %v changed on both sides, kept the newer version and saved the other as .conflict",
+}
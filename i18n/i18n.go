@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 界面语言，启动时按配置中的 language 设置
+// 文字以中文原文作为键，目录中没有翻译的文字原样显示中文
+var (
+	mu      sync.RWMutex
+	current = "zh"
+)
+
+// catalogs 各语言的翻译：中文原文 -> 译文（中文不需要目录）
+var catalogs = map[string]map[string]string{
+	"en": en,
+}
+
+// instructions 各语言追加到 LLM 系统提示词的要求
+var instructions = map[string]string{
+	"en": "Always reply in English, even though the instructions, restaurant data and notes above are in Chinese. " +
+		"Keep restaurant names exactly as given so the user can find them, adding a short English description in parentheses where helpful.",
+}
+
+// Supported 是否支持该语言（空表示默认的中文）
+func Supported(lang string) bool {
+	if lang == "" || lang == "zh" {
+		return true
+	}
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Languages 支持的语言
+func Languages() []string {
+	return []string{"zh", "en"}
+}
+
+// SetLanguage 设置界面语言
+func SetLanguage(lang string) error {
+	if !Supported(lang) {
+		return fmt.Errorf("不支持的语言: %s（可用 %s）", lang, strings.Join(Languages(), " / "))
+	}
+	if lang == "" {
+		lang = "zh"
+	}
+	mu.Lock()
+	current = lang
+	mu.Unlock()
+	return nil
+}
+
+// Language 当前界面语言
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T 翻译为当前语言，msg 为中文原文；有 args 时按格式化字符串处理
+func T(msg string, args ...interface{}) string {
+	mu.RLock()
+	if s, ok := catalogs[current][msg]; ok {
+		msg = s
+	}
+	mu.RUnlock()
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Instruction 让 LLM 用该语言回复的要求，中文时为空
+func Instruction(lang string) string {
+	return instructions[lang]
+}
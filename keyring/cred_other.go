@@ -0,0 +1,15 @@
+//go:build !windows
+
+package keyring
+
+func credRead(target string) (string, error) {
+	return "", unsupported()
+}
+
+func credWrite(target, user, secret string) error {
+	return unsupported()
+}
+
+func credDelete(target string) error {
+	return unsupported()
+}
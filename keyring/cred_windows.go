@@ -0,0 +1,113 @@
+//go:build windows
+
+package keyring
+
+import (
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Windows 凭据管理器中的"普通凭据"，密码按 UTF-16 保存（与 cmdkey /generic 添加的相同）
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = 1168
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential CREDENTIALW 结构
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credRead(target string) (string, error) {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, err := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return "", credError(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	chars := make([]uint16, len(blob)/2)
+	for i := range chars {
+		chars[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+func credWrite(target, user, secret string) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	userName, err := syscall.UTF16PtrFromString(user)
+	if err != nil {
+		return err
+	}
+	chars := utf16.Encode([]rune(secret))
+	blob := make([]byte, 2*len(chars))
+	for i, c := range chars {
+		blob[2*i], blob[2*i+1] = byte(c), byte(c>>8)
+	}
+
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		UserName:           userName,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	r, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+func credDelete(target string) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0)
+	if r == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+func credError(err error) error {
+	if errno, ok := err.(syscall.Errno); ok && errno == errorNotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("凭据管理器: %v", err)
+}
@@ -0,0 +1,148 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// 系统钥匙串中的密钥：macOS 钥匙串（security）、Linux Secret Service（secret-tool）、Windows 凭据管理器
+// 配置中写 keyring:meal-agent/amap 时从钥匙串读取服务 meal-agent、账户 amap 的密码
+
+// Prefix 配置中引用钥匙串的写法：keyring:<服务>/<账户>，只写账户时服务为 meal-agent
+const Prefix = "keyring:"
+
+// DefaultService 没有写服务时使用的服务名称
+const DefaultService = "meal-agent"
+
+// ErrNotFound 钥匙串中没有这一项
+var ErrNotFound = errors.New("钥匙串中没有这一项")
+
+// IsRef 是否是钥匙串引用
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Parse 解析 "keyring:服务/账户" 或 "服务/账户"
+func Parse(ref string) (service, account string, err error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), Prefix)
+	service, account, ok := strings.Cut(ref, "/")
+	if !ok {
+		service, account = DefaultService, ref
+	}
+	if service == "" || account == "" {
+		return "", "", fmt.Errorf("钥匙串引用格式错误: %s（应为 keyring:服务/账户，如 keyring:meal-agent/amap）", ref)
+	}
+	return service, account, nil
+}
+
+// Resolve 读取引用对应的密钥
+func Resolve(ref string) (string, error) {
+	service, account, err := Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return Get(service, account)
+}
+
+// Get 读取密钥
+func Get(service, account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := run("", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+		if err != nil {
+			return "", notFound(err, "could not be found")
+		}
+		return strings.TrimRight(out, "\n"), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		out, err := run("", "secret-tool", "lookup", "service", service, "account", account)
+		if err != nil {
+			return "", notFound(err, "")
+		}
+		if out == "" {
+			return "", ErrNotFound
+		}
+		return strings.TrimRight(out, "\n"), nil
+	case "windows":
+		return credRead(target(service, account))
+	}
+	return "", unsupported()
+}
+
+// Set 保存密钥（已存在时覆盖）
+func Set(service, account, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// security 只能通过参数传入密码
+		_, err := run("", "security", "add-generic-password", "-U", "-s", service, "-a", account, "-l", service+"/"+account, "-w", secret)
+		return err
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err := run(secret, "secret-tool", "store", "--label", service+"/"+account, "service", service, "account", account)
+		return err
+	case "windows":
+		return credWrite(target(service, account), account, secret)
+	}
+	return unsupported()
+}
+
+// Delete 删除密钥
+func Delete(service, account string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := run("", "security", "delete-generic-password", "-s", service, "-a", account)
+		return notFound(err, "could not be found")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err := run("", "secret-tool", "clear", "service", service, "account", account)
+		return err
+	case "windows":
+		return credDelete(target(service, account))
+	}
+	return unsupported()
+}
+
+// target Windows 凭据的名称，与引用的写法相同（cmdkey /generic:meal-agent/amap）
+func target(service, account string) string {
+	return service + "/" + account
+}
+
+// run 运行钥匙串命令，stdin 不为空时作为输入（避免密码出现在参数中）
+func run(stdin string, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		if name == "secret-tool" {
+			return "", fmt.Errorf("没有找到 secret-tool（Debian/Ubuntu 可安装 libsecret-tools）")
+		}
+		return "", fmt.Errorf("没有找到 %s", name)
+	}
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// notFound 命令的"没有这一项"错误换成 ErrNotFound（secret-tool 找不到时只返回 1，没有输出）
+func notFound(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	var exit *exec.ExitError
+	if message == "" && errors.As(err, &exit) || message != "" && strings.Contains(err.Error(), message) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func unsupported() error {
+	return fmt.Errorf("不支持在 %s 上使用系统钥匙串", runtime.GOOS)
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+type requestIDKey struct{}
+
+// NewRequestID 随机的请求 ID（16 位十六进制）
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestID 请求上下文中的请求 ID，没有时为空
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Handler 记录每个请求的方法、路径、状态码和耗时，请求 ID 放在上下文和 X-Request-ID 响应头中
+// （请求带有 X-Request-ID 时沿用，方便和反向代理的日志对应）
+func Handler(module string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			id = NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+
+		log := Module(module)
+		level := log.Info
+		if rec.status >= 500 {
+			level = log.Error
+		} else if rec.status >= 400 {
+			level = log.Warn
+		}
+		level("请求", "request_id", id, "method", r.Method, "path", r.URL.Path, "status", rec.status,
+			"latency_ms", time.Since(start).Milliseconds(), "remote", r.RemoteAddr)
+	})
+}
+
+// statusRecorder 记录响应的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 程序运行中的日志（加载失败、推送失败、网页请求等）使用 log/slog，各模块的日志带上 module 字段
+// 推荐、对话回复等给用户看的内容仍然直接输出到终端
+
+// Options 日志设置
+type Options struct {
+	Level      string // debug / info（默认）/ warn / error
+	Format     string // text（默认）/ json
+	File       string // 日志文件（留空输出到标准错误）
+	MaxSize    int    // 单个日志文件的大小上限（MB），超过时切分
+	MaxBackups int    // 保留的旧日志文件数量
+}
+
+var (
+	mu      sync.Mutex
+	current io.Closer // 正在写入的日志文件（重新设置时关闭）
+)
+
+// Setup 按设置创建日志并设为 slog 的默认日志；再次调用时替换原来的设置并关闭原来的日志文件
+func Setup(opts Options) error {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stderr
+	var file io.Closer
+	if opts.File != "" {
+		f, err := openRotating(opts.File, int64(opts.MaxSize)<<20, opts.MaxBackups)
+		if err != nil {
+			return fmt.Errorf("打开日志文件失败: %v", err)
+		}
+		out, file = f, f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch opts.Format {
+	case "", "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		if file != nil {
+			file.Close()
+		}
+		return fmt.Errorf("日志格式应为 text / json: %s", opts.Format)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil {
+		current.Close()
+	}
+	current = file
+	return nil
+}
+
+// ParseLevel 解析日志级别，空为 info
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("日志级别应为 debug / info / warn / error: %s", s)
+}
+
+// Module 某个模块的日志（每次使用时获取，Setup 之后的日志按新的设置输出）
+func Module(name string) *slog.Logger {
+	return slog.Default().With("module", name)
+}
+
+// Close 关闭日志文件（退出前调用）
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil {
+		current.Close()
+		current = nil
+	}
+}
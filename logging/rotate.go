@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultMaxSize    = 10 << 20 // 默认单个日志文件 10MB
+	defaultMaxBackups = 5
+)
+
+// rotatingFile 按大小切分的日志文件：超过 maxSize 时 app.log 改名为 app.log.1，原来的 .1 改为 .2，依此类推，最多保留 maxBackups 个
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotating(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			// 切分失败时继续写入原文件，不丢日志
+			fmt.Fprintf(os.Stderr, "切分日志文件失败: %v\n", err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate 把当前文件改名为 .1，较早的依次后移，超出数量的删除
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	renameErr := os.Rename(r.path, r.path+".1")
+	if err := r.open(); err != nil {
+		r.file = nil
+		return err
+	}
+	return renameErr
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
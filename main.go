@@ -2,64 +2,106 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
 	"meal-agent/agent"
+	"meal-agent/chatbot"
+	"meal-agent/cloudsync"
 	"meal-agent/config"
+	"meal-agent/encryption"
+	"meal-agent/i18n"
+	"meal-agent/logging"
 	"meal-agent/memory"
+	"meal-agent/network"
+	"meal-agent/notify"
+	"meal-agent/plugin"
 	"meal-agent/preference"
+	"meal-agent/service"
+	"meal-agent/session"
+	"meal-agent/tools"
+	"meal-agent/tracing"
+	"meal-agent/web"
 )
 
 func main() {
-	// 命令行参数
-	configPath := flag.String("config", "config.yaml", "配置文件路径")
-	prefPath := flag.String("pref", "restaurants.yaml", "餐厅偏好配置路径")
-	dataDir := flag.String("data", "./data", "数据目录路径")
-	mode := flag.String("mode", "chat", "运行模式: chat(交互) / daemon(后台定时)")
+	// 全局选项，子命令中也可以写
+	opts := &options{configPath: "config.yaml", prefPath: "restaurants.yaml", dataDir: "./data", output: "text"}
+	opts.register(flag.CommandLine)
+	flag.Var(&opts.output, "output", outputUsage)
+	flag.Var(jsonFlag{&opts.output}, "json", "同 --output json")
+	mode := flag.String("mode", "", "已废弃，等同于子命令: chat / daemon / server / mcp / stats")
+	// 帮助等在加载配置之前输出的文字也按配置中的语言显示（写在子命令后面的 -config 要到加载配置时才生效）
+	flag.Usage = func() {
+		i18n.SetLanguage(config.PeekLanguage(opts.configPath, opts.sets...))
+		printUsage()
+	}
 	flag.Parse()
+	i18n.SetLanguage(config.PeekLanguage(opts.configPath, opts.sets...))
 
-	// 加载配置
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		fmt.Printf("加载配置失败: %v\n", err)
-		fmt.Println("请复制 config.example.yaml 为 config.yaml 并填写配置")
-		os.Exit(1)
+	args := flag.Args()
+	if *mode != "" && len(args) == 0 {
+		name, ok := modeCommands[*mode]
+		if !ok {
+			fmt.Fprintln(os.Stderr, i18n.T("未知模式: %s", *mode))
+			os.Exit(exitUsage)
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("提示: -mode 已废弃，请改用 meal-agent %s", name))
+		args = []string{name}
+		// 原来的 -mode stats 输出 JSON
+		if name == "stats" {
+			args = append(args, "--json")
+		}
 	}
-
-	// 初始化历史记录
-	history, err := memory.NewHistory(*dataDir)
-	if err != nil {
-		fmt.Printf("初始化历史记录失败: %v\n", err)
-		os.Exit(1)
+	if len(args) == 0 {
+		args = []string{"chat"}
 	}
 
-	// 加载餐厅偏好配置（可选）
-	pref, err := preference.Load(*prefPath)
-	if err != nil {
-		fmt.Printf("加载偏好配置失败: %v（将使用默认权重）\n", err)
-		pref = nil
+	c := findCommand(args[0])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "%s\n\n", i18n.T("未知的子命令: %s", args[0]))
+		printUsage()
+		os.Exit(exitUsage)
 	}
-
-	// 创建 Agent
-	mealAgent := agent.NewMealAgent(cfg, history, pref)
-
-	switch *mode {
-	case "chat":
-		runChatMode(mealAgent)
-	case "daemon":
-		runDaemonMode(mealAgent, cfg)
-	default:
-		fmt.Printf("未知模式: %s\n", *mode)
-		os.Exit(1)
+	// 不加载配置的子命令（pref、history、learned 等）也要能读写加密的文件；加载配置时会再设置一次
+	if key, err := config.PeekEncryptionKey(opts.configPath, opts.sets...); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("⚠️ 读取加密密钥失败: %v", err))
+	} else {
+		encryption.SetKey(key)
+	}
+	err := c.run(opts, args[1:])
+	tracing.Shutdown(traceFlushTimeout) // 发送还没导出的链路追踪
+	plugin.Shutdown()
+	var usage usageError
+	switch {
+	case errors.Is(err, errNoResult):
+		os.Exit(exitNoResult)
+	case errors.As(err, &usage):
+		if usage.err != nil {
+			fmt.Fprintln(os.Stderr, usage.err)
+		}
+		os.Exit(exitUsage)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
 	}
 }
 
+// traceFlushTimeout 退出前等待导出链路追踪的最长时间
+const traceFlushTimeout = 5 * time.Second
+
 // runChatMode 交互模式
 func runChatMode(mealAgent *agent.MealAgent) {
 	printWelcome()
@@ -67,7 +109,7 @@ func runChatMode(mealAgent *agent.MealAgent) {
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
-		fmt.Print("\n你: ")
+		fmt.Printf("\n%s: ", i18n.T("你"))
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			break
@@ -81,7 +123,7 @@ func runChatMode(mealAgent *agent.MealAgent) {
 		// 处理特殊命令
 		switch strings.ToLower(input) {
 		case "quit", "exit", "q", "退出":
-			fmt.Println("\n再见，祝用餐愉快！🍽️")
+			fmt.Println("\n" + i18n.T("再见，祝用餐愉快！🍽️"))
 			return
 		case "help", "帮助", "h":
 			printHelp()
@@ -91,13 +133,41 @@ func runChatMode(mealAgent *agent.MealAgent) {
 			continue
 		case "reset", "重置":
 			mealAgent.Reset()
-			fmt.Println("\n助手: 已重置对话，有什么可以帮你的？")
+			reply(i18n.T("已重置对话，有什么可以帮你的？"))
 			continue
 		case "history", "历史":
 			handleHistory(mealAgent)
 			continue
 		}
 
+		// 检查是否是历史搜索命令：历史 泰国菜
+		if fields := strings.Fields(input); len(fields) > 1 && (fields[0] == "历史" || strings.ToLower(fields[0]) == "history") {
+			handleHistorySearch(mealAgent, strings.Join(fields[1:], " "))
+			continue
+		}
+
+		// 检查是否是查看/清空学习记录的命令：学习 [重置 [餐厅]]
+		if fields := strings.Fields(input); len(fields) > 0 && (fields[0] == "学习" || strings.ToLower(fields[0]) == "learned") {
+			if mealAgent.Learner() == nil {
+				reply(i18n.T("没有开启权重学习（在配置文件中设置 learning.enabled）"))
+			} else {
+				reply(learnedCommand(mealAgent.Learner(), fields[1:]))
+			}
+			continue
+		}
+
+		// 检查是否是食材库存命令：食材 [添加|用掉|删除] ...
+		if fields := strings.Fields(input); len(fields) > 0 && (fields[0] == "食材" || strings.ToLower(fields[0]) == "pantry") {
+			reply(pantryCommand(mealAgent.Pantry(), fields[1:]))
+			continue
+		}
+
+		// 检查是否是统计命令：统计 [week|month|all|2024-06] [json]
+		if fields := strings.Fields(input); len(fields) > 0 && (fields[0] == "统计" || strings.ToLower(fields[0]) == "stats") {
+			handleStats(mealAgent, fields[1:])
+			continue
+		}
+
 		// 检查是否是记录命令
 		if strings.HasPrefix(input, "记录 ") || strings.HasPrefix(input, "record ") {
 			handleRecord(mealAgent, input)
@@ -107,49 +177,390 @@ func runChatMode(mealAgent *agent.MealAgent) {
 		// 普通对话
 		response, err := mealAgent.Chat(input)
 		if err != nil {
-			fmt.Printf("\n助手: 抱歉，出错了: %v\n", err)
+			reply(i18n.T("抱歉，出错了: %v", err))
 			continue
 		}
 
-		fmt.Printf("\n助手: %s\n", response)
+		reply(response)
 	}
 }
 
+// renderReply 终端中输出回复前的处理，使用颜色时为 tui.Markdown
+var renderReply = func(text string) string { return text }
+
+// reply 输出助手的回复
+func reply(text string) {
+	fmt.Printf("\n%s: %s\n", i18n.T("助手"), renderReply(text))
+}
+
 // runDaemonMode 后台定时模式
-func runDaemonMode(mealAgent *agent.MealAgent, cfg *config.Config) {
-	fmt.Println("🍽️  饮食推荐 Agent 已启动（后台模式）")
-	fmt.Printf("午餐提醒时间: %s\n", cfg.Schedule.Lunch)
-	fmt.Printf("晚餐提醒时间: %s\n", cfg.Schedule.Dinner)
-	fmt.Println("按 Ctrl+C 退出")
+// statePath 保存提醒记录，重启后补发错过的提醒
+// watched 中的文件修改后或收到 SIGHUP 时调用 reload 重新加载，当天的临时排除等状态保留
+func runDaemonMode(mealAgent *agent.MealAgent, cfg *config.Config, statePath string, watched []string, reload func(*agent.Scheduler) error) {
+	fmt.Println(i18n.T("🍽️  饮食推荐 Agent 已启动（后台模式）"))
+	fmt.Println(i18n.T("午餐提醒时间: %s", cfg.Schedule.Lunch))
+	fmt.Println(i18n.T("晚餐提醒时间: %s", cfg.Schedule.Dinner))
+	if lunch, dinner, note := cfg.Schedule.TimesOn(time.Now()); lunch != cfg.Schedule.Lunch || dinner != cfg.Schedule.Dinner {
+		if note != "" {
+			note = "（" + note + "）"
+		}
+		fmt.Println(i18n.T("今天%s: 午餐 %s，晚餐 %s", note, scheduleTime(lunch), scheduleTime(dinner)))
+	}
+	if name := cfg.Schedule.LocationOn(time.Now()); name != "" {
+		fmt.Println(i18n.T("今天推荐时的位置: %s（对话中切换后当天不再自动切换）", name))
+	}
+	if p := cfg.Schedule.Preview; p.Lunch > 0 || p.Dinner > 0 {
+		fmt.Println(i18n.T("用餐预告: 午餐提前 %d 分钟，晚餐提前 %d 分钟（0 表示不预告）", p.Lunch, p.Dinner))
+	}
+	if cfg.Schedule.Report != "" {
+		fmt.Println(i18n.T("每周报告: %s", cfg.Schedule.Report))
+	}
+	fmt.Println(i18n.T("修改配置文件后自动重新加载，按 Ctrl+C 退出"))
 
-	scheduler := agent.NewScheduler(mealAgent, cfg.Schedule.Lunch, cfg.Schedule.Dinner)
+	// 退出时取消进行中的 LLM、天气、餐厅接口请求（推送使用单独的连接，不受影响）
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	http.DefaultTransport = tools.CancelOnDone(ctx, http.DefaultTransport)
+
+	scheduler := agent.NewScheduler(mealAgent, cfg.Schedule, newNotifier(cfg.Notify))
+	if err := scheduler.SetStatePath(statePath); err != nil {
+		logging.Module("scheduler").Warn("加载提醒记录失败", "path", statePath, "err", err)
+	}
+	var replyServer *http.Server
+	if cfg.Notify.Reply.Listen != "" {
+		if base := cfg.Notify.Reply.PublicURL(); base != "" {
+			scheduler.SetReplyURL(base)
+		}
+		replyServer = startReplyServer(cfg.Notify.Reply, scheduler)
+	}
 	scheduler.Start()
 
-	// 监听通知
+	// 推送失败时记录到日志
+	go func() {
+		for err := range scheduler.Errors() {
+			logging.Module("notify").Error("推送提醒失败", "err", err)
+		}
+	}()
+
+	// 终端中可以输入"过20分钟再提醒我"、"吃过了"，其他的输入接着推荐的对话回复（"第二个"、"换一批"）
 	go func() {
-		for notification := range scheduler.Notifications() {
-			fmt.Println(notification)
-			fmt.Println("\n---")
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			input := strings.TrimSpace(scanner.Text())
+			if input == "" {
+				continue
+			}
+			d, dismiss, ok := agent.ParseSnooze(input)
+			switch {
+			case !ok:
+				reply, err := scheduler.Chat(input)
+				if err != nil {
+					fmt.Println(i18n.T("错误: %v", err))
+				} else if !cfg.Notify.ConsoleEnabled() {
+					fmt.Println(renderReply(reply)) // 回复的内容推送到终端时不重复输出
+				}
+			case dismiss:
+				fmt.Println(scheduler.Dismiss())
+			default:
+				fmt.Println(scheduler.Snooze(d))
+			}
 		}
 	}()
 
+	changed := watchFiles(watched, fileWatchInterval)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+
 	// 等待退出信号
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+	for {
+		select {
+		case <-sigCh:
+			shutdown(cancel, scheduler, replyServer, sigCh)
+			return
+		case <-hupCh:
+		case <-changed:
+		}
+		if err := reload(scheduler); err != nil {
+			logging.Module("config").Warn(i18n.T("重新加载失败，继续使用原配置"), "err", err)
+		} else {
+			logging.Module("config").Info(i18n.T("已重新加载配置"))
+		}
+	}
+}
+
+// runServerMode 局域网网页模式：手机浏览器打开即可聊天、查看推荐卡片、一键确认和查看用餐记录
+func runServerMode(cfg *config.Config, dataDir string, newAgent func(user string) *agent.MealAgent) {
+	sessions := session.NewStore(newAgent)
+	server := &http.Server{
+		Addr:              cfg.Server.Listen,
+		Handler:           logging.Handler("web", web.NewServer(dataDir, cfg.Schedule, sessions).Handler()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Println(i18n.T("🍽️  饮食推荐 Agent 网页已启动: %s", cfg.Server.Listen))
+	for _, addr := range lanAddresses(cfg.Server.Listen) {
+		fmt.Println(i18n.T("   手机访问: http://%s", addr))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startChatBots(ctx, cfg.Server, sessions)
+	fmt.Println(i18n.T("按 Ctrl+C 退出"))
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		cancel() // 断开聊天机器人
+		ctx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelShutdown()
+		server.Shutdown(ctx) // 等正在进行的对话返回
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logging.Module("web").Error(i18n.T("网页服务启动失败"), "listen", cfg.Server.Listen, "err", err)
+		logging.Close()
+		os.Exit(exitError)
+	}
+	fmt.Println("\n" + i18n.T("已退出"))
+}
+
+// startChatBots 按配置连接 Slack、Discord，频道中的 /meal 命令与网页共用会话表（每个聊天用户一个会话）
+func startChatBots(ctx context.Context, cfg config.ServerConfig, sessions *session.Store) {
+	if cfg.Slack.AppToken != "" {
+		onError := func(err error) {
+			logging.Module("slack").Warn("聊天机器人出错", "err", err)
+		}
+		if slack, err := chatbot.NewSlack(chatbot.NewBot(sessions, cfg.Slack.Users), cfg.Slack.AppToken); err != nil {
+			onError(err)
+		} else {
+			go slack.Run(ctx, onError)
+			fmt.Println(i18n.T("   Slack: 已开启 /meal 命令"))
+		}
+	}
+	if cfg.Discord.Token != "" {
+		onError := func(err error) {
+			logging.Module("discord").Warn("聊天机器人出错", "err", err)
+		}
+		if discord, err := chatbot.NewDiscord(chatbot.NewBot(sessions, cfg.Discord.Users), cfg.Discord.Token, cfg.Discord.GuildID); err != nil {
+			onError(err)
+		} else {
+			go discord.Run(ctx, onError)
+			fmt.Println(i18n.T("   Discord: 已开启 /meal 命令"))
+		}
+	}
+}
+
+// lanAddresses 本机局域网 IPv4 地址加上监听端口，监听指定地址时只返回它
+func lanAddresses(listen string) []string {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return nil
+	}
+	if host != "" && host != "0.0.0.0" && host != "::" {
+		return []string{net.JoinHostPort(host, port)}
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var list []string
+	for _, a := range addrs {
+		if ip, ok := a.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			list = append(list, net.JoinHostPort(ip.IP.String(), port))
+		}
+	}
+	return list
+}
+
+// shutdownTimeout 退出时等待正在进行的推荐、推送和记录完成的最长时间（systemd 默认 90 秒后强制结束）
+const shutdownTimeout = 15 * time.Second
+
+// shutdown 退出后台模式：取消进行中的外部接口请求，停止接收回复，等调度协程把手上的推送、记录做完
+// 等待期间再次收到退出信号时立即退出
+func shutdown(cancel context.CancelFunc, scheduler *agent.Scheduler, replyServer *http.Server, sigCh <-chan os.Signal) {
+	fmt.Println("\n" + i18n.T("正在退出..."))
+	go func() {
+		<-sigCh
+		fmt.Println(i18n.T("强制退出"))
+		os.Exit(exitError)
+	}()
 
-	scheduler.Stop()
-	fmt.Println("\n已退出")
+	cancel()
+	ctx, stop := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer stop()
+	if replyServer != nil {
+		replyServer.Shutdown(ctx) // 等处理中的回复返回
+	}
+	deadline, _ := ctx.Deadline()
+	if err := scheduler.Shutdown(time.Until(deadline)); err != nil {
+		logging.Module("scheduler").Warn("退出时未完成", "err", err)
+	}
+	fmt.Println(i18n.T("已退出"))
+}
+
+// startReplyServer 启动回复服务，接收通知中的快捷回复；配置了企业微信回调时同时接收群里 @机器人 的消息
+func startReplyServer(cfg config.ReplyConfig, scheduler *agent.Scheduler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/reply", notify.ReplyHandler(scheduler.Reply))
+	if cfg.Wecom.Enabled() {
+		chat := func(msg notify.WecomMessage) (notify.Notification, error) {
+			return scheduler.GroupChat(msg.User, msg.Text)
+		}
+		onError := func(err error) {
+			logging.Module("wecom").Warn("回复企业微信消息失败", "err", err)
+		}
+		if wecom, err := notify.WecomHandler(cfg.Wecom.Token, cfg.Wecom.AESKey, chat, onError); err != nil {
+			logging.Module("wecom").Warn("不接收企业微信消息", "err", err)
+		} else {
+			mux.Handle("/wecom", wecom)
+		}
+	}
+	if token := cfg.HomeAssistant.Token; token != "" {
+		if ha, err := notify.HomeAssistantHandler(token, scheduler.TodayRecommendation, scheduler.LastMeal, scheduler.Recommend); err != nil {
+			logging.Module("homeassistant").Warn("不接收 Home Assistant 请求", "err", err)
+		} else {
+			mux.Handle("/ha/", ha)
+		}
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           logging.Handler("reply", mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logging.Module("reply").Error("回复服务启动失败，通知中的回复不可用", "listen", cfg.Listen, "err", err)
+		}
+	}()
+	return server
+}
+
+// newNotifier 按配置创建提醒的推送方式，创建失败的跳过
+func newNotifier(cfg config.NotifyConfig) notify.Notifier {
+	var notifier notify.Multi
+	if cfg.ConsoleEnabled() {
+		notifier = append(notifier, consoleNotifier())
+	}
+	if cfg.Desktop {
+		desktop, err := notify.NewDesktopNotifier()
+		if err != nil {
+			logging.Module("notify").Warn("不发送桌面通知", "err", err)
+		} else {
+			notifier = append(notifier, desktop)
+		}
+	}
+	for _, w := range cfg.Webhooks {
+		webhook, err := notify.NewWebhookNotifier(w.URL, w.Template, w.Secret, w.Headers)
+		if err != nil {
+			logging.Module("notify").Warn("跳过提醒方式", "err", err)
+			continue
+		}
+		notifier = append(notifier, webhook)
+	}
+	for _, b := range cfg.Bots {
+		bot, err := notify.NewBotNotifier(b.Type, b.URL, b.Secret, b.Token, b.ChatID)
+		if err != nil {
+			logging.Module("notify").Warn("跳过提醒方式", "err", err)
+			continue
+		}
+		notifier = append(notifier, bot)
+	}
+	for _, p := range cfg.Push {
+		push, err := notify.NewPushNotifier(p.Type, p.Server, p.Key, p.Topic, p.Token)
+		if err != nil {
+			logging.Module("notify").Warn("跳过提醒方式", "err", err)
+			continue
+		}
+		notifier = append(notifier, push)
+	}
+	if e := cfg.Email; e.Enabled() {
+		email, err := notify.NewEmailNotifier(notify.EmailOptions{
+			Host:     e.Host,
+			Port:     e.Port,
+			Username: e.Username,
+			Password: e.Password,
+			From:     e.From,
+			To:       e.To,
+			ReportTo: e.ReportTo,
+		})
+		if err != nil {
+			logging.Module("notify").Warn("跳过提醒方式", "err", err)
+		} else {
+			notifier = append(notifier, email)
+		}
+	}
+	if plugin.HasNotifier() {
+		notifier = append(notifier, plugin.Notifier{})
+	}
+	if len(notifier) == 0 {
+		logging.Module("notify").Warn(i18n.T("没有可用的提醒方式，只输出到终端"))
+		notifier = append(notifier, consoleNotifier())
+	}
+	return notifier
+}
+
+// consoleNotifier 输出到终端的提醒，推荐内容和对话回复一样渲染 Markdown
+func consoleNotifier() notify.Notifier {
+	c := notify.NewConsoleNotifier(os.Stdout)
+	c.Render = renderReply
+	return c
+}
+
+// scheduleTime 提醒时间，为空时显示不提醒
+func scheduleTime(t string) string {
+	if t == "" {
+		return i18n.T("不提醒")
+	}
+	return t
+}
+
+// fileWatchInterval 检查配置文件是否修改的间隔
+const fileWatchInterval = 2 * time.Second
+
+// watchFiles 定期检查文件的修改时间和大小，有变化时通知
+// 连续两次检查结果相同才通知，避免编辑器分几次写入时读到写了一半的文件
+func watchFiles(paths []string, interval time.Duration) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+	stat := func() string {
+		var sb strings.Builder
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil {
+				fmt.Fprintf(&sb, "%s %d %d\n", path, info.ModTime().UnixNano(), info.Size())
+			}
+		}
+		return sb.String()
+	}
+
+	go func() {
+		last, pending := stat(), false
+		for range time.Tick(interval) {
+			current := stat()
+			if current != last {
+				last, pending = current, true
+				continue
+			}
+			if pending {
+				pending = false
+				select {
+				case changed <- struct{}{}:
+				default: // 上一次通知还没处理，合并成一次
+				}
+			}
+		}
+	}()
+	return changed
 }
 
 // printWelcome 打印欢迎信息
 func printWelcome() {
 	fmt.Println("═══════════════════════════════════════════")
-	fmt.Println("       🍽️  饮食推荐助手 Meal Agent")
+	fmt.Println("       " + i18n.T("🍽️  饮食推荐助手 Meal Agent"))
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println()
-	fmt.Println("我可以根据天气和你的位置推荐附近餐厅。")
-	fmt.Println("输入 'help' 查看所有命令，输入 'quit' 退出。")
+	fmt.Println(i18n.T("我可以根据天气和你的位置推荐附近餐厅。"))
+	fmt.Println(i18n.T("输入 'help' 查看所有命令，输入 'quit' 退出。"))
 	fmt.Println()
 
 	// 显示当前时间和餐次
@@ -160,16 +571,23 @@ func printWelcome() {
 	} else if hour < 10 {
 		mealType = "早餐/早午餐"
 	}
-	fmt.Printf("现在是 %s 时间，需要我推荐%s吗？\n", time.Now().Format("15:04"), mealType)
+	fmt.Println(i18n.T("现在是 %s 时间，需要我推荐%s吗？", time.Now().Format("15:04"), i18n.T(mealType)))
 }
 
 // printHelp 打印帮助信息
 func printHelp() {
-	fmt.Println(`
+	fmt.Println(i18n.T(chatHelp))
+}
+
+const chatHelp = `
 命令列表:
   推荐 / r          获取用餐推荐
-  历史 / history    查看最近用餐记录
-  记录 <餐厅名>     记录本次用餐
+  历史 / history    查看最近用餐记录（加关键词搜索全部记录，如: 历史 泰国菜）
+  记录 <餐厅名> [类型] [金额]  记录本次用餐，如: 记录 海底捞 火锅 138
+                    可加 评分:5 备注:辣度刚好 照片:/path/a.jpg，下次推荐这家时会提醒备注
+  统计 / stats      本月用餐统计（可加 week / all / 2024-06，末尾加 json 输出 JSON）
+  学习 / learned    查看根据评分和选择学到的权重调整（学习 重置 [餐厅] 清空）
+  食材 / pantry     查看家里的食材库存（食材 添加 鸡蛋 10个 过期:7天 常备:4，食材 用掉 鸡蛋 3）
   重置 / reset      重置对话上下文
   帮助 / help       显示此帮助
   退出 / quit       退出程序
@@ -178,12 +596,28 @@ func printHelp() {
   "不想吃火锅"      排除火锅类餐厅
   "来点清淡的"      获取清淡食物推荐
   "就吃第一个"      确认选择
-	`)
-}
+  "点外卖"          切换到外卖推荐（"堂食"切回）
+  "今天在家做饭"    切换到做饭模式，推荐菜谱和购物清单（"出去吃"切回）
+  "记错了撤销"      撤销最近一条用餐记录
+  "删除今天中午的记录"         删除指定的用餐记录
+  "昨天晚上的记录改成海底捞"   修改记错的餐厅
+  "上次吃那家泰国菜是什么时候" 查询以前的用餐记录
+  "这顿打5分"                  给最近一次用餐评分（开启 learning 后会调整推荐权重）
+  "以后多推荐日料"             调整菜系偏好并保存（"以后少吃火锅"降低）
+  "把海底捞拉黑"               以后不再推荐
+  "这家店权重调到150"          调整餐厅权重并保存（100 为默认）
+  "下周减脂，沙拉权重x2，炸鸡为0，截止到7月1日"
+                               临时偏好，到期自动删除（"临时偏好"查看，"取消临时偏好"清空）
+  "我最近肠胃不好想吃清淡的，持续一周"
+                               临时口味，到期自动恢复（"以后少吃辣"设置长期口味，"口味"查看）
+  "每周至少吃一次山西面馆"     加入常吃清单，到期时保证出现在推荐中（"常吃清单"查看，"取消常吃山西面馆"移除）
+  "和 partner 一起吃"          合并几个人的偏好推荐（需配置 profiles，"一个人吃"恢复）
+  "我现在在家"                 切换到 locations 中配置的位置推荐（"当前位置"查看）
+	`
 
 // handleRecommend 处理推荐请求
 func handleRecommend(mealAgent *agent.MealAgent) {
-	fmt.Println("\n助手: 正在为你搜索附近餐厅...")
+	reply(i18n.T("正在为你搜索附近餐厅..."))
 
 	hour := time.Now().Hour()
 	mealType := "lunch"
@@ -193,43 +627,649 @@ func handleRecommend(mealAgent *agent.MealAgent) {
 
 	response, err := mealAgent.GetRecommendation(mealType)
 	if err != nil {
-		fmt.Printf("\n助手: 抱歉，获取推荐失败: %v\n", err)
+		reply(i18n.T("抱歉，获取推荐失败: %v", err))
 		return
 	}
 
-	fmt.Printf("\n助手: %s\n", response)
+	reply(response)
 }
 
 // handleHistory 处理历史记录查询
 func handleHistory(mealAgent *agent.MealAgent) {
-	summary := mealAgent.GetHistorySummary()
-	fmt.Printf("\n助手: %s\n", summary)
+	reply(mealAgent.GetHistorySummary())
 }
 
-// handleRecord 处理记录用餐
-func handleRecord(mealAgent *agent.MealAgent, input string) {
-	// 解析: "记录 餐厅名 [类型]"
-	parts := strings.Fields(input)
-	if len(parts) < 2 {
-		fmt.Println("\n助手: 请输入餐厅名称，例如: 记录 海底捞 火锅")
+// handleHistorySearch 按关键词搜索用餐记录
+func handleHistorySearch(mealAgent *agent.MealAgent, keyword string) {
+	result, err := mealAgent.SearchHistory(keyword)
+	if err != nil {
+		reply(i18n.T("搜索失败: %v", err))
 		return
 	}
+	reply(result)
+}
 
-	restaurant := parts[1]
-	category := ""
-	if len(parts) >= 3 {
-		category = parts[2]
+// handleStats 处理统计命令
+func handleStats(mealAgent *agent.MealAgent, args []string) {
+	asJSON := len(args) > 0 && strings.ToLower(args[len(args)-1]) == "json"
+	if asJSON {
+		args = args[:len(args)-1]
+	}
+	period := ""
+	if len(args) > 0 {
+		period = args[0]
 	}
 
-	err := mealAgent.RecordMeal(restaurant, category)
+	if asJSON {
+		fmt.Println()
+		if err := printStatsJSON(mealAgent, period); err != nil {
+			fmt.Printf("%s: %s\n", i18n.T("助手"), i18n.T("统计失败: %v", err))
+		}
+		return
+	}
+
+	stats, err := mealAgent.GetStats(period)
 	if err != nil {
-		fmt.Printf("\n助手: 记录失败: %v\n", err)
+		reply(err.Error())
 		return
 	}
+	reply(stats.Describe())
+}
+
+// printStatsJSON 以 JSON 输出统计结果（方便导入其他工具分析）
+func printStatsJSON(mealAgent *agent.MealAgent, period string) error {
+	stats, err := mealAgent.GetStats(period)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// learnedPath 学到的权重调整保存位置（指定用户时每人一份）
+func learnedPath(dataDir, user string) string {
+	if user == "" {
+		return filepath.Join(dataDir, "learned.json")
+	}
+	return filepath.Join(dataDir, "learned_"+user+".json")
+}
+
+// schedulerStatePath 后台模式的提醒记录文件（按用户分开）
+func schedulerStatePath(dataDir, user string) string {
+	if user == "" {
+		return filepath.Join(dataDir, "scheduler.json")
+	}
+	return filepath.Join(dataDir, "scheduler_"+user+".json")
+}
+
+// learnedCommand 查看或清空学到的权重调整
+//
+//	learned                 列出所有调整
+//	learned reset [餐厅]    清空某家餐厅（不指定时全部清空）
+func learnedCommand(learner *preference.Learner, args []string) string {
+	if len(args) > 0 && (args[0] == "reset" || args[0] == "重置") {
+		name := strings.Join(args[1:], " ")
+		n, err := learner.Reset(name)
+		switch {
+		case err != nil:
+			return i18n.T("清空失败: %v", err)
+		case n == 0 && name != "":
+			return i18n.T("没有「%s」的学习记录", name)
+		}
+		return i18n.T("已清空 %d 条学习记录", n)
+	}
+
+	list := learner.List()
+	if len(list) == 0 {
+		return i18n.T("还没有学到的权重调整（评分或从推荐中选择餐厅后会自动调整）")
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T("根据评分和选择学到的权重调整："))
+	for _, a := range list {
+		sb.WriteString(i18n.T("\n  %s %+d（评分 %d 次，选中 %d 次）", a.Name, a.Weight(), a.Ratings, a.Chosen))
+	}
+	return sb.String()
+}
+
+// pantryCommand 查看和修改家里的食材库存
+//
+//	pantry                                        列出所有食材
+//	pantry add 鸡蛋 10个 [过期:7天] [常备:4]      买了食材（已有时数量相加）
+//	pantry use 鸡蛋 [3]                           用掉一些（不写数量时全部用完）
+//	pantry remove 鸡蛋                            从库存中删除
+func pantryCommand(pantry *memory.Pantry, args []string) string {
+	now := time.Now()
+	if len(args) > 0 {
+		rest := strings.Join(args[1:], " ")
+		switch strings.ToLower(args[0]) {
+		case "add", "添加", "买了":
+			item, err := agent.ParsePantryItem(rest, now)
+			if err != nil {
+				return err.Error()
+			}
+			if item, err = pantry.Add(item); err != nil {
+				return i18n.T("保存失败: %v", err)
+			}
+			return i18n.T("已添加，现在有 %s", item.Describe(now))
+		case "use", "用掉", "用了":
+			name, quantity, err := agent.ParsePantryUse(rest)
+			if err != nil {
+				return err.Error()
+			}
+			item, err := pantry.Use(name, quantity)
+			if err != nil {
+				return i18n.T("保存失败: %v", err)
+			}
+			if item.Quantity == 0 {
+				if item.Min > 0 {
+					return i18n.T("%s用完了，记得补货", item.Name)
+				}
+				return i18n.T("%s用完了，已从库存中删除", item.Name)
+			}
+			return i18n.T("已更新，还剩 %s", item.Describe(now))
+		case "remove", "rm", "删除":
+			item, err := pantry.Remove(rest)
+			if err != nil {
+				return i18n.T("删除失败: %v", err)
+			}
+			return i18n.T("已删除%s", item.Name)
+		case "list", "列表":
+		default:
+			return i18n.T("用法: 食材 [添加|用掉|删除] ...，如: 食材 添加 鸡蛋 10个 过期:7天 常备:4")
+		}
+	}
+
+	items, err := pantry.Items()
+	if err != nil {
+		return i18n.T("读取食材库存失败: %v", err)
+	}
+	if len(items) == 0 {
+		return i18n.T("家里还没有记录食材（如: 食材 添加 鸡蛋 10个 过期:7天）")
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T("家里的食材："))
+	for _, item := range items {
+		sb.WriteString("\n  " + item.Describe(now))
+		if item.Low() {
+			sb.WriteString(i18n.T("  ⚠️ 不足%g%s", item.Min, item.Unit))
+		}
+	}
+	return sb.String()
+}
+
+const preferencesUsage = `子命令:
+  validate [偏好配置文件...]                              检查偏好配置（默认检查 -pref）
+  export [--name 名称] [--author 整理人] [--output 文件]  导出为偏好包，分享给同事
+  import <偏好包.yaml> [--replace]                       导入偏好包（默认合并）`
+
+// runPreferencesCommand 检查、导出、导入偏好配置（默认使用 -pref 指定的文件）
+func runPreferencesCommand(opts *options, args []string) error {
+	fs := opts.flags("pref")
+	fs.Parse(args)
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	prefPath := opts.prefPath
+
+	switch args[0] {
+	case "validate":
+		paths := parseArgs(opts.flags("pref validate"), args[1:])
+		if len(paths) == 0 {
+			paths = []string{prefPath}
+		}
+		if !validatePreferences(paths) {
+			return errors.New(i18n.T("偏好配置有错误"))
+		}
+		return nil
+
+	case "export":
+		fs := opts.flags("pref export")
+		name := fs.String("name", "", i18n.T("偏好包名称，如 公司周边好店"))
+		author := fs.String("author", "", i18n.T("整理人"))
+		output := fs.String("output", "", i18n.T("输出文件（留空输出到标准输出）"))
+		fs.Parse(args[1:])
+
+		pref, err := preference.Load(prefPath)
+		if err != nil {
+			return fmt.Errorf(i18n.T("加载偏好配置失败: %v"), err)
+		}
+		bundle := pref.Export(*name, *author, time.Now())
+		if *output == "" {
+			return bundle.Write(os.Stdout)
+		}
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf(i18n.T("创建输出文件失败: %v"), err)
+		}
+		defer f.Close()
+		if err := bundle.Write(f); err != nil {
+			return fmt.Errorf(i18n.T("导出失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已导出 %d 家餐厅、%d 个菜系、%d 条规则到 %s（不包含常吃清单、口味和临时偏好）",
+			len(bundle.Preferences.Restaurants), len(bundle.Preferences.Categories),
+			len(bundle.Preferences.Rules)+len(bundle.Preferences.WeightRules), *output))
+		return nil
+
+	case "import":
+		fs := opts.flags("pref import")
+		replace := fs.Bool("replace", false, i18n.T("用偏好包替换本地的餐厅、菜系和规则（默认合并，本地已有的保留）"))
+		files := parseArgs(fs, args[1:])
+		if len(files) == 0 {
+			return usageError{errors.New(i18n.T("用法: meal-agent pref import <偏好包.yaml> [--replace]"))}
+		}
+		file := files[0]
+
+		bundle, err := preference.LoadBundle(file)
+		if err != nil {
+			return fmt.Errorf(i18n.T("加载偏好包失败: %v"), err)
+		}
+		pref, err := preference.Load(prefPath)
+		if err != nil {
+			return fmt.Errorf(i18n.T("加载偏好配置失败: %v"), err)
+		}
+		if *replace {
+			// 替换前备份，导错了可以恢复
+			if data, err := os.ReadFile(prefPath); err == nil {
+				if err := os.WriteFile(prefPath+".bak", data, 0644); err != nil {
+					return fmt.Errorf(i18n.T("备份偏好配置失败: %v"), err)
+				}
+				fmt.Println(i18n.T("原配置已备份到 %s.bak", prefPath))
+			}
+		}
+		result, err := pref.Import(bundle, *replace)
+		if err != nil {
+			return fmt.Errorf(i18n.T("导入失败: %v"), err)
+		}
+		if err := pref.Save(prefPath); err != nil {
+			return fmt.Errorf(i18n.T("保存偏好配置失败: %v"), err)
+		}
+		verb := i18n.T("合并")
+		if *replace {
+			verb = i18n.T("替换为")
+		}
+		fmt.Print(i18n.T("已%s%s：%d 家餐厅、%d 个菜系、%d 条规则", verb, bundle.Describe(), result.Restaurants, result.Categories, result.Rules))
+		if result.Skipped > 0 {
+			fmt.Print(i18n.T("，%d 条本地已有的保留本地设置", result.Skipped))
+		}
+		fmt.Println()
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: pref %s\n%s"), args[0], i18n.T(preferencesUsage))}
+}
+
+const serviceUsage = `子命令:
+  install [--system] [--print]   安装并启动服务（--print 只输出生成的服务文件）
+  uninstall [--system]           停止并删除服务
+  status [--system]              查看服务状态`
+
+// runServiceCommand 处理 service 子命令，服务使用当前的 -config、-pref、-data、-user 参数运行后台模式
+func runServiceCommand(o *options, args []string) error {
+	fs := o.flags("service")
+	fs.Parse(args)
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	fs = o.flags("service " + args[0])
+	system := fs.Bool("system", false, i18n.T("Linux 上安装为系统服务（需要 root），默认为当前用户的服务"))
+	printOnly := fs.Bool("print", false, i18n.T("只输出生成的服务文件，不安装"))
+	fs.Parse(args[1:])
+	opts := service.Options{ConfigPath: o.configPath, PrefPath: o.prefPath, DataDir: o.dataDir, User: o.user, System: *system}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf(i18n.T("找不到可执行文件: %v"), err)
+	}
+	opts.Executable = exe
+	m, err := service.New(opts)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "install":
+		if *printOnly {
+			fmt.Printf("# %s\n%s", m.Path(), m.Render())
+			return nil
+		}
+		if err := m.Install(); err != nil {
+			return fmt.Errorf(i18n.T("安装服务失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已安装并启动服务 %s（%s），开机后自动运行后台模式", opts.Name(), m.Path()))
+		fmt.Println(m.Hint())
+		return nil
+	case "uninstall":
+		if err := m.Uninstall(); err != nil {
+			return fmt.Errorf(i18n.T("卸载服务失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已停止并删除服务 %s", opts.Name()))
+		return nil
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			return fmt.Errorf(i18n.T("查询服务状态失败: %v"), err)
+		}
+		fmt.Println(status)
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: service %s\n%s"), args[0], i18n.T(serviceUsage))}
+}
+
+// setupLogging 按 --log-level、--log-format 和配置中的 log 设置日志
+// 后台模式和网页服务（opts.logToFile）写入 log.file，其他命令输出到终端
+func setupLogging(opts *options, cfg *config.Config) {
+	o := logging.Options{Level: cfg.Log.Level, Format: cfg.Log.Format}
+	if opts.logLevel != "" {
+		o.Level = opts.logLevel
+	}
+	if opts.logFormat != "" {
+		o.Format = opts.logFormat
+	}
+	if opts.logToFile {
+		o.File, o.MaxSize, o.MaxBackups = cfg.Log.File, cfg.Log.MaxSize, cfg.Log.MaxBackups
+	}
+	if err := logging.Setup(o); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("⚠️ 日志设置失败: %v（输出到终端）", err))
+		if o.File != "" {
+			o.File = ""
+			logging.Setup(o)
+		}
+	}
+}
+
+// loadConfig 加载配置，补全依赖数据目录的默认值，解析只填写了地址的位置
+func loadConfig(opts *options) (*config.Config, error) {
+	cfg, err := config.Load(opts.configPath, opts.sets...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Search.CacheDir == "" {
+		cfg.Search.CacheDir = filepath.Join(opts.dataDir, "cache")
+	}
+	// 设置了密钥时用餐历史和偏好文件加密读写
+	encryption.SetKey(cfg.Encryption.Key)
+	// 之后的外部请求（包括下面的地址解析）按 network 的设置使用代理和证书
+	if err := setupNetwork(cfg.Network); err != nil {
+		return nil, err
+	}
+	// 只填写了地址的位置解析为坐标，结果缓存在搜索缓存目录中
+	if err := cfg.ResolveAddresses(tools.NewGeocoder(cfg.API.AmapKey, cfg.Search.CacheDir)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// setupNetwork 按配置设置外部请求的代理和证书
+func setupNetwork(cfg config.NetworkConfig) error {
+	clients := make(map[string]network.Settings, len(cfg.Clients))
+	for name, c := range cfg.Clients {
+		clients[name] = network.Settings{Proxy: c.Proxy, NoProxy: c.NoProxy, CAFile: c.CAFile}
+	}
+	return network.Setup(network.Settings{Proxy: cfg.Proxy, NoProxy: cfg.NoProxy, CAFile: cfg.CAFile}, clients)
+}
+
+// setupPlugins 按配置启动外部插件，启动失败的跳过
+func setupPlugins(cfg *config.Config) {
+	list := make([]plugin.Options, 0, len(cfg.Plugins))
+	for _, p := range cfg.Plugins {
+		timeout, _ := time.ParseDuration(p.Timeout) // 加载配置时已经检查过格式
+		list = append(list, plugin.Options{Name: p.Name, Command: p.Command, Args: p.Args, Timeout: timeout})
+	}
+	if err := plugin.Setup(list); err != nil {
+		logging.Module("plugin").Warn(i18n.T("部分插件没有启动"), "err", err)
+	}
+}
+
+// nameMatching 配置中餐厅名称的匹配方式
+func nameMatching(cfg *config.Config) preference.MatchOptions {
+	return preference.MatchOptions{
+		Brand:  cfg.NameMatch.BrandEnabled(),
+		Pinyin: cfg.NameMatch.Pinyin,
+		Fuzzy:  cfg.NameMatch.Fuzzy,
+	}
+}
+
+// loadPreferences 加载偏好配置并按配置设置名称匹配方式
+func loadPreferences(cfg *config.Config, path string) (*preference.Preferences, error) {
+	pref, err := preference.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	pref.SetMatching(nameMatching(cfg))
+	return pref, nil
+}
+
+// loadProfiles 加载其他人的偏好（一起吃饭时合并），加载失败的跳过
+func loadProfiles(cfg *config.Config) map[string]*preference.Preferences {
+	if len(cfg.Profiles) == 0 {
+		return nil
+	}
+	profiles := make(map[string]*preference.Preferences, len(cfg.Profiles))
+	for name, path := range cfg.Profiles {
+		p, err := loadPreferences(cfg, path)
+		if err != nil {
+			logging.Module("preference").Warn("加载其他人的偏好配置失败", "profile", name, "path", path, "err", err)
+			continue
+		}
+		profiles[name] = p
+	}
+	return profiles
+}
+
+// validatePreferences 检查偏好配置文件并输出发现的问题，有错误时返回 false
+func validatePreferences(paths []string) bool {
+	ok := true
+	for _, path := range paths {
+		issues, err := preference.Validate(path)
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			ok = false
+			continue
+		}
+		if len(issues) == 0 {
+			fmt.Println(i18n.T("%s: 没有发现问题", path))
+			continue
+		}
+		errors := 0
+		for _, issue := range issues {
+			if issue.Error {
+				errors++
+			}
+		}
+		fmt.Println(i18n.T("%s: %d 个错误，%d 个提醒", path, errors, len(issues)-errors))
+		for _, issue := range issues {
+			fmt.Printf("  %s\n", issue)
+		}
+		if errors > 0 {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// runSync 与远端同步用餐历史和偏好文件
+func runSync(cfg *config.Config, history *memory.History, dataDir, prefPath string) error {
+	var remote cloudsync.Remote
+	switch cfg.Sync.Provider {
+	case "webdav":
+		remote = cloudsync.NewWebDAVRemote(cfg.Sync.URL, cfg.Sync.Username, cfg.Sync.Password)
+	case "s3":
+		remote = cloudsync.NewS3Remote(cfg.Sync.URL, cfg.Sync.Bucket, cfg.Sync.Prefix, cfg.Sync.Region, cfg.Sync.AccessKey, cfg.Sync.SecretKey)
+	case "git":
+		remote = cloudsync.NewGitRemote(cfg.Sync.URL, cfg.Sync.Branch, filepath.Join(dataDir, "sync_repo"))
+	case "":
+		return errors.New(i18n.T("未配置云同步，请在 config.yaml 中设置 sync.provider"))
+	default:
+		return fmt.Errorf(i18n.T("未知的同步方式: %s"), cfg.Sync.Provider)
+	}
+
+	syncer := cloudsync.New(remote, history, filepath.Join(dataDir, "sync_state.json"), prefPath)
+	result, err := syncer.Run()
+	if err != nil {
+		return fmt.Errorf(i18n.T("云同步失败: %v"), err)
+	}
+	fmt.Println(result.Describe())
+	return nil
+}
+
+const historyUsage = `子命令:
+  list [--limit 20]                   最近的用餐记录（默认）
+  export [--format csv|xlsx] [--range 2024-01..2024-06] [--output 文件]
+  import <文件.csv|文件.xlsx>
+  search [关键词] [--restaurant 名称] [--category 菜系] [--note 文字] [--range 2024-01..2024-06] [--rating 4]`
+
+// runHistoryCommand 查看、导入导出和搜索用餐记录，不需要配置文件
+func runHistoryCommand(opts *options, args []string) error {
+	fs := opts.flags("history")
+	fs.Parse(args)
+	args = fs.Args()
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	root, err := memory.NewHistory(opts.dataDir)
+	if err != nil {
+		return fmt.Errorf(i18n.T("初始化历史记录失败: %v"), err)
+	}
+	history := root.ForUser(opts.user)
+
+	switch sub {
+	case "list":
+		fs := opts.flags("history list")
+		limit := fs.Int("limit", 20, i18n.T("最多列出多少条"))
+		fs.Parse(args)
+		out := opts.stdout()
+
+		records := history.Find(memory.Filter{})
+		total := len(records)
+		if *limit > 0 && len(records) > *limit {
+			records = records[:*limit]
+		}
+		if opts.structured() {
+			return writeOutput(out, opts.output, historyOutput{Records: records, Total: total})
+		}
+		if len(records) == 0 {
+			fmt.Fprintln(out, i18n.T("还没有用餐记录"))
+			return nil
+		}
+		printRecords(out, records)
+		return nil
+
+	case "export":
+		fs := opts.flags("history export")
+		format := fs.String("format", "csv", i18n.T("导出格式: csv / xlsx"))
+		period := fs.String("range", "all", i18n.T("日期范围: all / 2024-06 / 2024-01..2024-06"))
+		output := fs.String("output", "", i18n.T("输出文件（留空输出到标准输出）"))
+		fs.Parse(args)
+
+		p, err := memory.ParsePeriod(*period)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return fmt.Errorf(i18n.T("创建输出文件失败: %v"), err)
+			}
+			defer f.Close()
+			out = f
+		} else if *format == "xlsx" {
+			return errors.New(i18n.T("xlsx 格式需要用 --output 指定输出文件"))
+		}
+		return history.Export(out, *format, p)
+
+	case "import":
+		files := parseArgs(opts.flags("history import"), args)
+		if len(files) == 0 {
+			return usageError{errors.New(i18n.T("用法: meal-agent history import <文件.csv|文件.xlsx>"))}
+		}
+		added, err := history.Import(files[0])
+		if err != nil {
+			return fmt.Errorf(i18n.T("导入失败: %v"), err)
+		}
+		fmt.Println(i18n.T("已导入 %d 条记录（重复的记录已跳过）", added))
+		return nil
+
+	case "search":
+		fs := opts.flags("history search")
+		restaurant := fs.String("restaurant", "", i18n.T("餐厅名称（包含即可）"))
+		category := fs.String("category", "", i18n.T("菜系"))
+		note := fs.String("note", "", i18n.T("备注包含的文字"))
+		period := fs.String("range", "all", i18n.T("日期范围: all / 2024-06 / 2024-01..2024-06"))
+		rating := fs.Int("rating", 0, i18n.T("最低评分"))
+		keyword := strings.Join(parseArgs(fs, args), " ")
+		out := opts.stdout()
 
-	fmt.Printf("\n助手: 已记录本次用餐: %s", restaurant)
-	if category != "" {
-		fmt.Printf("（%s）", category)
+		p, err := memory.ParsePeriod(*period)
+		if err != nil {
+			return err
+		}
+		records, err := history.Search(memory.Filter{
+			Keyword:    keyword,
+			Restaurant: *restaurant,
+			Category:   *category,
+			Note:       *note,
+			Period:     p,
+			MinRating:  *rating,
+		})
+		if err != nil {
+			return fmt.Errorf(i18n.T("搜索失败: %v"), err)
+		}
+		if opts.structured() {
+			return writeOutput(out, opts.output, historyOutput{Records: records, Total: len(records)})
+		}
+		if len(records) == 0 {
+			fmt.Fprintln(out, i18n.T("没有找到符合条件的用餐记录"))
+			return nil
+		}
+		printRecords(out, records)
+		fmt.Fprintln(out, i18n.T("共 %d 条", len(records)))
+		return nil
+	}
+	return usageError{fmt.Errorf(i18n.T("未知的子命令: history %s\n%s"), sub, i18n.T(historyUsage))}
+}
+
+// printRecords 每条记录输出一行
+func printRecords(w io.Writer, records []memory.MealRecord) {
+	for _, r := range records {
+		line := r.Date + " " + r.MealType + " " + r.Restaurant
+		if r.Category != "" {
+			line += "（" + r.Category + "）"
+		}
+		if r.Rating > 0 {
+			line += i18n.T(" 评分%d", r.Rating)
+		}
+		if r.Note != "" {
+			line += i18n.T(" 备注：%s", r.Note)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// handleRecord 处理记录用餐: "记录 餐厅名 [类型] [金额] [评分:1-5] [备注:内容] [照片:路径或链接]"
+func handleRecord(mealAgent *agent.MealAgent, input string) {
+	var args string
+	if fields := strings.SplitN(input, " ", 2); len(fields) == 2 {
+		args = fields[1]
+	}
+	record, err := agent.ParseRecord(args)
+	if err != nil {
+		reply(err.Error())
+		return
+	}
+	if err := mealAgent.RecordMeal(record); err != nil {
+		reply(i18n.T("记录失败: %v", err))
+		return
 	}
-	fmt.Println("\n下次推荐时会避免重复。")
+	reply(agent.RecordSummary(record) + "\n" + i18n.T("下次推荐时会避免重复。"))
 }
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"meal-agent/agent"
+)
+
+// 支持的协议版本，客户端请求其中之一时按它回复，否则回复最新的
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 错误码
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxLineSize 单条消息的最大字节数
+const maxLineSize = 4 << 20
+
+// Server MCP（Model Context Protocol）服务：通过标准输入输出与 Claude Desktop 等客户端通信，
+// 把餐厅搜索、天气、用餐记录和推荐作为工具提供给客户端的模型
+type Server struct {
+	agent *agent.MealAgent
+	mu    sync.Mutex // 工具调用依次执行（Agent 不是并发安全的）
+
+	out   *json.Encoder
+	outMu sync.Mutex
+}
+
+// NewServer 创建 MCP 服务
+func NewServer(a *agent.MealAgent) *Server {
+	return &Server{agent: a}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // 没有 id 的是通知，不需要回复
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve 从 in 逐行读取请求，回复写到 out，直到 in 结束
+// 工具调用在后台执行（推荐需要调用 LLM，比较慢），期间仍能回复 ping 等请求
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, &rpcError{codeParseError, "无效的 JSON"})
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue // notifications/initialized、notifications/cancelled 等通知
+		}
+		if req.Method == "tools/call" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handle(req)
+			}()
+			continue
+		}
+		s.handle(req)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) handle(req request) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		for _, v := range protocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		s.reply(req.ID, map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]bool{"listChanged": false}},
+			"serverInfo":      map[string]string{"name": "meal-agent", "version": "1.0.0"},
+			"instructions":    "饮食推荐助手：搜索附近餐厅、查看天气和用餐记录、获取推荐并记录用餐。推荐结果中的序号可以直接用于 record_meal。",
+		}, nil)
+	case "ping":
+		s.reply(req.ID, map[string]interface{}{}, nil)
+	case "tools/list":
+		s.reply(req.ID, map[string]interface{}{"tools": toolList()}, nil)
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.reply(req.ID, nil, &rpcError{codeInvalidParams, "无效的参数"})
+			return
+		}
+		if _, ok := toolSet[params.Name]; !ok {
+			if _, ok := agent.LookupTool(params.Name); !ok {
+				s.reply(req.ID, nil, &rpcError{codeInvalidParams, "未知的工具: " + params.Name})
+				return
+			}
+		}
+		if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+			params.Arguments = json.RawMessage("{}")
+		}
+
+		s.mu.Lock()
+		text, err := callTool(s.agent, params.Name, params.Arguments)
+		s.mu.Unlock()
+		// 工具执行失败作为结果返回（isError），让客户端的模型看到原因
+		if err != nil {
+			text = err.Error()
+		}
+		s.reply(req.ID, map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+			"isError": err != nil,
+		}, nil)
+	default:
+		if req.Method == "" {
+			s.reply(req.ID, nil, &rpcError{codeInvalidRequest, "缺少 method"})
+			return
+		}
+		s.reply(req.ID, nil, &rpcError{codeMethodNotFound, fmt.Sprintf("不支持的方法: %s", req.Method)})
+	}
+}
+
+// reply 写一条回复（每条一行）
+func (s *Server) reply(id json.RawMessage, result interface{}, err *rpcError) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.out.Encode(response{JSONRPC: "2.0", ID: id, Result: result, Error: err})
+}
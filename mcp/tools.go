@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"meal-agent/agent"
+	"meal-agent/memory"
+)
+
+const recommendCandidates = 5 // 推荐结果后面列出的候选餐厅数量
+
+// tool MCP 专有的工具（记录用餐、推荐会修改对话和用餐记录，不放在 agent 的工具注册表中）
+// schema 为参数的 JSON Schema，call 返回给客户端的文字
+type tool struct {
+	description string
+	schema      map[string]interface{}
+	call        func(a *agent.MealAgent, args json.RawMessage) (string, error)
+}
+
+var toolSet = map[string]tool{
+	"record_meal": {
+		description: "记录这一餐：choice 为 recommend 结果中的序号，或者填写 restaurant 手动记录",
+		schema: object(map[string]interface{}{
+			"choice":     intProp("选择最近一次推荐中的第几家（从 1 开始）"),
+			"restaurant": stringProp("餐厅名称（没有 choice 时必填）"),
+			"category":   stringProp("菜系，如 火锅"),
+			"amount":     map[string]interface{}{"type": "number", "description": "花费（元）"},
+			"rating":     intProp("评分 1-5"),
+			"note":       stringProp("备注，下次推荐这家时会提醒"),
+		}),
+		call: recordMeal,
+	},
+	"recommend": {
+		description: "按天气、偏好和用餐记录推荐这一餐吃什么（会调用 LLM），可以附带要求；之后可以用 record_meal 的 choice 记录选择",
+		schema: object(map[string]interface{}{
+			"request":   stringProp("要求，如 不想吃辣、点外卖、预算 50 以内、换一批"),
+			"meal_type": mealTypeProp(),
+		}),
+		call: recommend,
+	},
+}
+
+// toolList tools/list 的结果：agent 中注册的工具（天气、餐厅搜索、用餐记录等）和 MCP 专有的工具，按名称排序
+func toolList() []map[string]interface{} {
+	var list []map[string]interface{}
+	for _, t := range agent.Tools() {
+		list = append(list, map[string]interface{}{
+			"name":        t.Name(),
+			"description": t.Description(),
+			"inputSchema": t.Schema(),
+		})
+	}
+	for name, t := range toolSet {
+		list = append(list, map[string]interface{}{
+			"name":        name,
+			"description": t.description,
+			"inputSchema": t.schema,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i]["name"].(string) < list[j]["name"].(string) })
+	return list
+}
+
+// callTool 执行工具，先找 MCP 专有的工具，再找 agent 中注册的
+func callTool(a *agent.MealAgent, name string, args json.RawMessage) (string, error) {
+	if t, ok := toolSet[name]; ok {
+		return t.call(a, args)
+	}
+	return a.CallTool(name, args)
+}
+
+func recordMeal(a *agent.MealAgent, raw json.RawMessage) (string, error) {
+	var args struct {
+		Choice     int     `json:"choice"`
+		Restaurant string  `json:"restaurant"`
+		Category   string  `json:"category"`
+		Amount     float64 `json:"amount"`
+		Rating     int     `json:"rating"`
+		Note       string  `json:"note"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", errors.New("无效的参数")
+	}
+	if args.Rating < 0 || args.Rating > 5 {
+		return "", errors.New("评分应为 1-5")
+	}
+	if args.Choice > 0 {
+		return a.ConfirmRestaurant(args.Choice - 1)
+	}
+	if strings.TrimSpace(args.Restaurant) == "" {
+		return "", errors.New("需要 choice 或 restaurant")
+	}
+	record := memory.MealRecord{
+		Restaurant: strings.TrimSpace(args.Restaurant),
+		Category:   args.Category,
+		Amount:     args.Amount,
+		Rating:     args.Rating,
+		Note:       args.Note,
+	}
+	if err := a.RecordMeal(record); err != nil {
+		return "", fmt.Errorf("记录失败: %v", err)
+	}
+	return agent.RecordSummary(record), nil
+}
+
+func recommend(a *agent.MealAgent, raw json.RawMessage) (string, error) {
+	var args struct {
+		Request  string `json:"request"`
+		MealType string `json:"meal_type"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", errors.New("无效的参数")
+	}
+	var (
+		reply string
+		err   error
+	)
+	if request := strings.TrimSpace(args.Request); request != "" {
+		reply, err = a.Chat("推荐，" + request)
+	} else {
+		reply, err = a.GetRecommendation(mealType(args.MealType))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 附上候选餐厅的序号，record_meal 的 choice 按这个序号
+	if candidates := a.LastRestaurants(); len(candidates) > 0 {
+		if len(candidates) > recommendCandidates {
+			candidates = candidates[:recommendCandidates]
+		}
+		var sb strings.Builder
+		sb.WriteString(reply + "\n\n候选餐厅（record_meal 的 choice）：")
+		for i, r := range candidates {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, r.Name))
+			if r.Cuisine != "" {
+				sb.WriteString("（" + r.Cuisine + "）")
+			}
+		}
+		reply = sb.String()
+	}
+	return reply, nil
+}
+
+// mealType 未指定时按当前时间判断午餐还是晚餐
+func mealType(s string) string {
+	if s == "lunch" || s == "dinner" {
+		return s
+	}
+	if time.Now().Hour() >= 15 {
+		return "dinner"
+	}
+	return "lunch"
+}
+
+func object(props map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func intProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "description": description}
+}
+
+func mealTypeProp() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": []string{"lunch", "dinner"}, "description": "午餐或晚餐（默认按当前时间）"}
+}
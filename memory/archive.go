@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// archiveDir 归档目录（与 history.json 同级的 archive/，每年一个文件）
+func (h *History) archiveDir() string {
+	return filepath.Join(filepath.Dir(h.filePath), "archive")
+}
+
+func (h *History) archivePath(year string) string {
+	return filepath.Join(h.archiveDir(), "history_"+year+".json")
+}
+
+// Archive 把 months 个月以前的记录移到按年份划分的归档文件，当前文件只保留最近的记录
+// 惩罚、推荐只看最近几天，归档后不必每次扫描全部历史。months<=0 时不归档，返回归档的记录数
+func (h *History) Archive(months int) (int, error) {
+	if months <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, -months, 0).Format("2006-01-02")
+
+	archived := 0
+	err := h.update(func(records []MealRecord) ([]MealRecord, error) {
+		byYear := make(map[string][]MealRecord)
+		active := make([]MealRecord, 0, len(records))
+		for _, r := range records {
+			if r.Date < cutoff && len(r.Date) >= 4 {
+				byYear[r.Date[:4]] = append(byYear[r.Date[:4]], r)
+			} else {
+				active = append(active, r)
+			}
+		}
+		if len(byYear) == 0 {
+			return records, nil
+		}
+
+		if err := os.MkdirAll(h.archiveDir(), 0755); err != nil {
+			return nil, err
+		}
+		// 先写归档再保存当前文件：中途崩溃最多产生重复记录（合并时按 ID 去重），不会丢记录
+		for year, moved := range byYear {
+			if err := h.appendArchive(year, moved); err != nil {
+				return nil, fmt.Errorf("写入%s年归档失败: %v", year, err)
+			}
+			archived += len(moved)
+		}
+		return active, nil
+	})
+	return archived, err
+}
+
+// appendArchive 把记录合并进某年的归档文件（按 ID 去重）
+func (h *History) appendArchive(year string, records []MealRecord) error {
+	path := h.archivePath(year)
+	existing, err := readRecords(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	seen := make(map[string]bool, len(existing))
+	for _, r := range existing {
+		seen[r.ID] = true
+	}
+	for _, r := range records {
+		if !seen[r.ID] {
+			existing = append(existing, r)
+		}
+	}
+	sort.SliceStable(existing, func(i, j int) bool { return existing[i].Date < existing[j].Date })
+	return writeRecords(path, existing)
+}
+
+// ArchivedYears 已归档的年份（从早到晚）
+func (h *History) ArchivedYears() []int {
+	entries, err := os.ReadDir(h.archiveDir())
+	if err != nil {
+		return nil
+	}
+
+	var years []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "history_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if year, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "history_"), ".json")); err == nil {
+			years = append(years, year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
+
+// LoadArchive 读取某年的归档记录（用户视图只返回该用户的记录）
+func (h *History) LoadArchive(year int) ([]MealRecord, error) {
+	records, err := readRecords(h.archivePath(strconv.Itoa(year)))
+	if os.IsNotExist(err) {
+		return []MealRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := records[:0]
+	for _, r := range records {
+		if h.belongs(r) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// Query 查询区间内的记录，区间涉及已归档的年份时一并读取归档
+func (h *History) Query(period Period) ([]MealRecord, error) {
+	var result []MealRecord
+	for _, year := range h.ArchivedYears() {
+		y := strconv.Itoa(year)
+		if (len(period.From) >= 4 && y < period.From[:4]) || (len(period.To) >= 4 && y > period.To[:4]) {
+			continue
+		}
+		archived, err := h.LoadArchive(year)
+		if err != nil {
+			return nil, fmt.Errorf("读取%d年归档失败: %v", year, err)
+		}
+		for _, r := range archived {
+			if period.Contains(r.Date) {
+				result = append(result, r)
+			}
+		}
+	}
+
+	for _, r := range h.Records {
+		if period.Contains(r.Date) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/encryption"
+)
+
+// CookRecord 在家做饭的记录（做饭模式中确认的菜谱，一道菜一条）
+type CookRecord struct {
+	ID          string   `json:"id"`
+	UserID      string   `json:"user_id,omitempty"`
+	Date        string   `json:"date"`                  // 日期 2024-01-15
+	MealType    string   `json:"meal_type"`             // lunch / dinner
+	Recipe      string   `json:"recipe"`                // 菜名
+	Ingredients []string `json:"ingredients,omitempty"` // 用到的食材
+	Rating      int      `json:"rating,omitempty"`      // 用户评分 1-5（可选）
+	Note        string   `json:"note,omitempty"`
+	UpdatedAt   int64    `json:"updated_at,omitempty"`
+}
+
+// CookingHistory 做饭记录，保存在数据目录的 cooking.json 中（与用餐记录分开，不参与餐厅的历史惩罚）
+// 每次读取都从文件加载，聊天和后台模式同时运行时也能看到对方写入的记录
+type CookingHistory struct {
+	filePath string
+	userID   string
+}
+
+// Cooking 同一数据目录、同一用户的做饭记录
+func (h *History) Cooking() *CookingHistory {
+	return &CookingHistory{
+		filePath: filepath.Join(filepath.Dir(h.filePath), "cooking.json"),
+		userID:   h.userID,
+	}
+}
+
+// Add 添加做饭记录，没有填写日期时取今天
+func (c *CookingHistory) Add(record CookRecord) error {
+	if record.Date == "" {
+		record.Date = time.Now().Format(dateLayout)
+	}
+	record.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	record.UserID = c.userID
+	record.UpdatedAt = time.Now().UnixMilli()
+
+	unlock, err := LockFile(c.filePath)
+	if err != nil {
+		return fmt.Errorf("锁定做饭记录失败: %v", err)
+	}
+	defer unlock()
+
+	all, err := c.readAll()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeJSON(c.filePath, append(all, record))
+}
+
+// Recent 最近 days 天（含今天）的做饭记录，最近的在前
+func (c *CookingHistory) Recent(days int) ([]CookRecord, error) {
+	all, err := c.readAll()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days+1).Format(dateLayout)
+	var recent []CookRecord
+	for _, r := range all {
+		if (c.userID == "" || r.UserID == c.userID) && r.Date >= cutoff {
+			recent = append(recent, r)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].Date > recent[j].Date })
+	return recent, nil
+}
+
+// Summary 最近 days 天做过的菜（给 LLM 用）
+func (c *CookingHistory) Summary(days int) string {
+	recent, err := c.Recent(days)
+	if err != nil || len(recent) == 0 {
+		return fmt.Sprintf("最近%d天没有在家做饭的记录\n", days)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("最近%d天在家做过：\n", days))
+	for _, r := range recent {
+		sb.WriteString("- " + r.Date + " " + r.MealType + ": " + r.Recipe)
+		if r.Rating > 0 {
+			sb.WriteString(fmt.Sprintf("（评分%d）", r.Rating))
+		}
+		if r.Note != "" {
+			sb.WriteString(" 备注：" + r.Note)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (c *CookingHistory) readAll() ([]CookRecord, error) {
+	data, err := encryption.ReadFile(c.filePath)
+	if err != nil {
+		return nil, err
+	}
+	var records []CookRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("做饭记录损坏: %v", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DaemonLock 后台模式的单实例锁：数据目录中的 daemon.lock 记录正在运行的进程
+// 两个后台实例会重复推送提醒，并同时写 history.json
+type DaemonLock struct {
+	path string
+	pid  int
+}
+
+// daemonLockInfo 锁文件的内容
+type daemonLockInfo struct {
+	PID     int    `json:"pid"`
+	Host    string `json:"host"`
+	User    string `json:"user,omitempty"`
+	Started string `json:"started"`
+}
+
+// LockDaemon 获取数据目录的后台模式锁，user 为 -user 指定的用户（只用于提示）
+// 已有实例在运行时返回错误；上次异常退出留下的锁（进程已不存在）自动清理
+func LockDaemon(dataDir, user string) (*DaemonLock, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dataDir, "daemon.lock")
+	host, _ := os.Hostname()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			info := daemonLockInfo{PID: os.Getpid(), Host: host, User: user, Started: time.Now().Format("2006-01-02 15:04:05")}
+			data, _ := json.Marshal(info)
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &DaemonLock{path: path, pid: info.PID}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		info, err := readDaemonLock(path)
+		if err == nil && (info.Host != host || processAlive(info.PID)) {
+			return nil, runningError(info, host, path)
+		}
+		if err != nil && recentlyModified(path) {
+			// 另一个实例刚创建锁文件、还没写入内容
+			return nil, fmt.Errorf("另一个后台实例正在启动（%s），请稍后重试", path)
+		}
+		// 锁文件损坏或进程已不存在（上次异常退出），清理后重试
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("无法获取后台模式锁 %s，请稍后重试", path)
+}
+
+// Release 释放锁（锁文件已被其他实例接管时不删除）
+func (l *DaemonLock) Release() {
+	if info, err := readDaemonLock(l.path); err == nil && info.PID == l.pid {
+		os.Remove(l.path)
+	}
+}
+
+func readDaemonLock(path string) (daemonLockInfo, error) {
+	var info daemonLockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, err
+	}
+	if info.PID <= 0 {
+		return info, fmt.Errorf("锁文件中没有进程号")
+	}
+	return info, nil
+}
+
+// recentlyModified 文件是否在几秒内修改过
+func recentlyModified(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && time.Since(st.ModTime()) < 5*time.Second
+}
+
+// runningError 已有实例在运行的提示
+func runningError(info daemonLockInfo, host, path string) error {
+	who := fmt.Sprintf("进程 %d", info.PID)
+	if info.Host != host {
+		who = fmt.Sprintf("主机 %s 上的进程 %d", info.Host, info.PID)
+	}
+	if info.User != "" {
+		who += "，用户 " + info.User
+	}
+	return fmt.Errorf("后台模式已经在运行（%s，%s 启动），同一个数据目录只能运行一个后台实例；"+
+		"如果确认没有在运行，删除 %s 后重试", who, info.Started, path)
+}
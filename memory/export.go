@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 导出的列（导入时也接受中文表头）
+var exportColumns = []string{"date", "meal_type", "restaurant", "category", "amount", "rating", "note", "restaurant_id", "meal_category", "photo", "calories", "protein", "fat"}
+
+// 导入时识别的表头别名
+var columnAliases = map[string]string{
+	"日期": "date", "餐次": "meal_type", "餐厅": "restaurant", "餐厅名称": "restaurant",
+	"菜系": "category", "类型": "category", "金额": "amount", "花费": "amount",
+	"评分": "rating", "备注": "note", "餐厅id": "restaurant_id", "大类": "meal_category",
+	"照片": "photo", "图片": "photo", "热量": "calories", "卡路里": "calories", "蛋白质": "protein", "脂肪": "fat",
+}
+
+// Export 导出区间内的记录（包括已归档的记录），format 为 csv 或 xlsx
+func (h *History) Export(w io.Writer, format string, period Period) error {
+	records, err := h.Query(period)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{exportColumns}
+	for _, r := range records {
+		rows = append(rows, recordRow(r))
+	}
+
+	switch format {
+	case "csv", "":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("写入CSV失败: %v", err)
+		}
+		return nil
+	case "xlsx":
+		return writeXLSX(w, rows)
+	}
+	return fmt.Errorf("不支持的导出格式: %s（可用 csv / xlsx）", format)
+}
+
+// Import 从 CSV 或 XLSX 文件导入记录（按文件扩展名判断格式），与已有记录合并去重
+// 返回新增的记录数
+func (h *History) Import(path string) (int, error) {
+	var rows [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		rows, err = readXLSX(path)
+	case ".csv":
+		rows, err = readCSV(path)
+	default:
+		return 0, fmt.Errorf("不支持的导入格式: %s（可用 .csv / .xlsx）", filepath.Ext(path))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := parseRows(rows)
+	if err != nil {
+		return 0, err
+	}
+	return h.Merge(records)
+}
+
+// Merge 合并记录，同一天同一餐次同一餐厅的记录视为重复（包括已归档的），返回新增的记录数
+func (h *History) Merge(records []MealRecord) (int, error) {
+	added := 0
+	err := h.update(func(existing []MealRecord) ([]MealRecord, error) {
+		seen := make(map[string]bool, len(existing))
+		for _, r := range existing {
+			seen[recordKey(r)] = true
+		}
+		for _, year := range h.ArchivedYears() {
+			archived, err := h.LoadArchive(year)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range archived {
+				seen[recordKey(r)] = true
+			}
+		}
+
+		base := time.Now().UnixNano()
+		for _, r := range records {
+			if seen[recordKey(r)] {
+				continue
+			}
+			seen[recordKey(r)] = true
+			r.ID = strconv.FormatInt(base+int64(added), 36)
+			if r.Time == "" {
+				if t := legacyTime(r.Date, r.MealType); !t.IsZero() {
+					r.Time = t.Format(time.RFC3339)
+				}
+			}
+			r.UserID = h.userID
+			r.UpdatedAt = time.Now().UnixMilli()
+			existing = append(existing, r)
+			added++
+		}
+		return existing, nil
+	})
+	return added, err
+}
+
+func recordKey(r MealRecord) string {
+	return r.UserID + "|" + r.Date + "|" + r.MealType + "|" + r.Restaurant
+}
+
+func recordRow(r MealRecord) []string {
+	amount, rating := "", ""
+	if r.Amount > 0 {
+		amount = strconv.FormatFloat(r.Amount, 'f', -1, 64)
+	}
+	if r.Rating > 0 {
+		rating = strconv.Itoa(r.Rating)
+	}
+	calories, protein, fat := "", "", ""
+	if n := r.Nutrition; n != nil {
+		calories = strconv.FormatFloat(n.Calories, 'f', -1, 64)
+		protein = strconv.FormatFloat(n.Protein, 'f', -1, 64)
+		fat = strconv.FormatFloat(n.Fat, 'f', -1, 64)
+	}
+	return []string{r.Date, r.MealType, r.Restaurant, r.Category, amount, rating, r.Note, r.RestaurantID, r.MealCategory, r.Photo, calories, protein, fat}
+}
+
+func readCSV(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1 // 表格软件导出的行长度可能不一致
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV失败: %v", err)
+	}
+	return rows, nil
+}
+
+// parseRows 按表头把表格行转换成记录，缺少日期或餐厅的行跳过
+func parseRows(rows [][]string) ([]MealRecord, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("文件为空")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("缺少日期列（date / 日期）")
+	}
+	if _, ok := columns["restaurant"]; !ok {
+		return nil, fmt.Errorf("缺少餐厅列（restaurant / 餐厅）")
+	}
+
+	records := make([]MealRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		get := func(col string) string {
+			if i, ok := columns[col]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		date := normalizeDate(get("date"))
+		restaurant := get("restaurant")
+		if date == "" || restaurant == "" {
+			continue
+		}
+
+		r := MealRecord{
+			Date:         date,
+			MealType:     normalizeMealType(get("meal_type")),
+			Restaurant:   restaurant,
+			RestaurantID: get("restaurant_id"),
+			Category:     get("category"),
+			MealCategory: get("meal_category"),
+			Note:         get("note"),
+			Photo:        get("photo"),
+		}
+		r.Amount, _ = strconv.ParseFloat(strings.TrimSuffix(get("amount"), "元"), 64)
+		r.Rating, _ = strconv.Atoi(get("rating"))
+		if calories, err := strconv.ParseFloat(get("calories"), 64); err == nil && calories > 0 {
+			r.Nutrition = &Nutrition{Calories: calories, Source: "import"}
+			r.Nutrition.Protein, _ = strconv.ParseFloat(get("protein"), 64)
+			r.Nutrition.Fat, _ = strconv.ParseFloat(get("fat"), 64)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// normalizeDate 统一日期格式，支持 2024-01-15、2024/1/15 和 Excel 日期序号
+func normalizeDate(s string) string {
+	if s == "" {
+		return ""
+	}
+	for _, layout := range []string{"2006-01-02", "2006/1/2", "2006-1-2", "2006.1.2", "2006年1月2日"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	// Excel 把日期存成 1899-12-30 起的天数
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02")
+	}
+	return ""
+}
+
+// normalizeMealType 把"午餐"、"晚饭"等转换成 lunch / dinner
+func normalizeMealType(s string) string {
+	switch strings.ToLower(s) {
+	case "午餐", "午饭", "中午", "lunch":
+		return "lunch"
+	case "晚餐", "晚饭", "晚上", "dinner":
+		return "dinner"
+	}
+	return s
+}
@@ -2,26 +2,47 @@ package memory
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
+
+	"meal-agent/encryption"
 )
 
+// ErrRecordNotFound 要修改或删除的记录不存在
+var ErrRecordNotFound = errors.New("没有找到这条用餐记录")
+
 // MealRecord 用餐记录
 type MealRecord struct {
-	Date         string `json:"date"`          // 日期 2024-01-15
-	MealType     string `json:"meal_type"`     // lunch / dinner
-	Restaurant   string `json:"restaurant"`    // 餐厅名称
-	Category     string `json:"category"`      // 菜系类型（川菜、湘菜等）
-	MealCategory string `json:"meal_category"` // 餐厅大类：quick(快餐) / full(正餐炒菜)
-	Rating       int    `json:"rating"`        // 用户评分 1-5（可选）
-	Note         string `json:"note"`          // 备注
+	ID           string     `json:"id,omitempty"`            // 记录 ID（修改、删除时使用）
+	UserID       string     `json:"user_id,omitempty"`       // 用户 ID（多人共用时区分，单人使用为空）
+	Date         string     `json:"date"`                    // 日期 2024-01-15（记录时所在时区的日期）
+	Time         string     `json:"time,omitempty"`          // 用餐时刻（RFC3339，带时区），旧记录加载时按日期和餐次补全
+	MealType     string     `json:"meal_type"`               // lunch / dinner
+	Restaurant   string     `json:"restaurant"`              // 餐厅名称
+	RestaurantID string     `json:"restaurant_id,omitempty"` // 餐厅 POI ID（名称变化时仍能匹配）
+	Category     string     `json:"category"`                // 菜系类型（川菜、湘菜等）
+	MealCategory string     `json:"meal_category"`           // 餐厅大类：quick(快餐) / full(正餐炒菜)
+	Rating       int        `json:"rating"`                  // 用户评分 1-5（可选）
+	Amount       float64    `json:"amount,omitempty"`        // 实际花费（元，可选）
+	Note         string     `json:"note"`                    // 备注
+	Photo        string     `json:"photo,omitempty"`         // 照片路径或链接（可选）
+	Nutrition    *Nutrition `json:"nutrition,omitempty"`     // 营养估算（未开启估算时为空）
+	UpdatedAt    int64      `json:"updated_at,omitempty"`    // 最后修改时间（Unix 毫秒，多设备同步时新的覆盖旧的）
 }
 
 // History 历史记录管理
+// 多人共用一个数据目录时，用 ForUser 获取各自的视图，记录、惩罚和统计互不影响
 type History struct {
 	Records  []MealRecord `json:"records"`
 	filePath string
+	userID   string      // 视图对应的用户（为空表示全部记录）
+	mu       *sync.Mutex // 串行化本进程内的写入（各视图共用）
 }
 
 // NewHistory 创建或加载历史记录
@@ -34,33 +55,152 @@ func NewHistory(dataDir string) (*History, error) {
 	h := &History{
 		Records:  []MealRecord{},
 		filePath: filePath,
+		mu:       &sync.Mutex{},
 	}
 
-	// 尝试加载已有记录
-	data, err := os.ReadFile(filePath)
+	// 尝试加载已有记录；加密的记录没有密钥或密钥不对时报错，不能当作没有记录
+	records, err := h.load()
 	if err == nil {
-		json.Unmarshal(data, &h.Records)
+		h.Records = records
+	} else if encryption.KeyError(err) {
+		return nil, err
+	}
+
+	// 只有日期的旧记录补上用餐时刻并保存；保存失败不影响使用，Timestamp 会按日期推算
+	if needsTimeMigration(h.Records) {
+		migrateTimes(h.Records)
+		h.update(func(records []MealRecord) ([]MealRecord, error) {
+			migrateTimes(records)
+			return records, nil
+		})
 	}
 
 	return h, nil
 }
 
+// ForUser 返回某个用户的历史视图，只包含该用户的记录，新增的记录自动标记用户
+// id 为空时返回全部记录
+func (h *History) ForUser(id string) *History {
+	if id == "" || id == h.userID {
+		return h
+	}
+
+	view := &History{
+		Records:  []MealRecord{},
+		filePath: h.filePath,
+		userID:   id,
+		mu:       h.mu,
+	}
+	for _, r := range h.Records {
+		if view.belongs(r) {
+			view.Records = append(view.Records, r)
+		}
+	}
+	return view
+}
+
+// UserID 视图对应的用户（为空表示全部记录）
+func (h *History) UserID() string {
+	return h.userID
+}
+
+// belongs 记录是否属于当前视图
+func (h *History) belongs(r MealRecord) bool {
+	return h.userID == "" || r.UserID == h.userID
+}
+
 // Add 添加用餐记录
+// 没有填写日期或日期是今天时，用餐时刻取当前时间；补记以前的用餐时按日期和餐次推算
 func (h *History) Add(record MealRecord) error {
+	now := time.Now()
 	if record.Date == "" {
-		record.Date = time.Now().Format("2006-01-02")
+		record.Date = now.Format(dateLayout)
+	}
+	if record.Time == "" {
+		if record.Date == now.Format(dateLayout) {
+			record.Time = now.Format(time.RFC3339)
+		} else if t := legacyTime(record.Date, record.MealType); !t.IsZero() {
+			record.Time = t.Format(time.RFC3339)
+		}
+	}
+	record.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	record.UserID = h.userID
+	record.UpdatedAt = time.Now().UnixMilli()
+	return h.update(func(records []MealRecord) ([]MealRecord, error) {
+		return append(records, record), nil
+	})
+}
+
+// Update 修改指定记录（ID 保持不变）
+func (h *History) Update(id string, record MealRecord) error {
+	return h.update(func(records []MealRecord) ([]MealRecord, error) {
+		for i := range records {
+			if records[i].ID == id {
+				record.ID = id
+				record.UserID = records[i].UserID
+				if record.Time == "" && record.Date == records[i].Date {
+					record.Time = records[i].Time
+				}
+				record.UpdatedAt = time.Now().UnixMilli()
+				records[i] = record
+				return records, nil
+			}
+		}
+		return records, ErrRecordNotFound
+	})
+}
+
+// Delete 删除指定记录
+func (h *History) Delete(id string) error {
+	return h.update(func(records []MealRecord) ([]MealRecord, error) {
+		for i := range records {
+			if records[i].ID == id {
+				return append(records[:i], records[i+1:]...), nil
+			}
+		}
+		return records, ErrRecordNotFound
+	})
+}
+
+// UndoLast 撤销最近添加的一条记录，返回被撤销的记录
+// 合并、同步和多人共用时文件中的顺序不一定是添加顺序，按 ID 中的添加时间找最新的一条
+func (h *History) UndoLast() (*MealRecord, error) {
+	var removed MealRecord
+	err := h.update(func(records []MealRecord) ([]MealRecord, error) {
+		if len(records) == 0 {
+			return records, ErrRecordNotFound
+		}
+		latest := 0
+		for i := range records {
+			if addedAt(records[i]) >= addedAt(records[latest]) {
+				latest = i
+			}
+		}
+		removed = records[latest]
+		return append(records[:latest], records[latest+1:]...), nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	h.Records = append(h.Records, record)
-	return h.save()
+	return &removed, nil
 }
 
-// GetRecent 获取最近 N 天的记录
+// addedAt 记录的添加时间（ID 为添加时 UnixNano 的 36 进制），旧格式的 ID 返回 0
+func addedAt(r MealRecord) int64 {
+	n, err := strconv.ParseInt(r.ID, 36, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetRecent 获取最近 N 天的记录（按本地日历日计算）
 func (h *History) GetRecent(days int) []MealRecord {
-	cutoff := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	cutoff := time.Now().AddDate(0, 0, -days).Format(dateLayout)
 	recent := []MealRecord{}
 
 	for _, r := range h.Records {
-		if r.Date >= cutoff {
+		if r.LocalDate() >= cutoff {
 			recent = append(recent, r)
 		}
 	}
@@ -69,17 +209,31 @@ func (h *History) GetRecent(days int) []MealRecord {
 
 // GetToday 获取今天的记录
 func (h *History) GetToday() []MealRecord {
-	today := time.Now().Format("2006-01-02")
+	today := time.Now().Format(dateLayout)
 	todayRecords := []MealRecord{}
 
 	for _, r := range h.Records {
-		if r.Date == today {
+		if r.LocalDate() == today {
 			todayRecords = append(todayRecords, r)
 		}
 	}
 	return todayRecords
 }
 
+// LastNote 返回某家餐厅最近一次用餐的备注（优先按 POI ID 匹配），没有备注时返回空
+func (h *History) LastNote(restaurantID, name string) string {
+	for i := len(h.Records) - 1; i >= 0; i-- {
+		r := h.Records[i]
+		if r.Note == "" {
+			continue
+		}
+		if (restaurantID != "" && r.RestaurantID == restaurantID) || r.Restaurant == name {
+			return r.Note
+		}
+	}
+	return ""
+}
+
 // GetRecentRestaurants 获取最近吃过的餐厅名称（用于避免重复推荐）
 func (h *History) GetRecentRestaurants(days int) []string {
 	recent := h.GetRecent(days)
@@ -103,49 +257,38 @@ func (h *History) GetRecentRestaurants(days int) []string {
 //   - 3天前吃过：-15
 //   - 更早或没吃过：0
 func (h *History) GetRecentPenalty(restaurantName string) int {
-	today := time.Now()
-
-	for _, r := range h.Records {
-		if r.Restaurant != restaurantName {
-			continue
-		}
-
-		recordDate, err := time.Parse("2006-01-02", r.Date)
-		if err != nil {
-			continue
-		}
-
-		daysDiff := int(today.Sub(recordDate).Hours() / 24)
-
-		switch {
-		case daysDiff == 0:
-			return -80 // 今天吃过
-		case daysDiff == 1:
-			return -50 // 昨天吃过
-		case daysDiff == 2:
-			return -30 // 2天前
-		case daysDiff == 3:
-			return -15 // 3天前
-		}
-	}
-
-	return 0 // 没有近期记录
+	return h.GetAllPenalties()[restaurantName] // 没有近期记录时为 0
 }
 
 // GetAllPenalties 获取所有餐厅的惩罚权重（批量查询更高效）
 func (h *History) GetAllPenalties() map[string]int {
+	return h.collectPenalties(func(r MealRecord) string { return r.Restaurant })
+}
+
+// GetAllIDPenalties 按餐厅 POI ID 获取惩罚权重（只包含记录了 ID 的用餐）
+func (h *History) GetAllIDPenalties() map[string]int {
+	return h.collectPenalties(func(r MealRecord) string { return r.RestaurantID })
+}
+
+// collectPenalties 计算惩罚权重，key 决定按什么字段聚合（空 key 跳过）
+func (h *History) collectPenalties(key func(MealRecord) string) map[string]int {
 	penalties := make(map[string]int)
-	today := time.Now()
+	now := time.Now()
 
 	for _, r := range h.Records {
-		recordDate, err := time.Parse("2006-01-02", r.Date)
-		if err != nil {
+		k := key(r)
+		if k == "" {
 			continue
 		}
 
-		daysDiff := int(today.Sub(recordDate).Hours() / 24)
-		if daysDiff > 3 {
-			continue // 超过3天不计算惩罚
+		eaten := r.Timestamp()
+		if eaten.IsZero() {
+			continue
+		}
+
+		daysDiff := calendarDays(eaten, now)
+		if daysDiff < 0 || daysDiff > 3 {
+			continue // 超过3天（或记录时间在未来）不计算惩罚
 		}
 
 		var penalty int
@@ -161,8 +304,8 @@ func (h *History) GetAllPenalties() map[string]int {
 		}
 
 		// 取最大惩罚（最近一次）
-		if existing, ok := penalties[r.Restaurant]; !ok || penalty < existing {
-			penalties[r.Restaurant] = penalty
+		if existing, ok := penalties[k]; !ok || penalty < existing {
+			penalties[k] = penalty
 		}
 	}
 
@@ -176,7 +319,7 @@ func (h *History) GetFrequent(topN int) []string {
 		count[r.Restaurant]++
 	}
 
-	// 简单排序
+	// 按次数排序，次数相同按名称保证结果稳定
 	type kv struct {
 		Name  string
 		Count int
@@ -185,13 +328,12 @@ func (h *History) GetFrequent(topN int) []string {
 	for k, v := range count {
 		sorted = append(sorted, kv{k, v})
 	}
-	for i := 0; i < len(sorted)-1; i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			if sorted[j].Count > sorted[i].Count {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
 		}
-	}
+		return sorted[i].Name < sorted[j].Name
+	})
 
 	result := make([]string, 0, topN)
 	for i := 0; i < topN && i < len(sorted); i++ {
@@ -200,13 +342,123 @@ func (h *History) GetFrequent(topN int) []string {
 	return result
 }
 
-// save 保存到文件
-func (h *History) save() error {
-	data, err := json.MarshalIndent(h.Records, "", "  ")
+// update 加锁后重新读取文件、修改并保存（modify 返回错误时不保存）
+// 聊天和定时模式可能同时运行，先读取最新内容可以避免覆盖另一个进程写入的记录
+// 用户视图中 modify 只能看到该用户的记录，其他用户的记录原样保留
+func (h *History) update(modify func([]MealRecord) ([]MealRecord, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unlock, err := LockFile(h.filePath)
 	if err != nil {
+		return fmt.Errorf("锁定历史记录失败: %v", err)
+	}
+	defer unlock()
+
+	all, err := h.load()
+	switch {
+	case os.IsNotExist(err):
+		all = []MealRecord{}
+	case encryption.KeyError(err):
 		return err
+	case err != nil && h.userID != "":
+		// 视图只持有部分记录，无法读取完整文件时不能保存，否则会丢掉其他用户的记录
+		return err
+	case err != nil:
+		all = h.Records
+	}
+
+	mine := make([]MealRecord, 0, len(all))
+	others := make([]MealRecord, 0)
+	for _, r := range all {
+		if h.belongs(r) {
+			mine = append(mine, r)
+		} else {
+			others = append(others, r)
+		}
 	}
-	return os.WriteFile(h.filePath, data, 0644)
+
+	mine, err = modify(mine)
+	if err != nil {
+		return err
+	}
+	h.Records = mine
+	return writeRecords(h.filePath, append(others, mine...))
+}
+
+// load 读取历史记录文件，文件损坏时从 .bak 备份恢复
+func (h *History) load() ([]MealRecord, error) {
+	records, err := readRecords(h.filePath)
+	if err == nil || os.IsNotExist(err) || errors.Is(err, encryption.ErrNoKey) {
+		return records, err
+	}
+
+	backup, bakErr := readRecords(h.filePath + ".bak")
+	if bakErr != nil {
+		if encryption.KeyError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("历史记录损坏且无法从备份恢复: %v", err)
+	}
+	return backup, nil
+}
+
+func readRecords(path string) ([]MealRecord, error) {
+	data, err := encryption.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRecords(data)
+}
+
+// writeRecords 原子地写入记录文件（设置了密钥时加密）
+func writeRecords(path string, records []MealRecord) error {
+	return writeJSON(path, records)
+}
+
+// writeJSON 原子地写入 JSON 文件（设置了密钥时加密）
+// 先写临时文件再重命名，写到一半崩溃也不会破坏原文件；替换前把原文件备份为 .bak
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if data, err = encryption.Encrypt(data); err != nil {
+		return err
+	}
+
+	// 只备份能正常解析的旧文件，避免用损坏的内容覆盖好的备份
+	if old, err := encryption.ReadFile(path); err == nil && json.Valid(old) {
+		encryption.WriteFile(path+".bak", old, 0644)
+	}
+
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// WriteFileAtomic 原子地写入文件：先写同目录下的临时文件再重命名，写到一半崩溃也不会留下不完整的文件
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后删除会失败，忽略
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // Summary 生成历史摘要（给 LLM 用）
@@ -222,11 +474,30 @@ func (h *History) Summary() string {
 		if r.Category != "" {
 			summary += "（" + r.Category + "）"
 		}
+		if r.Note != "" {
+			summary += " 备注：" + r.Note
+		}
 		summary += "\n"
 	}
+
+	if spend := h.MonthSpend(time.Now()); spend > 0 {
+		summary += fmt.Sprintf("本月餐饮已花费%.0f元\n", spend)
+	}
 	return summary
 }
 
+// MonthSpend 统计指定月份的餐饮花费（只计算记录了金额的用餐）
+func (h *History) MonthSpend(month time.Time) float64 {
+	period := monthPeriod(month)
+	total := 0.0
+	for _, r := range h.Records {
+		if period.Contains(r.Date) {
+			total += r.Amount
+		}
+	}
+	return total
+}
+
 // GetThisWeekMealCategoryCount 获取本周某类餐厅的用餐次数
 // mealCategory: "quick" 快餐类, "full" 正餐炒菜类
 func (h *History) GetThisWeekMealCategoryCount(mealCategory string) int {
@@ -237,13 +508,13 @@ func (h *History) GetThisWeekMealCategoryCount(mealCategory string) int {
 		weekday = 7 // 周日算作第7天
 	}
 	mondayOffset := -(weekday - 1)
-	monday := now.AddDate(0, 0, mondayOffset).Format("2006-01-02")
+	monday := now.AddDate(0, 0, mondayOffset).Format(dateLayout)
 
 	count := 0
 	for _, r := range h.Records {
-		if r.Date >= monday && r.MealCategory == mealCategory {
+		if r.LocalDate() >= monday && r.MealCategory == mealCategory {
 			count++
 		}
 	}
 	return count
-}
\ No newline at end of file
+}
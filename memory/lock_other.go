@@ -0,0 +1,20 @@
+//go:build !unix
+
+package memory
+
+import "os"
+
+// LockFile 非 Unix 平台不加文件锁，只依赖原子替换保证文件完整
+func LockFile(path string) (func(), error) {
+	return func() {}, nil
+}
+
+// processAlive 进程是否还在运行（Windows 上进程不存在时无法打开）
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
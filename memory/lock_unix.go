@@ -0,0 +1,31 @@
+//go:build unix
+
+package memory
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockFile 获取文件的独占咨询锁（聊天和定时模式同时运行时串行化写入），返回释放锁的函数
+// 进程崩溃时锁由系统自动释放
+func LockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// processAlive 进程是否还在运行（发送信号 0 只检查，不影响进程）
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,70 @@
+package memory
+
+import "time"
+
+const dateLayout = "2006-01-02"
+
+// 旧记录只有日期，迁移时按餐次补一个大致的用餐时刻
+var legacyMealHours = map[string]int{"lunch": 12, "dinner": 18}
+
+// Timestamp 用餐时刻
+// 新记录保存了带时区的 RFC3339 时间；只有日期的旧记录按本地时区和餐次推算（午餐 12 点、晚餐 18 点）
+func (r MealRecord) Timestamp() time.Time {
+	if t, err := time.Parse(time.RFC3339, r.Time); err == nil {
+		return t
+	}
+	return legacyTime(r.Date, r.MealType)
+}
+
+// LocalDate 用餐时刻在本地时区的日期（2006-01-02），无法解析时返回原日期
+func (r MealRecord) LocalDate() string {
+	t := r.Timestamp()
+	if t.IsZero() {
+		return r.Date
+	}
+	return t.In(time.Local).Format(dateLayout)
+}
+
+// legacyTime 把只有日期的记录换算成本地时区的用餐时刻，日期无法解析时返回零值
+func legacyTime(date, mealType string) time.Time {
+	day, err := time.ParseInLocation(dateLayout, date, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	hour, ok := legacyMealHours[mealType]
+	if !ok {
+		hour = 12
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.Local)
+}
+
+// calendarDays from 到 to 相差的日历天数（按 to 所在时区的日期计算）
+// 只比较年月日，不受夏令时切换和记录时间点离午夜远近的影响
+func calendarDays(from, to time.Time) int {
+	from = from.In(to.Location())
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// needsTimeMigration 是否有可以补全用餐时刻的旧记录（日期无法解析的记录不算，避免每次启动都重写文件）
+func needsTimeMigration(records []MealRecord) bool {
+	for _, r := range records {
+		if r.Time == "" && !legacyTime(r.Date, r.MealType).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateTimes 为只有日期的旧记录补上用餐时刻
+func migrateTimes(records []MealRecord) {
+	for i := range records {
+		if records[i].Time != "" {
+			continue
+		}
+		if t := legacyTime(records[i].Date, records[i].MealType); !t.IsZero() {
+			records[i].Time = t.Format(time.RFC3339)
+		}
+	}
+}
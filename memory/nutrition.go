@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Nutrition 一餐的营养估算
+type Nutrition struct {
+	Calories float64 `json:"calories"`         // 热量（千卡）
+	Protein  float64 `json:"protein"`          // 蛋白质（克）
+	Fat      float64 `json:"fat"`              // 脂肪（克）
+	Source   string  `json:"source,omitempty"` // 估算来源：table（按菜系查表）/ llm / import
+}
+
+// WeekCalories 一周的热量统计（只计算有营养估算的用餐）
+type WeekCalories struct {
+	Week     string  `json:"week"`     // 当周周一的日期
+	Meals    int     `json:"meals"`    // 有营养估算的用餐次数
+	Calories float64 `json:"calories"` // 总热量（千卡）
+	Average  float64 `json:"average"`  // 平均每餐热量
+}
+
+// weeklyCalories 按周汇总热量，从早到晚排列
+func weeklyCalories(records []MealRecord) []WeekCalories {
+	byWeek := make(map[string]*WeekCalories)
+	for _, r := range records {
+		if r.Nutrition == nil || r.Nutrition.Calories <= 0 {
+			continue
+		}
+		week, ok := weekStart(r.Date)
+		if !ok {
+			continue
+		}
+		w, exists := byWeek[week]
+		if !exists {
+			w = &WeekCalories{Week: week}
+			byWeek[week] = w
+		}
+		w.Meals++
+		w.Calories += r.Nutrition.Calories
+	}
+
+	weeks := make([]WeekCalories, 0, len(byWeek))
+	for _, w := range byWeek {
+		w.Average = w.Calories / float64(w.Meals)
+		weeks = append(weeks, *w)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Week < weeks[j].Week })
+	return weeks
+}
+
+// weekStart 日期所在周的周一
+func weekStart(date string) (string, bool) {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return "", false
+	}
+	offset := (int(day.Weekday()) + 6) % 7 // 周一为 0
+	return day.AddDate(0, 0, -offset).Format("2006-01-02"), true
+}
+
+// describeCalorieTrend 每周热量趋势的文字描述（最多展示最近几周）
+func describeCalorieTrend(weeks []WeekCalories) string {
+	const maxWeeks = 8
+	if len(weeks) > maxWeeks {
+		weeks = weeks[len(weeks)-maxWeeks:]
+	}
+
+	parts := make([]string, 0, len(weeks))
+	for _, w := range weeks {
+		parts = append(parts, fmt.Sprintf("%s起 %d餐 平均%.0f千卡", w.Week[5:], w.Meals, w.Average))
+	}
+	trend := "每周热量：" + strings.Join(parts, "、")
+
+	if n := len(weeks); n >= 2 {
+		diff := weeks[n-1].Average - weeks[n-2].Average
+		switch {
+		case diff > 0:
+			trend += fmt.Sprintf("（比上周每餐多%.0f千卡）", diff)
+		case diff < 0:
+			trend += fmt.Sprintf("（比上周每餐少%.0f千卡）", -diff)
+		}
+	}
+	return trend
+}
+
+// DayNutrition 某天记录的用餐中有营养估算的营养合计，以及这些用餐的餐次（lunch / dinner）
+func (h *History) DayNutrition(day time.Time) (total Nutrition, mealTypes []string) {
+	date := day.Format(dateLayout)
+	for _, r := range h.Records {
+		if r.LocalDate() != date || r.Nutrition == nil {
+			continue
+		}
+		total.Calories += r.Nutrition.Calories
+		total.Protein += r.Nutrition.Protein
+		total.Fat += r.Nutrition.Fat
+		mealTypes = append(mealTypes, r.MealType)
+	}
+	return total, mealTypes
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/encryption"
+)
+
+// ErrPantryItemNotFound 要使用或删除的食材不在库存中
+var ErrPantryItemNotFound = errors.New("家里没有这种食材")
+
+// PantryItem 家里的一种食材
+type PantryItem struct {
+	Name      string  `json:"name"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit,omitempty"`    // 个、克、袋等
+	Expires   string  `json:"expires,omitempty"` // 过期日期 2024-07-01
+	Min       float64 `json:"min,omitempty"`     // 常备食材的最少数量，低于它时提醒补货（0 表示不是常备食材）
+	UpdatedAt int64   `json:"updated_at,omitempty"`
+}
+
+// Amount 数量和单位，如 "10个"
+func (i PantryItem) Amount() string {
+	return strconv.FormatFloat(i.Quantity, 'f', -1, 64) + i.Unit
+}
+
+// DaysLeft 距离过期还有几天（今天过期为 0，已过期为负数），没有填写过期日期时 ok 为 false
+func (i PantryItem) DaysLeft(now time.Time) (days int, ok bool) {
+	if i.Expires == "" {
+		return 0, false
+	}
+	expires, err := time.ParseInLocation(dateLayout, i.Expires, now.Location())
+	if err != nil {
+		return 0, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(expires.Sub(today).Hours() / 24), true
+}
+
+// Expired 是否已经过期
+func (i PantryItem) Expired(now time.Time) bool {
+	days, ok := i.DaysLeft(now)
+	return ok && days < 0
+}
+
+// Low 常备食材是否不够了
+func (i PantryItem) Low() bool {
+	return i.Min > 0 && i.Quantity < i.Min
+}
+
+// Describe 名称、数量和过期时间，如 "鸡蛋 10个（3天后过期）"
+func (i PantryItem) Describe(now time.Time) string {
+	desc := i.Name + " " + i.Amount()
+	if days, ok := i.DaysLeft(now); ok {
+		switch {
+		case days < 0:
+			desc += "（已过期）"
+		case days == 0:
+			desc += "（今天过期）"
+		case days == 1:
+			desc += "（明天过期）"
+		default:
+			desc += fmt.Sprintf("（%d天后过期）", days)
+		}
+	}
+	return desc
+}
+
+// Pantry 家里的食材库存，保存在数据目录的 pantry.json 中（一家人共用，不区分用户）
+// 每次读取都从文件加载，命令行修改后正在运行的后台模式也能看到
+type Pantry struct {
+	filePath string
+}
+
+// NewPantry 数据目录中的食材库存
+func NewPantry(dataDir string) *Pantry {
+	return &Pantry{filePath: filepath.Join(dataDir, "pantry.json")}
+}
+
+// Pantry 同一数据目录的食材库存
+func (h *History) Pantry() *Pantry {
+	return NewPantry(filepath.Dir(h.filePath))
+}
+
+// Items 所有食材：快过期的在前，没有过期日期的按名称排在后面
+func (p *Pantry) Items() ([]PantryItem, error) {
+	items, err := p.readAll()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if (a.Expires == "") != (b.Expires == "") {
+			return a.Expires != ""
+		}
+		if a.Expires != b.Expires {
+			return a.Expires < b.Expires
+		}
+		return a.Name < b.Name
+	})
+	return items, nil
+}
+
+// Add 买了食材：已有同名食材时数量相加，填写了过期日期、常备数量时更新
+func (p *Pantry) Add(item PantryItem) (PantryItem, error) {
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		return item, errors.New("缺少食材名称")
+	}
+	var result PantryItem
+	err := p.update(func(items []PantryItem) ([]PantryItem, error) {
+		if i := findItem(items, item.Name); i >= 0 {
+			existing := &items[i]
+			existing.Quantity += item.Quantity
+			if existing.Unit == "" {
+				existing.Unit = item.Unit
+			}
+			if item.Expires != "" {
+				existing.Expires = item.Expires
+			}
+			if item.Min > 0 {
+				existing.Min = item.Min
+			}
+			existing.UpdatedAt = time.Now().UnixMilli()
+			result = *existing
+			return items, nil
+		}
+		item.UpdatedAt = time.Now().UnixMilli()
+		result = item
+		return append(items, item), nil
+	})
+	return result, err
+}
+
+// Use 用掉一些食材，quantity<=0 时全部用完；用完的常备食材保留（数量为 0，提醒补货），其他的删除
+func (p *Pantry) Use(name string, quantity float64) (PantryItem, error) {
+	var result PantryItem
+	err := p.update(func(items []PantryItem) ([]PantryItem, error) {
+		i := matchItem(items, name)
+		if i < 0 {
+			return nil, ErrPantryItemNotFound
+		}
+		item := &items[i]
+		if quantity <= 0 || quantity >= item.Quantity {
+			item.Quantity = 0
+		} else {
+			item.Quantity -= quantity
+		}
+		item.UpdatedAt = time.Now().UnixMilli()
+		result = *item
+		if item.Quantity == 0 && item.Min == 0 {
+			items = append(items[:i], items[i+1:]...)
+		}
+		return items, nil
+	})
+	return result, err
+}
+
+// Remove 从库存中删除食材（包括常备食材）
+func (p *Pantry) Remove(name string) (PantryItem, error) {
+	var removed PantryItem
+	err := p.update(func(items []PantryItem) ([]PantryItem, error) {
+		i := matchItem(items, name)
+		if i < 0 {
+			return nil, ErrPantryItemNotFound
+		}
+		removed = items[i]
+		return append(items[:i], items[i+1:]...), nil
+	})
+	return removed, err
+}
+
+// findItem 按名称精确查找
+func findItem(items []PantryItem, name string) int {
+	name = strings.TrimSpace(name)
+	for i := range items {
+		if items[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchItem 按名称查找，没有同名的时，名称包含它的只有一种时也算（"蛋" 匹配 "鸡蛋"）
+func matchItem(items []PantryItem, name string) int {
+	if i := findItem(items, name); i >= 0 {
+		return i
+	}
+	name = strings.TrimSpace(name)
+	found := -1
+	for i := range items {
+		if name != "" && strings.Contains(items[i].Name, name) {
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+	}
+	return found
+}
+
+// update 加锁后重新读取文件、修改并保存（modify 返回错误时不保存）
+func (p *Pantry) update(modify func([]PantryItem) ([]PantryItem, error)) error {
+	if err := os.MkdirAll(filepath.Dir(p.filePath), 0755); err != nil {
+		return err
+	}
+	unlock, err := LockFile(p.filePath)
+	if err != nil {
+		return fmt.Errorf("锁定食材库存失败: %v", err)
+	}
+	defer unlock()
+
+	items, err := p.readAll()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	items, err = modify(items)
+	if err != nil {
+		return err
+	}
+	return writeJSON(p.filePath, items)
+}
+
+func (p *Pantry) readAll() ([]PantryItem, error) {
+	data, err := encryption.ReadFile(p.filePath)
+	if err != nil {
+		return nil, err
+	}
+	var items []PantryItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("食材库存损坏: %v", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter 历史记录查询条件，零值字段表示不限制
+type Filter struct {
+	Keyword    string // 关键词，匹配餐厅名、菜系或备注中的任意一项
+	Restaurant string // 餐厅名称（包含即可）
+	Category   string // 菜系（包含即可）
+	MealType   string // lunch / dinner
+	Note       string // 备注包含的文字
+	Period     Period // 日期范围
+	MinRating  int    // 最低评分（大于 0 时跳过未评分的记录）
+	MaxRating  int    // 最高评分
+}
+
+// Match 记录是否满足查询条件（文字比较不区分大小写）
+func (f Filter) Match(r MealRecord) bool {
+	if !f.Period.Contains(r.Date) {
+		return false
+	}
+	if f.MealType != "" && r.MealType != f.MealType {
+		return false
+	}
+	if f.MinRating > 0 && r.Rating < f.MinRating {
+		return false
+	}
+	if f.MaxRating > 0 && r.Rating > f.MaxRating {
+		return false
+	}
+	if !containsFold(r.Restaurant, f.Restaurant) || !containsFold(r.Category, f.Category) || !containsFold(r.Note, f.Note) {
+		return false
+	}
+	if f.Keyword != "" && !containsFold(r.Restaurant, f.Keyword) &&
+		!containsFold(r.Category, f.Keyword) && !containsFold(r.Note, f.Keyword) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(substr)))
+}
+
+// Find 在当前（未归档的）记录中查找满足条件的记录，最近添加的在前
+func (h *History) Find(f Filter) []MealRecord {
+	found := []MealRecord{}
+	for i := len(h.Records) - 1; i >= 0; i-- {
+		if f.Match(h.Records[i]) {
+			found = append(found, h.Records[i])
+		}
+	}
+	return found
+}
+
+// Search 与 Find 相同，但日期范围涉及已归档的年份时一并查找归档，结果按日期从近到远排列
+func (h *History) Search(f Filter) ([]MealRecord, error) {
+	records, err := h.Query(f.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []MealRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		if f.Match(records[i]) {
+			found = append(found, records[i])
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Date > found[j].Date })
+	return found, nil
+}
+
+// DaysSinceLast 满足条件的最近一次用餐距 now 的天数（按日历日），没有记录时 ok 为 false
+func (h *History) DaysSinceLast(f Filter, now time.Time) (days int, ok bool) {
+	var last time.Time
+	for _, r := range h.Records {
+		if !f.Match(r) {
+			continue
+		}
+		if t := r.Timestamp(); !t.IsZero() && t.After(last) {
+			last = t
+		}
+	}
+	if last.IsZero() {
+		return 0, false
+	}
+	return calendarDays(last, now), true
+}
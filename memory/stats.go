@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Period 统计区间（日期格式 2006-01-02，包含两端；为空表示不限）
+type Period struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// ParsePeriod 解析统计区间：week（最近 7 天）、month（本月）、all（全部），
+// 或 2024-06、2024-06-15 这样的月份/日期，以及 2024-01..2024-06 这样的范围
+func ParsePeriod(s string) (Period, error) {
+	now := time.Now()
+	switch s {
+	case "", "month", "本月":
+		return monthPeriod(now), nil
+	case "week", "本周", "最近一周":
+		return Period{From: now.AddDate(0, 0, -6).Format("2006-01-02"), To: now.Format("2006-01-02")}, nil
+	case "all", "全部":
+		return Period{}, nil
+	}
+
+	from, to, isRange := strings.Cut(s, "..")
+	if !isRange {
+		to = from
+	}
+	start, ok := parsePeriodBound(from)
+	end, ok2 := parsePeriodBound(to)
+	if !ok || !ok2 || (from == "" && to == "") {
+		return Period{}, fmt.Errorf("无法识别的统计区间: %s（可用 week / month / all / 2024-06 / 2024-01..2024-06）", s)
+	}
+	return Period{From: start.From, To: end.To}, nil
+}
+
+// parsePeriodBound 把月份或日期解析成区间，空字符串表示不限
+func parsePeriodBound(s string) (Period, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Period{}, true
+	}
+	if day, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		date := day.Format("2006-01-02")
+		return Period{From: date, To: date}, true
+	}
+	if month, err := time.ParseInLocation("2006-01", s, time.Local); err == nil {
+		return monthPeriod(month), true
+	}
+	return Period{}, false
+}
+
+func monthPeriod(t time.Time) Period {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	return Period{From: first.Format("2006-01-02"), To: first.AddDate(0, 1, -1).Format("2006-01-02")}
+}
+
+// Contains 日期是否在区间内
+func (p Period) Contains(date string) bool {
+	return (p.From == "" || date >= p.From) && (p.To == "" || date <= p.To)
+}
+
+// CountItem 计数项
+type CountItem struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Ratio float64 `json:"ratio"` // 占总次数的比例
+}
+
+// Stats 用餐统计
+type Stats struct {
+	Period         Period         `json:"period"`
+	TotalMeals     int            `json:"total_meals"`
+	Cuisines       []CountItem    `json:"cuisines"`                // 菜系分布
+	TopRestaurants []CountItem    `json:"top_restaurants"`         // 去得最多的餐厅
+	TotalSpend     float64        `json:"total_spend"`             // 总花费（只计算记录了金额的用餐）
+	AverageSpend   float64        `json:"average_spend"`           // 记录了金额的用餐的平均花费
+	RepeatRate     float64        `json:"repeat_rate"`             // 重复去同一家的比例（0~1）
+	VarietyScore   int            `json:"variety_score"`           // 菜系多样性（0~100，菜系越多越均匀分数越高）
+	CalorieTrend   []WeekCalories `json:"calorie_trend,omitempty"` // 每周热量（只计算有营养估算的用餐）
+	Streaks        *Streaks       `json:"streaks,omitempty"`       // 截至今天的饮食习惯（与统计区间无关）
+}
+
+// 统计中展示的餐厅数量
+const topRestaurantCount = 5
+
+// Stats 统计区间内的用餐情况（包括已归档的记录）
+func (h *History) Stats(period Period) Stats {
+	stats := Stats{Period: period}
+
+	records, err := h.Query(period)
+	if err != nil {
+		records = h.Records // 归档读取失败时只统计当前记录
+	}
+
+	cuisines := make(map[string]int)
+	restaurants := make(map[string]int)
+	paidMeals := 0
+	inPeriod := make([]MealRecord, 0, len(records))
+	for _, r := range records {
+		if !period.Contains(r.Date) {
+			continue // 只统计当前记录时需要过滤
+		}
+		inPeriod = append(inPeriod, r)
+		stats.TotalMeals++
+		if r.Amount > 0 {
+			stats.TotalSpend += r.Amount
+			paidMeals++
+		}
+
+		cuisine := r.Category
+		if cuisine == "" {
+			cuisine = "未分类"
+		}
+		cuisines[cuisine]++
+		restaurants[r.Restaurant]++
+	}
+
+	if stats.TotalMeals == 0 {
+		return stats
+	}
+
+	if paidMeals > 0 {
+		stats.AverageSpend = stats.TotalSpend / float64(paidMeals)
+	}
+	stats.Cuisines = countItems(cuisines, stats.TotalMeals)
+	stats.TopRestaurants = countItems(restaurants, stats.TotalMeals)
+	if len(stats.TopRestaurants) > topRestaurantCount {
+		stats.TopRestaurants = stats.TopRestaurants[:topRestaurantCount]
+	}
+	stats.RepeatRate = 1 - float64(len(restaurants))/float64(stats.TotalMeals)
+	stats.VarietyScore = varietyScore(cuisines, stats.TotalMeals)
+	stats.CalorieTrend = weeklyCalories(inPeriod)
+
+	return stats
+}
+
+// countItems 按次数从多到少排序，次数相同按名称
+func countItems(counts map[string]int, total int) []CountItem {
+	items := make([]CountItem, 0, len(counts))
+	for name, count := range counts {
+		items = append(items, CountItem{Name: name, Count: count, Ratio: float64(count) / float64(total)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// varietyScore 菜系分布的归一化熵（只有一种菜系为 0，每次都不同为 100）
+func varietyScore(counts map[string]int, total int) int {
+	if len(counts) <= 1 || total <= 1 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log(p)
+	}
+
+	// 最大熵：total 次用餐最多有 total 种菜系
+	maxKinds := total
+	if maxKinds > 20 {
+		maxKinds = 20 // 统一菜系不超过二十多种
+	}
+	score := int(math.Round(entropy / math.Log(float64(maxKinds)) * 100))
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// Describe 统计结果的文字描述
+func (s Stats) Describe() string {
+	if s.TotalMeals == 0 {
+		return "这段时间没有用餐记录"
+	}
+
+	var sb strings.Builder
+	switch {
+	case s.Period.From != "" && s.Period.To != "":
+		sb.WriteString(fmt.Sprintf("%s 至 %s 共用餐 %d 次\n", s.Period.From, s.Period.To, s.TotalMeals))
+	default:
+		sb.WriteString(fmt.Sprintf("共用餐 %d 次\n", s.TotalMeals))
+	}
+
+	sb.WriteString("菜系分布：")
+	for i, c := range s.Cuisines {
+		if i > 0 {
+			sb.WriteString("、")
+		}
+		sb.WriteString(fmt.Sprintf("%s %d次（%.0f%%）", c.Name, c.Count, c.Ratio*100))
+	}
+	sb.WriteString("\n常去餐厅：")
+	for i, r := range s.TopRestaurants {
+		if i > 0 {
+			sb.WriteString("、")
+		}
+		sb.WriteString(fmt.Sprintf("%s %d次", r.Name, r.Count))
+	}
+	if s.TotalSpend > 0 {
+		sb.WriteString(fmt.Sprintf("\n总花费 %.0f 元，平均每餐 %.0f 元", s.TotalSpend, s.AverageSpend))
+	}
+	sb.WriteString(fmt.Sprintf("\n重复率 %.0f%%，多样性 %d/100", s.RepeatRate*100, s.VarietyScore))
+	if len(s.CalorieTrend) > 0 {
+		sb.WriteString("\n" + describeCalorieTrend(s.CalorieTrend))
+	}
+	if s.Streaks != nil {
+		sb.WriteString("\n" + s.Streaks.Describe())
+	}
+	return sb.String()
+}
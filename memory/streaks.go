@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Streaks 饮食习惯的连续记录（截至今天）
+type Streaks struct {
+	NoFastFoodDays  int      `json:"no_fast_food_days"`          // 连续没吃快餐的天数
+	FastFoodDays    int      `json:"fast_food_days"`             // 连续吃快餐的天数（到今天或昨天为止）
+	UnderBudgetDays int      `json:"under_budget_days"`          // 连续每餐都没超预算的天数（只计算记录了金额的日子）
+	NewRestaurants  []string `json:"new_restaurants_this_month"` // 本月第一次去的餐厅
+}
+
+// 算作快餐的菜系（与 tools 中的规范化菜系一致）
+var fastFoodCuisines = map[string]bool{"快餐": true, "汉堡炸鸡": true}
+
+func isFastFood(r MealRecord) bool {
+	return r.MealCategory == "quick" || fastFoodCuisines[r.Category]
+}
+
+// Streaks 统计饮食习惯（包括已归档的记录）
+// budget 返回某餐次的人均预算（0 表示不限制），为 nil 时不统计预算
+func (h *History) Streaks(budget func(mealType string) int) Streaks {
+	records, err := h.Query(Period{})
+	if err != nil {
+		records = h.Records // 归档读取失败时只统计当前记录
+	}
+
+	now := time.Now()
+	today := now.Format(dateLayout)
+	byDay := make(map[string][]MealRecord)
+	for _, r := range records {
+		day := r.LocalDate()
+		if day <= today {
+			byDay[day] = append(byDay[day], r)
+		}
+	}
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days))) // 从近到远
+
+	var streaks Streaks
+	if len(days) == 0 {
+		return streaks
+	}
+
+	// 距离上次吃快餐的天数；从没吃过时从第一条记录算起
+	streaks.NoFastFoodDays = -1
+	for _, day := range days {
+		if anyMeal(byDay[day], isFastFood) {
+			streaks.NoFastFoodDays = daysBefore(day, now)
+			break
+		}
+	}
+	if streaks.NoFastFoodDays < 0 {
+		streaks.NoFastFoodDays = daysBefore(days[len(days)-1], now) + 1
+	}
+
+	// 连续吃快餐：从今天（今天还没记录时从昨天）往前，每天都有快餐
+	expected := now
+	if days[0] != today {
+		expected = now.AddDate(0, 0, -1)
+	}
+	for _, day := range days {
+		if day != expected.Format(dateLayout) || !anyMeal(byDay[day], isFastFood) {
+			break
+		}
+		streaks.FastFoodDays++
+		expected = expected.AddDate(0, 0, -1)
+	}
+
+	// 连续不超预算：没记录金额的日子跳过，遇到超预算的一天停止
+	if budget != nil {
+		for _, day := range days {
+			paid, over := false, false
+			for _, r := range byDay[day] {
+				if r.Amount <= 0 {
+					continue
+				}
+				paid = true
+				if limit := budget(r.MealType); limit > 0 && r.Amount > float64(limit) {
+					over = true
+				}
+			}
+			if over {
+				break
+			}
+			if paid {
+				streaks.UnderBudgetDays++
+			}
+		}
+	}
+
+	// 本月新去的餐厅：本月之前没有出现过
+	month := monthPeriod(now)
+	before := make(map[string]bool)
+	for _, r := range records {
+		if r.LocalDate() < month.From {
+			before[r.Restaurant] = true
+		}
+	}
+	seen := make(map[string]bool)
+	for i := len(days) - 1; i >= 0; i-- {
+		if !month.Contains(days[i]) {
+			continue
+		}
+		for _, r := range byDay[days[i]] {
+			if !before[r.Restaurant] && !seen[r.Restaurant] {
+				seen[r.Restaurant] = true
+				streaks.NewRestaurants = append(streaks.NewRestaurants, r.Restaurant)
+			}
+		}
+	}
+
+	return streaks
+}
+
+func anyMeal(records []MealRecord, match func(MealRecord) bool) bool {
+	for _, r := range records {
+		if match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysBefore 某个日期距今天的日历天数
+func daysBefore(date string, now time.Time) int {
+	day, err := time.ParseInLocation(dateLayout, date, time.Local)
+	if err != nil {
+		return 0
+	}
+	return calendarDays(day, now)
+}
+
+// Describe 习惯统计的文字描述
+func (s Streaks) Describe() string {
+	parts := []string{}
+	if s.FastFoodDays >= 2 {
+		parts = append(parts, fmt.Sprintf("已连续 %d 天吃快餐", s.FastFoodDays))
+	} else if s.NoFastFoodDays > 0 {
+		parts = append(parts, fmt.Sprintf("连续 %d 天没吃快餐", s.NoFastFoodDays))
+	}
+	if s.UnderBudgetDays > 0 {
+		parts = append(parts, fmt.Sprintf("连续 %d 天没超预算", s.UnderBudgetDays))
+	}
+	if n := len(s.NewRestaurants); n > 0 {
+		parts = append(parts, fmt.Sprintf("本月尝试了 %d 家新餐厅", n))
+	} else {
+		parts = append(parts, "本月还没尝试新餐厅")
+	}
+	return "习惯：" + strings.Join(parts, "，")
+}
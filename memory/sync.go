@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// SyncResult 同步合并的结果
+type SyncResult struct {
+	Added   int // 从远端新增的记录数
+	Removed int // 一边已删除、合并后移除的记录数
+	Updated int // 远端修改较新、覆盖本地的记录数
+}
+
+// MergeRecords 三方合并本地和远端的记录
+// base 是上次同步时双方共有的记录 ID，lastSync 是上次同步时间（Unix 毫秒）：
+//   - 两边都有：保留 UpdatedAt 较新的一方（相同时保留本地）
+//   - 只有一边有：上次同步时已存在说明另一边删除了，除非之后又修改过，否则一并删除；否则是新记录，保留
+func MergeRecords(local, remote []MealRecord, base map[string]bool, lastSync int64) ([]MealRecord, SyncResult) {
+	var result SyncResult
+	remoteByID := make(map[string]MealRecord, len(remote))
+	for _, r := range remote {
+		remoteByID[r.ID] = r
+	}
+
+	merged := make([]MealRecord, 0, len(local)+len(remote))
+	seen := make(map[string]bool, len(local))
+	for _, l := range local {
+		seen[l.ID] = true
+		r, ok := remoteByID[l.ID]
+		switch {
+		case ok && r.UpdatedAt > l.UpdatedAt:
+			merged = append(merged, r)
+			result.Updated++
+		case ok:
+			merged = append(merged, l)
+		case base[l.ID] && l.UpdatedAt <= lastSync:
+			result.Removed++ // 远端已删除
+		default:
+			merged = append(merged, l)
+		}
+	}
+	for _, r := range remote {
+		if seen[r.ID] {
+			continue
+		}
+		if base[r.ID] && r.UpdatedAt <= lastSync {
+			result.Removed++ // 本地已删除
+			continue
+		}
+		merged = append(merged, r)
+		result.Added++
+	}
+	return merged, result
+}
+
+// Sync 在加锁状态下与远端交换全部记录（包括已归档的）
+// exchange 收到本地的全部记录，返回合并后的结果（通常在其中下载、合并并上传）
+// 合并结果中已归档的记录（包括远端对它们的修改和删除）写回原来的年份归档文件，
+// 其余写回当前文件，下次启动时再按配置归档
+func (h *History) Sync(exchange func(local []MealRecord) ([]MealRecord, error)) error {
+	if h.userID != "" {
+		return fmt.Errorf("同步需要使用全部用户的历史记录")
+	}
+
+	archivedYear := make(map[string]int) // 已归档记录的 ID → 所在的归档年份
+	byYear := make(map[int][]MealRecord)
+	var archived []MealRecord
+	for _, year := range h.ArchivedYears() {
+		records, err := h.LoadArchive(year)
+		if err != nil {
+			return fmt.Errorf("读取%d年归档失败: %v", year, err)
+		}
+		for _, r := range records {
+			archivedYear[r.ID] = year
+		}
+		byYear[year] = records
+		archived = append(archived, records...)
+	}
+
+	return h.update(func(records []MealRecord) ([]MealRecord, error) {
+		merged, err := exchange(append(archived, records...))
+		if err != nil {
+			return nil, err
+		}
+
+		active := make([]MealRecord, 0, len(merged))
+		mergedByYear := make(map[int][]MealRecord, len(byYear))
+		for _, r := range merged {
+			if year, ok := archivedYear[r.ID]; ok {
+				mergedByYear[year] = append(mergedByYear[year], r)
+			} else {
+				active = append(active, r)
+			}
+		}
+		// 和 Archive 一样先写归档再保存当前文件；只重写合并后有变化的年份
+		for year, records := range byYear {
+			if sameRecords(records, mergedByYear[year]) {
+				continue
+			}
+			if mergedByYear[year] == nil {
+				mergedByYear[year] = []MealRecord{}
+			}
+			if err := writeRecords(h.archivePath(strconv.Itoa(year)), mergedByYear[year]); err != nil {
+				return nil, fmt.Errorf("写入%d年归档失败: %v", year, err)
+			}
+		}
+		return active, nil
+	})
+}
+
+// sameRecords 两组记录是否相同（ID 和修改时间一一对应）
+func sameRecords(a, b []MealRecord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].UpdatedAt != b[i].UpdatedAt {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRecords 解析历史记录文件的内容（同步时解析远端下载的数据）
+func ParseRecords(data []byte) ([]MealRecord, error) {
+	records := []MealRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	// 旧版本的记录没有 ID，按位置生成固定的 ID（多个进程生成的结果一致），下次保存时写入
+	for i := range records {
+		if records[i].ID == "" {
+			records[i].ID = fmt.Sprintf("%s-%d", records[i].Date, i)
+		}
+	}
+	return records, nil
+}
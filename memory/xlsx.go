@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 只实现导入导出需要的最小 XLSX 子集：单个工作表，字符串和数字单元格
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="用餐记录" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+// writeXLSX 写入单个工作表的 XLSX 文件（字符串使用内联字符串，数字保持数值类型）
+func writeXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", sheetXML(rows)},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func sheetXML(rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, i+1))
+		for j, value := range row {
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if _, err := strconv.ParseFloat(value, 64); err == nil && i > 0 {
+				sb.WriteString(fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, value))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>`, ref))
+			xml.EscapeText(&sb, []byte(value))
+			sb.WriteString(`</t></is></c>`)
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// columnName 列序号（从 0 开始）转换成 A、B、…、AA
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// columnIndex 单元格引用（如 "C12"）转换成列序号
+func columnIndex(ref string) int {
+	idx := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		idx = idx*26 + int(c-'A'+1)
+	}
+	return idx - 1
+}
+
+// xlsxText 共享字符串和内联字符串的文本（富文本由多个 r/t 组成）
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+// readXLSX 读取第一个工作表的所有行
+func readXLSX(path string) ([][]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取XLSX失败: %v", err)
+	}
+	defer zr.Close()
+
+	var sharedStrings []string
+	var sheet *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			var sst struct {
+				SI []xlsxText `xml:"si"`
+			}
+			if err := decodeZipXML(f, &sst); err != nil {
+				return nil, err
+			}
+			for _, si := range sst.SI {
+				sharedStrings = append(sharedStrings, si.String())
+			}
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && strings.HasSuffix(f.Name, ".xml"):
+			// 优先使用 sheet1，其次按文件名排序的第一个
+			if sheet == nil || f.Name == "xl/worksheets/sheet1.xml" || (sheet.Name != "xl/worksheets/sheet1.xml" && f.Name < sheet.Name) {
+				sheet = f
+			}
+		}
+	}
+	if sheet == nil {
+		return nil, fmt.Errorf("XLSX 中没有工作表")
+	}
+
+	var data struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(sheet, &data); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		var values []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			switch c.Type {
+			case "s":
+				if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(sharedStrings) {
+					values[col] = sharedStrings[n]
+				}
+			case "inlineStr":
+				values[col] = c.Inline.String()
+			default:
+				values[col] = c.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", f.Name, err)
+	}
+	return nil
+}
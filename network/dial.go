@@ -0,0 +1,134 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Dial 按客户端的代理和证书设置连接 WebSocket 地址（ws / wss）：有代理时通过 HTTP CONNECT 建立隧道，
+// wss 完成 TLS 握手后返回（Slack、Discord 的长连接使用）
+func Dial(ctx context.Context, client string, u *url.URL) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	t := transportFor(client)
+	var proxy *url.URL
+	if t.Proxy != nil {
+		// 代理按 http / https 地址选择
+		target := *u
+		target.Scheme = map[string]string{"ws": "http", "wss": "https"}[u.Scheme]
+		p, err := t.Proxy(&http.Request{URL: &target, Header: http.Header{}})
+		if err != nil {
+			return nil, err
+		}
+		proxy = p
+	}
+
+	var conn net.Conn
+	var err error
+	if proxy == nil {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = dialProxy(ctx, t, proxy, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if u.Scheme == "wss" {
+		config := &tls.Config{}
+		if t.TLSClientConfig != nil {
+			config = t.TLSClientConfig.Clone()
+		}
+		config.ServerName = u.Hostname()
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
+// dialProxy 连接 HTTP(S) 代理并用 CONNECT 建立到 host 的隧道
+func dialProxy(ctx context.Context, t *http.Transport, proxy *url.URL, host string) (net.Conn, error) {
+	if proxy.Scheme != "http" && proxy.Scheme != "https" {
+		return nil, fmt.Errorf("长连接只支持 http / https 代理: %s", proxy.Redacted())
+	}
+	proxyHost := proxy.Host
+	if proxy.Port() == "" {
+		port := "80"
+		if proxy.Scheme == "https" {
+			port = "443"
+		}
+		proxyHost = net.JoinHostPort(proxy.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHost)
+	if err != nil {
+		return nil, fmt.Errorf("连接代理失败: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if proxy.Scheme == "https" {
+		config := &tls.Config{}
+		if t.TLSClientConfig != nil {
+			config = t.TLSClientConfig.Clone()
+		}
+		config.ServerName = proxy.Hostname()
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("连接代理失败: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: host},
+		Host:   host,
+		Header: http.Header{},
+	}
+	if user := proxy.User; user != nil {
+		password, _ := user.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("代理 CONNECT 失败: %v", err)
+	}
+	// 隧道建立后要等我们先发数据（TLS 握手、WebSocket 升级请求），读取响应头时不会读到隧道中的内容
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("代理 CONNECT 失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理 CONNECT 失败: %s", resp.Status)
+	}
+	return conn, nil
+}
@@ -0,0 +1,248 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 外部请求的代理和证书：所有客户端默认使用 network 的设置（代理留空时使用 HTTPS_PROXY / HTTP_PROXY / NO_PROXY 环境变量），
+// network.clients 中可以按客户端单独设置，如 LLM 走公司代理、高德直连
+
+// 客户端名称（network.clients 的键）
+const (
+	LLM     = "llm"     // LLM 接口
+	Amap    = "amap"    // 高德餐厅搜索、详情和地理编码
+	Weather = "weather" // 和风天气、OpenWeatherMap、Open-Meteo
+	OSM     = "osm"     // OpenStreetMap Overpass
+	Rating  = "rating"  // 第三方评分
+	Sync    = "sync"    // WebDAV、S3 云同步
+	Notify  = "notify"  // webhook、聊天机器人、手机推送
+	Chatbot = "chatbot" // Slack、Discord 的 /meal 命令
+	Trace   = "trace"   // 链路追踪的 OTLP 导出
+	Canteen = "canteen" // 公司食堂菜单
+)
+
+// Clients 所有客户端名称
+var Clients = []string{LLM, Amap, Weather, OSM, Rating, Sync, Notify, Chatbot, Trace, Canteen}
+
+// Direct 代理设置为 direct 时不使用代理（也不读取环境变量）
+const Direct = "direct"
+
+// Settings 一个客户端的代理和证书
+type Settings struct {
+	Proxy   string // 代理地址，如 http://proxy.corp:8080、socks5://127.0.0.1:1080；留空使用环境变量，direct 不使用代理
+	NoProxy string // 不走代理的域名、IP 或网段，逗号分隔，如 .corp.com,10.0.0.0/8
+	CAFile  string // 额外信任的 CA 证书（PEM），公司网络对 HTTPS 做检查时使用
+}
+
+// merge 客户端没有填写的项使用全局设置
+func (s Settings) merge(global Settings) Settings {
+	if s.Proxy == "" {
+		s.Proxy = global.Proxy
+	}
+	if s.NoProxy == "" {
+		s.NoProxy = global.NoProxy
+	}
+	if s.CAFile == "" {
+		s.CAFile = global.CAFile
+	}
+	return s
+}
+
+// base 程序启动时的默认连接设置（Setup 之后 http.DefaultTransport 被替换）
+var base = http.DefaultTransport.(*http.Transport).Clone()
+
+// router 按请求所属的客户端选择连接设置
+type router struct {
+	mu      sync.RWMutex
+	def     *http.Transport
+	clients map[string]*http.Transport
+}
+
+var (
+	setupMu   sync.Mutex
+	installed *router
+	fallback  = base.Clone() // Setup 之前 Transport、Direct 使用的连接
+)
+
+// Setup 按配置创建各客户端的连接设置，并把 http.DefaultTransport 换成按客户端选择的版本
+// 可以重复调用（重新加载配置），之后的请求使用新的设置
+func Setup(global Settings, clients map[string]Settings) error {
+	def, err := newTransport(global)
+	if err != nil {
+		return fmt.Errorf("network: %v", err)
+	}
+	table := make(map[string]*http.Transport, len(clients))
+	for name, s := range clients {
+		t, err := newTransport(s.merge(global))
+		if err != nil {
+			return fmt.Errorf("network.clients.%s: %v", name, err)
+		}
+		table[name] = t
+	}
+
+	setupMu.Lock()
+	defer setupMu.Unlock()
+	if installed == nil {
+		installed = &router{}
+		http.DefaultTransport = installed
+	}
+	installed.mu.Lock()
+	old := installed.def
+	oldClients := installed.clients
+	installed.def, installed.clients = def, table
+	installed.mu.Unlock()
+
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	for _, t := range oldClients {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (r *router) get(client string) *http.Transport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.clients[client]; ok {
+		return t
+	}
+	return r.def
+}
+
+func (r *router) RoundTrip(req *http.Request) (*http.Response, error) {
+	client, _ := req.Context().Value(clientKey{}).(string)
+	return r.get(client).RoundTrip(req)
+}
+
+// transportFor 客户端当前的连接设置
+func transportFor(client string) *http.Transport {
+	setupMu.Lock()
+	r := installed
+	setupMu.Unlock()
+	if r == nil {
+		return fallback
+	}
+	return r.get(client)
+}
+
+type clientKey struct{}
+
+// Transport 客户端的 http.Client 使用的 Transport：经过 http.DefaultTransport
+// （后台模式退出时取消请求、--debug-dir 记录响应），按客户端的设置连接
+func Transport(client string) http.RoundTripper {
+	return &named{client: client}
+}
+
+// Separate 不经过 http.DefaultTransport 的 Transport：推送、链路追踪导出在后台模式退出时仍要发出去
+func Separate(client string) http.RoundTripper {
+	return &named{client: client, separate: true}
+}
+
+type named struct {
+	client   string
+	separate bool
+}
+
+func (n *named) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.WithContext(context.WithValue(req.Context(), clientKey{}, n.client))
+	if n.separate {
+		return transportFor(n.client).RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTransport 按设置创建连接：代理、不走代理的地址和额外信任的 CA
+func newTransport(s Settings) (*http.Transport, error) {
+	t := base.Clone()
+	switch strings.ToLower(s.Proxy) {
+	case "":
+		t.Proxy = http.ProxyFromEnvironment
+	case Direct:
+		t.Proxy = nil
+	default:
+		proxy, err := ParseProxy(s.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		noProxy := s.NoProxy
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypass(req.URL.Host, noProxy) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+
+	if s.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s 中没有 PEM 格式的证书", s.CAFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return t, nil
+}
+
+// ParseProxy 解析代理地址（http / https / socks5，没有写协议时按 http）
+func ParseProxy(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("代理地址不正确: %s", s)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("代理只支持 http / https / socks5: %s", s)
+}
+
+// bypass host 是否不走代理：本机地址，以及 noProxy 中的域名（包括子域名）、IP 和网段
+func bypass(host, noProxy string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.ToLower(strings.Trim(hostname, "[]"))
+	ip := net.ParseIP(hostname)
+	if hostname == "localhost" || ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "/"):
+			if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		default:
+			entry = strings.TrimPrefix(entry, ".")
+			if hostname == entry || strings.HasSuffix(hostname, "."+entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// botMessageLimit 群机器人消息的最大字符数（企业微信 markdown 限制 4096 字节）
+const botMessageLimit = 1200
+
+// botMaxRestaurants 消息末尾附带的候选餐厅数量
+const botMaxRestaurants = 5
+
+// BotNotifier 推送到聊天软件的机器人：企业微信、钉钉、飞书群机器人或 Telegram Bot
+type BotNotifier struct {
+	kind   string // wecom / dingtalk / feishu / telegram
+	url    string // 群机器人的 webhook 地址（telegram 不需要）
+	secret string // 钉钉、飞书机器人的加签密钥（可选）
+	token  string // Telegram Bot Token
+	chatID string // Telegram 会话 ID
+	client *http.Client
+}
+
+// NewBotNotifier 创建机器人推送，kind 为 wecom / dingtalk / feishu 时使用 webhookURL（钉钉、飞书开启加签时填写 secret），
+// 为 telegram 时使用 token 和 chatID
+func NewBotNotifier(kind, webhookURL, secret, token, chatID string) (*BotNotifier, error) {
+	switch kind {
+	case "wecom", "dingtalk", "feishu":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("%s 机器人需要填写 url", kind)
+		}
+	case "telegram":
+		if token == "" || chatID == "" {
+			return nil, fmt.Errorf("telegram 机器人需要填写 token 和 chat_id")
+		}
+	default:
+		return nil, fmt.Errorf("未知的机器人类型: %s（可用 wecom / dingtalk / feishu / telegram）", kind)
+	}
+	return &BotNotifier{
+		kind:   kind,
+		url:    webhookURL,
+		secret: secret,
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}, nil
+}
+
+// Notify 发送提醒：标题、推荐内容和候选餐厅列表
+func (b *BotNotifier) Notify(n Notification) error {
+	text := n.Title + "\n\n" + messageBody(n)
+	replies := n.ReplyURL != "" && len(n.Restaurants) > 0
+
+	var (
+		endpoint = b.url
+		payload  interface{}
+	)
+	switch b.kind {
+	case "wecom":
+		if replies {
+			text += "\n\n" + markdownReplies(n)
+		}
+		payload = map[string]interface{}{"msgtype": "markdown", "markdown": map[string]string{"content": text}}
+	case "dingtalk":
+		if b.secret != "" {
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			sign := hmacBase64([]byte(b.secret), timestamp+"\n"+b.secret)
+			sep := "?"
+			if strings.Contains(endpoint, "?") {
+				sep = "&"
+			}
+			endpoint += sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+		}
+		if replies {
+			text += "\n\n" + markdownReplies(n)
+		}
+		payload = map[string]interface{}{"msgtype": "markdown", "markdown": map[string]string{"title": n.Title, "text": text}}
+	case "feishu":
+		body := map[string]interface{}{"msg_type": "text", "content": map[string]string{"text": text}}
+		if b.secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			body["timestamp"] = timestamp
+			body["sign"] = hmacBase64([]byte(timestamp+"\n"+b.secret), "")
+		}
+		payload = body
+	case "telegram":
+		endpoint = "https://api.telegram.org/bot" + b.token + "/sendMessage"
+		msg := map[string]interface{}{"chat_id": b.chatID, "text": text}
+		if replies {
+			msg["reply_markup"] = telegramReplies(n)
+		}
+		payload = msg
+	}
+
+	resp, err := postJSON(b.client, endpoint, nil, payload)
+	if err != nil {
+		return fmt.Errorf("%s 机器人推送失败: %v", b.kind, err)
+	}
+	return checkBotResponse(b.kind, resp)
+}
+
+// messageBody 机器人、手机推送的消息正文：推荐内容（过长时截断）和候选餐厅
+func messageBody(n Notification) string {
+	var sb strings.Builder
+	sb.WriteString(summary(n.Text, botMessageLimit))
+	if len(n.Restaurants) > 0 {
+		sb.WriteString("\n\n候选餐厅：")
+		for i, r := range n.Restaurants {
+			if i >= botMaxRestaurants {
+				break
+			}
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, r.Name))
+			if r.Distance != "" {
+				sb.WriteString("（" + r.Distance + "米）")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// markdownReplies 企业微信、钉钉消息末尾的快捷回复链接
+func markdownReplies(n Notification) string {
+	links := make([]string, 0, len(quickReplies))
+	for _, text := range quickReplies {
+		links = append(links, fmt.Sprintf("[%s](%s)", text, replyLink(n, text)))
+	}
+	return "回复：" + strings.Join(links, " · ")
+}
+
+// telegramReplies Telegram 消息下方的快捷回复按钮（打开回复链接）
+func telegramReplies(n Notification) map[string]interface{} {
+	row := make([]map[string]string, 0, len(quickReplies))
+	for _, text := range quickReplies {
+		row = append(row, map[string]string{"text": text, "url": replyLink(n, text)})
+	}
+	return map[string]interface{}{"inline_keyboard": [][]map[string]string{row}}
+}
+
+// checkBotResponse 机器人接口即使出错也返回 200，需要检查返回的错误码
+func checkBotResponse(kind string, body []byte) error {
+	var result struct {
+		ErrCode     int    `json:"errcode"`     // 企业微信、钉钉
+		ErrMsg      string `json:"errmsg"`      // 企业微信、钉钉
+		Code        int    `json:"code"`        // 飞书
+		Msg         string `json:"msg"`         // 飞书
+		OK          *bool  `json:"ok"`          // telegram
+		Description string `json:"description"` // telegram
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil // 返回的不是 JSON 时只看 HTTP 状态
+	}
+	switch {
+	case result.ErrCode != 0:
+		return fmt.Errorf("%s 机器人推送失败: %d %s", kind, result.ErrCode, result.ErrMsg)
+	case result.Code != 0:
+		return fmt.Errorf("%s 机器人推送失败: %d %s", kind, result.Code, result.Msg)
+	case result.OK != nil && !*result.OK:
+		return fmt.Errorf("%s 机器人推送失败: %s", kind, result.Description)
+	}
+	return nil
+}
+
+// hmacBase64 HMAC-SHA256 签名的 base64 编码（钉钉、飞书加签）
+func hmacBase64(key []byte, message string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
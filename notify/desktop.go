@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// desktopMessageLimit 桌面通知内容的最大字符数
+const desktopMessageLimit = 200
+
+// appName 通知中显示的应用名称
+const appName = "Meal Agent"
+
+// Windows 通知使用 PowerShell 的应用 ID（未注册的 ID 无法弹出通知），标题和内容通过环境变量传入避免转义问题
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:MEAL_AGENT_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:MEAL_AGENT_MESSAGE)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)
+`
+
+// DesktopNotifier 系统桌面通知
+// macOS 优先使用 terminal-notifier，没有安装时使用 osascript；Linux 使用 notify-send；Windows 使用 PowerShell 弹出通知
+type DesktopNotifier struct {
+	command func(title, message string) *exec.Cmd
+}
+
+// NewDesktopNotifier 按当前系统选择通知方式，系统不支持或缺少通知命令时返回错误
+func NewDesktopNotifier() (*DesktopNotifier, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("terminal-notifier"); err == nil {
+			return &DesktopNotifier{command: func(title, message string) *exec.Cmd {
+				return exec.Command("terminal-notifier", "-title", title, "-message", message, "-group", "meal-agent")
+			}}, nil
+		}
+		return &DesktopNotifier{command: func(title, message string) *exec.Cmd {
+			// 通过参数传入文本，避免引号等字符破坏脚本
+			return exec.Command("osascript",
+				"-e", "on run argv",
+				"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+				"-e", "end run",
+				title, message)
+		}}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil, fmt.Errorf("没有找到 notify-send（Debian/Ubuntu 可安装 libnotify-bin）")
+		}
+		return &DesktopNotifier{command: func(title, message string) *exec.Cmd {
+			return exec.Command("notify-send", "-a", appName, title, message)
+		}}, nil
+	case "windows":
+		return &DesktopNotifier{command: func(title, message string) *exec.Cmd {
+			cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+			cmd.Env = append(os.Environ(), "MEAL_AGENT_TITLE="+title, "MEAL_AGENT_MESSAGE="+message)
+			return cmd
+		}}, nil
+	}
+	return nil, fmt.Errorf("不支持在 %s 上发送桌面通知", runtime.GOOS)
+}
+
+// Notify 发送桌面通知，内容过长时截断
+func (d *DesktopNotifier) Notify(n Notification) error {
+	output, err := d.command(n.Title, summary(n.Text, desktopMessageLimit)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("发送桌面通知失败: %v %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	emailTimeout       = 30 * time.Second
+	emailSSLPort       = 465 // 连接时就使用 TLS 的端口，其他端口通过 STARTTLS 升级
+	emailMaxRestaurant = 5   // 邮件中的餐厅卡片数量
+)
+
+// EmailOptions 邮件的发送设置
+type EmailOptions struct {
+	Host     string   // SMTP 服务器
+	Port     int      // 端口，465 使用 SSL，其他端口服务器支持时使用 STARTTLS
+	Username string   // 登录用户名（为空时不登录）
+	Password string   // 密码或授权码
+	From     string   // 发件人（为空时同 Username）
+	To       []string // 收件人
+	ReportTo []string // 每周报告的收件人（为空时同 To）
+}
+
+// EmailNotifier 通过 SMTP 发送邮件：推荐和餐厅卡片用网页格式显示，同时附带纯文本
+type EmailNotifier struct {
+	opts     EmailOptions
+	from     *mail.Address
+	to       []*mail.Address
+	reportTo []*mail.Address
+}
+
+// NewEmailNotifier 创建邮件提醒，检查发件人和收件人的地址
+func NewEmailNotifier(opts EmailOptions) (*EmailNotifier, error) {
+	if opts.Host == "" {
+		return nil, errors.New("邮件需要填写 SMTP 服务器 host")
+	}
+	if opts.Port <= 0 {
+		opts.Port = emailSSLPort
+	}
+	if opts.From == "" {
+		opts.From = opts.Username
+	}
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("无效的发件人地址 %q: %v", opts.From, err)
+	}
+	if from.Name == "" {
+		from.Name = "饮食推荐"
+	}
+	to, err := parseAddresses(opts.To)
+	if err != nil {
+		return nil, err
+	}
+	if len(to) == 0 {
+		return nil, errors.New("邮件需要填写收件人 to")
+	}
+	reportTo, err := parseAddresses(opts.ReportTo)
+	if err != nil {
+		return nil, err
+	}
+	if len(reportTo) == 0 {
+		reportTo = to
+	}
+	return &EmailNotifier{opts: opts, from: from, to: to, reportTo: reportTo}, nil
+}
+
+func parseAddresses(list []string) ([]*mail.Address, error) {
+	addrs := make([]*mail.Address, 0, len(list))
+	for _, s := range list {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的收件人地址 %q: %v", s, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// Notify 发送邮件，每周报告发给 ReportTo
+func (e *EmailNotifier) Notify(n Notification) error {
+	to := e.to
+	if n.Report {
+		to = e.reportTo
+	}
+	msg, err := e.message(n, to)
+	if err != nil {
+		return fmt.Errorf("生成邮件失败: %v", err)
+	}
+	if err := e.send(to, msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return nil
+}
+
+// send 连接 SMTP 服务器发送 msg
+func (e *EmailNotifier) send(to []*mail.Address, msg []byte) error {
+	addr := net.JoinHostPort(e.opts.Host, strconv.Itoa(e.opts.Port))
+	dialer := &net.Dialer{Timeout: emailTimeout}
+	tlsConfig := &tls.Config{ServerName: e.opts.Host}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if e.opts.Port == emailSSLPort {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(emailTimeout))
+	c, err := smtp.NewClient(conn, e.opts.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && e.opts.Port != emailSSLPort {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.opts.Username != "" {
+		ok, mechanisms := c.Extension("AUTH")
+		if !ok {
+			return errors.New("服务器不支持登录（AUTH）")
+		}
+		// 优先 PLAIN，Exchange / Office 365 只支持 LOGIN（两者都只在加密连接上发送密码）
+		var auth smtp.Auth = smtp.PlainAuth("", e.opts.Username, e.opts.Password, e.opts.Host)
+		if !strings.Contains(" "+strings.ToUpper(mechanisms)+" ", " PLAIN ") && strings.Contains(strings.ToUpper(mechanisms), "LOGIN") {
+			auth = &loginAuth{username: e.opts.Username, password: e.opts.Password, host: e.opts.Host}
+		}
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("登录失败: %v", err)
+		}
+	}
+	if err := c.Mail(e.from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("收件人 %s: %v", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// loginAuth AUTH LOGIN 登录方式（net/smtp 只内置了 PLAIN 和 CRAM-MD5）
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("连接未加密，不发送密码")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("服务器地址不匹配")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(string(fromServer))
+	switch {
+	case strings.Contains(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.Contains(prompt, "password"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("无法识别的登录提示: %s", fromServer)
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// message 生成邮件：纯文本和网页两种格式（multipart/alternative），邮件客户端选择能显示的一种
+func (e *EmailNotifier) message(n Notification, to []*mail.Address) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	html, err := emailHTML(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", emailText(n)},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(p.content))
+		qp.Close()
+	}
+	mw.Close()
+
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		recipients = append(recipients, addr.String())
+	}
+	var msg bytes.Buffer
+	header := func(key, value string) {
+		msg.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", e.from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", n.Title))
+	header("Date", n.Time.Format(time.RFC1123Z))
+	header("Message-ID", messageID(e.from.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// messageID 随机的邮件 ID，域名取发件人地址的域名
+func messageID(from string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	domain := "meal-agent"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// emailText 纯文本格式：推荐内容、候选餐厅、天气和回复链接
+func emailText(n Notification) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(n.Text))
+	if len(n.Restaurants) > 0 {
+		sb.WriteString("\n\n候选餐厅：")
+		for i, c := range emailCards(n) {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, c.Name))
+			if c.Details != "" {
+				sb.WriteString("（" + c.Details + "）")
+			}
+			if c.Address != "" {
+				sb.WriteString("\n   " + c.Address)
+			}
+		}
+	}
+	if n.Weather != nil {
+		sb.WriteString("\n\n" + n.Weather.Describe())
+	}
+	if n.ReplyURL != "" {
+		sb.WriteString("\n\n回复：")
+		for _, text := range quickReplies {
+			sb.WriteString(fmt.Sprintf("\n%s %s", text, replyLink(n, text)))
+		}
+	}
+	return sb.String()
+}
+
+// emailCard 邮件中的餐厅卡片
+type emailCard struct {
+	Name    string
+	Details string // 菜系、距离、评分、人均
+	Address string
+	Tel     string
+	MapURL  string
+}
+
+func emailCards(n Notification) []emailCard {
+	restaurants := n.Restaurants
+	if len(restaurants) > emailMaxRestaurant {
+		restaurants = restaurants[:emailMaxRestaurant]
+	}
+	cards := make([]emailCard, 0, len(restaurants))
+	for _, r := range restaurants {
+		var details []string
+		if r.Cuisine != "" {
+			details = append(details, r.Cuisine)
+		}
+		if d := r.GetDistanceInt(); d > 0 {
+			details = append(details, fmt.Sprintf("%d米", d))
+		}
+		if r.WalkMinutes > 0 {
+			details = append(details, fmt.Sprintf("步行%d分钟", r.WalkMinutes))
+		}
+		if rating := r.GetRatingFloat(); rating > 0 {
+			details = append(details, fmt.Sprintf("⭐%.1f", rating))
+		}
+		if cost := r.GetCostFloat(); cost > 0 {
+			details = append(details, fmt.Sprintf("人均%.0f元", cost))
+		}
+		cards = append(cards, emailCard{Name: r.Name, Details: strings.Join(details, " · "), Address: r.Address, Tel: r.Tel, MapURL: r.MapURL()})
+	}
+	return cards
+}
+
+// emailTemplate 网页格式：样式写在标签上（很多邮件客户端不支持 <style>）
+var emailTemplate = template.Must(template.New("email").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"></head>
+<body style="margin:0;padding:16px;background:#f5f5f5;font-family:-apple-system,'PingFang SC','Microsoft YaHei',sans-serif;color:#333">
+<div style="max-width:600px;margin:0 auto;background:#fff;border-radius:8px;padding:20px">
+<h2 style="margin:0 0 12px;font-size:20px">{{.Title}}</h2>
+{{- with .Weather}}
+<p style="margin:0 0 12px;color:#666;font-size:14px">🌤️ {{.}}</p>
+{{- end}}
+{{- range .Paragraphs}}
+<p style="margin:0 0 10px;line-height:1.6">{{range $i, $line := .}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+{{- end}}
+{{- range $i, $c := .Cards}}
+<div style="border:1px solid #eee;border-radius:8px;padding:12px;margin:10px 0">
+<div style="font-size:16px;font-weight:bold">{{inc $i}}. {{$c.Name}}</div>
+{{- if $c.Details}}
+<div style="color:#666;font-size:13px;margin-top:4px">{{$c.Details}}</div>
+{{- end}}
+{{- if $c.Address}}
+<div style="color:#666;font-size:13px;margin-top:4px">📍 {{$c.Address}}</div>
+{{- end}}
+{{- if or $c.Tel $c.MapURL}}
+<div style="font-size:13px;margin-top:6px">{{if $c.Tel}}📞 <a href="tel:{{$c.Tel}}">{{$c.Tel}}</a> {{end}}{{if $c.MapURL}}<a href="{{$c.MapURL}}">查看地图</a>{{end}}</div>
+{{- end}}
+</div>
+{{- end}}
+{{- if .Replies}}
+<p style="margin:16px 0 0">{{range .Replies}}<a href="{{.URL}}" style="display:inline-block;margin:0 8px 8px 0;padding:6px 14px;border-radius:16px;background:#ff7a45;color:#fff;text-decoration:none;font-size:14px">{{.Text}}</a>{{end}}</p>
+{{- end}}
+<p style="margin:16px 0 0;color:#aaa;font-size:12px">{{.Time}} · meal-agent</p>
+</div>
+</body></html>
+`))
+
+// emailHTML 网页格式的邮件正文，文字按空行分段
+func emailHTML(n Notification) (string, error) {
+	type reply struct{ Text, URL string }
+	data := struct {
+		Title      string
+		Weather    string
+		Paragraphs [][]string
+		Cards      []emailCard
+		Replies    []reply
+		Time       string
+	}{
+		Title: n.Title,
+		Cards: emailCards(n),
+		Time:  n.Time.Format("2006-01-02 15:04"),
+	}
+	if n.Weather != nil {
+		data.Weather = n.Weather.Describe()
+	}
+	for _, p := range strings.Split(strings.TrimSpace(n.Text), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			data.Paragraphs = append(data.Paragraphs, strings.Split(p, "\n"))
+		}
+	}
+	if n.ReplyURL != "" {
+		for _, text := range quickReplies {
+			data.Replies = append(data.Replies, reply{text, replyLink(n, text)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := emailTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"meal-agent/memory"
+)
+
+// haStateLimit Home Assistant 实体状态的最大长度
+const haStateLimit = 255
+
+// HomeAssistantHandler 提供给 Home Assistant（RESTful 传感器、rest_command）的接口，厨房的仪表盘上显示今天吃什么
+// recommendation 返回今天最近一次推荐，lastMeal 返回最近一次用餐记录，recommend 立即推荐并推送
+// 接口都需要 Authorization: Bearer <token>，返回 {"state": ..., 其他字段作为属性}
+//
+//	GET  /ha/recommendation    今天的推荐，state 为排在第一的餐厅（还没推荐时为"暂无"）
+//	GET  /ha/last_meal         最近一餐，state 为餐厅名称
+//	POST /ha/recommend         立即推荐并推送，可以带 ?meal_type=lunch 或 {"meal_type": "dinner"}，返回同 /ha/recommendation
+func HomeAssistantHandler(token string, recommendation func() (Notification, bool), lastMeal func() (memory.MealRecord, bool),
+	recommend func(mealType string) (Notification, error)) (http.Handler, error) {
+	if token == "" {
+		return nil, errors.New("Home Assistant 接口需要填写 token")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ha/recommendation", func(w http.ResponseWriter, r *http.Request) {
+		n, ok := recommendation()
+		if !ok {
+			writeHAState(w, map[string]interface{}{"state": "暂无"})
+			return
+		}
+		writeHAState(w, recommendationState(n))
+	})
+	mux.HandleFunc("/ha/last_meal", func(w http.ResponseWriter, r *http.Request) {
+		m, ok := lastMeal()
+		if !ok {
+			writeHAState(w, map[string]interface{}{"state": "暂无"})
+			return
+		}
+		writeHAState(w, map[string]interface{}{
+			"state":      summary(m.Restaurant, haStateLimit),
+			"date":       m.Date,
+			"time":       m.Timestamp().Format(time.RFC3339),
+			"meal_type":  m.MealType,
+			"category":   m.Category,
+			"rating":     m.Rating,
+			"amount":     m.Amount,
+			"note":       m.Note,
+			"days_since": int(time.Since(m.Timestamp()).Hours() / 24),
+		})
+	})
+	mux.HandleFunc("/ha/recommend", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "请使用 POST", http.StatusMethodNotAllowed)
+			return
+		}
+		mealType := r.URL.Query().Get("meal_type")
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			var body struct {
+				MealType string `json:"meal_type"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.MealType != "" {
+				mealType = body.MealType
+			}
+		}
+		if mealType != "" && mealType != "lunch" && mealType != "dinner" {
+			http.Error(w, "meal_type 应为 lunch 或 dinner", http.StatusBadRequest)
+			return
+		}
+		n, err := recommend(mealType)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeHAState(w, recommendationState(n))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			http.Error(w, "未授权", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// recommendationState 推荐的传感器数据，text 为完整的推荐内容（仪表盘用 Markdown 卡片显示）
+func recommendationState(n Notification) map[string]interface{} {
+	state := map[string]interface{}{
+		"state":     "暂无",
+		"meal_type": n.MealType,
+		"title":     n.Title,
+		"text":      n.Text,
+		"time":      n.Time.Format(time.RFC3339),
+	}
+	names := make([]string, 0, len(n.Restaurants))
+	for i, r := range n.Restaurants {
+		if i >= botMaxRestaurants {
+			break
+		}
+		names = append(names, r.Name)
+	}
+	if len(names) > 0 {
+		state["state"] = summary(names[0], haStateLimit)
+	}
+	state["restaurants"] = names
+	if n.Weather != nil {
+		state["weather"] = n.Weather.Text
+		state["temperature"] = n.Weather.Temp
+	}
+	return state
+}
+
+func writeHAState(w http.ResponseWriter, state map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(state)
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"meal-agent/tools"
+)
+
+// Notification 推送的提醒，各推送方式按需要取用其中的字段
+type Notification struct {
+	MealType    string             `json:"meal_type"`             // lunch / dinner
+	Title       string             `json:"title"`                 // 如 "🍽️ 午餐时间到！"
+	Text        string             `json:"text"`                  // 推荐内容
+	Restaurants []tools.Restaurant `json:"restaurants,omitempty"` // 推荐的候选餐厅（按排序先后）
+	Weather     *tools.WeatherInfo `json:"weather,omitempty"`     // 用餐时间的天气（获取失败时为空）
+	Time        time.Time          `json:"time"`
+	Failed      bool               `json:"failed,omitempty"`    // 获取推荐失败，Text 为错误信息
+	Token       string             `json:"token,omitempty"`     // 回复令牌，回复时带上它交给产生这条推荐的对话
+	ReplyURL    string             `json:"reply_url,omitempty"` // 回复地址（已带 token，加上 &text= 即可回复；未开启回复时为空）
+	Report      bool               `json:"report,omitempty"`    // 每周报告（不是用餐提醒，MealType 为空）
+}
+
+// Notifier 提醒的推送方式
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// ConsoleNotifier 输出到终端
+type ConsoleNotifier struct {
+	out io.Writer
+	// Render 输出前处理内容（如终端中渲染 Markdown），为空时原样输出
+	Render func(string) string
+}
+
+// NewConsoleNotifier 创建输出到 out 的提醒
+func NewConsoleNotifier(out io.Writer) *ConsoleNotifier {
+	return &ConsoleNotifier{out: out}
+}
+
+// Notify 输出标题和内容，以分隔线结尾
+func (c *ConsoleNotifier) Notify(n Notification) error {
+	text := n.Text
+	if c.Render != nil {
+		text = c.Render(text)
+	}
+	_, err := fmt.Fprintf(c.out, "\n%s\n\n%s\n\n---\n", n.Title, text)
+	return err
+}
+
+// Multi 同时推送给多个 Notifier，某个失败不影响其他的，返回所有失败的错误
+type Multi []Notifier
+
+// Notify 推送给所有 Notifier
+func (m Multi) Notify(n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// summary 截取前 limit 个字符用于通知内容（系统通知、群机器人显示不下长文本），多余部分用省略号代替
+func summary(message string, limit int) string {
+	message = strings.TrimSpace(message)
+	runes := []rune(message)
+	if len(runes) <= limit {
+		return message
+	}
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
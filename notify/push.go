@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// 各推送服务的默认地址
+const (
+	defaultBarkServer = "https://api.day.app"
+	defaultNtfyServer = "https://ntfy.sh"
+)
+
+// Server酱³ 的 SendKey 以 sctp{uid}t 开头，使用单独的推送地址
+var serverChan3Key = regexp.MustCompile(`^sctp(\d+)t`)
+
+// PushNotifier 推送到手机：Server酱（微信）、Bark（iOS）或 ntfy
+type PushNotifier struct {
+	kind   string // serverchan / bark / ntfy
+	server string // Bark、ntfy 的服务地址（自建服务时填写）
+	key    string // Server酱 SendKey / Bark 设备 Key
+	topic  string // ntfy 主题
+	token  string // ntfy 访问令牌（可选）
+	client *http.Client
+}
+
+// NewPushNotifier 创建手机推送：serverchan 需要 key（SendKey）；bark 需要 key（设备 Key）；
+// ntfy 需要 topic，受保护的主题填写 token；server 留空时使用官方服务
+func NewPushNotifier(kind, server, key, topic, token string) (*PushNotifier, error) {
+	switch kind {
+	case "serverchan", "bark":
+		if key == "" {
+			return nil, fmt.Errorf("%s 推送需要填写 key", kind)
+		}
+	case "ntfy":
+		if topic == "" {
+			return nil, fmt.Errorf("ntfy 推送需要填写 topic")
+		}
+	default:
+		return nil, fmt.Errorf("未知的推送类型: %s（可用 serverchan / bark / ntfy）", kind)
+	}
+	if server == "" {
+		server = map[string]string{"bark": defaultBarkServer, "ntfy": defaultNtfyServer}[kind]
+	}
+	return &PushNotifier{
+		kind:   kind,
+		server: strings.TrimRight(server, "/"),
+		key:    key,
+		topic:  topic,
+		token:  token,
+		client: &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}, nil
+}
+
+// Notify 推送标题和消息正文
+func (p *PushNotifier) Notify(n Notification) error {
+	body := messageBody(n)
+
+	var (
+		endpoint string
+		header   map[string]string
+		payload  interface{}
+	)
+	switch p.kind {
+	case "serverchan":
+		endpoint = "https://sctapi.ftqq.com/" + p.key + ".send"
+		if m := serverChan3Key.FindStringSubmatch(p.key); m != nil {
+			endpoint = fmt.Sprintf("https://%s.push.ft07.com/send/%s.send", m[1], p.key)
+		}
+		// desp 按 markdown 显示，换行需要空行
+		payload = map[string]string{"title": n.Title, "desp": strings.ReplaceAll(body, "\n", "\n\n")}
+	case "bark":
+		endpoint = p.server + "/push"
+		payload = map[string]string{"device_key": p.key, "title": n.Title, "body": body, "group": "meal-agent"}
+	case "ntfy":
+		// 发到服务根地址的 JSON 格式支持中文标题（请求头中的 Title 只支持 ASCII）
+		endpoint = p.server
+		if p.token != "" {
+			header = map[string]string{"Authorization": "Bearer " + p.token}
+		}
+		msg := map[string]interface{}{"topic": p.topic, "title": n.Title, "message": body, "tags": []string{"fork_and_knife"}}
+		if n.ReplyURL != "" && len(n.Restaurants) > 0 {
+			msg["actions"] = ntfyActions(n)
+		}
+		payload = msg
+	}
+
+	resp, err := postJSON(p.client, endpoint, header, payload)
+	if err != nil {
+		return fmt.Errorf("%s 推送失败: %v", p.kind, err)
+	}
+	return checkPushResponse(p.kind, resp)
+}
+
+// ntfyActions 快捷回复按钮，点击后 POST 到回复地址
+func ntfyActions(n Notification) []map[string]interface{} {
+	var actions []map[string]interface{}
+	for _, text := range quickReplies {
+		actions = append(actions, map[string]interface{}{
+			"action": "http", "label": text, "url": replyLink(n, text), "method": "POST", "clear": true,
+		})
+	}
+	return actions
+}
+
+// checkPushResponse Server酱、Bark 出错时也可能返回 200，需要检查返回的状态码
+func checkPushResponse(kind string, body []byte) error {
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if kind == "ntfy" || json.Unmarshal(body, &result) != nil {
+		return nil
+	}
+	// Server酱成功时 code 为 0，Bark 为 200
+	if (kind == "serverchan" && result.Code != 0) || (kind == "bark" && result.Code != 200) {
+		return fmt.Errorf("%s 推送失败: %d %s", kind, result.Code, result.Message)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// quickReplies 通知中附带的快捷回复（ntfy 最多 3 个按钮）
+var quickReplies = []string{"第一个", "第二个", "换一批"}
+
+// ErrReplyExpired 回复的不是最近一次推荐（对话上下文已经换成了新的推荐）
+var ErrReplyExpired = errors.New("这条推荐已过期，请回复最新的提醒")
+
+// NewReplyToken 生成一条推荐的回复令牌
+func NewReplyToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ReplyURL 回复地址，base 为回复服务对外的地址（如 http://192.168.1.10:8787）
+func ReplyURL(base, token string) string {
+	if base == "" || token == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/reply?token=" + url.QueryEscape(token)
+}
+
+// replyLink 回复 text 的链接
+func replyLink(n Notification, text string) string {
+	return n.ReplyURL + "&text=" + url.QueryEscape(text)
+}
+
+// ReplyHandler 接收通知中的回复（ntfy 按钮、机器人消息中的链接或自定义集成），交给 reply 处理，返回回复的内容
+//
+//	GET/POST /reply?token=...&text=第二个
+//	POST /reply {"token": "...", "text": "换一批"}
+func ReplyHandler(reply func(token, text string) (string, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reply", func(w http.ResponseWriter, r *http.Request) {
+		token, text := r.FormValue("token"), r.FormValue("text")
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			var body struct {
+				Token string `json:"token"`
+				Text  string `json:"text"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "无效的 JSON", http.StatusBadRequest)
+				return
+			}
+			token, text = body.Token, body.Text
+		}
+		if token == "" || strings.TrimSpace(text) == "" {
+			http.Error(w, "需要 token 和 text", http.StatusBadRequest)
+			return
+		}
+
+		result, err := reply(token, strings.TrimSpace(text))
+		switch {
+		case errors.Is(err, ErrReplyExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(result))
+	})
+	return mux
+}
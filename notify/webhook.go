@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"meal-agent/network"
+)
+
+// webhookTimeout 推送请求的超时时间
+const webhookTimeout = 10 * time.Second
+
+// transport 推送不经过 http.DefaultTransport：后台模式退出时会取消其他外部接口的请求（替换 http.DefaultTransport），
+// 推送不受影响，退出前仍能把提醒发出去
+var transport = network.Separate(network.Notify)
+
+// WebhookNotifier POST 提醒到自定义地址（Home Assistant、n8n 或自己的服务）
+// 默认发送完整提醒的 JSON，也可以用模板自定义请求体；设置密钥时附带 HMAC-SHA256 签名
+//
+//	POST {url}
+//	{"meal_type": "lunch", "title": "...", "text": "...", "restaurants": [...], "weather": {...}, "time": "..."}
+type WebhookNotifier struct {
+	url      string
+	secret   string
+	template *template.Template // 为 nil 时发送完整提醒的 JSON
+	headers  map[string]string
+	client   *http.Client
+}
+
+// 签名的请求头：签名为 HMAC-SHA256(secret, 时间戳 + "." + 请求体) 的十六进制，接收方据此校验来源和防止重放
+const (
+	webhookTimestampHeader = "X-Meal-Agent-Timestamp"
+	webhookSignatureHeader = "X-Meal-Agent-Signature" // "sha256=..."
+)
+
+// webhookFuncs 请求体模板中可以使用的函数：json 把值转成 JSON（字符串会加上引号并转义）
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// NewWebhookNotifier 创建 webhook 推送，body 为请求体模板（Go text/template，数据为 Notification，留空发送 JSON），
+// secret 不为空时附带签名，headers 为额外的请求头（可以覆盖 Content-Type）
+func NewWebhookNotifier(url, body, secret string, headers map[string]string) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook 需要填写 url")
+	}
+	w := &WebhookNotifier{
+		url:     url,
+		secret:  secret,
+		headers: headers,
+		client:  &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}
+	if body != "" {
+		tmpl, err := template.New("webhook").Funcs(webhookFuncs).Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("webhook 模板有误: %v", err)
+		}
+		w.template = tmpl
+	}
+	return w, nil
+}
+
+// Notify 推送提醒，返回非 2xx 时视为失败
+func (w *WebhookNotifier) Notify(n Notification) error {
+	var (
+		body []byte
+		err  error
+	)
+	if w.template != nil {
+		var buf bytes.Buffer
+		err = w.template.Execute(&buf, n)
+		body = buf.Bytes()
+	} else {
+		body, err = json.Marshal(n)
+	}
+	if err != nil {
+		return fmt.Errorf("webhook 请求体生成失败: %v", err)
+	}
+
+	header := map[string]string{"Content-Type": "application/json"}
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header[webhookTimestampHeader] = timestamp
+		header[webhookSignatureHeader] = "sha256=" + WebhookSignature(w.secret, timestamp, body)
+	}
+	for k, v := range w.headers {
+		header[k] = v
+	}
+	if _, err := post(w.client, w.url, header, body); err != nil {
+		return fmt.Errorf("webhook 推送失败: %v", err)
+	}
+	return nil
+}
+
+// WebhookSignature webhook 的签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON POST JSON 请求（附带 header 中的请求头），返回响应内容
+func postJSON(client *http.Client, url string, header map[string]string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	h := map[string]string{"Content-Type": "application/json"}
+	for k, v := range header {
+		h[k] = v
+	}
+	return post(client, url, h, data)
+}
+
+// post POST 请求体（请求头由 header 指定），返回响应内容，非 2xx 时返回错误
+func post(client *http.Client, url string, header map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s", resp.Status, summary(string(data), 200))
+	}
+	return data, nil
+}
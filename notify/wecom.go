@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// wecomRecentMessages 记住最近处理过的消息数，企业微信重试推送同一条消息时跳过
+const wecomRecentMessages = 100
+
+// WecomMessage 企业微信群机器人收到的消息（群里 @机器人 或单聊机器人）
+type WecomMessage struct {
+	ID         string // 消息 ID
+	ChatID     string // 群聊 ID
+	User       string // 发消息的人（姓名，没有时为账号）
+	Text       string // 消息内容，已去掉开头的 @机器人
+	WebhookURL string // 回复到这个群的 webhook 地址
+}
+
+// wecomCrypto 企业微信回调消息的签名校验和加解密（Token + EncodingAESKey）
+type wecomCrypto struct {
+	token string
+	key   []byte // EncodingAESKey 解码后的 32 字节 AES 密钥，前 16 字节为 IV
+}
+
+func newWecomCrypto(token, aesKey string) (*wecomCrypto, error) {
+	if token == "" || aesKey == "" {
+		return nil, fmt.Errorf("企业微信机器人回调需要填写 token 和 aes_key")
+	}
+	key, err := base64.StdEncoding.DecodeString(aesKey + "=")
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("无效的企业微信 aes_key（应为 43 位的 EncodingAESKey）")
+	}
+	return &wecomCrypto{token: token, key: key}, nil
+}
+
+// signature 对 token、时间戳、随机数和密文排序后拼接的 SHA1
+func (c *wecomCrypto) signature(timestamp, nonce, encrypted string) string {
+	parts := []string{c.token, timestamp, nonce, encrypted}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// decrypt 校验签名并解密：明文为 16 字节随机数 + 4 字节长度 + 消息 + 接收方 ID
+func (c *wecomCrypto) decrypt(signature, timestamp, nonce, encrypted string) ([]byte, error) {
+	if c.signature(timestamp, nonce, encrypted) != signature {
+		return nil, errors.New("签名校验失败")
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("无效的密文")
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCDecrypter(block, c.key[:aes.BlockSize]).CryptBlocks(data, data)
+
+	// PKCS#7 补位（企业微信按 32 字节补位）
+	pad := int(data[len(data)-1])
+	if pad < 1 || pad > 32 || pad > len(data) {
+		return nil, errors.New("无效的补位")
+	}
+	data = data[:len(data)-pad]
+	if len(data) < 20 {
+		return nil, errors.New("消息长度不足")
+	}
+	size := int(binary.BigEndian.Uint32(data[16:20]))
+	if size > len(data)-20 {
+		return nil, errors.New("消息长度不符")
+	}
+	return data[20 : 20+size], nil
+}
+
+// wecomCallback 回调消息：外层只有密文，解密后为群机器人消息
+type wecomCallback struct {
+	Encrypt string `xml:"Encrypt"`
+}
+
+type wecomBotMessage struct {
+	WebhookURL string `xml:"WebhookUrl"`
+	ChatID     string `xml:"ChatId"`
+	MsgType    string `xml:"MsgType"`
+	MsgID      string `xml:"MsgId"`
+	From       struct {
+		UserID string `xml:"UserId"`
+		Name   string `xml:"Name"`
+	} `xml:"From"`
+	Text struct {
+		Content string `xml:"Content"`
+	} `xml:"Text"`
+}
+
+// WecomHandler 接收企业微信群机器人的回调（机器人设置中的"接收消息"），token、aesKey 为回调配置中的 Token 和 EncodingAESKey
+// 文字消息交给 chat 处理，返回的通知发回消息所在的群；处理在后台进行，失败时交给 onError
+//
+//	GET  /wecom?msg_signature=&timestamp=&nonce=&echostr=  验证回调地址
+//	POST /wecom?msg_signature=&timestamp=&nonce=           接收消息
+func WecomHandler(token, aesKey string, chat func(msg WecomMessage) (Notification, error), onError func(error)) (http.Handler, error) {
+	crypto, err := newWecomCrypto(token, aesKey)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu     sync.Mutex
+		recent []string
+	)
+	// seen 是否已经处理过这条消息（企业微信没及时收到响应时会重试）
+	seen := func(id string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range recent {
+			if r == id {
+				return true
+			}
+		}
+		recent = append(recent, id)
+		if len(recent) > wecomRecentMessages {
+			recent = recent[1:]
+		}
+		return false
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wecom", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		signature, timestamp, nonce := q.Get("msg_signature"), q.Get("timestamp"), q.Get("nonce")
+
+		if r.Method == http.MethodGet {
+			echo, err := crypto.decrypt(signature, timestamp, nonce, q.Get("echostr"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			w.Write(echo)
+			return
+		}
+
+		var callback wecomCallback
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err == nil {
+			err = xml.Unmarshal(body, &callback)
+		}
+		if err != nil {
+			http.Error(w, "无效的消息", http.StatusBadRequest)
+			return
+		}
+		plain, err := crypto.decrypt(signature, timestamp, nonce, callback.Encrypt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		var m wecomBotMessage
+		if err := xml.Unmarshal(plain, &m); err != nil {
+			http.Error(w, "无效的消息", http.StatusBadRequest)
+			return
+		}
+
+		// 只处理文字消息；立即返回空响应（不被动回复），回复的内容通过 webhook 发回群里
+		msg := WecomMessage{
+			ID:         m.MsgID,
+			ChatID:     m.ChatID,
+			User:       m.From.Name,
+			Text:       trimMentions(m.Text.Content),
+			WebhookURL: m.WebhookURL,
+		}
+		if msg.User == "" {
+			msg.User = m.From.UserID
+		}
+		w.WriteHeader(http.StatusOK)
+		if m.MsgType != "text" || msg.Text == "" || msg.WebhookURL == "" || (msg.ID != "" && seen(msg.ID)) {
+			return
+		}
+		go func() {
+			if err := replyWecom(msg, chat); err != nil && onError != nil {
+				onError(err)
+			}
+		}()
+	})
+	return mux, nil
+}
+
+// replyWecom 处理群里的消息，把回复发回这个群
+func replyWecom(msg WecomMessage, chat func(msg WecomMessage) (Notification, error)) error {
+	bot, err := NewBotNotifier("wecom", msg.WebhookURL, "", "", "")
+	if err != nil {
+		return err
+	}
+	n, err := chat(msg)
+	if err != nil {
+		n = Notification{Title: "💬 " + msg.Text, Text: "出错了: " + err.Error(), Failed: true}
+	}
+	return bot.Notify(n)
+}
+
+// trimMentions 去掉消息开头的 @机器人（企业微信在名字后面加空格或 U+2005）
+func trimMentions(text string) string {
+	text = strings.TrimSpace(text)
+	for strings.HasPrefix(text, "@") {
+		i := strings.IndexFunc(text, unicode.IsSpace)
+		if i < 0 {
+			return ""
+		}
+		text = strings.TrimLeftFunc(text[i:], unicode.IsSpace)
+	}
+	return text
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"meal-agent/agent"
+	"meal-agent/memory"
+	"meal-agent/tools"
+	"meal-agent/tui"
+)
+
+// outputFormat 全局选项 --output：text（默认）/ json / yaml
+// recommend、ask、cook、history、stats 支持 json 和 yaml，字段名以下面类型的 json 标签为准，yaml 与 json 相同
+type outputFormat string
+
+const outputUsage = "`format`: text / json / yaml（recommend、ask、cook、history、stats 支持 json 和 yaml）"
+
+func (f *outputFormat) String() string { return string(*f) }
+
+func (f *outputFormat) Set(s string) error {
+	switch s {
+	case "text", "json", "yaml":
+		*f = outputFormat(s)
+		return nil
+	}
+	return fmt.Errorf("可用 text / json / yaml")
+}
+
+// jsonFlag 全局选项 --json，同 --output json（方便在快捷指令、Raycast / Alfred 中调用）
+type jsonFlag struct {
+	output *outputFormat
+}
+
+func (f jsonFlag) String() string { return "" }
+
+func (f jsonFlag) IsBoolFlag() bool { return true }
+
+func (f jsonFlag) Set(s string) error {
+	if s == "true" {
+		*f.output = "json"
+	}
+	return nil
+}
+
+// recommendOutput recommend 的结构化输出
+type recommendOutput struct {
+	MealType    string             `json:"meal_type"`         // lunch / dinner
+	Request     string             `json:"request,omitempty"` // 推荐时的要求（如"不要辣"）
+	Reply       string             `json:"reply"`             // 推荐的文字
+	Weather     *tools.WeatherInfo `json:"weather,omitempty"` // 用餐时间的天气（获取失败时没有）
+	Restaurants []restaurantOutput `json:"restaurants"`       // 排在前面的候选餐厅，按推荐先后
+}
+
+// askOutput ask 的结构化输出
+type askOutput struct {
+	Question    string             `json:"question"`    // 问的话
+	Reply       string             `json:"reply"`       // 回复的文字
+	Restaurants []restaurantOutput `json:"restaurants"` // 回复中推荐了餐厅时排在前面的候选餐厅，没有推荐时为空
+}
+
+// cookOutput cook 的结构化输出
+type cookOutput struct {
+	MealType string             `json:"meal_type"`         // lunch / dinner
+	Request  string             `json:"request,omitempty"` // 想吃的（如"鱼"）
+	Reply    string             `json:"reply"`             // 推荐的文字
+	Weather  *tools.WeatherInfo `json:"weather,omitempty"` // 用餐时间的天气（获取失败时没有）
+	Recipes  []agent.Recipe     `json:"recipes"`           // 推荐的菜谱和购物清单（模型没有按格式回复时为空）
+}
+
+// restaurantOutput 候选餐厅，字段与 MCP 的 search_restaurants 一致
+type restaurantOutput struct {
+	Rank       int     `json:"rank"` // 从 1 开始
+	ID         string  `json:"id,omitempty"`
+	Name       string  `json:"name"`
+	Cuisine    string  `json:"cuisine,omitempty"`
+	Distance   int     `json:"distance_m,omitempty"`
+	Walk       int     `json:"walk_minutes,omitempty"`
+	Rating     float64 `json:"rating,omitempty"`
+	Cost       float64 `json:"cost_per_person,omitempty"`
+	OpenStatus string  `json:"open_status,omitempty"` // open / closing_soon / closed
+	OpenTime   string  `json:"open_time,omitempty"`
+	Address    string  `json:"address,omitempty"`
+	Tel        string  `json:"tel,omitempty"`
+	MapURL     string  `json:"map_url,omitempty"` // 高德地图链接
+
+	HealthWarnings []string `json:"health_warnings,omitempty"` // 健康状况相关的提醒（配置了 health 时）
+}
+
+// historyOutput history list / search 的结构化输出
+type historyOutput struct {
+	Records []memory.MealRecord `json:"records"` // 字段同 history.json，最近的在前
+	Total   int                 `json:"total"`   // 符合条件的记录数（list 时为全部记录数）
+}
+
+func newRestaurantOutputs(restaurants []tools.Restaurant) []restaurantOutput {
+	list := make([]restaurantOutput, 0, len(restaurants))
+	for i, r := range restaurants {
+		list = append(list, restaurantOutput{
+			Rank:       i + 1,
+			ID:         r.ID,
+			Name:       r.Name,
+			Cuisine:    r.Cuisine,
+			Distance:   r.GetDistanceInt(),
+			Walk:       r.WalkMinutes,
+			Rating:     r.GetRatingFloat(),
+			Cost:       r.GetCostFloat(),
+			OpenStatus: r.OpenStatus.Code(),
+			OpenTime:   r.OpenTime,
+			Address:    r.Address,
+			Tel:        r.Tel,
+			MapURL:     r.MapURL(),
+
+			HealthWarnings: r.HealthWarnings,
+		})
+	}
+	return list
+}
+
+// structured 是否输出 json / yaml
+func (o *options) structured() bool {
+	return o.output == "json" || o.output == "yaml"
+}
+
+// stdout 结构化输出时，加载提示、同步结果等其他输出改到标准错误（方便用管道交给 jq），返回数据的输出
+func (o *options) stdout() io.Writer {
+	out := os.Stdout
+	if o.structured() {
+		os.Stdout = os.Stderr
+	}
+	return out
+}
+
+// color 文字输出是否使用颜色：标准输出是终端，且没有 --no-color、NO_COLOR
+func (o *options) color() bool {
+	return !o.structured() && tui.UseColor(os.Stdout, o.noColor)
+}
+
+// writeOutput 按 --output 输出 v，yaml 先编码为 json 再转换，字段名和顺序与 json 相同
+func writeOutput(w io.Writer, format outputFormat, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == "yaml" {
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return err
+		}
+		blockStyle(&node)
+		var b bytes.Buffer
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return err
+		}
+		data = b.Bytes()
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// blockStyle 去掉从 json 解析来的流式、引号样式，按普通的 yaml 输出（需要引号的字符串编码时自动加上）
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
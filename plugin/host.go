@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"meal-agent/agent"
+	"meal-agent/notify"
+)
+
+var (
+	mu      sync.Mutex
+	running = make(map[string]*Plugin)  // 名称 -> 运行中的插件
+	tools   = make(map[string][]string) // 插件名称 -> 注册的工具名称
+)
+
+// Setup 按配置启动插件，把它们提供的工具注册到 agent 中
+// 可以重复调用（重新加载配置）：配置没变的插件继续运行，删除和修改了的插件结束或重新启动
+// 启动失败、工具名称和已有的重复时跳过，返回所有问题
+func Setup(list []Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	wanted := make(map[string]Options, len(list))
+	for _, opts := range list {
+		if opts.Timeout <= 0 {
+			opts.Timeout = defaultTimeout
+		}
+		wanted[opts.Name] = opts
+	}
+	for name, p := range running {
+		if opts, ok := wanted[name]; ok && reflect.DeepEqual(opts, p.opts) {
+			continue
+		}
+		stopLocked(name)
+	}
+
+	var errs []error
+	for _, opts := range list {
+		if _, ok := running[opts.Name]; ok {
+			continue
+		}
+		p, err := Start(opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		running[opts.Name] = p
+		for _, spec := range p.desc.Tools {
+			if _, dup := agent.LookupTool(spec.Name); dup {
+				errs = append(errs, fmt.Errorf("插件 %s 的工具 %s 与已有的工具重名，跳过", opts.Name, spec.Name))
+				continue
+			}
+			if err := register(&pluginTool{plugin: p, spec: spec}); err != nil {
+				errs = append(errs, fmt.Errorf("插件 %s: %v", opts.Name, err))
+				continue
+			}
+			tools[opts.Name] = append(tools[opts.Name], spec.Name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// register 注册工具，名称不合法时返回错误而不是 panic
+func register(t agent.Tool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	agent.RegisterTool(t)
+	return nil
+}
+
+// Shutdown 结束所有插件
+func Shutdown() {
+	mu.Lock()
+	defer mu.Unlock()
+	for name := range running {
+		stopLocked(name)
+	}
+}
+
+// stopLocked 结束插件并删除它注册的工具（调用时持有 mu）
+func stopLocked(name string) {
+	for _, tool := range tools[name] {
+		agent.UnregisterTool(tool)
+	}
+	delete(tools, name)
+	running[name].Close()
+	delete(running, name)
+}
+
+// pluginTool 插件提供的工具
+type pluginTool struct {
+	plugin *Plugin
+	spec   ToolSpec
+}
+
+func (t *pluginTool) Name() string        { return t.spec.Name }
+func (t *pluginTool) Description() string { return t.spec.Description }
+
+func (t *pluginTool) Schema() map[string]interface{} {
+	if t.spec.Schema == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return t.spec.Schema
+}
+
+func (t *pluginTool) Execute(a *agent.MealAgent, args json.RawMessage) (string, error) {
+	var result struct {
+		Text string `json:"text"`
+	}
+	err := t.plugin.Call("call_tool", map[string]interface{}{"name": t.spec.Name, "arguments": args}, &result)
+	return result.Text, err
+}
+
+// HasNotifier 是否有接收提醒的插件
+func HasNotifier() bool {
+	return len(notifiers()) > 0
+}
+
+// notifiers 接收提醒的插件，按名称排序
+func notifiers() []*Plugin {
+	mu.Lock()
+	defer mu.Unlock()
+	var list []*Plugin
+	for _, p := range running {
+		if p.desc.Notifier {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].opts.Name < list[j].opts.Name })
+	return list
+}
+
+// Notifier 把提醒发给接收提醒的插件（按推送时运行中的插件，重新加载配置后也有效）
+type Notifier struct{}
+
+// Notify 发给所有接收提醒的插件，某个失败不影响其他的
+func (Notifier) Notify(n notify.Notification) error {
+	var errs []error
+	for _, p := range notifiers() {
+		if err := p.Call("notify", n, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,248 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"meal-agent/logging"
+)
+
+// 外部插件：任意语言写的可执行文件，作为工具（如公司食堂菜单）或提醒方式使用，不需要重新编译
+// 插件启动后一直运行，通过标准输入输出逐行交换 JSON-RPC 2.0 消息，标准错误输出写入日志:
+//
+//	→ {"jsonrpc":"2.0","id":1,"method":"describe","params":{"protocol":1}}
+//	← {"jsonrpc":"2.0","id":1,"result":{"protocol":1,"tools":[{"name":"canteen_menu","description":"...","schema":{...}}],"notifier":true}}
+//	→ {"jsonrpc":"2.0","id":2,"method":"call_tool","params":{"name":"canteen_menu","arguments":{}}}
+//	← {"jsonrpc":"2.0","id":2,"result":{"text":"今天的菜单..."}}
+//	→ {"jsonrpc":"2.0","id":3,"method":"notify","params":{"title":"...","text":"...",...}}
+//	← {"jsonrpc":"2.0","id":3,"result":{}}
+//
+// 失败时返回 {"jsonrpc":"2.0","id":2,"error":{"message":"..."}}；标准输入关闭时插件应退出
+
+// Protocol 协议版本
+const Protocol = 1
+
+// EnvProtocol 启动插件时设置的环境变量，插件可以据此判断是被 meal-agent 启动的
+const EnvProtocol = "MEAL_AGENT_PLUGIN_PROTOCOL"
+
+// defaultTimeout 每次调用的默认超时
+const defaultTimeout = 30 * time.Second
+
+// Options 插件的启动方式
+type Options struct {
+	Name    string        // 名称（日志和错误提示中使用）
+	Command string        // 可执行文件
+	Args    []string      // 参数
+	Timeout time.Duration // 每次调用的超时，0 为 30 秒
+}
+
+// ToolSpec 插件提供的工具
+type ToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+// Description describe 的结果：插件提供的工具，以及是否接收提醒
+type Description struct {
+	Protocol int        `json:"protocol"`
+	Tools    []ToolSpec `json:"tools"`
+	Notifier bool       `json:"notifier"`
+}
+
+// Plugin 一个运行中的插件进程，调用按顺序进行；进程退出或调用超时后下次调用时重新启动
+type Plugin struct {
+	opts Options
+	desc Description
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan []byte   // 标准输出的每一行
+	exited  chan struct{} // 进程退出时关闭
+	stopped chan struct{} // 结束进程时关闭，不再读取输出
+	nextID  int
+}
+
+// Start 启动插件并读取它提供的工具
+func Start(opts Options) (*Plugin, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	p := &Plugin{opts: opts}
+	if err := p.call("describe", map[string]int{"protocol": Protocol}, &p.desc); err != nil {
+		p.Close()
+		return nil, err
+	}
+	if p.desc.Protocol != 0 && p.desc.Protocol != Protocol {
+		p.Close()
+		return nil, fmt.Errorf("插件 %s 的协议版本 %d 不支持（需要 %d）", opts.Name, p.desc.Protocol, Protocol)
+	}
+	return p, nil
+}
+
+// Name 插件名称
+func (p *Plugin) Name() string {
+	return p.opts.Name
+}
+
+// Description 插件提供的工具，以及是否接收提醒
+func (p *Plugin) Description() Description {
+	return p.desc
+}
+
+// Call 调用插件的方法，result 为 nil 时忽略结果
+func (p *Plugin) Call(method string, params, result interface{}) error {
+	return p.call(method, params, result)
+}
+
+// Close 关闭标准输入让插件退出，1 秒后还没退出时结束进程
+func (p *Plugin) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopLocked()
+}
+
+func (p *Plugin) call(method string, params, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.startLocked(); err != nil {
+		return err
+	}
+
+	p.nextID++
+	id := p.nextID
+	req, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	if _, err := p.stdin.Write(append(req, '\n')); err != nil {
+		p.stopLocked()
+		return fmt.Errorf("插件 %s 已退出: %v", p.opts.Name, err)
+	}
+
+	timer := time.NewTimer(p.opts.Timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case line := <-p.lines:
+			if done, err := p.response(line, id, result); done {
+				return err
+			}
+		case <-p.exited:
+			// 退出前输出的响应可能还在缓冲中
+			for len(p.lines) > 0 {
+				if done, err := p.response(<-p.lines, id, result); done {
+					p.stopLocked()
+					return err
+				}
+			}
+			p.stopLocked()
+			return fmt.Errorf("插件 %s 已退出", p.opts.Name)
+		case <-timer.C:
+			// 进程可能卡住了，结束后下次调用重新启动
+			p.stopLocked()
+			return fmt.Errorf("插件 %s 超过 %v 没有响应", p.opts.Name, p.opts.Timeout)
+		}
+	}
+}
+
+// response 处理插件输出的一行，是 id 的响应时 done 为 true
+func (p *Plugin) response(line []byte, id int, result interface{}) (done bool, err error) {
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		logging.Module("plugin").Warn("忽略插件输出的非 JSON 内容", "plugin", p.opts.Name, "line", string(line))
+		return false, nil
+	}
+	if resp.ID != id {
+		return false, nil
+	}
+	if resp.Error != nil {
+		return true, fmt.Errorf("插件 %s: %s", p.opts.Name, resp.Error.Message)
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return true, nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return true, fmt.Errorf("插件 %s 返回的结果格式不对: %v", p.opts.Name, err)
+	}
+	return true, nil
+}
+
+// startLocked 进程没有运行时启动（调用时持有 mu）
+func (p *Plugin) startLocked() error {
+	if p.cmd != nil {
+		return nil
+	}
+	cmd := exec.Command(p.opts.Command, p.opts.Args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", EnvProtocol, Protocol))
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动插件 %s 失败: %v", p.opts.Name, err)
+	}
+
+	lines := make(chan []byte, 16)
+	exited := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-stopped:
+			}
+		}
+		cmd.Wait()
+		close(exited)
+	}()
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logging.Module("plugin").Info(scanner.Text(), "plugin", p.opts.Name)
+		}
+	}()
+
+	p.cmd, p.stdin, p.lines, p.exited, p.stopped = cmd, stdin, lines, exited, stopped
+	return nil
+}
+
+// stopLocked 结束进程（调用时持有 mu）
+func (p *Plugin) stopLocked() {
+	if p.cmd == nil {
+		return
+	}
+	cmd, exited := p.cmd, p.exited
+	close(p.stopped)
+	p.stdin.Close()
+	p.cmd, p.stdin, p.lines, p.exited, p.stopped = nil, nil, nil, nil, nil
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		cmd.Process.Kill()
+	}
+}
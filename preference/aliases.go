@@ -0,0 +1,85 @@
+package preference
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"meal-agent/tools"
+)
+
+// categoryMatcher 一条菜系偏好的匹配规则
+// 配置中写的 type 和 aliases 是明确的写法，优先于内置别名表：
+// 同时配置了 "日料" 和 "寿司" 时，高德类型含 "寿司" 的店按 "寿司" 的权重
+type categoryMatcher struct {
+	weight   int
+	explicit []string // type 和配置的 aliases
+	cuisine  string   // type 对应的规范化菜系（识别不了时为空）
+	builtin  []string // 规范化菜系的内置别名
+}
+
+// indexCategories 按配置顺序构建菜系匹配规则
+func (p *Preferences) indexCategories() {
+	p.categoryIndex = make([]categoryMatcher, 0, len(p.Categories))
+	for _, c := range p.Categories {
+		m := categoryMatcher{weight: c.Weight, explicit: []string{c.Type}}
+		for _, alias := range c.Aliases {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				m.explicit = append(m.explicit, alias)
+			}
+		}
+		if cuisine := tools.CanonicalCuisine(c.Type, ""); tools.IsCanonicalCuisine(c.Type) {
+			m.cuisine = c.Type
+		} else if cuisine != tools.CuisineOther {
+			m.cuisine = cuisine // type 是某个规范化菜系的别名，如 日本料理 -> 日料
+		}
+		m.builtin = tools.CuisineAliases(m.cuisine)
+		p.categoryIndex = append(p.categoryIndex, m)
+	}
+}
+
+// matchCategory 查找餐厅对应的菜系权重：先找 type 或 aliases 与规范化菜系相同、或包含在高德类型中的，
+// 再按规范化菜系和内置别名表匹配；同一级中取配置里靠前的条目
+func (p *Preferences) matchCategory(cuisine, typeStr string) (int, bool) {
+	for _, m := range p.categoryIndex {
+		for _, word := range m.explicit {
+			if word == cuisine || strings.Contains(typeStr, word) {
+				return m.weight, true
+			}
+		}
+	}
+	for _, m := range p.categoryIndex {
+		if m.cuisine == "" {
+			continue
+		}
+		if m.cuisine == cuisine {
+			return m.weight, true
+		}
+		for _, word := range m.builtin {
+			// 单字的别名（如 "面"）容易误匹配 "面包"，只用于推断规范化菜系
+			if utf8.RuneCountInString(word) > 1 && strings.Contains(typeStr, word) {
+				return m.weight, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// addCategoryAliases 给已有的菜系偏好补充别名（跳过重复的）
+func (p *Preferences) addCategoryAliases(category string, aliases []string) {
+	for i := range p.Categories {
+		if p.Categories[i].Type != category {
+			continue
+		}
+		existing := make(map[string]bool)
+		for _, a := range p.Categories[i].Aliases {
+			existing[a] = true
+		}
+		for _, alias := range aliases {
+			if !existing[alias] {
+				existing[alias] = true
+				p.Categories[i].Aliases = append(p.Categories[i].Aliases, alias)
+			}
+		}
+	}
+	p.indexCategories()
+}
@@ -0,0 +1,55 @@
+package preference
+
+import (
+	"strings"
+
+	"meal-agent/tools"
+)
+
+// defaultFavoriteDays 常吃清单默认的间隔天数（每周至少一次）
+const defaultFavoriteDays = 7
+
+// Favorite 常吃清单中的餐厅：保证至少每隔 Days 天推荐一次
+// 与调高权重不同，到期后即使有分数更高的餐厅也会出现在推荐中
+type Favorite struct {
+	Name string `yaml:"name"`
+	Days int    `yaml:"days,omitempty"` // 间隔天数，默认 7
+	Note string `yaml:"note,omitempty"`
+}
+
+// Interval 间隔天数（未配置时为 7）
+func (f Favorite) Interval() int {
+	if f.Days <= 0 {
+		return defaultFavoriteDays
+	}
+	return f.Days
+}
+
+// Matches 餐厅是否属于这一项（按品牌包含匹配，任意分店都算）
+func (f Favorite) Matches(name string) bool {
+	brand := normalizeName(tools.BrandName(f.Name))
+	return brand != "" && strings.Contains(normalizeName(name), brand)
+}
+
+// SetFavorite 把餐厅加入常吃清单，已在清单中时更新间隔
+func (p *Preferences) SetFavorite(name string, days int, note string) {
+	for i, f := range p.Favorites {
+		if normalizeName(f.Name) == normalizeName(name) {
+			p.Favorites[i].Days = days
+			p.Favorites[i].Note = note
+			return
+		}
+	}
+	p.Favorites = append(p.Favorites, Favorite{Name: name, Days: days, Note: note})
+}
+
+// RemoveFavorite 从常吃清单中移除，返回是否找到
+func (p *Preferences) RemoveFavorite(name string) bool {
+	for i, f := range p.Favorites {
+		if f.Matches(name) || normalizeName(f.Name) == normalizeName(name) {
+			p.Favorites = append(p.Favorites[:i], p.Favorites[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
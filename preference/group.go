@@ -0,0 +1,132 @@
+package preference
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"meal-agent/tools"
+)
+
+// Merge 合并几个人的偏好用于一起吃饭
+// 硬性约束取交集：任何一人拉黑（权重为 0）的餐厅和菜系都排除；其余权重取平均（没配置的按 100 计），
+// 时间规则和表达式规则的加减分除以人数后累加，相当于取平均
+func Merge(profiles ...*Preferences) (*Preferences, error) {
+	var members []*Preferences
+	for _, p := range profiles {
+		if p != nil {
+			members = append(members, p)
+		}
+	}
+	merged := newPreferences()
+	if len(members) == 0 {
+		return merged, nil
+	}
+	n := len(members)
+	merged.match = members[0].match
+
+	// 餐厅：按规范化名称合并，ID 取第一个写了 ID 的条目
+	seen := make(map[string]bool)
+	for _, p := range members {
+		for _, r := range p.Restaurants {
+			key := normalizeName(r.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			id := r.ID
+			weights := make([]int, 0, n)
+			for _, other := range members {
+				weights = append(weights, other.GetRestaurantWeightFor(r.ID, r.Name))
+				if id == "" {
+					id = other.restaurantID(r.Name)
+				}
+			}
+			merged.SetRestaurantWeightByID(id, r.Name, averageWeight(weights), "")
+		}
+	}
+
+	// 菜系：按类型精确合并
+	seenTypes := make(map[string]bool)
+	for _, p := range members {
+		for _, c := range p.Categories {
+			if seenTypes[c.Type] {
+				continue
+			}
+			seenTypes[c.Type] = true
+
+			weights := make([]int, 0, n)
+			for _, other := range members {
+				weight, ok := other.categoryMap[c.Type]
+				if !ok {
+					weight = 100
+				}
+				weights = append(weights, weight)
+			}
+			merged.SetCategoryWeight(c.Type, averageWeight(weights), "")
+		}
+	}
+	for _, p := range members {
+		for _, c := range p.Categories {
+			merged.addCategoryAliases(c.Type, c.Aliases)
+		}
+	}
+
+	// 口味：各项取平均
+	for _, p := range members {
+		merged.Taste.Spicy += p.Taste.Spicy
+		merged.Taste.Oily += p.Taste.Oily
+		merged.Taste.Light += p.Taste.Light
+	}
+	merged.Taste.Spicy /= n
+	merged.Taste.Oily /= n
+	merged.Taste.Light /= n
+
+	// 规则：加减分按人数平均
+	for _, p := range members {
+		for _, r := range p.Rules {
+			r.Boost /= n
+			merged.Rules = append(merged.Rules, r)
+		}
+		merged.Overrides = append(merged.Overrides, p.Overrides...)
+		merged.TasteOverrides = append(merged.TasteOverrides, p.TasteOverrides...)
+		for _, r := range p.WeightRules {
+			boost, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(r.Then), "+"))
+			if err != nil {
+				continue // 加载时已经校验过
+			}
+			r.Then = strconv.Itoa(boost / n)
+			merged.WeightRules = append(merged.WeightRules, r)
+		}
+	}
+	rules, err := tools.NewWeightRuleSet(merged.WeightRules)
+	if err != nil {
+		return nil, fmt.Errorf("合并 weight_rules 失败: %v", err)
+	}
+	merged.weightRules = rules
+
+	return merged, nil
+}
+
+// averageWeight 权重平均值，任何一个为 0（拉黑）时结果为 0
+func averageWeight(weights []int) int {
+	total := 0
+	for _, w := range weights {
+		if w == 0 {
+			return 0
+		}
+		total += w
+	}
+	return total / len(weights)
+}
+
+// restaurantID 按名称查找配置中写的 POI ID
+func (p *Preferences) restaurantID(name string) string {
+	for _, r := range p.Restaurants {
+		if r.ID != "" && normalizeName(r.Name) == normalizeName(name) {
+			return r.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,229 @@
+package preference
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"meal-agent/encryption"
+)
+
+const (
+	maxLearnedAdjustment = 50 // 学到的调整上下限，避免几次评分就压过手写的偏好
+	skipThreshold        = 3  // 推荐了却没选满这么多次才降权
+)
+
+// 每次反馈对权重的基础调整（乘以学习率）
+const (
+	ratingBoost  = 10.0 // 评 5 分
+	ratingDrop   = 10.0 // 评 2 分（评 1 分加倍）
+	choiceBoost  = 2.0  // 从推荐中选中
+	skipDropStep = 5.0  // 连续多次推荐都没选
+)
+
+// Adjustment 某家餐厅学到的权重调整
+type Adjustment struct {
+	ID        string  `json:"id,omitempty"` // 高德 POI ID（有则优先匹配）
+	Name      string  `json:"name"`
+	Delta     float64 `json:"delta"`      // 累计调整（加到偏好权重上）
+	Ratings   int     `json:"ratings"`    // 参与学习的评分次数
+	Chosen    int     `json:"chosen"`     // 从推荐中选中的次数
+	Skips     int     `json:"skips"`      // 推荐了但没选、尚未计入降权的次数
+	UpdatedAt string  `json:"updated_at"` // 最后调整时间
+}
+
+// Weight 取整后的调整值
+func (a Adjustment) Weight() int {
+	return int(math.Round(a.Delta))
+}
+
+// Candidate 推荐过的餐厅
+type Candidate struct {
+	ID   string
+	Name string
+}
+
+// Learner 根据评分和推荐反馈自动调整餐厅权重
+// 学到的调整单独保存，不改动手写的 restaurants.yaml，可以随时查看和清空
+type Learner struct {
+	rate        float64
+	path        string
+	adjustments map[string]*Adjustment // 规范化名称 -> 调整
+	mu          sync.Mutex
+}
+
+// NewLearner 创建或加载学习记录，rate 为学习率（<=0 时使用 1）
+func NewLearner(path string, rate float64) (*Learner, error) {
+	if rate <= 0 {
+		rate = 1
+	}
+	l := &Learner{
+		rate:        rate,
+		path:        path,
+		adjustments: make(map[string]*Adjustment),
+	}
+
+	data, err := encryption.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Adjustment
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("解析学习记录失败: %v", err)
+	}
+	for i := range list {
+		l.adjustments[normalizeName(list[i].Name)] = &list[i]
+	}
+	return l, nil
+}
+
+// ObserveRating 根据评分调整：5 分加权，1~2 分降权（1 分加倍），3~4 分不调整
+func (l *Learner) ObserveRating(id, name string, rating int) error {
+	var delta float64
+	switch {
+	case rating == 5:
+		delta = ratingBoost
+	case rating == 2:
+		delta = -ratingDrop
+	case rating == 1:
+		delta = -2 * ratingDrop
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a := l.entry(id, name)
+	a.Ratings++
+	l.apply(a, delta)
+	return l.save()
+}
+
+// ObserveChoice 用户从推荐中选了 chosen，其他推荐过的餐厅记一次"没选"
+// 同一家累计没选满 skipThreshold 次时轻微降权；被选中时清零
+func (l *Learner) ObserveChoice(chosenID, chosenName string, shown []Candidate) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	chosen := l.entry(chosenID, chosenName)
+	chosen.Chosen++
+	chosen.Skips = 0
+	l.apply(chosen, choiceBoost)
+
+	for _, c := range shown {
+		a := l.entry(c.ID, c.Name)
+		if a == chosen {
+			continue
+		}
+		a.Skips++
+		if a.Skips >= skipThreshold {
+			a.Skips = 0
+			l.apply(a, -skipDropStep)
+		}
+	}
+	return l.save()
+}
+
+// Adjustment 某家餐厅学到的权重调整（没有时为 0），优先按 POI ID 匹配
+func (l *Learner) Adjustment(id, name string) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if id != "" {
+		for _, a := range l.adjustments {
+			if a.ID == id {
+				return a.Weight()
+			}
+		}
+	}
+	if a, ok := l.adjustments[normalizeName(name)]; ok {
+		return a.Weight()
+	}
+	return 0
+}
+
+// List 所有学到的调整，调整幅度大的在前
+func (l *Learner) List() []Adjustment {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]Adjustment, 0, len(l.adjustments))
+	for _, a := range l.adjustments {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if math.Abs(list[i].Delta) != math.Abs(list[j].Delta) {
+			return math.Abs(list[i].Delta) > math.Abs(list[j].Delta)
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Reset 清空某家餐厅学到的调整，name 为空时全部清空，返回清除的条数
+func (l *Learner) Reset(name string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := len(l.adjustments)
+	if name == "" {
+		l.adjustments = make(map[string]*Adjustment)
+	} else if _, ok := l.adjustments[normalizeName(name)]; ok {
+		delete(l.adjustments, normalizeName(name))
+		removed = 1
+	} else {
+		return 0, nil
+	}
+	return removed, l.save()
+}
+
+// entry 取得或创建某家餐厅的调整（调用方持有锁）
+func (l *Learner) entry(id, name string) *Adjustment {
+	if id != "" {
+		for _, a := range l.adjustments {
+			if a.ID == id {
+				return a
+			}
+		}
+	}
+	key := normalizeName(name)
+	a, ok := l.adjustments[key]
+	if !ok {
+		a = &Adjustment{Name: name}
+		l.adjustments[key] = a
+	}
+	if a.ID == "" {
+		a.ID = id
+	}
+	return a
+}
+
+// apply 按学习率累加调整并限制在上下限内（调用方持有锁）
+func (l *Learner) apply(a *Adjustment, delta float64) {
+	a.Delta = math.Max(-maxLearnedAdjustment, math.Min(maxLearnedAdjustment, a.Delta+delta*l.rate))
+	a.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+// save 保存学习记录（调用方持有锁）
+func (l *Learner) save() error {
+	list := make([]Adjustment, 0, len(l.adjustments))
+	for _, a := range l.adjustments {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return encryption.WriteFile(l.path, data, 0644)
+}
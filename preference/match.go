@@ -0,0 +1,105 @@
+package preference
+
+import (
+	"strings"
+
+	"meal-agent/tools"
+)
+
+// MatchOptions 餐厅名称的匹配方式（规范化名称精确匹配之外的补充）
+// 配置中带分店后缀的条目（如 "海底捞(国贸店)"）表示具体某家分店，只精确匹配
+type MatchOptions struct {
+	Brand  bool    // 忽略分店后缀："海底捞" 匹配 "海底捞(国贸店)"
+	Pinyin bool    // 按拼音匹配："haidilao" 匹配 "海底捞"
+	Fuzzy  float64 // 名称相似度阈值（0~1），如 0.8；0 关闭
+}
+
+// SetMatching 设置餐厅名称的匹配方式
+func (p *Preferences) SetMatching(opts MatchOptions) {
+	p.match = opts
+}
+
+// lookupRestaurant 查找餐厅对应的配置权重：POI ID > 规范化名称 > 品牌 > 拼音 > 相似度最高的名称
+func (p *Preferences) lookupRestaurant(id, name string) (int, bool) {
+	if id != "" {
+		if weight, ok := p.idMap[id]; ok {
+			return weight, true
+		}
+	}
+	if weight, ok := p.restaurantMap[normalizeName(name)]; ok {
+		return weight, true
+	}
+	if !p.match.Brand && !p.match.Pinyin && p.match.Fuzzy <= 0 {
+		return 0, false
+	}
+
+	brand := normalizeName(tools.BrandName(name))
+	var brandPinyin string
+	if p.match.Pinyin {
+		brandPinyin = toPinyin(brand)
+	}
+
+	// 按匹配方式的优先级依次查找，同一级中取配置里靠前的条目
+	var pinyinMatch, fuzzyMatch *RestaurantPreference
+	bestRatio := 0.0
+	for i := range p.Restaurants {
+		r := &p.Restaurants[i]
+		if tools.BrandName(r.Name) != strings.TrimSpace(r.Name) {
+			continue // 具体分店只精确匹配
+		}
+		key := normalizeName(r.Name)
+		if p.match.Brand && key == brand {
+			return r.Weight, true
+		}
+		if brandPinyin != "" && pinyinMatch == nil && toPinyin(key) == brandPinyin {
+			pinyinMatch = r
+		}
+		if p.match.Fuzzy > 0 {
+			if ratio := similarity(key, brand); ratio >= p.match.Fuzzy && ratio > bestRatio {
+				bestRatio = ratio
+				fuzzyMatch = r
+			}
+		}
+	}
+	if pinyinMatch != nil {
+		return pinyinMatch.Weight, true
+	}
+	if fuzzyMatch != nil {
+		return fuzzyMatch.Weight, true
+	}
+	return 0, false
+}
+
+// similarity 名称相似度：1 - 编辑距离 / 较长名称的字数
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance 按字计算的编辑距离
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,195 @@
+package preference
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Override 临时偏好：到期前按倍数或固定权重调整某类菜系或餐厅，过期后自动删除
+// 如 "下周减脂，沙拉权重x2，炸鸡为0"
+type Override struct {
+	Target    string `yaml:"target"` // 菜系（规范化菜系或高德类型中的关键词）或餐厅名称
+	Weight    string `yaml:"weight"` // "x2" 表示乘以 2，"0"、"150" 表示直接设为该权重
+	DateRange `yaml:",inline"`
+	Reason    string `yaml:"reason,omitempty"` // 原因，如 "减脂"
+}
+
+// DateRange 临时设置的生效日期范围
+type DateRange struct {
+	From  string `yaml:"from,omitempty"` // 开始日期（留空表示立即生效）
+	Until string `yaml:"until"`          // 截止日期（含当天），如 2024-07-01
+}
+
+// parseWeight 解析权重写法，multiply 为 true 时 value 是倍数，否则是固定权重
+func (o Override) parseWeight() (value float64, multiply bool, err error) {
+	w := strings.TrimSpace(o.Weight)
+	for _, prefix := range []string{"x", "X", "×", "*"} {
+		if strings.HasPrefix(w, prefix) {
+			value, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(w, prefix)), 64)
+			if err != nil || value < 0 {
+				return 0, false, fmt.Errorf("无效的倍数: %s", o.Weight)
+			}
+			return value, true, nil
+		}
+	}
+	n, err := strconv.Atoi(w)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("weight 应为 x2 这样的倍数或 0、150 这样的权重: %s", o.Weight)
+	}
+	return float64(n), false, nil
+}
+
+// expiry 截止日期次日零点（本地时区）
+func (o DateRange) expiry() (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(o.Until), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的截止日期: %s（格式为 2024-07-01）", o.Until)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// start 开始日期零点，未填写时为零值
+func (o DateRange) start() (time.Time, error) {
+	if strings.TrimSpace(o.From) == "" {
+		return time.Time{}, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(o.From), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的开始日期: %s（格式为 2024-06-24）", o.From)
+	}
+	return day, nil
+}
+
+// validate 检查写法，加载偏好配置时调用
+func (o Override) validate() error {
+	if strings.TrimSpace(o.Target) == "" {
+		return fmt.Errorf("需要填写 target")
+	}
+	if _, _, err := o.parseWeight(); err != nil {
+		return err
+	}
+	return o.DateRange.validate()
+}
+
+// validate 检查日期写法
+func (o DateRange) validate() error {
+	end, err := o.expiry()
+	if err != nil {
+		return err
+	}
+	start, err := o.start()
+	if err != nil {
+		return err
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("开始日期 %s 晚于截止日期 %s", o.From, o.Until)
+	}
+	return nil
+}
+
+// activeAt 在 t 时是否生效（已开始且未过期）
+func (o DateRange) activeAt(t time.Time) bool {
+	start, err := o.start()
+	return err == nil && !t.Before(start) && !o.Expired(t)
+}
+
+// Expired 在 now 时是否已过期
+func (o DateRange) Expired(now time.Time) bool {
+	end, err := o.expiry()
+	return err != nil || !now.Before(end)
+}
+
+// matches 是否适用于该餐厅（与时间规则相同：菜系精确匹配或包含在高德类型中，或餐厅名称包含）
+func (o Override) matches(cuisine, typeStr, name string) bool {
+	return o.Target == cuisine || strings.Contains(typeStr, o.Target) ||
+		strings.Contains(normalizeName(name), normalizeName(o.Target))
+}
+
+// Describe 如 "沙拉 x2（减脂，到 2024-07-01）"
+func (o Override) Describe() string {
+	weight := "权重 " + o.Weight
+	if _, multiply, _ := o.parseWeight(); multiply {
+		weight = strings.TrimSpace(o.Weight)
+	} else if o.Weight == "0" {
+		weight = "不推荐"
+	}
+	return fmt.Sprintf("%s %s（%s）", o.Target, weight, o.DateRange.describe(o.Reason))
+}
+
+// describe 如 "减脂，2024-06-24 到 2024-07-01"
+func (o DateRange) describe(reason string) string {
+	detail := "到 " + o.Until
+	if o.From != "" {
+		detail = o.From + " 到 " + o.Until
+	}
+	if reason != "" {
+		detail = reason + "，" + detail
+	}
+	return detail
+}
+
+// ApplyOverrides 按生效中的临时偏好调整权重，多条命中时依次生效
+// excluded 为 true 表示临时偏好把这家餐厅设为 0（排除）
+func (p *Preferences) ApplyOverrides(weight int, now time.Time, cuisine, typeStr, name string) (adjusted int, excluded bool) {
+	for _, o := range p.Overrides {
+		if !o.activeAt(now) || !o.matches(cuisine, typeStr, name) {
+			continue
+		}
+		value, multiply, err := o.parseWeight()
+		if err != nil {
+			continue
+		}
+		if multiply {
+			weight = int(float64(weight) * value)
+		} else {
+			weight = int(value)
+		}
+		if weight == 0 {
+			return 0, true
+		}
+	}
+	return weight, false
+}
+
+// AddOverrides 添加临时偏好，同一目标已有的临时偏好被替换
+func (p *Preferences) AddOverrides(overrides ...Override) {
+	for _, o := range overrides {
+		kept := p.Overrides[:0]
+		for _, existing := range p.Overrides {
+			if existing.Target != o.Target {
+				kept = append(kept, existing)
+			}
+		}
+		p.Overrides = append(kept, o)
+	}
+}
+
+// ActiveOverrides 未过期的临时偏好（包括还没开始的）
+func (p *Preferences) ActiveOverrides(now time.Time) []Override {
+	var active []Override
+	for _, o := range p.Overrides {
+		if !o.Expired(now) {
+			active = append(active, o)
+		}
+	}
+	return active
+}
+
+// PruneExpired 删除已过期的临时偏好和临时口味，返回删除的条数
+func (p *Preferences) PruneExpired(now time.Time) int {
+	active := p.ActiveOverrides(now)
+	activeTastes := p.ActiveTasteOverrides(now)
+	removed := len(p.Overrides) - len(active) + len(p.TasteOverrides) - len(activeTastes)
+	p.Overrides = active
+	p.TasteOverrides = activeTastes
+	return removed
+}
+
+// ClearOverrides 清空所有临时偏好，返回清空的条数
+func (p *Preferences) ClearOverrides() int {
+	n := len(p.Overrides)
+	p.Overrides = nil
+	return n
+}
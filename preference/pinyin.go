@@ -0,0 +1,66 @@
+package preference
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 餐厅名称常用字的拼音（不带声调，多音字取店名中常见的读音）
+// 只覆盖店名中常见的字，表外的汉字不参与拼音匹配
+var pinyinTable = map[string]string{
+	"a": "阿", "ai": "爱艾", "an": "安庵", "ao": "澳奥",
+	"ba": "八巴吧把霸", "bai": "白百佰", "ban": "板半办", "bang": "帮邦棒", "bao": "包宝煲饱保堡爆", "bei": "北贝杯", "ben": "本", "bi": "比必碧", "bian": "边便", "biao": "标", "bin": "宾滨", "bing": "饼冰兵", "bo": "波博", "bu": "不布部",
+	"cai": "菜彩才财", "can": "餐蚕", "cao": "草曹", "cha": "茶叉", "chai": "柴", "chang": "长常场肠厂昌", "chao": "炒潮朝超巢", "che": "车", "chen": "陈晨辰", "cheng": "城成诚橙", "chi": "吃池赤", "chong": "重崇", "chu": "出厨初楚", "chuan": "川串传船", "chun": "春纯", "ci": "慈", "cong": "葱从", "cui": "翠脆", "cun": "村",
+	"da": "大达", "dai": "代黛", "dan": "蛋丹单担", "dang": "当", "dao": "道刀岛稻", "de": "德得的", "deng": "灯登邓", "di": "地底帝弟第迪", "dian": "店点滇典", "diao": "雕", "ding": "丁鼎顶", "dong": "东冬洞董", "dou": "豆斗", "du": "都渡肚读杜", "duan": "段", "dui": "对队", "dun": "炖顿",
+	"e": "鹅", "er": "二儿",
+	"fa": "发法", "fan": "饭范番", "fang": "方坊房芳", "fei": "肥飞菲", "fen": "粉分芬", "feng": "丰风峰凤冯", "fu": "福府富夫复",
+	"gai": "盖", "gan": "干甘赣", "gang": "港岗", "gao": "高糕", "ge": "阁哥歌格", "gong": "宫功公工", "gou": "狗", "gu": "古谷骨鼓", "gua": "瓜", "guan": "馆关官", "guang": "广光", "gui": "贵桂", "guo": "锅国果郭",
+	"ha": "哈", "hai": "海", "han": "韩汉寒", "hang": "杭", "hao": "好豪", "he": "和合河荷盒", "hei": "黑", "heng": "恒", "hong": "红鸿宏洪", "hou": "侯后", "hu": "湖胡虎壶", "hua": "花华", "huai": "怀", "huan": "欢环", "huang": "黄皇", "hui": "汇会徽惠辉", "huo": "火活",
+	"ji": "鸡记吉基集极", "jia": "家佳加嘉甲", "jian": "煎剑健", "jiang": "江酱姜将", "jiao": "饺角脚椒", "jie": "街杰", "jin": "金锦津", "jing": "京井晶精景", "jiu": "九酒久旧", "ju": "居菊聚", "jue": "爵", "jun": "君",
+	"ka": "卡咖", "kai": "开凯", "kang": "康", "kao": "烤", "ke": "客可", "kou": "口", "ku": "酷", "kuai": "快", "kui": "魁",
+	"la": "拉辣腊", "lai": "来莱", "lan": "兰蓝", "lang": "朗", "lao": "老捞", "le": "乐", "li": "里李丽利力礼", "lian": "莲连", "liang": "凉良粮亮", "lin": "林临", "ling": "零灵岭", "liu": "刘六柳流", "long": "龙隆", "lou": "楼", "lu": "鲁卤炉路鹿陆", "luo": "罗螺骆洛", "lv": "绿驴",
+	"ma": "麻马妈", "mai": "麦卖", "man": "满曼", "mao": "毛猫茂", "mei": "美梅", "men": "门", "meng": "梦蒙", "mi": "米蜜秘", "mian": "面", "miao": "苗妙", "min": "民闽", "ming": "名明", "mo": "馍魔", "mu": "木牧",
+	"na": "那", "nai": "奶", "nan": "南", "niu": "牛", "nong": "农浓", "nuo": "糯",
+	"pai": "排派", "pan": "盘潘", "pao": "泡", "pen": "盆", "peng": "鹏蓬", "pi": "皮披啤", "pian": "片", "pin": "品", "ping": "平萍", "pu": "铺普浦",
+	"qi": "七齐奇其", "qian": "千钱前", "qiao": "桥巧", "qin": "秦琴", "qing": "青清庆", "qiu": "秋", "quan": "全泉",
+	"ren": "人仁", "ri": "日", "rong": "荣蓉", "rou": "肉", "ru": "如",
+	"san": "三", "sao": "臊", "sen": "森", "sha": "沙鲨", "shan": "山陕善膳", "shang": "上尚", "shao": "烧少邵", "she": "舍", "shen": "深神", "sheng": "生胜盛", "shi": "食石时世十师市", "shou": "手寿首", "shu": "蜀薯书", "shuan": "涮", "shuang": "双爽", "shui": "水", "shun": "顺", "si": "四丝思", "song": "松宋", "su": "苏素酥", "suan": "酸蒜", "sui": "岁",
+	"ta": "塔", "tai": "太泰台", "tan": "坛谭", "tang": "汤堂唐糖", "tao": "桃陶", "tian": "天田甜", "tie": "铁", "ting": "厅亭", "tong": "同通桐", "tu": "土兔",
+	"wa": "蛙瓦", "wan": "湾万碗丸晚", "wang": "王旺望", "wei": "味卫威伟", "wen": "文温", "wo": "我窝", "wu": "五吴武屋午",
+	"xi": "西喜溪锡", "xia": "虾夏霞", "xian": "鲜仙先咸县线馅", "xiang": "香湘乡祥巷", "xiao": "小笑肖", "xie": "蟹谢", "xin": "新心鑫", "xing": "兴星杏", "xiong": "熊", "xu": "徐许", "xuan": "轩",
+	"ya": "鸭雅亚", "yan": "炎盐燕岩", "yang": "羊杨阳洋", "yao": "瑶", "ye": "夜叶椰", "yi": "一壹意义艺", "yin": "印银", "ying": "鹰英迎", "yong": "永勇", "you": "油友有", "yu": "鱼渝玉御于宇裕", "yuan": "园源元圆", "yue": "粤月悦", "yun": "云",
+	"zai": "仔", "zao": "早灶", "zha": "炸扎", "zhai": "斋", "zhan": "站", "zhang": "张章", "zhao": "赵", "zhe": "浙", "zhen": "真珍镇", "zheng": "正蒸郑", "zhi": "之知汁芝", "zhong": "中钟", "zhou": "周粥州", "zhu": "猪竹朱煮", "zhuang": "庄", "zi": "子紫", "zong": "宗粽", "zui": "醉",
+}
+
+var pinyinOf = buildPinyinIndex()
+
+func buildPinyinIndex() map[rune]string {
+	index := make(map[rune]string)
+	for syllable, chars := range pinyinTable {
+		for _, c := range chars {
+			if _, ok := index[c]; !ok {
+				index[c] = syllable
+			}
+		}
+	}
+	return index
+}
+
+// toPinyin 名称转成拼音串用于匹配，如 "海底捞" -> "haidilao"
+// 字母和数字保留（转小写），其他符号忽略；含表外汉字时返回空串（无法按拼音匹配）
+func toPinyin(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		switch {
+		case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+			b.WriteRune(unicode.ToLower(c))
+		case unicode.Is(unicode.Han, c):
+			syllable, ok := pinyinOf[c]
+			if !ok {
+				return ""
+			}
+			b.WriteString(syllable)
+		}
+	}
+	return b.String()
+}
@@ -1,46 +1,71 @@
 package preference
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
+	"meal-agent/encryption"
+	"meal-agent/tools"
+
 	"gopkg.in/yaml.v3"
 )
 
 // RestaurantPreference 单个餐厅的偏好设置
 type RestaurantPreference struct {
-	Name   string `yaml:"name"`
-	Weight int    `yaml:"weight"` // 权重，100为基准
-	Note   string `yaml:"note"`   // 备注
+	ID      string `yaml:"id,omitempty"` // 高德 POI ID（可选，优先于名称匹配）
+	Name    string `yaml:"name"`
+	Weight  int    `yaml:"weight"`            // 权重，100为基准
+	Note    string `yaml:"note"`              // 备注
+	Reserve bool   `yaml:"reserve,omitempty"` // 需要提前订位或取号（用餐预告中提醒）
 }
 
 // CategoryPreference 菜系偏好设置
+// Type 可以是规范化菜系（如 "日料"）或其别名（如 "日本料理"），两者都会匹配整个分类的所有写法
 type CategoryPreference struct {
-	Type   string `yaml:"type"`
-	Weight int    `yaml:"weight"`
-	Note   string `yaml:"note"`
+	Type    string   `yaml:"type"`
+	Aliases []string `yaml:"aliases,omitempty"` // 额外的别名，补充内置别名表，如 轻食 -> 沙拉、能量碗
+	Weight  int      `yaml:"weight"`
+	Note    string   `yaml:"note"`
 }
 
 // Preferences 偏好配置
 type Preferences struct {
-	Restaurants []RestaurantPreference `yaml:"restaurants"`
-	Categories  []CategoryPreference   `yaml:"categories"`
+	Restaurants    []RestaurantPreference `yaml:"restaurants"`
+	Categories     []CategoryPreference   `yaml:"categories"`
+	Rules          []TimeRule             `yaml:"rules,omitempty"`           // 按餐次、星期、季节生效的规则
+	WeightRules    []tools.WeightRule     `yaml:"weight_rules,omitempty"`    // 按距离、评分等属性加减分的表达式规则
+	Favorites      []Favorite             `yaml:"favorites,omitempty"`       // 常吃清单，保证至少每隔几天推荐一次
+	Overrides      []Override             `yaml:"overrides,omitempty"`       // 临时偏好，过期后自动删除
+	Taste          Taste                  `yaml:"taste,omitempty"`           // 口味偏好（辣度、油腻、清淡）
+	TasteOverrides []TasteOverride        `yaml:"taste_overrides,omitempty"` // 临时口味，过期后自动删除
+
+	weightRules *tools.WeightRuleSet // 编译后的 WeightRules
+	match       MatchOptions         // 餐厅名称的匹配方式
 
 	// 内部索引
-	restaurantMap map[string]int // name -> weight
-	categoryMap   map[string]int // type -> weight
+	restaurantMap map[string]int    // 规范化名称 -> weight
+	idMap         map[string]int    // POI ID -> weight
+	categoryMap   map[string]int    // type -> weight
+	categoryIndex []categoryMatcher // 按配置顺序的菜系匹配规则
 }
 
-// Load 加载偏好配置
-func Load(path string) (*Preferences, error) {
-	p := &Preferences{
+func newPreferences() *Preferences {
+	return &Preferences{
 		Restaurants:   []RestaurantPreference{},
 		Categories:    []CategoryPreference{},
 		restaurantMap: make(map[string]int),
+		idMap:         make(map[string]int),
 		categoryMap:   make(map[string]int),
+		match:         MatchOptions{Brand: true},
 	}
+}
+
+// Load 加载偏好配置
+func Load(path string) (*Preferences, error) {
+	p := newPreferences()
 
-	data, err := os.ReadFile(path)
+	data, err := encryption.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// 文件不存在，返回空配置
@@ -52,16 +77,53 @@ func Load(path string) (*Preferences, error) {
 	if err := yaml.Unmarshal(data, p); err != nil {
 		return nil, err
 	}
+	if err := p.prepare(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// prepare 检查规则写法并构建索引，加载或导入偏好后调用
+func (p *Preferences) prepare() error {
+	for i, r := range p.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rules 第 %d 条: %v", i+1, err)
+		}
+	}
+	for i, o := range p.Overrides {
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("overrides 第 %d 条: %v", i+1, err)
+		}
+	}
+	if err := p.Taste.validate(); err != nil {
+		return fmt.Errorf("taste: %v", err)
+	}
+	for i, o := range p.TasteOverrides {
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("taste_overrides 第 %d 条: %v", i+1, err)
+		}
+	}
+	rules, err := tools.NewWeightRuleSet(p.WeightRules)
+	if err != nil {
+		return fmt.Errorf("weight_rules %v", err)
+	}
+	p.weightRules = rules
 
 	// 构建索引
+	p.restaurantMap = make(map[string]int)
+	p.idMap = make(map[string]int)
+	p.categoryMap = make(map[string]int)
 	for _, r := range p.Restaurants {
-		p.restaurantMap[r.Name] = r.Weight
+		p.restaurantMap[normalizeName(r.Name)] = r.Weight
+		if r.ID != "" {
+			p.idMap[r.ID] = r.Weight
+		}
 	}
 	for _, c := range p.Categories {
 		p.categoryMap[c.Type] = c.Weight
 	}
-
-	return p, nil
+	p.indexCategories()
+	return nil
 }
 
 // Save 保存偏好配置
@@ -70,55 +132,132 @@ func (p *Preferences) Save(path string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return encryption.WriteFile(path, data, 0644)
 }
 
 // GetRestaurantWeight 获取餐厅权重
 // 返回：权重值（未配置返回100）
 func (p *Preferences) GetRestaurantWeight(name string) int {
-	if weight, ok := p.restaurantMap[name]; ok {
+	return p.GetRestaurantWeightFor("", name)
+}
+
+// GetRestaurantWeightFor 获取餐厅权重，优先按 POI ID 匹配，其次按名称（匹配方式见 MatchOptions）
+// 这样餐厅改名或名称写法略有不同时，配置的权重仍然生效
+func (p *Preferences) GetRestaurantWeightFor(id, name string) int {
+	if weight, ok := p.lookupRestaurant(id, name); ok {
 		return weight
 	}
 	return 100 // 默认权重
 }
 
+// NeedsReservation 是否标记为需要提前订位或取号，按 POI ID、名称或品牌（不带分店后缀的条目）匹配
+func (p *Preferences) NeedsReservation(id, name string) bool {
+	key := normalizeName(name)
+	brand := normalizeName(tools.BrandName(name))
+	for _, r := range p.Restaurants {
+		if !r.Reserve {
+			continue
+		}
+		entry := normalizeName(r.Name)
+		if (id != "" && r.ID == id) || entry == key ||
+			(entry == brand && tools.BrandName(r.Name) == strings.TrimSpace(r.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetCategoryWeight 获取菜系权重
 // typeStr: 高德返回的类型字符串，如 "餐饮服务;中餐厅;川菜"
 func (p *Preferences) GetCategoryWeight(typeStr string) int {
-	for category, weight := range p.categoryMap {
-		if strings.Contains(typeStr, category) {
-			return weight
-		}
+	return p.GetCuisineWeight("", typeStr)
+}
+
+// GetCuisineWeight 获取菜系权重（匹配方式见 categoryMatcher）
+// cuisine: 规范化菜系（如 "川菜"），typeStr: 高德类型字符串
+func (p *Preferences) GetCuisineWeight(cuisine, typeStr string) int {
+	if weight, ok := p.matchCategory(cuisine, typeStr); ok {
+		return weight
 	}
 	return 100 // 默认权重
 }
 
 // SetRestaurantWeight 设置餐厅权重
 func (p *Preferences) SetRestaurantWeight(name string, weight int, note string) {
+	p.SetRestaurantWeightByID("", name, weight, note)
+}
+
+// SetRestaurantWeightByID 设置餐厅权重，同时记录 POI ID
+// 已有条目按 ID 或名称匹配，匹配到时补全缺失的 ID
+func (p *Preferences) SetRestaurantWeightByID(id, name string, weight int, note string) {
 	// 更新或添加
 	found := false
 	for i, r := range p.Restaurants {
-		if r.Name == name {
+		if (id != "" && r.ID == id) || normalizeName(r.Name) == normalizeName(name) {
 			p.Restaurants[i].Weight = weight
 			p.Restaurants[i].Note = note
+			if p.Restaurants[i].ID == "" {
+				p.Restaurants[i].ID = id
+			}
 			found = true
 			break
 		}
 	}
 	if !found {
 		p.Restaurants = append(p.Restaurants, RestaurantPreference{
+			ID:     id,
 			Name:   name,
 			Weight: weight,
 			Note:   note,
 		})
 	}
-	p.restaurantMap[name] = weight
+	p.restaurantMap[normalizeName(name)] = weight
+	if id != "" {
+		p.idMap[id] = weight
+	}
+}
+
+// SetCategoryWeight 设置菜系权重（type 为规范化菜系或高德类型中的关键词）
+func (p *Preferences) SetCategoryWeight(category string, weight int, note string) {
+	found := false
+	for i, c := range p.Categories {
+		if c.Type == category {
+			p.Categories[i].Weight = weight
+			p.Categories[i].Note = note
+			found = true
+			break
+		}
+	}
+	if !found {
+		p.Categories = append(p.Categories, CategoryPreference{
+			Type:   category,
+			Weight: weight,
+			Note:   note,
+		})
+	}
+	p.categoryMap[category] = weight
+	p.indexCategories()
+}
+
+// RuleBoost weight_rules 中餐厅命中的规则的加减分之和
+func (p *Preferences) RuleBoost(r tools.Restaurant) int {
+	return p.weightRules.Boost(r)
 }
 
 // IsBlacklisted 检查餐厅是否被排除（权重为0）
 func (p *Preferences) IsBlacklisted(name string) bool {
-	if weight, ok := p.restaurantMap[name]; ok {
+	if weight, ok := p.lookupRestaurant("", name); ok {
 		return weight == 0
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// 全角符号和空白统一处理，避免 "海底捞（国贸店）" 和 "海底捞(国贸店)" 匹配不上
+var nameReplacer = strings.NewReplacer(
+	"（", "(", "）", ")", "【", "[", "】", "]", "·", "", " ", "", "\u3000", "",
+)
+
+// normalizeName 规范化餐厅名称用于匹配
+func normalizeName(name string) string {
+	return strings.ToLower(nameReplacer.Replace(strings.TrimSpace(name)))
+}
@@ -0,0 +1,118 @@
+package preference
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/tools"
+)
+
+// TimeRule 按餐次、星期、季节生效的偏好规则，如 "午餐快餐加分"、"周五晚餐烧烤加分"、"夏天火锅减分"
+// 条件字段留空表示不限制；cuisine 和 restaurant 至少填写一个
+type TimeRule struct {
+	Meal       string   `yaml:"meal,omitempty"`       // lunch / dinner（也可写 午餐 / 晚餐）
+	Weekdays   []string `yaml:"weekdays,omitempty"`   // 周一 ~ 周日，或 mon ~ sun
+	Seasons    []string `yaml:"seasons,omitempty"`    // 春 / 夏 / 秋 / 冬，或 spring / summer / autumn / winter
+	Cuisine    string   `yaml:"cuisine,omitempty"`    // 菜系（规范化菜系或高德类型中的关键词）
+	Restaurant string   `yaml:"restaurant,omitempty"` // 餐厅名称
+	Boost      int      `yaml:"boost"`                // 加减分，负数表示降权
+	Note       string   `yaml:"note,omitempty"`
+}
+
+var seasonNames = map[string]string{
+	"春": "spring", "春天": "spring", "spring": "spring",
+	"夏": "summer", "夏天": "summer", "summer": "summer",
+	"秋": "autumn", "秋天": "autumn", "autumn": "autumn", "fall": "autumn",
+	"冬": "winter", "冬天": "winter", "winter": "winter",
+}
+
+var ruleMealNames = map[string]string{"lunch": "lunch", "午餐": "lunch", "dinner": "dinner", "晚餐": "dinner"}
+
+// season 按月份划分季节（3~5 月春，6~8 月夏，9~11 月秋，12~2 月冬）
+func season(t time.Time) string {
+	switch t.Month() {
+	case time.March, time.April, time.May:
+		return "spring"
+	case time.June, time.July, time.August:
+		return "summer"
+	case time.September, time.October, time.November:
+		return "autumn"
+	}
+	return "winter"
+}
+
+// validate 检查规则写法，加载偏好配置时调用
+func (r TimeRule) validate() error {
+	if r.Cuisine == "" && r.Restaurant == "" {
+		return fmt.Errorf("需要填写 cuisine 或 restaurant")
+	}
+	if _, ok := ruleMealNames[strings.ToLower(r.Meal)]; r.Meal != "" && !ok {
+		return fmt.Errorf("无法识别的餐次: %s（可用 lunch / dinner）", r.Meal)
+	}
+	for _, d := range r.Weekdays {
+		if _, ok := tools.ParseWeekday(d); !ok {
+			return fmt.Errorf("无法识别的星期: %s（可用 周一~周日 或 mon~sun）", d)
+		}
+	}
+	for _, s := range r.Seasons {
+		if _, ok := seasonNames[strings.ToLower(s)]; !ok {
+			return fmt.Errorf("无法识别的季节: %s（可用 春/夏/秋/冬 或 spring/summer/autumn/winter）", s)
+		}
+	}
+	return nil
+}
+
+// activeAt 规则在该餐次和时间是否生效
+func (r TimeRule) activeAt(mealType string, t time.Time) bool {
+	if r.Meal != "" && ruleMealNames[strings.ToLower(r.Meal)] != mealType {
+		return false
+	}
+	if len(r.Weekdays) > 0 {
+		matched := false
+		for _, d := range r.Weekdays {
+			if day, ok := tools.ParseWeekday(d); ok && day == t.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.Seasons) > 0 {
+		matched := false
+		for _, s := range r.Seasons {
+			if seasonNames[strings.ToLower(s)] == season(t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matches 规则是否适用于该餐厅（菜系精确匹配规范化菜系，或包含在高德类型中；餐厅按规范化名称包含匹配）
+func (r TimeRule) matches(cuisine, typeStr, name string) bool {
+	if r.Cuisine != "" && r.Cuisine != cuisine && !strings.Contains(typeStr, r.Cuisine) {
+		return false
+	}
+	if r.Restaurant != "" && !strings.Contains(normalizeName(name), normalizeName(r.Restaurant)) {
+		return false
+	}
+	return true
+}
+
+// TimeBoost 按餐次和用餐时间生效的规则对某家餐厅的加减分（多条规则生效时累加）
+func (p *Preferences) TimeBoost(mealType string, mealTime time.Time, cuisine, typeStr, name string) int {
+	boost := 0
+	for _, r := range p.Rules {
+		if r.activeAt(mealType, mealTime) && r.matches(cuisine, typeStr, name) {
+			boost += r.Boost
+		}
+	}
+	return boost
+}
@@ -0,0 +1,188 @@
+package preference
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"meal-agent/tools"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle 可分享的偏好包，如团队整理的 "公司周边好店"
+// 只包含餐厅、菜系和规则；常吃清单、口味、临时偏好属于个人设置，不导出
+type Bundle struct {
+	Name        string       `yaml:"name,omitempty"`   // 偏好包名称
+	Author      string       `yaml:"author,omitempty"` // 整理人
+	ExportedAt  string       `yaml:"exported_at,omitempty"`
+	Preferences *Preferences `yaml:"preferences"`
+}
+
+// ImportResult 导入偏好包的结果
+type ImportResult struct {
+	Restaurants int // 新增的餐厅
+	Categories  int // 新增的菜系
+	Rules       int // 新增的时间规则和表达式规则
+	Skipped     int // 本地已有（保留本地设置）而跳过的条目
+}
+
+// Export 导出可分享的偏好包
+func (p *Preferences) Export(name, author string, now time.Time) *Bundle {
+	shared := newPreferences()
+	shared.Restaurants = append(shared.Restaurants, p.Restaurants...)
+	shared.Categories = append(shared.Categories, p.Categories...)
+	shared.Rules = append(shared.Rules, p.Rules...)
+	shared.WeightRules = append(shared.WeightRules, p.WeightRules...)
+	return &Bundle{
+		Name:        name,
+		Author:      author,
+		ExportedAt:  now.Format("2006-01-02 15:04"),
+		Preferences: shared,
+	}
+}
+
+// Write 以 YAML 格式输出偏好包
+func (b *Bundle) Write(w io.Writer) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// LoadBundle 加载偏好包，规则写法按偏好配置的要求检查
+func LoadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{Preferences: newPreferences()}
+	if err := yaml.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	if b.Preferences == nil || len(b.Preferences.Restaurants)+len(b.Preferences.Categories)+
+		len(b.Preferences.Rules)+len(b.Preferences.WeightRules) == 0 {
+		return nil, fmt.Errorf("偏好包中没有 preferences 内容")
+	}
+	if err := b.Preferences.prepare(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Describe 如 "「公司周边好店」（张三 整理，2024-06-20 15:04 导出）"
+func (b *Bundle) Describe() string {
+	name := b.Name
+	if name == "" {
+		name = "未命名偏好包"
+	}
+	var detail string
+	if b.Author != "" {
+		detail = b.Author + " 整理"
+	}
+	if b.ExportedAt != "" {
+		if detail != "" {
+			detail += "，"
+		}
+		detail += b.ExportedAt + " 导出"
+	}
+	if detail == "" {
+		return "「" + name + "」"
+	}
+	return fmt.Sprintf("「%s」（%s）", name, detail)
+}
+
+// Import 导入偏好包
+// 合并（replace 为 false）时只添加本地没有的餐厅、菜系和规则，本地已有的保留本地设置；
+// 替换时用偏好包覆盖本地的餐厅、菜系和规则，个人设置（常吃清单、口味、临时偏好）不变
+func (p *Preferences) Import(b *Bundle, replace bool) (ImportResult, error) {
+	shared := b.Preferences
+	var result ImportResult
+
+	if replace {
+		p.Restaurants = append([]RestaurantPreference{}, shared.Restaurants...)
+		p.Categories = append([]CategoryPreference{}, shared.Categories...)
+		p.Rules = append([]TimeRule(nil), shared.Rules...)
+		p.WeightRules = append([]tools.WeightRule(nil), shared.WeightRules...)
+		result = ImportResult{
+			Restaurants: len(shared.Restaurants),
+			Categories:  len(shared.Categories),
+			Rules:       len(shared.Rules) + len(shared.WeightRules),
+		}
+		return result, p.prepare()
+	}
+
+	for _, r := range shared.Restaurants {
+		if p.hasRestaurant(r.ID, r.Name) {
+			result.Skipped++
+			continue
+		}
+		p.Restaurants = append(p.Restaurants, r)
+		result.Restaurants++
+	}
+
+	for _, c := range shared.Categories {
+		if _, ok := p.categoryMap[c.Type]; ok {
+			// 本地已有的菜系保留权重，只补充别名
+			p.addCategoryAliases(c.Type, c.Aliases)
+			result.Skipped++
+			continue
+		}
+		p.Categories = append(p.Categories, c)
+		p.categoryMap[c.Type] = c.Weight
+		result.Categories++
+	}
+
+	for _, r := range shared.Rules {
+		if containsTimeRule(p.Rules, r) {
+			result.Skipped++
+			continue
+		}
+		p.Rules = append(p.Rules, r)
+		result.Rules++
+	}
+	for _, r := range shared.WeightRules {
+		if containsWeightRule(p.WeightRules, r) {
+			result.Skipped++
+			continue
+		}
+		p.WeightRules = append(p.WeightRules, r)
+		result.Rules++
+	}
+
+	return result, p.prepare()
+}
+
+// hasRestaurant 是否已有该餐厅的条目（按 POI ID 或规范化名称精确匹配）
+func (p *Preferences) hasRestaurant(id, name string) bool {
+	for _, r := range p.Restaurants {
+		if (id != "" && r.ID == id) || normalizeName(r.Name) == normalizeName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTimeRule 是否已有完全相同的时间规则
+func containsTimeRule(rules []TimeRule, rule TimeRule) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWeightRule 是否已有完全相同的表达式规则
+func containsWeightRule(rules []tools.WeightRule, rule tools.WeightRule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
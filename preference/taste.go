@@ -0,0 +1,131 @@
+package preference
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/tools"
+)
+
+// tasteBoostUnit 口味偏好与菜系口味每一级乘积对应的加减分（不吃辣 -2 × 川菜 2 = -20）
+const tasteBoostUnit = 5
+
+// Taste 口味偏好，各项取值 -2 ~ 2，0 表示无所谓
+type Taste struct {
+	Spicy int `yaml:"spicy,omitempty"` // 辣度：2 无辣不欢，-2 不吃辣
+	Oily  int `yaml:"oily,omitempty"`  // 油腻：2 喜欢重油，-2 不要油腻
+	Light int `yaml:"light,omitempty"` // 清淡：2 偏爱清淡，-2 重口味
+}
+
+// TasteOverride 临时口味（如肠胃不好这周吃清淡的）：不为 0 的项覆盖长期口味，过期后自动删除
+type TasteOverride struct {
+	Taste     `yaml:",inline"`
+	DateRange `yaml:",inline"`
+	Reason    string `yaml:"reason,omitempty"` // 原因，如 "肠胃不好"
+}
+
+var tasteLevels = []struct {
+	name   string
+	levels map[int]string
+}{
+	{"辣度", map[int]string{2: "无辣不欢", 1: "喜欢吃辣", -1: "少辣", -2: "不吃辣"}},
+	{"油腻", map[int]string{2: "喜欢重油", 1: "不介意油", -1: "少油", -2: "不要油腻"}},
+	{"清淡", map[int]string{2: "偏爱清淡", 1: "偏清淡", -1: "口味偏重", -2: "重口味"}},
+}
+
+func (t Taste) values() []int {
+	return []int{t.Spicy, t.Oily, t.Light}
+}
+
+// IsZero 是否没有任何口味偏好
+func (t Taste) IsZero() bool {
+	return t == Taste{}
+}
+
+// Describe 如 "不吃辣、不要油腻、偏爱清淡"，没有偏好时为空
+func (t Taste) Describe() string {
+	var parts []string
+	for i, v := range t.values() {
+		if text, ok := tasteLevels[i].levels[v]; ok {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "、")
+}
+
+// merge 用 other 中不为 0 的项覆盖
+func (t Taste) merge(other Taste) Taste {
+	if other.Spicy != 0 {
+		t.Spicy = other.Spicy
+	}
+	if other.Oily != 0 {
+		t.Oily = other.Oily
+	}
+	if other.Light != 0 {
+		t.Light = other.Light
+	}
+	return t
+}
+
+// validate 各项应在 -2 ~ 2 之间
+func (t Taste) validate() error {
+	for i, v := range t.values() {
+		if v < -2 || v > 2 {
+			return fmt.Errorf("%s应在 -2~2 之间: %d", tasteLevels[i].name, v)
+		}
+	}
+	return nil
+}
+
+// validate 检查口味取值和日期写法
+func (o TasteOverride) validate() error {
+	if err := o.Taste.validate(); err != nil {
+		return err
+	}
+	return o.DateRange.validate()
+}
+
+// Describe 如 "偏爱清淡（肠胃不好，到 2024-06-25）"
+func (o TasteOverride) Describe() string {
+	return fmt.Sprintf("%s（%s）", o.Taste.Describe(), o.DateRange.describe(o.Reason))
+}
+
+// TasteAt t 时生效的口味：长期口味被生效中的临时口味覆盖（后添加的优先）
+func (p *Preferences) TasteAt(t time.Time) Taste {
+	taste := p.Taste
+	for _, o := range p.TasteOverrides {
+		if o.activeAt(t) {
+			taste = taste.merge(o.Taste)
+		}
+	}
+	return taste
+}
+
+// TasteBoost 口味偏好对某个菜系的加减分
+func (p *Preferences) TasteBoost(cuisine string, t time.Time) int {
+	taste := p.TasteAt(t)
+	profile := tools.CuisineTaste(cuisine)
+	return tasteBoostUnit * (taste.Spicy*profile.Spicy + taste.Oily*profile.Oily + taste.Light*profile.Light)
+}
+
+// SetTaste 修改长期口味（只修改不为 0 的项）
+func (p *Preferences) SetTaste(t Taste) {
+	p.Taste = p.Taste.merge(t)
+}
+
+// AddTasteOverride 添加临时口味
+func (p *Preferences) AddTasteOverride(o TasteOverride) {
+	p.TasteOverrides = append(p.TasteOverrides, o)
+}
+
+// ActiveTasteOverrides 未过期的临时口味（包括还没开始的）
+func (p *Preferences) ActiveTasteOverrides(now time.Time) []TasteOverride {
+	var active []TasteOverride
+	for _, o := range p.TasteOverrides {
+		if !o.Expired(now) {
+			active = append(active, o)
+		}
+	}
+	return active
+}
@@ -0,0 +1,188 @@
+package preference
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"meal-agent/encryption"
+	"meal-agent/tools"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxWeight 餐厅和菜系权重的上限（100 为基准，0 表示排除）
+const MaxWeight = 300
+
+// Issue 偏好配置检查发现的问题
+type Issue struct {
+	Error   bool   // true 为错误（配置不会按预期生效），false 为提醒
+	Where   string // 位置，如 "restaurants 第3条「海底捞」"
+	Message string
+}
+
+func (i Issue) String() string {
+	level := "提醒"
+	if i.Error {
+		level = "错误"
+	}
+	return fmt.Sprintf("[%s] %s: %s", level, i.Where, i.Message)
+}
+
+// Validate 检查偏好配置文件：重复和冲突的条目、超出范围的权重、无法识别的菜系、写错的规则
+// 文件无法读取或不是合法的 YAML 时返回 error，其余问题全部列在结果中
+func Validate(path string) ([]Issue, error) {
+	data, err := encryption.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Preferences
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("YAML 格式错误: %v", err)
+	}
+
+	var issues []Issue
+	add := func(isError bool, where, format string, args ...interface{}) {
+		issues = append(issues, Issue{Error: isError, Where: where, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 餐厅：同一名称或 POI ID 出现多次时，索引中后面的条目覆盖前面的
+	names := make(map[string]int)
+	ids := make(map[string]int)
+	for i, r := range p.Restaurants {
+		where := fmt.Sprintf("restaurants 第%d条「%s」", i+1, r.Name)
+		if strings.TrimSpace(r.Name) == "" {
+			add(true, fmt.Sprintf("restaurants 第%d条", i+1), "缺少 name")
+			continue
+		}
+		checkWeight(r.Weight, where, add)
+
+		if j, ok := names[normalizeName(r.Name)]; ok {
+			reportDuplicate(p.Restaurants[j].Weight, r.Weight, j, i, where, add)
+		} else {
+			names[normalizeName(r.Name)] = i
+		}
+		if r.ID == "" {
+			continue
+		}
+		if j, ok := ids[r.ID]; ok && normalizeName(p.Restaurants[j].Name) != normalizeName(r.Name) {
+			reportDuplicate(p.Restaurants[j].Weight, r.Weight, j, i, where+" 的 id", add)
+		} else if !ok {
+			ids[r.ID] = i
+		}
+	}
+
+	// 菜系：规范化菜系及其别名匹配整个分类，其他写法只按高德类型字符串和 aliases 包含匹配
+	types := make(map[string]int)
+	for i, c := range p.Categories {
+		where := fmt.Sprintf("categories 第%d条「%s」", i+1, c.Type)
+		if strings.TrimSpace(c.Type) == "" {
+			add(true, fmt.Sprintf("categories 第%d条", i+1), "缺少 type")
+			continue
+		}
+		checkWeight(c.Weight, where, add)
+		// 规范化菜系的别名（如 日本料理）会匹配整个分类，不需要提醒
+		if !tools.IsCanonicalCuisine(c.Type) && tools.CanonicalCuisine(c.Type, "") == tools.CuisineOther && len(c.Aliases) == 0 {
+			add(false, where, "「%s」不是规范化菜系，只会按高德类型包含匹配，可以用 aliases 补充其他写法（规范化菜系: %s）",
+				c.Type, strings.Join(tools.CanonicalCuisines(), "、"))
+		}
+
+		if j, ok := types[c.Type]; ok {
+			reportDuplicate(p.Categories[j].Weight, c.Weight, j, i, where, add)
+		} else {
+			types[c.Type] = i
+		}
+	}
+
+	for i, r := range p.Rules {
+		where := fmt.Sprintf("rules 第%d条", i+1)
+		if err := r.validate(); err != nil {
+			add(true, where, "%v", err)
+			continue
+		}
+		if r.Cuisine != "" {
+			checkCuisine(r.Cuisine, where, add)
+		}
+		if r.Boost == 0 {
+			add(false, where, "boost 为 0，这条规则不起作用")
+		}
+	}
+
+	for i, r := range p.WeightRules {
+		if _, err := tools.NewWeightRuleSet([]tools.WeightRule{r}); err != nil {
+			// 单条编译时错误信息里的序号总是第1条，去掉后换成实际位置
+			msg := strings.TrimPrefix(err.Error(), "第1条规则")
+			msg = strings.TrimPrefix(strings.TrimPrefix(msg, "的"), " ")
+			add(true, fmt.Sprintf("weight_rules 第%d条", i+1), "%s", msg)
+		}
+	}
+
+	for i, o := range p.Overrides {
+		where := fmt.Sprintf("overrides 第%d条「%s」", i+1, o.Target)
+		if err := o.validate(); err != nil {
+			add(true, where, "%v", err)
+		} else if o.Expired(time.Now()) {
+			add(false, where, "已于 %s 过期，下次启动时会自动删除", o.Until)
+		}
+	}
+
+	if err := p.Taste.validate(); err != nil {
+		add(true, "taste", "%v", err)
+	}
+	for i, o := range p.TasteOverrides {
+		where := fmt.Sprintf("taste_overrides 第%d条", i+1)
+		if err := o.validate(); err != nil {
+			add(true, where, "%v", err)
+		} else if o.IsZero() {
+			add(false, where, "没有设置任何口味，这条不起作用")
+		} else if o.Expired(time.Now()) {
+			add(false, where, "已于 %s 过期，下次启动时会自动删除", o.Until)
+		}
+	}
+
+	for i, f := range p.Favorites {
+		where := fmt.Sprintf("favorites 第%d条「%s」", i+1, f.Name)
+		if strings.TrimSpace(f.Name) == "" {
+			add(true, fmt.Sprintf("favorites 第%d条", i+1), "缺少 name")
+			continue
+		}
+		if f.Days < 0 {
+			add(true, where, "days 应为正数（默认 7，即每周至少一次）")
+		}
+		if j, ok := names[normalizeName(f.Name)]; ok && p.Restaurants[j].Weight == 0 {
+			add(true, where, "这家餐厅在 restaurants 中被拉黑（权重 0），不会被推荐")
+		}
+	}
+
+	return issues, nil
+}
+
+// checkWeight 权重应在 0 ~ MaxWeight 之间
+func checkWeight(weight int, where string, add func(bool, string, string, ...interface{})) {
+	if weight < 0 || weight > MaxWeight {
+		add(true, where, "权重 %d 超出范围，应在 0~%d 之间（100 为默认，0 表示排除）", weight, MaxWeight)
+	}
+}
+
+// checkCuisine 非规范化菜系只能按高德类型包含匹配，能推断出规范化菜系时给出建议
+func checkCuisine(name, where string, add func(bool, string, string, ...interface{})) {
+	if tools.IsCanonicalCuisine(name) {
+		return
+	}
+	if guess := tools.CanonicalCuisine(name, ""); guess != tools.CuisineOther {
+		add(false, where, "「%s」不是规范化菜系，建议改为「%s」", name, guess)
+		return
+	}
+	add(false, where, "「%s」不是规范化菜系，只会按高德类型字符串包含匹配（可用: %s）",
+		name, strings.Join(tools.CanonicalCuisines(), "、"))
+}
+
+// reportDuplicate 重复条目：权重相同时提醒，不同时报错（后面的条目生效）
+func reportDuplicate(firstWeight, weight, first, i int, where string, add func(bool, string, string, ...interface{})) {
+	if firstWeight == weight {
+		add(false, where, "与第%d条重复，可以删除其中一条", first+1)
+		return
+	}
+	add(true, where, "与第%d条冲突（权重 %d / %d），实际生效的是第%d条，请删除其中一条",
+		first+1, firstWeight, weight, i+1)
+}
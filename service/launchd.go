@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Launchd macOS 的 launchd 服务（~/Library/LaunchAgents），登录后自动启动
+type Launchd struct {
+	opts  Options
+	label string
+	path  string
+}
+
+func newLaunchd(opts Options) (*Launchd, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	label := "com." + opts.Name()
+	return &Launchd{
+		opts:  opts,
+		label: label,
+		path:  filepath.Join(home, "Library", "LaunchAgents", label+".plist"),
+	}, nil
+}
+
+// Path 服务文件的路径
+func (l *Launchd) Path() string {
+	return l.path
+}
+
+// logPath 服务的输出写到数据目录
+func (l *Launchd) logPath() string {
+	return filepath.Join(l.opts.DataDir, "daemon.log")
+}
+
+// Render 生成 plist：登录后启动，异常退出时重启
+func (l *Launchd) Render() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	sb.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	sb.WriteString("\t<key>Label</key>\n\t<string>" + xmlEscape(l.label) + "</string>\n")
+	sb.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{l.opts.Executable}, l.opts.Args()...) {
+		sb.WriteString("\t\t<string>" + xmlEscape(arg) + "</string>\n")
+	}
+	sb.WriteString("\t</array>\n")
+	sb.WriteString("\t<key>WorkingDirectory</key>\n\t<string>" + xmlEscape(l.opts.WorkDir()) + "</string>\n")
+	// launchd 的 PATH 只有系统目录，沿用当前的 PATH 才能找到 terminal-notifier、git 等命令
+	if path := os.Getenv("PATH"); path != "" {
+		sb.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n\t\t<key>PATH</key>\n\t\t<string>" + xmlEscape(path) + "</string>\n\t</dict>\n")
+	}
+	sb.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	sb.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	sb.WriteString("\t<key>ExitTimeOut</key>\n\t<integer>30</integer>\n")
+	sb.WriteString("\t<key>StandardOutPath</key>\n\t<string>" + xmlEscape(l.logPath()) + "</string>\n")
+	sb.WriteString("\t<key>StandardErrorPath</key>\n\t<string>" + xmlEscape(l.logPath()) + "</string>\n")
+	sb.WriteString("</dict>\n</plist>\n")
+	return sb.String()
+}
+
+// Install 写入 plist 并加载（已加载时先卸载，使修改生效）
+func (l *Launchd) Install() error {
+	if err := l.opts.checkConfig(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.opts.DataDir, 0755); err != nil {
+		return err
+	}
+	if err := writeFile(l.path, l.Render()); err != nil {
+		return err
+	}
+	run("launchctl", "unload", l.path)
+	_, err := run("launchctl", "load", "-w", l.path)
+	return err
+}
+
+// Uninstall 卸载并删除 plist
+func (l *Launchd) Uninstall() error {
+	if _, err := os.Stat(l.path); os.IsNotExist(err) {
+		return fmt.Errorf("没有安装服务（%s 不存在）", l.path)
+	}
+	if _, err := run("launchctl", "unload", "-w", l.path); err != nil {
+		return err
+	}
+	return os.Remove(l.path)
+}
+
+// Status launchctl list 中的进程号和上次退出码
+func (l *Launchd) Status() (string, error) {
+	if _, err := os.Stat(l.path); os.IsNotExist(err) {
+		return "没有安装服务", nil
+	}
+	out, err := run("launchctl", "list", l.label)
+	if err != nil {
+		return "已安装，未加载（" + l.path + "）", nil
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+// Hint 查看日志的命令
+func (l *Launchd) Hint() string {
+	return "查看日志: tail -f " + l.logPath()
+}
+
+// xmlEscape 转义 plist 中的字符串
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
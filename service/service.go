@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Options 要安装的后台服务：以后台模式运行 Executable，开机（登录）后自动启动
+type Options struct {
+	Executable string // 可执行文件的绝对路径
+	ConfigPath string // -config
+	PrefPath   string // -pref
+	DataDir    string // -data
+	User       string // -user（为空表示共享记录）
+	System     bool   // Linux 安装为系统服务（/etc/systemd/system，需要 root），默认为当前用户的服务
+}
+
+// Manager 某个系统的服务管理方式
+type Manager interface {
+	// Path 服务文件的路径
+	Path() string
+	// Render 生成服务文件的内容
+	Render() string
+	// Install 写入服务文件并启动
+	Install() error
+	// Uninstall 停止服务并删除服务文件
+	Uninstall() error
+	// Status 服务的运行状态
+	Status() (string, error)
+	// Hint 安装后的说明（查看日志等）
+	Hint() string
+}
+
+// New 按当前系统选择 systemd（Linux）或 launchd（macOS），路径转换为绝对路径
+func New(opts Options) (Manager, error) {
+	if err := opts.absolute(); err != nil {
+		return nil, err
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return newSystemd(opts)
+	case "darwin":
+		return newLaunchd(opts)
+	default:
+		return nil, fmt.Errorf("暂不支持在 %s 上安装服务（支持 Linux systemd 和 macOS launchd）", runtime.GOOS)
+	}
+}
+
+// Name 服务名称，指定用户时加上用户 ID，如 meal-agent-alice
+func (o Options) Name() string {
+	if o.User == "" {
+		return "meal-agent"
+	}
+	return "meal-agent-" + o.User
+}
+
+// Args 后台模式的命令行参数（不含可执行文件）
+func (o Options) Args() []string {
+	args := []string{"daemon", "-config", o.ConfigPath, "-pref", o.PrefPath, "-data", o.DataDir}
+	if o.User != "" {
+		args = append(args, "-user", o.User)
+	}
+	return args
+}
+
+// WorkDir 服务的工作目录（配置文件所在目录）
+func (o Options) WorkDir() string {
+	return filepath.Dir(o.ConfigPath)
+}
+
+// absolute 服务不在当前目录运行，路径都换成绝对路径
+func (o *Options) absolute() error {
+	exe, err := filepath.EvalSymlinks(o.Executable)
+	if err != nil {
+		return fmt.Errorf("找不到可执行文件: %v", err)
+	}
+	// go run 编译到临时目录，运行结束后就删除了
+	if strings.Contains(exe, "go-build") {
+		return fmt.Errorf("请先用 go build 生成可执行文件，再用它安装服务")
+	}
+	o.Executable = exe
+
+	for _, p := range []*string{&o.ConfigPath, &o.PrefPath, &o.DataDir} {
+		abs, err := filepath.Abs(*p)
+		if err != nil {
+			return err
+		}
+		*p = abs
+	}
+	return nil
+}
+
+// checkConfig 安装前检查配置文件，避免服务启动后立即退出
+func (o Options) checkConfig() error {
+	if _, err := os.Stat(o.ConfigPath); err != nil {
+		return fmt.Errorf("配置文件 %s 不存在，请先复制 config.example.yaml 并填写配置", o.ConfigPath)
+	}
+	return nil
+}
+
+// writeFile 写入服务文件，目录不存在时创建
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// run 执行服务管理命令，失败时附带命令输出
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s %s 失败: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Systemd Linux 的 systemd 服务：默认为当前用户的服务（~/.config/systemd/user），不需要 root
+type Systemd struct {
+	opts Options
+	path string
+}
+
+func newSystemd(opts Options) (*Systemd, error) {
+	dir := "/etc/systemd/system"
+	if !opts.System {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(config, "systemd", "user")
+	}
+	return &Systemd{opts: opts, path: filepath.Join(dir, opts.Name()+".service")}, nil
+}
+
+// Path 服务文件的路径
+func (s *Systemd) Path() string {
+	return s.path
+}
+
+// Render 生成 unit 文件；退出时发送 SIGTERM，留出时间把正在进行的推送和记录做完
+func (s *Systemd) Render() string {
+	command := []string{systemdQuote(s.opts.Executable)}
+	for _, arg := range s.opts.Args() {
+		command = append(command, systemdQuote(arg))
+	}
+	wantedBy := "default.target"
+	if s.opts.System {
+		wantedBy = "multi-user.target"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[Unit]\n")
+	sb.WriteString("Description=Meal Agent 午餐/晚餐提醒\n")
+	sb.WriteString("After=network-online.target\n")
+	sb.WriteString("Wants=network-online.target\n\n")
+	sb.WriteString("[Service]\n")
+	sb.WriteString("Type=simple\n")
+	sb.WriteString("WorkingDirectory=" + systemdQuote(s.opts.WorkDir()) + "\n")
+	sb.WriteString("ExecStart=" + strings.Join(command, " ") + "\n")
+	sb.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	sb.WriteString("Restart=on-failure\n")
+	sb.WriteString("RestartSec=10\n")
+	sb.WriteString("KillSignal=SIGTERM\n")
+	sb.WriteString("TimeoutStopSec=30\n")
+	if s.opts.System {
+		if owner := os.Getenv("SUDO_USER"); owner != "" {
+			sb.WriteString("User=" + owner + "\n") // sudo 安装时以原来的用户运行，数据文件的属主不变
+		}
+	}
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=" + wantedBy + "\n")
+	return sb.String()
+}
+
+// Install 写入 unit 文件，设置开机启动并立即启动
+func (s *Systemd) Install() error {
+	if err := s.opts.checkConfig(); err != nil {
+		return err
+	}
+	if err := writeFile(s.path, s.Render()); err != nil {
+		return err
+	}
+	if _, err := run("systemctl", s.args("daemon-reload")...); err != nil {
+		return err
+	}
+	_, err := run("systemctl", s.args("enable", "--now", s.opts.Name())...)
+	return err
+}
+
+// Uninstall 停止服务、取消开机启动并删除 unit 文件
+func (s *Systemd) Uninstall() error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return fmt.Errorf("没有安装服务（%s 不存在）", s.path)
+	}
+	if _, err := run("systemctl", s.args("disable", "--now", s.opts.Name())...); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	_, err := run("systemctl", s.args("daemon-reload")...)
+	return err
+}
+
+// Status systemctl status 的输出（服务没有运行时 systemctl 返回非 0，不算错误）
+func (s *Systemd) Status() (string, error) {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return "没有安装服务", nil
+	}
+	out, err := run("systemctl", s.args("status", "--no-pager", s.opts.Name())...)
+	if err != nil && strings.TrimSpace(out) == "" {
+		return "", err
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+// Hint 查看日志的命令；用户服务需要开启 linger 才能在未登录时启动
+func (s *Systemd) Hint() string {
+	if s.opts.System {
+		return "查看日志: journalctl -u " + s.opts.Name() + " -f"
+	}
+	return "查看日志: journalctl --user -u " + s.opts.Name() + " -f\n" +
+		"开机后未登录也要运行时执行: loginctl enable-linger $USER"
+}
+
+// args 用户服务加上 --user
+func (s *Systemd) args(args ...string) []string {
+	if s.opts.System {
+		return args
+	}
+	return append([]string{"--user"}, args...)
+}
+
+// systemdQuote 含空格、引号等字符的参数加上双引号
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%;") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%")
+	return `"` + r.Replace(arg) + `"`
+}
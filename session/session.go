@@ -0,0 +1,59 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"meal-agent/agent"
+)
+
+const (
+	idleTTL     = 12 * time.Hour // 会话闲置多久后清理
+	maxSessions = 100            // 最多同时保留的会话，超过时清理最久没用的
+)
+
+// Store 会话表：key 为浏览器的 cookie、聊天软件的用户 ID 等，每个会话一个 Agent
+type Store struct {
+	newAgent func(user string) *agent.MealAgent // 为会话创建 Agent，user 为空时使用共享记录
+
+	mu       sync.Mutex // 串行化对 Agent 的调用（各会话共用配置和偏好，不是并发安全的）
+	sessions map[string]*entry
+}
+
+type entry struct {
+	agent    *agent.MealAgent
+	user     string
+	lastUsed time.Time
+}
+
+// NewStore 创建会话表，newAgent 按用餐记录的用户创建 Agent
+func NewStore(newAgent func(user string) *agent.MealAgent) *Store {
+	return &Store{newAgent: newAgent, sessions: make(map[string]*entry)}
+}
+
+// Do 在 key 的会话中执行 fn，没有或换了用户时新建会话；所有会话的调用依次执行
+func (s *Store) Do(key, user string, fn func(a *agent.MealAgent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var oldest string
+	for k, e := range s.sessions {
+		if now.Sub(e.lastUsed) > idleTTL {
+			delete(s.sessions, k)
+		} else if oldest == "" || e.lastUsed.Before(s.sessions[oldest].lastUsed) {
+			oldest = k
+		}
+	}
+
+	e, ok := s.sessions[key]
+	if !ok || e.user != user {
+		if !ok && len(s.sessions) >= maxSessions && oldest != "" {
+			delete(s.sessions, oldest)
+		}
+		e = &entry{agent: s.newAgent(user), user: user}
+		s.sessions[key] = e
+	}
+	e.lastUsed = now
+	fn(e.agent)
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 分店后缀："海底捞(国贸店)"、"沙县小吃（望京SOHO店）"、"麦当劳【西直门】"
+var branchSuffixPattern = regexp.MustCompile(`\s*[(（【\[][^)）】\]]*[)）】\]]\s*$`)
+
+// BrandName 去掉分店后缀，得到品牌名
+func BrandName(name string) string {
+	brand := strings.TrimSpace(name)
+	for {
+		stripped := branchSuffixPattern.ReplaceAllString(brand, "")
+		if stripped == brand || stripped == "" {
+			break
+		}
+		brand = stripped
+	}
+	return brand
+}
+
+// GroupByBrand 按品牌合并连锁店，每个品牌只保留最近的一家分店
+// 保留的餐厅 Branches 字段记录附近的分店数量，结果保持原有顺序
+func GroupByBrand(restaurants []Restaurant) []Restaurant {
+	nearest := make(map[string]int) // brand -> 在结果中的下标
+	grouped := make([]Restaurant, 0, len(restaurants))
+
+	for _, r := range restaurants {
+		brand := BrandName(r.Name)
+		idx, ok := nearest[brand]
+		if !ok {
+			r.Branches = 1
+			nearest[brand] = len(grouped)
+			grouped = append(grouped, r)
+			continue
+		}
+
+		branches := grouped[idx].Branches + 1
+		if r.GetDistanceInt() < grouped[idx].GetDistanceInt() {
+			grouped[idx] = r
+		}
+		grouped[idx].Branches = branches
+	}
+
+	return grouped
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SearchCache 餐厅搜索结果的磁盘缓存
+// 附近的 POI 变化很慢，缓存可以避免定时模式每天重复请求，也让对话中的重试立即返回
+type SearchCache struct {
+	dir      string
+	provider string // 数据来源（amap / osm），写进缓存键，切换来源后不会读到另一个来源的结果
+	ttl      time.Duration
+}
+
+// cacheEntry 缓存文件内容
+type cacheEntry struct {
+	Key         string       `json:"key"`
+	FetchedAt   time.Time    `json:"fetched_at"`
+	Restaurants []Restaurant `json:"restaurants"`
+}
+
+// NewSearchCache 创建 provider 的搜索缓存，ttl<=0 时返回 nil（不缓存）
+func NewSearchCache(dir, provider string, ttl time.Duration) (*SearchCache, error) {
+	if ttl <= 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &SearchCache{dir: dir, provider: provider, ttl: ttl}, nil
+}
+
+// Get 读取未过期的缓存结果
+func (c *SearchCache) Get(lat, lng string, radius int, keyword string) ([]Restaurant, bool) {
+	entry, ok := c.load(c.key(lat, lng, radius, keyword))
+	if !ok || time.Since(entry.FetchedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.Restaurants, true
+}
+
+// GetStale 读取缓存结果（忽略过期时间），用于接口不可用或配额将尽时兜底
+func (c *SearchCache) GetStale(lat, lng string, radius int, keyword string) ([]Restaurant, bool) {
+	entry, ok := c.load(c.key(lat, lng, radius, keyword))
+	if !ok {
+		return nil, false
+	}
+	return entry.Restaurants, true
+}
+
+// load 读取缓存文件
+func (c *SearchCache) load(key string) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put 写入缓存
+func (c *SearchCache) Put(lat, lng string, radius int, keyword string, restaurants []Restaurant) error {
+	if c == nil {
+		return nil
+	}
+
+	key := c.key(lat, lng, radius, keyword)
+	data, err := json.Marshal(cacheEntry{
+		Key:         key,
+		FetchedAt:   time.Now(),
+		Restaurants: restaurants,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key), data, 0644)
+}
+
+// path 缓存文件路径（key 做哈希，避免关键词中的特殊字符）
+func (c *SearchCache) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, "search_"+hex.EncodeToString(sum[:8])+".json")
+}
+
+func (c *SearchCache) key(lat, lng string, radius int, keyword string) string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s,%s,%d,%s", c.provider, lat, lng, radius, keyword)
+}
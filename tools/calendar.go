@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SeasonalFood 节气或节日的饮食习俗
+type SeasonalFood struct {
+	Name       string   // 节气/节日名称，如 "冬至"
+	Suggestion string   // 习俗说明，写入 prompt
+	Keywords   []string // 相关的菜系或食物，命中的餐厅加分
+}
+
+// solarTerm 节气（按 21 世纪寿星公式计算日期，误差一般不超过 1 天）
+type solarTerm struct {
+	name  string
+	month time.Month
+	c     float64
+}
+
+var solarTerms = []solarTerm{
+	{"小寒", time.January, 5.4055}, {"大寒", time.January, 20.12},
+	{"立春", time.February, 3.87}, {"雨水", time.February, 18.73},
+	{"惊蛰", time.March, 5.63}, {"春分", time.March, 20.646},
+	{"清明", time.April, 4.81}, {"谷雨", time.April, 20.1},
+	{"立夏", time.May, 5.52}, {"小满", time.May, 21.04},
+	{"芒种", time.June, 5.678}, {"夏至", time.June, 21.37},
+	{"小暑", time.July, 7.108}, {"大暑", time.July, 22.83},
+	{"立秋", time.August, 7.5}, {"处暑", time.August, 23.13},
+	{"白露", time.September, 7.646}, {"秋分", time.September, 23.042},
+	{"寒露", time.October, 8.318}, {"霜降", time.October, 23.438},
+	{"立冬", time.November, 7.438}, {"小雪", time.November, 22.36},
+	{"大雪", time.December, 7.18}, {"冬至", time.December, 21.94},
+}
+
+// 有饮食习俗的节气
+var solarTermFoods = map[string]SeasonalFood{
+	"立春": {Suggestion: "立春有咬春的习俗，可以吃春饼、春卷", Keywords: []string{"春饼", "春卷"}},
+	"清明": {Suggestion: "清明前后有吃青团的习俗", Keywords: []string{"青团"}},
+	"立夏": {Suggestion: "立夏有吃立夏蛋、立夏饭的习俗", Keywords: []string{"立夏"}},
+	"夏至": {Suggestion: "冬至饺子夏至面，夏至有吃面的习俗", Keywords: []string{CuisineNoodle, "面馆"}},
+	"立秋": {Suggestion: "立秋有贴秋膘的习俗，可以吃点肉", Keywords: []string{CuisineBBQ, "烤肉", "红烧肉", "肘子"}},
+	"立冬": {Suggestion: "立冬有吃饺子、进补的习俗", Keywords: []string{CuisineDumpling, "饺子", "羊肉"}},
+	"冬至": {Suggestion: "冬至有吃饺子的习俗（南方也吃汤圆）", Keywords: []string{CuisineDumpling, "饺子", "汤圆"}},
+}
+
+// 农历节日的公历日期（农历换算复杂，按年份查表，需要定期补充）
+var springFestivals = map[int]string{
+	2025: "01-29", 2026: "02-17", 2027: "02-06", 2028: "01-26", 2029: "02-13", 2030: "02-03",
+}
+
+var dragonBoatFestivals = map[int]string{
+	2025: "05-31", 2026: "06-19", 2027: "06-09", 2028: "05-28", 2029: "06-16", 2030: "06-05",
+}
+
+var midAutumnFestivals = map[int]string{
+	2025: "10-06", 2026: "09-25", 2027: "09-15", 2028: "10-03", 2029: "09-22", 2030: "09-12",
+}
+
+// SeasonalFoodsOn 返回指定日期的节气、节日饮食习俗
+func SeasonalFoodsOn(t time.Time) []SeasonalFood {
+	var foods []SeasonalFood
+
+	if term := SolarTermOn(t); term != "" {
+		if food, ok := solarTermFoods[term]; ok {
+			food.Name = term
+			foods = append(foods, food)
+		}
+	}
+
+	day := t.Format("01-02")
+	year := t.Year()
+	if day == springFestivals[year] {
+		foods = append(foods, SeasonalFood{Name: "春节", Suggestion: "北方有吃饺子的习俗", Keywords: []string{CuisineDumpling, "饺子"}})
+	}
+	if lantern, ok := lanternFestival(year); ok && day == lantern {
+		foods = append(foods, SeasonalFood{Name: "元宵节", Suggestion: "有吃元宵、汤圆的习俗", Keywords: []string{"元宵", "汤圆", CuisineDessert}})
+	}
+	if day == dragonBoatFestivals[year] {
+		foods = append(foods, SeasonalFood{Name: "端午节", Suggestion: "有吃粽子的习俗", Keywords: []string{"粽子"}})
+	}
+	if day == midAutumnFestivals[year] {
+		foods = append(foods, SeasonalFood{Name: "中秋节", Suggestion: "有吃月饼、团圆饭的习俗", Keywords: []string{"月饼", CuisineHomestyle}})
+	}
+
+	return foods
+}
+
+// SolarTermOn 返回指定日期的节气名称，不是节气时返回空字符串
+func SolarTermOn(t time.Time) string {
+	year := t.Year()
+	if year < 2001 || year > 2100 {
+		return ""
+	}
+	y := float64(year % 100)
+
+	for _, term := range solarTerms {
+		if term.month != t.Month() {
+			continue
+		}
+		// 1、2 月的节气按上一年计算闰年数
+		leaps := (year % 100) / 4
+		if term.month <= time.February {
+			leaps = (year%100 - 1) / 4
+		}
+		if int(y*0.2422+term.c)-leaps == t.Day() {
+			return term.name
+		}
+	}
+	return ""
+}
+
+// SeasonalMatch 餐厅是否和当天的节气、节日食物相关
+func SeasonalMatch(r Restaurant, foods []SeasonalFood) bool {
+	text := r.Name + r.Type + r.Cuisine
+	for _, food := range foods {
+		for _, kw := range food.Keywords {
+			if strings.Contains(text, kw) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lanternFestival 元宵节（正月十五）的公历日期 "01-02"
+func lanternFestival(year int) (string, bool) {
+	day, ok := springFestivals[year]
+	if !ok {
+		return "", false
+	}
+	spring, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%s", year, day))
+	if err != nil {
+		return "", false
+	}
+	return spring.AddDate(0, 0, 14).Format("01-02"), true
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// CancelOnDone 包装 base，ctx 结束时取消进行中的请求，之后的请求直接失败
+// 后台模式退出时用它替换 http.DefaultTransport，不必等待慢的 LLM、天气、餐厅接口
+func CancelOnDone(ctx context.Context, base http.RoundTripper) http.RoundTripper {
+	return &cancelTransport{ctx: ctx, base: base}
+}
+
+type cancelTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip 请求自身的超时和 ctx 任一结束都会取消请求，响应内容读完关闭后释放
+func (t *cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
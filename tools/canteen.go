@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"meal-agent/network"
+)
+
+// CanteenID 食堂候选的 ID（不对应地图 POI，不查询详情和第三方评分）
+const CanteenID = "canteen"
+
+// canteenCacheDuration 从 URL 读取的菜单缓存时间
+const canteenCacheDuration = 10 * time.Minute
+
+// CanteenProvider 公司食堂菜单：按星期填写每天午餐、晚餐的菜，作为一个距离为 0 的候选和附近餐厅一起排序
+// 菜单可以是本地 YAML 文件（每次读取，修改后立即生效），也可以是返回 YAML / JSON 的 http(s) 地址:
+//
+//	name: 公司食堂
+//	cost: 15
+//	open_time: "11:30-13:00,17:30-19:00"
+//	days:
+//	  周一:
+//	    lunch: [红烧肉, 番茄炒蛋, 清炒时蔬]
+//	    dinner: [鸡腿饭, 紫菜蛋花汤]
+//	  周二: [宫保鸡丁, 麻婆豆腐]   # 午餐、晚餐相同
+//	closed: ["2024-10-01"]        # 不开门的日期
+//
+// 没有填写的星期（如周末）和餐次视为不开门
+type CanteenProvider struct {
+	source string
+	name   string
+	client *http.Client
+
+	mu      sync.Mutex
+	cached  *canteenMenu
+	fetched time.Time
+}
+
+// canteenMenu 菜单文件
+type canteenMenu struct {
+	Name     string                  `yaml:"name"`
+	Cost     int                     `yaml:"cost"`
+	Rating   float64                 `yaml:"rating"`
+	OpenTime string                  `yaml:"open_time"`
+	Days     map[string]canteenMeals `yaml:"days"`
+	Closed   []string                `yaml:"closed"`
+}
+
+// canteenMeals 一天的菜，可以按 lunch / dinner 分开填写，也可以是两餐相同的列表
+type canteenMeals struct {
+	Lunch  []string `yaml:"lunch"`
+	Dinner []string `yaml:"dinner"`
+}
+
+func (m *canteenMeals) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var dishes []string
+		if err := node.Decode(&dishes); err != nil {
+			return err
+		}
+		m.Lunch, m.Dinner = dishes, dishes
+		return nil
+	}
+	type plain canteenMeals
+	return node.Decode((*plain)(m))
+}
+
+// 星期的写法
+var canteenWeekdays = map[string]time.Weekday{
+	"周一": time.Monday, "周二": time.Tuesday, "周三": time.Wednesday, "周四": time.Thursday,
+	"周五": time.Friday, "周六": time.Saturday, "周日": time.Sunday, "周天": time.Sunday,
+	"星期一": time.Monday, "星期二": time.Tuesday, "星期三": time.Wednesday, "星期四": time.Thursday,
+	"星期五": time.Friday, "星期六": time.Saturday, "星期日": time.Sunday, "星期天": time.Sunday,
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+	"1": time.Monday, "2": time.Tuesday, "3": time.Wednesday, "4": time.Thursday,
+	"5": time.Friday, "6": time.Saturday, "7": time.Sunday,
+}
+
+// NewCanteenProvider 创建食堂菜单来源，source 为文件路径或 http(s) 地址，name 为菜单中没有填写名称时使用的名称
+func NewCanteenProvider(source, name string) *CanteenProvider {
+	return &CanteenProvider{
+		source: source,
+		name:   name,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: network.Transport(network.Canteen),
+		},
+	}
+}
+
+// Candidate 某天某餐的食堂候选（mealType 为 lunch / dinner），不开门时返回 nil
+func (c *CanteenProvider) Candidate(mealType string, day time.Time) (*Restaurant, error) {
+	menu, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	date := day.Format("2006-01-02")
+	for _, closed := range menu.Closed {
+		if closed == date {
+			return nil, nil
+		}
+	}
+
+	var dishes []string
+	for key, meals := range menu.Days {
+		if wd, ok := canteenWeekdays[strings.ToLower(strings.TrimSpace(key))]; ok && wd == day.Weekday() {
+			dishes = meals.Lunch
+			if mealType == "dinner" {
+				dishes = meals.Dinner
+			}
+			break
+		}
+	}
+	if len(dishes) == 0 {
+		return nil, nil
+	}
+
+	r := &Restaurant{
+		ID:       CanteenID,
+		Name:     menu.Name,
+		Type:     "餐饮服务;食堂",
+		Distance: "0",
+		OpenTime: menu.OpenTime,
+		Delivery: DeliveryNo,
+		Menu:     dishes,
+	}
+	if r.Name == "" {
+		r.Name = c.name
+	}
+	if menu.Cost > 0 {
+		r.Cost = strconv.Itoa(menu.Cost)
+	}
+	if menu.Rating > 0 {
+		r.Rating = fmt.Sprintf("%.1f", menu.Rating)
+	}
+	return r, nil
+}
+
+// load 读取菜单，URL 的内容缓存 10 分钟，请求失败时使用上次的结果
+func (c *CanteenProvider) load() (*canteenMenu, error) {
+	if !isURL(c.source) {
+		data, err := os.ReadFile(c.source)
+		if err != nil {
+			return nil, fmt.Errorf("读取食堂菜单失败: %v", err)
+		}
+		return parseCanteenMenu(data)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.fetched) < canteenCacheDuration {
+		return c.cached, nil
+	}
+	menu, err := c.fetch()
+	if err != nil {
+		if c.cached != nil {
+			return c.cached, nil
+		}
+		return nil, err
+	}
+	c.cached, c.fetched = menu, time.Now()
+	return menu, nil
+}
+
+func (c *CanteenProvider) fetch() (*canteenMenu, error) {
+	resp, err := c.client.Get(c.source)
+	if err != nil {
+		return nil, fmt.Errorf("获取食堂菜单失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取食堂菜单失败: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("获取食堂菜单失败: %v", err)
+	}
+	return parseCanteenMenu(data)
+}
+
+// parseCanteenMenu 解析菜单（JSON 也按 YAML 解析），检查星期的写法
+func parseCanteenMenu(data []byte) (*canteenMenu, error) {
+	var menu canteenMenu
+	if err := yaml.Unmarshal(data, &menu); err != nil {
+		return nil, fmt.Errorf("解析食堂菜单失败: %v", err)
+	}
+	for key := range menu.Days {
+		if _, ok := canteenWeekdays[strings.ToLower(strings.TrimSpace(key))]; !ok {
+			return nil, fmt.Errorf("食堂菜单中无效的星期: %s（如 周一、monday、1）", key)
+		}
+	}
+	return &menu, nil
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 规则条件表达式（天气→饮食规则、餐厅权重规则共用）
+//
+//   - 数值比较：数值字段与数字比较，运算符 < <= > >= == !=，如 "temp <= 5"
+//   - 文本包含：文本字段 ~ 文字（包含）、!~ 文字（不包含），如 "text ~ 雨"
+//   - 布尔字段：直接写字段名，前面加 ! 取反，如 "!severe"
+//   - 组合：&& 优先于 ||，不支持括号
+//
+// 可用的字段由 condFields 决定
+
+// condFields 表达式中可用的字段
+type condFields struct {
+	numbers map[string]bool
+	texts   map[string]bool
+	flags   map[string]bool
+}
+
+// condValues 求值时提供字段的值
+type condValues interface {
+	number(field string) float64
+	text(field string) string
+	flag(field string) bool
+}
+
+// condition 编译后的表达式：外层 ||，内层 &&，为空表示总是成立
+type condition [][]condTerm
+
+// condTerm 单个比较条件
+type condTerm struct {
+	field  string
+	kind   string // number / text / flag
+	op     string
+	value  float64
+	text   string
+	negate bool
+}
+
+// parseCondition 解析条件表达式
+func parseCondition(expr string, fields condFields) (condition, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	var result condition
+	for _, orPart := range strings.Split(expr, "||") {
+		var all []condTerm
+		for _, andPart := range strings.Split(orPart, "&&") {
+			c, err := parseCondTerm(strings.TrimSpace(andPart), fields)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, c)
+		}
+		result = append(result, all)
+	}
+	return result, nil
+}
+
+func parseCondTerm(term string, fields condFields) (condTerm, error) {
+	if term == "" {
+		return condTerm{}, fmt.Errorf("条件为空")
+	}
+
+	// 布尔字段
+	name := strings.TrimPrefix(term, "!")
+	if fields.flags[name] {
+		return condTerm{field: name, kind: "flag", negate: name != term}, nil
+	}
+
+	// 文本包含
+	for _, op := range []string{"!~", "~"} {
+		field, text, ok := strings.Cut(term, op)
+		if !ok {
+			continue
+		}
+		field = strings.TrimSpace(field)
+		if !fields.texts[field] {
+			return condTerm{}, fmt.Errorf("~ 只能用于文本字段: %s", strings.Join(sortedKeys(fields.texts), " / "))
+		}
+		return condTerm{field: field, kind: "text", text: strings.TrimSpace(text), negate: op == "!~"}, nil
+	}
+
+	// 数值比较（先匹配两个字符的运算符）
+	for _, op := range []string{"<=", ">=", "==", "!=", "<", ">"} {
+		field, value, ok := strings.Cut(term, op)
+		if !ok {
+			continue
+		}
+		field = strings.TrimSpace(field)
+		if !fields.numbers[field] {
+			return condTerm{}, fmt.Errorf("未知的字段: %s", field)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return condTerm{}, fmt.Errorf("无效的数值: %s", value)
+		}
+		return condTerm{field: field, kind: "number", op: op, value: v}, nil
+	}
+
+	return condTerm{}, fmt.Errorf("无法解析: %s", term)
+}
+
+// eval 表达式是否成立
+func (c condition) eval(v condValues) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, all := range c {
+		ok := true
+		for _, t := range all {
+			if !t.eval(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t condTerm) eval(v condValues) bool {
+	var result bool
+	switch t.kind {
+	case "flag":
+		result = v.flag(t.field)
+	case "text":
+		result = strings.Contains(v.text(t.field), t.text)
+	default:
+		n := v.number(t.field)
+		switch t.op {
+		case "<=":
+			result = n <= t.value
+		case ">=":
+			result = n >= t.value
+		case "==":
+			result = n == t.value
+		case "!=":
+			result = n != t.value
+		case "<":
+			result = n < t.value
+		case ">":
+			result = n > t.value
+		}
+	}
+	return result != t.negate
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
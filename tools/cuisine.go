@@ -0,0 +1,137 @@
+package tools
+
+import "strings"
+
+// 规范化菜系分类
+// 高德的类型字符串（如 "餐饮服务;中餐厅;四川菜(川菜)"）写法不统一，
+// 偏好匹配、排除过滤和统计都统一使用这里的分类
+const (
+	CuisineHotpot     = "火锅"
+	CuisineSichuan    = "川菜"
+	CuisineHunan      = "湘菜"
+	CuisineCantonese  = "粤菜"
+	CuisineDongbei    = "东北菜"
+	CuisineShanghai   = "本帮菜"
+	CuisineShandong   = "鲁菜"
+	CuisineAnhui      = "徽菜"
+	CuisineNorthwest  = "西北菜"
+	CuisineYunGui     = "云贵菜"
+	CuisineHalal      = "清真菜"
+	CuisineSeafood    = "海鲜"
+	CuisineHomestyle  = "家常菜"
+	CuisineBBQ        = "烧烤"
+	CuisineJapanese   = "日料"
+	CuisineKorean     = "韩餐"
+	CuisineSEAsian    = "东南亚菜"
+	CuisineWestern    = "西餐"
+	CuisineBurger     = "汉堡炸鸡"
+	CuisinePizza      = "披萨"
+	CuisineNoodle     = "面食"
+	CuisineRiceNoodle = "米粉米线"
+	CuisineDumpling   = "饺子馄饨"
+	CuisineMalatang   = "麻辣烫"
+	CuisineFastFood   = "快餐"
+	CuisineSnack      = "小吃"
+	CuisineBuffet     = "自助餐"
+	CuisineVegetarian = "素食"
+	CuisineCanteen    = "食堂"
+	CuisineDessert    = "甜品"
+	CuisineDrinks     = "饮品"
+	CuisineCoffee     = "咖啡"
+	CuisineOther      = "其他"
+)
+
+// cuisineRules 类型/名称关键词到规范化分类的映射
+// 按顺序匹配，越具体的规则越靠前（"川味火锅" 应归为火锅而不是川菜）
+var cuisineRules = []struct {
+	cuisine  string
+	keywords []string
+}{
+	{CuisineHotpot, []string{"火锅", "涮肉", "串串", "冒菜"}},
+	{CuisineMalatang, []string{"麻辣烫", "麻辣拌"}},
+	{CuisineBBQ, []string{"烧烤", "烤肉", "烤串", "烤鱼"}},
+	{CuisineBuffet, []string{"自助餐"}},
+	{CuisineCanteen, []string{"食堂"}},
+	{CuisineVegetarian, []string{"素食", "素菜"}},
+	{CuisineJapanese, []string{"日本料理", "日料", "寿司", "居酒屋", "日式"}},
+	{CuisineKorean, []string{"韩国料理", "韩餐", "韩式", "部队锅"}},
+	{CuisineSEAsian, []string{"东南亚", "泰国", "泰餐", "越南", "印度", "马来", "新加坡"}},
+	{CuisinePizza, []string{"披萨", "比萨", "必胜客"}},
+	{CuisineBurger, []string{"汉堡", "炸鸡", "肯德基", "麦当劳", "德克士", "华莱士", "塔斯汀"}},
+	{CuisineWestern, []string{"西餐", "牛排", "意大利", "法国", "墨西哥"}},
+	{CuisineCoffee, []string{"咖啡"}},
+	{CuisineDrinks, []string{"奶茶", "茶饮", "冷饮", "果汁", "茶艺"}},
+	{CuisineDessert, []string{"甜品", "糕饼", "面包", "蛋糕", "烘焙"}},
+	{CuisineSichuan, []string{"川菜", "四川菜", "重庆菜"}},
+	{CuisineHunan, []string{"湘菜", "湖南菜"}},
+	{CuisineCantonese, []string{"粤菜", "广东菜", "潮汕", "茶餐厅", "港式", "早茶"}},
+	{CuisineDongbei, []string{"东北菜"}},
+	{CuisineShanghai, []string{"本帮菜", "上海菜", "江浙菜", "浙菜", "苏菜"}},
+	{CuisineShandong, []string{"鲁菜", "山东菜"}},
+	{CuisineAnhui, []string{"徽菜", "安徽菜"}},
+	{CuisineNorthwest, []string{"西北菜", "新疆", "陕西", "西北"}},
+	{CuisineYunGui, []string{"云南菜", "贵州菜", "云贵"}},
+	{CuisineHalal, []string{"清真"}},
+	{CuisineSeafood, []string{"海鲜"}},
+	{CuisineRiceNoodle, []string{"米线", "米粉", "螺蛳粉", "酸辣粉", "肠粉"}},
+	{CuisineNoodle, []string{"面馆", "拉面", "面", "刀削", "油泼"}},
+	{CuisineDumpling, []string{"饺子", "馄饨", "抄手", "包子", "生煎"}},
+	{CuisineFastFood, []string{"快餐", "简餐", "盖饭", "拌饭", "便当"}},
+	{CuisineSnack, []string{"小吃", "煎饼", "肉夹馍", "凉皮"}},
+	{CuisineHomestyle, []string{"家常菜", "私房菜", "农家菜", "中餐厅", "中餐"}},
+}
+
+// CanonicalCuisine 根据高德类型和餐厅名称得到规范化菜系
+// 先按类型匹配（更可靠），匹配不到再按名称
+func CanonicalCuisine(typeStr, name string) string {
+	// 去掉 "餐饮服务" 这类通用前缀，避免干扰
+	typeStr = strings.ReplaceAll(typeStr, "餐饮服务", "")
+
+	for _, text := range []string{typeStr, name} {
+		for _, rule := range cuisineRules {
+			for _, kw := range rule.keywords {
+				if strings.Contains(text, kw) {
+					return rule.cuisine
+				}
+			}
+		}
+	}
+	return CuisineOther
+}
+
+// CanonicalCuisines 返回所有规范化菜系（用于校验和展示）
+func CanonicalCuisines() []string {
+	cuisines := make([]string, 0, len(cuisineRules)+1)
+	for _, rule := range cuisineRules {
+		cuisines = append(cuisines, rule.cuisine)
+	}
+	return append(cuisines, CuisineOther)
+}
+
+// CuisineAliases 规范化菜系的默认别名（高德类型和店名中的各种写法），如 日料 -> 日本料理、寿司、居酒屋
+// 不是规范化菜系时返回 nil
+func CuisineAliases(cuisine string) []string {
+	for _, rule := range cuisineRules {
+		if rule.cuisine == cuisine {
+			return append([]string{}, rule.keywords...)
+		}
+	}
+	return nil
+}
+
+// IsCanonicalCuisine 是否为规范化菜系名称
+func IsCanonicalCuisine(name string) bool {
+	for _, c := range CanonicalCuisines() {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeCuisines 为所有餐厅填充规范化菜系
+func NormalizeCuisines(restaurants []Restaurant) {
+	for i := range restaurants {
+		restaurants[i].Cuisine = CanonicalCuisine(restaurants[i].Type, restaurants[i].Name)
+	}
+}
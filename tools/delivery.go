@@ -0,0 +1,46 @@
+package tools
+
+// DeliverySupport 是否支持外卖
+type DeliverySupport int
+
+const (
+	DeliveryUnknown DeliverySupport = iota // 数据来源没有标注
+	DeliveryYes
+	DeliveryNo
+)
+
+// 外卖估算参数：骑手速度约 15km/h，出餐约 15 分钟
+const (
+	riderMetersPerMinute = 250
+	prepMinutes          = 15
+)
+
+// EstimateDelivery 根据距离估算外卖送达时间（分钟）和配送费（元）
+// baseFee: 起步配送费（3 公里内），超出部分每公里加 1 元
+func EstimateDelivery(r *Restaurant, baseFee int) (minutes, fee int) {
+	dist := r.GetDistanceInt()
+	minutes = prepMinutes + (dist+riderMetersPerMinute-1)/riderMetersPerMinute
+	fee = baseFee
+	if dist > 3000 {
+		fee += (dist - 3000 + 999) / 1000
+	}
+	return minutes, fee
+}
+
+// MarkDelivery 为所有餐厅填充外卖估算
+func MarkDelivery(restaurants []Restaurant, baseFee int) {
+	for i := range restaurants {
+		restaurants[i].DeliveryMinutes, restaurants[i].DeliveryFee = EstimateDelivery(&restaurants[i], baseFee)
+	}
+}
+
+// FilterNoDelivery 过滤掉明确不支持外卖的餐厅（数据来源没有标注的保留）
+func FilterNoDelivery(restaurants []Restaurant) []Restaurant {
+	filtered := make([]Restaurant, 0, len(restaurants))
+	for _, r := range restaurants {
+		if r.Delivery != DeliveryNo {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,67 @@
+package tools
+
+import "strings"
+
+// FakeRestaurantProvider 内存中的餐厅数据源，用于测试或离线演示
+// 按距离过滤半径，关键词匹配名称和类型
+type FakeRestaurantProvider struct {
+	Restaurants []Restaurant
+	Err         error // 非 nil 时每次搜索都返回该错误
+
+	Calls int // 已调用 SearchNearby 的次数
+}
+
+// SearchNearby 返回半径内匹配关键词的预置餐厅
+func (f *FakeRestaurantProvider) SearchNearby(lat, lng string, radius int, keyword string) ([]Restaurant, error) {
+	f.Calls++
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	restaurants := make([]Restaurant, 0, len(f.Restaurants))
+	for _, r := range f.Restaurants {
+		if radius > 0 && r.GetDistanceInt() > radius {
+			continue
+		}
+		if keyword != "" && !strings.Contains(r.Name+r.Type, keyword) {
+			continue
+		}
+		restaurants = append(restaurants, r)
+	}
+	return restaurants, nil
+}
+
+// FakeWeatherProvider 固定返回预置天气的数据源
+type FakeWeatherProvider struct {
+	Info     *WeatherInfo
+	Forecast []WeatherInfo
+	Warnings []WeatherWarning
+	Err      error
+}
+
+// GetWeather 返回预置天气
+func (f *FakeWeatherProvider) GetWeather(city string) (*WeatherInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Info, nil
+}
+
+// GetWarnings 返回预置的气象预警
+func (f *FakeWeatherProvider) GetWarnings(city string) ([]WeatherWarning, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Warnings, nil
+}
+
+// GetForecast 返回预置的天气预报（hours<=0 返回全部）
+func (f *FakeWeatherProvider) GetForecast(city string, hours int) ([]WeatherInfo, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if hours > 0 && len(f.Forecast) > hours {
+		return f.Forecast[:hours], nil
+	}
+	return f.Forecast, nil
+}
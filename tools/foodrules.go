@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FoodRule 天气→饮食规则
+//
+// 条件表达式（语法见 condition.go）支持：
+//   - 数值比较：temp / feels / humidity / pop / aqi / wind 与数字比较，如 "temp <= 5"
+//   - 天气描述：text ~ 雨（包含）、text !~ 雨（不包含）
+//   - 天气状况：bad（不适合走远路）、severe（大雨或重度污染），前面加 ! 取反
+//   - 组合：&& 优先于 ||，不支持括号
+type FoodRule struct {
+	When       string         `yaml:"when"`       // 条件表达式，留空表示总是生效
+	Group      string         `yaml:"group"`      // 同一组只取第一条命中的规则（留空则每条独立生效）
+	Suggestion string         `yaml:"suggestion"` // 给出的饮食建议
+	Boosts     map[string]int `yaml:"boosts"`     // 菜系加减分，如 火锅: 10
+}
+
+// FoodAdvice 规则匹配结果
+type FoodAdvice struct {
+	Suggestion string         // 各条命中规则的建议，用"；"连接
+	Boosts     map[string]int // 各菜系的加减分（多条规则累加）
+}
+
+// FoodRuleSet 编译后的规则集
+type FoodRuleSet struct {
+	rules []compiledFoodRule
+}
+
+type compiledFoodRule struct {
+	FoodRule
+	cond condition
+}
+
+// DefaultFoodRules 默认规则（按气温给建议，雨天和空气污染时提醒就近）
+var DefaultFoodRules = []FoodRule{
+	{When: "temp <= 5", Group: "temp", Suggestion: "天气寒冷，推荐热汤、火锅、羊肉等暖身食物", Boosts: map[string]int{CuisineHotpot: 10}},
+	{When: "temp <= 15", Group: "temp", Suggestion: "天气偏凉，推荐热食、炖菜、面食等"},
+	{When: "temp <= 25", Group: "temp", Suggestion: "天气舒适，各类食物都适合"},
+	{When: "temp <= 32", Group: "temp", Suggestion: "天气炎热，推荐清淡、凉菜、冷面等解暑食物"},
+	{Group: "temp", Suggestion: "天气酷热，推荐解暑降温的食物，注意多喝水", Boosts: map[string]int{CuisineHotpot: -10}},
+
+	{When: "aqi > 200", Group: "outdoor", Suggestion: "空气污染严重，尽量选最近的餐厅或点外卖，少在室外停留"},
+	{When: "severe", Group: "outdoor", Suggestion: "雨势较大，尽量选最近的餐厅或点外卖"},
+	{When: "pop >= 60 || text ~ 雨", Group: "outdoor", Suggestion: "可能下雨，记得带伞，优先考虑近一点的餐厅"},
+	{When: "aqi > 150", Group: "outdoor", Suggestion: "空气质量较差，建议就近用餐"},
+}
+
+var defaultFoodRuleSet, _ = NewFoodRuleSet(DefaultFoodRules)
+
+// NewFoodRuleSet 编译规则，rules 为空时使用默认规则
+func NewFoodRuleSet(rules []FoodRule) (*FoodRuleSet, error) {
+	if len(rules) == 0 {
+		rules = DefaultFoodRules
+	}
+
+	set := &FoodRuleSet{}
+	for i, rule := range rules {
+		cond, err := parseCondition(rule.When, weatherFields)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条规则 %q: %v", i+1, rule.When, err)
+		}
+		set.rules = append(set.rules, compiledFoodRule{FoodRule: rule, cond: cond})
+	}
+	return set, nil
+}
+
+// Evaluate 计算天气对应的饮食建议和菜系加减分
+func (s *FoodRuleSet) Evaluate(w *WeatherInfo) FoodAdvice {
+	advice := FoodAdvice{Boosts: make(map[string]int)}
+	if s == nil || w == nil {
+		return advice
+	}
+
+	var suggestions []string
+	matchedGroups := make(map[string]bool)
+	for _, rule := range s.rules {
+		if rule.Group != "" && matchedGroups[rule.Group] {
+			continue
+		}
+		if !rule.matches(w) {
+			continue
+		}
+		if rule.Group != "" {
+			matchedGroups[rule.Group] = true
+		}
+		if rule.Suggestion != "" {
+			suggestions = append(suggestions, rule.Suggestion)
+		}
+		for cuisine, boost := range rule.Boosts {
+			advice.Boosts[cuisine] += boost
+		}
+	}
+
+	advice.Suggestion = strings.Join(suggestions, "；")
+	return advice
+}
+
+// Boost 餐厅在当前天气下的加减分（按统一菜系匹配，其次匹配类型字符串）
+func (a FoodAdvice) Boost(r Restaurant) int {
+	total := 0
+	for cuisine, boost := range a.Boosts {
+		if r.Cuisine == cuisine || strings.Contains(r.Type, cuisine) {
+			total += boost
+		}
+	}
+	return total
+}
+
+func (r compiledFoodRule) matches(w *WeatherInfo) bool {
+	return r.cond.eval(weatherValues{w})
+}
+
+// weatherFields 天气规则中可用的字段
+var weatherFields = condFields{
+	numbers: map[string]bool{"temp": true, "feels": true, "humidity": true, "pop": true, "aqi": true, "wind": true},
+	texts:   map[string]bool{"text": true},
+	flags:   map[string]bool{"bad": true, "severe": true},
+}
+
+// weatherValues 天气规则求值时的字段值
+type weatherValues struct {
+	w *WeatherInfo
+}
+
+func (v weatherValues) number(field string) float64 {
+	var s string
+	switch field {
+	case "temp":
+		s = v.w.Temp
+	case "feels":
+		s = v.w.FeelsLike
+	case "humidity":
+		s = v.w.Humidity
+	case "pop":
+		s = v.w.Pop
+	case "aqi":
+		s = v.w.AQI
+	case "wind":
+		s = v.w.WindScale
+	}
+	n := 0.0
+	fmt.Sscanf(s, "%g", &n)
+	return n
+}
+
+func (v weatherValues) text(field string) string {
+	return v.w.Text
+}
+
+func (v weatherValues) flag(field string) bool {
+	if field == "severe" {
+		return v.w.IsSevereWeather()
+	}
+	return v.w.IsBadWeather()
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"meal-agent/network"
+)
+
+// Geocoder 高德地理编码：把地址转换为坐标，结果缓存在文件中（地址不变时不再请求接口）
+type Geocoder struct {
+	apiKey    string
+	cachePath string
+	client    *http.Client
+	mu        sync.Mutex
+}
+
+// GeoPoint 地址解析的结果
+type GeoPoint struct {
+	Lat     string `json:"lat"`
+	Lng     string `json:"lng"`
+	City    string `json:"city,omitempty"`
+	Address string `json:"formatted_address,omitempty"` // 高德规范化后的地址
+}
+
+// NewGeocoder 创建地理编码客户端，cacheDir 为空时不缓存
+func NewGeocoder(apiKey, cacheDir string) *Geocoder {
+	g := &Geocoder{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second, Transport: network.Transport(network.Amap)},
+	}
+	if cacheDir != "" {
+		g.cachePath = filepath.Join(cacheDir, "geocode.json")
+	}
+	return g
+}
+
+// Geocode 解析地址的坐标，city 用于限定查询的城市（可以为空）
+func (g *Geocoder) Geocode(address, city string) (*GeoPoint, error) {
+	address = strings.TrimSpace(address)
+	key := city + "|" + address
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cache := g.loadCache()
+	if p, ok := cache[key]; ok {
+		return &p, nil
+	}
+
+	if g.apiKey == "" {
+		return nil, fmt.Errorf("解析地址需要填写 api.amap_key")
+	}
+	p, err := g.request(address, city)
+	if err != nil {
+		return nil, err
+	}
+	cache[key] = *p
+	g.saveCache(cache)
+	return p, nil
+}
+
+// request 请求高德地理编码接口
+func (g *Geocoder) request(address, city string) (*GeoPoint, error) {
+	params := url.Values{}
+	params.Set("key", g.apiKey)
+	params.Set("address", address)
+	if city != "" {
+		params.Set("city", city)
+	}
+
+	resp, err := g.client.Get("https://restapi.amap.com/v3/geocode/geo?" + params.Encode())
+	if err != nil {
+		// 错误中的 URL 带有 Key，只保留原因
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return nil, fmt.Errorf("地址解析请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var result struct {
+		Status   string `json:"status"`
+		Info     string `json:"info"`
+		Geocodes []struct {
+			FormattedAddress flexString `json:"formatted_address"`
+			City             flexString `json:"city"`
+			Province         flexString `json:"province"`
+			Location         string     `json:"location"`
+		} `json:"geocodes"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.Status != "1" {
+		return nil, fmt.Errorf("地址解析失败: %s", result.Info)
+	}
+	if len(result.Geocodes) == 0 {
+		return nil, fmt.Errorf("没有找到地址: %s", address)
+	}
+
+	geo := result.Geocodes[0]
+	parts := strings.Split(geo.Location, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("地址解析返回的坐标无效: %s", geo.Location)
+	}
+	// 直辖市的 city 为空，用省份
+	cityName := string(geo.City)
+	if cityName == "" {
+		cityName = string(geo.Province)
+	}
+	return &GeoPoint{
+		Lat:     parts[1],
+		Lng:     parts[0],
+		City:    cityName,
+		Address: string(geo.FormattedAddress),
+	}, nil
+}
+
+// loadCache 读取缓存文件，不存在或损坏时为空
+func (g *Geocoder) loadCache() map[string]GeoPoint {
+	cache := make(map[string]GeoPoint)
+	if g.cachePath == "" {
+		return cache
+	}
+	data, err := os.ReadFile(g.cachePath)
+	if err != nil {
+		return cache
+	}
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+// saveCache 写入缓存文件（失败时忽略，下次重新请求）
+func (g *Geocoder) saveCache(cache map[string]GeoPoint) {
+	if g.cachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(g.cachePath), 0755); err != nil {
+		return
+	}
+	os.WriteFile(g.cachePath, data, 0644)
+}
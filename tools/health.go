@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HealthProfile 一种健康状况的饮食指导
+type HealthProfile struct {
+	Name     string          `yaml:"name"`     // 显示名称，如 痛风
+	Exclude  []string        `yaml:"exclude"`  // 不推荐的菜系、类型或名称关键词（直接排除）
+	Guidance string          `yaml:"guidance"` // 写进 prompt 的饮食指导
+	Warnings []HealthWarning `yaml:"warnings"` // 推荐某些餐厅时附带的提醒
+}
+
+// HealthWarning 推荐某类餐厅时附带的提醒
+type HealthWarning struct {
+	Match []string `yaml:"match"` // 菜系、类型或名称关键词，任意一个匹配即提醒
+	Text  string   `yaml:"text"`  // 提醒内容，如 "别配啤酒"
+}
+
+// DefaultHealthProfiles 内置的健康状况（health.conditions 中写键名或中文名称，health.profiles 中同名的会覆盖）
+// 指导只涉及怎么吃，不写功效，以免被输出过滤当成医疗宣传去掉
+var DefaultHealthProfiles = map[string]HealthProfile{
+	"high_blood_sugar": {
+		Name:     "高血糖",
+		Exclude:  []string{CuisineDessert, CuisineDrinks},
+		Guidance: "用户需要控制血糖：优先推荐蔬菜多、有粗粮、少油少糖的，主食适量；不要推荐甜品和含糖饮料，少选糖醋、红烧、勾芡等偏甜的菜，提醒先吃菜和肉、最后吃主食",
+		Warnings: []HealthWarning{
+			{Match: []string{CuisineNoodle, CuisineRiceNoodle, CuisineDumpling}, Text: "以主食为主，控制分量，多加一份青菜"},
+			{Match: []string{CuisineBuffet}, Text: "自助容易吃多，少拿主食和甜点"},
+			{Match: []string{CuisineCantonese, CuisineShanghai}, Text: "叉烧、红烧、糖醋类的菜偏甜，少点"},
+			{Match: []string{CuisineFastFood, CuisineBurger, CuisinePizza}, Text: "精制主食多，饮料换成无糖的"},
+		},
+	},
+	"gout": {
+		Name:     "痛风",
+		Exclude:  []string{CuisineSeafood},
+		Guidance: "用户有痛风（高尿酸）：不要推荐海鲜和以动物内脏为主的店，少喝浓汤和火锅汤，不要喝啤酒和含糖饮料，提醒多喝水",
+		Warnings: []HealthWarning{
+			{Match: []string{CuisineHotpot}, Text: "火锅汤底嘌呤高，别喝汤，少点海鲜和内脏，别配啤酒"},
+			{Match: []string{CuisineBBQ}, Text: "海鲜配啤酒最伤，别喝啤酒，少点海鲜和内脏"},
+			{Match: []string{CuisineJapanese}, Text: "刺身、鱼籽、贝类嘌呤高，少点，别配啤酒"},
+			{Match: []string{CuisineMalatang}, Text: "少放海鲜和内脏，别喝汤"},
+		},
+	},
+	"pregnancy": {
+		Name:     "孕期",
+		Exclude:  []string{"酒吧"},
+		Guidance: "用户在孕期：推荐卫生、全熟、营养均衡的，多蛋白质和蔬菜；不要推荐生食（刺身、生蚝、醉蟹）、没熟透的肉和蛋以及酒精，咖啡和浓茶适量，少吃重辣和腌制的",
+		Warnings: []HealthWarning{
+			{Match: []string{CuisineJapanese}, Text: "不要吃刺身、生鱼片和溏心蛋，选熟食"},
+			{Match: []string{CuisineSeafood}, Text: "海鲜要全熟，不吃生蚝、醉蟹和大型鱼"},
+			{Match: []string{CuisineHotpot, CuisineBBQ}, Text: "肉和海鲜要烫熟、烤熟"},
+			{Match: []string{CuisineCoffee}, Text: "每天咖啡不超过一杯，可以选低因的"},
+			{Match: []string{CuisineSichuan, CuisineHunan}, Text: "可以要微辣或不辣"},
+		},
+	},
+	"hypertension": {
+		Name:     "高血压",
+		Guidance: "用户需要控制血压：推荐少盐、清淡的，少选腌制、卤味和重口味的菜，提醒点菜时要求少盐",
+		Warnings: []HealthWarning{
+			{Match: []string{CuisineHotpot, CuisineMalatang}, Text: "汤底和蘸料盐多，少蘸，别喝汤"},
+			{Match: []string{CuisineSichuan, CuisineHunan, CuisineDongbei, CuisineBBQ}, Text: "口味重、盐多，点菜时说少盐"},
+			{Match: []string{CuisineFastFood, CuisineBurger, CuisinePizza}, Text: "钠含量高，少放酱料"},
+		},
+	},
+}
+
+// healthAliases 健康状况的中文写法
+var healthAliases = map[string]string{
+	"高血糖": "high_blood_sugar", "糖尿病": "high_blood_sugar", "控糖": "high_blood_sugar", "diabetes": "high_blood_sugar",
+	"痛风": "gout", "高尿酸": "gout",
+	"孕期": "pregnancy", "怀孕": "pregnancy", "pregnant": "pregnancy",
+	"高血压": "hypertension",
+}
+
+// HealthGuide 组合后的健康指导：可以同时有多种健康状况，排除和提醒合并
+type HealthGuide struct {
+	profiles []HealthProfile
+}
+
+// NewHealthGuide 组合 conditions 中的健康状况，custom 为自定义或覆盖内置的状况；没有选择时返回 nil
+func NewHealthGuide(conditions []string, custom map[string]HealthProfile) (*HealthGuide, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	guide := &HealthGuide{}
+	seen := make(map[string]bool)
+	for _, name := range conditions {
+		key, profile, ok := findHealthProfile(name, custom)
+		if !ok {
+			return nil, fmt.Errorf("未知的健康状况: %s（可用 %s，或在 health.profiles 中自定义）", name, strings.Join(HealthProfileNames(custom), " / "))
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if profile.Name == "" {
+			profile.Name = key
+		}
+		guide.profiles = append(guide.profiles, profile)
+	}
+	return guide, nil
+}
+
+// findHealthProfile 按键名或中文写法查找健康状况，自定义的优先
+func findHealthProfile(name string, custom map[string]HealthProfile) (string, HealthProfile, bool) {
+	key := strings.TrimSpace(name)
+	if p, ok := custom[key]; ok {
+		return key, p, true
+	}
+	if alias, ok := healthAliases[strings.ToLower(key)]; ok {
+		key = alias
+		if p, ok := custom[key]; ok {
+			return key, p, true
+		}
+	}
+	p, ok := DefaultHealthProfiles[strings.ToLower(key)]
+	return strings.ToLower(key), p, ok
+}
+
+// HealthProfileNames 可用的健康状况（内置和自定义的键名）
+func HealthProfileNames(custom map[string]HealthProfile) []string {
+	names := make([]string, 0, len(DefaultHealthProfiles)+len(custom))
+	for name := range DefaultHealthProfiles {
+		names = append(names, name)
+	}
+	for name := range custom {
+		if _, ok := DefaultHealthProfiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names 各健康状况的显示名称
+func (g *HealthGuide) Names() []string {
+	if g == nil {
+		return nil
+	}
+	names := make([]string, 0, len(g.profiles))
+	for _, p := range g.profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Exclude 所有健康状况要排除的关键词（去重）
+func (g *HealthGuide) Exclude() []string {
+	if g == nil {
+		return nil
+	}
+	var exclude []string
+	seen := make(map[string]bool)
+	for _, p := range g.profiles {
+		for _, e := range p.Exclude {
+			if e != "" && !seen[e] {
+				seen[e] = true
+				exclude = append(exclude, e)
+			}
+		}
+	}
+	return exclude
+}
+
+// Guidance 写进 prompt 的饮食指导，每种健康状况一行
+func (g *HealthGuide) Guidance() string {
+	if g == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range g.profiles {
+		if p.Guidance != "" {
+			sb.WriteString(p.Guidance + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// Warnings 推荐这家餐厅时要附带的提醒（多种健康状况的提醒都列出，相同的只列一次）
+func (g *HealthGuide) Warnings(r Restaurant) []string {
+	if g == nil {
+		return nil
+	}
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, p := range g.profiles {
+		for _, w := range p.Warnings {
+			if w.Text == "" || seen[w.Text] || !matchesAny(r, w.Match) {
+				continue
+			}
+			seen[w.Text] = true
+			text := w.Text
+			if len(g.profiles) > 1 {
+				text += "（" + p.Name + "）"
+			}
+			warnings = append(warnings, text)
+		}
+	}
+	return warnings
+}
+
+// matchesAny 餐厅的菜系、类型或名称是否匹配任意一个关键词（与 FilterByType 相同）
+func matchesAny(r Restaurant, keywords []string) bool {
+	for _, k := range keywords {
+		if k != "" && (r.Cuisine == k || strings.Contains(r.Type, k) || strings.Contains(r.Name, k)) {
+			return true
+		}
+	}
+	return false
+}
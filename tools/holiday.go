@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DayKind 日期类型
+type DayKind int
+
+const (
+	Workday DayKind = iota // 工作日（包括调休上班的周末）
+	Weekend                // 周末
+	Holiday                // 法定节假日
+)
+
+// 法定节假日和调休上班日（按国务院办公厅每年公布的安排查表，需要定期补充）
+var publicHolidays = []struct {
+	name        string
+	from, until string
+}{
+	{"元旦", "2025-01-01", "2025-01-01"},
+	{"春节", "2025-01-28", "2025-02-04"},
+	{"清明节", "2025-04-04", "2025-04-06"},
+	{"劳动节", "2025-05-01", "2025-05-05"},
+	{"端午节", "2025-05-31", "2025-06-02"},
+	{"国庆节", "2025-10-01", "2025-10-08"},
+	{"元旦", "2026-01-01", "2026-01-03"},
+	{"春节", "2026-02-15", "2026-02-23"},
+	{"清明节", "2026-04-04", "2026-04-06"},
+	{"劳动节", "2026-05-01", "2026-05-05"},
+	{"端午节", "2026-06-19", "2026-06-21"},
+	{"中秋节", "2026-09-25", "2026-09-27"},
+	{"国庆节", "2026-10-01", "2026-10-07"},
+}
+
+var makeupWorkdays = []string{
+	"2025-01-26", "2025-02-08", "2025-04-27", "2025-09-28", "2025-10-11",
+	"2026-01-04", "2026-02-14", "2026-02-28", "2026-05-09", "2026-09-20", "2026-10-10",
+}
+
+// HolidayCalendar 节假日日历：内置的法定节假日加上自定义的放假、上班日期
+type HolidayCalendar struct {
+	holidays map[string]string // 日期 -> 节日名称
+	workdays map[string]bool   // 调休上班的日期
+}
+
+// NewHolidayCalendar 创建节假日日历，extraHolidays、extraWorkdays 为补充的日期（如 2025-06-09），优先于内置的安排
+func NewHolidayCalendar(extraHolidays, extraWorkdays []string) (*HolidayCalendar, error) {
+	c := &HolidayCalendar{holidays: make(map[string]string), workdays: make(map[string]bool)}
+	for _, h := range publicHolidays {
+		from, _ := time.Parse("2006-01-02", h.from)
+		until, _ := time.Parse("2006-01-02", h.until)
+		for d := from; !d.After(until); d = d.AddDate(0, 0, 1) {
+			c.holidays[d.Format("2006-01-02")] = h.name
+		}
+	}
+	for _, d := range makeupWorkdays {
+		c.workdays[d] = true
+	}
+
+	for _, d := range extraHolidays {
+		day, err := parseDate(d)
+		if err != nil {
+			return nil, err
+		}
+		c.holidays[day] = "假期"
+		delete(c.workdays, day)
+	}
+	for _, d := range extraWorkdays {
+		day, err := parseDate(d)
+		if err != nil {
+			return nil, err
+		}
+		c.workdays[day] = true
+		delete(c.holidays, day)
+	}
+	return c, nil
+}
+
+// KindOf 日期类型，法定节假日同时返回节日名称
+func (c *HolidayCalendar) KindOf(t time.Time) (DayKind, string) {
+	day := t.Format("2006-01-02")
+	if name, ok := c.holidays[day]; ok {
+		return Holiday, name
+	}
+	if c.workdays[day] {
+		return Workday, ""
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return Weekend, ""
+	}
+	return Workday, ""
+}
+
+// parseDate 检查日期写法，返回 2006-01-02 格式
+func parseDate(s string) (string, error) {
+	day, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("无效的日期: %s（格式为 2025-06-09）", s)
+	}
+	return day.Format("2006-01-02"), nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"周日": time.Sunday, "周一": time.Monday, "周二": time.Tuesday, "周三": time.Wednesday,
+	"周四": time.Thursday, "周五": time.Friday, "周六": time.Saturday,
+	"星期日": time.Sunday, "星期天": time.Sunday, "周天": time.Sunday, "星期一": time.Monday, "星期二": time.Tuesday,
+	"星期三": time.Wednesday, "星期四": time.Thursday, "星期五": time.Friday, "星期六": time.Saturday,
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekday 解析星期的写法：周一~周日、星期一~星期日 或 mon~sun
+func ParseWeekday(s string) (time.Weekday, bool) {
+	d, ok := weekdayNames[strings.ToLower(strings.TrimSpace(s))]
+	return d, ok
+}
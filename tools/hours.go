@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OpenStatus 营业状态
+type OpenStatus int
+
+const (
+	OpenUnknown OpenStatus = iota // 没有营业时间信息
+	OpenNow                       // 营业中
+	ClosingSoon                   // 即将打烊
+	Closed                        // 已打烊 / 未开门
+)
+
+// Code 营业状态的英文代码（open / closing_soon / closed，未知时为空），用于 JSON 输出
+func (s OpenStatus) Code() string {
+	switch s {
+	case OpenNow:
+		return "open"
+	case ClosingSoon:
+		return "closing_soon"
+	case Closed:
+		return "closed"
+	}
+	return ""
+}
+
+// closingSoonWindow 距离打烊多久算"即将打烊"
+const closingSoonWindow = 30 * time.Minute
+
+// 匹配 "10:00-22:00"、"17:00~次日02:00" 这类时间段
+var hoursRangePattern = regexp.MustCompile(`(\d{1,2}):(\d{2})\s*[-~至到]\s*(次日)?(\d{1,2}):(\d{2})`)
+
+// ParseOpenStatus 根据营业时间字符串判断某一时刻的营业状态
+// 支持多个时间段（如 "10:00-14:00,17:00-22:00"）和跨午夜的时间段；
+// 星期描述（"周一至周五"）目前忽略，按每天营业处理
+func ParseOpenStatus(openTime string, t time.Time) OpenStatus {
+	if openTime == "" {
+		return OpenUnknown
+	}
+	if strings.Contains(openTime, "24小时") {
+		return OpenNow
+	}
+
+	matches := hoursRangePattern.FindAllStringSubmatch(openTime, -1)
+	if len(matches) == 0 {
+		return OpenUnknown
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	status := Closed
+	for _, m := range matches {
+		start := atoi(m[1])*60 + atoi(m[2])
+		end := atoi(m[4])*60 + atoi(m[5])
+		if m[3] != "" || end <= start {
+			end += 24 * 60 // 跨午夜
+		}
+
+		// 凌晨时刻也要检查前一天跨午夜的时间段
+		for _, cur := range []int{now, now + 24*60} {
+			if cur < start || cur >= end {
+				continue
+			}
+			if time.Duration(end-cur)*time.Minute <= closingSoonWindow {
+				status = ClosingSoon
+			} else {
+				return OpenNow
+			}
+		}
+	}
+	return status
+}
+
+// MarkOpenStatus 计算每个餐厅在 t 时刻的营业状态
+func MarkOpenStatus(restaurants []Restaurant, t time.Time) {
+	for i := range restaurants {
+		restaurants[i].OpenStatus = ParseOpenStatus(restaurants[i].OpenTime, t)
+	}
+}
+
+// FilterClosed 过滤掉已打烊的餐厅（营业时间未知的保留）
+func FilterClosed(restaurants []Restaurant) []Restaurant {
+	filtered := make([]Restaurant, 0, len(restaurants))
+	for _, r := range restaurants {
+		if r.OpenStatus != Closed {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// atoi 解析数字字符串（格式已由正则保证）
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
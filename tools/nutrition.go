@@ -0,0 +1,54 @@
+package tools
+
+// NutritionFacts 一人份一餐的营养估算
+type NutritionFacts struct {
+	Calories float64 // 热量（千卡）
+	Protein  float64 // 蛋白质（克）
+	Fat      float64 // 脂肪（克）
+}
+
+// cuisineNutrition 各菜系一人份正餐的典型营养（按常见点法粗略估算，只用于趋势统计）
+var cuisineNutrition = map[string]NutritionFacts{
+	CuisineHotpot:     {Calories: 1100, Protein: 45, Fat: 70},
+	CuisineSichuan:    {Calories: 950, Protein: 35, Fat: 55},
+	CuisineHunan:      {Calories: 900, Protein: 35, Fat: 50},
+	CuisineCantonese:  {Calories: 750, Protein: 35, Fat: 30},
+	CuisineDongbei:    {Calories: 1000, Protein: 38, Fat: 50},
+	CuisineShanghai:   {Calories: 850, Protein: 30, Fat: 38},
+	CuisineShandong:   {Calories: 900, Protein: 35, Fat: 42},
+	CuisineAnhui:      {Calories: 850, Protein: 32, Fat: 40},
+	CuisineNorthwest:  {Calories: 950, Protein: 38, Fat: 40},
+	CuisineYunGui:     {Calories: 800, Protein: 30, Fat: 35},
+	CuisineHalal:      {Calories: 900, Protein: 40, Fat: 40},
+	CuisineSeafood:    {Calories: 700, Protein: 50, Fat: 25},
+	CuisineHomestyle:  {Calories: 800, Protein: 30, Fat: 35},
+	CuisineBBQ:        {Calories: 1100, Protein: 50, Fat: 70},
+	CuisineJapanese:   {Calories: 700, Protein: 35, Fat: 22},
+	CuisineKorean:     {Calories: 850, Protein: 35, Fat: 35},
+	CuisineSEAsian:    {Calories: 800, Protein: 30, Fat: 35},
+	CuisineWestern:    {Calories: 950, Protein: 40, Fat: 50},
+	CuisineBurger:     {Calories: 1000, Protein: 35, Fat: 50},
+	CuisinePizza:      {Calories: 1000, Protein: 40, Fat: 45},
+	CuisineNoodle:     {Calories: 650, Protein: 22, Fat: 20},
+	CuisineRiceNoodle: {Calories: 600, Protein: 18, Fat: 20},
+	CuisineDumpling:   {Calories: 650, Protein: 25, Fat: 25},
+	CuisineMalatang:   {Calories: 700, Protein: 25, Fat: 35},
+	CuisineFastFood:   {Calories: 750, Protein: 28, Fat: 28},
+	CuisineSnack:      {Calories: 550, Protein: 15, Fat: 22},
+	CuisineBuffet:     {Calories: 1300, Protein: 55, Fat: 65},
+	CuisineVegetarian: {Calories: 600, Protein: 20, Fat: 22},
+	CuisineCanteen:    {Calories: 700, Protein: 28, Fat: 25},
+	CuisineDessert:    {Calories: 450, Protein: 8, Fat: 20},
+	CuisineDrinks:     {Calories: 300, Protein: 4, Fat: 10},
+	CuisineCoffee:     {Calories: 200, Protein: 6, Fat: 8},
+	CuisineOther:      {Calories: 800, Protein: 30, Fat: 35},
+}
+
+// EstimateNutrition 按菜系查表估算一餐的营养
+// cuisine 可以是规范化菜系或任意类型文字（如 "泰国菜"），name 为餐厅名称，都无法识别时按 "其他" 估算
+func EstimateNutrition(cuisine, name string) NutritionFacts {
+	if facts, ok := cuisineNutrition[cuisine]; ok {
+		return facts
+	}
+	return cuisineNutrition[CanonicalCuisine(cuisine, name)]
+}
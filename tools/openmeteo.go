@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"meal-agent/network"
+)
+
+// OpenMeteoClient 基于 Open-Meteo 的天气客户端（无需 API Key，全球覆盖）
+// 按坐标查询，GetWeather 的 city 参数不使用
+type OpenMeteoClient struct {
+	lat, lng string
+	client   *http.Client
+}
+
+// NewOpenMeteoClient 创建 Open-Meteo 客户端
+func NewOpenMeteoClient(lat, lng string) *OpenMeteoClient {
+	return &OpenMeteoClient{
+		lat: lat,
+		lng: lng,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: network.Transport(network.Weather),
+		},
+	}
+}
+
+// GetWeather 获取实时天气
+func (o *OpenMeteoClient) GetWeather(city string) (*WeatherInfo, error) {
+	var result struct {
+		Current struct {
+			Temperature   float64 `json:"temperature_2m"`
+			Apparent      float64 `json:"apparent_temperature"`
+			Humidity      float64 `json:"relative_humidity_2m"`
+			WeatherCode   int     `json:"weather_code"`
+			WindSpeed     float64 `json:"wind_speed_10m"`
+			WindDirection float64 `json:"wind_direction_10m"`
+		} `json:"current"`
+		Hourly struct {
+			Pop []float64 `json:"precipitation_probability"`
+		} `json:"hourly"`
+	}
+	if err := o.get("current=temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_direction_10m&hourly=precipitation_probability&forecast_hours=1", &result); err != nil {
+		return nil, err
+	}
+
+	c := result.Current
+	info := &WeatherInfo{
+		Temp:      fmt.Sprintf("%.0f", c.Temperature),
+		FeelsLike: fmt.Sprintf("%.0f", c.Apparent),
+		Text:      wmoWeatherText(c.WeatherCode),
+		WindDir:   windDirection(c.WindDirection),
+		WindScale: fmt.Sprintf("%d", beaufortScale(c.WindSpeed)),
+		Humidity:  fmt.Sprintf("%.0f", c.Humidity),
+	}
+	if len(result.Hourly.Pop) > 0 {
+		info.Pop = fmt.Sprintf("%.0f", result.Hourly.Pop[0])
+	}
+	if aqi, err := o.airQuality(); err == nil {
+		info.AQI = fmt.Sprintf("%.0f", aqi)
+	}
+	return info, nil
+}
+
+// airQuality 获取实时空气质量指数（美标 AQI）
+func (o *OpenMeteoClient) airQuality() (float64, error) {
+	reqURL := fmt.Sprintf(
+		"https://air-quality-api.open-meteo.com/v1/air-quality?latitude=%s&longitude=%s&current=us_aqi",
+		o.lat, o.lng,
+	)
+
+	resp, err := o.client.Get(reqURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Open-Meteo API错误: %s", resp.Status)
+	}
+
+	var result struct {
+		Current struct {
+			AQI *float64 `json:"us_aqi"`
+		} `json:"current"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	if result.Current.AQI == nil {
+		return 0, fmt.Errorf("Open-Meteo 未返回空气质量")
+	}
+	return *result.Current.AQI, nil
+}
+
+// GetForecast 获取未来 hours 小时的逐小时预报
+func (o *OpenMeteoClient) GetForecast(city string, hours int) ([]WeatherInfo, error) {
+	if hours <= 0 {
+		hours = 24
+	}
+
+	var result struct {
+		UTCOffsetSeconds int `json:"utc_offset_seconds"`
+		Hourly           struct {
+			Time          []string  `json:"time"`
+			Temperature   []float64 `json:"temperature_2m"`
+			Apparent      []float64 `json:"apparent_temperature"`
+			Humidity      []float64 `json:"relative_humidity_2m"`
+			Pop           []float64 `json:"precipitation_probability"`
+			WeatherCode   []int     `json:"weather_code"`
+			WindSpeed     []float64 `json:"wind_speed_10m"`
+			WindDirection []float64 `json:"wind_direction_10m"`
+		} `json:"hourly"`
+	}
+	params := fmt.Sprintf("hourly=temperature_2m,apparent_temperature,relative_humidity_2m,precipitation_probability,weather_code,wind_speed_10m,wind_direction_10m&forecast_hours=%d", hours)
+	if err := o.get(params, &result); err != nil {
+		return nil, err
+	}
+
+	h := result.Hourly
+	n := len(h.Time)
+	if len(h.Temperature) < n || len(h.Apparent) < n || len(h.Humidity) < n || len(h.Pop) < n ||
+		len(h.WeatherCode) < n || len(h.WindSpeed) < n || len(h.WindDirection) < n {
+		return nil, fmt.Errorf("Open-Meteo 返回数据不完整")
+	}
+
+	zone := time.FixedZone("", result.UTCOffsetSeconds)
+	forecasts := make([]WeatherInfo, 0, n)
+	for i := 0; i < n; i++ {
+		t, err := time.ParseInLocation("2006-01-02T15:04", h.Time[i], zone)
+		if err != nil {
+			continue
+		}
+		forecasts = append(forecasts, WeatherInfo{
+			Temp:      fmt.Sprintf("%.0f", h.Temperature[i]),
+			FeelsLike: fmt.Sprintf("%.0f", h.Apparent[i]),
+			Text:      wmoWeatherText(h.WeatherCode[i]),
+			WindDir:   windDirection(h.WindDirection[i]),
+			WindScale: fmt.Sprintf("%d", beaufortScale(h.WindSpeed[i])),
+			Humidity:  fmt.Sprintf("%.0f", h.Humidity[i]),
+			Pop:       fmt.Sprintf("%.0f", h.Pop[i]),
+			Time:      t,
+		})
+	}
+	return forecasts, nil
+}
+
+// get 请求 Open-Meteo 接口（风速单位 m/s，时间使用当地时区）
+func (o *OpenMeteoClient) get(params string, v interface{}) error {
+	reqURL := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%s&longitude=%s&wind_speed_unit=ms&timezone=auto&%s",
+		o.lat, o.lng, params,
+	)
+
+	resp, err := o.client.Get(reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Open-Meteo API错误: %s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// wmoWeatherText WMO 天气代码转中文描述
+func wmoWeatherText(code int) string {
+	switch code {
+	case 0:
+		return "晴"
+	case 1:
+		return "晴间多云"
+	case 2:
+		return "多云"
+	case 3:
+		return "阴"
+	case 45, 48:
+		return "雾"
+	case 51, 53, 55:
+		return "毛毛雨"
+	case 56, 57, 66, 67:
+		return "冻雨"
+	case 61:
+		return "小雨"
+	case 63:
+		return "中雨"
+	case 65:
+		return "大雨"
+	case 71:
+		return "小雪"
+	case 73:
+		return "中雪"
+	case 75:
+		return "大雪"
+	case 77:
+		return "雪粒"
+	case 80, 81:
+		return "阵雨"
+	case 82:
+		return "强阵雨"
+	case 85, 86:
+		return "阵雪"
+	case 95:
+		return "雷阵雨"
+	case 96, 99:
+		return "雷阵雨伴有冰雹"
+	}
+	return "未知"
+}
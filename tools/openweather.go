@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"meal-agent/network"
+)
+
+// OpenWeatherClient 基于 OpenWeatherMap 的天气客户端（适合和风天气覆盖不到的地区）
+// 按坐标查询，GetWeather 的 city 参数不使用
+type OpenWeatherClient struct {
+	apiKey   string
+	lat, lng string
+	client   *http.Client
+}
+
+// owmData OpenWeatherMap 实时天气和预报共用的数据结构
+type owmData struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		Humidity  float64 `json:"humidity"`
+	} `json:"main"`
+	Weather []struct {
+		Description string `json:"description"`
+	} `json:"weather"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+		Deg   float64 `json:"deg"`
+	} `json:"wind"`
+	Pop *float64 `json:"pop"` // 降水概率 0~1，仅预报有
+}
+
+// NewOpenWeatherClient 创建 OpenWeatherMap 客户端
+func NewOpenWeatherClient(apiKey, lat, lng string) *OpenWeatherClient {
+	return &OpenWeatherClient{
+		apiKey: apiKey,
+		lat:    lat,
+		lng:    lng,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: network.Transport(network.Weather),
+		},
+	}
+}
+
+// GetWeather 获取实时天气
+func (o *OpenWeatherClient) GetWeather(city string) (*WeatherInfo, error) {
+	var result owmData
+	if err := o.get("weather", "", &result); err != nil {
+		return nil, err
+	}
+	info := result.toWeatherInfo()
+
+	// 实时接口没有降水概率，取最近一条预报的；
+	// OpenWeatherMap 的空气质量是 1~5 级，和 AQI 不可比，不填写
+	if forecasts, err := o.GetForecast(city, 3); err == nil && len(forecasts) > 0 {
+		info.Pop = forecasts[0].Pop
+	}
+	return &info, nil
+}
+
+// GetForecast 获取未来 hours 小时的预报（免费接口为 3 小时一条）
+func (o *OpenWeatherClient) GetForecast(city string, hours int) ([]WeatherInfo, error) {
+	count := 8 // 默认 24 小时
+	if hours > 0 {
+		count = (hours + 2) / 3
+	}
+
+	var result struct {
+		List []owmData `json:"list"`
+	}
+	if err := o.get("forecast", fmt.Sprintf("&cnt=%d", count), &result); err != nil {
+		return nil, err
+	}
+
+	forecasts := make([]WeatherInfo, 0, len(result.List))
+	for _, item := range result.List {
+		info := item.toWeatherInfo()
+		info.Time = time.Unix(item.Dt, 0)
+		forecasts = append(forecasts, info)
+	}
+	return forecasts, nil
+}
+
+// get 请求 OpenWeatherMap 接口（公制单位、中文描述）
+func (o *OpenWeatherClient) get(endpoint, extra string, v interface{}) error {
+	reqURL := fmt.Sprintf(
+		"https://api.openweathermap.org/data/2.5/%s?lat=%s&lon=%s&appid=%s&units=metric&lang=zh_cn%s",
+		endpoint, o.lat, o.lng, o.apiKey, extra,
+	)
+
+	resp, err := o.client.Get(reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OpenWeatherMap API错误: %s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (d owmData) toWeatherInfo() WeatherInfo {
+	info := WeatherInfo{
+		Temp:      fmt.Sprintf("%.0f", d.Main.Temp),
+		FeelsLike: fmt.Sprintf("%.0f", d.Main.FeelsLike),
+		Text:      "未知",
+		WindDir:   windDirection(d.Wind.Deg),
+		WindScale: fmt.Sprintf("%d", beaufortScale(d.Wind.Speed)),
+		Humidity:  fmt.Sprintf("%.0f", d.Main.Humidity),
+	}
+	if len(d.Weather) > 0 {
+		info.Text = d.Weather[0].Description
+	}
+	if d.Pop != nil {
+		info.Pop = fmt.Sprintf("%.0f", *d.Pop*100)
+	}
+	return info
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/network"
+)
+
+const defaultOverpassURL = "https://overpass-api.de/api/interpreter"
+
+// OverpassClient 基于 OpenStreetMap Overpass API 的餐厅搜索（无需 API Key）
+type OverpassClient struct {
+	baseURL    string
+	maxResults int
+	client     *http.Client
+}
+
+// NewOverpassClient 创建 Overpass 客户端
+// baseURL: Overpass 接口地址（留空使用公共实例）
+func NewOverpassClient(baseURL string, maxResults int) *OverpassClient {
+	if baseURL == "" {
+		baseURL = defaultOverpassURL
+	}
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+	return &OverpassClient{
+		baseURL:    baseURL,
+		maxResults: maxResults,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: network.Transport(network.OSM),
+		},
+	}
+}
+
+// SearchNearby 搜索附近餐厅（amenity=restaurant / fast_food）
+func (o *OverpassClient) SearchNearby(lat, lng string, radius int, keyword string) ([]Restaurant, error) {
+	originLat, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的纬度: %s", lat)
+	}
+	originLng, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的经度: %s", lng)
+	}
+
+	query := fmt.Sprintf(
+		`[out:json][timeout:25];(node["amenity"~"^(restaurant|fast_food)$"](around:%d,%s,%s);way["amenity"~"^(restaurant|fast_food)$"](around:%d,%s,%s););out center tags;`,
+		radius, lat, lng, radius, lat, lng,
+	)
+
+	resp, err := o.client.PostForm(o.baseURL, url.Values{"data": {query}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Overpass API错误: %s", resp.Status)
+	}
+
+	var result struct {
+		Elements []struct {
+			ID     int64   `json:"id"`
+			Type   string  `json:"type"`
+			Lat    float64 `json:"lat"`
+			Lon    float64 `json:"lon"`
+			Center *struct {
+				Lat float64 `json:"lat"`
+				Lon float64 `json:"lon"`
+			} `json:"center"`
+			Tags map[string]string `json:"tags"`
+		} `json:"elements"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	restaurants := make([]Restaurant, 0, len(result.Elements))
+	for _, el := range result.Elements {
+		name := el.Tags["name:zh"]
+		if name == "" {
+			name = el.Tags["name"]
+		}
+		if name == "" {
+			continue // 没有名字的 POI 无法推荐
+		}
+
+		// way 类型的坐标在 center 中
+		poiLat, poiLng := el.Lat, el.Lon
+		if el.Center != nil {
+			poiLat, poiLng = el.Center.Lat, el.Center.Lon
+		}
+
+		r := Restaurant{
+			ID:       fmt.Sprintf("osm:%s/%d", el.Type, el.ID),
+			Name:     name,
+			Type:     osmType(el.Tags["amenity"], el.Tags["cuisine"]),
+			Address:  osmAddress(el.Tags),
+			Distance: strconv.Itoa(int(haversine(originLat, originLng, poiLat, poiLng))),
+			Location: fmt.Sprintf("%.6f,%.6f", poiLng, poiLat),
+			Tel:      el.Tags["phone"],
+			OpenTime: el.Tags["opening_hours"],
+			Delivery: osmDelivery(el.Tags["delivery"]),
+		}
+
+		if keyword != "" && !strings.Contains(r.Name+r.Type, keyword) {
+			continue
+		}
+		restaurants = append(restaurants, r)
+	}
+
+	// 按距离由近到远，超出上限的截断
+	sort.SliceStable(restaurants, func(i, j int) bool {
+		return restaurants[i].GetDistanceInt() < restaurants[j].GetDistanceInt()
+	})
+	if len(restaurants) > o.maxResults {
+		restaurants = restaurants[:o.maxResults]
+	}
+
+	return restaurants, nil
+}
+
+// OSM cuisine 标签到中文类型的映射
+var osmCuisineNames = map[string]string{
+	"chinese":    "中餐厅",
+	"sichuan":    "川菜",
+	"hunan":      "湘菜",
+	"cantonese":  "粤菜",
+	"shanghai":   "本帮菜",
+	"dongbei":    "东北菜",
+	"hot_pot":    "火锅",
+	"noodle":     "面馆",
+	"dumpling":   "饺子",
+	"barbecue":   "烧烤",
+	"japanese":   "日本料理",
+	"sushi":      "寿司",
+	"ramen":      "拉面",
+	"korean":     "韩国料理",
+	"thai":       "泰餐",
+	"vietnamese": "东南亚",
+	"western":    "西餐",
+	"italian":    "西餐",
+	"pizza":      "披萨",
+	"burger":     "汉堡",
+	"chicken":    "炸鸡",
+	"sandwich":   "三明治",
+	"salad":      "沙拉",
+	"coffee":     "咖啡",
+	"dessert":    "甜品",
+	"bubble_tea": "奶茶",
+}
+
+// osmType 把 OSM 标签转换成类似高德的类型字符串，如 "餐饮服务;中餐厅;川菜"
+func osmType(amenity, cuisine string) string {
+	parts := []string{"餐饮服务"}
+	if amenity == "fast_food" {
+		parts = append(parts, "快餐厅")
+	}
+
+	for _, c := range strings.Split(cuisine, ";") {
+		c = strings.TrimSpace(strings.ToLower(c))
+		if c == "" {
+			continue
+		}
+		if name, ok := osmCuisineNames[c]; ok {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, c)
+		}
+	}
+
+	if len(parts) == 1 {
+		parts = append(parts, "餐厅")
+	}
+	return strings.Join(parts, ";")
+}
+
+// osmDelivery 解析 delivery 标签
+func osmDelivery(tag string) DeliverySupport {
+	switch tag {
+	case "yes", "only":
+		return DeliveryYes
+	case "no":
+		return DeliveryNo
+	}
+	return DeliveryUnknown
+}
+
+// osmAddress 拼接 OSM 地址标签
+func osmAddress(tags map[string]string) string {
+	if full := tags["addr:full"]; full != "" {
+		return full
+	}
+	return tags["addr:street"] + tags["addr:housenumber"]
+}
+
+// haversine 计算两点间的球面距离（米）
+func haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
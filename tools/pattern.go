@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NameMatcher 餐厅名称匹配，支持三种写法：
+//   - 精确名称："某某餐厅"、"海底捞(国贸店)"（括号、+ 等字符按原样比较）
+//   - 通配符："*便利店*"（* 匹配任意字符，? 匹配单个字符，需要与整个名称匹配）
+//   - 正则：以 re: 开头或写在 /…/ 中，如 "re:兰州拉面"、"/^沙县/"，在名称中查找，
+//     需要与整个名称匹配时自己写 ^ 和 $
+//
+// 每条规则都先按精确名称比较，分店名中的括号不会被当成正则
+type NameMatcher struct {
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewNameMatcher 编译名称规则，无效的正则只按精确名称比较（ValidateNameRule 检查配置时报出）
+func NewNameMatcher(rules []string) *NameMatcher {
+	m := &NameMatcher{exact: make(map[string]bool)}
+
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		m.exact[rule] = true
+		if re, err := namePattern(rule); err == nil && re != nil {
+			m.patterns = append(m.patterns, re)
+		}
+	}
+
+	return m
+}
+
+// ValidateNameRule 检查一条名称规则，正则写错时返回错误
+func ValidateNameRule(rule string) error {
+	_, err := namePattern(strings.TrimSpace(rule))
+	return err
+}
+
+// namePattern 规则对应的正则，精确名称返回 nil
+func namePattern(rule string) (*regexp.Regexp, error) {
+	switch {
+	case strings.HasPrefix(rule, "re:"):
+		return regexp.Compile(strings.TrimPrefix(rule, "re:"))
+	case len(rule) > 2 && strings.HasPrefix(rule, "/") && strings.HasSuffix(rule, "/"):
+		return regexp.Compile(rule[1 : len(rule)-1])
+	case strings.ContainsAny(rule, "*?"):
+		return regexp.Compile(globToRegexp(rule))
+	}
+	return nil, nil
+}
+
+// Match 名称是否命中任意规则
+func (m *NameMatcher) Match(name string) bool {
+	if m.exact[name] {
+		return true
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp 把通配符转换成完整匹配的正则
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
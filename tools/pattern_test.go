@@ -0,0 +1,75 @@
+package tools
+
+import "testing"
+
+func TestNameMatcher(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		input string
+		want  bool
+	}{
+		{name: "精确名称", rules: []string{"某某餐厅"}, input: "某某餐厅", want: true},
+		{name: "精确名称不匹配其他名称", rules: []string{"某某餐厅"}, input: "某某餐厅二店", want: false},
+		{name: "英文括号分店名", rules: []string{"海底捞(国贸店)"}, input: "海底捞(国贸店)", want: true},
+		{name: "英文括号不当成正则分组", rules: []string{"海底捞(国贸店)"}, input: "海底捞国贸店", want: false},
+		{name: "英文括号不匹配其他分店", rules: []string{"海底捞(国贸店)"}, input: "海底捞(望京店)", want: false},
+		{name: "中文括号分店名", rules: []string{"海底捞（国贸店）"}, input: "海底捞（国贸店）", want: true},
+		{name: "中文括号不匹配英文括号", rules: []string{"海底捞（国贸店）"}, input: "海底捞(国贸店)", want: false},
+		{name: "加号按原样比较", rules: []string{"麦当劳+"}, input: "麦当劳+", want: true},
+		{name: "加号不当成正则", rules: []string{"麦当劳+"}, input: "麦当劳", want: false},
+		{name: "方括号和竖线按原样比较", rules: []string{"[新]牛肉面|总店"}, input: "[新]牛肉面|总店", want: true},
+		{name: "前后空格", rules: []string{"  某某餐厅 "}, input: "某某餐厅", want: true},
+		{name: "通配符", rules: []string{"*便利店*"}, input: "全家便利店(国贸店)", want: true},
+		{name: "通配符匹配整个名称", rules: []string{"便利店*"}, input: "全家便利店", want: false},
+		{name: "问号匹配单个字符", rules: []string{"沙县小吃?店"}, input: "沙县小吃A店", want: true},
+		{name: "带括号的通配符", rules: []string{"海底捞(*)"}, input: "海底捞(望京店)", want: true},
+		{name: "re: 正则在名称中查找", rules: []string{"re:兰州拉面"}, input: "正宗兰州拉面(国贸店)", want: true},
+		{name: "re: 正则锚定", rules: []string{"re:^沙县"}, input: "老沙县小吃", want: false},
+		{name: "斜线正则", rules: []string{"/^沙县/"}, input: "沙县小吃", want: true},
+		{name: "斜线正则不匹配", rules: []string{"/^沙县/"}, input: "老沙县小吃", want: false},
+		{name: "无效正则不匹配其他名称", rules: []string{"re:(兰州"}, input: "兰州拉面", want: false},
+		{name: "空规则", rules: []string{"", "  "}, input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewNameMatcher(tt.rules).Match(tt.input); got != tt.want {
+				t.Errorf("NewNameMatcher(%q).Match(%q) = %v, want %v", tt.rules, tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByBlacklist(t *testing.T) {
+	restaurants := []Restaurant{
+		{Name: "海底捞(国贸店)"},
+		{Name: "海底捞国贸店"},
+		{Name: "海底捞（望京店）"},
+		{Name: "麦当劳"},
+		{Name: "麦当劳+"},
+	}
+	got := names(FilterByBlacklist(restaurants, []string{"海底捞(国贸店)", "海底捞（望京店）", "麦当劳+"}))
+	want := []string{"海底捞国贸店", "麦当劳"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("FilterByBlacklist() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateNameRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{rule: "海底捞(国贸店", wantErr: false}, // 精确名称不检查正则
+		{rule: "*便利店*", wantErr: false},
+		{rule: "re:兰州拉面", wantErr: false},
+		{rule: "re:(兰州", wantErr: true},
+		{rule: "/[沙县/", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateNameRule(tt.rule); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateNameRule(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
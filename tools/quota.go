@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"meal-agent/memory"
+)
+
+// ErrQuotaExceeded 今日接口调用量已用完
+var ErrQuotaExceeded = errors.New("高德接口今日调用量已达上限")
+
+// quotaWarnRatio 调用量超过配额的该比例时提示并切换为只用缓存
+const quotaWarnRatio = 0.9
+
+// QuotaTracker 高德接口限流与每日调用量统计
+// 调用量持久化到文件，重启后继续累计，跨天自动清零
+type QuotaTracker struct {
+	mu       sync.Mutex
+	path     string
+	limit    int           // 每日配额（0 表示不限制）
+	interval time.Duration // 两次调用的最小间隔
+	lastCall time.Time
+
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// NewQuotaTracker 创建配额统计
+// dailyLimit: 每日配额；qps: 每秒最多请求数（<=0 不限流）
+func NewQuotaTracker(path string, dailyLimit, qps int) *QuotaTracker {
+	q := &QuotaTracker{
+		path:  path,
+		limit: dailyLimit,
+	}
+	if qps > 0 {
+		q.interval = time.Second / time.Duration(qps)
+	}
+
+	os.MkdirAll(filepath.Dir(path), 0755)
+	q.load()
+	return q
+}
+
+// Acquire 在发起一次请求前调用：按 QPS 等待，并累计当日调用量
+// 等待时不持有锁，同一进程中的其他请求可以同时排队
+func (q *QuotaTracker) Acquire() error {
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	q.load()
+	if q.limit > 0 && q.Count >= q.limit {
+		q.mu.Unlock()
+		return ErrQuotaExceeded
+	}
+	// 预留下一个调用时间，多个请求依次错开
+	next := q.lastCall.Add(q.interval)
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	q.lastCall = next
+	q.mu.Unlock()
+
+	time.Sleep(time.Until(next))
+	return q.add()
+}
+
+// add 累计一次调用：在文件锁内重新读取其他进程（对话、后台、网页服务、自检）累计的次数后加一再保存
+// 保存失败时只在本进程内累计，不影响这次请求
+func (q *QuotaTracker) add() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if unlock, err := memory.LockFile(q.path); err == nil {
+		defer unlock()
+	}
+	q.load()
+	if q.limit > 0 && q.Count >= q.limit {
+		return ErrQuotaExceeded
+	}
+	q.Count++
+	q.save()
+	return nil
+}
+
+// NearLimit 调用量是否接近配额（此时应尽量使用缓存）
+func (q *QuotaTracker) NearLimit() bool {
+	if q == nil || q.limit <= 0 {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.load()
+	return float64(q.Count) >= float64(q.limit)*quotaWarnRatio
+}
+
+// Usage 返回今日已用调用量和配额
+func (q *QuotaTracker) Usage() (used, limit int) {
+	if q == nil {
+		return 0, 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.load()
+	return q.Count, q.limit
+}
+
+// load 重新读取文件中的计数（其他进程也在累计），读取失败时沿用内存中的计数；跨天清零（调用方需持有锁）
+func (q *QuotaTracker) load() {
+	if data, err := os.ReadFile(q.path); err == nil {
+		var saved struct {
+			Date  string `json:"date"`
+			Count int    `json:"count"`
+		}
+		if json.Unmarshal(data, &saved) == nil {
+			q.Date, q.Count = saved.Date, saved.Count
+		}
+	}
+	q.rollover()
+}
+
+// rollover 跨天清零（调用方需持有锁）
+func (q *QuotaTracker) rollover() {
+	today := time.Now().Format("2006-01-02")
+	if q.Date != today {
+		q.Date = today
+		q.Count = 0
+	}
+}
+
+// save 原子地保存计数（调用方需持有锁和文件锁）
+func (q *QuotaTracker) save() error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	return memory.WriteFileAtomic(q.path, data, 0644)
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"meal-agent/network"
+)
+
+// RatingInfo 第三方评分信息
+type RatingInfo struct {
+	Rating      float64 `json:"rating"`       // 评分（5 分制）
+	ReviewCount int     `json:"review_count"` // 评价数量
+	Source      string  `json:"source"`       // 来源，如 "dianping"
+}
+
+// RatingProvider 第三方评分来源（大众点评、美团等）
+// 高德 biz_ext.rating 经常为空，可以用它补全
+type RatingProvider interface {
+	GetRating(r *Restaurant) (*RatingInfo, error)
+}
+
+// HTTPRatingProvider 通过 HTTP 接口查询评分的适配器
+// 接口由用户自行提供（官方开放平台代理或自建抓取服务），约定：
+//
+//	GET {url}?name=餐厅名&address=地址&location=lng,lat
+//	-> {"rating": 4.6, "review_count": 1234, "source": "dianping"}
+type HTTPRatingProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPRatingProvider 创建 HTTP 评分适配器，apiKey 可为空
+func NewHTTPRatingProvider(baseURL, apiKey string) *HTTPRatingProvider {
+	return &HTTPRatingProvider{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: network.Transport(network.Rating),
+		},
+	}
+}
+
+// GetRating 查询餐厅评分
+func (p *HTTPRatingProvider) GetRating(r *Restaurant) (*RatingInfo, error) {
+	query := url.Values{}
+	query.Set("name", r.Name)
+	query.Set("address", r.Address)
+	if r.Location != "" {
+		query.Set("location", r.Location)
+	}
+
+	req, err := http.NewRequest("GET", p.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil // 没有该餐厅的评分
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("评分接口错误: %s", resp.Status)
+	}
+
+	var info RatingInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ApplyRating 用第三方评分补全餐厅信息
+func (r *Restaurant) ApplyRating(info *RatingInfo) {
+	if info == nil || info.Rating <= 0 {
+		return
+	}
+	r.Rating = fmt.Sprintf("%.1f", info.Rating)
+	r.ReviewCount = info.ReviewCount
+	r.RatingSource = info.Source
+}
@@ -5,113 +5,301 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
+
+	"meal-agent/network"
+)
+
+const (
+	pageSize          = 20                     // 每页 POI 数量（高德上限 25）
+	pageInterval      = 300 * time.Millisecond // 翻页请求间隔
+	defaultMaxResults = 100                    // 默认最多获取的 POI 数量
 )
 
+// RestaurantProvider 餐厅数据来源
+type RestaurantProvider interface {
+	SearchNearby(lat, lng string, radius int, keyword string) ([]Restaurant, error)
+}
+
 // RestaurantClient 高德地图餐厅搜索客户端
 type RestaurantClient struct {
-	apiKey string
-	client *http.Client
+	apiKey     string
+	maxResults int
+	quota      *QuotaTracker // 限流与调用量统计（可选）
+	client     *http.Client
 }
 
 // MealCategory 餐厅大类
 type MealCategory string
 
 const (
-	CategoryQuickMeal MealCategory = "quick"  // 快餐类：面、拌饭、简餐
-	CategoryFullMeal  MealCategory = "full"   // 正餐炒菜类
-	CategoryOther     MealCategory = "other"  // 其他
+	CategoryQuickMeal MealCategory = "quick" // 快餐类：面、拌饭、简餐
+	CategoryFullMeal  MealCategory = "full"  // 正餐炒菜类
+	CategoryOther     MealCategory = "other" // 其他
 )
 
 // Restaurant 餐厅信息
 type Restaurant struct {
-	Name     string `json:"name"`     // 餐厅名称
-	Type     string `json:"type"`     // 餐厅类型（川菜、火锅等）
-	Address  string `json:"address"`  // 地址
-	Distance string `json:"distance"` // 距离（米）
-	Rating   string `json:"rating"`   // 评分
-	Cost     string `json:"cost"`     // 人均消费
-	Tel      string `json:"tel"`      // 电话
-	Weight   int    `json:"-"`        // 计算后的权重（不序列化）
-	Category MealCategory `json:"-"`  // 餐厅大类（快餐/正餐）
+	ID              string          `json:"id"`                        // 高德 POI ID
+	Name            string          `json:"name"`                      // 餐厅名称
+	Type            string          `json:"type"`                      // 餐厅类型（川菜、火锅等）
+	Cuisine         string          `json:"cuisine,omitempty"`         // 规范化菜系（火锅、川菜、日料等）
+	Address         string          `json:"address"`                   // 地址
+	Distance        string          `json:"distance"`                  // 距离（米）
+	Location        string          `json:"location,omitempty"`        // 坐标 "lng,lat"
+	Rating          string          `json:"rating"`                    // 评分
+	ReviewCount     int             `json:"review_count,omitempty"`    // 评价数量（来自第三方评分）
+	RatingSource    string          `json:"rating_source,omitempty"`   // 评分来源（为空表示高德）
+	Cost            string          `json:"cost"`                      // 人均消费
+	Tel             string          `json:"tel"`                       // 电话
+	OpenTime        string          `json:"open_time,omitempty"`       // 营业时间（来自详情接口）
+	Photos          []string        `json:"photos,omitempty"`          // 图片 URL（来自详情接口）
+	Menu            []string        `json:"menu,omitempty"`            // 当天的菜（食堂）
+	HealthWarnings  []string        `json:"health_warnings,omitempty"` // 健康状况相关的提醒（配置了 health 时）
+	Weight          int             `json:"-"`                         // 计算后的权重（不序列化）
+	Category        MealCategory    `json:"-"`                         // 餐厅大类（快餐/正餐）
+	OpenStatus      OpenStatus      `json:"-"`                         // 推荐时刻的营业状态
+	WalkMinutes     int             `json:"-"`                         // 步行时间（分钟，0 表示未知）
+	Branches        int             `json:"-"`                         // 附近同品牌分店数量（分组后）
+	Delivery        DeliverySupport `json:"delivery,omitempty"`        // 是否支持外卖
+	DeliveryMinutes int             `json:"-"`                         // 外卖预计送达时间（分钟，外卖模式下计算）
+	DeliveryFee     int             `json:"-"`                         // 外卖预计配送费（元）
+}
+
+// RestaurantDetail 餐厅详情
+type RestaurantDetail struct {
+	OpenTime string   // 营业时间
+	Photos   []string // 图片 URL
+	Rating   string   // 评分
+	Cost     string   // 人均消费
+	Tel      string   // 电话
+}
+
+// DetailProvider 支持查询餐厅详情的数据来源
+type DetailProvider interface {
+	GetDetails(poiID string) (*RestaurantDetail, error)
 }
 
 // NewRestaurantClient 创建餐厅搜索客户端
-func NewRestaurantClient(apiKey string) *RestaurantClient {
+// maxResults: 单次搜索最多获取的餐厅数量（<=0 使用默认值 100）
+func NewRestaurantClient(apiKey string, maxResults int) *RestaurantClient {
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
 	return &RestaurantClient{
-		apiKey: apiKey,
-		client: &http.Client{},
+		apiKey:     apiKey,
+		maxResults: maxResults,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: network.Transport(network.Amap),
+		},
 	}
 }
 
-// SearchNearby 搜索附近餐厅
+// SetQuota 设置限流与每日调用量统计
+func (r *RestaurantClient) SetQuota(q *QuotaTracker) {
+	r.quota = q
+}
+
+// get 发起高德接口请求（先经过限流和配额检查）
+func (r *RestaurantClient) get(url string) (*http.Response, error) {
+	if err := r.quota.Acquire(); err != nil {
+		return nil, err
+	}
+	return r.client.Get(url)
+}
+
+// SearchNearby 搜索附近餐厅（自动翻页，最多返回 maxResults 个）
 // lat, lng: 经纬度
 // radius: 搜索半径（米）
 // keyword: 可选关键词（如"火锅"、"川菜"）
 func (r *RestaurantClient) SearchNearby(lat, lng string, radius int, keyword string) ([]Restaurant, error) {
+	restaurants := make([]Restaurant, 0, r.maxResults)
+	seen := make(map[string]bool)
+
+	for page := 1; len(restaurants) < r.maxResults; page++ {
+		if page > 1 {
+			time.Sleep(pageInterval) // 节流，避免触发高德 QPS 限制
+		}
+
+		pois, total, err := r.searchPage(lat, lng, radius, keyword, page)
+		if err != nil {
+			if page > 1 {
+				// 后续页失败时保留已获取的结果
+				break
+			}
+			return nil, err
+		}
+
+		for _, poi := range pois {
+			// 不同页可能返回重复的 POI，按 ID（无 ID 时按名称+地址）去重
+			key := poi.ID
+			if key == "" {
+				key = poi.Name + "|" + poi.Address
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			restaurants = append(restaurants, poi)
+			if len(restaurants) >= r.maxResults {
+				break
+			}
+		}
+
+		if len(pois) < pageSize || page*pageSize >= total {
+			break
+		}
+	}
+
+	return restaurants, nil
+}
+
+// searchPage 查询单页 POI，返回本页结果和总数
+func (r *RestaurantClient) searchPage(lat, lng string, radius int, keyword string, page int) ([]Restaurant, int, error) {
 	// 高德 POI 搜索 API
 	// types=050000 表示餐饮服务
-	url := fmt.Sprintf(
-		"https://restapi.amap.com/v3/place/around?key=%s&location=%s,%s&radius=%d&types=050000&offset=20&extensions=all",
+	reqURL := fmt.Sprintf(
+		"https://restapi.amap.com/v3/place/around?key=%s&location=%s,%s&radius=%d&types=050000&offset=%d&page=%d&extensions=all",
 		r.apiKey,
 		lng, // 高德是 lng,lat 顺序
 		lat,
 		radius,
+		pageSize,
+		page,
 	)
 
 	if keyword != "" {
-		url += "&keywords=" + keyword
+		reqURL += "&keywords=" + url.QueryEscape(keyword)
 	}
 
-	resp, err := r.client.Get(url)
+	resp, err := r.get(reqURL)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var result struct {
-		Status string `json:"status"`
-		Info   string `json:"info"`
+		Status string     `json:"status"`
+		Info   string     `json:"info"`
+		Count  flexString `json:"count"`
 		Pois   []struct {
+			ID       flexString      `json:"id"`
 			Name     flexString      `json:"name"`
 			Type     flexString      `json:"type"`
 			Address  flexString      `json:"address"`
 			Distance flexString      `json:"distance"`
+			Location flexString      `json:"location"`
 			BizExt   json.RawMessage `json:"biz_ext"` // 可能是对象或空数组
 			Tel      flexString      `json:"tel"`
 		} `json:"pois"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if result.Status != "1" {
-		return nil, fmt.Errorf("高德API错误: %s", result.Info)
+		return nil, 0, fmt.Errorf("高德API错误: %s", result.Info)
 	}
 
+	var total int
+	fmt.Sscanf(string(result.Count), "%d", &total)
+
 	restaurants := make([]Restaurant, 0, len(result.Pois))
 	for _, poi := range result.Pois {
 		// 解析 biz_ext，处理可能是空数组的情况
 		rating, cost := parseBizExt(poi.BizExt)
 
 		restaurants = append(restaurants, Restaurant{
+			ID:       string(poi.ID),
 			Name:     string(poi.Name),
 			Type:     string(poi.Type),
 			Address:  string(poi.Address),
 			Distance: string(poi.Distance),
+			Location: string(poi.Location),
 			Rating:   rating,
 			Cost:     cost,
 			Tel:      string(poi.Tel),
 		})
 	}
 
-	return restaurants, nil
+	return restaurants, total, nil
+}
+
+// GetDetails 查询餐厅详情（营业时间、图片等）
+// 高德详情接口不提供评论，只返回评分和人均
+func (r *RestaurantClient) GetDetails(poiID string) (*RestaurantDetail, error) {
+	url := fmt.Sprintf(
+		"https://restapi.amap.com/v3/place/detail?key=%s&id=%s",
+		r.apiKey,
+		poiID,
+	)
+
+	resp, err := r.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Info   string `json:"info"`
+		Pois   []struct {
+			Tel    flexString `json:"tel"`
+			BizExt struct {
+				Rating    flexString `json:"rating"`
+				Cost      flexString `json:"cost"`
+				OpenTime  flexString `json:"open_time"`
+				OpenTime2 flexString `json:"opentime2"`
+			} `json:"biz_ext"`
+			Photos []struct {
+				URL flexString `json:"url"`
+			} `json:"photos"`
+		} `json:"pois"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "1" {
+		return nil, fmt.Errorf("高德API错误: %s", result.Info)
+	}
+	if len(result.Pois) == 0 {
+		return nil, fmt.Errorf("未找到餐厅详情: %s", poiID)
+	}
+
+	poi := result.Pois[0]
+	detail := &RestaurantDetail{
+		Rating:   string(poi.BizExt.Rating),
+		Cost:     string(poi.BizExt.Cost),
+		Tel:      string(poi.Tel),
+		OpenTime: string(poi.BizExt.OpenTime2), // opentime2 更完整，如 "周一至周日 10:00-22:00"
+	}
+	if detail.OpenTime == "" {
+		detail.OpenTime = string(poi.BizExt.OpenTime)
+	}
+	for _, p := range poi.Photos {
+		if p.URL != "" {
+			detail.Photos = append(detail.Photos, string(p.URL))
+		}
+	}
+
+	return detail, nil
 }
 
 // flexString 处理高德API中可能是字符串或空数组的字段
@@ -153,15 +341,13 @@ func parseBizExt(raw json.RawMessage) (rating, cost string) {
 }
 
 // FilterByBlacklist 过滤黑名单餐厅
+// 黑名单支持精确名称、通配符（*便利店*）和正则（re:兰州拉面），见 NameMatcher
 func FilterByBlacklist(restaurants []Restaurant, blacklist []string) []Restaurant {
-	blacklistMap := make(map[string]bool)
-	for _, name := range blacklist {
-		blacklistMap[name] = true
-	}
+	matcher := NewNameMatcher(blacklist)
 
 	filtered := make([]Restaurant, 0)
 	for _, r := range restaurants {
-		if !blacklistMap[r.Name] {
+		if !matcher.Match(r.Name) {
 			filtered = append(filtered, r)
 		}
 	}
@@ -174,7 +360,7 @@ func FilterByType(restaurants []Restaurant, excludeTypes []string) []Restaurant
 	for _, r := range restaurants {
 		excluded := false
 		for _, t := range excludeTypes {
-			if strings.Contains(r.Type, t) || strings.Contains(r.Name, t) {
+			if r.Cuisine == t || strings.Contains(r.Type, t) || strings.Contains(r.Name, t) {
 				excluded = true
 				break
 			}
@@ -189,30 +375,98 @@ func FilterByType(restaurants []Restaurant, excludeTypes []string) []Restaurant
 // Describe 返回餐厅描述
 func (r *Restaurant) Describe() string {
 	desc := fmt.Sprintf("%s", r.Name)
-	if r.Type != "" {
+	if r.Cuisine != "" && r.Cuisine != CuisineOther {
+		desc += fmt.Sprintf("（%s）", r.Cuisine)
+	} else if r.Type != "" {
 		desc += fmt.Sprintf("（%s）", r.Type)
 	}
 	if r.Distance != "" {
 		desc += fmt.Sprintf(" - %s米", r.Distance)
 	}
+	if r.WalkMinutes > 0 {
+		desc += fmt.Sprintf(" - 步行约%d分钟", r.WalkMinutes)
+	}
+	if r.DeliveryMinutes > 0 {
+		desc += fmt.Sprintf(" - 外卖约%d分钟送达，配送费约¥%d", r.DeliveryMinutes, r.DeliveryFee)
+	}
+	if r.Branches > 1 {
+		desc += fmt.Sprintf(" - 附近共%d家分店", r.Branches)
+	}
 	if r.Rating != "" && r.Rating != "[]" {
 		desc += fmt.Sprintf(" - 评分%s", r.Rating)
+		if r.ReviewCount > 0 {
+			desc += fmt.Sprintf("（%d条评价）", r.ReviewCount)
+		}
 	}
 	if r.Cost != "" && r.Cost != "[]" {
 		desc += fmt.Sprintf(" - 人均¥%s", r.Cost)
 	}
+	if r.OpenTime != "" {
+		desc += fmt.Sprintf(" - 营业时间 %s", r.OpenTime)
+	}
+	if r.OpenStatus == ClosingSoon {
+		desc += " - ⚠️即将打烊"
+	}
+	if len(r.Menu) > 0 {
+		desc += " - 今天的菜：" + strings.Join(r.Menu, "、")
+	}
 	return desc
 }
 
+// IsCanteen 是否为公司食堂
+func (r *Restaurant) IsCanteen() bool {
+	return r.ID == CanteenID
+}
+
+// ApplyDetail 用详情补全餐厅信息（评分、人均、电话只在缺失时填充）
+func (r *Restaurant) ApplyDetail(d *RestaurantDetail) {
+	if d == nil {
+		return
+	}
+	r.OpenTime = d.OpenTime
+	r.Photos = d.Photos
+	if r.Rating == "" || r.Rating == "[]" {
+		r.Rating = d.Rating
+	}
+	if r.Cost == "" || r.Cost == "[]" {
+		r.Cost = d.Cost
+	}
+	if r.Tel == "" {
+		r.Tel = d.Tel
+	}
+}
+
 // SortByWeight 按权重排序（权重高的在前）
+// 权重相同时依次按评分降序、距离升序，仍相同则保持原有顺序
 func SortByWeight(restaurants []Restaurant) {
-	for i := 0; i < len(restaurants)-1; i++ {
-		for j := i + 1; j < len(restaurants); j++ {
-			if restaurants[j].Weight > restaurants[i].Weight {
-				restaurants[i], restaurants[j] = restaurants[j], restaurants[i]
-			}
-		}
+	sort.SliceStable(restaurants, func(i, j int) bool {
+		return rankLess(&restaurants[i], &restaurants[j])
+	})
+}
+
+// TopK 返回排序后的前 k 个餐厅（不修改原切片），k<=0 时返回空列表，k 超过餐厅数时返回全部
+func TopK(restaurants []Restaurant, k int) []Restaurant {
+	if k <= 0 {
+		return []Restaurant{}
+	}
+	sorted := make([]Restaurant, len(restaurants))
+	copy(sorted, restaurants)
+	SortByWeight(sorted)
+	if k < len(sorted) {
+		sorted = sorted[:k]
+	}
+	return sorted
+}
+
+// rankLess 排序规则：权重降序 > 评分降序 > 距离升序
+func rankLess(a, b *Restaurant) bool {
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	if ra, rb := a.GetRatingFloat(), b.GetRatingFloat(); ra != rb {
+		return ra > rb
 	}
+	return a.GetDistanceInt() < b.GetDistanceInt()
 }
 
 // FilterByWeight 过滤掉权重为0或负数的餐厅
@@ -281,6 +535,16 @@ func (r *Restaurant) GetDistanceInt() int {
 	return dist
 }
 
+// GetCostFloat 获取人均消费的数值（元），未知返回 0
+func (r *Restaurant) GetCostFloat() float64 {
+	if r.Cost == "" || r.Cost == "[]" {
+		return 0
+	}
+	var cost float64
+	fmt.Sscanf(r.Cost, "%f", &cost)
+	return cost
+}
+
 // GetRatingFloat 获取评分的浮点值
 func (r *Restaurant) GetRatingFloat() float64 {
 	if r.Rating == "" || r.Rating == "[]" {
@@ -290,3 +554,11 @@ func (r *Restaurant) GetRatingFloat() float64 {
 	fmt.Sscanf(r.Rating, "%f", &rating)
 	return rating
 }
+
+// MapURL 高德地图的标注链接，没有坐标时为空
+func (r *Restaurant) MapURL() string {
+	if r.Location == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://uri.amap.com/marker?position=%s&name=%s", url.QueryEscape(r.Location), url.QueryEscape(r.Name))
+}
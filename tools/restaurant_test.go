@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// benchmarkCandidates 生成 n 家候选餐厅，权重集中在少数几个值上，排序时有大量需要按评分、距离区分的并列
+func benchmarkCandidates(n int) []Restaurant {
+	rng := rand.New(rand.NewSource(1))
+	restaurants := make([]Restaurant, n)
+	for i := range restaurants {
+		restaurants[i] = Restaurant{
+			ID:       "B" + strconv.Itoa(i),
+			Name:     fmt.Sprintf("餐厅%d", i),
+			Rating:   fmt.Sprintf("%.1f", 3+rng.Float64()*2),
+			Distance: strconv.Itoa(rng.Intn(3000)),
+			Weight:   rng.Intn(20) * 10,
+		}
+	}
+	return restaurants
+}
+
+func BenchmarkSortByWeight(b *testing.B) {
+	for _, n := range []int{500, 2000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			candidates := benchmarkCandidates(n)
+			restaurants := make([]Restaurant, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				copy(restaurants, candidates) // 每次从未排序的列表开始
+				SortByWeight(restaurants)
+			}
+		})
+	}
+}
+
+func BenchmarkTopK(b *testing.B) {
+	for _, n := range []int{500, 2000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			candidates := benchmarkCandidates(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				TopK(candidates, 15)
+			}
+		})
+	}
+}
+
+// names 餐厅名称列表，方便比较排序结果
+func names(restaurants []Restaurant) []string {
+	result := make([]string, len(restaurants))
+	for i, r := range restaurants {
+		result[i] = r.Name
+	}
+	return result
+}
+
+func TestSortByWeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		restaurants []Restaurant
+		want        []string
+	}{
+		{
+			name: "权重高的在前",
+			restaurants: []Restaurant{
+				{Name: "A", Weight: 50, Rating: "4.9", Distance: "100"},
+				{Name: "B", Weight: 120, Rating: "3.5", Distance: "900"},
+				{Name: "C", Weight: 80},
+			},
+			want: []string{"B", "C", "A"},
+		},
+		{
+			name: "权重相同按评分降序",
+			restaurants: []Restaurant{
+				{Name: "A", Weight: 100, Rating: "4.2", Distance: "100"},
+				{Name: "B", Weight: 100, Rating: "4.8", Distance: "900"},
+				{Name: "C", Weight: 100, Rating: "4.5", Distance: "500"},
+			},
+			want: []string{"B", "C", "A"},
+		},
+		{
+			name: "权重、评分相同按距离升序",
+			restaurants: []Restaurant{
+				{Name: "A", Weight: 100, Rating: "4.5", Distance: "800"},
+				{Name: "B", Weight: 100, Rating: "4.5", Distance: "200"},
+				{Name: "C", Weight: 100, Rating: "4.5", Distance: "500"},
+			},
+			want: []string{"B", "C", "A"},
+		},
+		{
+			name: "没有评分的排在有评分的后面",
+			restaurants: []Restaurant{
+				{Name: "A", Weight: 100, Distance: "100"},
+				{Name: "B", Weight: 100, Rating: "3.0", Distance: "900"},
+			},
+			want: []string{"B", "A"},
+		},
+		{
+			name: "完全相同时保持原有顺序",
+			restaurants: []Restaurant{
+				{Name: "A", Weight: 100, Rating: "4.5", Distance: "300"},
+				{Name: "B", Weight: 100, Rating: "4.5", Distance: "300"},
+				{Name: "C", Weight: 100, Rating: "4.5", Distance: "300"},
+			},
+			want: []string{"A", "B", "C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SortByWeight(tt.restaurants)
+			if got := names(tt.restaurants); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("SortByWeight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopK(t *testing.T) {
+	restaurants := []Restaurant{
+		{Name: "A", Weight: 10},
+		{Name: "B", Weight: 30},
+		{Name: "C", Weight: 20, Rating: "4.0"},
+		{Name: "D", Weight: 20, Rating: "4.6"},
+	}
+
+	tests := []struct {
+		name string
+		k    int
+		want []string
+	}{
+		{name: "前两个", k: 2, want: []string{"B", "D"}},
+		{name: "k 等于数量", k: 4, want: []string{"B", "D", "C", "A"}},
+		{name: "k 超过数量时返回全部", k: 10, want: []string{"B", "D", "C", "A"}},
+		{name: "k 为 0", k: 0, want: []string{}},
+		{name: "k 为负数", k: -1, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TopK(restaurants, tt.k)
+			if fmt.Sprint(names(got)) != fmt.Sprint(tt.want) {
+				t.Errorf("TopK(%d) = %v, want %v", tt.k, names(got), tt.want)
+			}
+			if order := names(restaurants); fmt.Sprint(order) != "[A B C D]" {
+				t.Errorf("TopK(%d) 修改了原切片: %v", tt.k, order)
+			}
+		})
+	}
+
+	if got := TopK(nil, 3); len(got) != 0 {
+		t.Errorf("TopK(nil, 3) = %v, want empty", names(got))
+	}
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 高德距离测量接口单次最多支持的起点数量
+const maxDistanceOrigins = 100
+
+// WalkingTimeProvider 支持计算实际步行时间的数据来源
+type WalkingTimeProvider interface {
+	FillWalkingTimes(lat, lng string, restaurants []Restaurant) error
+}
+
+// FillWalkingTimes 批量计算各餐厅到用户位置的步行时间，写入 WalkMinutes
+// 使用高德距离测量 API（type=3 步行），每批最多 100 个餐厅
+func (r *RestaurantClient) FillWalkingTimes(lat, lng string, restaurants []Restaurant) error {
+	// 只计算有坐标的餐厅，记录其在原切片中的下标
+	indexes := make([]int, 0, len(restaurants))
+	for i := range restaurants {
+		if restaurants[i].Location != "" {
+			indexes = append(indexes, i)
+		}
+	}
+
+	for start := 0; start < len(indexes); start += maxDistanceOrigins {
+		end := start + maxDistanceOrigins
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		batch := indexes[start:end]
+
+		origins := make([]string, 0, len(batch))
+		for _, idx := range batch {
+			origins = append(origins, restaurants[idx].Location)
+		}
+
+		durations, err := r.walkingDurations(origins, lng+","+lat)
+		if err != nil {
+			return err
+		}
+
+		for originID, seconds := range durations {
+			if originID < 1 || originID > len(batch) {
+				continue
+			}
+			minutes := (seconds + 59) / 60
+			if minutes == 0 {
+				minutes = 1
+			}
+			restaurants[batch[originID-1]].WalkMinutes = minutes
+		}
+	}
+
+	return nil
+}
+
+// walkingDurations 调用高德距离测量接口，返回 origin_id(从1开始) -> 步行秒数
+func (r *RestaurantClient) walkingDurations(origins []string, destination string) (map[int]int, error) {
+	url := fmt.Sprintf(
+		"https://restapi.amap.com/v3/distance?key=%s&origins=%s&destination=%s&type=3",
+		r.apiKey,
+		strings.Join(origins, "|"),
+		destination,
+	)
+
+	resp, err := r.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status  string `json:"status"`
+		Info    string `json:"info"`
+		Results []struct {
+			OriginID flexString `json:"origin_id"`
+			Duration flexString `json:"duration"`
+		} `json:"results"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "1" {
+		return nil, fmt.Errorf("高德API错误: %s", result.Info)
+	}
+
+	durations := make(map[int]int, len(result.Results))
+	for _, res := range result.Results {
+		var originID, seconds int
+		fmt.Sscanf(string(res.OriginID), "%d", &originID)
+		if _, err := fmt.Sscanf(string(res.Duration), "%d", &seconds); err != nil {
+			continue
+		}
+		durations[originID] = seconds
+	}
+	return durations, nil
+}
+
+// FilterByWalkTime 过滤掉步行时间超过上限的餐厅（步行时间未知的保留）
+func FilterByWalkTime(restaurants []Restaurant, maxMinutes int) []Restaurant {
+	if maxMinutes <= 0 {
+		return restaurants
+	}
+	filtered := make([]Restaurant, 0, len(restaurants))
+	for _, r := range restaurants {
+		if r.WalkMinutes == 0 || r.WalkMinutes <= maxMinutes {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
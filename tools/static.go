@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StaticProvider 从本地 YAML 文件读取餐厅列表（无需 API Key）
+// 适合公司内网、食堂等地图数据覆盖不到的场景，每次搜索都重新读取文件，修改后立即生效
+type StaticProvider struct {
+	path string
+}
+
+// staticRestaurant 餐厅列表文件中的一项
+type staticRestaurant struct {
+	ID       string  `yaml:"id"`
+	Name     string  `yaml:"name"`
+	Type     string  `yaml:"type"` // 类型，如 "中餐厅;川菜"
+	Address  string  `yaml:"address"`
+	Distance int     `yaml:"distance"` // 距离（米），填写了坐标时可省略
+	Lat      float64 `yaml:"lat"`
+	Lng      float64 `yaml:"lng"`
+	Rating   float64 `yaml:"rating"`
+	Cost     int     `yaml:"cost"` // 人均消费（元）
+	Tel      string  `yaml:"tel"`
+	OpenTime string  `yaml:"open_time"`
+	Delivery *bool   `yaml:"delivery"` // 是否支持外卖（可选）
+}
+
+// NewStaticProvider 创建静态餐厅列表数据来源
+func NewStaticProvider(path string) *StaticProvider {
+	return &StaticProvider{path: path}
+}
+
+// SearchNearby 返回列表中半径范围内的餐厅
+func (s *StaticProvider) SearchNearby(lat, lng string, radius int, keyword string) ([]Restaurant, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取餐厅列表失败: %v", err)
+	}
+
+	var file struct {
+		Restaurants []staticRestaurant `yaml:"restaurants"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析餐厅列表失败: %v", err)
+	}
+
+	originLat, errLat := strconv.ParseFloat(lat, 64)
+	originLng, errLng := strconv.ParseFloat(lng, 64)
+	hasOrigin := errLat == nil && errLng == nil
+
+	restaurants := make([]Restaurant, 0, len(file.Restaurants))
+	for i, item := range file.Restaurants {
+		if item.Name == "" {
+			continue
+		}
+
+		// 有坐标时按坐标计算距离，否则使用填写的距离
+		dist := item.Distance
+		location := ""
+		if item.Lat != 0 && item.Lng != 0 {
+			location = fmt.Sprintf("%.6f,%.6f", item.Lng, item.Lat)
+			if hasOrigin {
+				dist = int(haversine(originLat, originLng, item.Lat, item.Lng))
+			}
+		}
+		if radius > 0 && dist > radius {
+			continue
+		}
+
+		r := Restaurant{
+			ID:       item.ID,
+			Name:     item.Name,
+			Type:     item.Type,
+			Address:  item.Address,
+			Distance: strconv.Itoa(dist),
+			Location: location,
+			Tel:      item.Tel,
+			OpenTime: item.OpenTime,
+		}
+		if r.ID == "" {
+			r.ID = fmt.Sprintf("static:%d", i)
+		}
+		if r.Type == "" {
+			r.Type = "餐饮服务;餐厅"
+		}
+		if item.Rating > 0 {
+			r.Rating = fmt.Sprintf("%.1f", item.Rating)
+		}
+		if item.Cost > 0 {
+			r.Cost = strconv.Itoa(item.Cost)
+		}
+		if item.Delivery != nil {
+			if *item.Delivery {
+				r.Delivery = DeliveryYes
+			} else {
+				r.Delivery = DeliveryNo
+			}
+		}
+
+		if keyword != "" && !strings.Contains(r.Name+r.Type, keyword) {
+			continue
+		}
+		restaurants = append(restaurants, r)
+	}
+
+	return restaurants, nil
+}
@@ -0,0 +1,42 @@
+package tools
+
+// TasteProfile 菜系的口味特征，各项取值 -2 ~ 2
+type TasteProfile struct {
+	Spicy int // 辣：2 很辣，-2 完全不辣
+	Oily  int // 油腻：2 很油，-2 很清爽
+	Light int // 清淡：2 很清淡，-2 口味很重
+}
+
+// cuisineTastes 各菜系的典型口味（按常见做法粗略划分，未列出的菜系各项为 0）
+var cuisineTastes = map[string]TasteProfile{
+	CuisineHotpot:     {Spicy: 2, Oily: 2, Light: -2},
+	CuisineSichuan:    {Spicy: 2, Oily: 1, Light: -2},
+	CuisineHunan:      {Spicy: 2, Oily: 1, Light: -2},
+	CuisineMalatang:   {Spicy: 2, Oily: 1, Light: -1},
+	CuisineYunGui:     {Spicy: 1, Oily: 0, Light: -1},
+	CuisineBBQ:        {Spicy: 1, Oily: 2, Light: -2},
+	CuisineDongbei:    {Spicy: 0, Oily: 2, Light: -1},
+	CuisineNorthwest:  {Spicy: 1, Oily: 1, Light: -1},
+	CuisineKorean:     {Spicy: 1, Oily: 0, Light: -1},
+	CuisineSEAsian:    {Spicy: 1, Oily: 0, Light: -1},
+	CuisineShandong:   {Spicy: 0, Oily: 1, Light: -1},
+	CuisineBurger:     {Spicy: 0, Oily: 2, Light: -2},
+	CuisinePizza:      {Spicy: 0, Oily: 2, Light: -1},
+	CuisineBuffet:     {Spicy: 0, Oily: 1, Light: -1},
+	CuisineCantonese:  {Spicy: -2, Oily: -1, Light: 2},
+	CuisineShanghai:   {Spicy: -2, Oily: 1, Light: 0},
+	CuisineJapanese:   {Spicy: -2, Oily: -1, Light: 2},
+	CuisineSeafood:    {Spicy: -1, Oily: -1, Light: 1},
+	CuisineVegetarian: {Spicy: -1, Oily: -2, Light: 2},
+	CuisineDumpling:   {Spicy: -1, Oily: -1, Light: 1},
+	CuisineNoodle:     {Spicy: 0, Oily: 0, Light: 1},
+	CuisineCanteen:    {Spicy: 0, Oily: 0, Light: 1},
+	CuisineHomestyle:  {Spicy: 0, Oily: 0, Light: 0},
+	CuisineDessert:    {Spicy: -2, Oily: 0, Light: 0},
+	CuisineDrinks:     {Spicy: -2, Oily: -1, Light: 1},
+}
+
+// CuisineTaste 菜系的口味特征，未知菜系各项为 0
+func CuisineTaste(cuisine string) TasteProfile {
+	return cuisineTastes[cuisine]
+}
@@ -6,23 +6,65 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"meal-agent/network"
 )
 
+// WeatherProvider 天气数据来源
+type WeatherProvider interface {
+	GetWeather(city string) (*WeatherInfo, error)
+}
+
+// ForecastProvider 支持天气预报的数据来源
+type ForecastProvider interface {
+	GetForecast(city string, hours int) ([]WeatherInfo, error)
+}
+
+// WarningProvider 支持气象灾害预警的数据来源
+type WarningProvider interface {
+	GetWarnings(city string) ([]WeatherWarning, error)
+}
+
+// WeatherWarning 气象灾害预警（台风、暴雨、高温等）
+type WeatherWarning struct {
+	Title    string `json:"title"`              // 预警标题，如 "北京市气象台发布暴雨蓝色预警"
+	Type     string `json:"type"`               // 预警类型，如 "暴雨"
+	Severity string `json:"severity,omitempty"` // 预警等级
+	Text     string `json:"text,omitempty"`     // 预警详情
+}
+
 // WeatherClient 和风天气客户端
 type WeatherClient struct {
 	apiKey string
 	client *http.Client
+
+	coordinates string // 设置后直接按坐标查询（"经度,纬度"），不再查询城市 ID
+
+	mu        sync.Mutex
+	cityIDs   map[string]string // 城市名 -> 和风天气 location ID
+	cityCache string            // 城市 ID 缓存文件（留空只缓存在内存）
 }
 
 // WeatherInfo 天气信息
 type WeatherInfo struct {
-	Temp      string // 温度
-	FeelsLike string // 体感温度
-	Text      string // 天气描述（晴、多云等）
-	WindDir   string // 风向
-	WindScale string // 风力等级
-	Humidity  string // 湿度
+	Temp      string `json:"temp"`                 // 温度
+	FeelsLike string `json:"feels_like,omitempty"` // 体感温度
+	Text      string `json:"text"`                 // 天气描述（晴、多云等）
+	WindDir   string `json:"wind_dir,omitempty"`   // 风向
+	WindScale string `json:"wind_scale,omitempty"` // 风力等级
+	Humidity  string `json:"humidity,omitempty"`   // 湿度
+	Pop       string `json:"pop,omitempty"`        // 降水概率（%）
+	AQI       string `json:"aqi,omitempty"`        // 空气质量指数（获取失败时为空）
+
+	Time time.Time `json:"-"` // 预报对应的时间（实时天气为零值）
+
+	Warnings []WeatherWarning `json:"warnings,omitempty"` // 生效中的气象预警
 }
 
 // NewWeatherClient 创建天气客户端
@@ -30,8 +72,35 @@ func NewWeatherClient(apiKey string) *WeatherClient {
 	return &WeatherClient{
 		apiKey: apiKey,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: network.Transport(network.Weather),
 		},
+		cityIDs: make(map[string]string),
+	}
+}
+
+// SetCoordinates 按坐标查询天气，避免城市名有歧义，也省去城市 ID 查询
+func (w *WeatherClient) SetCoordinates(lat, lng string) {
+	latF, errLat := strconv.ParseFloat(lat, 64)
+	lngF, errLng := strconv.ParseFloat(lng, 64)
+	if errLat != nil || errLng != nil {
+		return
+	}
+	// 和风天气的坐标格式为 "经度,纬度"，最多两位小数
+	w.coordinates = fmt.Sprintf("%.2f,%.2f", lngF, latF)
+}
+
+// SetCityCache 把查询到的城市 ID 持久化到文件，重启后不必重新查询
+func (w *WeatherClient) SetCityCache(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.cityCache = path
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &w.cityIDs)
+	}
+	if w.cityIDs == nil {
+		w.cityIDs = make(map[string]string)
 	}
 }
 
@@ -54,10 +123,9 @@ func (w *WeatherClient) GetWeather(city string) (*WeatherInfo, error) {
 
 // getWeatherOnce 单次获取天气
 func (w *WeatherClient) getWeatherOnce(city string) (*WeatherInfo, error) {
-	// 先查询城市 ID
-	locationID, err := w.getCityID(city)
+	locationID, err := w.resolveLocation(city)
 	if err != nil {
-		return nil, fmt.Errorf("查询城市失败: %v", err)
+		return nil, err
 	}
 
 	// 获取实时天气
@@ -98,14 +166,237 @@ func (w *WeatherClient) getWeatherOnce(city string) (*WeatherInfo, error) {
 		return nil, fmt.Errorf("天气API错误，code: %s", result.Code)
 	}
 
-	return &WeatherInfo{
+	info := &WeatherInfo{
 		Temp:      result.Now.Temp,
 		FeelsLike: result.Now.FeelsLike,
 		Text:      result.Now.Text,
 		WindDir:   result.Now.WindDir,
 		WindScale: result.Now.WindScale,
 		Humidity:  result.Now.Humidity,
-	}, nil
+	}
+
+	// 空气质量和降水概率只是补充信息，获取失败不影响天气结果
+	info.AQI, _ = w.airQuality(locationID)
+	if forecasts, err := w.hourlyForecast(locationID); err == nil && len(forecasts) > 0 {
+		info.Pop = forecasts[0].Pop
+	}
+	return info, nil
+}
+
+// GetWarnings 获取生效中的气象灾害预警
+func (w *WeatherClient) GetWarnings(city string) ([]WeatherWarning, error) {
+	locationID, err := w.resolveLocation(city)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Code    string `json:"code"`
+		Warning []struct {
+			Title    string `json:"title"`
+			TypeName string `json:"typeName"`
+			Severity string `json:"severity"`
+			Text     string `json:"text"`
+		} `json:"warning"`
+	}
+	if err := w.getJSON("https://devapi.qweather.com/v7/warning/now", locationID, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != "200" {
+		return nil, fmt.Errorf("天气预警API错误，code: %s", result.Code)
+	}
+
+	warnings := make([]WeatherWarning, 0, len(result.Warning))
+	for _, warn := range result.Warning {
+		warnings = append(warnings, WeatherWarning{
+			Title:    warn.Title,
+			Type:     warn.TypeName,
+			Severity: warn.Severity,
+			Text:     warn.Text,
+		})
+	}
+	return warnings, nil
+}
+
+// airQuality 获取实时空气质量指数
+func (w *WeatherClient) airQuality(locationID string) (string, error) {
+	var result struct {
+		Code string `json:"code"`
+		Now  struct {
+			AQI string `json:"aqi"`
+		} `json:"now"`
+	}
+	if err := w.getJSON("https://devapi.qweather.com/v7/air/now", locationID, &result); err != nil {
+		return "", err
+	}
+	if result.Code != "200" {
+		return "", fmt.Errorf("空气质量API错误，code: %s", result.Code)
+	}
+	return result.Now.AQI, nil
+}
+
+// GetForecast 获取未来 hours 小时的天气预报
+// 24 小时内使用逐小时预报，更长时间使用 3 天逐日预报（逐日预报的温度取当天最高温）
+func (w *WeatherClient) GetForecast(city string, hours int) ([]WeatherInfo, error) {
+	locationID, err := w.resolveLocation(city)
+	if err != nil {
+		return nil, err
+	}
+
+	if hours > 24 {
+		return w.dailyForecast(locationID)
+	}
+
+	forecasts, err := w.hourlyForecast(locationID)
+	if err != nil {
+		return nil, err
+	}
+	if hours > 0 && len(forecasts) > hours {
+		forecasts = forecasts[:hours]
+	}
+	return forecasts, nil
+}
+
+// hourlyForecast 逐小时预报（未来 24 小时）
+func (w *WeatherClient) hourlyForecast(locationID string) ([]WeatherInfo, error) {
+	var result struct {
+		Code   string `json:"code"`
+		Hourly []struct {
+			FxTime    string `json:"fxTime"`
+			Temp      string `json:"temp"`
+			Text      string `json:"text"`
+			WindDir   string `json:"windDir"`
+			WindScale string `json:"windScale"`
+			Humidity  string `json:"humidity"`
+			Pop       string `json:"pop"`
+		} `json:"hourly"`
+	}
+	if err := w.getJSON("https://devapi.qweather.com/v7/weather/24h", locationID, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != "200" {
+		return nil, fmt.Errorf("天气预报API错误，code: %s", result.Code)
+	}
+
+	forecasts := make([]WeatherInfo, 0, len(result.Hourly))
+	for _, h := range result.Hourly {
+		t, err := time.Parse("2006-01-02T15:04-07:00", h.FxTime)
+		if err != nil {
+			continue
+		}
+		forecasts = append(forecasts, WeatherInfo{
+			Temp:      h.Temp,
+			FeelsLike: h.Temp, // 预报没有体感温度
+			Text:      h.Text,
+			WindDir:   h.WindDir,
+			WindScale: h.WindScale,
+			Humidity:  h.Humidity,
+			Pop:       h.Pop,
+			Time:      t,
+		})
+	}
+	return forecasts, nil
+}
+
+// dailyForecast 逐日预报（未来 3 天），Time 为当天中午
+func (w *WeatherClient) dailyForecast(locationID string) ([]WeatherInfo, error) {
+	var result struct {
+		Code  string `json:"code"`
+		Daily []struct {
+			FxDate       string `json:"fxDate"`
+			TempMax      string `json:"tempMax"`
+			TextDay      string `json:"textDay"`
+			WindDirDay   string `json:"windDirDay"`
+			WindScaleDay string `json:"windScaleDay"`
+			Humidity     string `json:"humidity"`
+		} `json:"daily"`
+	}
+	if err := w.getJSON("https://devapi.qweather.com/v7/weather/3d", locationID, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != "200" {
+		return nil, fmt.Errorf("天气预报API错误，code: %s", result.Code)
+	}
+
+	forecasts := make([]WeatherInfo, 0, len(result.Daily))
+	for _, d := range result.Daily {
+		date, err := time.ParseInLocation("2006-01-02", d.FxDate, time.Local)
+		if err != nil {
+			continue
+		}
+		forecasts = append(forecasts, WeatherInfo{
+			Temp:      d.TempMax,
+			FeelsLike: d.TempMax,
+			Text:      d.TextDay,
+			WindDir:   d.WindDirDay,
+			WindScale: d.WindScaleDay,
+			Humidity:  d.Humidity,
+			Time:      date.Add(12 * time.Hour),
+		})
+	}
+	return forecasts, nil
+}
+
+// getJSON 请求和风天气接口并解析 JSON
+func (w *WeatherClient) getJSON(endpoint, locationID string, v interface{}) error {
+	resp, err := w.client.Get(fmt.Sprintf("%s?location=%s&key=%s", endpoint, locationID, w.apiKey))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// ForecastAt 从预报中选出与目标时间最接近的一条，没有预报时返回 nil
+func ForecastAt(forecasts []WeatherInfo, at time.Time) *WeatherInfo {
+	var best *WeatherInfo
+	var bestDiff time.Duration
+	for i := range forecasts {
+		diff := forecasts[i].Time.Sub(at)
+		if diff < 0 {
+			diff = -diff
+		}
+		if best == nil || diff < bestDiff {
+			best, bestDiff = &forecasts[i], diff
+		}
+	}
+	return best
+}
+
+// resolveLocation 返回查询天气用的 location 参数
+// 优先使用坐标，其次使用缓存的城市 ID，都没有时才查询城市
+func (w *WeatherClient) resolveLocation(city string) (string, error) {
+	if w.coordinates != "" {
+		return w.coordinates, nil
+	}
+
+	w.mu.Lock()
+	id, ok := w.cityIDs[city]
+	w.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := w.getCityID(city)
+	if err != nil {
+		return "", fmt.Errorf("查询城市失败: %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cityIDs[city] = id
+	if w.cityCache != "" {
+		if data, err := json.Marshal(w.cityIDs); err == nil {
+			os.MkdirAll(filepath.Dir(w.cityCache), 0755)
+			os.WriteFile(w.cityCache, data, 0644)
+		}
+	}
+	return id, nil
 }
 
 // getCityID 获取城市 ID
@@ -148,28 +439,108 @@ func (w *WeatherClient) getCityID(city string) (string, error) {
 
 // Describe 返回天气描述文本
 func (w *WeatherInfo) Describe() string {
-	return fmt.Sprintf(
-		"当前天气：%s，温度 %s°C，体感温度 %s°C，%s %s级，湿度 %s%%",
-		w.Text, w.Temp, w.FeelsLike, w.WindDir, w.WindScale, w.Humidity,
-	)
+	var desc string
+	if !w.Time.IsZero() {
+		desc = fmt.Sprintf(
+			"预计 %s 天气：%s，温度 %s°C，%s %s级，湿度 %s%%",
+			w.Time.Local().Format("15:04"), w.Text, w.Temp, w.WindDir, w.WindScale, w.Humidity,
+		)
+	} else {
+		desc = fmt.Sprintf(
+			"当前天气：%s，温度 %s°C，体感温度 %s°C，%s %s级，湿度 %s%%",
+			w.Text, w.Temp, w.FeelsLike, w.WindDir, w.WindScale, w.Humidity,
+		)
+	}
+
+	if w.Pop != "" {
+		desc += fmt.Sprintf("，降水概率 %s%%", w.Pop)
+	}
+	if w.AQI != "" {
+		desc += fmt.Sprintf("，空气质量指数 %s", w.AQI)
+	}
+	for _, warn := range w.Warnings {
+		desc += "\n⚠️ 气象预警：" + warn.Title
+	}
+	return desc
 }
 
-// SuggestFoodType 根据天气推荐食物类型
-func (w *WeatherInfo) SuggestFoodType() string {
-	// 简单的规则引擎
+// PopValue 降水概率（%，未知为 0）
+func (w *WeatherInfo) PopValue() int {
+	pop := 0
+	fmt.Sscanf(w.Pop, "%d", &pop)
+	return pop
+}
+
+// AQIValue 空气质量指数（未知为 0）
+func (w *WeatherInfo) AQIValue() int {
+	aqi := 0
+	fmt.Sscanf(w.AQI, "%d", &aqi)
+	return aqi
+}
+
+// IsSevereWeather 是否应该尽量不出门（有气象预警、大雨暴雨、降水概率很高、重度污染）
+// 这种天气只推荐很近的餐厅或外卖
+func (w *WeatherInfo) IsSevereWeather() bool {
+	if len(w.Warnings) > 0 {
+		return true
+	}
+	for _, kw := range []string{"大雨", "暴雨", "雷阵雨", "大雪", "暴雪", "冰雹"} {
+		if strings.Contains(w.Text, kw) {
+			return true
+		}
+	}
+	return w.PopValue() >= 80 || w.AQIValue() > 200
+}
+
+// IsBadWeather 是否不适合走远路（雨雪、严寒、酷暑、大风、空气污染）
+func (w *WeatherInfo) IsBadWeather() bool {
+	if w.IsSevereWeather() {
+		return true
+	}
+	for _, kw := range []string{"雨", "雪", "冰雹", "沙尘", "雾霾"} {
+		if strings.Contains(w.Text, kw) {
+			return true
+		}
+	}
+
 	temp := 0
 	fmt.Sscanf(w.Temp, "%d", &temp)
+	if temp <= 0 || temp >= 35 {
+		return true
+	}
+
+	// 降水概率较高、空气较差时也按坏天气处理
+	if w.PopValue() >= 60 || w.AQIValue() > 150 {
+		return true
+	}
+
+	windScale := 0
+	fmt.Sscanf(w.WindScale, "%d", &windScale)
+	return windScale >= 6
+}
 
-	switch {
-	case temp <= 5:
-		return "天气寒冷，推荐热汤、火锅、羊肉等暖身食物"
-	case temp <= 15:
-		return "天气偏凉，推荐热食、炖菜、面食等"
-	case temp <= 25:
-		return "天气舒适，各类食物都适合"
-	case temp <= 32:
-		return "天气炎热，推荐清淡、凉菜、冷面等解暑食物"
-	default:
-		return "天气酷热，推荐解暑降温的食物，注意多喝水"
-	}
-}
\ No newline at end of file
+// SuggestFoodType 根据天气推荐食物类型（使用默认规则，自定义规则见 FoodRuleSet）
+func (w *WeatherInfo) SuggestFoodType() string {
+	return defaultFoodRuleSet.Evaluate(w).Suggestion
+}
+
+// beaufortScale 风速（m/s）转蒲福风力等级
+func beaufortScale(speed float64) int {
+	limits := []float64{0.3, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+	for scale, limit := range limits {
+		if speed < limit {
+			return scale
+		}
+	}
+	return len(limits)
+}
+
+// windDirection 风向角度转中文八方位
+func windDirection(deg float64) string {
+	names := []string{"北风", "东北风", "东风", "东南风", "南风", "西南风", "西风", "西北风"}
+	idx := int((deg+22.5)/45) % len(names)
+	if idx < 0 {
+		idx = 0
+	}
+	return names[idx]
+}
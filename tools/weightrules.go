@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WeightRule 按餐厅属性加减分的规则，如 when: "distance < 300 && rating >= 4.5"，then: "+30"
+//
+// 条件表达式（语法见 condition.go）可用的字段：
+//   - 数值：distance（米）、walk（步行分钟）、rating（评分）、cost（人均）、reviews（评价数）、
+//     branches（附近同品牌分店数）、delivery_minutes（外卖送达分钟）；未知时为 0
+//   - 文本：name、type、cuisine，如 "name ~ 兰州"、"cuisine !~ 火锅"
+//   - 布尔：quick（快餐类）、full（正餐炒菜类）、delivery（支持外卖）
+type WeightRule struct {
+	When string `yaml:"when"` // 条件表达式，留空表示总是生效
+	Then string `yaml:"then"` // 加减分，如 "+30"、"-20"
+	Note string `yaml:"note,omitempty"`
+}
+
+// WeightRuleSet 编译后的权重规则
+type WeightRuleSet struct {
+	rules []compiledWeightRule
+}
+
+type compiledWeightRule struct {
+	cond  condition
+	boost int
+}
+
+// restaurantFields 权重规则中可用的字段
+var restaurantFields = condFields{
+	numbers: map[string]bool{"distance": true, "walk": true, "rating": true, "cost": true, "reviews": true, "branches": true, "delivery_minutes": true},
+	texts:   map[string]bool{"name": true, "type": true, "cuisine": true},
+	flags:   map[string]bool{"quick": true, "full": true, "delivery": true},
+}
+
+// NewWeightRuleSet 编译权重规则，规则为空时返回 nil（不加减分）
+func NewWeightRuleSet(rules []WeightRule) (*WeightRuleSet, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	set := &WeightRuleSet{}
+	for i, rule := range rules {
+		cond, err := parseCondition(rule.When, restaurantFields)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条规则 %q: %v", i+1, rule.When, err)
+		}
+		boost, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(rule.Then), "+"))
+		if err != nil {
+			return nil, fmt.Errorf("第%d条规则的 then 应为 +30 / -20 这样的加减分: %q", i+1, rule.Then)
+		}
+		set.rules = append(set.rules, compiledWeightRule{cond: cond, boost: boost})
+	}
+	return set, nil
+}
+
+// Boost 餐厅命中的所有规则的加减分之和
+func (s *WeightRuleSet) Boost(r Restaurant) int {
+	if s == nil {
+		return 0
+	}
+	total := 0
+	for _, rule := range s.rules {
+		if rule.cond.eval(restaurantValues{&r}) {
+			total += rule.boost
+		}
+	}
+	return total
+}
+
+// restaurantValues 权重规则求值时的字段值
+type restaurantValues struct {
+	r *Restaurant
+}
+
+func (v restaurantValues) number(field string) float64 {
+	switch field {
+	case "distance":
+		return float64(v.r.GetDistanceInt())
+	case "walk":
+		return float64(v.r.WalkMinutes)
+	case "rating":
+		return v.r.GetRatingFloat()
+	case "cost":
+		return v.r.GetCostFloat()
+	case "reviews":
+		return float64(v.r.ReviewCount)
+	case "branches":
+		return float64(v.r.Branches)
+	case "delivery_minutes":
+		return float64(v.r.DeliveryMinutes)
+	}
+	return 0
+}
+
+func (v restaurantValues) text(field string) string {
+	switch field {
+	case "name":
+		return v.r.Name
+	case "type":
+		return v.r.Type
+	}
+	return v.r.Cuisine
+}
+
+func (v restaurantValues) flag(field string) bool {
+	switch field {
+	case "quick":
+		return v.r.Category == CategoryQuickMeal
+	case "full":
+		return v.r.Category == CategoryFullMeal
+	}
+	return v.r.Delivery == DeliveryYes
+}
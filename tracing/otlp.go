@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/network"
+)
+
+// otlpExporter 按 OTLP/HTTP 的 JSON 格式发送到 OpenTelemetry Collector、Jaeger、Tempo 等
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	resource []otlpAttr
+	client   *http.Client
+}
+
+func newOTLPExporter(endpoint string, getenv func(string) string) (*otlpExporter, error) {
+	if endpoint == "" {
+		endpoint = "http://localhost:4318/v1/traces"
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("OTLP 导出地址不正确: %s", endpoint)
+	}
+	if p := getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"); p == "" {
+		if p = getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); p != "" && p != "http/json" {
+			return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_PROTOCOL 只支持 http/json: %s", p)
+		}
+	} else if p != "http/json" {
+		return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL 只支持 http/json: %s", p)
+	}
+
+	headers := parseList(getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	for k, v := range parseList(getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")) {
+		headers[k] = v
+	}
+
+	timeout := 10 * time.Second
+	if ms := getenv("OTEL_EXPORTER_OTLP_TIMEOUT"); ms != "" {
+		n, err := strconv.Atoi(ms)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_TIMEOUT 应为毫秒数: %s", ms)
+		}
+		timeout = time.Duration(n) * time.Millisecond
+	}
+
+	resource := parseList(getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if name := getenv("OTEL_SERVICE_NAME"); name != "" {
+		resource["service.name"] = name
+	} else if resource["service.name"] == "" {
+		resource["service.name"] = "meal-agent"
+	}
+	e := &otlpExporter{endpoint: endpoint, headers: headers, client: &http.Client{
+		Timeout: timeout,
+		// 不经过 http.DefaultTransport：后台模式退出时会取消它上面的请求，最后一批 span 发不出去
+		Transport: network.Separate(network.Trace),
+	}}
+	for k, v := range resource {
+		e.resource = append(e.resource, otlpAttr{Key: k, Value: otlpValue(v)})
+	}
+	return e, nil
+}
+
+// parseList 解析 key1=value1,key2=value2（值可以是 URL 编码的）
+func parseList(s string) map[string]string {
+	m := map[string]string{}
+	for _, item := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(item, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		m[k] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+type otlpAttr struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID      string     `json:"traceId"`
+	SpanID       string     `json:"spanId"`
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Kind         int        `json:"kind"`
+	Start        string     `json:"startTimeUnixNano"`
+	End          string     `json:"endTimeUnixNano"`
+	Attributes   []otlpAttr `json:"attributes,omitempty"`
+	Status       otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 未设置，2 出错
+	Message string `json:"message,omitempty"`
+}
+
+// otlpValue OTLP 的 AnyValue（64 位整数按规定写成字符串）
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	}
+	return map[string]interface{}{"stringValue": attrString(v)}
+}
+
+func (e *otlpExporter) export(spans []*Span) error {
+	list := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:      s.traceID,
+			SpanID:       s.spanID,
+			ParentSpanID: s.parentID,
+			Name:         s.name,
+			Kind:         s.kind,
+			Start:        strconv.FormatInt(s.start.UnixNano(), 10),
+			End:          strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		for _, a := range s.attrs {
+			o.Attributes = append(o.Attributes, otlpAttr{Key: a.key, Value: otlpValue(a.value)})
+		}
+		if s.failed {
+			o.Status = otlpStatus{Code: 2, Message: s.errMsg}
+		}
+		list = append(list, o)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": e.resource},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "meal-agent"},
+				"spans": list,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
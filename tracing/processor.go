@@ -0,0 +1,161 @@
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"meal-agent/logging"
+)
+
+const (
+	queueSize     = 2048            // 等待导出的 span 上限，放不下时丢弃
+	batchSize     = 512             // 攒够这么多 span 时立即导出
+	flushInterval = 5 * time.Second // 其他情况下最长等待时间（根 span 结束时也立即导出）
+)
+
+// exporter 把一批结束的 span 发送出去
+type exporter interface {
+	export(spans []*Span) error
+}
+
+// processor 在后台分批导出结束的 span，导出不阻塞推荐
+type processor struct {
+	queue    chan *Span
+	done     chan struct{}
+	exporter exporter
+}
+
+func newProcessor(e exporter) *processor {
+	p := &processor{queue: make(chan *Span, queueSize), done: make(chan struct{}), exporter: e}
+	go p.run()
+	return p
+}
+
+func (p *processor) add(s *Span) {
+	defer func() { recover() }() // 退出后结束的 span（队列已关闭）直接丢弃
+	select {
+	case p.queue <- s:
+	default:
+	}
+}
+
+func (p *processor) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.exporter.export(batch); err != nil {
+			logging.Module("trace").Warn("导出链路追踪失败", "spans", len(batch), "err", err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= batchSize || s.parentID == "" {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Setup 按 OpenTelemetry 的标准环境变量开启导出：
+//
+//	OTEL_TRACES_EXPORTER            otlp / console（输出到日志）/ none；设置了 OTLP 地址时默认为 otlp，否则不导出
+//	OTEL_EXPORTER_OTLP_ENDPOINT     OTLP/HTTP 地址，如 http://localhost:4318（发送到 /v1/traces）
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT 完整的导出地址，优先于上一项
+//	OTEL_EXPORTER_OTLP_HEADERS      请求头，如 x-api-key=xxx,x-team=yyy
+//	OTEL_EXPORTER_OTLP_TIMEOUT      导出超时（毫秒，默认 10000）
+//	OTEL_EXPORTER_OTLP_PROTOCOL     只支持 http/json
+//	OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES  服务名称和资源属性
+//	OTEL_SDK_DISABLED=true          关闭
+func Setup() error {
+	e, err := newExporter(os.Getenv)
+	if err != nil || e == nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = newProcessor(e)
+	}
+	return nil
+}
+
+func newExporter(getenv func(string) string) (exporter, error) {
+	if strings.EqualFold(getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+	endpoint := getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+		}
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(getenv("OTEL_TRACES_EXPORTER")))
+	if kind == "" && endpoint != "" {
+		kind = "otlp"
+	}
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "console", "logging":
+		return consoleExporter{}, nil
+	case "otlp":
+		return newOTLPExporter(endpoint, getenv)
+	}
+	return nil, fmt.Errorf("OTEL_TRACES_EXPORTER 应为 otlp / console / none: %s", kind)
+}
+
+// Shutdown 导出剩下的 span 并停止导出，最多等待 timeout（没有开启导出时直接返回）
+func Shutdown(timeout time.Duration) {
+	mu.Lock()
+	p := current
+	current = nil
+	mu.Unlock()
+	if p == nil {
+		return
+	}
+	close(p.queue)
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+	}
+}
+
+// consoleExporter 把 span 写到日志中（module=trace），不需要收集服务也能看到各步骤的耗时
+type consoleExporter struct{}
+
+func (consoleExporter) export(spans []*Span) error {
+	log := logging.Module("trace")
+	for _, s := range spans {
+		args := []interface{}{"name", s.name, "trace_id", s.traceID, "span_id", s.spanID,
+			"duration_ms", s.end.Sub(s.start).Milliseconds()}
+		if s.parentID != "" {
+			args = append(args, "parent_id", s.parentID)
+		}
+		for _, a := range s.attrs {
+			args = append(args, a.key, a.value)
+		}
+		if s.failed {
+			args = append(args, "err", s.errMsg)
+		}
+		log.Info("span", args...)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 推荐流程的链路追踪：每次推荐一个根 span，天气、餐厅搜索、排序、LLM 调用等步骤为子 span
+// 没有开启导出时 Start 返回 nil，nil 的 Span 上的方法什么都不做，调用的地方不用判断
+
+// Span 一个步骤的耗时和属性
+type Span struct {
+	name      string
+	traceID   string
+	spanID    string
+	parentID  string
+	kind      int
+	start     time.Time
+	end       time.Time
+	attrs     []attr
+	errMsg    string
+	failed    bool
+	mu        sync.Mutex
+	ended     bool
+	processor *processor
+}
+
+type attr struct {
+	key   string
+	value interface{}
+}
+
+// OTLP 中 span 的类型
+const (
+	KindInternal = 1
+	KindClient   = 3 // 调用外部接口
+)
+
+var (
+	mu      sync.Mutex
+	current *processor // 开启导出时的 span 处理器
+)
+
+// Start 开始一个 span，parent 为 nil 时开始新的链路；没有开启导出时返回 nil
+func Start(parent *Span, name string, kind int) *Span {
+	mu.Lock()
+	p := current
+	mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	s := &Span{name: name, kind: kind, start: time.Now(), spanID: randomHex(8), processor: p}
+	if parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	return s
+}
+
+// Set 设置属性（字符串、整数、小数、布尔值，其他类型转为字符串）
+func (s *Span) Set(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attr{key, value})
+}
+
+// Fail 标记出错，err 为 nil 时不做什么
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed, s.errMsg = true, err.Error()
+}
+
+// End 结束 span 并交给导出，重复调用只算第一次
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+	s.processor.add(s)
+}
+
+// TraceID 链路 ID（32 位十六进制），没有开启导出时为空
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func attrString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
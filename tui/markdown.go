@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 终端中显示 LLM 回复的 Markdown：编号列表、加粗、标题等按颜色和样式显示，去掉标记符号
+
+var (
+	headingPattern  = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	bulletPattern   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedPattern = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	quotePattern    = regexp.MustCompile(`^>\s?(.*)$`)
+	rulePattern     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	codePattern     = regexp.MustCompile("`[^`\n]+`")
+	linkPattern     = regexp.MustCompile(`\[([^\]\n]+)\]\((\S+?)\)`)
+	boldPattern     = regexp.MustCompile(`\*\*([^*\n]+?)\*\*|__([^_\n]+?)__`)
+	italicPattern   = regexp.MustCompile(`\*([^*\s](?:[^*\n]*[^*\s])?)\*`)
+)
+
+// UseColor 输出到 f 时是否使用颜色：f 是终端，没有 --no-color，也没有设置 NO_COLOR 环境变量（https://no-color.org）
+func UseColor(f *os.File, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Markdown 按终端颜色和样式渲染 Markdown 文本
+func Markdown(text string) string {
+	return renderMarkdown(text, true)
+}
+
+// StripMarkdown 去掉 Markdown 标记，只保留文字（用于已经有自己样式的地方）
+func StripMarkdown(text string) string {
+	return renderMarkdown(text, false)
+}
+
+func renderMarkdown(text string, color bool) string {
+	s := styler(color)
+	lines := strings.Split(text, "\n")
+	inCode := false
+	for i, line := range lines {
+		// 代码块原样显示，去掉 ``` 所在的行
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			lines[i] = ""
+			continue
+		}
+		if inCode {
+			lines[i] = s.apply(line, "36", "39")
+			continue
+		}
+		switch {
+		case rulePattern.MatchString(line):
+			lines[i] = s.apply(strings.Repeat("─", 24), "2", "22")
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			lines[i] = s.apply(s.apply(s.inline(m[1]), "1", "22"), "33", "39")
+		case numberedPattern.MatchString(line):
+			m := numberedPattern.FindStringSubmatch(line)
+			lines[i] = m[1] + s.apply(s.apply(m[2], "1", "22"), "36", "39") + " " + s.inline(m[3])
+		case bulletPattern.MatchString(line):
+			m := bulletPattern.FindStringSubmatch(line)
+			lines[i] = m[1] + s.apply("•", "36", "39") + " " + s.inline(m[2])
+		case quotePattern.MatchString(line):
+			m := quotePattern.FindStringSubmatch(line)
+			lines[i] = s.apply("│ "+s.inline(m[1]), "2", "22")
+		default:
+			lines[i] = s.inline(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// styler 为 true 时加上 ANSI 样式，否则只去掉标记
+type styler bool
+
+// apply 用 SGR 参数 on 开启样式，off 只关闭这一种样式（嵌套时不影响外层）
+func (s styler) apply(text, on, off string) string {
+	if !s || text == "" {
+		return text
+	}
+	return "\x1b[" + on + "m" + text + "\x1b[" + off + "m"
+}
+
+// inline 行内的代码、链接、加粗和斜体，代码中的内容不再处理
+func (s styler) inline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range codePattern.FindAllStringIndex(line, -1) {
+		b.WriteString(s.emphasis(line[last:loc[0]]))
+		b.WriteString(s.apply(line[loc[0]+1:loc[1]-1], "36", "39"))
+		last = loc[1]
+	}
+	b.WriteString(s.emphasis(line[last:]))
+	return b.String()
+}
+
+func (s styler) emphasis(text string) string {
+	text = linkPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := linkPattern.FindStringSubmatch(m)
+		return s.apply(sub[1], "4", "24") + " " + s.apply("("+sub[2]+")", "2", "22")
+	})
+	text = boldPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := boldPattern.FindStringSubmatch(m)
+		return s.apply(sub[1]+sub[2], "1", "22")
+	})
+	return italicPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return s.apply(m[1:len(m)-1], "3", "23")
+	})
+}
@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadSecret 读取一行输入（用于输入密钥）：f 是终端时显示提示并且不回显，否则直接读取（如从管道传入）
+func ReadSecret(f *os.File, prompt string) (string, error) {
+	if restore, err := noEcho(f); err == nil {
+		fmt.Fprint(os.Stderr, prompt)
+		defer func() {
+			restore()
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("终端界面目前只支持 Linux 和 macOS")
+}
+
+func noEcho(f *os.File) (func(), error) {
+	return nil, errors.New("不支持关闭回显")
+}
+
+func termSize(f *os.File) (rows, cols int) {
+	return 24, 80
+}
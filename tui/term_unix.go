@@ -0,0 +1,52 @@
+//go:build unix
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// makeRaw 把终端切换到原始模式（逐个读取按键、不回显），返回恢复原设置的函数
+func makeRaw(f *os.File) (func(), error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("读取终端设置失败: %v", err)
+	}
+	if _, err := stty(f, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("设置终端失败: %v", err)
+	}
+	return func() {
+		stty(f, strings.TrimSpace(saved))
+	}, nil
+}
+
+// noEcho 关闭终端回显（输入密码时使用），返回恢复的函数
+func noEcho(f *os.File) (func(), error) {
+	if _, err := stty(f, "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(f, "echo")
+	}, nil
+}
+
+// termSize 终端的行数和列数，获取失败时按 24x80
+func termSize(f *os.File) (rows, cols int) {
+	out, err := stty(f, "size")
+	if err == nil {
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return rows, cols
+		}
+	}
+	return 24, 80
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,460 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"meal-agent/agent"
+	"meal-agent/i18n"
+	"meal-agent/tools"
+)
+
+const (
+	replyLines  = 3 // 列表上方显示的推荐理由行数
+	detailLines = 8 // 详情区的行数
+)
+
+var mealNames = map[string]string{"lunch": "午餐", "dinner": "晚餐"}
+
+// Run 终端界面：推荐的候选餐厅显示为列表，方向键浏览，下方显示选中餐厅的地址、评分、距离和地图链接，
+// 回车确认并记录，/ 按名称、菜系或地址筛选，r 换一批，q 退出
+// keyword 不为空时按关键词定向搜索（同 SearchRecommendation）
+func Run(a *agent.MealAgent, mealType, keyword string) error {
+	in, out := os.Stdin, os.Stdout
+	if fi, err := in.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return errors.New(i18n.T("终端界面需要在终端中运行（不能重定向输入）"))
+	}
+
+	fmt.Fprintln(out, i18n.T("正在为你搜索附近餐厅..."))
+	var (
+		reply string
+		err   error
+	)
+	if keyword != "" {
+		reply, err = a.SearchRecommendation(mealType, keyword)
+	} else {
+		reply, err = a.GetRecommendation(mealType)
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.T("获取推荐失败: %v"), err)
+	}
+	m := newModel(mealType, reply, a.LastWeather(), a.LastRestaurants())
+	if len(m.restaurants) == 0 {
+		fmt.Fprintln(out, reply)
+		return nil
+	}
+
+	restore, err := makeRaw(in)
+	if err != nil {
+		return err
+	}
+	// 备用屏幕，退出后终端恢复原来的内容
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	leave := func() {
+		fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+		restore()
+	}
+
+	buf := make([]byte, 64)
+	for {
+		rows, cols := termSize(in)
+		draw(out, m.view(rows, cols))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			leave()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			switch m.update(k) {
+			case actionQuit:
+				leave()
+				return nil
+			case actionConfirm:
+				index, _ := m.selected()
+				leave()
+				reply, err := a.ConfirmRestaurant(index)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, reply)
+				return nil
+			case actionRefresh:
+				m.status = i18n.T("正在换一批...")
+				draw(out, m.view(rows, cols))
+				reply, err := a.Chat("换一批")
+				if err != nil {
+					m.status = i18n.T("换一批失败: %v", err)
+					continue
+				}
+				if restaurants := a.LastRestaurants(); len(restaurants) > 0 {
+					m = newModel(mealType, reply, a.LastWeather(), restaurants)
+				} else {
+					m.status = firstLine(reply)
+				}
+			}
+		}
+	}
+}
+
+// draw 清屏后输出（原始模式下换行需要 \r\n）
+func draw(w io.Writer, lines []string) {
+	var b bytes.Buffer
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(strings.Join(lines, "\x1b[K\r\n"))
+	w.Write(b.Bytes())
+}
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionConfirm
+	actionRefresh
+)
+
+// model 界面状态，update 处理按键，view 生成要显示的各行
+type model struct {
+	mealType    string
+	reply       string
+	weather     *tools.WeatherInfo
+	restaurants []tools.Restaurant
+	visible     []int // 符合筛选条件的餐厅在 restaurants 中的序号
+	cursor      int   // 选中的是 visible 中的第几个
+	offset      int   // 列表从 visible 中的第几个开始显示
+	filter      []rune
+	filtering   bool
+	status      string
+}
+
+func newModel(mealType, reply string, weather *tools.WeatherInfo, restaurants []tools.Restaurant) *model {
+	m := &model{mealType: mealType, reply: reply, weather: weather, restaurants: restaurants}
+	m.applyFilter()
+	return m
+}
+
+// applyFilter 按筛选文字更新列表，名称、菜系、类型或地址包含即可（不区分大小写）
+func (m *model) applyFilter() {
+	kw := strings.ToLower(string(m.filter))
+	m.visible = m.visible[:0]
+	for i, r := range m.restaurants {
+		text := strings.ToLower(r.Name + " " + r.Cuisine + " " + r.Type + " " + r.Address)
+		if kw == "" || strings.Contains(text, kw) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+// selected 选中餐厅在 restaurants 中的序号（与 ConfirmRestaurant 一致），筛选后没有餐厅时 ok 为 false
+func (m *model) selected() (int, bool) {
+	if m.cursor >= len(m.visible) {
+		return 0, false
+	}
+	return m.visible[m.cursor], true
+}
+
+func (m *model) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *model) update(k key) action {
+	m.status = ""
+	if m.filtering {
+		switch k.name {
+		case "enter":
+			m.filtering = false
+		case "esc":
+			m.filtering = false
+			m.filter = nil
+			m.applyFilter()
+		case "backspace":
+			if len(m.filter) > 0 {
+				m.filter = m.filter[:len(m.filter)-1]
+				m.applyFilter()
+			}
+		case "up":
+			m.move(-1)
+		case "down":
+			m.move(1)
+		case "ctrl-c":
+			return actionQuit
+		case "":
+			m.filter = append(m.filter, []rune(k.text)...)
+			m.applyFilter()
+		}
+		return actionNone
+	}
+
+	switch k.name {
+	case "up":
+		m.move(-1)
+	case "down":
+		m.move(1)
+	case "pgup":
+		m.move(-10)
+	case "pgdn":
+		m.move(10)
+	case "enter":
+		if _, ok := m.selected(); !ok {
+			m.status = i18n.T("没有符合筛选条件的餐厅")
+			return actionNone
+		}
+		return actionConfirm
+	case "esc":
+		if len(m.filter) > 0 {
+			m.filter = nil
+			m.applyFilter()
+		}
+	case "ctrl-c":
+		return actionQuit
+	case "":
+		switch k.text {
+		case "k":
+			m.move(-1)
+		case "j":
+			m.move(1)
+		case "/":
+			m.filtering = true
+		case "r":
+			return actionRefresh
+		case "q":
+			return actionQuit
+		}
+	}
+	return actionNone
+}
+
+// view 按终端大小生成各行：标题、推荐理由、候选列表、选中餐厅的详情和按键提示
+func (m *model) view(rows, cols int) []string {
+	var lines []string
+	add := func(s string) {
+		lines = append(lines, truncate(s, cols))
+	}
+
+	title := "🍽️ " + i18n.T(mealNames[m.mealType]+"推荐")
+	if m.weather != nil && m.weather.Text != "" {
+		title += fmt.Sprintf(" · %s %s°C", m.weather.Text, m.weather.Temp)
+	}
+	title += " · " + i18n.T("候选 %d 家", len(m.restaurants))
+	add("\x1b[1m" + title + "\x1b[0m")
+	for i, line := range strings.Split(strings.TrimSpace(StripMarkdown(m.reply)), "\n") {
+		if i >= replyLines {
+			break
+		}
+		add("\x1b[2m" + line + "\x1b[0m")
+	}
+	add("")
+
+	// 列表占用剩下的行
+	height := rows - len(lines) - detailLines - 3
+	if height < 3 {
+		height = 3
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	for row := 0; row < height; row++ {
+		i := m.offset + row
+		if i >= len(m.visible) {
+			if i == 0 {
+				add("  " + i18n.T("没有符合筛选条件的餐厅"))
+			} else {
+				add("")
+			}
+			continue
+		}
+		line := fmt.Sprintf("%2d. %s", m.visible[i]+1, listLine(m.restaurants[m.visible[i]]))
+		if i == m.cursor {
+			add("\x1b[7m> " + truncate(line, cols-2) + "\x1b[0m")
+		} else {
+			add("  " + line)
+		}
+	}
+
+	add(strings.Repeat("─", max(cols, 1)))
+	detail := []string{}
+	if index, ok := m.selected(); ok {
+		detail = details(m.restaurants[index])
+	}
+	for i := 0; i < detailLines; i++ {
+		if i < len(detail) {
+			add(detail[i])
+		} else {
+			add("")
+		}
+	}
+
+	switch {
+	case m.status != "":
+		add(m.status)
+	case m.filtering:
+		add(i18n.T("筛选: %s█  回车完成，Esc 清除", string(m.filter)))
+	case len(m.filter) > 0:
+		add(i18n.T("筛选「%s」· ↑↓ 浏览  回车 确认并记录  / 修改筛选  Esc 清除筛选  q 退出", string(m.filter)))
+	default:
+		add(i18n.T("↑↓ 浏览  回车 确认并记录  / 筛选  r 换一批  q 退出"))
+	}
+	return lines
+}
+
+// listLine 列表中的一行：名称、菜系、距离、评分、人均
+func listLine(r tools.Restaurant) string {
+	parts := []string{r.Name}
+	if r.Cuisine != "" {
+		parts[0] += "（" + r.Cuisine + "）"
+	}
+	if d := r.GetDistanceInt(); d > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", d))
+	}
+	if rating := r.GetRatingFloat(); rating > 0 {
+		parts = append(parts, fmt.Sprintf("⭐%.1f", rating))
+	}
+	if cost := r.GetCostFloat(); cost > 0 {
+		parts = append(parts, fmt.Sprintf("¥%.0f", cost))
+	}
+	if r.OpenStatus == tools.ClosingSoon {
+		parts = append(parts, i18n.T("即将打烊"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// details 选中餐厅的详情
+func details(r tools.Restaurant) []string {
+	lines := []string{"\x1b[1m" + r.Name + "\x1b[0m"}
+	if r.Address != "" {
+		lines = append(lines, i18n.T("地址: %s", r.Address))
+	}
+	var info []string
+	if rating := r.GetRatingFloat(); rating > 0 {
+		s := i18n.T("评分: %.1f", rating)
+		if r.RatingSource != "" && r.ReviewCount > 0 {
+			s += i18n.T("（%s %d 条评价）", r.RatingSource, r.ReviewCount)
+		}
+		info = append(info, s)
+	}
+	if cost := r.GetCostFloat(); cost > 0 {
+		info = append(info, i18n.T("人均: ¥%.0f", cost))
+	}
+	if len(info) > 0 {
+		lines = append(lines, strings.Join(info, "  "))
+	}
+	if d := r.GetDistanceInt(); d > 0 {
+		s := i18n.T("距离: %d 米", d)
+		if r.WalkMinutes > 0 {
+			s += i18n.T("，步行约 %d 分钟", r.WalkMinutes)
+		}
+		lines = append(lines, s)
+	}
+	if r.OpenTime != "" {
+		lines = append(lines, i18n.T("营业时间: %s", r.OpenTime))
+	}
+	if r.Tel != "" {
+		lines = append(lines, i18n.T("电话: %s", r.Tel))
+	}
+	if u := r.MapURL(); u != "" {
+		lines = append(lines, i18n.T("地图: %s", u))
+	}
+	return lines
+}
+
+type key struct {
+	name string // up / down / pgup / pgdn / enter / esc / backspace / ctrl-c，输入文字时为空
+	text string
+}
+
+// parseKeys 解析一次读到的输入，可能包含多个按键（粘贴、输入法一次提交多个字）
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch {
+		case bytes.HasPrefix(b, []byte("\x1b[A")), bytes.HasPrefix(b, []byte("\x1bOA")):
+			keys, b = append(keys, key{name: "up"}), b[3:]
+		case bytes.HasPrefix(b, []byte("\x1b[B")), bytes.HasPrefix(b, []byte("\x1bOB")):
+			keys, b = append(keys, key{name: "down"}), b[3:]
+		case bytes.HasPrefix(b, []byte("\x1b[5~")):
+			keys, b = append(keys, key{name: "pgup"}), b[4:]
+		case bytes.HasPrefix(b, []byte("\x1b[6~")):
+			keys, b = append(keys, key{name: "pgdn"}), b[4:]
+		case b[0] == 0x1b && len(b) > 2 && b[1] == '[':
+			// 其他不处理的控制序列：跳到结束字符
+			i := 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			b = b[min(i+1, len(b)):]
+		case b[0] == 0x1b:
+			keys, b = append(keys, key{name: "esc"}), b[1:]
+		case b[0] == '\r' || b[0] == '\n':
+			keys, b = append(keys, key{name: "enter"}), b[1:]
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys, b = append(keys, key{name: "backspace"}), b[1:]
+		case b[0] == 0x03:
+			keys, b = append(keys, key{name: "ctrl-c"}), b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				keys = append(keys, key{text: string(r)})
+			}
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+// truncate 按显示宽度截断（中文、emoji 占两格），忽略颜色等控制序列
+func truncate(s string, width int) string {
+	w, inEscape := 0, false
+	for i, r := range s {
+		switch {
+		case r == 0x1b:
+			inEscape = true
+		case inEscape:
+			if r >= 0x40 && r <= 0x7e && r != '[' {
+				inEscape = false
+			}
+		default:
+			w += runeWidth(r)
+			if w > width {
+				return s[:i] + "\x1b[0m"
+			}
+		}
+	}
+	return s
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x1100:
+		return 1
+	case r >= 0x2600 && r <= 0x27bf, r == 0x2b50, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3, r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1faff, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
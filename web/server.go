@@ -0,0 +1,278 @@
+package web
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"meal-agent/agent"
+	"meal-agent/calendar"
+	"meal-agent/config"
+	"meal-agent/memory"
+	"meal-agent/session"
+	"meal-agent/tools"
+)
+
+//go:embed static/index.html
+var indexHTML []byte
+
+const (
+	sessionCookie = "meal_session"
+	cookieMaxAge  = 12 * time.Hour // 与会话的闲置清理时间一致
+	maxCards      = 5              // 推荐卡片的数量（与推送中的候选餐厅一致）
+	historyLimit  = 30             // 用餐记录页显示的条数
+)
+
+// Server 局域网网页：聊天、推荐卡片、一键确认和用餐记录，家里人用手机浏览器就能用
+// 每个浏览器一个会话（各自的对话上下文），可以在页面上填写用户 ID 分开记录
+type Server struct {
+	dataDir  string
+	schedule config.Schedule
+	sessions *session.Store
+}
+
+// Card 推荐卡片
+type Card struct {
+	Index    int     `json:"index"` // 在推荐中的序号（确认时使用）
+	Name     string  `json:"name"`
+	Cuisine  string  `json:"cuisine,omitempty"`
+	Distance int     `json:"distance,omitempty"` // 米
+	Walk     int     `json:"walk,omitempty"`     // 步行分钟（未知时为 0）
+	Rating   float64 `json:"rating,omitempty"`
+	Cost     float64 `json:"cost,omitempty"` // 人均
+	Address  string  `json:"address,omitempty"`
+	Tel      string  `json:"tel,omitempty"`
+	MapURL   string  `json:"map_url,omitempty"` // 高德地图链接（手机上打开 App 导航）
+}
+
+// NewServer 创建网页服务，dataDir 为数据目录（读取用餐记录），schedule 为日历中安排用餐的提醒时间，
+// 会话与聊天机器人共用 sessions
+func NewServer(dataDir string, schedule config.Schedule, sessions *session.Store) *Server {
+	return &Server{dataDir: dataDir, schedule: schedule, sessions: sessions}
+}
+
+// Handler 页面和接口
+//
+//	GET  /              网页
+//	POST /api/chat      {"text": "推荐", "user": ""} -> {"reply": "...", "cards": [...]}
+//	POST /api/confirm   {"index": 0, "user": ""}     -> {"reply": "..."}
+//	POST /api/reset     清空对话上下文
+//	GET  /api/history?user=                          -> {"records": [...]}
+//	GET  /calendar.ics?user=&days=14                  用餐安排和确认的选择（日历软件订阅）
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexHTML)
+	})
+	mux.HandleFunc("/api/chat", s.handleChat)
+	mux.HandleFunc("/api/confirm", s.handleConfirm)
+	mux.HandleFunc("/api/reset", s.handleReset)
+	mux.HandleFunc("/api/history", s.handleHistory)
+	mux.HandleFunc("/calendar.ics", s.handleCalendar)
+	return mux
+}
+
+type request struct {
+	Text  string `json:"text"`
+	Index int    `json:"index"`
+	User  string `json:"user"`
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	req, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		writeError(w, http.StatusBadRequest, "请输入内容")
+		return
+	}
+
+	var (
+		reply string
+		err   error
+		after []tools.Restaurant
+		fresh bool
+	)
+	s.sessions.Do(sessionID(w, r), strings.TrimSpace(req.User), func(a *agent.MealAgent) {
+		before := a.LastRestaurants()
+		reply, err = a.Chat(text)
+		after = a.LastRestaurants()
+		fresh = len(after) > 0 && !sameNames(before, after)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := map[string]interface{}{"reply": reply}
+	// 推荐有变化时返回新的卡片
+	if fresh {
+		resp["cards"] = cards(after)
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	req, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	var (
+		reply string
+		err   error
+	)
+	s.sessions.Do(sessionID(w, r), strings.TrimSpace(req.User), func(a *agent.MealAgent) {
+		reply, err = a.ConfirmRestaurant(req.Index)
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, map[string]interface{}{"reply": reply})
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	req, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	s.sessions.Do(sessionID(w, r), strings.TrimSpace(req.User), func(a *agent.MealAgent) {
+		a.Reset()
+	})
+	writeJSON(w, map[string]interface{}{"reply": "已清空对话"})
+}
+
+// handleHistory 最近的用餐记录，每次重新读取（其他会话、终端中记录的也能看到）
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := memory.NewHistory(s.dataDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	records := history.ForUser(r.URL.Query().Get("user")).Find(memory.Filter{})
+	if len(records) > historyLimit {
+		records = records[:historyLimit]
+	}
+	writeJSON(w, map[string]interface{}{"records": records})
+}
+
+// handleCalendar iCalendar 订阅：今后的用餐安排和最近确认的选择，每次重新读取记录
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	history, err := memory.NewHistory(s.dataDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user := r.URL.Query().Get("user")
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	now := time.Now()
+	events := calendar.Feed(s.schedule, history.ForUser(user).Find(memory.Filter{}), user, now, days)
+	name := "饮食安排"
+	if user != "" {
+		name += " - " + user
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	calendar.Write(w, name, events, now)
+}
+
+// sessionID 浏览器的会话 ID，没有时生成新的，每次请求都延长 cookie 的有效期
+func sessionID(w http.ResponseWriter, r *http.Request) string {
+	var id string
+	if c, err := r.Cookie(sessionCookie); err == nil && c.Value != "" {
+		id = c.Value
+	} else {
+		id = newSessionID()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(cookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "web:" + id
+}
+
+// newSessionID 随机的会话 ID
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// cards 推荐卡片：推荐中排在前面的几家
+func cards(restaurants []tools.Restaurant) []Card {
+	if len(restaurants) > maxCards {
+		restaurants = restaurants[:maxCards]
+	}
+	list := make([]Card, 0, len(restaurants))
+	for i, r := range restaurants {
+		c := Card{
+			Index:    i,
+			Name:     r.Name,
+			Cuisine:  r.Cuisine,
+			Distance: r.GetDistanceInt(),
+			Walk:     r.WalkMinutes,
+			Rating:   r.GetRatingFloat(),
+			Cost:     r.GetCostFloat(),
+			Address:  r.Address,
+			Tel:      r.Tel,
+		}
+		if u := r.MapURL(); u != "" {
+			c.MapURL = u + "&callnative=1"
+		}
+		list = append(list, c)
+	}
+	return list
+}
+
+// sameNames 两次推荐的餐厅是否相同
+func sameNames(a, b []tools.Restaurant) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// decode 解析 POST 的 JSON 请求
+func decode(w http.ResponseWriter, r *http.Request) (request, bool) {
+	var req request
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "请使用 POST")
+		return req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "无效的请求")
+		return req, false
+	}
+	return req, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}